@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/base64"
 	"net/url"
+	"sort"
+	"strings"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"io"
@@ -64,15 +66,47 @@ func (f FileURL) GetParentDir() (DirectoryURL, error) {
 // Create creates a new file or replaces a file. Note that this method only initializes the file.
 // For more information, see https://docs.microsoft.com/en-us/rest/api/storageservices/create-file.
 func (f FileURL) Create(ctx context.Context, headers BlobFSHTTPHeaders) (*PathCreateResponse, error) {
+	return f.CreateWithOptions(ctx, headers, nil, "")
+}
+
+// CreateWithOptions is like Create, but also sets the file's user-defined properties, and its POSIX permissions.
+// The permissions are in symbolic (e.g. rwxr-x---) or 4-digit octal (e.g. 0750) notation, and are applied exactly as given, without any umask.
+// Empty permissions leave them to the service's defaults.
+func (f FileURL) CreateWithOptions(ctx context.Context, headers BlobFSHTTPHeaders, properties map[string]string, permissions string) (*PathCreateResponse, error) {
+	var xMsProperties, xMsPermissions, xMsUmask *string
+	if len(properties) > 0 {
+		encoded := encodePathProperties(properties)
+		xMsProperties = &encoded
+	}
+	if permissions != "" {
+		noUmask := "0000"
+		xMsPermissions, xMsUmask = &permissions, &noUmask
+	}
 	return f.fileClient.Create(ctx, f.fileSystemName, f.path, PathResourceFile,
 		nil, PathRenameModeNone, nil, nil, nil, nil,
 		&headers.CacheControl, &headers.ContentType, &headers.ContentEncoding, &headers.ContentLanguage, &headers.ContentDisposition,
-		nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, xMsProperties, xMsPermissions, xMsUmask,
 		nil, nil, nil, nil, nil,
 		nil, nil, nil, nil, nil,
 		nil)
 }
 
+// encodePathProperties formats properties for the x-ms-properties header, as a comma-separated list of name=value pairs
+// in which each value is base64 encoded. The names are sorted, so that the header is the same each time.
+func encodePathProperties(properties map[string]string) string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + base64.StdEncoding.EncodeToString([]byte(properties[name]))
+	}
+	return strings.Join(pairs, ",")
+}
+
 // Download downloads count bytes of data from the start offset. If count is CountToEnd (0), then data is read from specified offset to the end.
 // The response includes all of the file’s properties. However, passing true for rangeGetContentMD5 returns the range’s MD5 in the ContentMD5
 // response header/property if the range is <= 4MB; the HTTP request fails with 400 (Bad Request) if the requested range is greater than 4MB.
@@ -0,0 +1,65 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// accountTierHeadroom is the fraction of an account's scalability targets that --account-tier lets AzCopy use.
+// The rest is left for other workloads that share the account.
+const accountTierHeadroom = 0.8
+
+// accountTierTargets are the published scalability targets of a storage account.
+type accountTierTargets struct {
+	requestsPerSecond int64
+	ingressMbps       float64
+}
+
+// accountTiers holds the targets for each value of --account-tier.
+// Standard is a general-purpose v2 account, using the ingress target for regions outside the US and Europe,
+// since that is the lower of the two. Premium uses the maximums for a premium file share.
+var accountTiers = map[string]accountTierTargets{
+	"standard": {requestsPerSecond: 20000, ingressMbps: 25 * 1000},
+	"premium":  {requestsPerSecond: 100000, ingressMbps: 10 * 1024 * 1024 * 1024 * 8 / (1000 * 1000)},
+}
+
+// resolveAccountTierCaps works out the request and bandwidth caps to use. An explicit cap always wins. Otherwise,
+// if a tier is given, the cap is the tier's target less the headroom. Zero means not capped.
+func resolveAccountTierCaps(tier string, explicitRequestsPerSecond int64, explicitMbps float64) (requestsPerSecond int64, mbps float64, err error) {
+	requestsPerSecond, mbps = explicitRequestsPerSecond, explicitMbps
+	if tier == "" {
+		return
+	}
+
+	targets, ok := accountTiers[strings.ToLower(tier)]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid account tier %q. Choose 'standard' or 'premium'", tier)
+	}
+	if requestsPerSecond == 0 {
+		requestsPerSecond = int64(float64(targets.requestsPerSecond) * accountTierHeadroom)
+	}
+	if mbps == 0 {
+		mbps = targets.ingressMbps * accountTierHeadroom
+	}
+	return
+}
@@ -0,0 +1,130 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// blobVersionSelector gathers the versions of each blob, which a listing returns one after another, and hands on the newest of them
+// once it has seen them all. A nil selector hands on each item as it is, for listings that don't include versions.
+type blobVersionSelector struct {
+	maxVersions int // zero means all versions are kept
+	name        string
+	pending     []azblob.BlobItemInternal
+}
+
+func newBlobVersionSelector(maxVersions int) *blobVersionSelector {
+	return &blobVersionSelector{maxVersions: maxVersions}
+}
+
+// add takes the next item of the listing, and returns those of the previous blob that were chosen, once the listing has moved past it
+func (s *blobVersionSelector) add(item azblob.BlobItemInternal) []azblob.BlobItemInternal {
+	if s == nil {
+		return []azblob.BlobItemInternal{item}
+	}
+
+	var chosen []azblob.BlobItemInternal
+	if item.Name != s.name {
+		chosen = s.flush()
+		s.name = item.Name
+	}
+	s.pending = append(s.pending, item)
+	return chosen
+}
+
+// flush returns the chosen versions of the blob that was listed last, since no more of its versions can follow
+func (s *blobVersionSelector) flush() []azblob.BlobItemInternal {
+	if s == nil || len(s.pending) == 0 {
+		return nil
+	}
+	chosen := newestBlobVersions(s.pending, s.maxVersions)
+	s.pending = nil
+	return chosen
+}
+
+// newestBlobVersions returns the newest maxVersions of the versions of one blob, newest first, counting the current version.
+// Version IDs are timestamps, so their order is that of the versions.
+func newestBlobVersions(versions []azblob.BlobItemInternal, maxVersions int) []azblob.BlobItemInternal {
+	sorted := append([]azblob.BlobItemInternal(nil), versions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if isCurrentBlobVersion(sorted[i]) != isCurrentBlobVersion(sorted[j]) {
+			return isCurrentBlobVersion(sorted[i])
+		}
+		return blobVersionID(sorted[i]) > blobVersionID(sorted[j])
+	})
+
+	if maxVersions > 0 && len(sorted) > maxVersions {
+		sorted = sorted[:maxVersions]
+	}
+	return sorted
+}
+
+// isCurrentBlobVersion says whether the item is the blob itself, rather than one of its previous versions.
+// Blobs written while versioning was off have no version ID, and are current.
+func isCurrentBlobVersion(item azblob.BlobItemInternal) bool {
+	if item.VersionID == nil {
+		return true
+	}
+	return item.IsCurrentVersion != nil && *item.IsCurrentVersion
+}
+
+func blobVersionID(item azblob.BlobItemInternal) string {
+	if item.VersionID == nil {
+		return ""
+	}
+	return *item.VersionID
+}
+
+// versionedObjectName names a previous version of a blob at the destination, so that it doesn't overwrite the blob itself
+func versionedObjectName(versionID string, name string) string {
+	return strings.ReplaceAll(versionID, ":", "-") + "-" + name
+}
+
+func validateIncludeVersions(includeVersions bool, maxVersionsPerBlob int, fromTo common.FromTo, fromList bool, shardByPrefix int, destTemplate string) error {
+	if maxVersionsPerBlob < 0 {
+		return errors.New("max-versions-per-blob cannot be negative")
+	}
+	if !includeVersions {
+		if maxVersionsPerBlob > 0 {
+			return errors.New("max-versions-per-blob requires include-versions")
+		}
+		return nil
+	}
+	if fromTo.From() != common.ELocation.Blob() {
+		return errors.New("include-versions is only supported when copying from Blob storage")
+	}
+	if fromList {
+		return errors.New("include-versions cannot be used with list-of-files, include-path or list-of-versions")
+	}
+	if shardByPrefix > 0 {
+		return errors.New("include-versions cannot be combined with shard-by-prefix")
+	}
+	if destTemplate != "" {
+		return errors.New("include-versions cannot be combined with dest-template, since the versions of a blob would be given the same name")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// casLayout places downloaded files in a content-addressed store, in which each file is named for its content hash,
+// and kept under a directory named for the first two characters of that hash. Files with identical content are only stored once.
+// The hash is the Content-MD5 of the source, which is the same hash against which the job validates the downloaded content.
+// The manifest records, for each source file, where it would have been downloaded to without the store, and its hash.
+type casLayout struct {
+	manifestPath string
+	manifest     map[string]string // original relative path -> content hash
+	stored       map[string]bool   // content hashes already scheduled for download in this job
+	missingHash  uint64            // count of files skipped because the source has no Content-MD5
+}
+
+func newCasLayout(manifestPath string) *casLayout {
+	return &casLayout{
+		manifestPath: manifestPath,
+		manifest:     make(map[string]string),
+		stored:       make(map[string]bool),
+	}
+}
+
+// place records the file in the manifest, and returns its destination in the store.
+// shouldDownload is false if the file can't be placed (because it has no hash),
+// or if a file with the same content has already been scheduled.
+func (l *casLayout) place(originalRelativePath string, contentMD5 []byte) (dstRelativePath string, shouldDownload bool) {
+	if len(contentMD5) == 0 {
+		l.missingHash++
+		return "", false
+	}
+
+	hash := hex.EncodeToString(contentMD5)
+	l.manifest[strings.TrimPrefix(originalRelativePath, common.AZCOPY_PATH_SEPARATOR_STRING)] = hash
+	if l.stored[hash] {
+		return "", false
+	}
+	l.stored[hash] = true
+
+	return common.AZCOPY_PATH_SEPARATOR_STRING + hash[:2] + common.AZCOPY_PATH_SEPARATOR_STRING + hash, true
+}
+
+// warnMissingHashes warns about any files that could not be placed in the store, once they have all been enumerated
+func (l *casLayout) warnMissingHashes() {
+	if l.missingHash > 0 {
+		WarnStdoutAndJobLog(fmt.Sprintf("%d file(s) were not downloaded, because the content-addressed layout requires the source to have a Content-MD5", l.missingHash))
+	}
+}
+
+// writeManifest writes the manifest of the files whose content is in the store. Files whose content failed to download,
+// or was never downloaded, are left out, so that every name in the manifest can be found by its hash
+func (l *casLayout) writeManifest(inStore map[string]bool) error {
+	manifest := make(map[string]string, len(l.manifest))
+	for name, hash := range l.manifest {
+		if inStore[hash] {
+			manifest[name] = hash
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(l.manifestPath, data, common.DEFAULT_FILE_PERM)
+}
+
+// listHashesInStore returns the hashes of the content that the job put in the store. A file that was skipped because it
+// already existed is in the store too, since the name of each file there is the hash of its content
+func listHashesInStore(jm ste.IJobMgr) map[string]bool {
+	hashes := make(map[string]bool)
+	for partNum := ste.PartNumber(0); true; partNum++ {
+		jpm, found := jm.JobPartMgr(partNum)
+		if !found {
+			break
+		}
+		plan := jpm.Plan()
+		for t := uint32(0); t < plan.NumTransfers; t++ {
+			status := plan.Transfer(t).TransferStatus()
+			if status != common.ETransferStatus.Success() && status != common.ETransferStatus.SkippedEntityAlreadyExists() {
+				continue
+			}
+			_, dst, _ := plan.TransferSrcDstStrings(t)
+			hashes[filepath.Base(dst)] = true
+		}
+	}
+	return hashes
+}
+
+// writeCasManifest writes the manifest of the content-addressed store, once the job is done
+func (cca *cookedCopyCmdArgs) writeCasManifest() error {
+	jm, exists := ste.JobsAdmin.JobMgr(cca.jobID)
+	if !exists {
+		return fmt.Errorf("job %s is not loaded", cca.jobID)
+	}
+	return cca.cas.writeManifest(listHashesInStore(jm))
+}
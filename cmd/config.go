@@ -0,0 +1,283 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// configSetting is one of the settings shown by config show, with where its value came from
+type configSetting struct {
+	Name   string
+	Value  string
+	Source string // e.g. "default", "flag --cap-mbps" or "environment variable AZCOPY_CONCURRENCY_VALUE"
+}
+
+const defaultSettingSource = "default"
+
+// config command is used to encapsulate the sub-commands that inspect AzCopy's configuration
+// config command itself is not runnable
+var configCmd = &cobra.Command{
+	Use:     "config",
+	Short:   configCmdShortDescription,
+	Long:    configCmdLongDescription,
+	Example: configCmdExample,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:     "show [command [arguments] [flags]]",
+	Short:   showConfigCmdShortDescription,
+	Long:    showConfigCmdLongDescription,
+	Example: configCmdExample,
+	// the flags belong to the command being shown, so they are parsed against it, rather than against this one
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		invoked, positional, err := parseShownInvocation(cmd, args)
+		if err == pflag.ErrHelp {
+			_ = cmd.Help()
+			glcm.Exit(nil, common.EExitCode.Success())
+		}
+		if err != nil {
+			glcm.Error(err.Error())
+		}
+
+		// the root flags were only parsed just now, along with those of the command being shown, so these must be parsed again
+		if err = azcopyOutputFormat.Parse(outputFormatRaw); err != nil {
+			glcm.Error(err.Error())
+		}
+		glcm.SetOutputFormat(azcopyOutputFormat)
+		glcm.SetQuiet(azcopyQuiet)
+		glcm.SetSummaryFile(azcopySummaryFile)
+		if azcopyEndpoints, err = parseEndpointFlags(); err != nil {
+			glcm.Error(err.Error())
+		}
+
+		settings := resolveConfiguration(invoked, positional)
+		glcm.Exit(func(format common.OutputFormat) string {
+			if format == common.EOutputFormat.Json() {
+				jsonOutput, err := json.Marshal(settings)
+				common.PanicIfErr(err)
+				return string(jsonOutput)
+			}
+
+			var sb strings.Builder
+			for _, s := range settings {
+				sb.WriteString(fmt.Sprintf("%s: %s\n  Source: %s\n", s.Name, s.Value, s.Source))
+			}
+			return sb.String()
+		}, common.EExitCode.Success())
+	},
+}
+
+// parseShownInvocation finds the command that config show was asked about, and parses its flags (which include the root flags),
+// without running it. With no command, only the root flags are parsed.
+func parseShownInvocation(showCmd *cobra.Command, args []string) (invoked *cobra.Command, positional []string, err error) {
+	invoked, rest := rootCmd, args
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		for _, a := range args {
+			if a == "-h" || a == "--help" {
+				return nil, nil, pflag.ErrHelp // asking about config show itself, whose own flags aren't parsed
+			}
+		}
+	} else {
+		if invoked, rest, err = rootCmd.Find(args); err != nil {
+			return nil, nil, err
+		}
+		if invoked == rootCmd || invoked == configCmd || invoked == showCmd {
+			return nil, nil, fmt.Errorf("%q is not a command whose configuration can be shown", args[0])
+		}
+	}
+
+	if err = invoked.ParseFlags(rest); err != nil {
+		return nil, nil, err
+	}
+	return invoked, invoked.Flags().Args(), nil
+}
+
+// resolveConfiguration works out the settings that the command would run with, given its flags (already parsed) and its arguments.
+// Settings whose flags the command doesn't have are left out, since they don't apply to it.
+func resolveConfiguration(invoked *cobra.Command, positional []string) []configSetting {
+	settings := make([]configSetting, 0)
+
+	autoConcurrency := invoked.Flags().Lookup("auto-concurrency")
+	autoTuneRequested := autoConcurrency != nil && autoConcurrency.Value.String() == "true"
+	concurrency := ste.NewConcurrencySettings(azcopyMaxFileAndSocketHandles, invoked == benchCmd || autoTuneRequested)
+	s := configSetting{Name: "Concurrency", Value: fmt.Sprintf("%d", concurrency.MaxMainPoolSize.Value)}
+	if concurrency.MaxMainPoolSize.IsUserSpecified {
+		s.Source = "environment variable " + concurrency.MaxMainPoolSize.EnvVarName
+	} else {
+		s.Source = defaultSettingSource + ", " + concurrency.MaxMainPoolSize.DefaultSourceDesc
+		if concurrency.AutoTuneMainPool() {
+			s.Value = fmt.Sprintf("auto-tuned, from %d up to %d", concurrency.InitialMainPoolSize, concurrency.MaxMainPoolSize.Value)
+			if autoTuneRequested && invoked != benchCmd {
+				s.Source = "flag --auto-concurrency"
+			}
+		}
+	}
+	settings = append(settings, s)
+
+	if blockSize := invoked.Flags().Lookup("block-size-mb"); blockSize != nil {
+		s = configSetting{Name: "Block size", Source: defaultSettingSource,
+			Value: "8 MiB for block blobs, doubled until the file fits in 50,000 blocks; 4 MiB for page blobs and Azure Files"}
+		if maxBlocks := invoked.Flags().Lookup("max-blocks"); blockSize.Changed {
+			s.Value, s.Source = blockSize.Value.String()+" MiB", "flag --block-size-mb"
+		} else if maxBlocks != nil && maxBlocks.Changed {
+			s.Value, s.Source = "the smallest whole number of MiB that fits each file in "+maxBlocks.Value.String()+" blocks", "flag --max-blocks"
+		}
+		settings = append(settings, s)
+
+		if s2sBlockSize := invoked.Flags().Lookup("s2s-block-size-mb"); s2sBlockSize != nil {
+			s = configSetting{Name: "Server-side copy block size", Value: "the same as the block size", Source: defaultSettingSource}
+			if s2sBlockSize.Changed {
+				s.Value, s.Source = s2sBlockSize.Value.String()+" MiB", "flag --s2s-block-size-mb"
+			}
+			settings = append(settings, s)
+		}
+	}
+
+	accountTier := invoked.Flags().Lookup("account-tier")
+	if capMbps := invoked.Flags().Lookup("cap-mbps"); capMbps != nil {
+		s = configSetting{Name: "Bandwidth cap", Value: "none", Source: defaultSettingSource}
+		if capMbps.Changed {
+			s.Value, s.Source = capMbps.Value.String()+" Mbps", "flag --cap-mbps"
+		} else if accountTier != nil && accountTier.Changed {
+			if _, mbps, err := resolveAccountTierCaps(accountTier.Value.String(), 0, 0); err == nil {
+				s.Value, s.Source = fmt.Sprintf("%.0f Mbps", mbps), "flag --account-tier"
+			}
+		}
+		settings = append(settings, s)
+	}
+
+	if capRequests := invoked.Flags().Lookup("cap-requests-per-second"); capRequests != nil {
+		s = configSetting{Name: "Request rate cap", Value: "none", Source: defaultSettingSource}
+		if capRequests.Changed {
+			s.Value, s.Source = capRequests.Value.String()+" per second", "flag --cap-requests-per-second"
+		} else if accountTier != nil && accountTier.Changed {
+			if requestsPerSecond, _, err := resolveAccountTierCaps(accountTier.Value.String(), 0, 0); err == nil {
+				s.Value, s.Source = fmt.Sprintf("%d per second", requestsPerSecond), "flag --account-tier"
+			}
+		}
+		settings = append(settings, s)
+	}
+
+	if logLevel := invoked.Flags().Lookup("log-level"); logLevel != nil {
+		s = configSetting{Name: "Log level", Value: logLevel.Value.String(), Source: defaultSettingSource}
+		if logLevel.Changed {
+			s.Source = "flag --log-level"
+		}
+		settings = append(settings, s)
+	}
+
+	settings = append(settings,
+		folderSetting("Log location", common.EEnvironmentVariable.LogLocation(), azcopyLogPathFolder),
+		folderSetting("Job plan location", common.EEnvironmentVariable.JobPlanLocation(), azcopyJobPlanFolder))
+
+	names := []string{"Resource"}
+	if len(positional) == 2 {
+		names = []string{"Source", "Destination"}
+	}
+	for i, resource := range positional {
+		if i >= len(names) {
+			break
+		}
+		settings = append(settings, resourceSettings(names[i], resource)...)
+	}
+	return settings
+}
+
+// folderSetting describes a folder that AzCopy uses, which can be moved with an environment variable
+func folderSetting(name string, envVar common.EnvironmentVariable, folder string) configSetting {
+	if glcm.GetEnvironmentVariable(envVar) != "" {
+		return configSetting{Name: name, Value: folder, Source: "environment variable " + envVar.Name}
+	}
+	return configSetting{Name: name, Value: folder, Source: defaultSettingSource}
+}
+
+// resourceSettings describes the endpoint that a resource resolves to, and how AzCopy would authenticate to it.
+// Nothing is sent to the resource, so an authentication method that depends on the response (e.g. whether a container is public) is shown as a possibility.
+func resourceSettings(name string, resource string) []configSetting {
+	resolved := azcopyEndpoints.apply(resource)
+	location := inferArgumentLocation(resolved)
+
+	endpoint := configSetting{Name: name + " endpoint", Value: "local", Source: "argument"}
+	if u, err := url.Parse(resolved); err == nil && u.Host != "" {
+		endpoint.Value = u.Scheme + "://" + u.Host + " (" + location.String() + ")"
+		if resolved != resource {
+			endpoint.Source = "flag --endpoint-suffix"
+			if loc, ok := azcopyEndpoints.locationOf(u.Host); ok {
+				endpoint.Source = "flag --" + map[common.Location]string{common.ELocation.Blob(): "blob", common.ELocation.File(): "file", common.ELocation.BlobFS(): "dfs"}[loc] + "-endpoint"
+			}
+		}
+	} else if resource == pipeLocation {
+		endpoint.Value = "pipe"
+	}
+
+	auth := configSetting{Name: name + " authentication"}
+	auth.Value, auth.Source = describeAuthentication(location, resolved)
+	return []configSetting{endpoint, auth}
+}
+
+// describeAuthentication says how AzCopy would authenticate to the resource, in the same order of precedence as getCredentialTypeForLocation,
+// but without sending anything to it
+func describeAuthentication(location common.Location, resource string) (value string, source string) {
+	switch location {
+	case common.ELocation.Blob(), common.ELocation.File(), common.ELocation.BlobFS():
+		if u, err := url.Parse(resource); err == nil && u.Query().Get("sig") != "" {
+			return "SAS token", "argument"
+		}
+		if credType := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.CredentialType()); credType != "" {
+			return credType, "environment variable " + common.EEnvironmentVariable.CredentialType().Name
+		}
+		if location == common.ELocation.File() {
+			return "none, since Azure Files needs a SAS token in the URL", defaultSettingSource
+		}
+		if loginType := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AutoLoginType()); loginType != "" {
+			return "OAuth, logging in automatically with " + loginType, "environment variable " + common.EEnvironmentVariable.AutoLoginType().Name
+		}
+		if oAuthTokenExists() {
+			return "OAuth", "azcopy login"
+		}
+		return "anonymous, which only works for public containers", defaultSettingSource
+	case common.ELocation.S3():
+		if glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AWSAccessKeyID()) != "" {
+			return "S3 access key", "environment variable " + common.EEnvironmentVariable.AWSAccessKeyID().Name
+		}
+		return "none, since AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set", defaultSettingSource
+	case common.ELocation.Http():
+		return "anonymous, since credentials are never sent to other web servers; any token must be in the URL", defaultSettingSource
+	default:
+		return "none needed", defaultSettingSource
+	}
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -30,7 +30,9 @@ import (
 	"math"
 	"net/url"
 	"os"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -70,21 +72,65 @@ type rawCopyCmdArgs struct {
 	// implemented for remove (and sync) only
 	include               string
 	exclude               string
+	includeFrom           string // a file of patterns to include, one to a line, as well as those of include
+	excludeFrom           string // a file of patterns to exclude, one to a line, as well as those of exclude
 	includePath           string // NOTE: This gets handled like list-of-files! It may LOOK like a bug, but it is not.
 	excludePath           string
 	includeFileAttributes string
 	excludeFileAttributes string
+	priorityPattern       string
 	includeBefore         string
 	includeAfter          string
+	changedSinceJob       string
+	allowSameLocation     bool
+	startJitter           time.Duration
 	legacyInclude         string // used only for warnings
 	legacyExclude         string // used only for warnings
 	listOfVersionIDs      string
 
 	// filters from flags
 	listOfFilesToCopy string
+	listStartLine     int
+	failedList        string
+	sha256Manifest    string
 	recursive         bool
 	followSymlinks    bool
 	autoDecompress    bool
+	skipLocked        bool
+	retryLocked       bool
+	progressBasis     string
+	normalizeUnicode  string
+	maxTransfers      int
+	lookahead         int
+	deadline          string
+	// the most transfers in flight under each destination prefix, e.g. logs/=10,data/=100
+	prefixConcurrency string
+	shardByPrefix     int
+	precreateDirs     bool
+	// whether directories are marked with empty blobs on upload, and recreated from such markers on download
+	createDirMarkers bool
+	// where the JSON output records are also streamed, for a GUI to follow, if anywhere
+	progressSocket string
+	// where the command is saved as a job definition, instead of being run, if anywhere
+	exportJobDefinition string
+	// the command that a single blob is downloaded into, instead of to disk, if any
+	pipeTo string
+	// whether parts of the source listing that keep failing are skipped, instead of failing the enumeration
+	continueOnEnumerationError bool
+	// whether previous versions of blobs are copied too, and the most of each blob's versions to copy
+	includeVersions    bool
+	maxVersionsPerBlob int
+	// record, or restore, the order of the files in their directories' listings
+	preserveListingOrder bool
+	// record hard links among the files uploaded, instead of uploading them again, or recreate them when downloading
+	preserveHardLinks bool
+	// check, before a download starts, that the destination has space for it, with this much more to spare
+	checkDiskSpace bool
+	reserveSpaceMB int64
+	// the throughput that the job must keep up, over the window, and what to do if it doesn't
+	minThroughputMbps   float64
+	minThroughputWindow time.Duration
+	minThroughputAction string
 	// forceWrite flag is used to define the User behavior
 	// to overwrite the existing blobs or not.
 	forceWrite      string
@@ -92,34 +138,91 @@ type rawCopyCmdArgs struct {
 
 	// options from flags
 	blockSizeMB              float64
+	s2sBlockSizeMB           float64
+	maxBlocks                int
 	metadata                 string
 	contentType              string
 	contentEncoding          string
 	contentDisposition       string
 	contentLanguage          string
 	cacheControl             string
+	metadataRules            string
+	metadataFrom             string
 	noGuessMimeType          bool
 	preserveLastModifiedTime bool
 	putMd5                   bool
+	deltaUpdate              bool
+	clientSideEncryptionKey  string
+	clientSideKeyID          string
 	md5ValidationOption      string
+	createDestination        bool
+	destinationPublicAccess  string
+	preflight                bool
+	casOutput                bool
+	casManifest              string
+	destTemplate             string
+	partitionBy              string
+	checkNames               bool
+	sanitizeNames            bool
 	CheckLength              bool
 	deleteSnapshotsOption    string
+	batchDelete              bool
+	requireSoftDelete        bool
 
 	blobTags string
+	// how to set the expiry of each blob written, and whether to copy it from the source blob instead
+	expiry            string
+	s2sPreserveExpiry bool
+	// whether to check that each blob written is encrypted, and the encryption scope that it must be encrypted with
+	verifyEncryption      bool
+	verifyEncryptionScope string
+	// whether to give each blob copied the immutability policy and legal hold of its source
+	preserveImmutability bool
+	// the sequence number that page blobs are created with. Zero is the service's default
+	pageBlobSequenceNumber int64
+	// the start of the ID of each block staged, for tools that read the block lists of blobs
+	blockIDPrefix string
+	// rules that choose each block blob's tier by when its source was last accessed, e.g. 90d=Archive,30d=Cool
+	tierByAccessTime string
 	// defines the type of the blob at the destination in case of upload / account to account copy
 	blobType      string
 	blockBlobTier string
 	pageBlobTier  string
 	output        string // TODO: Is this unused now? replaced with param at root level?
 	logVerbosity  string
+	logFormat     string
 	// list of blobTypes to exclude while enumerating the transfer
 	excludeBlobType string
+	// lists of access tiers, of which blobs must (or must not) be in one to be transferred
+	includeTier string
+	excludeTier string
+	// leave out files that have no content
+	skipEmpty bool
+	// content types (e.g. image/*) that files must have one of to be transferred
+	includeContentType string
 	// Opt-in flag to persist SMB ACLs to Azure Files.
 	preserveSMBPermissions bool
 	preserveOwner          bool // works in conjunction with preserveSmbPermissions
 	// Opt-in flag to persist additional SMB properties to Azure Files. Named ...info instead of ...properties
 	// because the latter was similar enough to preserveSMBPermissions to induce user error
 	preserveSMBInfo bool
+	// Opt-in flag to keep Windows file attributes in blob metadata on upload, and restore them on download
+	preserveFileAttributes bool
+	// Opt-in flag to keep the mode, owner, group and modification time of files on Linux and macOS
+	preservePOSIX bool
+	// Opt-in flag to set the modification time of downloaded directories from their source
+	preserveDirectoryTimestamps bool
+	// Opt-in flag to set the read-only attribute of Azure Files files once they have been written
+	setReadOnly bool
+	// Opt-in flag to make no SMB property or permission calls to Azure Files, for plain data copies
+	skipSMBInfo bool
+	// Permission bits, in octal, to set on downloaded files and on the directories created for them
+	fileMode string
+	dirMode  string
+	// Opt-in flag to delete the blobs that an upload created, if any of its transfers fail
+	transactional bool
+	// the number of transfers of a transactional upload that may fail without it being rolled back
+	transactionalFailureThreshold uint32
 	// Flag to enable Window's special privileges
 	backupMode bool
 	// whether user wants to preserve full properties during service to service copy, the default value is true.
@@ -138,6 +241,10 @@ type rawCopyCmdArgs struct {
 	s2sSourceChangeValidation bool
 	// specify how user wants to handle invalid metadata.
 	s2sInvalidMetadataHandleOption string
+	// whether a copy between remote locations is done by the service: prefer, never or require
+	s2sPreference string
+	// whether archived source blobs are rehydrated, and each copied once it's online, all in the one job
+	rehydrateAndCopy bool
 
 	// internal override to enforce strip-top-dir
 	internalOverrideStripTopDir bool
@@ -160,6 +267,138 @@ func (raw *rawCopyCmdArgs) parsePatterns(pattern string) (cookedPatterns []strin
 	return
 }
 
+// parseAccessTiers parses a list of blob access tiers, separated by ';' or ',', into the names the service uses for them
+func parseAccessTiers(rawTiers string, flagName string) ([]azblob.AccessTierType, error) {
+	tiers := make([]azblob.AccessTierType, 0)
+	for _, rawTier := range strings.FieldsFunc(rawTiers, func(r rune) bool { return r == ';' || r == ',' }) {
+		rawTier = strings.TrimSpace(rawTier)
+		found := false
+		for _, tier := range azblob.PossibleAccessTierTypeValues() {
+			if tier != azblob.AccessTierNone && strings.EqualFold(rawTier, string(tier)) {
+				tiers = append(tiers, tier)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("error parsing the access tier %s provided with %s flag", rawTier, flagName)
+		}
+	}
+
+	return tiers, nil
+}
+
+// parseExpiry parses the value of the expiry flag: RelativeToNow:<duration>, Absolute:<time> (or just the time), or NeverExpire.
+// Durations are given in days (e.g. 30d), or in any form that Go understands (e.g. 36h).
+// The expiry time is returned in milliseconds: the time to expiry for RelativeToNow, or the Unix time of expiry for Absolute.
+func parseExpiry(rawExpiry string, now time.Time) (option common.BlobExpiryOption, expiryTime int64, err error) {
+	if rawExpiry == "" {
+		return common.EBlobExpiryOption.None(), 0, nil
+	}
+
+	// an absolute time has colons of its own, so it's only split if what comes before the first colon names an option
+	name, value := rawExpiry, ""
+	if i := strings.Index(rawExpiry, ":"); i >= 0 {
+		name, value = rawExpiry[:i], rawExpiry[i+1:]
+	}
+	if option.Parse(name) != nil {
+		option, value = common.EBlobExpiryOption.Absolute(), rawExpiry // a bare time
+	}
+
+	switch option {
+	case common.EBlobExpiryOption.RelativeToNow():
+		var d time.Duration
+		if days := strings.TrimSuffix(value, "d"); days != value {
+			var n int64
+			if n, err = strconv.ParseInt(days, 10, 64); err == nil {
+				d = time.Duration(n) * 24 * time.Hour
+			}
+		} else {
+			d, err = time.ParseDuration(value)
+		}
+		if err != nil || d < time.Millisecond {
+			return option, 0, fmt.Errorf("the expiry %s is not a positive duration, such as 30d or 12h", value)
+		}
+		return option, d.Milliseconds(), nil
+	case common.EBlobExpiryOption.Absolute():
+		t, err := parseISO8601(value, false)
+		if err != nil {
+			return option, 0, fmt.Errorf("the expiry %s is not a valid time: %s", value, err)
+		}
+		if !t.After(now) {
+			return option, 0, fmt.Errorf("the expiry %s is in the past", value)
+		}
+		return option, t.UnixNano() / int64(time.Millisecond), nil
+	case common.EBlobExpiryOption.NeverExpire():
+		if value != "" {
+			return option, 0, errors.New("NeverExpire doesn't take a time")
+		}
+		return option, 0, nil
+	default:
+		return option, 0, fmt.Errorf("the expiry %s must be RelativeToNow:<duration>, Absolute:<time>, or NeverExpire", rawExpiry)
+	}
+}
+
+// validateExpiry checks that expiry is only set on blobs, and only preserved from blobs
+func validateExpiry(option common.BlobExpiryOption, preserve bool, fromTo common.FromTo) error {
+	if option != common.EBlobExpiryOption.None() && fromTo.To() != common.ELocation.Blob() {
+		return errors.New("expiry can only be set when transferring to Blob storage")
+	}
+	if preserve && fromTo != common.EFromTo.BlobBlob() {
+		return errors.New("s2s-preserve-expiry is only supported when copying from Blob storage to Blob storage")
+	}
+	if preserve && option != common.EBlobExpiryOption.None() {
+		return errors.New("cannot combine expiry and s2s-preserve-expiry")
+	}
+	return nil
+}
+
+// validatePageBlobSequenceNumber checks the value of page-blob-sequence-number, which is zero, the service's default, when the flag was not given
+func validatePageBlobSequenceNumber(sequenceNumber int64, fromTo common.FromTo) error {
+	if sequenceNumber < 0 {
+		return errors.New("page-blob-sequence-number cannot be negative")
+	}
+	if sequenceNumber > 0 && fromTo.To() != common.ELocation.Blob() {
+		return errors.New("page-blob-sequence-number is only supported when transferring to Blob storage")
+	}
+	return nil
+}
+
+// validateBlockIDPrefix checks that a block ID prefix is only given for uploads and copies to Blob storage, and that it's short
+// enough for the block IDs to fit in the 64 bytes that the service allows. It may only contain letters, digits, '-', '_' and '.',
+// so that the block IDs sort the same way in any tool that reads them.
+func validateBlockIDPrefix(prefix string, fromTo common.FromTo) error {
+	if prefix == "" {
+		return nil
+	}
+	if fromTo.To() != common.ELocation.Blob() {
+		return errors.New("block-id-prefix is only supported when transferring to Blob storage")
+	}
+	if len(prefix) > ste.BlockIDPrefixMaxBytes {
+		return fmt.Errorf("block-id-prefix %s is longer than the %d characters allowed", prefix, ste.BlockIDPrefixMaxBytes)
+	}
+	for _, r := range prefix {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("block-id-prefix %s contains %q, but only letters, digits, '-', '_' and '.' are allowed", prefix, r)
+		}
+	}
+	return nil
+}
+
+// validateVerifyEncryption checks that encryption is only verified on blobs. An expected encryption scope implies verification.
+func validateVerifyEncryption(verify bool, scope string, fromTo common.FromTo) error {
+	if !verify && scope == "" {
+		return nil
+	}
+	if fromTo.To() != common.ELocation.Blob() {
+		return errors.New("verify-encryption is only supported when transferring to Blob storage")
+	}
+	if len(scope) > ste.EncryptionScopeMaxBytes-1 {
+		return fmt.Errorf("verify-encryption-scope %s is longer than the %d characters that an encryption scope name can have", scope, ste.EncryptionScopeMaxBytes-1)
+	}
+	return nil
+}
+
 // blocSizeInBytes converts a FLOATING POINT number of MiB, to a number of bytes
 // A non-nil error is returned if the conversion is not possible to do accurately (e.g. it comes out of a fractional number of bytes)
 // The purpose of using floating point is to allow specialist users (e.g. those who want small block sizes to tune their read IOPS)
@@ -183,6 +422,51 @@ func blockSizeInBytes(rawBlockSizeInMiB float64) (int64, error) {
 	return int64(math.Round(rawSizeInBytes)), nil
 }
 
+// validateBlockSizes checks the block sizes against the service's limits, and returns the one that the job uses: s2s-block-size-mb,
+// when it's given for a server-side copy, or else block-size-mb. The two are separate because what suits reading from a local disk
+// doesn't suit Put Block From URL, where the service reads the source itself, and bigger blocks mean fewer requests.
+// s2s-block-size-mb is ignored by jobs that aren't server-side copies, including those between remote locations with s2s=never.
+func validateBlockSizes(blockSize int64, s2sBlockSize int64, blobType common.BlobType, fromTo common.FromTo, s2sPreference common.S2SPreference) (int64, error) {
+	serverSide := fromTo.IsS2S() && s2sPreference != common.ES2SPreference.Never()
+	if fromTo.To() == common.ELocation.Blob() && blockSize > common.MaxBlockBlobBlockSize {
+		return 0, fmt.Errorf("block-size-mb cannot be more than %d MiB, the largest block that the service accepts", common.MaxBlockBlobBlockSize/(1024*1024))
+	}
+	if serverSide && s2sBlockSize > common.MaxPutBlockFromURLBlockSize {
+		return 0, fmt.Errorf("s2s-block-size-mb cannot be more than %d MiB, the largest block that Put Block From URL accepts", common.MaxPutBlockFromURLBlockSize/(1024*1024))
+	}
+
+	if serverSide && s2sBlockSize != 0 {
+		blockSize = s2sBlockSize
+	} else if serverSide && blockSize > common.MaxPutBlockFromURLBlockSize {
+		return 0, fmt.Errorf("block-size-mb cannot be more than %d MiB for a server-side copy, the largest block that Put Block From URL accepts", common.MaxPutBlockFromURLBlockSize/(1024*1024))
+	}
+	if blobType == common.EBlobType.AppendBlob() && blockSize > common.MaxAppendBlobBlockSize {
+		return 0, fmt.Errorf("block size cannot be greater than 4MB for AppendBlob blob type")
+	}
+	return blockSize, nil
+}
+
+// validateMaxBlocks checks the max-blocks flag against the service's limit on blocks per blob and against the other options it depends on.
+// Since the block size is derived from it, it cannot be combined with an explicit block size, and it only makes sense for block blobs.
+func validateMaxBlocks(rawMaxBlocks int, blockSize int64, blobType common.BlobType, fromTo common.FromTo) (uint16, error) {
+	if rawMaxBlocks == 0 {
+		return 0, nil
+	}
+	if rawMaxBlocks < 0 || rawMaxBlocks > common.MaxNumberOfBlocksPerBlob {
+		return 0, fmt.Errorf("max-blocks must be between 1 and %d, the most blocks a blob can have", common.MaxNumberOfBlocksPerBlob)
+	}
+	if blockSize != 0 {
+		return 0, errors.New("max-blocks cannot be used together with block-size-mb or s2s-block-size-mb")
+	}
+	if fromTo.To() != common.ELocation.Blob() {
+		return 0, errors.New("max-blocks is only supported when the destination is Blob storage")
+	}
+	if blobType == common.EBlobType.AppendBlob() || blobType == common.EBlobType.PageBlob() {
+		return 0, errors.New("max-blocks is only supported for block blobs")
+	}
+	return uint16(rawMaxBlocks), nil
+}
+
 // validates and transform raw input into cooked input
 func (raw rawCopyCmdArgs) cook() (cookedCopyCmdArgs, error) {
 	// generate a unique job ID
@@ -275,7 +559,17 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		return cooked, err
 	}
 
+	if !raw.allowSameLocation {
+		if err = checkNotSameLocation(tempSrc, tempDest, fromTo, !cooked.stripTopDir); err != nil {
+			return cooked, err
+		}
+	}
+
 	cooked.fromTo = fromTo
+	if err = validatePipeTo(raw.pipeTo, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	cooked.pipeTo = raw.pipeTo
 	cooked.recursive = raw.recursive
 	cooked.followSymlinks = raw.followSymlinks
 	cooked.forceIfReadOnly = raw.forceIfReadOnly
@@ -294,6 +588,70 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 	}
 	cooked.autoDecompress = raw.autoDecompress
 
+	if err = validateSkipLocked(raw.skipLocked, raw.retryLocked, fromTo); err != nil {
+		return cooked, err
+	}
+	cooked.skipLocked = raw.skipLocked
+	cooked.retryLocked = raw.retryLocked
+
+	if err = cooked.progressBasis.Parse(raw.progressBasis); err != nil {
+		return cooked, err
+	}
+
+	if err = cooked.normalizeUnicode.Parse(raw.normalizeUnicode); err != nil {
+		return cooked, err
+	}
+
+	if raw.maxTransfers < 0 {
+		return cooked, errors.New("max-transfers cannot be negative")
+	}
+	cooked.maxTransfers = raw.maxTransfers
+
+	if raw.lookahead < 0 {
+		return cooked, errors.New("enumeration-lookahead cannot be negative")
+	}
+	cooked.lookahead = raw.lookahead
+
+	if cooked.deadline, err = parseDeadline(raw.deadline, time.Now()); err != nil {
+		return cooked, err
+	}
+
+	if cooked.prefixConcurrency, err = parsePrefixConcurrency(raw.prefixConcurrency, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+
+	if raw.shardByPrefix < 0 {
+		return cooked, errors.New("shard-by-prefix cannot be negative")
+	}
+	cooked.shardByPrefix = raw.shardByPrefix
+
+	if raw.batchDelete && fromTo != common.EFromTo.BlobTrash() {
+		return cooked, errors.New("batch-delete is only supported when removing blobs")
+	}
+	cooked.batchDelete = raw.batchDelete
+
+	if raw.requireSoftDelete && fromTo != common.EFromTo.BlobTrash() {
+		return cooked, errors.New("require-soft-delete is only supported when removing blobs")
+	}
+	cooked.requireSoftDelete = raw.requireSoftDelete
+
+	if err = validateStartJitter(raw.startJitter); err != nil {
+		return cooked, err
+	}
+	cooked.startJitter = raw.startJitter
+
+	if raw.createDestination && !fromTo.To().IsRemote() {
+		return cooked, errors.New("create-destination is only supported when the destination is Blob storage, Azure Files or ADLS Gen2")
+	}
+	cooked.createDestination = raw.createDestination
+	if cooked.destinationPublicAccess, err = parseDestinationPublicAccess(raw.destinationPublicAccess); err != nil {
+		return cooked, err
+	}
+	if cooked.destinationPublicAccess != azblob.PublicAccessNone && (!cooked.createDestination || fromTo.To() != common.ELocation.Blob()) {
+		return cooked, errors.New("destination-public-access is only supported with create-destination, when the destination is Blob storage")
+	}
+	cooked.preflight = raw.preflight
+
 	// cooked.stripTopDir is effectively a workaround for the lack of wildcards in remote sources.
 	// Local, however, still supports wildcards, and thus needs its top directory stripped whenever a wildcard is used.
 	// Thus, we check for wildcards and instruct the processor to strip the top dir later instead of repeatedly checking cca.source for wildcards.
@@ -305,6 +663,10 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 	if err != nil {
 		return cooked, err
 	}
+	s2sBlockSize, err := blockSizeInBytes(raw.s2sBlockSizeMB)
+	if err != nil {
+		return cooked, err
+	}
 
 	// parse the given blob type.
 	err = cooked.blobType.Parse(raw.blobType)
@@ -312,24 +674,48 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		return cooked, err
 	}
 
-	// If the given blobType is AppendBlob, block-size-mb should not be greater than
-	// 4MB.
-	if cookedSize, _ := blockSizeInBytes(raw.blockSizeMB); cooked.blobType == common.EBlobType.AppendBlob() && cookedSize > common.MaxAppendBlobBlockSize {
-		return cooked, fmt.Errorf("block size cannot be greater than 4MB for AppendBlob blob type")
+	// check the block sizes against the service's limits, and choose the one that this job uses, which depends on whether it's server-side
+	if err = cooked.s2sPreference.Parse(raw.s2sPreference); err != nil {
+		return cooked, err
+	}
+	if cooked.blockSize, err = validateBlockSizes(cooked.blockSize, s2sBlockSize, cooked.blobType, fromTo, cooked.s2sPreference); err != nil {
+		return cooked, err
 	}
 
-	err = cooked.blockBlobTier.Parse(raw.blockBlobTier)
-	if err != nil {
+	if cooked.maxBlocks, err = validateMaxBlocks(raw.maxBlocks, cooked.blockSize, cooked.blobType, fromTo); err != nil {
 		return cooked, err
 	}
+	if cooked.maxBlocks > 0 {
+		glcm.Info(fmt.Sprintf("The block size of each blob will be derived from the size of its file, so that no blob needs more than %d blocks. "+
+			"The size used for each file is recorded in the log.", cooked.maxBlocks))
+	}
+
+	if err = cooked.blockBlobTier.Parse(raw.blockBlobTier); err != nil {
+		// a tier that AzCopy doesn't know of, such as one added to the service since, is passed on for the service to validate
+		if cooked.blockBlobTierName, err = parseBlockBlobTierName(raw.blockBlobTier); err != nil {
+			return cooked, err
+		}
+	}
 	err = cooked.pageBlobTier.Parse(raw.pageBlobTier)
 	if err != nil {
 		return cooked, err
 	}
+	if raw.tierByAccessTime != "" {
+		if cooked.tierByAccessTime, err = ste.ParseAccessTimeTiers(raw.tierByAccessTime); err != nil {
+			return cooked, fmt.Errorf("invalid tier-by-access-time: %s", err.Error())
+		}
+		if err = validateTierByAccessTime(cooked); err != nil {
+			return cooked, err
+		}
+	}
 	err = cooked.logVerbosity.Parse(raw.logVerbosity)
 	if err != nil {
 		return cooked, err
 	}
+	err = cooked.logFormat.Parse(raw.logFormat)
+	if err != nil {
+		return cooked, err
+	}
 
 	// Everything uses the new implementation of list-of-files now.
 	// This handles both list-of-files and include-path as a list enumerator.
@@ -340,7 +726,7 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		return cooked, fmt.Errorf("the include and exclude parameters have been replaced by include-pattern; include-path; exclude-pattern and exclude-path. For info, run: azcopy copy help")
 	}
 
-	if (len(raw.include) > 0 || len(raw.exclude) > 0) && cooked.fromTo == common.EFromTo.BlobFSTrash() {
+	if (len(raw.include) > 0 || len(raw.exclude) > 0 || raw.includeFrom != "" || raw.excludeFrom != "") && cooked.fromTo == common.EFromTo.BlobFSTrash() {
 		return cooked, fmt.Errorf("include/exclude flags are not supported for this destination")
 		// note there's another, more rigorous check, in removeBfsResources()
 	}
@@ -358,6 +744,14 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		if err != nil {
 			return cooked, fmt.Errorf("cannot open %s file passed with the list-of-file flag", raw.listOfFilesToCopy)
 		}
+		if err = validateListStartLine(f, raw.listStartLine); err != nil {
+			return cooked, err
+		}
+		if raw.listStartLine > 0 {
+			glcm.Info(fmt.Sprintf("Skipping the first %d lines of %s", raw.listStartLine, raw.listOfFilesToCopy))
+		}
+	} else if raw.listStartLine != 0 {
+		return cooked, errors.New("list-start-line can only be used with list-of-files")
 	}
 
 	// Prepare UTF-8 byte order marker
@@ -380,6 +774,10 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 			headerLineNum := 0
 			firstLineIsCurlyBrace := false
 
+			for skipped := 0; skipped < raw.listStartLine && scanner.Scan(); skipped++ {
+				checkBOM = true // the BOM, if any, was on a line that has been skipped
+			}
+
 			for scanner.Scan() {
 				v := scanner.Text()
 
@@ -429,6 +827,11 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 	if raw.listOfFilesToCopy != "" || raw.includePath != "" {
 		cooked.listOfFilesChannel = listChan
 	}
+	cooked.failedList = raw.failedList
+	cooked.sha256Manifest = raw.sha256Manifest
+	cooked.continueOnEnumerationError = raw.continueOnEnumerationError
+	cooked.includeVersions = raw.includeVersions
+	cooked.maxVersionsPerBlob = raw.maxVersionsPerBlob
 
 	if raw.includeBefore != "" {
 		// must set chooseEarliest = false, so that if there's an ambiguous local date, the latest will be returned
@@ -450,6 +853,23 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		cooked.includeAfter = &parsedIncludeAfter
 	}
 
+	if raw.changedSinceJob != "" {
+		if raw.includeAfter != "" {
+			return cooked, fmt.Errorf("cannot combine --changed-since-job with --%s, since both set the earliest modification time to copy", common.IncludeAfterFlagName)
+		}
+		priorJobID, err := common.ParseJobID(raw.changedSinceJob)
+		if err != nil {
+			return cooked, fmt.Errorf("error parsing the job ID given with --changed-since-job: %w", err)
+		}
+		var details common.GetJobDetailsResponse
+		Rpc(common.ERpcCmd.GetJobDetails(), &common.GetJobDetailsRequest{JobID: priorJobID}, &details)
+		threshold, err := changedSinceJobThreshold(priorJobID, details)
+		if err != nil {
+			return cooked, err
+		}
+		cooked.includeAfter = &threshold
+	}
+
 	versionsChan := make(chan string)
 	var filePtr *os.File
 	// Get file path from user which would contain list of all versionIDs
@@ -493,6 +913,9 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 	cooked.contentType = raw.contentType
 	cooked.contentEncoding = raw.contentEncoding
 	cooked.contentLanguage = raw.contentLanguage
+	if err = validateContentLanguage(cooked.contentLanguage); err != nil {
+		return cooked, err
+	}
 	cooked.contentDisposition = raw.contentDisposition
 	cooked.cacheControl = raw.cacheControl
 	cooked.noGuessMimeType = raw.noGuessMimeType
@@ -509,6 +932,35 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 	}
 	cooked.blobTags = blobTags
 
+	if cooked.expiryOption, cooked.expiryTime, err = parseExpiry(raw.expiry, time.Now()); err != nil {
+		return cooked, err
+	}
+	if err = validateExpiry(cooked.expiryOption, raw.s2sPreserveExpiry, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	if raw.s2sPreserveExpiry {
+		cooked.expiryOption = common.EBlobExpiryOption.PreserveSource()
+	}
+	if err = validateVerifyEncryption(raw.verifyEncryption, raw.verifyEncryptionScope, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	cooked.verifyEncryption = raw.verifyEncryption || raw.verifyEncryptionScope != ""
+	cooked.verifyEncryptionScope = raw.verifyEncryptionScope
+	if err = validatePreserveImmutability(raw.preserveImmutability, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	cooked.preserveImmutability = raw.preserveImmutability
+
+	if err = validatePageBlobSequenceNumber(raw.pageBlobSequenceNumber, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	cooked.pageBlobSequenceNumber = raw.pageBlobSequenceNumber
+
+	if err = validateBlockIDPrefix(raw.blockIDPrefix, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	cooked.blockIDPrefix = raw.blockIDPrefix
+
 	// Make sure the given input is the one of the enums given by the blob SDK
 	err = cooked.deleteSnapshotsOption.Parse(raw.deleteSnapshotsOption)
 	if err != nil {
@@ -550,6 +1002,65 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		return cooked, err
 	}
 
+	if cooked.preserveFileAttributes, err = validatePreserveFileAttributes(raw.preserveFileAttributes, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+
+	cooked.preservePOSIX = raw.preservePOSIX
+	if err = validatePreservePOSIX(cooked.preservePOSIX, cooked.fromTo, false); err != nil {
+		return cooked, err
+	}
+
+	cooked.setReadOnly = raw.setReadOnly
+	if err = validateSetReadOnly(cooked.setReadOnly, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+
+	cooked.skipSMBInfo = raw.skipSMBInfo
+	if err = validateSkipSMBInfo(cooked); err != nil {
+		return cooked, err
+	}
+
+	if cooked.fileMode, err = parseDownloadMode("file-mode", raw.fileMode); err != nil {
+		return cooked, err
+	}
+	if cooked.dirMode, err = parseDownloadMode("dir-mode", raw.dirMode); err != nil {
+		return cooked, err
+	}
+	if err = validateDownloadModes(cooked); err != nil {
+		return cooked, err
+	}
+
+	if err = validateSha256Manifest(cooked.sha256Manifest, cooked.fromTo, cooked.autoDecompress); err != nil {
+		return cooked, err
+	}
+
+	cooked.transactional = raw.transactional
+	cooked.transactionalFailureThreshold = raw.transactionalFailureThreshold
+	if err = validateTransactional(cooked.transactional, cooked.transactionalFailureThreshold, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+
+	cooked.preserveDirectoryTimestamps = raw.preserveDirectoryTimestamps
+	if err = validatePreserveDirectoryTimestamps(cooked.preserveDirectoryTimestamps, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+
+	if err = validateShardByPrefix(cooked.shardByPrefix, cooked.fromTo, cooked.recursive, cooked.source,
+		cooked.listOfFilesChannel != nil || cooked.listOfVersionIDs != nil); err != nil {
+		return cooked, err
+	}
+
+	if err = validateContinueOnEnumerationError(cooked.continueOnEnumerationError, cooked.fromTo,
+		cooked.listOfFilesChannel != nil || cooked.listOfVersionIDs != nil, cooked.shardByPrefix); err != nil {
+		return cooked, err
+	}
+
+	if err = validateIncludeVersions(cooked.includeVersions, cooked.maxVersionsPerBlob, cooked.fromTo,
+		cooked.listOfFilesChannel != nil || cooked.listOfVersionIDs != nil, cooked.shardByPrefix, raw.destTemplate); err != nil {
+		return cooked, err
+	}
+
 	if err = crossValidateSymlinksAndPermissions(cooked.followSymlinks, cooked.preserveSMBPermissions.IsTruthy()); err != nil {
 		return cooked, err
 	}
@@ -570,8 +1081,7 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		if cooked.preserveLastModifiedTime {
 			return cooked, fmt.Errorf("preserve-last-modified-time is not supported while uploading")
 		}
-		if cooked.blockBlobTier != common.EBlockBlobTier.None() ||
-			cooked.pageBlobTier != common.EPageBlobTier.None() {
+		if cooked.setsBlobTier() {
 			return cooked, fmt.Errorf("blob-tier is not supported while uploading to ADLS Gen 2")
 		}
 		if cooked.preserveSMBPermissions.IsTruthy() {
@@ -609,8 +1119,7 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		if cooked.preserveLastModifiedTime {
 			return cooked, fmt.Errorf("preserve-last-modified-time is not supported while uploading")
 		}
-		if cooked.blockBlobTier != common.EBlockBlobTier.None() ||
-			cooked.pageBlobTier != common.EPageBlobTier.None() {
+		if cooked.setsBlobTier() {
 			return cooked, fmt.Errorf("blob-tier is not supported while uploading to Azure File")
 		}
 		if cooked.s2sPreserveProperties {
@@ -634,8 +1143,7 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		if cooked.followSymlinks {
 			return cooked, fmt.Errorf("follow-symlinks flag is not supported while downloading")
 		}
-		if cooked.blockBlobTier != common.EBlockBlobTier.None() ||
-			cooked.pageBlobTier != common.EPageBlobTier.None() {
+		if cooked.setsBlobTier() {
 			return cooked, fmt.Errorf("blob-tier is not supported while downloading")
 		}
 		if cooked.noGuessMimeType {
@@ -658,6 +1166,7 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		}
 	case common.EFromTo.BlobFile(),
 		common.EFromTo.S3Blob(),
+		common.EFromTo.HttpBlob(),
 		common.EFromTo.BlobBlob(),
 		common.EFromTo.FileBlob(),
 		common.EFromTo.FileFile():
@@ -673,8 +1182,7 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 		}
 
 		// Setting blob tier is supported only when destination is a blob storage. Disabling it for all the other transfer scenarios.
-		if (cooked.blockBlobTier != common.EBlockBlobTier.None() || cooked.pageBlobTier != common.EPageBlobTier.None()) &&
-			cooked.fromTo.To() != common.ELocation.Blob() {
+		if cooked.setsBlobTier() && cooked.fromTo.To() != common.ELocation.Blob() {
 			return cooked, fmt.Errorf("blob-tier is not supported for the scenario (%s)", cooked.fromTo.String())
 		}
 		if cooked.noGuessMimeType {
@@ -687,9 +1195,88 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 	if err = validatePutMd5(cooked.putMd5, cooked.fromTo); err != nil {
 		return cooked, err
 	}
+	if err = validateDeltaUpdate(raw.deltaUpdate, cooked.fromTo, cooked.blobType); err != nil {
+		return cooked, err
+	}
+	cooked.deltaUpdate = raw.deltaUpdate
+	if cooked.clientSideEncryptionKey, err = validateClientSideEncryption(raw.clientSideEncryptionKey, raw.clientSideKeyID, cooked); err != nil {
+		return cooked, err
+	}
+	if raw.metadataRules != "" {
+		if !cooked.fromTo.IsUpload() || (cooked.fromTo.To() != common.ELocation.Blob() && cooked.fromTo.To() != common.ELocation.File()) {
+			return cooked, errors.New("metadata-rules is only supported when uploading to Blob storage or Azure Files")
+		}
+		cooked.metadataRules, err = loadMetadataRules(raw.metadataRules)
+		if err != nil {
+			return cooked, fmt.Errorf("cannot use the metadata rules in %s: %s", raw.metadataRules, err.Error())
+		}
+	}
+	if raw.metadataFrom != "" {
+		if !cooked.fromTo.IsUpload() || (cooked.fromTo.To() != common.ELocation.Blob() && cooked.fromTo.To() != common.ELocation.File()) {
+			return cooked, errors.New("metadata-from is only supported when uploading to Blob storage or Azure Files")
+		}
+		cooked.metadataFrom, err = loadMetadataManifest(raw.metadataFrom)
+		if err != nil {
+			return cooked, fmt.Errorf("cannot use the metadata manifest %s: %s", raw.metadataFrom, err.Error())
+		}
+	}
 	if err = validateMd5Option(cooked.md5ValidationOption, cooked.fromTo); err != nil {
 		return cooked, err
 	}
+	if err = validateCasOutput(raw.casOutput, raw.casManifest, cooked); err != nil {
+		return cooked, err
+	}
+	cooked.casOutput = raw.casOutput
+	cooked.casManifest = raw.casManifest
+	if err = validateDestTemplate(raw.destTemplate, cooked); err != nil {
+		return cooked, err
+	}
+	cooked.destTemplate = raw.destTemplate
+	if err = validatePartitionBy(raw.partitionBy, cooked); err != nil {
+		return cooked, err
+	}
+	cooked.partitionBy = raw.partitionBy
+	if err = validateDestinationNameChecks(raw.checkNames, raw.sanitizeNames, cooked); err != nil {
+		return cooked, err
+	}
+	cooked.checkNames = raw.checkNames
+	cooked.sanitizeNames = raw.sanitizeNames
+	if err = validatePrecreateDirectories(raw.precreateDirs, cooked); err != nil {
+		return cooked, err
+	}
+	cooked.precreateDirs = raw.precreateDirs
+	if err = validateCreateDirectoryMarkers(raw.createDirMarkers, cooked); err != nil {
+		return cooked, err
+	}
+	cooked.createDirMarkers = raw.createDirMarkers
+	if cooked.createDirMarkers && cooked.fromTo.IsDownload() {
+		cooked.includeDirectoryStubs = true // so that the markers with Hadoop's metadata are listed, to be recreated
+	}
+	if err = validateDeterministicCopy(azcopyDeterministic, cooked); err != nil {
+		return cooked, err
+	}
+	if err = validatePreserveListingOrder(raw.preserveListingOrder, cooked); err != nil {
+		return cooked, err
+	}
+	cooked.preserveListingOrder = raw.preserveListingOrder
+	if err = validatePreserveHardLinks(raw.preserveHardLinks, cooked); err != nil {
+		return cooked, err
+	}
+	cooked.preserveHardLinks = raw.preserveHardLinks
+	if raw.reserveSpaceMB < 0 {
+		return cooked, errors.New("reserve-space-mb cannot be negative")
+	}
+	if raw.reserveSpaceMB != 0 && (fromTo.To() != common.ELocation.Local() || !raw.checkDiskSpace) {
+		return cooked, errors.New("reserve-space-mb only applies to downloads, when check-disk-space is true")
+	}
+	// the source is listed once to add up its size, and again for the transfers, but a list of files can only be read once
+	listed := cooked.listOfFilesChannel != nil || cooked.listOfVersionIDs != nil
+	if raw.reserveSpaceMB != 0 && (listed || cooked.destination.Value == common.Dev_Null) {
+		return cooked, errors.New("reserve-space-mb cannot be combined with list-of-files, include-path or list-of-versions, or used when the destination is " + common.Dev_Null)
+	}
+	// since it's on by default, the check is just skipped when it can't be done, or when nothing is written
+	cooked.checkDiskSpace = raw.checkDiskSpace && fromTo.To() == common.ELocation.Local() && !listed && cooked.destination.Value != common.Dev_Null
+	cooked.reserveSpace = raw.reserveSpaceMB * 1024 * 1024
 
 	// Because of some of our defaults, these must live down here and can't be properly checked.
 	// TODO: Remove the above checks where they can't be done.
@@ -697,6 +1284,13 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 	cooked.s2sGetPropertiesInBackend = raw.s2sGetPropertiesInBackend
 	cooked.s2sPreserveAccessTier = raw.s2sPreserveAccessTier
 	cooked.s2sSourceChangeValidation = raw.s2sSourceChangeValidation
+	if err = validateS2SPreference(cooked.s2sPreference, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	if raw.rehydrateAndCopy && cooked.fromTo.From() != common.ELocation.Blob() {
+		return cooked, errors.New("rehydrate-and-copy only applies when the source is Blob storage")
+	}
+	cooked.rehydrateAndCopy = raw.rehydrateAndCopy
 
 	// If the user has provided some input with excludeBlobType flag, parse the input.
 	if len(raw.excludeBlobType) > 0 {
@@ -711,6 +1305,22 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 			cooked.excludeBlobType = append(cooked.excludeBlobType, eBlobType.ToAzBlobType())
 		}
 	}
+	if (raw.includeTier != "" || raw.excludeTier != "") && fromTo.From() != common.ELocation.Blob() {
+		return cooked, errors.New("include-tier and exclude-tier are only supported when the source is Blob storage")
+	}
+	if cooked.includeTier, err = parseAccessTiers(raw.includeTier, "include-tier"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludeTier, err = parseAccessTiers(raw.excludeTier, "exclude-tier"); err != nil {
+		return cooked, err
+	}
+	cooked.skipEmpty = raw.skipEmpty
+	if raw.includeContentType != "" && fromTo.From() != common.ELocation.Local() && fromTo.From() != common.ELocation.Blob() {
+		return cooked, errors.New("include-content-type is only supported when uploading, or when the source is Blob storage")
+	}
+	if cooked.includeContentType, err = parseContentTypePatterns(raw.includeContentType); err != nil {
+		return cooked, err
+	}
 
 	err = cooked.s2sInvalidMetadataHandleOption.Parse(raw.s2sInvalidMetadataHandleOption)
 	if err != nil {
@@ -721,6 +1331,17 @@ func (raw rawCopyCmdArgs) cookWithId(jobId common.JobID) (cookedCopyCmdArgs, err
 	cooked.includePatterns = raw.parsePatterns(raw.include)
 	cooked.excludePatterns = raw.parsePatterns(raw.exclude)
 	cooked.excludePathPatterns = raw.parsePatterns(raw.excludePath)
+	cooked.priorityPatterns = raw.parsePatterns(raw.priorityPattern)
+	if cooked.includePatterns, err = addPatternsFromFile(cooked.includePatterns, raw.includeFrom, "include-from"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludePatterns, err = addPatternsFromFile(cooked.excludePatterns, raw.excludeFrom, "exclude-from"); err != nil {
+		return cooked, err
+	}
+
+	if cooked.throughputFloor, err = validateMinThroughput(raw.minThroughputMbps, raw.minThroughputWindow, raw.minThroughputAction, fromTo); err != nil {
+		return cooked, err
+	}
 
 	if (raw.includeFileAttributes != "" || raw.excludeFileAttributes != "") && fromTo.From() != common.ELocation.Local() {
 		return cooked, errors.New("cannot check file attributes on remote objects")
@@ -744,26 +1365,232 @@ func (raw *rawCopyCmdArgs) warnIfHasWildcard(oncer *sync.Once, paramName string,
 	}
 }
 
-// When other commands use the copy command arguments to cook cook, set the blobType to None and validation option
-// else parsing the arguments will fail.
-func (raw *rawCopyCmdArgs) setMandatoryDefaults() {
-	raw.blobType = common.EBlobType.Detect().String()
-	raw.blockBlobTier = common.EBlockBlobTier.None().String()
-	raw.pageBlobTier = common.EPageBlobTier.None().String()
-	raw.md5ValidationOption = common.DefaultHashValidationOption.String()
-	raw.s2sInvalidMetadataHandleOption = common.DefaultInvalidMetadataHandleOption.String()
-	raw.forceWrite = common.EOverwriteOption.True().String()
-	raw.preserveOwner = common.PreserveOwnerDefault
+// When other commands use the copy command arguments to cook cook, set the blobType to None and validation option
+// else parsing the arguments will fail.
+func (raw *rawCopyCmdArgs) setMandatoryDefaults() {
+	raw.blobType = common.EBlobType.Detect().String()
+	raw.blockBlobTier = common.EBlockBlobTier.None().String()
+	raw.pageBlobTier = common.EPageBlobTier.None().String()
+	raw.md5ValidationOption = common.DefaultHashValidationOption.String()
+	raw.s2sInvalidMetadataHandleOption = common.DefaultInvalidMetadataHandleOption.String()
+	raw.forceWrite = common.EOverwriteOption.True().String()
+	raw.preserveOwner = common.PreserveOwnerDefault
+}
+
+func validateForceIfReadOnly(toForce bool, fromTo common.FromTo) error {
+	targetIsFiles := fromTo.To() == common.ELocation.File() ||
+		fromTo == common.EFromTo.FileTrash()
+	targetIsWindowsFS := fromTo.To() == common.ELocation.Local() &&
+		runtime.GOOS == "windows"
+	targetIsOK := targetIsFiles || targetIsWindowsFS
+	if toForce && !targetIsOK {
+		return errors.New("force-if-read-only is only supported when the target is Azure Files or a Windows file system")
+	}
+	return nil
+}
+
+func validateSetReadOnly(setReadOnly bool, fromTo common.FromTo) error {
+	if setReadOnly && fromTo.To() != common.ELocation.File() {
+		return errors.New("set-readonly is only supported when the destination is Azure Files")
+	}
+	return nil
+}
+
+// validateSkipSMBInfo checks that skip-smb-info is only combined with options that need none of the calls it leaves out
+func validateSkipSMBInfo(cooked cookedCopyCmdArgs) error {
+	if !cooked.skipSMBInfo {
+		return nil
+	}
+	if cooked.fromTo.To() != common.ELocation.File() {
+		// downloads, and other copies from Azure Files, only get SMB info when it's to be preserved
+		return errors.New("skip-smb-info is only supported when the destination is Azure Files")
+	}
+	if cooked.preserveSMBInfo {
+		return errors.New("skip-smb-info cannot be combined with preserve-smb-info")
+	}
+	if cooked.preserveSMBPermissions.IsTruthy() {
+		return errors.New("skip-smb-info cannot be combined with preserve-smb-permissions")
+	}
+	if cooked.setReadOnly {
+		return errors.New("skip-smb-info cannot be combined with set-readonly, which sets an SMB attribute")
+	}
+	if cooked.forceIfReadOnly {
+		return errors.New("skip-smb-info cannot be combined with force-if-read-only, which clears an SMB attribute")
+	}
+	return nil
+}
+
+// validateListStartLine checks that the lines that list-start-line skips leave some of the list to copy.
+// It counts the lines of the list, then rewinds it, so that it can be read again from the start
+func validateListStartLine(list *os.File, startLine int) error {
+	if startLine < 0 {
+		return fmt.Errorf("list-start-line cannot be negative, but was %d", startLine)
+	}
+	if startLine == 0 {
+		return nil
+	}
+
+	lines := 0
+	scanner := bufio.NewScanner(list)
+	for scanner.Scan() {
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("cannot read %s to check list-start-line: %w", list.Name(), err)
+	}
+	if startLine >= lines {
+		return fmt.Errorf("list-start-line %d skips all of %s, which has %d lines", startLine, list.Name(), lines)
+	}
+
+	_, err := list.Seek(0, io.SeekStart)
+	return err
+}
+
+// parseDownloadMode parses the octal permission bits given to file-mode or dir-mode. Only the permission bits may be given,
+// since os.Chmod takes setuid, setgid and sticky as os.FileMode flags rather than as their POSIX bits
+func parseDownloadMode(flagName, mode string) (uint16, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	bits, err := strconv.ParseUint(mode, 8, 16)
+	if err != nil || bits > 0777 {
+		return 0, fmt.Errorf("invalid %s %q: expected permission bits in octal, such as 0640", flagName, mode)
+	}
+	if bits == 0 {
+		return 0, fmt.Errorf("invalid %s %q: it grants no permissions, so not even the owner could use what is downloaded", flagName, mode)
+	}
+	return uint16(bits), nil
+}
+
+// validateS2SPreference checks that the copy can be done the way the preference asks. Copies to and from local locations
+// always go through AzCopy, so never is no different for them from prefer, but they can't be required to be server-to-server.
+func validateS2SPreference(preference common.S2SPreference, fromTo common.FromTo) error {
+	switch preference {
+	case common.ES2SPreference.Require():
+		if !supportsServiceSideCopy(fromTo) {
+			return fmt.Errorf("s2s=require, but a copy from %s to %s can't be done server-to-server", fromTo.From(), fromTo.To())
+		}
+	case common.ES2SPreference.Never():
+		// only the destinations with uploaders can be sent the data through AzCopy
+		if to := fromTo.To(); fromTo.IsS2S() && to != common.ELocation.Blob() && to != common.ELocation.File() {
+			return fmt.Errorf("s2s=never is only supported for copies to Blob storage and Azure Files, not to %s", to)
+		}
+	}
+	return nil
+}
+
+// supportsServiceSideCopy says whether the service can copy from the source to the destination by itself.
+// Only Blob storage and Azure Files can copy from a URL, and neither can read from the dfs endpoint.
+func supportsServiceSideCopy(fromTo common.FromTo) bool {
+	if !fromTo.IsS2S() {
+		return false
+	}
+	switch fromTo.From() {
+	case common.ELocation.Blob(), common.ELocation.File(), common.ELocation.S3(), common.ELocation.Http():
+	default:
+		return false
+	}
+	to := fromTo.To()
+	return to == common.ELocation.Blob() || to == common.ELocation.File()
+}
+
+// validateDownloadModes checks that file-mode and dir-mode are only given when downloading to Linux or macOS,
+// and not along with preserve-posix, which restores the mode of each file from its source instead
+func validateDownloadModes(cooked cookedCopyCmdArgs) error {
+	if cooked.fileMode == 0 && cooked.dirMode == 0 {
+		return nil
+	}
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		return errors.New("file-mode and dir-mode are only supported on Linux and macOS")
+	}
+	if cooked.fromTo.To() != common.ELocation.Local() {
+		return errors.New("file-mode and dir-mode are only supported when downloading")
+	}
+	if cooked.preservePOSIX {
+		return fmt.Errorf("file-mode and dir-mode cannot be combined with %s, which restores the mode of each file from its source", common.PreservePOSIXFlagName)
+	}
+	return nil
+}
+
+// blockBlobTierNameRegex matches what a tier name may be: a word, such as Cold, which the service knows and AzCopy may not yet
+var blockBlobTierNameRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// parseBlockBlobTierName checks that a tier that isn't one of common.BlockBlobTier's values is at least shaped like a tier name,
+// so that a typo of a flag value isn't sent, and that it fits in the plan. Whether it's a tier is up to the service
+func parseBlockBlobTierName(name string) (string, error) {
+	if !blockBlobTierNameRegex.MatchString(name) || len(name) > ste.BlobTierMaxBytes {
+		return "", fmt.Errorf("invalid block-blob-tier %q: a tier is a name of letters and digits, at most %d long", name, ste.BlobTierMaxBytes)
+	}
+	return name, nil
+}
+
+func validateTierByAccessTime(cooked cookedCopyCmdArgs) error {
+	if cooked.fromTo != common.EFromTo.BlobBlob() {
+		return errors.New("tier-by-access-time only applies when copying from Blob storage to Blob storage, since the tiers are chosen by when each source blob was last accessed")
+	}
+	if cooked.blockBlobTier != common.EBlockBlobTier.None() || cooked.blockBlobTierName != "" {
+		return errors.New("tier-by-access-time cannot be combined with block-blob-tier, which gives every block blob the same tier")
+	}
+	if rules := cooked.tierByAccessTime.String(); len(rules) > ste.AccessTimeTiersMaxBytes {
+		return fmt.Errorf("tier-by-access-time has too many rules: %q is longer than %d characters", rules, ste.AccessTimeTiersMaxBytes)
+	}
+	return nil
+}
+
+// setsBlobTier says whether the user chose a tier for the blobs written
+func (cca *cookedCopyCmdArgs) setsBlobTier() bool {
+	return cca.blockBlobTier != common.EBlockBlobTier.None() || cca.blockBlobTierName != "" || cca.pageBlobTier != common.EPageBlobTier.None()
+}
+
+// blockBlobAccessTier returns the tier that the user chose for block blobs, whether or not AzCopy knows of it, or AccessTierNone if they didn't choose one
+func (cca *cookedCopyCmdArgs) blockBlobAccessTier() azblob.AccessTierType {
+	if cca.blockBlobTierName != "" {
+		return azblob.AccessTierType(cca.blockBlobTierName)
+	}
+	if cca.blockBlobTier != common.EBlockBlobTier.None() {
+		return cca.blockBlobTier.ToAccessTierType()
+	}
+	return azblob.AccessTierNone
+}
+
+// validateTransactional checks that the job is an upload to Blob storage, which is the only case in which a rollback
+// knows how to tell the blobs it created from those it overwrote, and how to delete them
+func validateTransactional(transactional bool, failureThreshold uint32, fromTo common.FromTo) error {
+	if transactional && fromTo != common.EFromTo.LocalBlob() {
+		return errors.New("transactional is only supported when uploading to Blob storage")
+	}
+	if failureThreshold > 0 && !transactional {
+		return errors.New("transactional-failure-threshold can only be used with transactional")
+	}
+	return nil
+}
+
+// validatePreserveDirectoryTimestamps checks that the source has directories of its own, with modification times to preserve.
+// Blob storage only has virtual directories, so it doesn't.
+func validatePreserveDirectoryTimestamps(preserve bool, fromTo common.FromTo) error {
+	if preserve && fromTo != common.EFromTo.FileLocal() && fromTo != common.EFromTo.BlobFSLocal() {
+		return errors.New("preserve-directory-timestamps is only supported when downloading from Azure Files or ADLS Gen2")
+	}
+	return nil
 }
 
-func validateForceIfReadOnly(toForce bool, fromTo common.FromTo) error {
-	targetIsFiles := fromTo.To() == common.ELocation.File() ||
-		fromTo == common.EFromTo.FileTrash()
-	targetIsWindowsFS := fromTo.To() == common.ELocation.Local() &&
-		runtime.GOOS == "windows"
-	targetIsOK := targetIsFiles || targetIsWindowsFS
-	if toForce && !targetIsOK {
-		return errors.New("force-if-read-only is only supported when the target is Azure Files or a Windows file system")
+// validateShardByPrefix checks that the source is a directory whose top-level directories can each be listed on their own,
+// which rules out listing it partially (by a list of files, or a wildcard) as well as listing it non-recursively
+func validateShardByPrefix(shards int, fromTo common.FromTo, recursive bool, source common.ResourceString, isListed bool) error {
+	if shards == 0 {
+		return nil
+	}
+	if fromTo.From() != common.ELocation.Local() && fromTo.From() != common.ELocation.Blob() {
+		return errors.New("shard-by-prefix is only supported when the source is Blob storage or a local directory")
+	}
+	if !recursive {
+		return errors.New("shard-by-prefix requires recursive, since the source is split by its top-level directories")
+	}
+	if isListed {
+		return errors.New("cannot combine shard-by-prefix with list-of-files, include-path or list-of-versions")
+	}
+	if fromTo.From() == common.ELocation.Local() && strings.Contains(source.ValueLocal(), "*") {
+		return errors.New("cannot combine shard-by-prefix with a wildcard in the source")
 	}
 	return nil
 }
@@ -786,6 +1613,53 @@ func validatePreserveSMBPropertyOption(toPreserve bool, fromTo common.FromTo, ov
 	return nil
 }
 
+// validatePreserveFileAttributes returns whether the file attributes should actually be preserved,
+// since they are ignored (with a note to the user) when downloading to a non-Windows destination
+func validatePreserveFileAttributes(preserve bool, fromTo common.FromTo) (bool, error) {
+	if !preserve {
+		return false, nil
+	}
+
+	if fromTo != common.EFromTo.LocalBlob() && fromTo != common.EFromTo.BlobLocal() {
+		return false, fmt.Errorf("%s is only supported when uploading to or downloading from Blob storage. For Azure Files, use preserve-smb-info instead", common.PreserveFileAttributesFlagName)
+	}
+
+	if runtime.GOOS != "windows" {
+		if fromTo.IsUpload() {
+			return false, fmt.Errorf("%s is set but file attributes can only be read from a Windows file system", common.PreserveFileAttributesFlagName)
+		}
+		glcm.Info("File attributes can only be restored on Windows, so the attributes kept in blob metadata will be ignored.")
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// validatePreservePOSIX checks that the POSIX properties can be both read and kept in this kind of transfer.
+// Sync can only keep them when uploading, since restoring the original modification times on download
+// would make the downloaded files look older than their source, and so be downloaded again by every later sync.
+func validatePreservePOSIX(preserve bool, fromTo common.FromTo, isSync bool) error {
+	if !preserve {
+		return nil
+	}
+
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		return fmt.Errorf("%s is only supported on Linux and macOS", common.PreservePOSIXFlagName)
+	}
+
+	switch fromTo {
+	case common.EFromTo.LocalBlob(), common.EFromTo.LocalBlobFS(), common.EFromTo.LocalFile():
+		return nil
+	case common.EFromTo.BlobLocal(), common.EFromTo.FileLocal():
+		if isSync {
+			return fmt.Errorf("with sync, %s is only supported when uploading", common.PreservePOSIXFlagName)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s is only supported when uploading to Blob storage, ADLS Gen2 or Azure Files, or when downloading from Blob storage or Azure Files", common.PreservePOSIXFlagName)
+	}
+}
+
 func validatePreserveOwner(preserve bool, fromTo common.FromTo) error {
 	if fromTo.IsDownload() {
 		return nil // it can be used in downloads
@@ -817,6 +1691,36 @@ func validateBackupMode(backupMode bool, fromTo common.FromTo) error {
 	}
 }
 
+func validateSkipLocked(skipLocked, retryLocked bool, fromTo common.FromTo) error {
+	if retryLocked && !skipLocked {
+		return errors.New("retry-locked can only be used together with skip-locked")
+	}
+	if skipLocked && !fromTo.IsUpload() {
+		return errors.New("skip-locked is only supported for uploads")
+	}
+	return nil
+}
+
+// validateSyncAppendOnly rejects the sync options that would remove or modify objects at an append-only destination
+func validateSyncAppendOnly(appendOnly bool, deleteDestination common.DeleteDestination, onCaseMismatch common.CaseMismatchOption, compareAttributes, mirror bool) error {
+	if !appendOnly {
+		return nil
+	}
+	if deleteDestination != common.EDeleteDestination.False() {
+		return fmt.Errorf("delete-destination=%s cannot be used with --append-only, since it removes or marks objects at the destination", deleteDestination.String())
+	}
+	if onCaseMismatch == common.ECaseMismatchOption.Rename() {
+		return errors.New("on-case-mismatch=rename cannot be used with --append-only, since it removes the destination object")
+	}
+	if compareAttributes {
+		return errors.New("compare-metadata and compare-tags cannot be used with --append-only, since they modify objects at the destination")
+	}
+	if mirror {
+		return errors.New("mirror cannot be used with --append-only, since it modifies objects at the destination")
+	}
+	return nil
+}
+
 func validatePutMd5(putMd5 bool, fromTo common.FromTo) error {
 	// In case of S2S transfers, log info message to inform the users that MD5 check doesn't work for S2S Transfers.
 	// This is because we cannot calculate MD5 hash of the data stored at a remote locations.
@@ -829,6 +1733,234 @@ func validatePutMd5(putMd5 bool, fromTo common.FromTo) error {
 	return nil
 }
 
+// parseDestinationPublicAccess parses the public access level for a destination container that is created by the copy
+func parseDestinationPublicAccess(s string) (azblob.PublicAccessType, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return azblob.PublicAccessNone, nil
+	case "blob":
+		return azblob.PublicAccessBlob, nil
+	case "container":
+		return azblob.PublicAccessContainer, nil
+	default:
+		return azblob.PublicAccessNone, fmt.Errorf("invalid destination-public-access value '%s'. Valid values are none, blob and container", s)
+	}
+}
+
+func validateDeltaUpdate(deltaUpdate bool, fromTo common.FromTo, blobType common.BlobType) error {
+	if !deltaUpdate {
+		return nil
+	}
+	if fromTo != common.EFromTo.LocalBlob() {
+		return errors.New("upload-changed-blocks is only supported when uploading to Blob storage")
+	}
+	if blobType != common.EBlobType.Detect() && blobType != common.EBlobType.BlockBlob() {
+		return errors.New("upload-changed-blocks is only supported for block blobs")
+	}
+	return nil
+}
+
+// validateClientSideEncryption loads the key named by client-side-encryption-key, if the transfers can be encrypted or decrypted with it
+func validateClientSideEncryption(keyPath string, keyID string, cooked cookedCopyCmdArgs) (common.ClientSideEncryptionKey, error) {
+	if keyPath == "" {
+		if keyID != "" {
+			return common.ClientSideEncryptionKey{}, errors.New("client-side-encryption-key-id can only be used with client-side-encryption-key")
+		}
+		return common.ClientSideEncryptionKey{}, nil
+	}
+	if cooked.fromTo != common.EFromTo.LocalBlob() && cooked.fromTo != common.EFromTo.BlobLocal() {
+		return common.ClientSideEncryptionKey{}, errors.New("client-side-encryption-key is only supported when uploading to, or downloading from, Blob storage")
+	}
+	if cooked.fromTo.IsUpload() && cooked.blobType != common.EBlobType.Detect() && cooked.blobType != common.EBlobType.BlockBlob() {
+		return common.ClientSideEncryptionKey{}, errors.New("client-side-encryption-key is only supported for block blobs")
+	}
+	if cooked.putMd5 {
+		return common.ClientSideEncryptionKey{}, errors.New("client-side-encryption-key can't be combined with put-md5, since the blob holds the encrypted content, not the file")
+	}
+	if cooked.deltaUpdate {
+		return common.ClientSideEncryptionKey{}, errors.New("client-side-encryption-key can't be combined with upload-changed-blocks, since each upload encrypts with a content key of its own")
+	}
+	if strings.HasPrefix(strings.ToLower(keyPath), "https://") {
+		return common.ClientSideEncryptionKey{}, errors.New("keys in Azure Key Vault are not supported by client-side-encryption-key. Export the key to a file, and give the path of the file instead")
+	}
+	return common.LoadClientSideEncryptionKey(keyPath, keyID)
+}
+
+func validateCasOutput(casOutput bool, casManifest string, cooked cookedCopyCmdArgs) error {
+	if !casOutput {
+		if casManifest != "" {
+			return errors.New("cas-manifest can only be used with cas-output")
+		}
+		return nil
+	}
+	if !cooked.fromTo.IsDownload() || cooked.destination.Value == common.Dev_Null {
+		return errors.New("cas-output is only supported when downloading to a local directory")
+	}
+	if casManifest == "" {
+		return errors.New("cas-output requires cas-manifest, so that the original names of the files are kept")
+	}
+	// the files are named for their hash, so the content must be checked against it
+	if cooked.md5ValidationOption != common.EHashValidationOption.FailIfDifferent() &&
+		cooked.md5ValidationOption != common.EHashValidationOption.FailIfDifferentOrMissing() {
+		return errors.New("cas-output requires check-md5 to be FailIfDifferent or FailIfDifferentOrMissing")
+	}
+	if cooked.autoDecompress {
+		return errors.New("cas-output cannot be combined with decompress, since the decompressed content would not match its hash")
+	}
+	if cooked.listOfVersionIDs != nil {
+		return errors.New("cas-output cannot be combined with list-of-versions")
+	}
+	return nil
+}
+
+func validateDestTemplate(template string, cooked cookedCopyCmdArgs) error {
+	if template == "" {
+		return nil
+	}
+	if _, err := newDestTemplate(template, time.Now()); err != nil {
+		return fmt.Errorf("invalid dest-template: %s", err.Error())
+	}
+	if cooked.destination.Value == common.Dev_Null {
+		return errors.New("dest-template cannot be used when the destination is " + common.Dev_Null)
+	}
+	if cooked.casOutput {
+		return errors.New("dest-template cannot be combined with cas-output, since both decide where the files go")
+	}
+	return nil
+}
+
+func validatePartitionBy(partition string, cooked cookedCopyCmdArgs) error {
+	if partition == "" {
+		return nil
+	}
+	if _, err := newPartitionBy(partition, time.Now()); err != nil {
+		return fmt.Errorf("invalid partition-by: %s", err.Error())
+	}
+	if cooked.fromTo.From() != common.ELocation.Local() {
+		return errors.New("partition-by is only supported when uploading")
+	}
+	if cooked.destTemplate != "" {
+		return errors.New("partition-by cannot be combined with dest-template, since both decide where the files go. Use {year}, {month} and {day} in the template instead")
+	}
+	return nil
+}
+
+// changedSinceJobThreshold works out, from the details of an earlier job, the modification time from which files may have changed
+// since that job listed them. Jobs that didn't finish successfully are refused, since files they failed on would not be copied again.
+func changedSinceJobThreshold(priorJobID common.JobID, details common.GetJobDetailsResponse) (time.Time, error) {
+	if details.ErrorMsg != "" {
+		return time.Time{}, errors.New(details.ErrorMsg)
+	}
+
+	switch details.JobStatus {
+	case common.EJobStatus.Completed(), common.EJobStatus.CompletedWithSkipped():
+	default:
+		return time.Time{}, fmt.Errorf("job %s did not complete successfully (its status is %s), so files that it didn't copy would be missed. "+
+			"Use --%s instead, if that's really what you want", priorJobID, details.JobStatus, common.IncludeAfterFlagName)
+	}
+
+	if details.CommandStartTime.IsZero() {
+		// older plans only record when the first part was created, and files listed before then may have changed since
+		return time.Time{}, fmt.Errorf("job %s was created by an older version of AzCopy, which didn't record when it started. "+
+			"Use --%s with the ISO 8601 START TIME from its log instead", priorJobID, common.IncludeAfterFlagName)
+	}
+
+	// allow the same few seconds of leeway as the ISO 8601 START TIME in the job log
+	return details.CommandStartTime.Add(-5 * time.Second), nil
+}
+
+func validatePrecreateDirectories(precreate bool, cooked cookedCopyCmdArgs) error {
+	if !precreate {
+		return nil
+	}
+	// the source is listed once to find the directories, and again for the transfers, but a list of files can only be read once
+	if cooked.listOfFilesChannel != nil || cooked.listOfVersionIDs != nil {
+		return errors.New("precreate-directories cannot be combined with list-of-files, include-path or list-of-versions")
+	}
+	if cooked.destTemplate != "" {
+		return errors.New("precreate-directories cannot be combined with dest-template, since the template decides the directories")
+	}
+	if cooked.partitionBy != "" {
+		return errors.New("precreate-directories cannot be combined with partition-by, since the partitions decide the directories")
+	}
+	return nil
+}
+
+func validateCreateDirectoryMarkers(create bool, cooked cookedCopyCmdArgs) error {
+	if !create {
+		return nil
+	}
+	if cooked.fromTo != common.EFromTo.LocalBlob() && cooked.fromTo != common.EFromTo.BlobLocal() {
+		return errors.New("create-directory-markers only applies when uploading to, or downloading from, Blob storage")
+	}
+	if !cooked.recursive {
+		return errors.New("create-directory-markers requires recursive, since there are no directories to mark or recreate otherwise")
+	}
+	if cooked.fromTo.IsDownload() {
+		return nil
+	}
+	// as for precreate-directories, the source is listed once to find the directories, and again for the transfers
+	if cooked.listOfFilesChannel != nil {
+		return errors.New("create-directory-markers cannot be combined with list-of-files or include-path when uploading")
+	}
+	if cooked.destTemplate != "" || cooked.partitionBy != "" {
+		return errors.New("create-directory-markers cannot be combined with dest-template or partition-by, since they decide the directories")
+	}
+	return nil
+}
+
+func validatePreserveListingOrder(preserve bool, cooked cookedCopyCmdArgs) error {
+	if !preserve {
+		return nil
+	}
+	switch cooked.fromTo {
+	case common.EFromTo.LocalBlob(), common.EFromTo.LocalFile():
+		// the positions are worked out from the source path, which a wildcard would not be part of
+		if strings.Contains(cooked.source.ValueLocal(), "*") {
+			return errors.New("preserve-listing-order cannot be combined with a wildcard in the source")
+		}
+	case common.EFromTo.BlobLocal(), common.EFromTo.FileLocal():
+		// the files are created empty, in order, before they are downloaded, so the downloads must be allowed to overwrite them
+		if cooked.forceWrite != common.EOverwriteOption.True() {
+			return errors.New("preserve-listing-order requires overwrite=true when downloading, since the files are created before they are downloaded")
+		}
+		// the source is listed once to find the order, and again for the transfers, but a list of files can only be read once
+		if cooked.listOfFilesChannel != nil || cooked.listOfVersionIDs != nil {
+			return errors.New("preserve-listing-order cannot be combined with list-of-files, include-path or list-of-versions")
+		}
+		if cooked.casOutput || cooked.destTemplate != "" {
+			return errors.New("preserve-listing-order cannot be combined with cas-output or dest-template, since they decide where the files go")
+		}
+	default:
+		return errors.New("preserve-listing-order is only supported when uploading from the local file system to Blob or Azure Files, or downloading from them. " +
+			"For ADLS Gen2, use the Blob endpoint")
+	}
+	return nil
+}
+
+func validatePreserveHardLinks(preserve bool, cooked cookedCopyCmdArgs) error {
+	if !preserve {
+		return nil
+	}
+	if cooked.fromTo != common.EFromTo.LocalBlob() && cooked.fromTo != common.EFromTo.BlobLocal() {
+		return errors.New("preserve-hardlinks only applies when uploading to, or downloading from, Blob storage")
+	}
+	if !cooked.recursive {
+		return errors.New("preserve-hardlinks requires recursive, since the links are found among the files of a directory")
+	}
+	if cooked.fromTo.IsUpload() {
+		if runtime.GOOS == "windows" {
+			return errors.New("preserve-hardlinks is only supported on Linux and macOS when uploading")
+		}
+		return nil
+	}
+	// each link is recorded relative to where the file it's linked to was uploaded, so the files must be downloaded to the same relative paths
+	if cooked.casOutput || cooked.destTemplate != "" || cooked.partitionBy != "" {
+		return errors.New("preserve-hardlinks cannot be combined with cas-output, dest-template or partition-by when downloading, since they decide where the files go")
+	}
+	return nil
+}
+
 func validateMd5Option(option common.HashValidationOption, fromTo common.FromTo) error {
 	hasMd5Validation := option != common.DefaultHashValidationOption
 	if hasMd5Validation && !fromTo.IsDownload() {
@@ -852,6 +1984,40 @@ func isValidBlobTagsKeyValue(keyVal string) bool {
 	return true
 }
 
+// validateContentLanguage checks the value of the Content-Language header, which is a comma separated list of language tags.
+// Each tag is a primary subtag of 1 to 8 letters, optionally followed by hyphen separated subtags of 1 to 8 letters or digits,
+// e.g. "en", "en-US" or "zh-Hant-TW".
+func validateContentLanguage(contentLanguage string) error {
+	if contentLanguage == "" {
+		return nil
+	}
+	for _, tag := range strings.Split(contentLanguage, ",") {
+		if !isValidLanguageTag(strings.TrimSpace(tag)) {
+			return fmt.Errorf("content-language %q is not a comma separated list of language tags such as en-US", contentLanguage)
+		}
+	}
+	return nil
+}
+
+func isValidLanguageTag(tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for i, subtag := range strings.Split(tag, "-") {
+		if len(subtag) == 0 || len(subtag) > 8 {
+			return false
+		}
+		for _, c := range subtag {
+			isLetter := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+			isDigit := c >= '0' && c <= '9'
+			if !isLetter && (i == 0 || !isDigit) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // ValidateBlobTagsKeyValue
 // The tag set may contain at most 10 tags. Tag keys and values are case sensitive.
 // Tag keys must be between 1 and 128 characters, and tag values must be between 0 and 256 characters.
@@ -899,6 +2065,7 @@ type cookedCopyCmdArgs struct {
 	excludePathPatterns   []string
 	includeFileAttributes []string
 	excludeFileAttributes []string
+	priorityPatterns      []string // the files whose transfers are picked up ahead of those waiting
 	includeBefore         *time.Time
 	includeAfter          *time.Time
 
@@ -906,22 +2073,81 @@ type cookedCopyCmdArgs struct {
 	listOfVersionIDs chan string
 	// filters from flags
 	listOfFilesChannel chan string // Channels are nullable.
+	failedList         string      // where to write the files whose transfers failed, once the job is done, so that they can be retried
+	sha256Manifest     string      // where to write the SHA-256 of each file transferred, once the job is done
 	recursive          bool
 	stripTopDir        bool
 	followSymlinks     bool
 	forceWrite         common.OverwriteOption // says whether we should try to overwrite
 	forceIfReadOnly    bool                   // says whether we should _force_ any overwrites (triggered by forceWrite) to work on Azure Files objects that are set to read-only
 	autoDecompress     bool
+	skipLocked         bool // says whether source files that are locked by another process should be skipped, rather than failed
+	retryLocked        bool // says whether locked source files should be retried once, after the other transfers, before being skipped
+	progressBasis      common.ProgressBasis
+	normalizeUnicode   common.UnicodeNormalization // says which Unicode normalization form source names are converted to, to name destination files
+	maxTransfers       int                         // the number of files after which no more are queued, for sampling. Zero means no limit
+	lookahead          int                         // the most transfers that scanning may get ahead of those that are done. Zero means no limit
+	deadline           time.Time                   // when the job stops starting transfers, leaving the rest to be resumed. Zero means no deadline
+	prefixConcurrency  map[string]int              // the most transfers in flight under each destination prefix. Nil means no limits
+	shardByPrefix      int                         // the number of shards that the source's top-level directories are traversed by, in parallel. Zero means no sharding
+	precreateDirs      bool                        // create the destination's directory tree, in parallel, before scheduling the transfers
+
+	// upload an empty marker blob for each directory, or create a local directory for each marker that is downloaded
+	createDirMarkers bool
+
+	// record each uploaded file's position in its directory's listing, or create the downloaded files in the recorded order
+	preserveListingOrder bool
+
+	// record hard links as empty blobs, instead of uploading the files again, or recreate the links once the files are downloaded
+	preserveHardLinks bool
+	hardLinks         *hardLinks
+
+	// whether downloads refuse to start when the destination hasn't the space for them, and how many bytes must be left to spare
+	checkDiskSpace bool
+	reserveSpace   int64
+
+	// whether parts of the source listing that keep failing are skipped, and if so, what was skipped
+	continueOnEnumerationError bool
+	listingErrors              *listingErrorTolerance
+
+	// the files placed in the content-addressed store, whose manifest is written once the job is done. Nil unless casOutput is set
+	cas *casLayout
+
+	// whether the previous versions of each blob are copied too, and the most of each blob's versions to copy, newest first. Zero means all of them
+	includeVersions    bool
+	maxVersionsPerBlob int
+
+	// the throughput that the job is held to, if any, and the reason that the job was cancelled if it fell below it
+	throughputFloor        *throughputFloor
+	throughputFloorFailure string
 
 	// options from flags
 	blockSize int64
+	maxBlocks uint16 // when blockSize is 0, the number of blocks each blob must fit in, from which its block size is derived. Zero means the default sizing
 	// list of blobTypes to exclude while enumerating the transfer
 	excludeBlobType []azblob.BlobType
-	blobType        common.BlobType
+	// access tiers, as listed for each blob, to select blobs by
+	includeTier []azblob.AccessTierType
+	excludeTier []azblob.AccessTierType
+	skipEmpty   bool // says whether files with no content should be left out of the transfer
+	blobType    common.BlobType
+	// the content types, as patterns like image/*, that files must match one of. Empty means any
+	includeContentType []string
 	// Blob index tags categorize data in your storage account utilizing key-value tag attributes.
 	// These tags are automatically indexed and exposed as a queryable multi-dimensional index to easily find data.
-	blobTags                 common.BlobTags
+	blobTags common.BlobTags
+	// how the expiry of each blob written is set, and the time that it's set to (see common.BlobTransferAttributes.ExpiryTime)
+	expiryOption common.BlobExpiryOption
+	expiryTime   int64
+	// whether each blob written is checked to be encrypted, and with which encryption scope (if any) once it has been written
+	verifyEncryption         bool
+	verifyEncryptionScope    string
+	pageBlobSequenceNumber   int64 // the sequence number that page blobs are created with
+	preserveImmutability     bool  // whether each blob copied gets the immutability policy and legal hold of its source, once it has been written
+	blockIDPrefix            string
+	tierByAccessTime         ste.AccessTimeTiers
 	blockBlobTier            common.BlockBlobTier
+	blockBlobTierName        string // a tier that blockBlobTier has no value for, which is passed to the service as it is
 	pageBlobTier             common.PageBlobTier
 	metadata                 string
 	contentType              string
@@ -929,13 +2155,30 @@ type cookedCopyCmdArgs struct {
 	contentLanguage          string
 	contentDisposition       string
 	cacheControl             string
+	metadataRules            metadataRules     // per-file content headers, applied to uploads on top of the ones above
+	metadataFrom             *metadataManifest // per-file metadata, applied to uploads on top of the metadata above
 	noGuessMimeType          bool
 	preserveLastModifiedTime bool
 	deleteSnapshotsOption    common.DeleteSnapshotsOption
+	batchDelete              bool // when removing blobs, group the deletions into Blob Batch requests
+	requireSoftDelete        bool // when removing blobs, refuse to unless the account's soft delete is confirmed to be enabled
 	putMd5                   bool
+	deltaUpdate              bool // when uploading over an existing block blob, only send the blocks that have changed
+	clientSideEncryptionKey  common.ClientSideEncryptionKey
+	createDestination        bool // create the destination container/share/filesystem, if it is missing, before the transfers start
+	preflight                bool // check the destination for settings that affect every transfer before anything is scheduled
+	destinationPublicAccess  azblob.PublicAccessType
+	casOutput                bool   // download into a content-addressed store, keyed by the Content-MD5 of each file
+	casManifest              string // where to write the mapping of original names to content hashes, when casOutput is set
+	destTemplate             string // names each file's destination from placeholders, instead of from its path under the source
+	partitionBy              string // puts each uploaded file in a date partition, e.g. year=2021/month=03/day=07, ahead of its path under the source
+	checkNames               bool   // list the source first, and fail if any destination name breaks the destination's naming rules
+	sanitizeNames            bool   // percent-encode the parts of destination names that break the destination's naming rules
 	md5ValidationOption      common.HashValidationOption
 	CheckLength              bool
+	startJitter              time.Duration
 	logVerbosity             common.LogLevel
+	logFormat                common.LogFormat
 	// commandString hold the user given command which is logged to the Job log file
 	commandString string
 
@@ -949,8 +2192,9 @@ type cookedCopyCmdArgs struct {
 	// intervalStartTime holds the last time value when the progress summary was fetched
 	// the value of this variable is used to calculate the throughput
 	// it gets updated every time the progress summary is fetched
-	intervalStartTime        time.Time
-	intervalBytesTransferred uint64
+	intervalStartTime          time.Time
+	intervalBytesTransferred   uint64
+	intervalTransfersCompleted uint32
 
 	// used to calculate job summary
 	jobStartTime time.Time
@@ -963,6 +2207,23 @@ type cookedCopyCmdArgs struct {
 	preserveSMBPermissions common.PreservePermissionsOption
 	// Whether the user wants to preserve the SMB properties ...
 	preserveSMBInfo bool
+	// Whether the user wants to keep Windows file attributes in blob metadata, and restore them from it
+	preserveFileAttributes bool
+	// Whether the user wants to keep the POSIX properties of local files, and restore them
+	preservePOSIX bool
+	// Whether to set the modification time of each downloaded directory from its source, once the job is done
+	preserveDirectoryTimestamps bool
+	// Whether to set the read-only attribute of each file written to Azure Files
+	setReadOnly bool
+	// Whether to make no calls to Azure Files to get or set SMB properties or permissions, beyond those that create each file or folder
+	skipSMBInfo bool
+	// If not zero, the permission bits set on each downloaded file, and on each directory created for them
+	fileMode uint16
+	dirMode  uint16
+	// Whether to delete the blobs that the job created, once it is done, if any of its transfers failed
+	transactional bool
+	// How many transfers may fail without the blobs that the job created being deleted
+	transactionalFailureThreshold uint32
 
 	// Whether to enable Windows special privileges
 	backupMode bool
@@ -983,6 +2244,10 @@ type cookedCopyCmdArgs struct {
 	s2sSourceChangeValidation bool
 	// specify how user wants to handle invalid metadata.
 	s2sInvalidMetadataHandleOption common.InvalidMetadataHandleOption
+	// whether a copy between remote locations is done by the service, or through AzCopy
+	s2sPreference common.S2SPreference
+	// whether archived source blobs are rehydrated, and each transferred once it's online
+	rehydrateAndCopy bool
 
 	// followup/cleanup properties are NOT available on resume, and so should not be used for jobs that may be resumed
 	// TODO: consider find a way to enforce that, or else to allow them to be preserved. Initially, they are just for benchmark jobs, so not a problem immediately because those jobs can't be resumed, by design.
@@ -993,6 +2258,9 @@ type cookedCopyCmdArgs struct {
 
 	// whether to include blobs that have metadata 'hdi_isfolder = true'
 	includeDirectoryStubs bool
+
+	// the command whose standard input a BlobPipe download is written to, rather than to AzCopy's own standard output
+	pipeTo string
 }
 
 func (cca *cookedCopyCmdArgs) isRedirection() bool {
@@ -1023,6 +2291,17 @@ func (cca *cookedCopyCmdArgs) process() error {
 		// if no error, the operation is now complete
 		glcm.Exit(nil, common.EExitCode.Success())
 	}
+
+	if cca.fromTo == common.EFromTo.HttpBlob() {
+		streamed, err := cca.processHttpStreamCopyIfNeeded()
+		if err != nil {
+			return err
+		}
+
+		if streamed {
+			glcm.Exit(nil, common.EExitCode.Success())
+		}
+	}
 	return cca.processCopyJobPartOrders()
 }
 
@@ -1030,6 +2309,8 @@ func (cca *cookedCopyCmdArgs) process() error {
 func (cca *cookedCopyCmdArgs) processRedirectionCopy() error {
 	if cca.fromTo == common.EFromTo.PipeBlob() {
 		return cca.processRedirectionUpload(cca.destination, cca.blockSize)
+	} else if cca.fromTo == common.EFromTo.BlobPipe() && cca.pipeTo != "" {
+		return cca.processPipeToDownload(cca.source)
 	} else if cca.fromTo == common.EFromTo.BlobPipe() {
 		return cca.processRedirectionDownload(cca.source)
 	}
@@ -1166,29 +2447,56 @@ func (cca *cookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 		ForceWrite:      cca.forceWrite,
 		ForceIfReadOnly: cca.forceIfReadOnly,
 		AutoDecompress:  cca.autoDecompress,
+		SkipLocked:      cca.skipLocked,
+		RetryLocked:     cca.retryLocked,
+		ProgressBasis:   cca.progressBasis,
+		TrailingDot:     azcopyTrailingDot,
+		AppendOnly:      azcopyAppendOnly,
+		Transactional:   cca.transactional,
+		ComputeSha256:   cca.sha256Manifest != "",
 		Priority:        common.EJobPriority.Normal(),
 		LogLevel:        cca.logVerbosity,
+		LogFormat:       cca.logFormat,
 		ExcludeBlobType: cca.excludeBlobType,
 		BlobAttributes: common.BlobTransferAttributes{
 			BlobType:                 cca.blobType,
 			BlockSizeInBytes:         cca.blockSize,
+			MaxBlocksPerBlob:         cca.maxBlocks,
 			ContentType:              cca.contentType,
 			ContentEncoding:          cca.contentEncoding,
 			ContentLanguage:          cca.contentLanguage,
 			ContentDisposition:       cca.contentDisposition,
 			CacheControl:             cca.cacheControl,
 			BlockBlobTier:            cca.blockBlobTier,
+			BlockBlobTierName:        cca.blockBlobTierName,
 			PageBlobTier:             cca.pageBlobTier,
 			Metadata:                 cca.metadata,
 			NoGuessMimeType:          cca.noGuessMimeType,
 			PreserveLastModifiedTime: cca.preserveLastModifiedTime,
 			PutMd5:                   cca.putMd5,
+			DeltaUpdate:              cca.deltaUpdate,
 			MD5ValidationOption:      cca.md5ValidationOption,
 			DeleteSnapshotsOption:    cca.deleteSnapshotsOption,
 			BlobTagsString:           cca.blobTags.ToString(),
+			ExpiryOption:             cca.expiryOption,
+			ExpiryTime:               cca.expiryTime,
+			VerifyEncryption:         cca.verifyEncryption,
+			ExpectedEncryptionScope:  cca.verifyEncryptionScope,
+			PageBlobSequenceNumber:   cca.pageBlobSequenceNumber,
+			BlockIDPrefix:            cca.blockIDPrefix,
+			PreserveImmutability:     cca.preserveImmutability,
+			TierByAccessTime:         cca.tierByAccessTime.String(),
 		},
-		CommandString:  cca.commandString,
-		CredentialInfo: cca.credentialInfo,
+		CommandString:        cca.commandString,
+		CommandStartTime:     timeAtPrestart,
+		CredentialInfo:       cca.credentialInfo,
+		ChunkTimelinePath:    azcopyChunkTimelinePath,
+		EnumerationLookahead: cca.lookahead,
+		Deadline:             cca.deadline,
+		PrefixConcurrency:    cca.prefixConcurrency,
+
+		ClientSideEncryptionKey:       cca.clientSideEncryptionKey,
+		TransactionalFailureThreshold: cca.transactionalFailureThreshold,
 	}
 
 	from := cca.fromTo.From()
@@ -1225,6 +2533,7 @@ func (cca *cookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 		common.EFromTo.FileFile(),
 		common.EFromTo.BlobFile(),
 		common.EFromTo.S3Blob(),
+		common.EFromTo.HttpBlob(),
 		common.EFromTo.BenchmarkBlob(),
 		common.EFromTo.BenchmarkBlobFS(),
 		common.EFromTo.BenchmarkFile():
@@ -1284,6 +2593,7 @@ func (cca *cookedCopyCmdArgs) waitUntilJobCompletion(blocking bool) {
 	cca.jobStartTime = time.Now()
 	cca.intervalStartTime = time.Now()
 	cca.intervalBytesTransferred = 0
+	cca.intervalTransfersCompleted = 0
 
 	// hand over control to the lifecycle manager if blocking
 	if blocking {
@@ -1364,10 +2674,42 @@ func (cca *cookedCopyCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 	duration := time.Now().Sub(cca.jobStartTime) // report the total run time of the job
 
 	if jobDone {
+		if cca.failedList != "" {
+			if err := cca.writeFailedList(); err != nil {
+				glcm.Info(fmt.Sprintf("Cannot write the failed list %s: %s", cca.failedList, err.Error()))
+			}
+		}
+		if cca.sha256Manifest != "" {
+			if err := cca.writeSha256Manifest(); err != nil {
+				glcm.Info(fmt.Sprintf("Cannot write the SHA-256 manifest %s: %s", cca.sha256Manifest, err.Error()))
+			}
+		}
+		if cca.cas != nil {
+			if err := cca.writeCasManifest(); err != nil {
+				glcm.Info(fmt.Sprintf("Cannot write the content-addressed store manifest %s: %s", cca.casManifest, err.Error()))
+			}
+		}
+		// the parts of the source that couldn't be listed are named in the summary, so that they're shown even with --quiet
+		skippedListing := ""
+		if cca.listingErrors != nil {
+			skippedListing = formatSkippedListingRanges(cca.listingErrors.skippedRanges())
+		}
+		hardLinksFailed := 0
+		if cca.hardLinks != nil && cca.fromTo.IsDownload() {
+			hardLinksFailed = cca.hardLinks.recreateLinks(cca.destination.ValueLocal(), cca.forceWrite)
+		}
+
+		// the failures of the job that aren't failed transfers
+		otherFailures := (cca.metadataFrom != nil && cca.metadataFrom.malformedCount() > 0) || cca.throughputFloorFailure != "" || hardLinksFailed > 0 || skippedListing != ""
+
 		exitCode := cca.getSuccessExitCode()
-		if summary.TransfersFailed > 0 {
+		if summary.TransfersFailed > 0 || otherFailures {
 			exitCode = common.EExitCode.Error()
 		}
+		if summary.TransfersNotStartedByDeadline > 0 && exitCode == common.EExitCode.Error() &&
+			summary.TransfersFailed == summary.TransfersNotStartedByDeadline && !otherFailures {
+			exitCode = common.EExitCode.DeadlineReached() // nothing went wrong, there just wasn't time for everything
+		}
 
 		builder := func(format common.OutputFormat) string {
 			if format == common.EOutputFormat.Json() {
@@ -1387,9 +2729,9 @@ Number of Folder Property Transfers: %v
 Total Number of Transfers: %v
 Number of Transfers Completed: %v
 Number of Transfers Failed: %v
-Number of Transfers Skipped: %v
+Number of Transfers Skipped: %v%s%s%s
 TotalBytesTransferred: %v
-Final Job Status: %v%s%s
+Final Job Status: %v%s%s%s
 `,
 					summary.JobID.String(),
 					ste.ToFixed(duration.Minutes(), 4),
@@ -1399,11 +2741,22 @@ Final Job Status: %v%s%s
 					summary.TransfersCompleted,
 					summary.TransfersFailed,
 					summary.TransfersSkipped,
+					formatSkippedLockedStats(summary.TransfersSkippedLocked),
+					formatDeadlineStats(summary),
+					formatTransactionStats(summary),
 					summary.TotalBytesTransferred,
 					summary.JobStatus,
+					formatChunkTimeBreakdown(summary.ChunkTimeBreakdown)+formatConcurrencyHistory(summary.ConcurrencyHistory)+formatInterfaceBytes(summary.BytesByInterface),
 					screenStats,
 					formatPerfAdvice(summary.PerformanceAdvice))
 
+				if cca.throughputFloorFailure != "" {
+					output += cca.throughputFloorFailure + "\n"
+				}
+				if skippedListing != "" {
+					output += skippedListing + "\n"
+				}
+
 				// abbreviated output for cleanup jobs
 				if cca.isCleanupJob {
 					output = fmt.Sprintf("%s: %s)", cleanupStatusString, summary.JobStatus)
@@ -1427,16 +2780,22 @@ Final Job Status: %v%s%s
 		}
 	}
 
-	var computeThroughput = func() float64 {
-		// compute the average throughput for the last time interval
+	if cca.throughputFloor != nil && cca.throughputFloorFailure == "" {
+		cca.checkThroughputFloor(summary)
+	}
+
+	var computeThroughput = func() (throughput float64, transferRate float64) {
+		// compute the average throughput, and the rate at which transfers complete, for the last time interval
 		bytesInMb := float64(float64(summary.BytesOverWire-cca.intervalBytesTransferred) / float64(base10Mega))
+		transfersCompleted := float64(summary.TransfersCompleted - cca.intervalTransfersCompleted)
 		timeElapsed := time.Since(cca.intervalStartTime).Seconds()
 
-		// reset the interval timer and byte count
+		// reset the interval timer, byte count and transfer count
 		cca.intervalStartTime = time.Now()
 		cca.intervalBytesTransferred = summary.BytesOverWire
+		cca.intervalTransfersCompleted = summary.TransfersCompleted
 
-		return common.Iffloat64(timeElapsed != 0, bytesInMb/timeElapsed, 0) * 8
+		return common.Iffloat64(timeElapsed != 0, bytesInMb/timeElapsed, 0) * 8, common.Iffloat64(timeElapsed != 0, transfersCompleted/timeElapsed, 0)
 	}
 
 	glcm.Progress(func(format common.OutputFormat) string {
@@ -1457,22 +2816,28 @@ Final Job Status: %v%s%s
 				scanningString = ""
 			}
 
-			throughput := computeThroughput()
+			throughput, transferRate := computeThroughput()
 			throughputString := fmt.Sprintf("2-sec Throughput (Mb/s): %v", ste.ToFixed(throughput, 4))
 			if throughput == 0 {
 				// As there would be case when no bits sent from local, e.g. service side copy, when throughput = 0, hide it.
 				throughputString = ""
 			}
+			if cca.fromTo.To() == common.ELocation.Unknown() {
+				// deletions move no bytes, so report how quickly they are going instead
+				throughputString = fmt.Sprintf("2-sec Deletions/s: %v", ste.ToFixed(transferRate, 1))
+			}
 
 			// indicate whether constrained by disk or not
 			isBenchmark := cca.fromTo.From() == common.ELocation.Benchmark()
 			perfString, diskString := getPerfDisplayText(summary.PerfStrings, summary.PerfConstraint, duration, isBenchmark)
 
-			return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Skipped, %v Total%s, %s%s%s",
+			return fmt.Sprintf("%.1f %%%s, %v Done, %v Failed, %v Pending%s, %v Skipped, %v Total%s, %s%s%s",
 				summary.PercentComplete,
+				formatProgressBasis(summary.ProgressBasis),
 				summary.TransfersCompleted,
 				summary.TransfersFailed,
 				summary.TotalTransfers-(summary.TransfersCompleted+summary.TransfersFailed+summary.TransfersSkipped),
+				formatRehydrating(summary)+formatPrefixInFlight(summary),
 				summary.TransfersSkipped, summary.TotalTransfers, scanningString, perfString, throughputString, diskString)
 		}
 	})
@@ -1480,6 +2845,114 @@ Final Job Status: %v%s%s
 	return
 }
 
+// formatProgressBasis notes what the percentage complete is measured against, unless it's the default of bytes
+func formatProgressBasis(basis common.ProgressBasis) string {
+	switch basis {
+	case common.EProgressBasis.Files():
+		return " (by file count)"
+	case common.EProgressBasis.Auto():
+		return " (by bytes and file count)"
+	default:
+		return ""
+	}
+}
+
+// formatRehydrating notes how many of the pending transfers are waiting for their source to be rehydrated. Returns nothing if none are
+func formatRehydrating(summary common.ListJobSummaryResponse) string {
+	if summary.TransfersRehydrating == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%v Rehydrating)", summary.TransfersRehydrating)
+}
+
+// formatDeadlineStats reports the transfers that weren't started because the deadline passed, which remain for a resume to do
+func formatDeadlineStats(summary common.ListJobSummaryResponse) string {
+	if summary.TransfersNotStartedByDeadline == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nNumber of Transfers Not Started (Deadline Reached): %v. Run 'azcopy jobs resume %s' to transfer them",
+		summary.TransfersNotStartedByDeadline, summary.JobID)
+}
+
+// formatTransactionStats reports the requests sent against the cap of max-transactions, if there is one
+func formatTransactionStats(summary common.ListJobSummaryResponse) string {
+	if summary.MaxTransactions == 0 {
+		return ""
+	}
+	if summary.TransactionsIssued < summary.MaxTransactions {
+		return fmt.Sprintf("\nTransactions Issued: %v of %v", summary.TransactionsIssued, summary.MaxTransactions)
+	}
+	return fmt.Sprintf("\nTransactions Issued: %v of %v (Limit Reached). Run 'azcopy jobs resume %s' to transfer the rest",
+		summary.TransactionsIssued, summary.MaxTransactions, summary.JobID)
+}
+
+// parseDeadline parses the deadline given to copy. An ambiguous local time is taken as the earlier one, so as not to overrun the window
+func parseDeadline(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	deadline, err := parseISO8601(s, true)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid deadline: %s", err.Error())
+	}
+	if !deadline.After(now) {
+		return time.Time{}, fmt.Errorf("the deadline %s has already passed", formatAsUTC(deadline))
+	}
+	return deadline, nil
+}
+
+// format the count of transfers that were skipped because their source was locked. Returns nothing
+// if there weren't any, so that the summary is unchanged for the vast majority of jobs, which never see a locked file
+func formatSkippedLockedStats(skippedLocked uint32) string {
+	if skippedLocked == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nNumber of Transfers Skipped (Locked): %v", skippedLocked)
+}
+
+func formatChunkTimeBreakdown(b common.ChunkTimeBreakdown) string {
+	if b.IsEmpty() {
+		return ""
+	}
+	other := 100 - b.NetworkPercentage - b.DiskPercentage - b.RAMPercentage
+	if other < 0 {
+		other = 0 // rounding
+	}
+	return fmt.Sprintf("\nChunk Time Breakdown: Network %.2f%%, Disk %.2f%%, RAM %.2f%%, Other %.2f%%",
+		b.NetworkPercentage, b.DiskPercentage, b.RAMPercentage, other)
+}
+
+// formatConcurrencyHistory lists the concurrency values that the auto-tuner chose, with the number of seconds after the
+// start of tuning at which each was chosen, e.g. "4 (0s), 16 (4s), 8 (8s, backing off)"
+func formatConcurrencyHistory(history []common.ConcurrencyChange) string {
+	if len(history) == 0 {
+		return ""
+	}
+	values := make([]string, len(history))
+	for i, h := range history {
+		values[i] = fmt.Sprintf("%d (%ds)", h.Concurrency, h.ElapsedSeconds)
+		if h.Reason != "" && i > 0 && h.Reason != history[i-1].Reason {
+			values[i] = fmt.Sprintf("%d (%ds, %s)", h.Concurrency, h.ElapsedSeconds, h.Reason)
+		}
+	}
+	return "\nConcurrency Over Time: " + strings.Join(values, ", ")
+}
+
+// formatInterfaceBytes lists the bytes sent and received through each network interface, e.g. "eth0 (10.0.0.4) 1048576"
+func formatInterfaceBytes(byInterface []common.InterfaceBytes) string {
+	if len(byInterface) == 0 {
+		return ""
+	}
+	values := make([]string, len(byInterface))
+	for i, b := range byInterface {
+		values[i] = fmt.Sprintf("%s %d", b.Address, b.Bytes)
+		if b.Interface != "" {
+			values[i] = fmt.Sprintf("%s (%s) %d", b.Interface, b.Address, b.Bytes)
+		}
+	}
+	return "\nBytes By Network Interface: " + strings.Join(values, ", ")
+}
+
 func formatPerfAdvice(advice []common.PerformanceAdvice) string {
 	if len(advice) == 0 {
 		return ""
@@ -1576,6 +3049,9 @@ func init() {
 		Example:    copyCmdExample,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 1 { // redirection
+				if raw.fromTo == "" && raw.pipeTo != "" {
+					raw.fromTo = common.EFromTo.BlobPipe().String() // the only redirection that pipe-to supports
+				}
 				// Enforce the usage of from-to flag when pipes are involved
 				if raw.fromTo == "" {
 					return fmt.Errorf("fatal: from-to argument required, PipeBlob (upload) or BlobPipe (download) is acceptable")
@@ -1619,6 +3095,22 @@ func init() {
 				glcm.Error("failed to parse user input due to error: " + err.Error())
 			}
 
+			// only a job that would run is saved
+			if raw.exportJobDefinition != "" {
+				msg, err := exportJobDefinition(cmd, args, raw.exportJobDefinition)
+				if err != nil {
+					glcm.Error("cannot save the job definition due to error: " + err.Error())
+				}
+				glcm.Exit(func(format common.OutputFormat) string { return msg }, common.EExitCode.Success())
+			}
+
+			if raw.progressSocket != "" {
+				if err = glcm.SetProgressSocket(raw.progressSocket); err != nil {
+					glcm.Error("cannot stream progress to " + raw.progressSocket + " due to error: " + err.Error())
+				}
+			}
+
+			waitForStartJitter(cooked.startJitter)
 			glcm.Info("Scanning...")
 
 			cooked.commandString = copyHandlerUtil{}.ConstructCommandStringFromArgs()
@@ -1636,42 +3128,202 @@ func init() {
 	cpCmd.PersistentFlags().BoolVar(&raw.followSymlinks, "follow-symlinks", false, "Follow symbolic links when uploading from local file system.")
 	cpCmd.PersistentFlags().StringVar(&raw.includeBefore, common.IncludeBeforeFlagName, "", "Include only those files modified before or on the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone. As of AzCopy 10.7, this flag applies only to files, not folders, so folder properties won't be copied when using this flag with --preserve-smb-info or --preserve-smb-permissions.")
 	cpCmd.PersistentFlags().StringVar(&raw.includeAfter, common.IncludeAfterFlagName, "", "Include only those files modified on or after the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone. As of AzCopy 10.5, this flag applies only to files, not folders, so folder properties won't be copied when using this flag with --preserve-smb-info or --preserve-smb-permissions.")
+	cpCmd.PersistentFlags().BoolVar(&raw.allowSameLocation, "allow-same-location", false, "False by default. Allow the source and destination to be the same location, "+
+		"e.g. to rewrite blobs with new properties. Otherwise, AzCopy refuses to copy a location onto itself, since that's usually a mistake.")
+	cpCmd.PersistentFlags().DurationVar(&raw.startJitter, "start-jitter", 0, startJitterFlagHelp)
+	cpCmd.PersistentFlags().StringVar(&raw.exportJobDefinition, exportJobDefinitionFlag, "", exportJobDefinitionFlagHelp)
+	cpCmd.PersistentFlags().StringVar(&raw.pipeTo, "pipe-to", "", pipeToFlagHelp)
+	cpCmd.PersistentFlags().StringVar(&raw.changedSinceJob, "changed-since-job", "", "Copies only those files modified since the given earlier job started, for simple incremental copies. "+
+		"The earlier job must have completed without failures. Like --"+common.IncludeAfterFlagName+", this applies only to files, not folders.")
 	cpCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only these files when copying. "+
 		"This option supports wildcard characters (*). Separate files by using a ';'.")
+	cpCmd.PersistentFlags().StringVar(&raw.includeFrom, "include-from", "", "Include only the files that match the patterns in this file, as well as those of include-pattern. "+
+		"Each line holds one pattern, with the same syntax as include-pattern. Blank lines, and lines starting with #, are ignored.")
+	cpCmd.PersistentFlags().StringVar(&raw.priorityPattern, "priority-pattern", "", "Start these files before the others that are waiting, e.g. an index file that consumers wait for. "+
+		"Their transfers, and their chunks, are picked up ahead of those of other files that are still queued, even of other jobs, but transfers already under way aren't interrupted. This option supports wildcard characters (*), and applies to file names, as include-pattern does. Separate files by using a ';'.")
+	cpCmd.PersistentFlags().Float64Var(&raw.minThroughputMbps, "min-throughput-mbps", 0, "Cancel the job, and fail the command, if its throughput averages less than this many megabits per second over min-throughput-window, "+
+		"e.g. because the network is degraded. Throughput is only measured while transfers are under way, and not for the last of them, once what's left would be sent within a window. "+
+		"It's only checked once it has been measured for a whole window. If this option is set to zero, or it is omitted, throughput isn't checked.")
+	cpCmd.PersistentFlags().DurationVar(&raw.minThroughputWindow, "min-throughput-window", 5*time.Minute, "The window over which throughput is averaged for min-throughput-mbps, e.g. 10m. It must be at least "+shortestThroughputWindow.String()+".")
+	cpCmd.PersistentFlags().StringVar(&raw.minThroughputAction, "min-throughput-action", throughputFloorActionFail, "What to do when throughput falls below min-throughput-mbps: "+
+		throughputFloorActionFail+" cancels the job, and "+throughputFloorActionWarn+" only reports it, each time it happens, in the output and the log.")
 	cpCmd.PersistentFlags().StringVar(&raw.includePath, "include-path", "", "Include only these paths when copying. "+
 		"This option does not support wildcard characters (*). Checks relative path prefix (For example: myFolder;myFolder/subDirName/file.pdf).")
 	cpCmd.PersistentFlags().StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when copying. "+ // Currently, only exclude-path is supported alongside account traversal.
 		"This option does not support wildcard characters (*). Checks relative path prefix(For example: myFolder;myFolder/subDirName/file.pdf). When used in combination with account traversal, paths do not include the container name.")
 	// This flag is implemented only for Storage Explorer.
 	cpCmd.PersistentFlags().StringVar(&raw.listOfFilesToCopy, "list-of-files", "", "Defines the location of text file which has the list of only files to be copied.")
+	cpCmd.PersistentFlags().IntVar(&raw.listStartLine, "list-start-line", 0, "Skips the first N lines of the list-of-files file, to restart a list that was interrupted before its job was created, "+
+		"from the line where it stopped. Must leave at least one line of the list to copy.")
+	cpCmd.PersistentFlags().StringVar(&raw.failedList, "failed-list", "", "Once the job is done, write the files whose transfers failed to this path, one per line and relative to the source, "+
+		"so that they can be retried by passing it to list-of-files with the same source. It's written whether or not anything failed. "+
+		"If the path ends in .csv, a CSV file with each file's status and error code is written instead, for reporting.")
+	cpCmd.PersistentFlags().BoolVar(&raw.continueOnEnumerationError, "continue-on-enumeration-error", false, "False by default. When copying from Blob storage, "+
+		"if a page of the listing of the source keeps failing with a transient error, such as a timeout or throttling, ask for it again a few more times, and if it still fails, "+
+		"log it and carry on with the rest of the listing instead of failing the enumeration. Since the next page can only be found from the failed one, "+
+		"the rest of the virtual directory that it was in is skipped (or, if hierarchical listing is disabled, the rest of the listing). The parts skipped are reported in the summary of the job, and the job exits with an error if there are any. "+
+		"Can't be used with shard-by-prefix.")
+	cpCmd.PersistentFlags().BoolVar(&raw.includeVersions, "include-versions", false, "False by default. When copying a container or virtual directory from Blob storage, "+
+		"copy the previous versions of each blob too, as well as the blob itself. Each previous version is named for its version ID, followed by a hyphen and the name of the blob, "+
+		"next to where the blob itself is copied to. To copy chosen versions of a single blob, use list-of-versions instead.")
+	cpCmd.PersistentFlags().IntVar(&raw.maxVersionsPerBlob, "max-versions-per-blob", 0, "Used with --include-versions. Copy only this many of the newest versions of each blob, "+
+		"counting the current version if the blob still exists, to bound the size of the copy while keeping its recent history. (default 0, which means all versions).")
+	cpCmd.PersistentFlags().StringVar(&raw.sha256Manifest, "sha256-manifest", "", "Once the job is done, write the SHA-256 of each file transferred to this path, in the format of sha256sum, "+
+		"so that it can be checked with 'sha256sum -c' or handed on with the data. Paths are relative to the source. "+
+		"The hashes are computed from each file's data as it's read for upload, or saved on download, so the files aren't read again. "+
+		"Only supported when uploading or downloading, and not with decompress.")
 	cpCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude these files when copying. This option supports wildcard characters (*)")
+	cpCmd.PersistentFlags().StringVar(&raw.excludeFrom, "exclude-from", "", "Exclude the files that match the patterns in this file, as well as those of exclude-pattern. "+
+		"Each line holds one pattern, with the same syntax as exclude-pattern. Blank lines, and lines starting with #, are ignored.")
 	cpCmd.PersistentFlags().StringVar(&raw.forceWrite, "overwrite", "true", "Overwrite the conflicting files and blobs at the destination if this flag is set to true. (default 'true') Possible values include 'true', 'false', 'prompt', and 'ifSourceNewer'. For destinations that support folders, conflicting folder-level properties will be overwritten this flag is 'true' or if a positive response is provided to the prompt.")
-	cpCmd.PersistentFlags().BoolVar(&raw.autoDecompress, "decompress", false, "Automatically decompress files when downloading, if their content-encoding indicates that they are compressed. The supported content-encoding values are 'gzip' and 'deflate'. File extensions of '.gz'/'.gzip' or '.zz' aren't necessary, but will be removed if present.")
+	cpCmd.PersistentFlags().BoolVar(&raw.autoDecompress, "decompress", false, "Automatically decompress files when downloading, if their content-encoding indicates that they are compressed. The supported content-encoding values are 'gzip' (or 'x-gzip') and 'deflate'. Files with no content-encoding, or 'identity', are downloaded unchanged. File extensions of '.gz'/'.gzip' or '.zz' aren't necessary, but will be removed if present.")
+	cpCmd.PersistentFlags().BoolVar(&raw.skipLocked, "skip-locked", false, "When uploading, skip files that cannot be opened because another process has them locked (e.g. a sharing violation on Windows), instead of failing them. Skipped files are reported separately in the job summary. Files are only ever locked against reading on Windows.")
+	cpCmd.PersistentFlags().BoolVar(&raw.retryLocked, "retry-locked", false, "Used with --skip-locked. Retry each locked file once, after the other transfers have been started, before skipping it.")
+	cpCmd.PersistentFlags().StringVar(&raw.progressBasis, "progress-basis", common.EProgressBasis.Bytes().String(), "Specifies what the percentage complete is measured against. "+
+		"Available values include: Bytes, Files (the number of files, regardless of their size, which is more truthful when most files are small), and Auto (a blend of the two). (default 'Bytes')")
+	cpCmd.PersistentFlags().StringVar(&raw.progressSocket, "progress-socket", "", "Also stream the output records, one JSON object per line in the same format as --output-type=json, "+
+		"to a Unix domain socket created at this path (e.g. /tmp/azcopy.sock), so that a GUI can follow the job without parsing the console output. "+
+		"If the path is an existing named pipe (FIFO), the records are written to it instead, whenever it has a reader. "+
+		"Consumers may connect and disconnect at any time, and a consumer that stops reading is dropped; the transfer carries on regardless.")
+	cpCmd.PersistentFlags().StringVar(&raw.normalizeUnicode, "normalize-unicode", common.EUnicodeNormalization.None().String(), "Converts the names of source files to one Unicode normalization form when naming destination files, "+
+		"so that names which look the same but are encoded differently (e.g. the decomposed names written by macOS) are written the same way. If two source names are the same once converted, the job fails rather than transferring one over the other. Could be set to none, NFC, or NFD. (default 'none', which keeps names exactly as found).")
+	cpCmd.PersistentFlags().IntVar(&raw.maxTransfers, "max-transfers", 0, "Transfer only the first this many files found in the source. Scanning may carry on after that, but no more files are queued. "+
+		"Useful for trying out filters and destination settings on a sample of a large source. The files chosen are the first ones found, in the order the source is listed. (default 0, which means no limit).")
+	cpCmd.PersistentFlags().IntVar(&raw.lookahead, "enumeration-lookahead", 0, enumerationLookaheadFlagHelp)
+	cpCmd.PersistentFlags().StringVar(&raw.prefixConcurrency, "prefix-concurrency", "", prefixConcurrencyFlagHelp)
+	cpCmd.PersistentFlags().StringVar(&raw.deadline, "deadline", "", "Stops starting transfers at this time, given in ISO 8601 format (e.g. 2024-06-01T02:00:00Z), for jobs that must fit a maintenance window. "+
+		"Transfers in progress at the deadline finish, and the rest are reported as not started, so that 'azcopy jobs resume' transfers them. "+
+		"If any transfers weren't started, and nothing else failed, AzCopy exits with code 3. The source is still listed in full, so that the job can be resumed.")
+	cpCmd.PersistentFlags().IntVar(&raw.shardByPrefix, "shard-by-prefix", 0, "Split the listing of the source among this many shards, which run in parallel, by dealing out its top-level directories among them. "+
+		"Useful when listing a very large container or directory is what holds the job back. The shards all add to the one job, so they share its job ID, concurrency and summary, "+
+		"and it is resumed as usual. Only for Blob and local sources, listed recursively. (default 0, which means the source is listed as a whole).")
+	cpCmd.PersistentFlags().BoolVar(&raw.precreateDirs, "precreate-directories", false, "False by default. Create the whole directory tree at the destination, in parallel, before scheduling any files, "+
+		"rather than having each file create its parent directories as it is transferred. Suits deep hierarchies in Azure Files. The source is listed an extra time to find the directories. "+
+		"Has no effect for other destinations, such as Blob storage, whose directories don't need creating.")
+	cpCmd.PersistentFlags().BoolVar(&raw.createDirMarkers, "create-directory-markers", false, "False by default. When uploading to Blob storage, also create an empty marker blob for each directory, "+
+		"named for the directory with a trailing slash (e.g. dir/) and with the metadata hdi_isfolder=true, as Hadoop and Spark expect in a flat namespace. "+
+		"When downloading, recognize such markers and create the directories they stand for, even if empty, instead of downloading them as files. Requires recursive.")
+	cpCmd.PersistentFlags().BoolVar(&raw.preserveListingOrder, "preserve-listing-order", false, "False by default. When uploading to Blob or Azure Files, record each file's position in its directory's listing "+
+		"in its '"+listingOrderMetadataKey+"' metadata. When downloading files uploaded that way, create them in that order, empty, before downloading them, which requires overwrite=true. "+
+		"For tools that depend on the order in which a directory lists its files. This is best effort: the order is only meaningful on file systems that list a directory's entries "+
+		"in the order they were created, and files that already exist at the destination keep their place.")
+	cpCmd.PersistentFlags().BoolVar(&raw.preserveHardLinks, "preserve-hardlinks", false, "False by default. When uploading from Linux or macOS to Blob storage, find the files that are hard links to the same file "+
+		"(the same device and inode), upload the first of them as usual, and record each of the others as an empty blob whose '"+hardLinkMetadataKey+"' metadata gives the path of the first, "+
+		"relative to its own virtual directory. When downloading blobs uploaded that way, recreate the hard links, once the files they are linked to have been downloaded, "+
+		"instead of downloading the empty blobs. The links are recreated when the job is done, so a resumed job doesn't recreate them. Requires recursive.")
+	cpCmd.PersistentFlags().BoolVar(&raw.checkDiskSpace, "check-disk-space", true, "True by default. Before a download starts, list the source to add up its size, and refuse to start if the destination's volume hasn't that much free space. "+
+		"Files that already exist at the destination only need the space by which the new ones are bigger. Set it to false to skip the extra listing, or to start anyway. "+
+		"It's skipped when the destination is "+common.Dev_Null+", and with list-of-files, include-path or list-of-versions, since those lists can only be read once.")
+	cpCmd.PersistentFlags().Int64Var(&raw.reserveSpaceMB, "reserve-space-mb", 0, "The space, in MiB, that must still be free at the destination once a download is done, as checked by check-disk-space. 0 by default.")
 	cpCmd.PersistentFlags().BoolVar(&raw.recursive, "recursive", false, "Look into sub-directories recursively when uploading from local file system.")
 	cpCmd.PersistentFlags().StringVar(&raw.fromTo, "from-to", "", "Optionally specifies the source destination combination. For Example: LocalBlob, BlobLocal, LocalBlobFS. Piping: BlobPipe, PipeBlob")
 	cpCmd.PersistentFlags().StringVar(&raw.excludeBlobType, "exclude-blob-type", "", "Optionally specifies the type of blob (BlockBlob/ PageBlob/ AppendBlob) to exclude when copying blobs from the container "+
 		"or the account. Use of this flag is not applicable for copying data from non azure-service to service. More than one blob should be separated by ';'. ")
+	cpCmd.PersistentFlags().StringVar(&raw.includeTier, "include-tier", "", "Optionally specifies the access tiers (e.g. Hot,Cool) of the blobs to copy, as listed by the service; blobs in other tiers, or without one, are left out. "+
+		"Separate the tiers with ',' or ';'. Only available when the source is Blob storage.")
+	cpCmd.PersistentFlags().StringVar(&raw.excludeTier, "exclude-tier", "", "Optionally specifies the access tiers (e.g. Archive) of the blobs not to copy, as listed by the service. "+
+		"Excluding Archive avoids failing on each archived blob, which can't be read until it is rehydrated. Separate the tiers with ',' or ';'. Only available when the source is Blob storage.")
+	cpCmd.PersistentFlags().StringVar(&raw.includeContentType, "include-content-type", "", "Only transfer files whose content type is one of these, e.g. image/*,application/pdf, where * matches any type or subtype. "+
+		"Separate the types with ',' or ';'. Blobs are matched by the Content-Type they're stored with; local files by the content type that uploading them would give them, "+
+		"which is guessed from the extension or, failing that, the file's first bytes. Each file that is left out is logged with its content type. Only available when uploading, or when the source is Blob storage.")
+	cpCmd.PersistentFlags().BoolVar(&raw.skipEmpty, "skip-empty", false, "Leave out files and blobs that are empty (zero bytes), for example when they are only placeholders. Folders are not affected. (By default, empty files are transferred as empty files.) "+
+		"Only supported by copy: sync applies its filters to the destination too, where leaving out empty files would make them look like extra files to delete.")
 	// options change how the transfers are performed
+	cpCmd.PersistentFlags().IntVar(&raw.maxBlocks, "max-blocks", 0, fmt.Sprintf("Derive the block size of each block blob from its file's size, so that it has at most this many blocks (up to %d). "+
+		"Cannot be used together with block-size-mb.", common.MaxNumberOfBlocksPerBlob))
 	cpCmd.PersistentFlags().Float64Var(&raw.blockSizeMB, "block-size-mb", 0, "Use this block size (specified in MiB) when uploading to Azure Storage, and downloading from Azure Storage. The default value is automatically calculated based on file size. Decimal fractions are allowed (For example: 0.25).")
+	cpCmd.PersistentFlags().Float64Var(&raw.s2sBlockSizeMB, "s2s-block-size-mb", 0, fmt.Sprintf("Use this block size (specified in MiB) for server-side copies, such as from Blob storage to Blob storage, in which the service reads each block from the source with Put Block From URL. "+
+		"Up to %d MiB. When it's not given, block-size-mb (or the automatically calculated size) is used for server-side copies too, so scripts can give both, to tune uploads and server-side copies separately. "+
+		"Other jobs, including copies between remote locations with s2s=never, ignore it.", common.MaxPutBlockFromURLBlockSize/(1024*1024)))
 	cpCmd.PersistentFlags().StringVar(&raw.logVerbosity, "log-level", "INFO", "Define the log verbosity for the log file, available levels: INFO(all requests/responses), WARNING(slow responses), ERROR(only failed requests), and NONE(no output logs). (default 'INFO').")
+	cpCmd.PersistentFlags().StringVar(&raw.logFormat, "log-format", "text", "Define the format of the log file, available formats: text, and json (one JSON object per entry, with level, timestamp, job ID, transfer path, request ID, error code and message fields). (default 'text').")
 	cpCmd.PersistentFlags().StringVar(&raw.blobType, "blob-type", "Detect", "Defines the type of blob at the destination. This is used for uploading blobs and when copying between accounts (default 'Detect'). Valid values include 'Detect', 'BlockBlob', 'PageBlob', and 'AppendBlob'. "+
 		"When copying between accounts, a value of 'Detect' causes AzCopy to use the type of source blob to determine the type of the destination blob. When uploading a file, 'Detect' determines if the file is a VHD or a VHDX file based on the file extension. If the file is either a VHD or VHDX file, AzCopy treats the file as a page blob.")
-	cpCmd.PersistentFlags().StringVar(&raw.blockBlobTier, "block-blob-tier", "None", "upload block blob to Azure Storage using this blob tier.")
+	cpCmd.PersistentFlags().StringVar(&raw.blockBlobTier, "block-blob-tier", "None", "upload block blob to Azure Storage using this blob tier, such as Hot, Cool, Cold or Archive. "+
+		"A tier that AzCopy doesn't know of, such as one added to the service since this release, is passed to the service as it is, and the service decides whether it's valid.")
+	cpCmd.PersistentFlags().StringVar(&raw.tierByAccessTime, "tier-by-access-time", "", "When copying from Blob storage to Blob storage, choose the tier of each block blob by when its source was last accessed, "+
+		"with rules such as 90d=Archive,30d=Cool: a blob whose source was last accessed at least 90 days ago is archived, one last accessed at least 30 days ago is made Cool, "+
+		"and others get the tier they would otherwise get. Ages are in whole days, and the longest age that applies wins. "+
+		"Access times are only tracked if the source account has last access time tracking enabled; otherwise, or for blobs not accessed since it was, the last modified time is used. "+
+		"Cannot be combined with block-blob-tier.")
 	cpCmd.PersistentFlags().StringVar(&raw.pageBlobTier, "page-blob-tier", "None", "Upload page blob to Azure Storage using this blob tier. (default 'None').")
 	cpCmd.PersistentFlags().StringVar(&raw.metadata, "metadata", "", "Upload to Azure Storage with these key-value pairs as metadata.")
 	cpCmd.PersistentFlags().StringVar(&raw.contentType, "content-type", "", "Specifies the content type of the file. Implies no-guess-mime-type. Returned on download.")
 	cpCmd.PersistentFlags().StringVar(&raw.contentEncoding, "content-encoding", "", "Set the content-encoding header. Returned on download.")
 	cpCmd.PersistentFlags().StringVar(&raw.contentDisposition, "content-disposition", "", "Set the content-disposition header. Returned on download.")
-	cpCmd.PersistentFlags().StringVar(&raw.contentLanguage, "content-language", "", "Set the content-language header. Returned on download.")
+	cpCmd.PersistentFlags().StringVar(&raw.contentLanguage, "content-language", "", "Set the content-language header, a comma separated list of language tags such as 'en-US'. Returned on download.")
 	cpCmd.PersistentFlags().StringVar(&raw.cacheControl, "cache-control", "", "Set the cache-control header. Returned on download.")
+	cpCmd.PersistentFlags().StringVar(&raw.metadataRules, "metadata-rules", "", "Path to a YAML (.yaml or .yml) or JSON file listing rules, in order, each with a 'pattern' and any of 'contentType', 'cacheControl', 'contentEncoding' and 'contentLanguage'. "+
+		"Each uploaded file gets the headers of the first rule whose pattern matches it; a pattern containing '/' is matched against the path relative to the source, otherwise against the file name. "+
+		"The content-type, cache-control, content-encoding and content-language flags take precedence over the rules, and a rule's content type takes precedence over the one AzCopy would otherwise guess.")
+	cpCmd.PersistentFlags().StringVar(&raw.metadataFrom, "metadata-from", "", "Path to a JSON file that maps the paths of files, relative to the source, to their metadata, for example {\"images/cat.jpg\": {\"animal\": \"cat\"}}. "+
+		"Each uploaded file with an entry gets that metadata, on top of any given by the metadata flag (the entry wins where both give the same key). Files without an entry get just the metadata flag's. "+
+		"A file whose entry is malformed, or has keys that aren't valid metadata names, fails and isn't transferred; the rest of the job goes ahead.")
 	cpCmd.PersistentFlags().BoolVar(&raw.noGuessMimeType, "no-guess-mime-type", false, "Prevents AzCopy from detecting the content-type based on the extension or content of the file.")
 	cpCmd.PersistentFlags().BoolVar(&raw.preserveLastModifiedTime, "preserve-last-modified-time", false, "Only available when destination is file system.")
 	cpCmd.PersistentFlags().BoolVar(&raw.preserveSMBPermissions, "preserve-smb-permissions", false, "False by default. Preserves SMB ACLs between aware resources (Windows and Azure Files). For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
 	cpCmd.PersistentFlags().BoolVar(&raw.preserveOwner, common.PreserveOwnerFlagName, common.PreserveOwnerDefault, "Only has an effect in downloads, and only when --preserve-smb-permissions is used. If true (the default), the file Owner and Group are preserved in downloads. If set to false, --preserve-smb-permissions will still preserve ACLs but Owner and Group will be based on the user running AzCopy")
 	cpCmd.PersistentFlags().BoolVar(&raw.preserveSMBInfo, "preserve-smb-info", false, "False by default. Preserves SMB property info (last write time, creation time, attribute bits) between SMB-aware resources (Windows and Azure Files). Only the attribute bits supported by Azure Files will be transferred; any others will be ignored. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern). The info transferred for folders is the same as that for files, except for Last Write Time which is never preserved for folders.")
+	cpCmd.PersistentFlags().BoolVar(&raw.preserveFileAttributes, common.PreserveFileAttributesFlagName, false, "False by default. When uploading from Windows to Blob storage, keeps each file's attributes (e.g. hidden, system, read-only and archive) in the blob's metadata. "+
+		"When downloading from Blob storage to Windows, restores the attributes kept in the metadata. Ignored, with a note, when downloading to other operating systems. For Azure Files, use preserve-smb-info instead.")
+	cpCmd.PersistentFlags().BoolVar(&raw.preservePOSIX, common.PreservePOSIXFlagName, false, "False by default. Only on Linux and macOS. When uploading, keeps each file's mode (permission bits, including setuid, setgid and sticky), "+
+		"owner and group (as numeric IDs), and modification time. Blob storage and Azure Files keep all four in the file's metadata. ADLS Gen2 applies the mode to the file's permissions, "+
+		"and keeps all four in the file's properties, since its owners are Azure AD identities rather than numeric IDs. When downloading from Blob storage or Azure Files, restores what was kept in the metadata; "+
+		"the owner and group are only restored when running as root. Folders are not affected.")
+	cpCmd.PersistentFlags().BoolVar(&raw.preserveDirectoryTimestamps, "preserve-directory-timestamps", false, "False by default. When downloading from Azure Files or ADLS Gen2, sets the modification time of each directory to that of its source. "+
+		"This is done once all the files have been written, since writing them changes the time. Requires recursive, and has no effect if a file-only filter is specified (e.g. include-pattern), since then folders are not processed.")
+	cpCmd.PersistentFlags().BoolVar(&raw.transactional, "transactional", false, "False by default. When uploading to Blob storage, if more files fail than transactional-failure-threshold allows, deletes the blobs that the job created once it is done, "+
+		"so that a failed publish leaves nothing new behind. Blobs that already existed and were overwritten are not restored. The rolled back files are reported as failed, "+
+		"so resuming the job uploads them again. Checks whether each blob exists before uploading it, to know whether it is new.")
+	cpCmd.PersistentFlags().Uint32Var(&raw.transactionalFailureThreshold, "transactional-failure-threshold", 0, "The number of files of a transactional upload that may fail without it being rolled back. "+
+		"(default 0, which rolls the upload back if any file fails)")
+	cpCmd.PersistentFlags().BoolVar(&raw.setReadOnly, "set-readonly", false, "False by default. When copying to Azure Files, sets the read-only attribute of each file once its content has been written. "+
+		"Combined with preserve-smb-permissions, this approximates a write-once posture, but it is not true WORM storage: anyone with write access to the share can clear the attribute, "+
+		"and later overwrites by AzCopy succeed if force-if-read-only is given. Folders are not affected.")
+	cpCmd.PersistentFlags().BoolVar(&raw.skipSMBInfo, "skip-smb-info", false, "False by default. When copying to Azure Files, make no calls to get or set SMB properties or permissions, "+
+		"beyond those that create each file and folder, to save round trips on plain data copies. In particular, the attributes of each file and the properties of each folder aren't set after it's created, "+
+		"so they keep the attributes and times the service gave them. Can't be combined with preserve-smb-info, preserve-smb-permissions, set-readonly or force-if-read-only.")
+	cpCmd.PersistentFlags().StringVar(&raw.fileMode, "file-mode", "", "Only on Linux and macOS, when downloading. Sets the permission bits of each downloaded file to this octal mode (e.g. 0640), "+
+		"regardless of the process umask. Can't be combined with "+common.PreservePOSIXFlagName+".")
+	cpCmd.PersistentFlags().StringVar(&raw.dirMode, "dir-mode", "", "Only on Linux and macOS, when downloading. Sets the permission bits of each directory the download creates to this octal mode (e.g. 0750), "+
+		"regardless of the process umask. Directories that already existed are left as they are. Can't be combined with "+common.PreservePOSIXFlagName+".")
 	cpCmd.PersistentFlags().BoolVar(&raw.forceIfReadOnly, "force-if-read-only", false, "When overwriting an existing file on Windows or Azure Files, force the overwrite to work even if the existing file has its read-only attribute set")
 	cpCmd.PersistentFlags().BoolVar(&raw.backupMode, common.BackupModeFlagName, false, "Activates Windows' SeBackupPrivilege for uploads, or SeRestorePrivilege for downloads, to allow AzCopy to see read all files, regardless of their file system permissions, and to restore all permissions. Requires that the account running AzCopy already has these permissions (e.g. has Administrator rights or is a member of the 'Backup Operators' group). All this flag does is activate privileges that the account already has")
 	cpCmd.PersistentFlags().BoolVar(&raw.putMd5, "put-md5", false, "Create an MD5 hash of each file, and save the hash as the Content-MD5 property of the destination blob or file. (By default the hash is NOT created.) Only available when uploading.")
+	cpCmd.PersistentFlags().BoolVar(&raw.createDestination, "create-destination", false, "False by default. Create the destination container, file share or file system named in the destination URL, if it doesn't exist yet, before any transfers start. "+
+		"If it cannot be created, the job stops with an error that says why, rather than every transfer failing.")
+	cpCmd.PersistentFlags().StringVar(&raw.destinationPublicAccess, "destination-public-access", "none", "Used with create-destination, when copying to Blob storage. The level of anonymous public read access of the container, if it is created. "+
+		"Available values include: none, blob (anonymous read of blobs only), and container (anonymous read and listing of the container). (default 'none').")
+	cpCmd.PersistentFlags().BoolVar(&raw.preflight, "preflight", false, "False by default. Before anything is scheduled, check the destination for settings that affect every transfer, and report them up front. "+
+		"When copying to Blob storage, reports the default encryption scope of the destination container, which the blobs copied get since AzCopy doesn't ask for a scope, and whether the container denies other scopes.")
+	cpCmd.PersistentFlags().BoolVar(&raw.deltaUpdate, "upload-changed-blocks", false, "False by default. When uploading a file over an existing block blob, compare each block of the file with the block at the same position in the blob, "+
+		"and only upload the blocks whose content has changed. This suits large files that are modified in place, such as disk images and databases. "+
+		"Each block is given an ID made from its content, so blocks are compared by their IDs, without downloading the blob. Blocks are only matched at the same position: "+
+		"this is not a rolling comparison, so inserting or removing bytes in a file causes every block after that point to be uploaded again. "+
+		"Only blobs that were uploaded with upload-changed-blocks, and with the same block-id-prefix, can be compared; otherwise, or if the blob doesn't exist, the whole file is uploaded. The blob's own block size is used.")
+	cpCmd.PersistentFlags().StringVar(&raw.clientSideEncryptionKey, "client-side-encryption-key", "", "The path of a file holding a 256-bit key, base64 encoded, with which to encrypt each file before it's uploaded to Blob storage, and decrypt each blob that was encrypted with it when it's downloaded. "+
+		"Unlike customer-provided keys, the service never sees the content unencrypted. Blobs are written in the version 2 client-side encryption format of the Azure Storage SDKs, with the content key, wrapped by this key, in the blob's metadata. "+
+		"The key isn't kept in the job's plan, so it must be given again to jobs resume. Keys held in Azure Key Vault can't be used directly: export the key to a file instead, "+
+		"and to decrypt blobs it encrypted, give the file of the same key.")
+	cpCmd.PersistentFlags().StringVar(&raw.clientSideKeyID, "client-side-encryption-key-id", "", "The key ID to record in the envelope of each blob encrypted with client-side-encryption-key, such as the key's Key Vault URL, "+
+		"so that other tools know which key to unwrap its content key with. The name of the key file by default. It isn't checked when decrypting.")
+	cpCmd.PersistentFlags().BoolVar(&raw.casOutput, "cas-output", false, "Download into a content-addressed layout, where each file is stored once, as <hash[0:2]>/<hash> under the destination, keyed by the Content-MD5 of its source. "+
+		"Files whose source has no Content-MD5 are not downloaded. Requires cas-manifest, and check-md5 of FailIfDifferent (the default) or FailIfDifferentOrMissing.")
+	cpCmd.PersistentFlags().StringVar(&raw.casManifest, "cas-manifest", "", "With cas-output, the path of a JSON file to write once the job is done, mapping the name of each file (relative to the destination, as it would have been without cas-output) to its hash. "+
+		"Files whose content didn't make it into the store, such as those whose download failed, are left out.")
+	cpCmd.PersistentFlags().StringVar(&raw.destTemplate, "dest-template", "", "Name each file's destination, relative to the destination directory, from a template instead of from its path under the source. For example: {year}/{month}/{name}. "+
+		"Placeholders are {name}, {base} (name without extension), {ext}, {dir} (the file's directory under the source), {size} (small: under 1 MiB, medium: under 128 MiB, large: under 1 GiB, or huge), and {year}, {month} and {day} of the upload (UTC). "+
+		"If the template gives two files the same path, only the first is transferred, and the others are reported. Folders are not transferred.")
+	cpCmd.PersistentFlags().StringVar(&raw.partitionBy, "partition-by", "", "When uploading, puts each file in a Hive-style date partition ahead of its path under the source, e.g. year=2021/month=03/day=07/logs/app.log, "+
+		"ready for analytics engines. Given as basis:granularity, e.g. mtime:daily. The basis is mtime (each file's modification time) or upload-time (when the job started), "+
+		"and the granularity is hourly, daily or monthly. Dates are in UTC. Folders are not transferred.")
+	cpCmd.PersistentFlags().BoolVar(&raw.checkNames, "check-names", false, "False by default. Before transferring anything, lists the source and checks the name each file and folder will have "+
+		"against the naming rules of Blob storage, ADLS Gen2 or Azure Files: no control characters, no '.' or '..' segments, at most 1024 characters for a blob and no dot at its end, "+
+		"or for Azure Files at most 2048 characters, at most 255 in each directory or file name, and none ending in a space or dot. "+
+		"If any name breaks a rule, all such names are reported, with the rules they break, and nothing is transferred.")
+	cpCmd.PersistentFlags().BoolVar(&raw.sanitizeNames, "sanitize-names", false, "False by default. When copying to Blob storage, ADLS Gen2 or Azure Files, fixes destination names that break the naming rules "+
+		"by percent-encoding the characters that break them: each control character becomes %XX (e.g. a tab becomes %09), a '.' or '..' segment becomes %2E or %2E%2E, "+
+		"and a trailing dot becomes %2E (for Azure Files, trailing dots and spaces in each directory or file name become %2E and %20). Names that are too long are left as they are.")
 	cpCmd.PersistentFlags().StringVar(&raw.md5ValidationOption, "check-md5", common.DefaultHashValidationOption.String(), "Specifies how strictly MD5 hashes should be validated when downloading. Only available when downloading. Available options: NoCheck, LogOnly, FailIfDifferent, FailIfDifferentOrMissing. (default 'FailIfDifferent')")
 	cpCmd.PersistentFlags().StringVar(&raw.includeFileAttributes, "include-attributes", "", "(Windows only) Include files whose attributes match the attribute list. For example: A;S;R")
 	cpCmd.PersistentFlags().StringVar(&raw.excludeFileAttributes, "exclude-attributes", "", "(Windows only) Exclude files whose attributes match the attribute list. For example: A;S;R")
@@ -1680,7 +3332,35 @@ func init() {
 		"For AWS S3 and Azure File non-single file source, the list operation doesn't return full properties of objects and files. To preserve full properties, AzCopy needs to send one additional request per object or file.")
 	cpCmd.PersistentFlags().BoolVar(&raw.s2sPreserveAccessTier, "s2s-preserve-access-tier", true, "Preserve access tier during service to service copy. "+
 		"Please refer to [Azure Blob storage: hot, cool, and archive access tiers](https://docs.microsoft.com/azure/storage/blobs/storage-blob-storage-tiers) to ensure destination storage account supports setting access tier. "+
-		"In the cases that setting access tier is not supported, please use s2sPreserveAccessTier=false to bypass copying access tier. (default true). ")
+		"In the cases that setting access tier is not supported, please use s2sPreserveAccessTier=false to bypass copying access tier. "+
+		"Tiers that AzCopy doesn't know of, such as ones added to the service since this release, are preserved as they are listed. (default true). ")
+	cpCmd.PersistentFlags().StringVar(&raw.expiry, "expiry", "", "Sets the expiry of each block blob once it has been written, after which the service deletes it. "+
+		"Could be RelativeToNow:<duration> (e.g. RelativeToNow:30d or RelativeToNow:12h), Absolute:<time> (an ISO 8601 time, which may also be given on its own), or NeverExpire to remove an existing expiry. "+
+		"Only accounts with a hierarchical namespace (ADLS Gen2) support expiry; elsewhere, AzCopy says so once and writes the blobs without it.")
+	cpCmd.PersistentFlags().BoolVar(&raw.s2sPreserveExpiry, "s2s-preserve-expiry", false, "False by default. When copying from Blob storage to Blob storage, sets the expiry of each block blob to that of its source, if the source has one. "+
+		"The listing of the source doesn't include expiry, so AzCopy sends one additional request per blob to get it.")
+	cpCmd.PersistentFlags().BoolVar(&raw.verifyEncryption, "verify-encryption", false, "False by default. Once each blob has been written, check that the service reports it as encrypted (x-ms-server-encrypted), and fail the transfer if it doesn't. "+
+		"This sends one additional request per blob.")
+	cpCmd.PersistentFlags().StringVar(&raw.verifyEncryptionScope, "verify-encryption-scope", "", "Like verify-encryption, and also check that each blob is encrypted with this encryption scope (x-ms-encryption-scope), "+
+		"such as the default encryption scope of the destination container. Transfers of blobs encrypted otherwise fail. "+
+		"Since blobs get the default encryption scope of their container, the copy fails before anything is transferred if the destination container's is another scope.")
+	cpCmd.PersistentFlags().BoolVar(&raw.preserveImmutability, "preserve-immutability", false, "False by default. When copying from Blob storage to Blob storage, gives each blob the immutability policy "+
+		"and legal hold of its source, once its content, properties and metadata have been written, for migrating WORM data. The destination container must have version-level immutability support enabled; "+
+		"if it doesn't, the copy fails before anything is transferred. The listing of the source doesn't include immutability, so AzCopy sends additional requests per blob to get and set it.")
+	cpCmd.PersistentFlags().Int64Var(&raw.pageBlobSequenceNumber, "page-blob-sequence-number", 0, "Creates each page blob with this sequence number (x-ms-blob-sequence-number), "+
+		"e.g. for disk images whose users rely on it to tell generations apart. Applies only to page blobs. (default 0, the same as the service's default).")
+	cpCmd.PersistentFlags().StringVar(&raw.blockIDPrefix, "block-id-prefix", "", "Advanced. Starts the ID of each block staged with this prefix, followed by the block index with leading zeros (e.g. myprefix00042-), "+
+		"so that other tools which read the uncommitted blocks of a blob can recognize AzCopy's blocks and sort them into order. "+
+		fmt.Sprintf("At most %d letters, digits, '-', '_' or '.'. Applies only to block blobs.", ste.BlockIDPrefixMaxBytes))
+	cpCmd.PersistentFlags().StringVar(&raw.s2sPreference, "s2s", "prefer", "Whether a copy between two remote locations is done by the service, server-to-server (S2S). "+
+		"Available options include: prefer, never and require. 'prefer' copies server-to-server, as AzCopy always has. 'never' downloads the data through AzCopy and uploads it again, "+
+		"e.g. when server-to-server copies are blocked by network policy; it costs the bandwidth of both the download and the upload. "+
+		"'require' fails unless the copy can be done server-to-server, rather than through AzCopy.")
+	cpCmd.PersistentFlags().BoolVar(&raw.rehydrateAndCopy, "rehydrate-and-copy", false, "False by default. When the source is Blob storage, rehydrate each archived blob to the Hot tier, "+
+		"and transfer it once it's online, all in the one job, instead of failing it. Each archived blob is checked every few minutes while it's rehydrated, which can take hours, "+
+		"and the progress shows how many are still rehydrating. If the job is interrupted, resuming it carries on waiting for those not yet done. "+
+		"The source must allow its tier to be set, e.g. a SAS with write permission. When copying between accounts, consider --s2s-preserve-access-tier=false, "+
+		"so that the copies aren't archived in turn.")
 	cpCmd.PersistentFlags().BoolVar(&raw.s2sSourceChangeValidation, "s2s-detect-source-changed", false, "Detect if the source file/blob changes while it is being read. (This parameter only applies to service to service copies, because the corresponding check is permanently enabled for uploads and downloads.)")
 	cpCmd.PersistentFlags().StringVar(&raw.s2sInvalidMetadataHandleOption, "s2s-handle-invalid-metadata", common.DefaultInvalidMetadataHandleOption.String(), "Specifies how invalid metadata keys are handled. Available options: ExcludeIfInvalid, FailIfInvalid, RenameIfInvalid. (default 'ExcludeIfInvalid').")
 	cpCmd.PersistentFlags().StringVar(&raw.listOfVersionIDs, "list-of-versions", "", "Specifies a file where each version id is listed on a separate line. Ensure that the source must point to a single blob and all the version ids specified in the file using this flag must belong to the source blob only. AzCopy will download the specified versions in the destination folder provided.")
@@ -5,18 +5,21 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/azure-storage-file-go/azfile"
 
+	"github.com/Azure/azure-storage-azcopy/azbfs"
 	"github.com/Azure/azure-storage-azcopy/common"
 	"github.com/Azure/azure-storage-azcopy/ste"
 )
@@ -40,6 +43,15 @@ func (cca *cookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 
 	jobPartOrder.PreserveSMBPermissions = cca.preserveSMBPermissions
 	jobPartOrder.PreserveSMBInfo = cca.preserveSMBInfo
+	jobPartOrder.PreserveFileAttributes = cca.preserveFileAttributes
+	jobPartOrder.PreservePOSIX = cca.preservePOSIX
+	jobPartOrder.PreserveDirectoryTimestamps = cca.preserveDirectoryTimestamps
+	jobPartOrder.SetReadOnly = cca.setReadOnly
+	jobPartOrder.SkipSMBInfo = cca.skipSMBInfo
+	jobPartOrder.FileMode = cca.fileMode
+	jobPartOrder.DirMode = cca.dirMode
+	jobPartOrder.S2SPreference = cca.s2sPreference
+	jobPartOrder.RehydrateAndCopy = cca.rehydrateAndCopy
 
 	// Infer on download so that we get LMT and MD5 on files download
 	// On S2S transfers the following rules apply:
@@ -54,17 +66,66 @@ func (cca *cookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	jobPartOrder.DestLengthValidation = cca.CheckLength
 	jobPartOrder.S2SInvalidMetadataHandleOption = cca.s2sInvalidMetadataHandleOption
 
-	traverser, err = initResourceTraverser(cca.source, cca.fromTo.From(), &ctx, &srcCredInfo, &cca.followSymlinks, cca.listOfFilesChannel, cca.recursive, getRemoteProperties, cca.includeDirectoryStubs, func(common.EntityType) {}, cca.listOfVersionIDs)
+	// to preserve tiers that came after those AzCopy knows of, the source must be listed with a service version that reports them
+	srcCtx := ctx
+	if cca.fromTo == common.EFromTo.BlobBlob() && cca.s2sPreserveAccessTier {
+		srcCtx = context.WithValue(ctx, ste.ServiceAPIVersionOverride, ste.ServiceVersionForAccessTiers())
+	}
+
+	traverser, err = initResourceTraverser(cca.source, cca.fromTo.From(), &srcCtx, &srcCredInfo, &cca.followSymlinks, cca.listOfFilesChannel, cca.recursive, getRemoteProperties, cca.includeDirectoryStubs, func(common.EntityType) {}, cca.listOfVersionIDs)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if cca.continueOnEnumerationError {
+		cca.listingErrors = newListingErrorTolerance()
+		switch t := traverser.(type) {
+		case *blobTraverser:
+			t.listingErrors = cca.listingErrors
+		case *blobAccountTraverser:
+			t.listingErrors = cca.listingErrors
+		}
+	}
+
+	if cca.includeVersions {
+		switch t := traverser.(type) {
+		case *blobTraverser:
+			t.includeVersions, t.maxVersionsPerBlob = true, cca.maxVersionsPerBlob
+		case *blobAccountTraverser:
+			t.includeVersions, t.maxVersionsPerBlob = true, cca.maxVersionsPerBlob
+		}
+	}
+
+	if cca.preserveImmutability {
+		if err = cca.checkDestinationAcceptsImmutability(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Ensure we're only copying from a directory with a trailing wildcard or recursive.
 	isSourceDir := traverser.isDirectory(true)
 	if isSourceDir && !cca.recursive && !cca.stripTopDir {
 		return nil, errors.New("cannot use directory as source without --recursive or a trailing wildcard (/*)")
 	}
+	if cca.includeVersions && !isSourceDir {
+		return nil, errors.New("include-versions requires the source to be a container or virtual directory. To copy chosen versions of a single blob, use list-of-versions")
+	}
+
+	// A single file has nothing to split, so it's listed as usual
+	if cca.shardByPrefix > 0 && isSourceDir {
+		if srcLevel, err := determineLocationLevel(cca.source.Value, cca.fromTo.From(), true); err != nil {
+			return nil, err
+		} else if srcLevel == ELocationLevel.Service() {
+			return nil, errors.New("cannot combine shard-by-prefix with account traversal")
+		}
+
+		traverser, err = newShardedTraverser(srcCtx, cca.source, cca.fromTo.From(), &srcCredInfo, cca.followSymlinks, getRemoteProperties, cca.includeDirectoryStubs, cca.shardByPrefix, func(common.EntityType) {})
+		if err != nil {
+			return nil, err
+		}
+	}
+	traverser = newUnicodeNormalizingTraverser(traverser, cca.normalizeUnicode)
 
 	// Check if the destination is a directory so we can correctly decide where our files land
 	isDestDir := cca.isDestDirectory(cca.destination, &ctx)
@@ -118,8 +179,12 @@ func (cca *cookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 			return nil, err
 		}
 
-		// only create the destination container in S2S scenarios
-		if cca.fromTo.From().IsRemote() && dstContainerName != "" { // if the destination has a explicit container name
+		if cca.createDestination && dstContainerName != "" {
+			// the user asked for the container, so if it can't be created, say so now rather than letting every transfer fail
+			if err = cca.createDstContainer(dstContainerName, cca.destination, ctx, existingContainers); err != nil {
+				return nil, explainDstContainerCreateFailure(dstContainerName, err)
+			}
+		} else if cca.fromTo.From().IsRemote() && dstContainerName != "" { // only create the destination container in S2S scenarios, if the destination has a explicit container name
 			// Attempt to create the container. If we fail, fail silently.
 			err = cca.createDstContainer(dstContainerName, cca.destination, ctx, existingContainers)
 
@@ -189,6 +254,12 @@ func (cca *cookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 		}
 	}
 
+	if (cca.preflight || cca.verifyEncryptionScope != "") && cca.fromTo.To() == common.ELocation.Blob() && dstContainerName != "" {
+		if err = cca.preflightDestinationEncryptionScope(ctx, dstContainerName); err != nil {
+			return nil, err
+		}
+	}
+
 	filters := cca.initModularFilters()
 
 	// decide our folder transfer strategy
@@ -198,8 +269,64 @@ func (cca *cookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	if ste.JobsAdmin != nil {
 		ste.JobsAdmin.LogToJobLog(message, pipeline.LogInfo)
 	}
+	if cca.preserveDirectoryTimestamps && jobPartOrder.Fpo == common.EFolderPropertiesOption.NoFolders() {
+		WarnStdoutAndJobLog("Directory timestamps will not be preserved, since folders will not be processed")
+	}
 
+	var cas *casLayout
+	if cca.casOutput {
+		cas = newCasLayout(cca.casManifest)
+		cca.cas = cas // its manifest is written once the job is done
+	}
+
+	var template *destTemplate
+	if cca.destTemplate != "" {
+		if !isDestDir {
+			return nil, errors.New("dest-template requires the destination to be a directory or container")
+		}
+		if template, err = newDestTemplate(cca.destTemplate, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	var partition *partitionBy
+	if cca.partitionBy != "" {
+		if !isDestDir {
+			return nil, errors.New("partition-by requires the destination to be a directory or container")
+		}
+		if partition, err = newPartitionBy(cca.partitionBy, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	var nameRules destinationNameRules
+	if cca.checkNames || cca.sanitizeNames {
+		if nameRules, err = newDestinationNameRules(cca.fromTo.To(), cca.destination.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	var order *listingOrder
+	if cca.preserveListingOrder && cca.fromTo.IsUpload() {
+		order = newListingOrder(cca.source.ValueLocal())
+	}
+
+	if cca.preserveHardLinks {
+		sourceRoot := "" // only uploads look at the files themselves
+		if cca.fromTo.IsUpload() {
+			sourceRoot = cca.source.ValueLocal()
+		}
+		cca.hardLinks = newHardLinks(sourceRoot)
+	}
+
+	filesQueued := 0
 	processor := func(object storedObject) error {
+		if cca.maxTransfers > 0 && filesQueued >= cca.maxTransfers {
+			// the traverser didn't stop when first told to, so whatever else it finds is ignored, as though it were filtered out.
+			// Then the local traverser doesn't descend into symlinked directories either
+			return ignoredError
+		}
+
 		// Start by resolving the name and creating the container
 		if object.containerName != "" {
 			// set up the destination container name.
@@ -232,6 +359,49 @@ func (cca *cookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 
 		srcRelPath := cca.makeEscapedRelativePath(true, isDestDir, object)
 		dstRelPath := cca.makeEscapedRelativePath(false, isDestDir, object)
+		if cas != nil {
+			if object.entityType != common.EEntityType.File() {
+				return nil // the store only holds file content
+			}
+			originalRelPath := dstRelPath
+			if originalRelPath == "" {
+				originalRelPath = object.name // the destination named the file itself
+			}
+			var shouldDownload bool
+			if dstRelPath, shouldDownload = cas.place(originalRelPath, object.md5); !shouldDownload {
+				return nil
+			}
+		}
+		if template != nil {
+			if object.entityType != common.EEntityType.File() {
+				return nil // the template names files; folders under the source have no counterpart at the destination
+			}
+			var shouldTransfer bool
+			if dstRelPath, shouldTransfer = template.resolve(object); !shouldTransfer {
+				return nil
+			}
+			dstRelPath = pathEncodeRules(dstRelPath, cca.fromTo, false)
+		}
+		if partition != nil {
+			if object.entityType != common.EEntityType.File() {
+				return nil // folders under the source are spread across the partitions, by the files in them
+			}
+			dstRelPath = partition.apply(dstRelPath, object)
+		}
+		if cca.sanitizeNames {
+			dstRelPath = nameRules.sanitizeEscaped(dstRelPath, object)
+		}
+		if cca.createDirMarkers && cca.fromTo.IsDownload() && isDirectoryMarker(object) {
+			return cca.recreateMarkedDirectory(dstRelPath)
+		}
+		if cca.hardLinks != nil {
+			if cca.fromTo.IsUpload() && cca.hardLinks.findUploadLink(object, dstRelPath) {
+				return nil // recorded by an empty blob once the listing is done, rather than uploaded again
+			}
+			if cca.fromTo.IsDownload() && cca.hardLinks.findDownloadLink(object, dstRelPath) {
+				return nil // linked to its file once the job is done, rather than downloaded
+			}
+		}
 
 		transfer, shouldSendToSte := object.ToNewCopyTransfer(
 			cca.autoDecompress && cca.fromTo.IsDownload(),
@@ -240,17 +410,87 @@ func (cca *cookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 			jobPartOrder.Fpo,
 		)
 		transfer.BlobTags = cca.blobTags
+		transfer.Priority = transferPriority(cca.priorityPatterns, object)
+		if cca.metadataRules != nil {
+			cca.metadataRules.apply(&transfer, object, cca)
+		}
+		if cca.metadataFrom != nil && !cca.metadataFrom.apply(&transfer, object) {
+			return nil
+		}
+		if order != nil {
+			order.apply(&transfer, object)
+		}
 
 		if shouldSendToSte {
-			return addTransfer(&jobPartOrder, transfer, cca)
+			if err := addTransfer(&jobPartOrder, transfer, cca); err != nil {
+				return err
+			}
+			if object.entityType == common.EEntityType.File() {
+				filesQueued++
+				if filesQueued == cca.maxTransfers {
+					WarnStdoutAndJobLog(fmt.Sprintf("Queued %d files, the most that --max-transfers allows, so no more of the source will be transferred", filesQueued))
+					return enumerationLimitReached
+				}
+			}
+			return nil
 		} else {
 			return nil
 		}
 	}
 	finalizer := func() error {
+		if cas != nil {
+			cas.warnMissingHashes()
+		}
+		if template != nil {
+			template.finish()
+		}
+		if cca.metadataFrom != nil {
+			cca.metadataFrom.finish()
+		}
+		if cca.hardLinks != nil && cca.fromTo.IsUpload() {
+			if err := cca.hardLinks.createLinkBlobs(ctx, cca); err != nil {
+				return err
+			}
+		}
 		return dispatchFinalPart(&jobPartOrder, cca)
 	}
 
+	if cca.checkDiskSpace {
+		// before the other passes, since some of them create folders or files at the destination
+		if err = cca.ensureDiskSpace(traverser, filters, isDestDir, srcLevel != ELocationLevel.Service()); err != nil {
+			return nil, err
+		}
+	}
+	if cca.checkNames {
+		if srcLevel == ELocationLevel.Service() {
+			return nil, errors.New("cannot combine check-names with account traversal")
+		}
+		if err = cca.checkDestinationNames(traverser, filters, isDestDir, nameRules); err != nil {
+			return nil, err
+		}
+	}
+	if cca.precreateDirs && isSourceDir {
+		if srcLevel == ELocationLevel.Service() {
+			return nil, errors.New("cannot combine precreate-directories with account traversal")
+		}
+		if err = cca.precreateDirectories(ctx, traverser, filters, isDestDir); err != nil {
+			return nil, err
+		}
+	}
+	if cca.createDirMarkers && cca.fromTo.IsUpload() && isSourceDir {
+		if err = cca.createDirectoryMarkers(ctx, traverser, filters, isDestDir); err != nil {
+			return nil, err
+		}
+	}
+	if cca.preserveListingOrder && cca.fromTo.IsDownload() && isSourceDir {
+		if srcLevel == ELocationLevel.Service() {
+			return nil, errors.New("cannot combine preserve-listing-order with account traversal")
+		}
+		if err = cca.precreateFilesInListingOrder(traverser, filters, isDestDir); err != nil {
+			return nil, err
+		}
+	}
+
 	return newCopyEnumerator(traverser, filters, processor, finalizer), nil
 }
 
@@ -278,6 +518,18 @@ func (cca *cookedCopyCmdArgs) isDestDirectory(dst common.ResourceString, ctx *co
 }
 
 // Initialize the modular filters outside of copy to increase readability.
+// transferPriority returns High for a file whose name matches one of the priority patterns, so that it's transferred ahead of
+// everything else. Anything else gets Normal, which leaves its transfer with the priority of its job.
+func transferPriority(priorityPatterns []string, object storedObject) common.JobPriority {
+	if len(priorityPatterns) == 0 || object.entityType != common.EEntityType.File() {
+		return common.EJobPriority.Normal()
+	}
+	if (&includeFilter{patterns: priorityPatterns}).doesPass(object) {
+		return common.EJobPriority.High()
+	}
+	return common.EJobPriority.Normal()
+}
+
 func (cca *cookedCopyCmdArgs) initModularFilters() []objectFilter {
 	filters := make([]objectFilter, 0) // same as []objectFilter{} under the hood
 
@@ -318,6 +570,30 @@ func (cca *cookedCopyCmdArgs) initModularFilters() []objectFilter {
 		filters = append(filters, &excludeBlobTypeFilter{blobTypes: excludeSet})
 	}
 
+	if len(cca.includeTier) != 0 {
+		filters = append(filters, newAccessTierFilter(cca.includeTier, true))
+	}
+
+	if len(cca.excludeTier) != 0 {
+		filters = append(filters, newAccessTierFilter(cca.excludeTier, false))
+	}
+
+	if cca.skipEmpty {
+		filters = append(filters, &excludeEmptyFilter{})
+	}
+
+	if len(cca.includeContentType) != 0 {
+		f := &contentTypeFilter{patterns: cca.includeContentType}
+		if cca.fromTo.From() == common.ELocation.Local() {
+			f.localRoot = cca.source.ValueLocal()
+		}
+		filters = append(filters, f)
+	}
+
+	if to := cca.fromTo.To(); to == common.ELocation.Blob() || to == common.ELocation.File() {
+		filters = append(filters, &sizeLimitFilter{destination: to, blobType: cca.blobType, blockSize: cca.blockSize, maxBlocks: cca.maxBlocks})
+	}
+
 	if len(cca.includeFileAttributes) != 0 {
 		filters = append(filters, buildAttrFilters(cca.includeFileAttributes, cca.source.ValueLocal(), true)...)
 	}
@@ -353,8 +629,7 @@ func (cca *cookedCopyCmdArgs) createDstContainer(containerName string, dstWithSA
 		return
 	}
 
-	// Because the only use-cases for createDstContainer will be on service-level S2S and service-level download
-	// We only need to create "containers" on local and blob.
+	// The use-cases for createDstContainer are service-level S2S, service-level download, and create-destination.
 	// TODO: Reduce code dupe somehow
 	switch cca.fromTo.To() {
 	case common.ELocation.Local():
@@ -380,7 +655,7 @@ func (cca *cookedCopyCmdArgs) createDstContainer(containerName string, dstWithSA
 			return err // Container already exists, return gracefully
 		}
 
-		_, err = bcu.Create(ctx, azblob.Metadata{}, azblob.PublicAccessNone)
+		_, err = bcu.Create(ctx, azblob.Metadata{}, cca.destinationPublicAccess)
 
 		if stgErr, ok := err.(azblob.StorageError); ok {
 			if stgErr.ServiceCode() != azblob.ServiceCodeContainerAlreadyExists {
@@ -422,6 +697,35 @@ func (cca *cookedCopyCmdArgs) createDstContainer(containerName string, dstWithSA
 		} else {
 			return err
 		}
+	case common.ELocation.BlobFS():
+		accountRoot, err := GetAccountRoot(dstWithSAS, cca.fromTo.To())
+
+		if err != nil {
+			return err
+		}
+
+		dstURL, err := url.Parse(accountRoot)
+
+		if err != nil {
+			return err
+		}
+
+		fsURL := azbfs.NewServiceURL(*dstURL, dstPipeline).NewFileSystemURL(containerName)
+		_, err = fsURL.GetProperties(ctx)
+
+		if err == nil {
+			return err
+		}
+
+		_, err = fsURL.Create(ctx)
+
+		if stgErr, ok := err.(azbfs.StorageError); ok {
+			if stgErr.ServiceCode() != azbfs.ServiceCodeFileSystemAlreadyExists {
+				return err
+			}
+		} else {
+			return err
+		}
 	default:
 		panic(fmt.Sprintf("cannot create a destination container at location %s.", cca.fromTo.To()))
 	}
@@ -429,6 +733,16 @@ func (cca *cookedCopyCmdArgs) createDstContainer(containerName string, dstWithSA
 	return
 }
 
+// explainDstContainerCreateFailure says why the destination container could not be created,
+// and in particular, points out when the credentials don't permit it
+func explainDstContainerCreateFailure(containerName string, err error) error {
+	if respErr, ok := err.(interface{ Response() *http.Response }); ok && respErr.Response() != nil && respErr.Response().StatusCode == http.StatusForbidden {
+		return fmt.Errorf("the destination '%s' could not be created, because the credentials used for the destination do not have permission to create it. "+
+			"Create it with the make command, or use credentials (e.g. an account-level SAS) that allow it. If it already exists, run the copy without create-destination. Details: %s", containerName, err)
+	}
+	return fmt.Errorf("failed to create the destination '%s': %s", containerName, err)
+}
+
 // Because some invalid characters weren't being properly encoded by url.PathEscape, we're going to instead manually encode them.
 var encodedInvalidCharacters = map[rune]string{
 	'<':  "%3C",
@@ -511,11 +825,11 @@ func (cca *cookedCopyCmdArgs) makeEscapedRelativePath(source bool, dstIsDir bool
 				// Our source points to a specific file (and so has no relative path)
 				// but our dest does not point to a specific file, it just points to a directory,
 				// and so relativePath needs the _name_ of the source.
-				processedVID := ""
+				name := object.name
 				if len(object.blobVersionID) > 0 {
-					processedVID = strings.ReplaceAll(object.blobVersionID, ":", "-") + "-"
+					name = versionedObjectName(object.blobVersionID, name)
 				}
-				relativePath += "/" + processedVID + object.name
+				relativePath += "/" + name
 			} else {
 				relativePath = ""
 			}
@@ -529,7 +843,13 @@ func (cca *cookedCopyCmdArgs) makeEscapedRelativePath(source bool, dstIsDir bool
 	if object.isSourceRootFolder() {
 		relativePath = "" // otherwise we get "/" from the line below, and that breaks some clients, e.g. blobFS
 	} else {
-		relativePath = "/" + strings.Replace(object.relativePath, common.OS_PATH_SEPARATOR, common.AZCOPY_PATH_SEPARATOR_STRING, -1)
+		relativePath = "/" + strings.Replace(common.IffString(source, object.addressableRelativePath(), object.relativePath), common.OS_PATH_SEPARATOR, common.AZCOPY_PATH_SEPARATOR_STRING, -1)
+
+		// previous versions, listed alongside the blob, are named for their version at the destination, next to it
+		if !source && len(object.blobVersionID) > 0 {
+			nameStart := strings.LastIndex(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING) + 1
+			relativePath = relativePath[:nameStart] + versionedObjectName(object.blobVersionID, relativePath[nameStart:])
+		}
 	}
 
 	if common.IffString(source, object.containerName, object.dstContainerName) != "" {
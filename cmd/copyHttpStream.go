@@ -0,0 +1,185 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// processHttpStreamCopyIfNeeded copies an HTTP(S) source by streaming it through AzCopy, when the service can't fetch it itself.
+// That's the case when the source doesn't say how long it is, or doesn't support range requests.
+// If the service can fetch the source, nothing is done and false is returned, so that the copy runs as a normal S2S job.
+func (cca *cookedCopyCmdArgs) processHttpStreamCopyIfNeeded() (streamed bool, err error) {
+	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+	client := ste.NewAzcopyHTTPClient(frontEndMaxIdleConnectionsPerHost)
+
+	srcURL, err := cca.source.FullURL()
+	if err != nil {
+		return false, fmt.Errorf("fatal: cannot parse source URL due to error: %s", err.Error())
+	}
+	redactedSource := common.URLExtension{URL: *srcURL}.RedactSecretQueryParamForLogging()
+
+	info, err := common.ProbeHTTPSource(ctx, client, *srcURL)
+	if err != nil {
+		return false, fmt.Errorf("cannot get the properties of %s: %w", redactedSource, err)
+	}
+	if info.SupportsServerSideCopy() {
+		return false, nil
+	}
+	if cca.s2sPreference == common.ES2SPreference.Require() {
+		return true, fmt.Errorf("s2s=require, but %s does not report its length, or does not support range requests, so the service can't copy it", redactedSource)
+	}
+	if unsupported := cca.flagsUnsupportedByHttpStream(); len(unsupported) > 0 {
+		return true, fmt.Errorf("%s does not report its length, or does not support range requests, so it must be streamed through AzCopy, "+
+			"which doesn't support %s", redactedSource, strings.Join(unsupported, ", "))
+	}
+	glcm.Info("The source does not report its length, or does not support range requests, so it will be streamed through AzCopy rather than copied by the service. " +
+		"The copy doesn't run as a job, so it has no job log or plan, and can't be resumed.")
+
+	// step 1: initialize the destination pipeline
+	credInfo, _, err := getCredentialInfoForLocation(ctx, common.ELocation.Blob(), cca.destination.Value, cca.destination.SAS, false)
+	if err != nil {
+		return true, fmt.Errorf("fatal: cannot find auth on destination blob URL: %s", err.Error())
+	}
+	p, err := createBlobPipeline(ctx, credInfo)
+	if err != nil {
+		return true, err
+	}
+
+	// step 2: work out the destination blob, which is named after the source if the destination is a folder or container
+	dstURL, err := cca.destination.FullURL()
+	if err != nil {
+		return true, fmt.Errorf("fatal: cannot parse destination blob URL due to error: %s", err.Error())
+	}
+	if cca.isDestDirectory(cca.destination, &ctx) {
+		dstURL.Path = strings.TrimSuffix(dstURL.Path, "/") + "/" + httpSourceName(srcURL)
+		dstURL.RawPath = ""
+	}
+	redactedDestination := common.URLExtension{URL: *dstURL}.RedactSecretQueryParamForLogging()
+	blockBlobURL := azblob.NewBlockBlobURL(*dstURL, p)
+
+	// step 3: respect --overwrite, and --append-only, as the STE would
+	accessConditions, shouldCopy, err := cca.httpStreamDestinationAccessConditions(ctx, blockBlobURL, info, redactedDestination)
+	if err != nil || !shouldCopy {
+		return true, err
+	}
+
+	// step 4: stream the source into the destination, in parallel blocks
+	body, err := common.OpenHTTPSource(ctx, client, info.URL)
+	if err != nil {
+		return true, fmt.Errorf("cannot read %s: %w", redactedSource, err)
+	}
+	defer body.Close()
+
+	blockSize := cca.blockSize
+	if blockSize == 0 {
+		blockSize = pipingDefaultBlockSize
+	}
+	options := azblob.UploadStreamToBlockBlobOptions{
+		BufferSize:       int(blockSize),
+		MaxBuffers:       pipingUploadParallelism,
+		AccessConditions: accessConditions,
+		BlobAccessTier:   cca.blockBlobAccessTier(),
+	}
+	if cca.s2sPreserveProperties {
+		options.BlobHTTPHeaders = azblob.BlobHTTPHeaders{
+			ContentType:        info.ContentType(),
+			ContentEncoding:    info.ContentEncoding(),
+			ContentLanguage:    info.ContentLanguage(),
+			ContentDisposition: info.ContentDisposition(),
+			CacheControl:       info.CacheControl(),
+		}
+	}
+	if _, err = azblob.UploadStreamToBlockBlob(ste.ContextForAccessTier(ctx, options.BlobAccessTier), body, blockBlobURL, options); err != nil {
+		return true, fmt.Errorf("cannot copy %s to %s: %w", redactedSource, redactedDestination, err)
+	}
+
+	glcm.Info(fmt.Sprintf("Copied %s to %s.", redactedSource, redactedDestination))
+	return true, nil
+}
+
+// flagsUnsupportedByHttpStream returns the flags that were given which a streamed copy would ignore, since it doesn't run as a job.
+// The content headers, metadata and put-md5 are already refused for any copy from an HTTP(S) source.
+func (cca *cookedCopyCmdArgs) flagsUnsupportedByHttpStream() []string {
+	unsupported := make([]string, 0)
+	if len(cca.blobTags) > 0 {
+		unsupported = append(unsupported, "blob-tags")
+	}
+	if cca.blobType != common.EBlobType.Detect() && cca.blobType != common.EBlobType.BlockBlob() {
+		unsupported = append(unsupported, "blob-type "+cca.blobType.String())
+	}
+	if cmdLineCapMegaBitsPerSecond > 0 {
+		unsupported = append(unsupported, "cap-mbps")
+	}
+	if cmdLineCapRequestsPerSecond > 0 {
+		unsupported = append(unsupported, "cap-requests-per-second")
+	}
+	return unsupported
+}
+
+// httpStreamDestinationAccessConditions decides whether a streamed copy should replace the destination blob, if it already exists.
+// The returned conditions make the upload fail, rather than overwrite, if the blob is created while the source is being streamed
+// and the user did not want existing blobs overwritten.
+func (cca *cookedCopyCmdArgs) httpStreamDestinationAccessConditions(ctx context.Context, blockBlobURL azblob.BlockBlobURL, info common.HTTPSourceInfo, redactedDestination string) (azblob.BlobAccessConditions, bool, error) {
+	mustNotExist := azblob.BlobAccessConditions{ModifiedAccessConditions: azblob.ModifiedAccessConditions{IfNoneMatch: azblob.ETagAny}}
+	if cca.forceWrite == common.EOverwriteOption.True() && !azcopyAppendOnly {
+		return azblob.BlobAccessConditions{}, true, nil
+	}
+
+	props, err := blockBlobURL.GetProperties(ctx, azblob.BlobAccessConditions{})
+	if stgErr, ok := err.(azblob.StorageError); ok && stgErr.Response() != nil && stgErr.Response().StatusCode == http.StatusNotFound {
+		return mustNotExist, true, nil
+	} else if err != nil {
+		return azblob.BlobAccessConditions{}, false, fmt.Errorf("cannot check whether %s already exists: %w", redactedDestination, err)
+	}
+
+	// the destination exists
+	if azcopyAppendOnly {
+		return azblob.BlobAccessConditions{}, false, fmt.Errorf("%s already exists at the destination, and --append-only prevents it being overwritten", redactedDestination)
+	}
+	shouldCopy := false
+	switch cca.forceWrite {
+	case common.EOverwriteOption.IfSourceNewer():
+		// without a last modified time we can't tell, so copy, as we would if the source were newer
+		shouldCopy = info.LastModified.IsZero() || info.LastModified.After(props.LastModified())
+	case common.EOverwriteOption.Prompt():
+		answer := glcm.Prompt(fmt.Sprintf("%s already exists at the destination. Do you wish to overwrite?", redactedDestination),
+			common.PromptDetails{
+				PromptType:      common.EPromptType.Overwrite(),
+				PromptTarget:    redactedDestination,
+				ResponseOptions: []common.ResponseOption{common.EResponseOption.Yes(), common.EResponseOption.No()},
+			})
+		shouldCopy = answer == common.EResponseOption.Yes()
+	}
+	if !shouldCopy {
+		glcm.Info(fmt.Sprintf("Skipped %s because it already exists at the destination.", redactedDestination))
+		return azblob.BlobAccessConditions{}, false, nil
+	}
+	// only replace the version we looked at
+	return azblob.BlobAccessConditions{ModifiedAccessConditions: azblob.ModifiedAccessConditions{IfMatch: props.ETag()}}, true, nil
+}
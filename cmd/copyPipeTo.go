@@ -0,0 +1,195 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+const pipeToFlagHelp = "Download the blob into the standard input of this command, run by the shell (sh, or cmd on Windows), instead of writing it to disk. For example: \"gzip -d > out.txt\". " +
+	"The command's output goes to AzCopy's own. The blob is downloaded in parallel blocks, of block-size-mb, which are given to the command in order. " +
+	"The download succeeds only if the command reads all of the blob and exits with status zero. Give only the URL of the blob, with no destination."
+
+// validatePipeTo checks that pipe-to is only used to download a single blob, with the source as the only argument
+func validatePipeTo(pipeTo string, fromTo common.FromTo) error {
+	if pipeTo != "" && fromTo != common.EFromTo.BlobPipe() {
+		return fmt.Errorf("pipe-to is only supported when downloading a single blob. Give the blob's URL, and no destination")
+	}
+	return nil
+}
+
+// processPipeToDownload downloads a blob into the standard input of a command that AzCopy starts, rather than into AzCopy's own standard output.
+// Unlike the plain BlobPipe download, AzCopy runs the command, and the download fails if the command does
+func (cca *cookedCopyCmdArgs) processPipeToDownload(blobResource common.ResourceString) error {
+	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+
+	credInfo, _, err := getCredentialInfoForLocation(ctx, common.ELocation.Blob(), blobResource.Value, blobResource.SAS, true)
+	if err != nil {
+		return fmt.Errorf("fatal: cannot find auth on source blob URL: %s", err.Error())
+	}
+	p, err := createBlobPipeline(ctx, credInfo)
+	if err != nil {
+		return err
+	}
+	u, err := blobResource.FullURL()
+	if err != nil {
+		return fmt.Errorf("fatal: cannot parse source blob URL due to error: %s", err.Error())
+	}
+
+	blobURL := azblob.NewBlobURL(*u, p)
+	props, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{})
+	if err != nil {
+		return fmt.Errorf("fatal: cannot get the properties of the blob due to error: %s", err.Error())
+	}
+
+	// every block must come from the same version of the blob, or the command would be given a mix of two
+	sameVersion := azblob.BlobAccessConditions{ModifiedAccessConditions: azblob.ModifiedAccessConditions{IfMatch: props.ETag()}}
+	downloadBlock := func(ctx context.Context, offset, count int64) ([]byte, error) {
+		resp, err := blobURL.Download(ctx, offset, count, sameVersion, false)
+		if err != nil {
+			return nil, err
+		}
+		body := resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: ste.MaxRetryPerDownloadBody})
+		defer body.Close()
+		block := make([]byte, count)
+		_, err = io.ReadFull(body, block)
+		return block, err
+	}
+
+	blockSize := cca.blockSize
+	if blockSize == 0 {
+		blockSize = pipingDefaultBlockSize
+	}
+	return pipeToCommand(cca.pipeTo, func(w io.Writer) error {
+		if err := downloadInOrder(ctx, props.ContentLength(), blockSize, pipingUploadParallelism, downloadBlock, w); err != nil {
+			return fmt.Errorf("cannot download blob due to error: %w", err)
+		}
+		return nil
+	})
+}
+
+// downloadInOrder downloads size bytes in blocks of blockSize, up to parallelism of them at once, and writes them to w in order.
+// Blocks that arrive early wait for those before them, so at most about parallelism blocks are held in memory
+func downloadInOrder(ctx context.Context, size, blockSize int64, parallelism int,
+	download func(ctx context.Context, offset, count int64) ([]byte, error), w io.Writer) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the downloads still running, if one has failed
+
+	type block struct {
+		data []byte
+		err  error
+	}
+	pending := make(chan chan block, parallelism)
+	go func() {
+		defer close(pending)
+		for offset := int64(0); offset < size; offset += blockSize {
+			count := blockSize
+			if size-offset < count {
+				count = size - offset
+			}
+			result := make(chan block, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+			go func(offset, count int64) {
+				data, err := download(ctx, offset, count)
+				result <- block{data: data, err: err}
+			}(offset, count)
+		}
+	}()
+
+	for result := range pending {
+		b := <-result
+		if b.err != nil {
+			return b.err
+		}
+		if _, err := w.Write(b.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pipeWriter remembers whether writing to the command failed, which happens if the command stops reading early
+type pipeWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *pipeWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	return n, err
+}
+
+// pipeToCommand runs command in the shell, with its output going to AzCopy's own, and calls write to feed its standard input.
+// If write fails for any reason but the command, the command is killed, rather than given the end of its input,
+// so that it can't mistake what it has read for the whole. Otherwise, the command must read everything and exit with status zero
+func pipeToCommand(command string, write func(w io.Writer) error) error {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", command)
+	} else {
+		c = exec.Command("sh", "-c", command)
+	}
+	c.Stdout, c.Stderr = os.Stdout, os.Stderr
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err = c.Start(); err != nil {
+		return fmt.Errorf("cannot start the command %q: %w", command, err)
+	}
+
+	w := &pipeWriter{w: stdin}
+	if err = write(w); err != nil && w.err == nil {
+		_ = c.Process.Kill()
+		_ = c.Wait()
+		return err
+	}
+	_ = stdin.Close()
+
+	if err = c.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("the command %q exited with status %d", command, exitErr.ExitCode())
+		}
+		return fmt.Errorf("the command %q failed: %w", command, err)
+	}
+	if w.err != nil {
+		return fmt.Errorf("the command %q stopped reading the blob before its end: %w", command, w.err)
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -40,6 +41,53 @@ const (
 	NumOfFilesPerDispatchJobPart = 10000
 )
 
+// enumerationLookaheadFlagHelp is shared by the commands that enumerate a source into a job
+var enumerationLookaheadFlagHelp = fmt.Sprintf("Bounds how many transfers scanning can get ahead of the transfers that are done, so that a huge job doesn't hold millions of pending transfers in memory. "+
+	"When the bound is reached, scanning pauses until enough transfers finish. Since transfers are handed over in batches of %d, a bound smaller than that still lets one batch through at a time. "+
+	"(default 0, which means no limit).", NumOfFilesPerDispatchJobPart)
+
+// prefixConcurrencyFlagHelp is shared by the commands that transfer to a remote destination
+const prefixConcurrencyFlagHelp = "Limits how many transfers may be in flight at once under each destination prefix, e.g. logs/=10,data/=100, " +
+	"so that prefixes that map to different storage partitions are paced independently, and one that's throttled doesn't hold back the rest. " +
+	"A prefix is the start of the names of the blobs or files in their container or share. Where prefixes overlap, the longest that matches applies, " +
+	"and transfers under no prefix are not limited. The progress shows the transfers in flight, and waiting, under each prefix."
+
+// parsePrefixConcurrency parses the limits on the transfers in flight under each destination prefix. Nil if none are given
+func parsePrefixConcurrency(raw string, fromTo common.FromTo) (map[string]int, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if !fromTo.To().IsRemote() {
+		return nil, errors.New("prefix-concurrency can only be used when the destination is remote, since its prefixes are of the names of blobs or files in their container or share")
+	}
+	limits, err := ste.ParsePrefixConcurrency(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prefix-concurrency: %s", err.Error())
+	}
+	// the limits are kept in the job's plan, so that they still apply when it's resumed
+	if formatted := ste.FormatPrefixConcurrency(limits); len(formatted) > ste.PrefixConcurrencyMaxBytes {
+		return nil, fmt.Errorf("prefix-concurrency has too many rules: %q is longer than %d characters", formatted, ste.PrefixConcurrencyMaxBytes)
+	}
+	return limits, nil
+}
+
+// formatPrefixInFlight notes the transfers in flight, of the most allowed, under each destination prefix that the job limits,
+// and how many are waiting for a slot. Returns nothing if the job has no such limits
+func formatPrefixInFlight(summary common.ListJobSummaryResponse) string {
+	if len(summary.TransfersInFlightByPrefix) == 0 {
+		return ""
+	}
+	prefixes := make([]string, 0, len(summary.TransfersInFlightByPrefix))
+	for _, p := range summary.TransfersInFlightByPrefix {
+		s := fmt.Sprintf("%s %d/%d", p.Prefix, p.InFlight, p.Limit)
+		if p.Waiting > 0 {
+			s += fmt.Sprintf(" +%d waiting", p.Waiting)
+		}
+		prefixes = append(prefixes, s)
+	}
+	return " (In flight: " + strings.Join(prefixes, ", ") + ")"
+}
+
 type copyHandlerUtil struct{}
 
 // TODO: Need be replaced with anonymous embedded field technique.
@@ -92,6 +92,14 @@ func GetOAuthTokenManagerInstance() (*common.UserOAuthTokenManager, error) {
 			lca.aadEndpoint = endpoint
 		}
 
+		// the command line takes precedence over the environment
+		if cmdLineTenantID != "" {
+			lca.tenantID = cmdLineTenantID
+		}
+		if cmdLineAADAuthority != "" {
+			lca.aadEndpoint = cmdLineAADAuthority
+		}
+
 		// Fill up lca
 		switch glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AutoLoginType()) {
 		case "SPN":
@@ -111,6 +119,11 @@ func GetOAuthTokenManagerInstance() (*common.UserOAuthTokenManager, error) {
 			lca.identity = false
 		}
 
+		if !lca.identity && ste.JobsAdmin != nil {
+			// the login command only tells the console, but when logging in automatically it belongs in the job log too
+			ste.JobsAdmin.LogToJobLog(lca.tokenSourceMessage(), pipeline.LogInfo)
+		}
+
 		lca.persistToken = false
 		err = lca.process()
 	})
@@ -149,17 +162,22 @@ func getBlobCredentialType(ctx context.Context, blobResourceURL string, canBePub
 	}
 
 	checkPublic := func() (isPublicResource bool) {
-		p := azblob.NewPipeline(
-			azblob.NewAnonymousCredential(),
-			azblob.PipelineOptions{
-				Retry: azblob.RetryOptions{
-					Policy:        azblob.RetryPolicyExponential,
-					MaxTries:      ste.UploadMaxTries,
-					TryTimeout:    ste.UploadTryTimeout,
-					RetryDelay:    ste.UploadRetryDelay,
-					MaxRetryDelay: ste.UploadMaxRetryDelay,
-				},
-			})
+		// this mirrors azblob.NewPipeline for an anonymous credential, with the addition of the request header and endpoint policies
+		p := pipeline.NewPipeline([]pipeline.Factory{
+			azblob.NewTelemetryPolicyFactory(azblob.TelemetryOptions{}),
+			azblob.NewUniqueRequestIDPolicyFactory(),
+			ste.NewRequestHeaderPolicyFactory(),
+			ste.NewEndpointPolicyFactory(),
+			azblob.NewRetryPolicyFactory(azblob.RetryOptions{
+				Policy:        azblob.RetryPolicyExponential,
+				MaxTries:      ste.UploadMaxTries,
+				TryTimeout:    ste.UploadTryTimeout,
+				RetryDelay:    ste.UploadRetryDelay,
+				MaxRetryDelay: ste.UploadMaxRetryDelay,
+			}),
+			azblob.NewRequestLogPolicyFactory(azblob.RequestLogOptions{}),
+			pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
+		}, pipeline.Options{})
 
 		isContainer := copyHandlerUtil{}.urlIsContainerOrVirtualDirectory(resourceURL)
 		isPublicResource = false
@@ -457,6 +475,9 @@ func doGetCredentialTypeForLocation(ctx context.Context, location common.Locatio
 		switch location {
 		case common.ELocation.Local(), common.ELocation.Benchmark():
 			credType = common.ECredentialType.Anonymous()
+		case common.ELocation.Http():
+			// we never send credentials to arbitrary web servers. Any token the source needs must already be in its URL
+			credType, isPublic = common.ECredentialType.Anonymous(), true
 		case common.ELocation.Blob():
 			credType, isPublic, err = getBlobCredentialType(ctx, resource, isSource, resourceSAS != "")
 			if azErr, ok := err.(common.AzError); ok && azErr.Equals(common.EAzError.LoginCredMissing()) {
@@ -495,7 +516,7 @@ func doGetCredentialTypeForLocation(ctx context.Context, location common.Locatio
 		}
 	}
 
-	if err = checkAuthSafeForTarget(credType, resource, cmdLineExtraSuffixesAAD, location); err != nil {
+	if err = checkAuthSafeForTarget(credType, resource, azcopyEndpoints.withTrustedSuffixes(cmdLineExtraSuffixesAAD), location); err != nil {
 		return common.ECredentialType.Unknown(), false, err
 	}
 
@@ -589,36 +610,48 @@ func createBlobFSPipeline(ctx context.Context, credInfo common.CredentialInfo) (
 		LogError: glcm.Info,
 	})
 
-	return azbfs.NewPipeline(
+	// this mirrors azbfs.NewPipeline, with the addition of the request header and endpoint policies
+	f := []pipeline.Factory{
+		azbfs.NewTelemetryPolicyFactory(azbfs.TelemetryOptions{
+			Value: glcm.AddUserAgentPrefix(common.UserAgent),
+		}),
+		azbfs.NewUniqueRequestIDPolicyFactory(),
+		ste.NewRequestHeaderPolicyFactory(),
+		ste.NewEndpointPolicyFactory(),
+		azbfs.NewRetryPolicyFactory(azbfs.RetryOptions{
+			Policy:        azbfs.RetryPolicyExponential,
+			MaxTries:      ste.UploadMaxTries,
+			TryTimeout:    ste.UploadTryTimeout,
+			RetryDelay:    ste.UploadRetryDelay,
+			MaxRetryDelay: ste.UploadMaxRetryDelay,
+		}),
 		credential,
-		azbfs.PipelineOptions{
-			Retry: azbfs.RetryOptions{
-				Policy:        azbfs.RetryPolicyExponential,
-				MaxTries:      ste.UploadMaxTries,
-				TryTimeout:    ste.UploadTryTimeout,
-				RetryDelay:    ste.UploadRetryDelay,
-				MaxRetryDelay: ste.UploadMaxRetryDelay,
-			},
-			Telemetry: azbfs.TelemetryOptions{
-				Value: glcm.AddUserAgentPrefix(common.UserAgent),
-			},
-		}), nil
+		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
+		azbfs.NewRequestLogPolicyFactory_Deprecated(azbfs.RequestLogOptions{}),
+	}
+	return pipeline.NewPipeline(f, pipeline.Options{}), nil
 }
 
 // TODO note: ctx and credInfo are ignored at the moment because we only support SAS for Azure File
 func createFilePipeline(ctx context.Context, credInfo common.CredentialInfo) (pipeline.Pipeline, error) {
-	return azfile.NewPipeline(
-		azfile.NewAnonymousCredential(),
-		azfile.PipelineOptions{
-			Retry: azfile.RetryOptions{
-				Policy:        azfile.RetryPolicyExponential,
-				MaxTries:      ste.UploadMaxTries,
-				TryTimeout:    ste.UploadTryTimeout,
-				RetryDelay:    ste.UploadRetryDelay,
-				MaxRetryDelay: ste.UploadMaxRetryDelay,
-			},
-			Telemetry: azfile.TelemetryOptions{
-				Value: glcm.AddUserAgentPrefix(common.UserAgent),
-			},
-		}), nil
+	// this mirrors azfile.NewPipeline (which omits the anonymous credential), with the addition of the request header, endpoint and trailing dot policies
+	f := []pipeline.Factory{
+		azfile.NewTelemetryPolicyFactory(azfile.TelemetryOptions{
+			Value: glcm.AddUserAgentPrefix(common.UserAgent),
+		}),
+		azfile.NewUniqueRequestIDPolicyFactory(),
+		ste.NewRequestHeaderPolicyFactory(),
+		ste.NewEndpointPolicyFactory(),
+		azfile.NewRetryPolicyFactory(azfile.RetryOptions{
+			Policy:        azfile.RetryPolicyExponential,
+			MaxTries:      ste.UploadMaxTries,
+			TryTimeout:    ste.UploadTryTimeout,
+			RetryDelay:    ste.UploadRetryDelay,
+			MaxRetryDelay: ste.UploadMaxRetryDelay,
+		}),
+		ste.NewTrailingDotPolicyFactory(azcopyTrailingDot),
+		azfile.NewRequestLogPolicyFactory(azfile.RequestLogOptions{}),
+		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
+	}
+	return pipeline.NewPipeline(f, pipeline.Options{}), nil
 }
@@ -0,0 +1,165 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// destTemplate computes the destination path of each file from a template such as "{year}/{month}/{name}",
+// in place of the file's path relative to the source. Since several files may resolve to the same path,
+// it remembers which file got each path, and skips (and reports) any later file that would overwrite it.
+type destTemplate struct {
+	segments   []string // the template, split on "/", with placeholders left in place
+	uploadDate time.Time
+	assigned   map[string]string // resolved destination path -> relative path of the source file that got it
+	collisions uint64
+}
+
+// the placeholders that may appear in a destination template
+var destTemplatePlaceholders = map[string]bool{
+	"name":  true, // file name, with its extension
+	"base":  true, // file name, without its extension
+	"ext":   true, // extension, without the dot. Empty if the file has none
+	"dir":   true, // directory of the file, relative to the source
+	"size":  true, // size bucket of the file: small, medium, large or huge
+	"year":  true, // year of the upload date (UTC)
+	"month": true, // two-digit month of the upload date
+	"day":   true, // two-digit day of the upload date
+}
+
+func newDestTemplate(template string, uploadDate time.Time) (*destTemplate, error) {
+	template = strings.Trim(strings.Replace(template, `\`, "/", -1), "/")
+	if template == "" {
+		return nil, errors.New("the template is empty")
+	}
+
+	segments := strings.Split(template, "/")
+	for _, seg := range segments {
+		if seg == "." || seg == ".." {
+			return nil, errors.New("the template cannot contain '.' or '..' path segments")
+		}
+		rest := seg
+		for {
+			open := strings.Index(rest, "{")
+			close := strings.Index(rest, "}")
+			if open < 0 && close < 0 {
+				break
+			}
+			if open < 0 || close < open {
+				return nil, fmt.Errorf("unmatched brace in '%s'", seg)
+			}
+			name := rest[open+1 : close]
+			if !destTemplatePlaceholders[name] {
+				return nil, fmt.Errorf("unknown placeholder {%s}", name)
+			}
+			rest = rest[close+1:]
+		}
+	}
+
+	return &destTemplate{
+		segments:   segments,
+		uploadDate: uploadDate.UTC(),
+		assigned:   make(map[string]string),
+	}, nil
+}
+
+// sizeBucket groups file sizes coarsely, so that files of similar size can be kept together
+func sizeBucket(size int64) string {
+	const mib = 1024 * 1024
+	switch {
+	case size < mib:
+		return "small"
+	case size < 128*mib:
+		return "medium"
+	case size < 1024*mib:
+		return "large"
+	default:
+		return "huge"
+	}
+}
+
+// resolve returns the destination path of the file, relative to the destination, as the template names it.
+// shouldTransfer is false if another file has already been given the same path.
+func (t *destTemplate) resolve(object storedObject) (dstRelativePath string, shouldTransfer bool) {
+	relativePath := strings.Trim(strings.Replace(object.relativePath, common.OS_PATH_SEPARATOR, "/", -1), "/")
+	dir := path.Dir(relativePath)
+	if dir == "." {
+		dir = ""
+	}
+	ext := path.Ext(object.name)
+
+	r := strings.NewReplacer(
+		"{name}", object.name,
+		"{base}", strings.TrimSuffix(object.name, ext),
+		"{ext}", strings.TrimPrefix(ext, "."),
+		"{dir}", dir,
+		"{size}", sizeBucket(object.size),
+		"{year}", fmt.Sprintf("%04d", t.uploadDate.Year()),
+		"{month}", fmt.Sprintf("%02d", t.uploadDate.Month()),
+		"{day}", fmt.Sprintf("%02d", t.uploadDate.Day()),
+	)
+
+	// placeholders that resolve to nothing (e.g. {dir} for a file at the root) should not leave empty segments behind
+	resolved := make([]string, 0, len(t.segments))
+	for _, seg := range t.segments {
+		for _, part := range strings.Split(r.Replace(seg), "/") {
+			if part != "" {
+				resolved = append(resolved, part)
+			}
+		}
+	}
+	dstRelativePath = strings.Join(resolved, "/")
+	if object.dstContainerName != "" {
+		dstRelativePath = object.dstContainerName + "/" + dstRelativePath
+	}
+
+	source := relativePath
+	if object.containerName != "" {
+		source = object.containerName + "/" + source
+	}
+	if first, ok := t.assigned[dstRelativePath]; ok {
+		t.collisions++
+		if ste.JobsAdmin != nil {
+			ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Not transferring %s, because dest-template resolves it to %s, which %s already resolved to", source, dstRelativePath, first), pipeline.LogWarning)
+		}
+		return "", false
+	}
+	t.assigned[dstRelativePath] = source
+
+	return "/" + dstRelativePath, true
+}
+
+// finish reports any files that were skipped because their template paths collided
+func (t *destTemplate) finish() {
+	if t.collisions > 0 {
+		WarnStdoutAndJobLog(fmt.Sprintf("%d file(s) were not transferred, because dest-template resolved them to the same path as an earlier file. See the job log for details", t.collisions))
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+const (
+	maxBlobNameLength          = 1024 // characters, in the whole blob name
+	maxFilePathLength          = 2048 // characters, in the whole path of an Azure Files file or directory
+	maxFilePathComponentLength = 255  // characters, in each directory or file name of an Azure Files path
+)
+
+// destinationNameRules checks names against the naming rules of the destination service, so that names that would fail
+// can be reported before anything is transferred, and fixes the names that a documented mapping can fix.
+// Characters that AzCopy already encodes for Azure Files (e.g. < > : " | ? *) aren't reported, since they don't fail.
+type destinationNameRules struct {
+	location common.Location
+	prefix   string // the path of the destination within its container or share, which every name starts with
+}
+
+func newDestinationNameRules(location common.Location, destination string) (destinationNameRules, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return destinationNameRules{}, err
+	}
+	r := destinationNameRules{location: location}
+	if parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 2); len(parts) == 2 {
+		r.prefix = parts[1]
+	}
+	return r, nil
+}
+
+// violations returns each rule that the name, which is relative to the destination, breaks
+func (r destinationNameRules) violations(name string) []string {
+	fullName := strings.Trim(r.prefix+"/"+name, "/")
+	var broken []string
+
+	for _, c := range fullName {
+		if isControlCharacter(c) {
+			broken = append(broken, fmt.Sprintf("contains the control character U+%04X, which %s names can't hold", c, r.service()))
+			break
+		}
+	}
+	for _, segment := range strings.Split(fullName, "/") {
+		if segment == "." || segment == ".." {
+			broken = append(broken, fmt.Sprintf("has a '%s' path segment, which the service would resolve away", segment))
+			break
+		}
+	}
+
+	if r.location == common.ELocation.File() {
+		if n := utf8.RuneCountInString(fullName); n > maxFilePathLength {
+			broken = append(broken, fmt.Sprintf("is %d characters long, but Azure Files paths can be at most %d", n, maxFilePathLength))
+		}
+		for _, segment := range strings.Split(fullName, "/") {
+			if utf8.RuneCountInString(segment) > maxFilePathComponentLength {
+				broken = append(broken, fmt.Sprintf("has a directory or file name longer than the %d characters Azure Files allows", maxFilePathComponentLength))
+				break
+			}
+		}
+		for _, segment := range strings.Split(fullName, "/") {
+			if strings.HasSuffix(segment, " ") || strings.HasSuffix(segment, ".") {
+				broken = append(broken, "has a directory or file name ending in a space or dot, which Azure Files doesn't allow")
+				break
+			}
+		}
+	} else {
+		if n := utf8.RuneCountInString(fullName); n > maxBlobNameLength {
+			broken = append(broken, fmt.Sprintf("is %d characters long, but blob names can be at most %d", n, maxBlobNameLength))
+		}
+		if strings.HasSuffix(fullName, ".") {
+			broken = append(broken, "ends in a dot, which the service may remove")
+		}
+	}
+	return broken
+}
+
+// sanitize maps the parts of a name that break the rules to ones that don't, by percent-encoding them, as AzCopy does
+// for the characters that Azure Files can't hold: control characters, '.' and '..' segments, and trailing dots (and,
+// for Azure Files, spaces). Names that are too long can't be fixed this way, so they're left as they are.
+func (r destinationNameRules) sanitize(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		var b strings.Builder
+		for _, c := range segment {
+			if isControlCharacter(c) {
+				b.WriteString(fmt.Sprintf("%%%02X", c))
+			} else {
+				b.WriteRune(c)
+			}
+		}
+		segment = b.String()
+
+		if segment == "." || segment == ".." {
+			segment = strings.Repeat("%2E", len(segment))
+		}
+
+		// only Azure Files objects to each name ending in a dot or space. For blobs, only the last dot of the whole name matters
+		trailing := ""
+		if r.location == common.ELocation.File() {
+			for strings.HasSuffix(segment, " ") || strings.HasSuffix(segment, ".") {
+				trailing = common.IffString(strings.HasSuffix(segment, " "), "%20", "%2E") + trailing
+				segment = segment[:len(segment)-1]
+			}
+		} else if i == len(segments)-1 {
+			for strings.HasSuffix(segment, ".") {
+				trailing += "%2E"
+				segment = segment[:len(segment)-1]
+			}
+		}
+		segments[i] = segment + trailing
+	}
+	return strings.Join(segments, "/")
+}
+
+func (r destinationNameRules) service() string {
+	if r.location == common.ELocation.File() {
+		return "Azure Files"
+	}
+	return "blob"
+}
+
+func isControlCharacter(c rune) bool {
+	return c < 0x20 || c == 0x7F
+}
+
+// splitDestinationRelativePath splits the escaped destination path of an object, relative to the destination, into the
+// destination container it's in, if it names one, and the rest, unescaped
+func splitDestinationRelativePath(dstRelPath string, object storedObject) (container, name string, err error) {
+	if object.dstContainerName != "" {
+		container = "/" + object.dstContainerName
+		dstRelPath = strings.TrimPrefix(dstRelPath, container)
+	}
+	name, err = url.PathUnescape(strings.TrimPrefix(dstRelPath, "/"))
+	return container, name, err
+}
+
+// sanitizeEscaped sanitizes an object's escaped destination path, relative to the destination, as sanitize does
+func (r destinationNameRules) sanitizeEscaped(dstRelPath string, object storedObject) string {
+	container, name, err := splitDestinationRelativePath(dstRelPath, object)
+	if err != nil || name == "" {
+		return dstRelPath // nothing that AzCopy escaped itself should fail to unescape, but if it does, the name is left alone
+	}
+	segments := strings.Split(r.sanitize(name), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return container + "/" + strings.Join(segments, "/")
+}
+
+// checkDestinationNames lists the source, and reports every object whose destination name breaks the rules of the
+// destination service. If there are any, it fails, so that nothing is transferred until they're renamed or sanitized
+func (cca *cookedCopyCmdArgs) checkDestinationNames(traverser resourceTraverser, filters []objectFilter, isDestDir bool, rules destinationNameRules) error {
+	invalid := 0
+	processor := func(object storedObject) error {
+		object.containerName, object.dstContainerName = "", "" // as for the transfers, when the source is below the service level
+		dstRelPath := cca.makeEscapedRelativePath(false, isDestDir, object)
+		if cca.sanitizeNames {
+			dstRelPath = rules.sanitizeEscaped(dstRelPath, object)
+		}
+		_, name, err := splitDestinationRelativePath(dstRelPath, object)
+		if err != nil {
+			return err
+		}
+
+		broken := rules.violations(name)
+		if len(broken) > 0 {
+			invalid++
+			message := fmt.Sprintf("Invalid destination name for %s: it %s", object.relativePath, strings.Join(broken, ", and it "))
+			glcm.Info(message)
+			if ste.JobsAdmin != nil {
+				ste.JobsAdmin.LogToJobLog(message, pipeline.LogError)
+			}
+		}
+		return nil
+	}
+	if err := traverser.traverse(noPreProccessor, processor, filters); err != nil {
+		return fmt.Errorf("cannot list the source to check the destination names: %s", err.Error())
+	}
+
+	if invalid > 0 {
+		advice := "Rename them, or use --sanitize-names to percent-encode the characters that break the rules"
+		if cca.sanitizeNames {
+			advice = "--sanitize-names can't fix names that are too long, so rename them"
+		}
+		return fmt.Errorf("%d name(s) are invalid at the destination, as listed above, so nothing was transferred. %s", invalid, advice)
+	}
+	return nil
+}
+
+// validateDestinationNameChecks checks that check-names and sanitize-names are used where there are rules to check against
+func validateDestinationNameChecks(checkNames, sanitizeNames bool, cooked cookedCopyCmdArgs) error {
+	if !checkNames && !sanitizeNames {
+		return nil
+	}
+	switch cooked.fromTo.To() {
+	case common.ELocation.Blob(), common.ELocation.BlobFS(), common.ELocation.File():
+	default:
+		return errors.New("check-names and sanitize-names are only supported when copying to Blob storage, ADLS Gen2 or Azure Files")
+	}
+	if !checkNames {
+		return nil
+	}
+	// the source is listed once to check the names, and again for the transfers, but a list of files can only be read once
+	if cooked.listOfFilesChannel != nil || cooked.listOfVersionIDs != nil {
+		return errors.New("check-names cannot be combined with list-of-files, include-path or list-of-versions")
+	}
+	if cooked.destTemplate != "" || cooked.partitionBy != "" {
+		return errors.New("check-names cannot be combined with dest-template or partition-by, which name the files as they're transferred")
+	}
+	return nil
+}
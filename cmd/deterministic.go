@@ -0,0 +1,68 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// deterministicSeed is what math/rand is seeded with in deterministic mode, in place of the time, so that whatever AzCopy
+// does at random, such as shuffling the transfers of each job part, it does the same way every run
+const deterministicSeed int64 = 1
+
+// applyDeterministicMode seeds math/rand, and fixes the concurrency settings that would otherwise make the order of operations vary between runs
+func applyDeterministicMode(settings *ste.ConcurrencySettings) {
+	rand.Seed(deterministicSeed)
+
+	const reason = "deterministic mode"
+	settings.TransferInitiationPoolSize = &ste.ConfiguredInt{Value: 1, EnvVarName: settings.TransferInitiationPoolSize.EnvVarName, DefaultSourceDesc: reason}
+	settings.EnumerationPoolSize = &ste.ConfiguredInt{Value: 1, EnvVarName: settings.EnumerationPoolSize.EnvVarName, DefaultSourceDesc: reason}
+	settings.ParallelStatFiles = &ste.ConfiguredBool{Value: false, EnvVarName: settings.ParallelStatFiles.EnvVarName, DefaultSourceDesc: reason}
+
+	// the main pool is kept at its initial size, since tuning it depends on the throughput seen
+	if settings.AutoTuneMainPool() {
+		settings.MaxMainPoolSize = &ste.ConfiguredInt{Value: settings.InitialMainPoolSize, EnvVarName: settings.MaxMainPoolSize.EnvVarName, DefaultSourceDesc: reason}
+	}
+}
+
+func validateDeterministic(deterministic bool, autoConcurrency bool) error {
+	if deterministic && autoConcurrency {
+		return errors.New("deterministic cannot be combined with auto-concurrency, since tuning depends on the throughput seen")
+	}
+	return nil
+}
+
+// validateDeterministicCopy rejects the copy options that do their work in parallel, out of order
+func validateDeterministicCopy(deterministic bool, cooked cookedCopyCmdArgs) error {
+	if !deterministic {
+		return nil
+	}
+	if cooked.shardByPrefix > 0 {
+		return errors.New("shard-by-prefix cannot be combined with deterministic, since the shards are listed in parallel")
+	}
+	if cooked.precreateDirs {
+		return errors.New("precreate-directories cannot be combined with deterministic, since the directories are created in parallel")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// directoryMarkerMetadataKey is set on directory markers, as Hadoop's WASB driver does for the directories it creates in Blob storage
+const directoryMarkerMetadataKey = "hdi_isfolder"
+
+// createDirectoryMarkers uploads an empty marker blob, named for the directory with a trailing slash (e.g. dir/), for each directory
+// under the source, so that tools that expect directories to be marked in a flat namespace, such as Hadoop and Spark, can find them.
+// Like precreateDirectories, the source is listed an extra time to find the directories.
+func (cca *cookedCopyCmdArgs) createDirectoryMarkers(ctx context.Context, traverser resourceTraverser, filters []objectFilter, isDestDir bool) error {
+	dirs := make(map[string]bool)
+	processor := func(object storedObject) error {
+		dstRelativePath := cca.makeEscapedRelativePath(false, isDestDir, object)
+		if object.entityType != common.EEntityType.Folder() {
+			dstRelativePath = dstRelativePath[:strings.LastIndex(dstRelativePath, "/")+1] // the file's parent
+		}
+		addDirectoryAndParents(dirs, dstRelativePath)
+		return nil
+	}
+	if err := traverser.traverse(noPreProccessor, processor, filters); err != nil {
+		return fmt.Errorf("cannot list the source to find the directories to mark: %s", err.Error())
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	container, destinationDir, err := cca.destinationContainer(ctx)
+	if err != nil {
+		return err
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted) // just so that the order is predictable
+	err = createDirectoriesInParallel(sorted, func(dir string) error {
+		return createDirectoryMarker(ctx, container, destinationDir, dir)
+	})
+	if err != nil {
+		return err
+	}
+
+	if ste.JobsAdmin != nil {
+		ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Created %d directory markers at the destination, before scheduling the transfers", len(dirs)), pipeline.LogInfo)
+	}
+	return nil
+}
+
+// destinationContainer returns the container that an upload to Blob storage is going to, and the virtual directory in it, if any
+func (cca *cookedCopyCmdArgs) destinationContainer(ctx context.Context) (container azblob.ContainerURL, destinationDir string, err error) {
+	dstCredInfo, _, err := getCredentialInfoForLocation(ctx, cca.fromTo.To(), cca.destination.Value, cca.destination.SAS, false)
+	if err != nil {
+		return
+	}
+	p, err := createBlobPipeline(ctx, dstCredInfo)
+	if err != nil {
+		return
+	}
+	rootURL, err := cca.destination.FullURL()
+	if err != nil {
+		return
+	}
+	rootParts := azblob.NewBlobURLParts(*rootURL)
+	return azblob.NewContainerURL(copyHandlerUtil{}.getContainerUrl(rootParts), p), rootParts.BlobName, nil
+}
+
+// blobNameAtDestination is the name of the blob for the path, which is escaped and relative to the destination's virtual directory, if any
+func blobNameAtDestination(destinationDir string, relativePath string) (string, error) {
+	name, err := url.PathUnescape(strings.Trim(relativePath, "/"))
+	if err != nil {
+		return "", err
+	}
+	if destinationDir = strings.Trim(destinationDir, "/"); destinationDir != "" {
+		name = destinationDir + "/" + name
+	}
+	return name, nil
+}
+
+// directoryMarkerName is the name of the marker blob for the directory, which is escaped and relative to the destination's virtual directory, if any
+func directoryMarkerName(destinationDir string, dir string) (string, error) {
+	name, err := blobNameAtDestination(destinationDir, dir)
+	if err != nil {
+		return "", err
+	}
+	return name + "/", nil
+}
+
+func createDirectoryMarker(ctx context.Context, container azblob.ContainerURL, destinationDir string, dir string) error {
+	name, err := directoryMarkerName(destinationDir, dir)
+	if err != nil {
+		return err
+	}
+	_, err = container.NewBlockBlobURL(name).Upload(ctx, bytes.NewReader(nil), azblob.BlobHTTPHeaders{},
+		azblob.Metadata{directoryMarkerMetadataKey: "true"}, azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create the directory marker %s at the destination: %s", name, err.Error())
+	}
+	return nil
+}
+
+// isDirectoryMarker reports whether the blob marks a directory, either by being empty and named with a trailing slash,
+// or by the metadata that Hadoop's WASB driver, and createDirectoryMarkers, set on its markers
+func isDirectoryMarker(object storedObject) bool {
+	if object.entityType != common.EEntityType.File() || object.size != 0 {
+		return false
+	}
+	return strings.HasSuffix(object.relativePath, common.AZCOPY_PATH_SEPARATOR_STRING) ||
+		strings.HasSuffix(object.name, common.AZCOPY_PATH_SEPARATOR_STRING) ||
+		object.Metadata[directoryMarkerMetadataKey] == "true"
+}
+
+// recreateMarkedDirectory creates the local directory that a directory marker stands for, in place of downloading the marker as a file
+func (cca *cookedCopyCmdArgs) recreateMarkedDirectory(dstRelativePath string) error {
+	dir := filepath.Join(cca.destination.ValueLocal(), filepath.FromSlash(strings.Trim(dstRelativePath, "/")))
+	if err := common.CreateDirectoryIfNotExist(dir, common.NewFolderCreationTracker(common.EFolderPropertiesOption.NoFolders())); err != nil {
+		return fmt.Errorf("cannot create the directory %s, marked at the source: %s", dir, err.Error())
+	}
+	if cca.dirMode != 0 {
+		if err := os.Chmod(dir, os.FileMode(cca.dirMode)); err != nil {
+			return fmt.Errorf("cannot set the mode of the directory %s: %s", dir, err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-file-go/azfile"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// how many directories are created at once, when precreating the destination's directory tree
+const directoryPrecreationParallelism = 32
+
+// precreateDirectories creates the destination's whole directory tree before any files are scheduled, so that transfers
+// into deep hierarchies don't each have to create, and race each other to create, their parent directories.
+// The source is listed an extra time to find the directories. They are created a level at a time, since a directory
+// can't be created before its parent, with the directories of each level created in parallel.
+// Only Azure Files needs its directories created, so for other destinations this does nothing.
+func (cca *cookedCopyCmdArgs) precreateDirectories(ctx context.Context, traverser resourceTraverser, filters []objectFilter, isDestDir bool) error {
+	if cca.fromTo.To() != common.ELocation.File() {
+		return nil
+	}
+
+	dirs := make(map[string]bool)
+	processor := func(object storedObject) error {
+		object.containerName, object.dstContainerName = "", "" // as for the transfers, when the source is below the service level
+		dstRelativePath := cca.makeEscapedRelativePath(false, isDestDir, object)
+		if object.entityType != common.EEntityType.Folder() {
+			dstRelativePath = dstRelativePath[:strings.LastIndex(dstRelativePath, "/")+1] // the file's parent
+		}
+		addDirectoryAndParents(dirs, dstRelativePath)
+		return nil
+	}
+	if err := traverser.traverse(noPreProccessor, processor, filters); err != nil {
+		return fmt.Errorf("cannot list the source to find the directories to create: %s", err.Error())
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	dstCredInfo, _, err := getCredentialInfoForLocation(ctx, cca.fromTo.To(), cca.destination.Value, cca.destination.SAS, false)
+	if err != nil {
+		return err
+	}
+	p, err := createFilePipeline(ctx, dstCredInfo)
+	if err != nil {
+		return err
+	}
+	rootURL, err := cca.destination.FullURL()
+	if err != nil {
+		return err
+	}
+	root := azfile.NewDirectoryURL(*rootURL, p)
+
+	for _, level := range directoriesByDepth(dirs) {
+		if err = createDirectoriesInParallel(level, func(dir string) error { return createDirectory(ctx, root, dir) }); err != nil {
+			return err
+		}
+	}
+
+	if ste.JobsAdmin != nil {
+		ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Created (or found) %d directories at the destination, before scheduling the transfers", len(dirs)), pipeline.LogInfo)
+	}
+	return nil
+}
+
+// addDirectoryAndParents adds the directory, which is relative to the destination, and all the directories above it
+func addDirectoryAndParents(dirs map[string]bool, dir string) {
+	dir = strings.Trim(dir, "/")
+	for dir != "" && !dirs[dir] {
+		dirs[dir] = true
+		i := strings.LastIndex(dir, "/")
+		if i < 0 {
+			return
+		}
+		dir = dir[:i]
+	}
+}
+
+// directoriesByDepth groups the directories by the number of directories above them, shallowest first
+func directoriesByDepth(dirs map[string]bool) [][]string {
+	levels := make([][]string, 0)
+	for dir := range dirs {
+		depth := strings.Count(dir, "/")
+		for len(levels) <= depth {
+			levels = append(levels, nil)
+		}
+		levels[depth] = append(levels[depth], dir)
+	}
+	for _, level := range levels {
+		sort.Strings(level) // just so that the order is predictable
+	}
+	return levels
+}
+
+// createDirectoriesInParallel calls create for each of the directories, directoryPrecreationParallelism at a time
+func createDirectoriesInParallel(dirs []string, create func(dir string) error) error {
+	work := make(chan string)
+	errs := make(chan error, len(dirs))
+	wg := &sync.WaitGroup{}
+	for i := 0; i < directoryPrecreationParallelism && i < len(dirs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for dir := range work {
+				errs <- create(dir)
+			}
+		}()
+	}
+	for _, dir := range dirs {
+		work <- dir
+	}
+	close(work)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createDirectory creates the directory, unless it exists already. The directory is relative to root, and escaped.
+func createDirectory(ctx context.Context, root azfile.DirectoryURL, dir string) error {
+	dirURL := root
+	for _, segment := range strings.Split(dir, "/") {
+		name, err := url.PathUnescape(segment)
+		if err != nil {
+			return err
+		}
+		dirURL = dirURL.NewDirectoryURL(name)
+	}
+
+	_, err := dirURL.Create(ctx, azfile.Metadata{}, azfile.SMBProperties{})
+	if stgErr, ok := err.(azfile.StorageError); ok && stgErr.Response() != nil && stgErr.Response().StatusCode == http.StatusConflict {
+		return nil // already there
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create the directory %s at the destination: %s", dir, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// ensureDiskSpace refuses to start a download if the destination's volume doesn't have the space for it, plus the space
+// reserved with reserve-space-mb. It's done before any transfer is scheduled, so that running out of space doesn't leave
+// the download half done.
+func (cca *cookedCopyCmdArgs) ensureDiskSpace(traverser resourceTraverser, filters []objectFilter, isDestDir bool, findExisting bool) error {
+	needed, err := cca.spaceNeededForDownload(traverser, filters, isDestDir, findExisting)
+	if err != nil {
+		return err
+	}
+
+	volume := existingAncestor(cca.destination.ValueLocal())
+	free, err := freeDiskSpace(volume)
+	if err != nil {
+		WarnStdoutAndJobLog(fmt.Sprintf("Cannot check the free space at %s, so the download is started without checking it: %s", volume, err.Error()))
+		return nil
+	}
+
+	if needed+cca.reserveSpace > free {
+		reserved := ""
+		if cca.reserveSpace > 0 {
+			reserved = fmt.Sprintf(", plus the %s reserved with reserve-space-mb", byteSizeToString(cca.reserveSpace))
+		}
+		return fmt.Errorf("there isn't enough free space at %s for the download: it needs %s%s, but only %s is free. "+
+			"Free up some space, or set check-disk-space to false to start the download anyway", volume, byteSizeToString(needed), reserved, byteSizeToString(free))
+	}
+
+	if ste.JobsAdmin != nil {
+		ste.JobsAdmin.LogToJobLog(fmt.Sprintf("The download needs %s of the %s free at %s", byteSizeToString(needed), byteSizeToString(free), volume), pipeline.LogInfo)
+	}
+	return nil
+}
+
+// spaceNeededForDownload lists the source, to add up how much the download will write. When findExisting is true, files that
+// already exist at the destination only need as much more as the new content is bigger than they are (it's false when the source
+// is an account, since the names of the destination folders aren't known until the containers' names have been resolved)
+func (cca *cookedCopyCmdArgs) spaceNeededForDownload(traverser resourceTraverser, filters []objectFilter, isDestDir bool, findExisting bool) (int64, error) {
+	var needed int64
+	processor := func(object storedObject) error {
+		if object.entityType != common.EEntityType.File() || object.size <= 0 {
+			return nil
+		}
+		size := object.size
+		if findExisting {
+			object.containerName, object.dstContainerName = "", "" // as for the transfers, when the source is below the service level
+			fullPath := common.GenerateFullPath(cca.destination.ValueLocal(), cca.makeEscapedRelativePath(false, isDestDir, object))
+			if info, err := os.Stat(fullPath); err == nil && info.Mode().IsRegular() {
+				size -= info.Size()
+			}
+		}
+		if size > 0 {
+			needed += size
+		}
+		return nil
+	}
+	if err := traverser.traverse(noPreProccessor, processor, filters); err != nil {
+		return 0, fmt.Errorf("cannot list the source to find how much space the download needs: %s", err.Error())
+	}
+	return needed, nil
+}
+
+// existingAncestor returns the path, or the nearest of its parents that exists, since the destination folder may not have been created yet
+func existingAncestor(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
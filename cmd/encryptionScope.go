@@ -0,0 +1,97 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// preflightDestinationEncryptionScope reports, before anything is scheduled, the default encryption scope of the destination container.
+// AzCopy doesn't ask for a scope when it writes a blob, so each blob gets the default scope of its container, which may not be the one
+// the user has in mind, and which a container that denies overrides holds every blob to.
+// With verify-encryption-scope, every transfer would fail once its content had been written if that's another scope than the one expected,
+// so the copy fails here instead.
+// A container that doesn't exist yet, or whose properties can't be read, is left for each transfer to deal with.
+func (cca *cookedCopyCmdArgs) preflightDestinationEncryptionScope(ctx context.Context, containerName string) error {
+	dstCredInfo, _, err := getCredentialInfoForLocation(ctx, cca.fromTo.To(), cca.destination.Value, cca.destination.SAS, false)
+	if err != nil {
+		return err
+	}
+	p, err := initPipeline(ctx, cca.fromTo.To(), dstCredInfo)
+	if err != nil {
+		return err
+	}
+	dstURL, err := cca.destination.FullURL()
+	if err != nil {
+		return err
+	}
+
+	parts := azblob.NewBlobURLParts(*dstURL)
+	parts.ContainerName = containerName
+	parts.BlobName = ""
+	containerURL := azblob.NewContainerURL(parts.URL(), p)
+
+	props, err := containerURL.GetProperties(ctx, azblob.LeaseAccessConditions{})
+	if err != nil {
+		if stgErr, ok := err.(azblob.StorageError); !ok || stgErr.Response().StatusCode != http.StatusNotFound {
+			glcm.Info(fmt.Sprintf("Preflight: cannot read the default encryption scope of the destination container %s: %s", containerName, err.Error()))
+		}
+		return nil
+	}
+	if cca.verifyEncryptionScope != "" {
+		return checkDefaultEncryptionScope(containerName, cca.verifyEncryptionScope, props.DefaultEncryptionScope(), props.DenyEncryptionScopeOverride())
+	}
+	if msg := describeDefaultEncryptionScope(containerName, props.DefaultEncryptionScope(), props.DenyEncryptionScopeOverride()); msg != "" {
+		glcm.Info(msg)
+	}
+	return nil
+}
+
+// describeDefaultEncryptionScope says which encryption scope the blobs copied to the container will get, or nothing when the container
+// reports no default scope, in which case its blobs are encrypted with the account's key
+func describeDefaultEncryptionScope(container, defaultScope, denyOverride string) string {
+	if defaultScope == "" {
+		return ""
+	}
+	msg := fmt.Sprintf("Preflight: the blobs copied to the destination container %s will be encrypted with its default encryption scope %s", container, defaultScope)
+	if strings.EqualFold(denyOverride, "true") {
+		msg += ". The container doesn't allow its blobs to have any other scope"
+	}
+	return msg
+}
+
+// checkDefaultEncryptionScope fails if the default encryption scope of the container, which the blobs that AzCopy writes get,
+// isn't the one expected. A container that reports no default scope is left for each transfer to check
+func checkDefaultEncryptionScope(container, expectedScope, defaultScope, denyOverride string) error {
+	if defaultScope == "" || defaultScope == expectedScope {
+		return nil
+	}
+	msg := fmt.Sprintf("the blobs copied to the destination container %s would be encrypted with its default encryption scope %s, rather than %s, "+
+		"so each transfer would fail the check of verify-encryption-scope", container, defaultScope, expectedScope)
+	if strings.EqualFold(denyOverride, "true") {
+		msg += ". The container doesn't allow its blobs to have any other scope"
+	}
+	return fmt.Errorf("%s. Copy to a container whose default encryption scope is %s, or without verify-encryption-scope", msg, expectedScope)
+}
@@ -0,0 +1,156 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// endpointOverrides redirects the standard endpoints of the storage services, <account>.<service>.<cloud suffix>,
+// for sovereign clouds and Private Link. Requests are redirected by the pipelines as they are sent (see ste.NewEndpointPolicyFactory),
+// so that every command uses the override, while the URLs saved in job plans stay as the user gave them.
+type endpointOverrides struct {
+	suffix    string                     // replaces the cloud suffix of standard hosts, e.g. core.usgovcloudapi.net
+	endpoints map[string]*url.URL        // for each service that has one, the scheme and the domain that each account's host is put under
+	locations map[string]common.Location // for each domain in endpoints, the location it serves
+}
+
+// azcopyEndpoints holds the overrides given on the command line. Like azcopyTrailingDot, it is global,
+// since every command that addresses the storage services must address them the same way
+var azcopyEndpoints endpointOverrides
+
+// endpointServices maps the name of each service, as it appears in the second label of its standard hosts, to its location
+var endpointServices = map[string]common.Location{
+	"blob": common.ELocation.Blob(),
+	"file": common.ELocation.File(),
+	"dfs":  common.ELocation.BlobFS(),
+}
+
+// parseEndpointFlags parses the endpoint flags of the root command
+func parseEndpointFlags() (endpointOverrides, error) {
+	return parseEndpointOverrides(endpointSuffixRaw, map[string]string{"blob": blobEndpointRaw, "file": fileEndpointRaw, "dfs": dfsEndpointRaw})
+}
+
+// parseEndpointOverrides parses the values of --endpoint-suffix and of the per-service endpoint flags, keyed by service name.
+// Empty values mean no override.
+func parseEndpointOverrides(suffix string, serviceEndpoints map[string]string) (endpointOverrides, error) {
+	o := endpointOverrides{suffix: strings.ToLower(strings.Trim(strings.TrimSpace(suffix), "."))}
+	if strings.ContainsAny(o.suffix, "/:?#@ ") {
+		return o, fmt.Errorf("the endpoint suffix %q must be a domain name, such as core.usgovcloudapi.net", suffix)
+	}
+
+	for service, raw := range serviceEndpoints {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+			return o, fmt.Errorf("the %s endpoint %q must be a URL with only a scheme and a domain, such as https://privatelink.%s.core.windows.net", service, raw, service)
+		}
+		if o.endpoints == nil {
+			o.endpoints = make(map[string]*url.URL)
+			o.locations = make(map[string]common.Location)
+		}
+		host := strings.ToLower(u.Host)
+		o.endpoints[service] = &url.URL{Scheme: strings.ToLower(u.Scheme), Host: host}
+		o.locations[host] = endpointServices[service]
+	}
+	return o, nil
+}
+
+// apply returns the resource with its host redirected, if it is the standard host of a storage service in one of the clouds we know.
+// The account name stays the first label of the host, so that the accounts of a service to service copy are still told apart.
+// Anything else, such as a local path, a Private Link FQDN or a custom domain, is returned as it is.
+func (o endpointOverrides) apply(resource string) string {
+	if o.suffix == "" && len(o.endpoints) == 0 {
+		return resource
+	}
+
+	// splice the new host in, rather than rebuilding the URL, so that the path and query are passed on exactly as given
+	schemeEnd := strings.Index(resource, "://")
+	if schemeEnd < 0 || !startsWith(resource, "http") {
+		return resource
+	}
+	hostStart := schemeEnd + len("://")
+	hostEnd := len(resource)
+	if i := strings.IndexAny(resource[hostStart:], "/?#"); i >= 0 {
+		hostEnd = hostStart + i
+	}
+
+	labels := strings.SplitN(strings.ToLower(resource[hostStart:hostEnd]), ".", 3)
+	if len(labels) < 3 || !isStandardCloudSuffix(labels[2]) {
+		return resource
+	}
+	service := labels[1]
+	if _, ok := endpointServices[service]; !ok {
+		return resource
+	}
+
+	if e, ok := o.endpoints[service]; ok {
+		return e.Scheme + "://" + labels[0] + "." + e.Host + resource[hostEnd:]
+	}
+	if o.suffix != "" {
+		return resource[:hostStart] + labels[0] + "." + service + "." + o.suffix + resource[hostEnd:]
+	}
+	return resource
+}
+
+// locationOf returns the location served by the given host, if it is an account's host under one of the overriding endpoints.
+// Those needn't look like standard hosts, so their location can't be inferred from their names.
+func (o endpointOverrides) locationOf(host string) (common.Location, bool) {
+	host = strings.ToLower(host)
+	for domain, loc := range o.locations {
+		if strings.HasSuffix(host, "."+domain) {
+			return loc, true
+		}
+	}
+	return common.ELocation.Unknown(), false
+}
+
+// withTrustedSuffixes adds the overrides to a list of domain suffixes to which Azure Active Directory tokens may be sent,
+// since they were given as the endpoints of the storage services
+func (o endpointOverrides) withTrustedSuffixes(suffixes string) string {
+	trusted := make([]string, 0)
+	if suffixes = strings.TrimSpace(suffixes); suffixes != "" {
+		trusted = append(trusted, suffixes)
+	}
+	if o.suffix != "" {
+		trusted = append(trusted, "*."+o.suffix)
+	}
+	for _, e := range o.endpoints {
+		trusted = append(trusted, "*."+e.Host)
+	}
+	return strings.Join(trusted, ";")
+}
+
+// isStandardCloudSuffix returns true if the suffix is the one that the storage services use in one of the Azure clouds we know
+func isStandardCloudSuffix(suffix string) bool {
+	for _, s := range strings.Split(trustedSuffixesAAD, ";") {
+		if suffix == strings.TrimPrefix(s, "*.") {
+			return true
+		}
+	}
+	return false
+}
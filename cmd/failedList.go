@@ -0,0 +1,128 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// failedTransfer is a file whose transfer failed, as it's written to the failed list
+type failedTransfer struct {
+	relativePath string // relative to the source, unescaped, as list-of-files expects
+	status       common.TransferStatus
+	errorCode    int32
+}
+
+// listFailedTransfers returns the files whose transfers failed in the job. Folders are left out,
+// since listing a folder in list-of-files would transfer everything in it again
+func listFailedTransfers(jm ste.IJobMgr, from common.Location) []failedTransfer {
+	failed := make([]failedTransfer, 0)
+	for partNum := ste.PartNumber(0); true; partNum++ {
+		jpm, found := jm.JobPartMgr(partNum)
+		if !found {
+			break
+		}
+		plan := jpm.Plan()
+		for t := uint32(0); t < plan.NumTransfers; t++ {
+			jppt := plan.Transfer(t)
+			// skipped and cancelled transfers didn't fail, so they're left out
+			if !jppt.TransferStatus().IsFailure() || jppt.EntityType != common.EEntityType.File() {
+				continue
+			}
+			relativePath := unescapeSourceRelativePath(plan.TransferSrcRelativePath(t), from)
+			if relativePath == "" {
+				continue // the source is the file itself, so there's no path, relative to it, to list
+			}
+			failed = append(failed, failedTransfer{relativePath: relativePath, status: jppt.TransferStatus(), errorCode: jppt.ErrorCode()})
+		}
+	}
+	return failed
+}
+
+// unescapeSourceRelativePath reverses the escaping of a source path in the plan, so that it's written as list-of-files reads it
+func unescapeSourceRelativePath(relativePath string, from common.Location) string {
+	relativePath = strings.TrimPrefix(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)
+	if !from.IsRemote() {
+		return relativePath
+	}
+	parts := strings.Split(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)
+	for i, p := range parts {
+		if unescaped, err := url.PathUnescape(p); err == nil {
+			parts[i] = unescaped
+		}
+	}
+	return strings.Join(parts, common.AZCOPY_PATH_SEPARATOR_STRING)
+}
+
+// writeFailedTransfers writes one path per line, as list-of-files reads them, or, asCSV, a CSV file that also has each file's status and error code
+func writeFailedTransfers(w io.Writer, failed []failedTransfer, asCSV bool) error {
+	if !asCSV {
+		bw := bufio.NewWriter(w)
+		for _, f := range failed {
+			if _, err := bw.WriteString(f.relativePath + "\n"); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"path", "status", "error_code"}); err != nil {
+		return err
+	}
+	for _, f := range failed {
+		if err := cw.Write([]string{f.relativePath, f.status.String(), strconv.Itoa(int(f.errorCode))}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeFailedList writes the failed list of the job, once it's done, whether or not anything failed,
+// so that a script can always retry from it. A path ending in .csv gets the CSV form
+func (cca *cookedCopyCmdArgs) writeFailedList() error {
+	jm, exists := ste.JobsAdmin.JobMgr(cca.jobID)
+	if !exists {
+		return fmt.Errorf("job %s is not loaded", cca.jobID)
+	}
+	failed := listFailedTransfers(jm, cca.fromTo.From())
+
+	f, err := os.Create(cca.failedList)
+	if err != nil {
+		return err
+	}
+	if err = writeFailedTransfers(f, failed, strings.EqualFold(filepath.Ext(cca.failedList), ".csv")); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,207 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// the metadata key under which an upload with preserve-hardlinks records, on the empty blob that stands in for a hard link,
+// the path of the file it's linked to. The path is escaped, and relative to the blob's own virtual directory, like the target of a relative symlink.
+const hardLinkMetadataKey = "azcopy_hardlink"
+
+// hardLink is a file that is to be linked to another, both given by their escaped paths relative to the destination
+type hardLink struct {
+	path   string
+	target string
+}
+
+// hardLinks keeps track of the hard links among the files of a job. When uploading, the first file found of each set of linked files
+// is uploaded as usual, and the others are left to be created as empty blobs that record which file they are linked to.
+// When downloading, those blobs are left to be recreated as hard links, once the files they are linked to are there.
+// The processor is only ever called by one goroutine at a time, so no locking is needed.
+type hardLinks struct {
+	sourceRoot  string
+	firstLinked map[fileIdentity]string // when uploading: the escaped destination path of the first file found of each set
+	links       []hardLink
+}
+
+func newHardLinks(sourceRoot string) *hardLinks {
+	return &hardLinks{sourceRoot: sourceRoot, firstLinked: make(map[fileIdentity]string)}
+}
+
+// relativeLinkTarget returns the path of target relative to the directory of link, where both are relative to the same root
+func relativeLinkTarget(link string, target string) string {
+	linkDirs := strings.Split(path.Dir(strings.Trim(link, "/")), "/")
+	if linkDirs[0] == "." {
+		linkDirs = nil // the link is at the root
+	}
+	targetParts := strings.Split(strings.Trim(target, "/"), "/")
+
+	shared := 0
+	for shared < len(linkDirs) && shared < len(targetParts)-1 && linkDirs[shared] == targetParts[shared] {
+		shared++
+	}
+	return strings.Repeat("../", len(linkDirs)-shared) + strings.Join(targetParts[shared:], "/")
+}
+
+// findUploadLink reports whether the file is a hard link to one found earlier, in which case, rather than being uploaded,
+// it is kept to be created as a blob that records the link. If the file can't be checked, that is logged, and it's uploaded as usual.
+func (h *hardLinks) findUploadLink(object storedObject, dstRelativePath string) bool {
+	if object.entityType != common.EEntityType.File() || object.isSingleSourceFile() {
+		return false
+	}
+	fullPath := common.GenerateFullPath(h.sourceRoot, object.relativePath)
+
+	info, err := os.Lstat(fullPath) // a symlink is not a hard link to its target
+	if err != nil {
+		if ste.JobsAdmin != nil {
+			ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Cannot check whether %s is a hard link: %s", fullPath, err.Error()), pipeline.LogWarning)
+		}
+		return false
+	}
+	id, linked := hardLinkIdentity(info)
+	if !linked {
+		return false
+	}
+
+	first, seen := h.firstLinked[id]
+	if !seen {
+		h.firstLinked[id] = dstRelativePath
+		return false
+	}
+	h.links = append(h.links, hardLink{path: dstRelativePath, target: relativeLinkTarget(dstRelativePath, first)})
+	return true
+}
+
+// createLinkBlobs creates the empty blobs that stand in for the hard links found while uploading
+func (h *hardLinks) createLinkBlobs(ctx context.Context, cca *cookedCopyCmdArgs) error {
+	if len(h.links) == 0 {
+		return nil
+	}
+
+	container, destinationDir, err := cca.destinationContainer(ctx)
+	if err != nil {
+		return err
+	}
+	targets := make(map[string]string, len(h.links))
+	paths := make([]string, 0, len(h.links))
+	for _, link := range h.links {
+		targets[link.path] = link.target
+		paths = append(paths, link.path)
+	}
+	err = createDirectoriesInParallel(paths, func(linkPath string) error {
+		name, err := blobNameAtDestination(destinationDir, linkPath)
+		if err != nil {
+			return err
+		}
+		_, err = container.NewBlockBlobURL(name).Upload(ctx, bytes.NewReader(nil), azblob.BlobHTTPHeaders{},
+			azblob.Metadata{hardLinkMetadataKey: targets[linkPath]}, azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil)
+		if err != nil {
+			return fmt.Errorf("cannot create the blob for the hard link %s at the destination: %s", name, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if ste.JobsAdmin != nil {
+		ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Recorded %d hard links at the destination, instead of uploading the files again", len(h.links)), pipeline.LogInfo)
+	}
+	return nil
+}
+
+// findDownloadLink reports whether the blob stands in for a hard link, in which case, rather than being downloaded,
+// it is kept to be recreated as a hard link once the job is done
+func (h *hardLinks) findDownloadLink(object storedObject, dstRelativePath string) bool {
+	target, ok := object.Metadata[hardLinkMetadataKey]
+	if !ok || object.entityType != common.EEntityType.File() || object.isSingleSourceFile() {
+		return false
+	}
+	h.links = append(h.links, hardLink{path: dstRelativePath, target: target})
+	return true
+}
+
+// recreateLinks links each file kept by findDownloadLink to the file it was linked to, under the local destination root,
+// and returns how many of them could not be linked. Those are reported as they're found.
+func (h *hardLinks) recreateLinks(destinationRoot string, overwrite common.OverwriteOption) (failed int) {
+	for _, link := range h.links {
+		if err := recreateLink(destinationRoot, link, overwrite); err != nil {
+			glcm.Info(err.Error())
+			failed++
+		}
+	}
+	return failed
+}
+
+func recreateLink(destinationRoot string, link hardLink, overwrite common.OverwriteOption) error {
+	linkRelativePath, err := url.PathUnescape(strings.Trim(link.path, "/"))
+	if err != nil {
+		return fmt.Errorf("cannot recreate the hard link %s: %s", link.path, err.Error())
+	}
+	linkPath := filepath.Join(destinationRoot, filepath.FromSlash(linkRelativePath))
+	targetRelativePath, err := url.PathUnescape(link.target)
+	if err != nil {
+		return fmt.Errorf("cannot recreate the hard link %s, since the file it's linked to is malformed: %s", linkPath, err.Error())
+	}
+	targetPath := filepath.Join(filepath.Dir(linkPath), filepath.FromSlash(targetRelativePath))
+	if rel, err := filepath.Rel(destinationRoot, targetPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("cannot recreate the hard link %s, since the file it's linked to, %s, is outside the destination", linkPath, targetPath)
+	}
+
+	targetInfo, err := os.Stat(targetPath)
+	if err != nil {
+		return fmt.Errorf("cannot recreate the hard link %s, since the file it's linked to wasn't downloaded: %s", linkPath, err.Error())
+	}
+	if existing, err := os.Lstat(linkPath); err == nil {
+		if os.SameFile(existing, targetInfo) {
+			return nil // already linked, e.g. by an earlier download
+		}
+		if overwrite == common.EOverwriteOption.False() {
+			return fmt.Errorf("did not recreate the hard link %s, since a file is already there and overwrite is false", linkPath)
+		}
+		if err = os.Remove(linkPath); err != nil {
+			return fmt.Errorf("cannot replace %s with a hard link: %s", linkPath, err.Error())
+		}
+	}
+
+	if err = common.CreateDirectoryIfNotExist(filepath.Dir(linkPath), common.NewFolderCreationTracker(common.EFolderPropertiesOption.NoFolders())); err != nil {
+		return fmt.Errorf("cannot create the directory for the hard link %s: %s", linkPath, err.Error())
+	}
+	if err = os.Link(targetPath, linkPath); err != nil {
+		return fmt.Errorf("cannot recreate the hard link %s: %s", linkPath, err.Error())
+	}
+	return nil
+}
@@ -28,6 +28,7 @@ Copies source data to a destination location. The supported directions are:
   - Azure Files (SAS) -> Azure Files (SAS)
   - Azure Files (SAS) -> Azure Blob (SAS or OAuth authentication)
   - AWS S3 (Access Key) -> Azure Block Blob (SAS or OAuth authentication)
+  - Any HTTP(S) URL (public, or with a token in its query string) -> Azure Blob (SAS or OAuth authentication)
 
 Please refer to the examples for more information.
 
@@ -103,6 +104,10 @@ Download a single file by using OAuth and then piping the output to a file (bloc
   
   - azcopy cp "https://[account].blob.core.windows.net/[container]/[path/to/blob]" --from-to BlobPipe > "/path/to/file.txt"
 
+Download a single compressed file, and decompress it on the fly with a command that AzCopy runs, without writing the compressed file to disk:
+
+  - azcopy cp "https://[account].blob.core.windows.net/[container]/[path/to/blob.gz]?[SAS]" --pipe-to "gzip -d > /path/to/file.txt"
+
 Download an entire directory by using a SAS token:
   
   - azcopy cp "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" "/path/to/dir" --recursive=true
@@ -167,6 +172,10 @@ Copy a subset of buckets by using a wildcard symbol (*) in the bucket name. Like
 
   - azcopy cp "https://s3.amazonaws.com/[bucket*name]/" "https://[destaccount].blob.core.windows.net?[SAS]" --recursive=true
 
+Copy a file from any web server to Blob Storage. Redirects are followed. If the server reports the file's length and supports range requests, the service fetches it directly. Otherwise, it is streamed through AzCopy, without a job, so it can't be resumed and only the overwrite, block-size and block-blob-tier flags and the preserved properties apply.
+
+  - azcopy cp "https://[host]/[path/to/file]" "https://[destaccount].blob.core.windows.net/[container]?[SAS]" --from-to=HttpBlob
+
 Transfer files and directories to Azure Storage account and set the given query-string encoded tags on the blob. 
 
 	- To set tags {key = "bla bla", val = "foo"} and {key = "bla bla 2", val = "bar"}, use the following syntax :
@@ -184,6 +193,49 @@ const envCmdLongDescription = `Shows the environment variables that you can use
 ` + environmentVariableNotice
 
 // ===================================== JOBS COMMAND ===================================== //
+const configCmdShortDescription = "Sub-commands related to AzCopy's configuration"
+
+const configCmdLongDescription = "Sub-commands related to AzCopy's configuration."
+
+const configCmdExample = `Show the settings that a copy would run with:
+  - azcopy config show copy "/path/to/dir" "https://[account].blob.core.windows.net/[container]" --recursive --block-size-mb=16
+
+Show the settings that apply to every command:
+  - azcopy config show`
+
+const showConfigCmdShortDescription = "Shows the settings that a command would run with, and where each one comes from"
+
+const showConfigCmdLongDescription = `Shows the settings that a command would run with, such as the concurrency, block size, bandwidth cap, log level,
+and, for each resource, its endpoint and how AzCopy would authenticate to it. Each setting is shown with where its value comes from:
+a default, an environment variable, a flag, or the resource argument itself.
+
+Give the command, with its arguments and flags, after 'config show', exactly as you would run it. The command is not run,
+and nothing is sent to the resources. So, where the authentication method depends on the resource (e.g. whether a container is public),
+what is shown is what AzCopy would try.
+
+` + environmentVariableNotice
+
+const runCmdShortDescription = "Runs a copy or sync that was saved as a job definition"
+
+const runCmdLongDescription = `Runs a copy or sync that was saved as a job definition file, with the export-job-definition flag of copy or sync.
+
+The file gives the command, its source and destination, and its flags, so that a complex job that recurs can be reviewed,
+kept under version control and scheduled. It's written in YAML, and can be edited by hand. SAS tokens are never stored in it:
+each one is read, when the job is run, from the environment variable that the file names in sasFromEnv
+(` + sourceSASEnvVar + ` and ` + destinationSASEnvVar + `, unless the file is edited).
+
+Flags given after the file name are added to those in the file, and take precedence over them.`
+
+const runCmdExample = `Save a copy as a job definition, instead of running it:
+  - azcopy copy "/path/to/dir" "https://[account].blob.core.windows.net/[container]?[SAS]" --recursive --include-pattern="*.jpg" --export-job-definition=job.yaml
+
+Run it, with the SAS token in the environment:
+  - export ` + destinationSASEnvVar + `="[SAS]"
+  - azcopy run job.yaml
+
+Run it with a higher log level than the file gives:
+  - azcopy run job.yaml --log-level=DEBUG`
+
 const jobsCmdShortDescription = "Sub-commands related to managing jobs"
 
 const jobsCmdLongDescription = "Sub-commands related to managing jobs."
@@ -223,12 +275,30 @@ Note that you can customize the location where log and plan files are saved. See
 
 const cleanJobsCmdExample = "  azcopy jobs clean --with-status=completed"
 
+const migrateJobsCmdShortDescription = "Convert the plan files of jobs that were run by an earlier version of AzCopy, so that they can be resumed"
+
+const migrateJobsCmdLongDescription = `
+Convert the plan files of jobs that were run by an earlier version of AzCopy into the format used by this version, so that those jobs can be resumed after upgrading.
+If a job ID is given, only that job is converted. Otherwise, all jobs are.
+
+Not every change to the format can be converted. The jobs that cannot be converted are listed, along with the reason, and are left as they are.
+
+Note that you can customize the location where log and plan files are saved. See the env command to learn more.`
+
+const migrateJobsCmdExample = "  azcopy jobs migrate e52247de-0323-b14d-4cc8-76e0be2e2d44"
+
 // ===================================== LIST COMMAND ===================================== //
 const listCmdShortDescription = "List the entities in a given resource"
 
-const listCmdLongDescription = `List the entities in a given resource. Blob, Files, and ADLS Gen 2 containers, folders, and accounts are supported.`
+const listCmdLongDescription = `List the entities in a given resource. Blob, Files, and ADLS Gen 2 containers, folders, and accounts are supported.
+
+Use --columns to choose which properties are printed for each entity, and --sort-by (optionally with --reverse) to order them.
+When --output-type is json, each entity is printed as an Info message whose content is a JSON object carrying the same columns.`
 
-const listCmdExample = "azcopy list [containerURL]"
+const listCmdExample = `azcopy list [containerURL]
+
+List the largest blobs first, showing their last modified time and access tier:
+  - azcopy list [containerURL] --sort-by=size --reverse --columns=name,size,mtime,tier`
 
 // ===================================== LOGIN COMMAND ===================================== //
 const loginCmdShortDescription = "Log in to Azure Active Directory (AD) to access Azure Storage resources."
@@ -341,6 +411,26 @@ Remove a single directory from a Blob Storage account that has a hierarchical na
    - azcopy rm "https://[account].dfs.core.windows.net/[container]/[path/to/directory]?[SAS]"
 `
 
+// ===================================== UNDELETE COMMAND ===================================== //
+const undeleteCmdShortDescription = "Restore soft-deleted blobs"
+
+const undeleteCmdLongDescription = `
+Restore the soft-deleted blobs in a container, or in a virtual directory within it, or a single soft-deleted blob.
+Blobs can only be restored if they were removed while soft delete was enabled for the account, and only until its retention period ends.
+Restoring a blob also restores its soft-deleted snapshots.
+
+Use 'azcopy remove' with --require-soft-delete to make sure that removals can be undone like this.`
+
+const undeleteCmdExample = `
+Restore a single blob:
+
+   - azcopy undelete "https://[account].blob.core.windows.net/[container]/[path/to/blob]?[SAS]"
+
+Restore every soft-deleted blob in a virtual directory, including its subdirectories:
+
+   - azcopy undelete "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true
+`
+
 // ===================================== SYNC COMMAND ===================================== //
 const syncCmdShortDescription = "Replicate source to the destination location"
 
@@ -356,6 +446,7 @@ The sync command differs from the copy command in several ways:
   1. By default, the recursive flag is true and sync copies all subdirectories. Sync only copies the top-level files inside a directory if the recursive flag is false.
   2. When syncing between virtual directories, add a trailing slash to the path (refer to examples) if there's a blob with the same name as one of the virtual directories.
   3. If the 'deleteDestination' flag is set to true or prompt, then sync will delete files and blobs at the destination that are not present at the source.
+     If it is set to tombstone, then sync will instead mark them as deleted in their metadata, so that they can be recovered.
 
 Advanced:
 
@@ -0,0 +1,83 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// validatePreserveImmutability checks that blobs are being copied from Blob storage, since only blobs have immutability to preserve
+func validatePreserveImmutability(preserve bool, fromTo common.FromTo) error {
+	if preserve && fromTo != common.EFromTo.BlobBlob() {
+		return errors.New("preserve-immutability is only supported when copying from Blob storage to Blob storage")
+	}
+	return nil
+}
+
+// checkDestinationAcceptsImmutability fails unless the destination container has version-level immutability support enabled,
+// without which the service refuses the immutability policies and legal holds of individual blobs. It's checked before
+// anything is copied, rather than having every transfer fail once its content has been written.
+func (cca *cookedCopyCmdArgs) checkDestinationAcceptsImmutability(ctx context.Context) error {
+	dstCredInfo, _, err := getCredentialInfoForLocation(ctx, cca.fromTo.To(), cca.destination.Value, cca.destination.SAS, false)
+	if err != nil {
+		return err
+	}
+	p, err := initPipeline(ctx, cca.fromTo.To(), dstCredInfo)
+	if err != nil {
+		return err
+	}
+	dstURL, err := cca.destination.FullURL()
+	if err != nil {
+		return err
+	}
+
+	parts := azblob.NewBlobURLParts(*dstURL)
+	if parts.ContainerName == "" {
+		return errors.New("preserve-immutability requires the destination to be an existing container, or a directory in one, " +
+			"since the containers that AzCopy creates don't have immutability support enabled")
+	}
+	parts.BlobName = ""
+	containerURL := azblob.NewContainerURL(parts.URL(), p)
+
+	ctx = context.WithValue(ctx, ste.ServiceAPIVersionOverride, ste.BlobImmutabilityServiceVersion)
+	props, err := containerURL.GetProperties(ctx, azblob.LeaseAccessConditions{})
+	if err != nil {
+		return fmt.Errorf("cannot check whether the destination container %s accepts immutability settings: %s", parts.ContainerName, err.Error())
+	}
+	if !acceptsImmutability(props.Response().Header) {
+		return fmt.Errorf("the destination container %s cannot accept immutability settings, since it doesn't have version-level immutability support enabled. "+
+			"Enable it, or copy without preserve-immutability", parts.ContainerName)
+	}
+	return nil
+}
+
+// acceptsImmutability reads, from the response to Get Container Properties, whether the container has version-level immutability support enabled
+func acceptsImmutability(h http.Header) bool {
+	return strings.EqualFold(h.Get("x-ms-immutable-storage-with-versioning-enabled"), "true")
+}
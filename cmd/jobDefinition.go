@@ -0,0 +1,285 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// the environment variables that the SAS tokens of exported job definitions are read from
+const (
+	sourceSASEnvVar      = "AZCOPY_SOURCE_SAS"
+	destinationSASEnvVar = "AZCOPY_DESTINATION_SAS"
+)
+
+const exportJobDefinitionFlag = "export-job-definition"
+
+const exportJobDefinitionFlagHelp = "Instead of running the job, save it to this file as a job definition, to be run later, or on a schedule, with azcopy run. " +
+	"SAS tokens are not saved in the file. When the job is run, they're read from the " + sourceSASEnvVar + " and " + destinationSASEnvVar + " environment variables."
+
+const jobDefinitionHeader = `# An AzCopy job definition. Run it with: azcopy run <this file>
+# SAS tokens are never stored here. Each is read, when the job is run, from the environment variable named by sasFromEnv.
+`
+
+// jobDefinition is a copy or sync command, with its arguments and flags, saved so that it can be reviewed, kept under
+// version control and run again with the run command. Secrets are never part of it: a SAS token is referenced by the name
+// of the environment variable that holds it.
+type jobDefinition struct {
+	Command     string                 `yaml:"command"`
+	Source      jobDefinitionResource  `yaml:"source"`
+	Destination jobDefinitionResource  `yaml:"destination"`
+	Flags       map[string]interface{} `yaml:"flags,omitempty"` // a list, for a flag that can be given more than once
+}
+
+type jobDefinitionResource struct {
+	Location   string `yaml:"location"`
+	SASFromEnv string `yaml:"sasFromEnv,omitempty"`
+}
+
+// newJobDefinition captures the command as it was invoked: its arguments, and the flags that were set on the command line
+func newJobDefinition(cmd *cobra.Command, args []string) (def jobDefinition, err error) {
+	if len(args) != 2 {
+		return def, fmt.Errorf("only a %s from a source to a destination can be saved as a job definition, not one that uses a pipe", cmd.Name())
+	}
+	def.Command = cmd.Name()
+	if def.Source, err = newJobDefinitionResource(args[0], sourceSASEnvVar); err != nil {
+		return def, err
+	}
+	if def.Destination, err = newJobDefinitionResource(args[1], destinationSASEnvVar); err != nil {
+		return def, err
+	}
+
+	def.Flags = make(map[string]interface{})
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if err != nil || f.Name == exportJobDefinitionFlag {
+			return
+		}
+		var values []string
+		switch f.Value.Type() {
+		case "stringArray":
+			values, _ = cmd.Flags().GetStringArray(f.Name)
+		case "stringSlice":
+			values, _ = cmd.Flags().GetStringSlice(f.Name)
+		case "bool":
+			def.Flags[f.Name], _ = strconv.ParseBool(f.Value.String())
+			return
+		default:
+			if hasSAS(f.Value.String()) {
+				err = fmt.Errorf("the value of --%s contains a SAS token, which can't be saved in a job definition", f.Name)
+			}
+			def.Flags[f.Name] = f.Value.String()
+			return
+		}
+		for _, v := range values {
+			if hasSAS(v) {
+				err = fmt.Errorf("a value of --%s contains a SAS token, which can't be saved in a job definition", f.Name)
+			}
+		}
+		def.Flags[f.Name] = values
+	})
+	return def, err
+}
+
+// newJobDefinitionResource takes the SAS token, if there is one, out of the resource, and names the environment variable to read it from instead.
+// A local path is saved as an absolute one, since the job may be run from another directory
+func newJobDefinitionResource(arg string, sasEnvVar string) (jobDefinitionResource, error) {
+	loc := inferArgumentLocation(arg)
+	if !loc.IsRemote() {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return jobDefinitionResource{}, fmt.Errorf("cannot find the absolute path of %s: %w", arg, err)
+		}
+		if strings.HasSuffix(arg, "/") || strings.HasSuffix(arg, string(os.PathSeparator)) {
+			abs += string(os.PathSeparator) // Abs drops it, but it can change what's copied
+		}
+		return jobDefinitionResource{Location: abs}, nil
+	}
+
+	resource, _ := SplitResourceString(arg, loc)
+	if resource.Value == "" {
+		return jobDefinitionResource{}, fmt.Errorf("cannot read the location %s", common.URLStringExtension(arg).RedactSecretQueryParamForLogging())
+	}
+	r := jobDefinitionResource{Location: resource.Value}
+	if resource.ExtraQuery != "" {
+		r.Location += "?" + resource.ExtraQuery
+	}
+	if resource.SAS != "" {
+		r.SASFromEnv = sasEnvVar
+	}
+	if hasSAS(r.Location) {
+		return jobDefinitionResource{}, fmt.Errorf("cannot take the SAS token out of %s, so it can't be saved in a job definition", common.URLStringExtension(arg).RedactSecretQueryParamForLogging())
+	}
+	return r, nil
+}
+
+// hasSAS says whether the value is a URL with a signature in it
+func hasSAS(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	for key := range u.Query() {
+		if strings.EqualFold(key, "sig") {
+			return true
+		}
+	}
+	return false
+}
+
+// commandLine returns the arguments to run the job with, reading the SAS tokens from the environment
+func (d jobDefinition) commandLine(getenv func(string) string) ([]string, error) {
+	if d.Command != "copy" && d.Command != "sync" {
+		return nil, fmt.Errorf("the command of a job definition must be copy or sync, not %q", d.Command)
+	}
+	src, err := d.Source.resolve("source", getenv)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := d.Destination.resolve("destination", getenv)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{d.Command, src, dst}
+
+	names := make([]string, 0, len(d.Flags))
+	for name := range d.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names) // so that the command line is the same each time
+	for _, name := range names {
+		switch v := d.Flags[name].(type) {
+		case nil:
+			args = append(args, "--"+name) // e.g. "recursive:" on its own, in a definition written by hand
+		case []interface{}:
+			for _, item := range v {
+				args = append(args, fmt.Sprintf("--%s=%v", name, item))
+			}
+		default:
+			args = append(args, fmt.Sprintf("--%s=%v", name, v))
+		}
+	}
+	return args, nil
+}
+
+func (r jobDefinitionResource) resolve(role string, getenv func(string) string) (string, error) {
+	if r.Location == "" {
+		return "", fmt.Errorf("the job definition has no %s location", role)
+	}
+	if hasSAS(r.Location) {
+		return "", fmt.Errorf("the %s location contains a SAS token, which mustn't be saved in a job definition. Put it in an environment variable, named by sasFromEnv", role)
+	}
+	if r.SASFromEnv == "" {
+		return r.Location, nil
+	}
+
+	sas := strings.TrimPrefix(getenv(r.SASFromEnv), "?")
+	if sas == "" {
+		return "", fmt.Errorf("the environment variable %s, which holds the SAS token of the %s, is not set", r.SASFromEnv, role)
+	}
+	if strings.Contains(r.Location, "?") {
+		return r.Location + "&" + sas, nil
+	}
+	return r.Location + "?" + sas, nil
+}
+
+// exportJobDefinition saves the command, as it was invoked, to the file, and returns a message saying how to run it
+func exportJobDefinition(cmd *cobra.Command, args []string, path string) (string, error) {
+	def, err := newJobDefinition(cmd, args)
+	if err != nil {
+		return "", err
+	}
+	content, err := yaml.Marshal(def)
+	if err != nil {
+		return "", err
+	}
+	if err = ioutil.WriteFile(path, append([]byte(jobDefinitionHeader), content...), 0644); err != nil {
+		return "", fmt.Errorf("cannot write the job definition to %s: %w", path, err)
+	}
+
+	msg := fmt.Sprintf("Saved the job definition to %s. Run it with: azcopy run %s", path, path)
+	for _, r := range []jobDefinitionResource{def.Source, def.Destination} {
+		if r.SASFromEnv != "" {
+			msg += fmt.Sprintf("\nBefore running it, set %s to the SAS token of %s", r.SASFromEnv, r.Location)
+		}
+	}
+	return msg, nil
+}
+
+func readJobDefinition(path string) (def jobDefinition, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return def, fmt.Errorf("cannot read the job definition: %w", err)
+	}
+	if err = yaml.UnmarshalStrict(content, &def); err != nil {
+		return def, fmt.Errorf("cannot parse the job definition %s: %w", path, err)
+	}
+	return def, nil
+}
+
+func init() {
+	runCmd := &cobra.Command{
+		Use:     "run [job definition file] [flags]",
+		Short:   runCmdShortDescription,
+		Long:    runCmdLongDescription,
+		Example: runCmdExample,
+		// any flags are passed on to the command being run, which parses them (along with the root flags) itself
+		DisableFlagParsing: true,
+		// the command being run does what the root command would do before it, so it mustn't be done twice
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+				_ = cmd.Help()
+				glcm.Exit(nil, common.EExitCode.Success())
+			}
+
+			def, err := readJobDefinition(args[0])
+			if err != nil {
+				glcm.Error(err.Error())
+			}
+			commandLine, err := def.commandLine(os.Getenv)
+			if err != nil {
+				glcm.Error(err.Error())
+			}
+
+			// flags given to run come last, so that they win over those in the definition
+			rootCmd.SetArgs(append(commandLine, args[1:]...))
+			if err = rootCmd.Execute(); err != nil {
+				glcm.Error(err.Error())
+			}
+		},
+	}
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,144 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// JobMigrationResult says what happened to the plan of one job that was written by an earlier version of AzCopy
+type JobMigrationResult struct {
+	JobID    common.JobID
+	Migrated bool
+	Reason   string `json:",omitempty"` // why the job could not be migrated
+}
+
+func init() {
+	var jobID common.JobID
+
+	jobsMigrateCmd := &cobra.Command{
+		Use:     "migrate [jobID]",
+		Short:   migrateJobsCmdShortDescription,
+		Long:    migrateJobsCmdLongDescription,
+		Example: migrateJobsCmdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("migrate command accepts at most one JobID")
+			}
+			if len(args) == 1 {
+				id, err := common.ParseJobID(args[0])
+				if err != nil {
+					return errors.New("invalid jobId given " + args[0])
+				}
+				jobID = id
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			results, err := handleMigrateJobsCommand(azcopyJobPlanFolder, jobID)
+			if err != nil {
+				glcm.Error(fmt.Sprintf("Failed to migrate job plan files due to error: %s.", err))
+			}
+			printJobMigrationResults(results)
+		},
+	}
+
+	jobsCmd.AddCommand(jobsMigrateCmd)
+}
+
+// handleMigrateJobsCommand migrates the plans of all the jobs in the plan folder that were written by an earlier version of AzCopy,
+// or only the plan of the given job, if there is one
+func handleMigrateJobsCommand(planFolder string, jobID common.JobID) ([]JobMigrationResult, error) {
+	files, err := ioutil.ReadDir(planFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	// group the plan files that need migrating by job, since the parts of a job are migrated together
+	toMigrate := make(map[common.JobID][]string)
+	for _, f := range files {
+		version, err := ste.JobPartPlanFileVersion(f.Name())
+		if err != nil || version == ste.DataSchemaVersion {
+			continue
+		}
+		id, err := common.ParseJobID(strings.Split(f.Name(), "--")[0])
+		if err != nil || (!jobID.IsEmpty() && id != jobID) {
+			continue
+		}
+		toMigrate[id] = append(toMigrate[id], f.Name())
+	}
+
+	results := make([]JobMigrationResult, 0, len(toMigrate))
+	for id, fileNames := range toMigrate {
+		result := JobMigrationResult{JobID: id, Migrated: true}
+		if err := ste.MigrateJobPlan(planFolder, fileNames); err != nil {
+			result.Migrated = false
+			result.Reason = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	// sort the results, so that they are always displayed in the same order
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].JobID.String() < results[j].JobID.String()
+	})
+	return results, nil
+}
+
+func printJobMigrationResults(results []JobMigrationResult) {
+	exitCode := common.EExitCode.Success()
+	for _, r := range results {
+		if !r.Migrated {
+			exitCode = common.EExitCode.Error()
+		}
+	}
+
+	glcm.Exit(func(format common.OutputFormat) string {
+		if format == common.EOutputFormat.Json() {
+			jsonOutput, err := json.Marshal(results)
+			common.PanicIfErr(err)
+			return string(jsonOutput)
+		}
+
+		if len(results) == 0 {
+			return "No job plan files need migrating."
+		}
+		var sb strings.Builder
+		for _, r := range results {
+			if r.Migrated {
+				sb.WriteString(fmt.Sprintf("Job %s: migrated, and can be resumed.\n", r.JobID))
+			} else {
+				sb.WriteString(fmt.Sprintf("Job %s: cannot be resumed, because %s.\n", r.JobID, r.Reason))
+			}
+		}
+		return sb.String()
+	}, exitCode)
+}
@@ -38,14 +38,17 @@ import (
 // TODO the progress reporting code is almost the same as the copy command, the copy-paste should be avoided
 type resumeJobController struct {
 	// generated
-	jobID common.JobID
+	jobID      common.JobID
+	fromTo     common.FromTo
+	failedOnly bool // only the failed transfers were resumed
 
 	// variables used to calculate progress
 	// intervalStartTime holds the last time value when the progress summary was fetched
 	// the value of this variable is used to calculate the throughput
 	// it gets updated every time the progress summary is fetched
-	intervalStartTime        time.Time
-	intervalBytesTransferred uint64
+	intervalStartTime          time.Time
+	intervalBytesTransferred   uint64
+	intervalTransfersCompleted uint32
 
 	// used to calculate job summary
 	jobStartTime time.Time
@@ -62,6 +65,7 @@ func (cca *resumeJobController) waitUntilJobCompletion(blocking bool) {
 	cca.jobStartTime = time.Now()
 	cca.intervalStartTime = time.Now()
 	cca.intervalBytesTransferred = 0
+	cca.intervalTransfersCompleted = 0
 
 	// hand over control to the lifecycle manager if blocking
 	if blocking {
@@ -99,6 +103,9 @@ func (cca *resumeJobController) ReportProgressOrExit(lcm common.LifecycleMgr) (t
 		if summary.TransfersFailed > 0 {
 			exitCode = common.EExitCode.Error()
 		}
+		if left := summary.TotalTransfers - summary.TransfersCompleted - summary.TransfersFailed - summary.TransfersSkipped; cca.failedOnly && left > 0 {
+			glcm.Info(fmt.Sprintf("%d transfers that hadn't failed were left as they were, so the job isn't complete. Resume it without --failed-only to transfer them.", left))
+		}
 
 		lcm.Exit(func(format common.OutputFormat) string {
 			if format == common.EOutputFormat.Json() {
@@ -107,7 +114,7 @@ func (cca *resumeJobController) ReportProgressOrExit(lcm common.LifecycleMgr) (t
 				return string(jsonOutput)
 			} else {
 				return fmt.Sprintf(
-					"\n\nJob %s summary\nElapsed Time (Minutes): %v\nNumber of File Transfers: %v\nNumber of Folder Property Transfers: %v\nTotal Number Of Transfers: %v\nNumber of Transfers Completed: %v\nNumber of Transfers Failed: %v\nNumber of Transfers Skipped: %v\nTotalBytesTransferred: %v\nFinal Job Status: %v\n",
+					"\n\nJob %s summary\nElapsed Time (Minutes): %v\nNumber of File Transfers: %v\nNumber of Folder Property Transfers: %v\nTotal Number Of Transfers: %v\nNumber of Transfers Completed: %v\nNumber of Transfers Failed: %v\nNumber of Transfers Skipped: %v%s\nTotalBytesTransferred: %v\nFinal Job Status: %v\n",
 					summary.JobID.String(),
 					ste.ToFixed(duration.Minutes(), 4),
 					summary.FileTransfers,
@@ -116,22 +123,25 @@ func (cca *resumeJobController) ReportProgressOrExit(lcm common.LifecycleMgr) (t
 					summary.TransfersCompleted,
 					summary.TransfersFailed,
 					summary.TransfersSkipped,
+					formatSkippedLockedStats(summary.TransfersSkippedLocked),
 					summary.TotalBytesTransferred,
 					summary.JobStatus)
 			}
 		}, exitCode)
 	}
 
-	var computeThroughput = func() float64 {
-		// compute the average throughput for the last time interval
+	var computeThroughput = func() (throughput float64, transferRate float64) {
+		// compute the average throughput, and the rate at which transfers complete, for the last time interval
 		bytesInMb := float64(float64(summary.BytesOverWire-cca.intervalBytesTransferred) / float64(base10Mega))
+		transfersCompleted := float64(summary.TransfersCompleted - cca.intervalTransfersCompleted)
 		timeElapsed := time.Since(cca.intervalStartTime).Seconds()
 
-		// reset the interval timer and byte count
+		// reset the interval timer, byte count and transfer count
 		cca.intervalStartTime = time.Now()
 		cca.intervalBytesTransferred = summary.BytesOverWire
+		cca.intervalTransfersCompleted = summary.TransfersCompleted
 
-		return common.Iffloat64(timeElapsed != 0, bytesInMb/timeElapsed, 0) * 8
+		return common.Iffloat64(timeElapsed != 0, bytesInMb/timeElapsed, 0) * 8, common.Iffloat64(timeElapsed != 0, transfersCompleted/timeElapsed, 0)
 	}
 
 	glcm.Progress(func(format common.OutputFormat) string {
@@ -147,18 +157,23 @@ func (cca *resumeJobController) ReportProgressOrExit(lcm common.LifecycleMgr) (t
 				scanningString = ""
 			}
 
-			throughput := computeThroughput()
+			throughput, transferRate := computeThroughput()
 			throughputString := fmt.Sprintf("2-sec Throughput (Mb/s): %v", ste.ToFixed(throughput, 4))
 			if throughput == 0 {
 				// As there would be case when no bits sent from local, e.g. service side copy, when throughput = 0, hide it.
 				throughputString = ""
 			}
+			if cca.fromTo.To() == common.ELocation.Unknown() {
+				// deletions move no bytes, so report how quickly they are going instead
+				throughputString = fmt.Sprintf("2-sec Deletions/s: %v", ste.ToFixed(transferRate, 1))
+			}
 
 			// indicate whether constrained by disk or not
 			perfString, diskString := getPerfDisplayText(summary.PerfStrings, summary.PerfConstraint, duration, false)
 
-			return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Skipped, %v Total%s, %s%s%s",
+			return fmt.Sprintf("%.1f %%%s, %v Done, %v Failed, %v Pending, %v Skipped, %v Total%s, %s%s%s",
 				summary.PercentComplete,
+				formatProgressBasis(summary.ProgressBasis),
 				summary.TransfersCompleted,
 				summary.TransfersFailed,
 				summary.TotalTransfers-(summary.TransfersCompleted+summary.TransfersFailed+summary.TransfersSkipped),
@@ -202,18 +217,26 @@ func init() {
 		"Files should be separated by ';'.")
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.excludeTransfer, "exclude", "", "Filter: exclude these failed transfer(s) when resuming the job. "+
 		"Files should be separated by ';'.")
+	resumeCmd.PersistentFlags().BoolVar(&resumeCmdArgs.failedOnly, "failed-only", false, "Only retry the transfers that failed, without rescheduling the ones that were skipped, cancelled or never finished. "+
+		"This is quicker than a full resume when a job has only a few failures. If any transfers were left unfinished, the job ends as cancelled, so that resuming it again without this flag transfers them.")
 	// oauth options
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.SourceSAS, "source-sas", "", "Source SAS token of the source for a given Job ID.")
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.DestinationSAS, "destination-sas", "", "destination SAS token of the destination for a given Job ID.")
+	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.clientSideEncryptionKey, "client-side-encryption-key", "", "The key file the job was started with, if it encrypts or decrypts client-side. The key isn't kept in the job's plan.")
+	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.clientSideEncryptionKeyID, "client-side-encryption-key-id", "", "The client-side-encryption-key-id the job was started with, if any.")
 }
 
 type resumeCmdArgs struct {
 	jobID           string
 	includeTransfer string
 	excludeTransfer string
+	failedOnly      bool
 
 	SourceSAS      string
 	DestinationSAS string
+
+	clientSideEncryptionKey   string
+	clientSideEncryptionKeyID string
 }
 
 // processes the resume command,
@@ -226,6 +249,13 @@ func (rca resumeCmdArgs) process() error {
 		return fmt.Errorf("error parsing the jobId %s. Failed with error %s", rca.jobID, err.Error())
 	}
 
+	var clientSideEncryptionKey common.ClientSideEncryptionKey
+	if rca.clientSideEncryptionKey != "" {
+		if clientSideEncryptionKey, err = common.LoadClientSideEncryptionKey(rca.clientSideEncryptionKey, rca.clientSideEncryptionKeyID); err != nil {
+			return err
+		}
+	}
+
 	includeTransfer := make(map[string]int)
 	excludeTransfer := make(map[string]int)
 
@@ -306,6 +336,9 @@ func (rca resumeCmdArgs) process() error {
 			CredentialInfo:  credentialInfo,
 			IncludeTransfer: includeTransfer,
 			ExcludeTransfer: excludeTransfer,
+			FailedOnly:      rca.failedOnly,
+
+			ClientSideEncryptionKey: clientSideEncryptionKey,
 		},
 		&resumeJobResponse)
 
@@ -313,7 +346,7 @@ func (rca resumeCmdArgs) process() error {
 		glcm.Error(resumeJobResponse.ErrorMsg)
 	}
 
-	controller := resumeJobController{jobID: jobID}
+	controller := resumeJobController{jobID: jobID, fromTo: getJobFromToResponse.FromTo, failedOnly: rca.failedOnly}
 	controller.waitUntilJobCompletion(true)
 
 	return nil
@@ -147,7 +147,7 @@ func PrintJobProgressSummary(summary common.ListJobSummaryResponse) {
 		}
 
 		return fmt.Sprintf(
-			"\nJob %s summary\nNumber of File Transfers: %v\nNumber of Folder Property Transfers: %v\nTotal Number Of Transfers: %v\nNumber of Transfers Completed: %v\nNumber of Transfers Failed: %v\nNumber of Transfers Skipped: %v\nPercent Complete (approx): %.1f\nFinal Job Status: %v\n",
+			"\nJob %s summary\nNumber of File Transfers: %v\nNumber of Folder Property Transfers: %v\nTotal Number Of Transfers: %v\nNumber of Transfers Completed: %v\nNumber of Transfers Failed: %v\nNumber of Transfers Skipped: %v%s\nPercent Complete (approx): %.1f%s\nFinal Job Status: %v\n",
 			summary.JobID.String(),
 			summary.FileTransfers,
 			summary.FolderPropertyTransfers,
@@ -155,7 +155,9 @@ func PrintJobProgressSummary(summary common.ListJobSummaryResponse) {
 			summary.TransfersCompleted,
 			summary.TransfersFailed,
 			summary.TransfersSkipped,
+			formatSkippedLockedStats(summary.TransfersSkippedLocked),
 			summary.PercentComplete, // noted as approx in the format string because won't include in-flight files if this Show command is run from a different process
+			formatProgressBasis(summary.ProgressBasis),
 			summary.JobStatus,
 		)
 	}, common.EExitCode.Success())
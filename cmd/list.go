@@ -22,9 +22,13 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -34,6 +38,8 @@ import (
 
 func init() {
 	var sourcePath = ""
+	var sortByRaw = ""
+	var columnsRaw = ""
 	// listContainerCmd represents the list container command
 	// listContainer list the blobs inside the container or virtual directory inside the container
 	listContainerCmd := &cobra.Command{
@@ -62,7 +68,19 @@ func init() {
 				glcm.Error("invalid path passed for listing. given source is of type " + location.String() + " while expect is container / container path ")
 			}
 
-			err := HandleListContainerCommand(sourcePath, location)
+			if err := parameters.SortBy.Parse(sortByRaw); err != nil {
+				glcm.Error(fmt.Sprintf("invalid value '%s' for --sort-by: expected one of name, size or mtime", sortByRaw))
+			}
+			if parameters.Reverse && parameters.SortBy == common.EListSortOption.None() {
+				glcm.Error("--reverse can only be used together with --sort-by")
+			}
+			columns, err := parseListColumns(columnsRaw)
+			if err != nil {
+				glcm.Error(err.Error())
+			}
+			parameters.Columns = columns
+
+			err = HandleListContainerCommand(sourcePath, location)
 			if err == nil {
 				glcm.Exit(nil, common.EExitCode.Success())
 			} else {
@@ -75,6 +93,11 @@ func init() {
 	listContainerCmd.PersistentFlags().BoolVar(&parameters.MachineReadable, "machine-readable", false, "Lists file sizes in bytes.")
 	listContainerCmd.PersistentFlags().BoolVar(&parameters.RunningTally, "running-tally", false, "Counts the total number of files and their sizes.")
 	listContainerCmd.PersistentFlags().BoolVar(&parameters.MegaUnits, "mega-units", false, "Displays units in orders of 1000, not 1024.")
+	listContainerCmd.PersistentFlags().StringVar(&sortByRaw, "sort-by", common.EListSortOption.None().String(), "Sorts the listed objects by 'name', 'size' or 'mtime' (last modified time). "+
+		"Sorting holds every listed object in memory until the listing is complete.")
+	listContainerCmd.PersistentFlags().BoolVar(&parameters.Reverse, "reverse", false, "Reverses the order given by --sort-by.")
+	listContainerCmd.PersistentFlags().StringVar(&columnsRaw, "columns", strings.Join(defaultListColumns, ","), "Comma separated list of the properties to print for each object. "+
+		"Available columns are 'name', 'size', 'mtime' and 'tier'.")
 
 	rootCmd.AddCommand(listContainerCmd)
 }
@@ -83,10 +106,169 @@ type ListParameters struct {
 	MachineReadable bool
 	RunningTally    bool
 	MegaUnits       bool
+	SortBy          common.ListSortOption
+	Reverse         bool
+	Columns         []string
 }
 
 var parameters = ListParameters{}
 
+// needsLastModifiedTime says whether the last modified time of each object is printed or sorted on. Not every listing
+// returns it, so then the properties of each object are read as it's listed
+func (p ListParameters) needsLastModifiedTime() bool {
+	if p.SortBy == common.EListSortOption.MTime() {
+		return true
+	}
+	for _, c := range p.Columns {
+		if c == listColumnMTime {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	listColumnName  = "name"
+	listColumnSize  = "size"
+	listColumnMTime = "mtime"
+	listColumnTier  = "tier"
+)
+
+var allListColumns = []string{listColumnName, listColumnSize, listColumnMTime, listColumnTier}
+var defaultListColumns = []string{listColumnName, listColumnSize}
+
+// sorting means holding every object in memory until the traversal completes.
+// Past this many objects, we tell the user why memory use keeps growing.
+const listSortWarningThreshold = 1000000
+
+// parseListColumns validates the value of --columns, keeping the order the user asked for
+func parseListColumns(raw string) ([]string, error) {
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, c := range strings.Split(raw, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "" {
+			continue
+		}
+
+		valid := false
+		for _, known := range allListColumns {
+			if c == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid column '%s' in --columns: available columns are %s", c, strings.Join(allListColumns, ", "))
+		}
+
+		if !seen[c] {
+			seen[c] = true
+			columns = append(columns, c)
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("--columns must name at least one column")
+	}
+	return columns, nil
+}
+
+// listEntry is what the list command knows about one listed object
+type listEntry struct {
+	path         string
+	size         int64
+	lastModified time.Time
+	tier         string
+}
+
+// listEntryJSON is the JSON form of a listEntry. Columns that were not asked for are left empty, and so omitted.
+type listEntryJSON struct {
+	Path             string     `json:",omitempty"`
+	ContentLength    string     `json:",omitempty"`
+	LastModifiedTime *time.Time `json:",omitempty"`
+	BlobAccessTier   string     `json:",omitempty"`
+}
+
+func formatListSize(size int64) string {
+	if parameters.MachineReadable {
+		return strconv.FormatInt(size, 10)
+	}
+	return byteSizeToString(size)
+}
+
+func (e listEntry) text(columns []string) string {
+	fields := make([]string, 0, len(columns))
+	for _, c := range columns {
+		switch c {
+		case listColumnName:
+			fields = append(fields, e.path)
+		case listColumnSize:
+			fields = append(fields, "Content Length: "+formatListSize(e.size))
+		case listColumnMTime:
+			fields = append(fields, "Last Modified: "+e.lastModified.UTC().Format(time.RFC3339))
+		case listColumnTier:
+			fields = append(fields, "Access Tier: "+e.tier)
+		}
+	}
+	return strings.Join(fields, "; ")
+}
+
+func (e listEntry) json(columns []string) string {
+	out := listEntryJSON{}
+	for _, c := range columns {
+		switch c {
+		case listColumnName:
+			out.Path = e.path
+		case listColumnSize:
+			out.ContentLength = formatListSize(e.size)
+		case listColumnMTime:
+			lmt := e.lastModified.UTC()
+			out.LastModifiedTime = &lmt
+		case listColumnTier:
+			out.BlobAccessTier = e.tier
+		}
+	}
+
+	jsonOutput, err := json.Marshal(out)
+	common.PanicIfErr(err)
+	return string(jsonOutput)
+}
+
+func printListEntry(e listEntry) {
+	if azcopyOutputFormat == common.EOutputFormat.Json() {
+		glcm.Output(func(common.OutputFormat) string {
+			return e.json(parameters.Columns)
+		})
+	} else {
+		glcm.Info(e.text(parameters.Columns))
+	}
+}
+
+// sortListEntries orders the entries in place. Ties are broken by path so the output is deterministic.
+func sortListEntries(entries []listEntry, by common.ListSortOption, reverse bool) {
+	less := func(a, b listEntry) bool {
+		switch by {
+		case common.EListSortOption.Size():
+			if a.size != b.size {
+				return a.size < b.size
+			}
+		case common.EListSortOption.MTime():
+			if !a.lastModified.Equal(b.lastModified) {
+				return a.lastModified.Before(b.lastModified)
+			}
+		}
+		return a.path < b.path
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if reverse {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+}
+
 // HandleListContainerCommand handles the list container command
 func HandleListContainerCommand(unparsedSource string, location common.Location) (err error) {
 	// TODO: Temporarily use context.TODO(), this should be replaced with a root context from main.
@@ -119,7 +301,7 @@ func HandleListContainerCommand(unparsedSource string, location common.Location)
 		}
 	}
 
-	traverser, err := initResourceTraverser(source, location, &ctx, &credentialInfo, nil, nil, true, false, false, func(common.EntityType) {}, nil)
+	traverser, err := initResourceTraverser(source, location, &ctx, &credentialInfo, nil, nil, true, parameters.needsLastModifiedTime(), false, func(common.EntityType) {}, nil)
 
 	if err != nil {
 		return fmt.Errorf("failed to initialize traverser: %s", err.Error())
@@ -128,21 +310,24 @@ func HandleListContainerCommand(unparsedSource string, location common.Location)
 	var fileCount int64 = 0
 	var sizeCount int64 = 0
 
+	sorting := parameters.SortBy != common.EListSortOption.None()
+	entries := make([]listEntry, 0)
+
 	processor := func(object storedObject) error {
 		path := object.relativePath
 		if object.entityType == common.EEntityType.Folder() {
 			path += "/" // TODO: reviewer: same questions as for jobs status: OK to hard code direction of slash? OK to use trailing slash to distinguish dirs from files?
 		}
-		objectSummary := path + "; Content Length: "
 
 		if level == level.Service() {
-			objectSummary = object.containerName + "/" + objectSummary
+			path = object.containerName + "/" + path
 		}
 
-		if parameters.MachineReadable {
-			objectSummary += strconv.Itoa(int(object.size))
-		} else {
-			objectSummary += byteSizeToString(object.size)
+		entry := listEntry{
+			path:         path,
+			size:         object.size,
+			lastModified: object.lastModifiedTime,
+			tier:         string(object.blobAccessTier),
 		}
 
 		if parameters.RunningTally {
@@ -150,7 +335,15 @@ func HandleListContainerCommand(unparsedSource string, location common.Location)
 			sizeCount += object.size
 		}
 
-		glcm.Info(objectSummary)
+		if sorting {
+			entries = append(entries, entry)
+			if len(entries) == listSortWarningThreshold {
+				glcm.Info(fmt.Sprintf("More than %d objects have been listed. All of them are held in memory so that they can be sorted; "+
+					"omit --sort-by to print objects as they are listed.", listSortWarningThreshold))
+			}
+		} else {
+			printListEntry(entry)
+		}
 
 		// No need to strip away from the name as the traverser has already done so.
 		return nil
@@ -162,15 +355,29 @@ func HandleListContainerCommand(unparsedSource string, location common.Location)
 		return fmt.Errorf("failed to traverse container: %s", err.Error())
 	}
 
+	if sorting {
+		sortListEntries(entries, parameters.SortBy, parameters.Reverse)
+		for _, entry := range entries {
+			printListEntry(entry)
+		}
+	}
+
 	if parameters.RunningTally {
+		if azcopyOutputFormat == common.EOutputFormat.Json() {
+			glcm.Output(func(common.OutputFormat) string {
+				jsonOutput, err := json.Marshal(struct {
+					FileCount     int64
+					TotalFileSize string
+				}{fileCount, formatListSize(sizeCount)})
+				common.PanicIfErr(err)
+				return string(jsonOutput)
+			})
+			return nil
+		}
+
 		glcm.Info("")
 		glcm.Info("File count: " + strconv.Itoa(int(fileCount)))
-
-		if parameters.MachineReadable {
-			glcm.Info("Total file size: " + strconv.Itoa(int(sizeCount)))
-		} else {
-			glcm.Info("Total file size: " + byteSizeToString(sizeCount))
-		}
+		glcm.Info("Total file size: " + formatListSize(sizeCount))
 	}
 
 	return nil
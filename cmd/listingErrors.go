@@ -0,0 +1,170 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+const (
+	// listingPageRetries is how many more times a page of a listing is asked for, once the pipeline has given up on it
+	listingPageRetries = 3
+	// listingPageRetryDelay is how long to wait before the first of those, and is doubled for each one after it
+	listingPageRetryDelay = 5 * time.Second
+)
+
+// skippedListingRange is the part of a listing that was left out, because a page of it couldn't be got.
+// Its next page can only be found from the page itself, so everything from its marker on is skipped.
+// That's the rest of the virtual directory with parallel listing, or the rest of the listing otherwise
+type skippedListingRange struct {
+	container string
+	prefix    string
+	marker    string // empty when the first page is the one that failed
+	err       error
+}
+
+func (r skippedListingRange) String() string {
+	marker := "the start"
+	if r.marker != "" {
+		marker = "marker " + r.marker
+	}
+	return fmt.Sprintf("container %s, prefix '%s', from %s: %s", r.container, r.prefix, marker, r.err)
+}
+
+// listingErrorTolerance lets a listing carry on past pages that keep failing with transient errors, rather than
+// fail the enumeration, and keeps track of what was skipped so that it can be reported once the job is done.
+// It's shared by the goroutines of a parallel listing
+type listingErrorTolerance struct {
+	retries    int
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	skipped []skippedListingRange
+}
+
+func newListingErrorTolerance() *listingErrorTolerance {
+	return &listingErrorTolerance{retries: listingPageRetries, retryDelay: listingPageRetryDelay}
+}
+
+// listPage gets a page with list, retrying it with a growing delay if it fails with a transient error.
+// A nil tolerance doesn't retry, so that the listing fails as it always has
+func (t *listingErrorTolerance) listPage(ctx context.Context, list func() error) error {
+	err := list()
+	if t == nil {
+		return err
+	}
+	delay := t.retryDelay
+	for try := 0; try < t.retries && err != nil && isTransientListingError(err); try++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+		err = list()
+	}
+	return err
+}
+
+// skip records that the rest of the listing from marker on is left out, because of err, and says whether the listing
+// can carry on without it. It can't if the error isn't transient, since then the same is likely for every other page
+func (t *listingErrorTolerance) skip(container string, prefix string, marker azblob.Marker, err error) bool {
+	if t == nil || !isTransientListingError(err) {
+		return false
+	}
+	r := skippedListingRange{container: container, prefix: prefix, err: err}
+	if marker.Val != nil {
+		r.marker = *marker.Val
+	}
+
+	t.mu.Lock()
+	t.skipped = append(t.skipped, r)
+	t.mu.Unlock()
+
+	WarnStdoutAndJobLog("Skipping part of the listing, which could not be listed after retries: " + r.String())
+	return true
+}
+
+// skippedRanges returns the parts of the listing that were left out, in the order they were skipped
+func (t *listingErrorTolerance) skippedRanges() []skippedListingRange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]skippedListingRange(nil), t.skipped...)
+}
+
+// isTransientListingError says whether a listing error is likely to go away if the page is asked for again later:
+// a timeout, throttling, a server error, or a network failure
+func isTransientListingError(err error) bool {
+	if stgErr, ok := err.(azblob.StorageError); ok {
+		if stgErr.Response() == nil {
+			return true
+		}
+		status := stgErr.Response().StatusCode
+		return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	}
+	_, isNetErr := pipeline.Cause(err).(net.Error)
+	return isNetErr
+}
+
+// validateContinueOnEnumerationError checks that the source is listed by the Blob traverser, which is the one that
+// knows how to skip what it can't list. With a list of files or versions, each one is looked up on its own instead,
+// and with shards, the source is listed by traversers of each shard's own
+func validateContinueOnEnumerationError(continueOn bool, fromTo common.FromTo, fromList bool, shardByPrefix int) error {
+	if !continueOn {
+		return nil
+	}
+	if fromTo.From() != common.ELocation.Blob() {
+		return errors.New("continue-on-enumeration-error is only supported when copying from Blob storage")
+	}
+	if fromList {
+		return errors.New("continue-on-enumeration-error cannot be used with list-of-files, include-path or list-of-versions")
+	}
+	if shardByPrefix > 0 {
+		return errors.New("continue-on-enumeration-error cannot be used with shard-by-prefix")
+	}
+	return nil
+}
+
+// formatSkippedListingRanges tells the user, in the summary of the job, which parts of the source weren't listed, so weren't transferred.
+// It's empty if nothing was skipped
+func formatSkippedListingRanges(skipped []skippedListingRange) string {
+	if len(skipped) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(skipped)+1)
+	lines = append(lines, fmt.Sprintf("%d part(s) of the source could not be listed, so the files in them were not transferred. Run the copy again to transfer them:", len(skipped)))
+	for _, r := range skipped {
+		lines = append(lines, "  "+r.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,175 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// the metadata key under which an upload with preserve-listing-order records a file's position in its directory's listing
+const listingOrderMetadataKey = "azcopy_listing_order"
+
+// listingOrder numbers local files by their positions among the entries of their directories, in the order that the
+// file system lists them. On file systems that keep a directory's entries in the order they were created, that is the
+// creation order. Each directory is read once, when the first of its files is numbered.
+// The processor is only ever called by one goroutine at a time, so no locking is needed.
+type listingOrder struct {
+	sourceRoot string
+	positions  map[string]map[string]int // by directory, then by name
+}
+
+func newListingOrder(sourceRoot string) *listingOrder {
+	return &listingOrder{sourceRoot: sourceRoot, positions: make(map[string]map[string]int)}
+}
+
+// position returns the file's position among the entries of its directory
+func (o *listingOrder) position(fullPath string) (int, error) {
+	dir, name := filepath.Dir(fullPath), filepath.Base(fullPath)
+	byName, ok := o.positions[dir]
+	if !ok {
+		names, err := readDirInListingOrder(dir)
+		if err != nil {
+			return 0, err
+		}
+		byName = make(map[string]int, len(names))
+		for i, n := range names {
+			byName[n] = i
+		}
+		o.positions[dir] = byName
+	}
+
+	position, ok := byName[name]
+	if !ok {
+		return 0, fmt.Errorf("%s was not among the entries of %s", name, dir)
+	}
+	return position, nil
+}
+
+// readDirInListingOrder returns the names of the directory's entries in the order the file system gives them.
+// Unlike ioutil.ReadDir, it doesn't sort them.
+func readDirInListingOrder(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
+
+// apply records the file's position in the transfer's metadata. If the position can't be found, that is logged,
+// and the file is uploaded without it.
+func (o *listingOrder) apply(transfer *common.CopyTransfer, object storedObject) {
+	if object.entityType != common.EEntityType.File() {
+		return
+	}
+	fullPath := o.sourceRoot
+	if !object.isSingleSourceFile() {
+		fullPath = common.GenerateFullPath(o.sourceRoot, object.relativePath)
+	}
+
+	position, err := o.position(fullPath)
+	if err != nil {
+		if ste.JobsAdmin != nil {
+			ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Cannot record the listing order of %s: %s", fullPath, err.Error()), pipeline.LogWarning)
+		}
+		return
+	}
+
+	metadata := make(common.Metadata, len(transfer.Metadata)+1) // a copy, since the transfer's metadata may be shared with other files
+	for k, v := range transfer.Metadata {
+		metadata[k] = v
+	}
+	metadata[listingOrderMetadataKey] = strconv.Itoa(position)
+	transfer.Metadata = metadata
+}
+
+// listedFile is a file to be downloaded, with the position recorded when it was uploaded with preserve-listing-order
+type listedFile struct {
+	path     string
+	position int
+}
+
+// precreateFilesInListingOrder creates empty files at the destination, a directory at a time, in the order recorded in their
+// metadata when they were uploaded. The downloads then overwrite them in place, so they keep that order.
+// This is best effort: files without the metadata, and those already at the destination, are left to be created by their downloads,
+// and the order is only kept by file systems that list a directory's entries in the order they were created.
+func (cca *cookedCopyCmdArgs) precreateFilesInListingOrder(traverser resourceTraverser, filters []objectFilter, isDestDir bool) error {
+	byDir := make(map[string][]listedFile)
+	processor := func(object storedObject) error {
+		if object.entityType != common.EEntityType.File() {
+			return nil
+		}
+		position, err := strconv.Atoi(object.Metadata[listingOrderMetadataKey])
+		if err != nil {
+			return nil // not uploaded with preserve-listing-order, or the metadata has since been changed
+		}
+		object.containerName, object.dstContainerName = "", "" // as for the transfers, when the source is below the service level
+		fullPath := common.GenerateFullPath(cca.destination.ValueLocal(), cca.makeEscapedRelativePath(false, isDestDir, object))
+		dir := filepath.Dir(fullPath)
+		byDir[dir] = append(byDir[dir], listedFile{path: fullPath, position: position})
+		return nil
+	}
+	if err := traverser.traverse(noPreProccessor, processor, filters); err != nil {
+		return fmt.Errorf("cannot list the source to find the listing order of the files: %s", err.Error())
+	}
+
+	created, err := createInListingOrder(byDir)
+	if err != nil {
+		return err
+	}
+
+	if ste.JobsAdmin != nil {
+		ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Created %d empty files at the destination in their recorded listing order, before scheduling the transfers", created), pipeline.LogInfo)
+	}
+	return nil
+}
+
+// createInListingOrder creates the files of each directory, empty, in the order of their positions.
+// Files that exist already are left alone, since creating them now would not change their place in the listing.
+func createInListingOrder(byDir map[string][]listedFile) (created int, err error) {
+	for dir, files := range byDir {
+		if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+			return created, err
+		}
+		sort.SliceStable(files, func(i, j int) bool { return files[i].position < files[j].position })
+
+		for _, f := range files {
+			file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, common.DEFAULT_FILE_PERM)
+			if os.IsExist(err) {
+				continue
+			}
+			if err != nil {
+				return created, fmt.Errorf("cannot create %s at the destination: %s", f.path, err.Error())
+			}
+			file.Close()
+			created++
+		}
+	}
+	return created, nil
+}
@@ -252,6 +252,13 @@ func init() {
 	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
 		if cmd.Name() == loadCmd.Name() || (cmd.Parent() != nil && cmd.Parent().Name() == loadCmd.Name()) {
 			cmd.Flags().MarkHidden("cap-mbps")
+			cmd.Flags().MarkHidden("cap-disk-read-mbps")
+			cmd.Flags().MarkHidden("checkpoint-interval")
+			cmd.Flags().MarkHidden("fairness")
+			cmd.Flags().MarkHidden("append-only")
+			cmd.Flags().MarkHidden("export-chunk-timeline")
+			cmd.Flags().MarkHidden("log-max-size-mb")
+			cmd.Flags().MarkHidden("log-max-files")
 			cmd.Flags().MarkHidden("trusted-microsoft-suffixes")
 		}
 		originalHelp(cmd, args)
@@ -23,6 +23,8 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/Azure/azure-storage-azcopy/common"
@@ -47,6 +49,13 @@ func init() {
 			loginCmdArgs.clientSecret = glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ClientSecret())
 			loginCmdArgs.persistToken = true
 
+			// --tenant-id is a global flag, since it also applies to automatic login.
+			// Likewise --aad-authority, which is an alternative to --aad-endpoint here
+			loginCmdArgs.tenantID = cmdLineTenantID
+			if loginCmdArgs.aadEndpoint == "" {
+				loginCmdArgs.aadEndpoint = cmdLineAADAuthority
+			}
+
 			if loginCmdArgs.certPass != "" || loginCmdArgs.clientSecret != "" {
 				glcm.Info(environmentVariableNotice)
 			}
@@ -64,7 +73,6 @@ func init() {
 
 	rootCmd.AddCommand(lgCmd)
 
-	lgCmd.PersistentFlags().StringVar(&loginCmdArgs.tenantID, "tenant-id", "", "The Azure Active Directory tenant ID to use for OAuth device interactive login.")
 	lgCmd.PersistentFlags().StringVar(&loginCmdArgs.aadEndpoint, "aad-endpoint", "", "The Azure Active Directory endpoint to use. The default ("+common.DefaultActiveDirectoryEndpoint+") is correct for the public Azure cloud. Set this parameter when authenticating in a national cloud. Not needed for Managed Service Identity")
 	// Use identity which aligns to Azure powershell and CLI.
 	lgCmd.PersistentFlags().BoolVar(&loginCmdArgs.identity, "identity", false, "Log in using virtual machine's identity, also known as managed service identity (MSI).")
@@ -111,6 +119,12 @@ type argValidity struct {
 }
 
 func (lca loginCmdArgs) validate() error {
+	if lca.aadEndpoint != "" {
+		if u, err := url.Parse(lca.aadEndpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("the Azure Active Directory authority '%s' is not a valid URL", lca.aadEndpoint)
+		}
+	}
+
 	// Only support one kind of oauth login at same time.
 	switch {
 	case lca.identity:
@@ -122,6 +136,12 @@ func (lca loginCmdArgs) validate() error {
 		if lca.tenantID != "" || lca.applicationID != "" || lca.certPath != "" {
 			return errors.New("tenant ID/application ID/cert path/client secret cannot be used with identity")
 		}
+
+		// only one selector can be given to IMDS, else it can't tell which identity is meant
+		identityInfo := common.IdentityInfo{ClientID: lca.identityClientID, ObjectID: lca.identityObjectID, MSIResID: lca.identityResourceID}
+		if err := identityInfo.Validate(); err != nil {
+			return errors.New("only one of identity-client-id, identity-object-id and identity-resource-id can be specified")
+		}
 	case lca.servicePrincipal:
 		if lca.identity {
 			return errors.New("you can only log in with one type of auth at once")
@@ -153,6 +173,13 @@ func (lca loginCmdArgs) validate() error {
 	return nil
 }
 
+// tokenSourceMessage names the tenant and the authority that the OAuth token is acquired from
+func (lca loginCmdArgs) tokenSourceMessage() string {
+	return fmt.Sprintf("Acquiring OAuth token from Azure Active Directory tenant '%s' at authority '%s'.",
+		common.IffString(lca.tenantID == "", common.DefaultTenantID, lca.tenantID),
+		common.IffString(lca.aadEndpoint == "", common.DefaultActiveDirectoryEndpoint, lca.aadEndpoint))
+}
+
 func (lca loginCmdArgs) process() error {
 	// Validate login parameters.
 	if err := lca.validate(); err != nil {
@@ -162,6 +189,11 @@ func (lca loginCmdArgs) process() error {
 	uotm := GetUserOAuthTokenManagerInstance()
 	// Persist the token to cache, if login fulfilled successfully.
 
+	if !lca.identity {
+		// confirm where the token comes from, since picking the wrong tenant is a common cause of authorization failures
+		glcm.Info(lca.tokenSourceMessage())
+	}
+
 	switch {
 	case lca.servicePrincipal:
 
@@ -0,0 +1,133 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// metadataManifest gives the metadata of individual files, from a JSON file that maps their paths, relative to the source,
+// to their metadata. For example:
+//
+//	{"images/cat.jpg": {"animal": "cat"}, "docs/report.pdf": {"author": "finance", "year": "2020"}}
+//
+// Each entry is only checked once the file it describes is found, so that a malformed entry stops just that file
+// from being transferred, rather than the whole job.
+type metadataManifest struct {
+	entries         map[string]json.RawMessage // nil for a path given more than once
+	atomicMalformed uint32                     // the number of files not transferred, because their entries are malformed
+}
+
+func loadMetadataManifest(fileName string) (*metadataManifest, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("the file is not a JSON object, mapping paths to metadata: %s", err.Error())
+	}
+
+	m := &metadataManifest{entries: make(map[string]json.RawMessage, len(raw))}
+	for path, entry := range raw {
+		path = normalizeManifestPath(path)
+		if _, seen := m.entries[path]; seen {
+			entry = nil // e.g. both "a/b.txt" and "/a/b.txt". We can't tell which was meant
+		}
+		m.entries[path] = entry
+	}
+	return m, nil
+}
+
+func normalizeManifestPath(path string) string {
+	path = strings.Replace(path, common.OS_PATH_SEPARATOR, common.AZCOPY_PATH_SEPARATOR_STRING, -1)
+	return strings.Trim(path, common.AZCOPY_PATH_SEPARATOR_STRING)
+}
+
+// lookup returns the metadata that the manifest gives the file, if it has an entry for it
+func (m *metadataManifest) lookup(relativePath string) (metadata common.Metadata, found bool, err error) {
+	entry, found := m.entries[normalizeManifestPath(relativePath)]
+	if !found {
+		return nil, false, nil
+	}
+	if entry == nil {
+		return nil, true, fmt.Errorf("the manifest has more than one entry for it")
+	}
+
+	if err = json.Unmarshal(entry, &metadata); err != nil || metadata == nil {
+		return nil, true, fmt.Errorf("its entry is not an object of string values")
+	}
+	if _, invalid, hasInvalid := metadata.ExcludeInvalidKey(); hasInvalid {
+		keys := make([]string, 0, len(invalid))
+		for k := range invalid {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return nil, true, fmt.Errorf("its entry has keys that are not valid metadata names: %s", strings.Join(keys, ", "))
+	}
+	return metadata, true, nil
+}
+
+// apply gives the transfer the metadata that the manifest has for its file. Files without an entry are left with the job's metadata.
+// shouldTransfer is false if the file's entry is malformed, in which case it has been reported.
+func (m *metadataManifest) apply(transfer *common.CopyTransfer, object storedObject) (shouldTransfer bool) {
+	if object.entityType != common.EEntityType.File() {
+		return true
+	}
+	relativePath := object.relativePath
+	if object.isSingleSourceFile() {
+		relativePath = object.name
+	}
+
+	metadata, found, err := m.lookup(relativePath)
+	if err != nil {
+		atomic.AddUint32(&m.atomicMalformed, 1)
+		if ste.JobsAdmin != nil {
+			ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Not transferring %s, because of its entry in the metadata-from manifest: %s", relativePath, err.Error()), pipeline.LogError)
+		}
+		return false
+	}
+	if found {
+		transfer.Metadata = metadata
+	}
+	return true
+}
+
+func (m *metadataManifest) malformedCount() uint32 {
+	return atomic.LoadUint32(&m.atomicMalformed)
+}
+
+// finish reports the files that were not transferred because of their entries in the manifest
+func (m *metadataManifest) finish() {
+	if n := m.malformedCount(); n > 0 {
+		WarnStdoutAndJobLog(fmt.Sprintf("%d file(s) failed, and were not transferred, because their entries in the metadata-from manifest are malformed. See the job log for details", n))
+	}
+}
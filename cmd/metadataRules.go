@@ -0,0 +1,134 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// metadataRule sets content headers on the uploaded files whose path matches its pattern.
+// Patterns use the same wildcards as include-pattern. A pattern without a slash is matched against the file name,
+// and a pattern with a slash is matched against the path of the file relative to the source.
+type metadataRule struct {
+	Pattern         string `json:"pattern" yaml:"pattern"`
+	ContentType     string `json:"contentType" yaml:"contentType"`
+	CacheControl    string `json:"cacheControl" yaml:"cacheControl"`
+	ContentEncoding string `json:"contentEncoding" yaml:"contentEncoding"`
+	ContentLanguage string `json:"contentLanguage" yaml:"contentLanguage"`
+}
+
+// metadataRules are evaluated in order, and the first rule that matches a file is the only one applied to it
+type metadataRules []metadataRule
+
+// loadMetadataRules reads the rules from a YAML file (.yaml or .yml) or otherwise from a JSON file.
+// Either way the file holds a list of rules, for example:
+//
+//	- pattern: "*.html"
+//	  contentType: text/html
+//	  cacheControl: no-cache
+//	- pattern: "assets/*"
+//	  cacheControl: public, max-age=31536000
+func loadMetadataRules(fileName string) (metadataRules, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules metadataRules
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, &rules)
+	default:
+		err = json.Unmarshal(data, &rules)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range rules {
+		if r.Pattern == "" {
+			return nil, fmt.Errorf("rule %d has no pattern", i+1)
+		}
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("rule %d has an invalid pattern %q", i+1, r.Pattern)
+		}
+		if r.ContentType == "" && r.CacheControl == "" && r.ContentEncoding == "" && r.ContentLanguage == "" {
+			return nil, fmt.Errorf("rule %d (%s) sets none of contentType, cacheControl, contentEncoding or contentLanguage", i+1, r.Pattern)
+		}
+		if err := validateContentLanguage(r.ContentLanguage); err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %s", i+1, r.Pattern, err.Error())
+		}
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("the file contains no rules")
+	}
+	return rules, nil
+}
+
+// match returns the first rule whose pattern matches the file, or nil if there is none
+func (rules metadataRules) match(name string, relativePath string) *metadataRule {
+	relativePath = strings.Replace(relativePath, common.OS_PATH_SEPARATOR, common.AZCOPY_PATH_SEPARATOR_STRING, -1)
+	for i := range rules {
+		checkItem := name
+		if strings.Contains(rules[i].Pattern, common.AZCOPY_PATH_SEPARATOR_STRING) {
+			checkItem = relativePath
+		}
+		if matched, _ := path.Match(rules[i].Pattern, checkItem); matched {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// apply sets the headers of the first matching rule on the transfer.
+// Headers given explicitly on the command line take precedence, so the rule only fills in the ones that were not.
+func (rules metadataRules) apply(transfer *common.CopyTransfer, object storedObject, cca *cookedCopyCmdArgs) {
+	if object.entityType != common.EEntityType.File() {
+		return
+	}
+	r := rules.match(object.name, object.relativePath)
+	if r == nil {
+		return
+	}
+	if cca.contentType == "" {
+		transfer.ContentType = r.ContentType
+	}
+	if cca.cacheControl == "" {
+		transfer.CacheControl = r.CacheControl
+	}
+	if cca.contentEncoding == "" {
+		transfer.ContentEncoding = r.ContentEncoding
+	}
+	if cca.contentLanguage == "" {
+		transfer.ContentLanguage = r.ContentLanguage
+	}
+}
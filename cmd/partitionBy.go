@@ -0,0 +1,87 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// partitionBy routes each uploaded file into a Hive-style date partition, such as year=2021/month=03/day=07/,
+// ahead of its path under the source, so that the destination is laid out ready for analytics engines
+type partitionBy struct {
+	useMtime    bool      // partition by each file's modification time, rather than by when it was uploaded
+	granularity string    // hourly, daily or monthly
+	uploadTime  time.Time // when the job started, which all its files are partitioned by, if not by modification time
+}
+
+func newPartitionBy(value string, uploadTime time.Time) (*partitionBy, error) {
+	parts := strings.Split(strings.ToLower(value), ":")
+	if len(parts) != 2 {
+		return nil, errors.New("expected a basis and a granularity, such as mtime:daily")
+	}
+
+	p := &partitionBy{uploadTime: uploadTime.UTC()}
+	switch parts[0] {
+	case "mtime":
+		p.useMtime = true
+	case "upload-time":
+	default:
+		return nil, fmt.Errorf("unknown basis '%s'. The choices are mtime and upload-time", parts[0])
+	}
+	switch parts[1] {
+	case "hourly", "daily", "monthly":
+		p.granularity = parts[1]
+	default:
+		return nil, fmt.Errorf("unknown granularity '%s'. The choices are hourly, daily and monthly", parts[1])
+	}
+	return p, nil
+}
+
+// partition returns the partition that the file belongs in, without a leading or trailing slash
+func (p *partitionBy) partition(object storedObject) string {
+	t := p.uploadTime
+	if p.useMtime {
+		t = object.lastModifiedTime.UTC()
+	}
+
+	partition := fmt.Sprintf("year=%04d/month=%02d", t.Year(), t.Month())
+	if p.granularity != "monthly" {
+		partition += fmt.Sprintf("/day=%02d", t.Day())
+	}
+	if p.granularity == "hourly" {
+		partition += fmt.Sprintf("/hour=%02d", t.Hour())
+	}
+	return partition
+}
+
+// apply puts the partition of the file ahead of its destination path, relative to the destination,
+// but after the destination container, if there is one
+func (p *partitionBy) apply(dstRelPath string, object storedObject) string {
+	container := ""
+	if object.dstContainerName != "" {
+		container = "/" + object.dstContainerName
+		dstRelPath = strings.TrimPrefix(dstRelPath, container)
+	}
+	return container + "/" + p.partition(object) + dstRelPath
+}
@@ -55,6 +55,8 @@ func determineLocationLevel(location string, locationType common.Location, sourc
 		}
 	case common.ELocation.Benchmark():
 		return ELocationLevel.Object(), nil // we always benchmark to a subfolder, not the container root
+	case common.ELocation.Http():
+		return ELocationLevel.Object(), nil // an HTTP source is always a single object
 
 	case common.ELocation.Blob(),
 		common.ELocation.File(),
@@ -99,7 +101,8 @@ func GetResourceRoot(resource string, location common.Location) (resourceBase st
 	// todo: reduce code-delicateness, maybe?
 	switch location {
 	case common.ELocation.Unknown(),
-		common.ELocation.Benchmark(): // do nothing
+		common.ELocation.Benchmark(),
+		common.ELocation.Http(): // do nothing. An HTTP source is always a single object
 		return resource, nil
 	case common.ELocation.Local():
 		return cleanLocalPath(getPathBeforeFirstWildcard(resource)), nil
@@ -205,6 +208,7 @@ func splitAuthTokenFromResource(resource string, location common.Location) (reso
 		*baseURL = common.URLExtension{URL: *baseURL}.URLWithPlusDecodedInPath()
 		return baseURL.String(), "", nil
 	case common.ELocation.Benchmark(), // cover for benchmark as we generate data for that
+		common.ELocation.Http(),    // any query string is the web server's business, so it's kept with the URL
 		common.ELocation.Unknown(): // cover for unknown as we treat that as garbage
 		// Local and S3 don't feature URL-embedded tokens
 		return resource, "", nil
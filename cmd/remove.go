@@ -71,6 +71,10 @@ func init() {
 				glcm.EnableCancelFromStdIn()
 			}
 
+			if azcopyAppendOnly {
+				glcm.Error("the remove command cannot be used with --append-only, since it removes objects from the destination")
+			}
+
 			cooked, err := raw.cook()
 			if err != nil {
 				glcm.Error("failed to parse user input due to error: " + err.Error())
@@ -88,6 +92,7 @@ func init() {
 
 	deleteCmd.PersistentFlags().BoolVar(&raw.recursive, "recursive", false, "Look into sub-directories recursively when syncing between directories.")
 	deleteCmd.PersistentFlags().StringVar(&raw.logVerbosity, "log-level", "INFO", "Define the log verbosity for the log file. Available levels include: INFO(all requests/responses), WARNING(slow responses), ERROR(only failed requests), and NONE(no output logs). (default 'INFO')")
+	deleteCmd.PersistentFlags().StringVar(&raw.logFormat, "log-format", "text", "Define the format of the log file. Available formats include: text, and json (one JSON object per entry, with level, timestamp, job ID, transfer path, request ID, error code and message fields). (default 'text')")
 	deleteCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
 	deleteCmd.PersistentFlags().StringVar(&raw.includePath, "include-path", "", "Include only these paths when removing. "+
 		"This option does not support wildcard characters (*). Checks relative path prefix. For example: myFolder;myFolder/subDirName/file.pdf")
@@ -98,4 +103,8 @@ func init() {
 	deleteCmd.PersistentFlags().StringVar(&raw.listOfFilesToCopy, "list-of-files", "", "Defines the location of a file which contains the list of files and directories to be deleted. The relative paths should be delimited by line breaks, and the paths should NOT be URL-encoded.")
 	deleteCmd.PersistentFlags().StringVar(&raw.deleteSnapshotsOption, "delete-snapshots", "", "By default, the delete operation fails if a blob has snapshots. Specify 'include' to remove the root blob and all its snapshots; alternatively specify 'only' to remove only the snapshots but keep the root blob.")
 	deleteCmd.PersistentFlags().StringVar(&raw.listOfVersionIDs, "list-of-versions", "", "Specifies a file where each version id is listed on a separate line. Ensure that the source must point to a single blob and all the version ids specified in the file using this flag must belong to the source blob only. Specified version ids of the given blob will get deleted from Azure Storage.")
+	deleteCmd.PersistentFlags().BoolVar(&raw.batchDelete, "batch-delete", false, "Group the deletions into Blob Batch requests of up to 256 blobs each, which is much faster when removing a large number of blobs. "+
+		"Requires the source to be authenticated with a SAS token; otherwise the blobs are deleted one at a time. If the job is interrupted, it can be resumed with 'azcopy jobs resume'.")
+	deleteCmd.PersistentFlags().BoolVar(&raw.requireSoftDelete, "require-soft-delete", false, "Remove blobs only if soft delete is confirmed to be enabled for the account, so that they can be restored with 'azcopy undelete'. "+
+		"Confirming it needs a credential for the whole account, such as an account SAS or OAuth. Without this flag, AzCopy warns when soft delete is not enabled, but removes the blobs anyway.")
 }
@@ -45,6 +45,12 @@ func newRemoveEnumerator(cca *cookedCopyCmdArgs) (enumerator *copyEnumerator, er
 
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
 
+	if cca.fromTo == common.EFromTo.BlobTrash() && !cca.isCleanupJob {
+		if err = cca.checkSoftDelete(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Include-path is handled by ListOfFilesChannel.
 	sourceTraverser, err = initResourceTraverser(cca.source, cca.fromTo.From(), &ctx, &cca.credentialInfo, nil,
 		cca.listOfFilesChannel, cca.recursive, false, cca.includeDirectoryStubs, func(common.EntityType) {}, cca.listOfVersionIDs)
@@ -34,10 +34,16 @@ func newRemoveTransferProcessor(cca *cookedCopyCmdArgs, numOfTransfersPerPart in
 		SourceRoot:      cca.source.CloneWithConsolidatedSeparators(), // TODO: why do we consolidate here, but not in "copy"? Is it needed in both places or neither? Or is copy just covering the same need differently?
 		CredentialInfo:  cca.credentialInfo,
 		ForceIfReadOnly: cca.forceIfReadOnly,
+		TrailingDot:     azcopyTrailingDot,
+		AppendOnly:      azcopyAppendOnly,
 
 		// flags
-		LogLevel:       cca.logVerbosity,
-		BlobAttributes: common.BlobTransferAttributes{DeleteSnapshotsOption: cca.deleteSnapshotsOption},
+		LogLevel:  cca.logVerbosity,
+		LogFormat: cca.logFormat,
+		BlobAttributes: common.BlobTransferAttributes{
+			DeleteSnapshotsOption: cca.deleteSnapshotsOption,
+			BatchDelete:           cca.batchDelete,
+		},
 	}
 
 	reportFirstPart := func(jobStarted bool) {
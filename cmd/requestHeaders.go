@@ -0,0 +1,96 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// reservedRequestHeaders are the headers which --request-header may not set, because they carry credentials, because
+// they control how the request is framed on the connection, or because the service, or AzCopy itself, relies on their
+// values. Any header starting with x-ms- is reserved too.
+var reservedRequestHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Host":                true,
+	"Connection":          true,
+	"Transfer-Encoding":   true,
+	"Expect":              true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+	"Content-Md5":         true,
+	"Content-Encoding":    true,
+	"Content-Language":    true,
+	"Date":                true,
+	"Range":               true,
+	"If-Match":            true,
+	"If-None-Match":       true,
+	"If-Modified-Since":   true,
+	"If-Unmodified-Since": true,
+	"User-Agent":          true,
+}
+
+const reservedRequestHeaderPrefix = "X-Ms-"
+
+// parseRequestHeaders parses the values of --request-header, each of which is in the form "Name: value".
+// A name that is given more than once gets all of its values.
+func parseRequestHeaders(raw []string) (http.Header, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	headers := http.Header{}
+	for _, r := range raw {
+		colon := strings.Index(r, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("the request header %q must be in the form 'Name: value'", r)
+		}
+		name := strings.TrimSpace(r[:colon])
+		value := strings.TrimSpace(r[colon+1:])
+		if !isHeaderToken(name) || strings.ContainsAny(value, "\r\n\x00") {
+			return nil, fmt.Errorf("the request header %q is not a valid HTTP header", r)
+		}
+
+		name = http.CanonicalHeaderKey(name)
+		if reservedRequestHeaders[name] || strings.HasPrefix(name, reservedRequestHeaderPrefix) {
+			return nil, fmt.Errorf("the request header %s cannot be set, because it is reserved for use by the service or by AzCopy", name)
+		}
+		headers.Add(name, value)
+	}
+	return headers, nil
+}
+
+// isHeaderToken returns true if the name is a valid HTTP header name (a "token" in RFC 7230)
+func isHeaderToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		isAlphaNum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphaNum && !strings.ContainsRune("!#$%&'*+-.^_`|~", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
@@ -43,9 +44,27 @@ var azcopyLogPathFolder string
 var azcopyJobPlanFolder string
 var azcopyMaxFileAndSocketHandles int
 var outputFormatRaw string
+var azcopyQuiet bool
+var azcopySummaryFile string
 var cancelFromStdin bool
 var azcopyOutputFormat common.OutputFormat
 var cmdLineCapMegaBitsPerSecond float64
+var cmdLineCapDiskReadMegaBitsPerSecond float64
+var cmdLineReadAheadMB float64
+var cmdLineMaxRetriesPerFile int32
+var cmdLineMaxTotalRetries int64
+var cmdLineMaxTransactions int64
+var cmdLineCapRequestsPerSecond int64
+var cmdLineRampUp time.Duration
+var cmdLineAccountTier string
+var remoteLogRaw string
+var otelEnabled bool
+var cmdLineAutoConcurrency bool
+var azcopyDeterministic bool
+var cmdLineCheckpointIntervalSeconds uint32
+var cmdLineChunkFairnessRaw string
+var cmdLineLogMaxSizeMB uint32
+var cmdLineLogMaxFiles uint32
 var azcopyAwaitContinue bool
 var azcopyAwaitAllowOpenFiles bool
 
@@ -55,6 +74,37 @@ var azcopyAwaitAllowOpenFiles bool
 // it as a global
 var cmdLineExtraSuffixesAAD string
 
+// Like cmdLineExtraSuffixesAAD, these are read directly by credential util, when AzCopy logs in automatically (see AZCOPY_AUTO_LOGIN_TYPE),
+// in which case they take precedence over the equivalent environment variables. They are also read by the login command.
+var cmdLineTenantID string
+var cmdLineAADAuthority string
+
+// Like cmdLineExtraSuffixesAAD, this is read directly by credential util when it creates Azure Files pipelines,
+// since every command that touches Azure Files must address names the same way
+var trailingDotRaw string
+var azcopyTrailingDot common.TrailingDotOption
+
+var requestHeadersRaw []string
+
+// bindInterfaceRaw names the network interface, by name or IP address, that connections are made from
+var bindInterfaceRaw string
+
+// these are parsed into azcopyEndpoints
+var endpointSuffixRaw string
+var blobEndpointRaw string
+var fileEndpointRaw string
+var dfsEndpointRaw string
+
+// azcopyAppendOnly is recorded in the plan of every job, so that the STE refuses to modify or remove existing objects at the destination,
+// even when the job is resumed. Commands whose whole purpose is to modify the destination refuse to run at all.
+var azcopyAppendOnly bool
+
+// azcopyChunkTimelinePath is passed to the STE in the orders of copy and sync jobs. It is not saved in the plan, so resumed jobs don't export a timeline
+var azcopyChunkTimelinePath string
+
+// timeAtPrestart is when this command started, before it did anything else
+var timeAtPrestart time.Time
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Version: common.AzcopyVersion, // will enable the user to see the version info in the standard posix way: --version
@@ -68,13 +118,62 @@ var rootCmd = &cobra.Command{
 			glcm.E2EAwaitContinue()
 		}
 
-		timeAtPrestart := time.Now()
+		timeAtPrestart = time.Now()
 
 		err := azcopyOutputFormat.Parse(outputFormatRaw)
 		glcm.SetOutputFormat(azcopyOutputFormat)
 		if err != nil {
 			return err
 		}
+		glcm.SetQuiet(azcopyQuiet)
+		glcm.SetSummaryFile(azcopySummaryFile)
+
+		err = azcopyTrailingDot.Parse(trailingDotRaw)
+		if err != nil {
+			return fmt.Errorf("error parsing the trailing-dot value %q: %w", trailingDotRaw, err)
+		}
+
+		if err = validateChunkTimelinePath(azcopyChunkTimelinePath); err != nil {
+			return err
+		}
+
+		requestHeaders, err := parseRequestHeaders(requestHeadersRaw)
+		if err != nil {
+			return err
+		}
+		ste.SetRequestHeaders(requestHeaders)
+
+		if bindInterfaceRaw != "" {
+			if err = ste.SetBindInterface(bindInterfaceRaw); err != nil {
+				return err
+			}
+		}
+
+		remoteLogTarget, err := common.ParseRemoteLogTarget(remoteLogRaw)
+		if err != nil {
+			return err
+		}
+		ste.SetRemoteLogTarget(remoteLogTarget)
+
+		if otelEnabled {
+			tracer, err := common.NewOTLPTracerFromEnv(os.Getenv)
+			if err != nil {
+				return err
+			}
+			var parent common.TraceContext
+			if traceParent := os.Getenv("TRACEPARENT"); traceParent != "" {
+				if parent, err = common.ParseTraceParent(traceParent); err != nil {
+					return fmt.Errorf("invalid TRACEPARENT: %w", err)
+				}
+			}
+			ste.SetTracer(tracer, parent)
+		}
+
+		azcopyEndpoints, err = parseEndpointFlags()
+		if err != nil {
+			return err
+		}
+		ste.SetEndpointRewriter(azcopyEndpoints.apply)
 
 		// warn Windows users re quoting (since our docs all use single quotes, but CMD needs double)
 		// Single ones just come through as part of the args, in CMD.
@@ -92,13 +191,49 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
-		// currently, we only automatically do auto-tuning when benchmarking
-		preferToAutoTuneGRs := cmd == benchCmd // TODO: do we have a better way to do this than making benchCmd global?
+		if err = validateDeterministic(azcopyDeterministic, cmdLineAutoConcurrency); err != nil {
+			return err
+		}
+
+		// we automatically do auto-tuning when benchmarking, and otherwise only when asked to, unless the run must be repeatable
+		preferToAutoTuneGRs := (cmd == benchCmd || cmdLineAutoConcurrency) && !azcopyDeterministic // TODO: do we have a better way to do this than making benchCmd global?
 		providePerformanceAdvice := cmd == benchCmd
 
+		var chunkFairness common.ChunkFairness
+		if err = chunkFairness.Parse(cmdLineChunkFairnessRaw); err != nil {
+			return fmt.Errorf("error parsing the fairness value %q: %w", cmdLineChunkFairnessRaw, err)
+		}
+
+		logRotation := common.LogRotationPolicy{
+			MaxSizeBytes: int64(cmdLineLogMaxSizeMB) * 1024 * 1024,
+			MaxFiles:     int(cmdLineLogMaxFiles),
+		}
+
+		capRequestsPerSecond, capMegaBitsPerSecond, err := resolveAccountTierCaps(cmdLineAccountTier, cmdLineCapRequestsPerSecond, cmdLineCapMegaBitsPerSecond)
+		if err != nil {
+			return err
+		}
+		if err = validateRampUp(cmdLineRampUp, capRequestsPerSecond, capMegaBitsPerSecond); err != nil {
+			return err
+		}
+
 		// startup of the STE happens here, so that the startup can access the values of command line parameters that are defined for "root" command
 		concurrencySettings := ste.NewConcurrencySettings(azcopyMaxFileAndSocketHandles, preferToAutoTuneGRs)
-		err = ste.MainSTE(concurrencySettings, float64(cmdLineCapMegaBitsPerSecond), azcopyJobPlanFolder, azcopyLogPathFolder, providePerformanceAdvice)
+		if azcopyDeterministic {
+			applyDeterministicMode(&concurrencySettings)
+		}
+		if cmdLineReadAheadMB < 0 {
+			return fmt.Errorf("read-ahead-mb cannot be negative")
+		}
+		readAheadBytes := int64(cmdLineReadAheadMB * 1024 * 1024)
+		if cmdLineMaxRetriesPerFile < 0 || cmdLineMaxTotalRetries < 0 {
+			return fmt.Errorf("max-retries-per-file and max-total-retries cannot be negative")
+		}
+		if cmdLineMaxTransactions < 0 {
+			return fmt.Errorf("max-transactions cannot be negative")
+		}
+
+		err = ste.MainSTE(concurrencySettings, capMegaBitsPerSecond, cmdLineCapDiskReadMegaBitsPerSecond, readAheadBytes, cmdLineMaxRetriesPerFile, cmdLineMaxTotalRetries, cmdLineMaxTransactions, capRequestsPerSecond, cmdLineRampUp, time.Duration(cmdLineCheckpointIntervalSeconds)*time.Second, chunkFairness, logRotation, azcopyJobPlanFolder, azcopyLogPathFolder, providePerformanceAdvice)
 		if err != nil {
 			return err
 		}
@@ -153,16 +288,102 @@ func Execute(azsAppPathFolder, logPathFolder string, jobPlanFolder string, maxFi
 	}
 }
 
+// validateRampUp checks that ramp-up has a cap to ramp up to
+func validateRampUp(rampUp time.Duration, capRequestsPerSecond int64, capMegaBitsPerSecond float64) error {
+	if rampUp < 0 {
+		return fmt.Errorf("ramp-up cannot be negative")
+	}
+	if rampUp > 0 && capRequestsPerSecond <= 0 && capMegaBitsPerSecond <= 0 {
+		return fmt.Errorf("ramp-up needs cap-mbps, cap-requests-per-second or account-tier, to know what rate to ramp up to")
+	}
+	return nil
+}
+
 func init() {
 	// replace the word "global" to avoid confusion (e.g. it doesn't affect all instances of AzCopy)
 	rootCmd.SetUsageTemplate(strings.Replace((&cobra.Command{}).UsageTemplate(), "Global Flags", "Flags Applying to All Commands", -1))
 
 	rootCmd.PersistentFlags().Float64Var(&cmdLineCapMegaBitsPerSecond, "cap-mbps", 0, "Caps the transfer rate, in megabits per second. Moment-by-moment throughput might vary slightly from the cap. If this option is set to zero, or it is omitted, the throughput isn't capped.")
+	rootCmd.PersistentFlags().Float64Var(&cmdLineCapDiskReadMegaBitsPerSecond, "cap-disk-read-mbps", 0, "Caps the rate, in megabits per second, at which local files are read when uploading, so that AzCopy leaves disk bandwidth for other processes. This cap is independent of cap-mbps. If this option is set to zero, or it is omitted, disk reads aren't capped.")
+	rootCmd.PersistentFlags().Float64Var(&cmdLineReadAheadMB, "read-ahead-mb", 0, "When uploading, read each large file this many MiB ahead of the chunk being sent, "+
+		"so that one or a few huge files on a fast link don't leave the network waiting on the disk. The read-ahead counts against the RAM that AzCopy may use (AZCOPY_BUFFER_GB), and can be at most a quarter of it. "+
+		"With AZCOPY_SHOW_PERF_STATES set, the states include RA: the chunks read ahead, out of those being read ahead. If this option is set to zero, or it is omitted, each chunk is read just before it's sent.")
+	rootCmd.PersistentFlags().Int32Var(&cmdLineMaxRetriesPerFile, "max-retries-per-file", 0, "Caps the retries of the requests for any one file, over all of its requests, so that a file that keeps failing "+
+		"can't use up the retries of the job. A file that reaches the cap fails with the error of its last try. Retries of requests to Blob storage and ADLS Gen2 are counted. "+
+		"If this option is set to zero, or it is omitted, each request is retried as usual, with no cap across the file.")
+	rootCmd.PersistentFlags().Int64Var(&cmdLineMaxTotalRetries, "max-total-retries", 0, "Caps the retries of requests over the whole job. Once the job reaches the cap, the transfers in progress carry on, "+
+		"but no more are started, and those left are failed, so that resuming the job starts them. Counted as for max-retries-per-file. "+
+		"If this option is set to zero, or it is omitted, the job's retries aren't capped.")
+	rootCmd.PersistentFlags().Int64Var(&cmdLineMaxTransactions, "max-transactions", 0, "Caps the requests, including retries, that the transfers send to the service over the whole job, each of which the service bills as a transaction. "+
+		"Once the job reaches the cap, no more requests are sent: the transfers in progress fail, no more are started, and those left are failed, so that resuming the job carries on from there. "+
+		"The summary reports how many were sent. Requests made while scanning the source and destination aren't counted. If this option is set to zero, or it is omitted, transactions aren't capped.")
+	rootCmd.PersistentFlags().Int64Var(&cmdLineCapRequestsPerSecond, "cap-requests-per-second", 0, "Caps the number of requests, including retries, that AzCopy sends to the service each second. If this option is set to zero, or it is omitted, the request rate isn't capped.")
+	rootCmd.PersistentFlags().StringVar(&cmdLineAccountTier, "account-tier", "", "Caps the request rate and bandwidth to 80% of the published scalability targets of the storage account AzCopy is transferring to, "+
+		"leaving the rest for other workloads. The choices are 'standard' and 'premium'. cap-mbps and cap-requests-per-second, if set, override the corresponding cap.")
+	rootCmd.PersistentFlags().DurationVar(&cmdLineRampUp, "ramp-up", 0, "Starts the job at a tenth of its caps, and raises the request rate and bandwidth linearly to the caps over this long (e.g. 5m), "+
+		"timed from the first request, so that a cold account or partition isn't hit with the full rate at once. Needs cap-mbps, cap-requests-per-second or account-tier, since it ramps up to them. "+
+		"If this option is set to zero, or it is omitted, the caps apply from the start.")
+	rootCmd.PersistentFlags().BoolVar(&cmdLineAutoConcurrency, "auto-concurrency", false, "Tunes the number of concurrent connections while the job runs, instead of using a fixed number. AzCopy starts with a few connections, "+
+		"adds more while throughput keeps rising, and backs off when throughput levels out or the service starts throttling. The values chosen are shown in the job summary. "+
+		"Has no effect if AZCOPY_CONCURRENCY_VALUE is set to a number.")
+	rootCmd.PersistentFlags().BoolVar(&azcopyDeterministic, "deterministic", false, "False by default. Runs the same way every time over the same data, for reproducible benchmarks and bug reports: "+
+		"the source is listed by one worker, transfers are started one at a time in the order they were scheduled, concurrency isn't auto-tuned, "+
+		"and what's otherwise random, such as the shuffling of the transfers in each job part, is seeded with a fixed value. The wait of start-jitter isn't, so that it still differs between hosts. "+
+		"This costs some peak throughput, mostly from listing and starting transfers one at a time. Requests still run concurrently, "+
+		"so their timing, retries and the order they finish in can differ; for fully serial operation, also set AZCOPY_CONCURRENCY_VALUE to 1. "+
+		"Can't be combined with auto-concurrency, nor with shard-by-prefix or precreate-directories, which work in parallel.")
+	rootCmd.PersistentFlags().Uint32Var(&cmdLineCheckpointIntervalSeconds, "checkpoint-interval", 0, "Writes the progress of each upload to the job plan on disk every this many seconds, so that if AzCopy or its host crashes, 'azcopy jobs resume' "+
+		"only uploads again what was sent in the last interval. Currently applies to uploads to block blobs. If this option is set to zero, or it is omitted, an upload that was interrupted part way through starts again from the beginning when resumed.")
+	rootCmd.PersistentFlags().StringVar(&cmdLineChunkFairnessRaw, "fairness", "none", "Decides which file's chunks are transferred next. With 'round-robin', AzCopy takes one chunk from each file in turn, "+
+		"so that small files complete promptly even while very large files are being transferred. With 'none' (the default), chunks are transferred in the order they are ready, which can leave small files waiting behind large ones.")
+	rootCmd.PersistentFlags().Uint32Var(&cmdLineLogMaxSizeMB, "log-max-size-mb", 0, "Rotates the job's log, and its chunk log, when it would grow past this many MiB, so that long running jobs can't fill the disk. "+
+		"Rotated logs are renamed with a number before the extension, e.g. <job-id>.1.log for the newest. If this option is set to zero, or it is omitted, logs are never rotated.")
+	rootCmd.PersistentFlags().Uint32Var(&cmdLineLogMaxFiles, "log-max-files", 5, "The number of rotated logs to keep for each log when log-max-size-mb is set. Older ones are deleted. Zero keeps none.")
+	rootCmd.PersistentFlags().StringVar(&remoteLogRaw, "remote-log", "", "Also sends each job log entry to a syslog server, given as udp://host:port or tcp://host:port, "+
+		"or posts it to an HTTP endpoint, given as an http:// or https:// URL. Entries are sent as they are written to the local log, in the same format, so with --log-format=json each one is a JSON object. "+
+		"Sending is best effort: entries are dropped, rather than slowing down the job, if the endpoint can't keep up or can't be reached.")
+	rootCmd.PersistentFlags().BoolVar(&otelEnabled, "otel", false, "Sends an OpenTelemetry span for the job, and one for each of its transfers with its size, status and retries, to an OTLP endpoint. "+
+		"The endpoint is configured with the standard OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and OTEL_EXPORTER_OTLP_HEADERS environment variables, and spans are sent as http/json. "+
+		"If the TRACEPARENT environment variable is set, the job's span is a child of the span it names. Sending is best effort: spans are dropped if the endpoint can't be reached.")
 	rootCmd.PersistentFlags().StringVar(&outputFormatRaw, "output-type", "text", "Format of the command's output. The choices include: text, json. The default value is 'text'.")
+	rootCmd.PersistentFlags().BoolVar(&azcopyQuiet, "quiet", false, "False by default. Print nothing on success: no progress, and no summary. Errors, and the summary of a job that failed, "+
+		"are still printed, to stderr, and the exit code says whether the command succeeded, which suits scripts and pipelines. Prompts are still shown, since they must be answered.")
+	rootCmd.PersistentFlags().StringVar(&azcopySummaryFile, "summary-file", "", "Also write the summary that's printed at the end of the command, such as the job summary, to this file, "+
+		"in the format chosen by output-type. It's written whether or not quiet is set.")
 
 	rootCmd.PersistentFlags().StringVar(&cmdLineExtraSuffixesAAD, trustedSuffixesNameAAD, "", "Specifies additional domain suffixes where Azure Active Directory login tokens may be sent.  The default is '"+
 		trustedSuffixesAAD+"'. Any listed here are added to the default. For security, you should only put Microsoft Azure domains here. Separate multiple entries with semi-colons.")
 
+	rootCmd.PersistentFlags().StringVar(&cmdLineTenantID, "tenant-id", "", "The Azure Active Directory tenant ID to acquire OAuth tokens from, when logging in with the login command or automatically (see AZCOPY_AUTO_LOGIN_TYPE). "+
+		"Overrides "+common.EEnvironmentVariable.TenantID().Name+". Use this when the default tenant discovery picks the wrong tenant.")
+	rootCmd.PersistentFlags().StringVar(&cmdLineAADAuthority, "aad-authority", "", "The Azure Active Directory authority (endpoint) URL to acquire OAuth tokens from. The default ("+common.DefaultActiveDirectoryEndpoint+") is correct for the public Azure cloud. "+
+		"Set this when using a national or air-gapped cloud. Overrides "+common.EEnvironmentVariable.AADEndpoint().Name+". Not needed for Managed Service Identity.")
+
+	rootCmd.PersistentFlags().StringVar(&trailingDotRaw, "trailing-dot", "Disable", "Specifies how Azure Files treats file and directory names that end with a dot. "+
+		"Enable asks the service to keep the trailing dots (this uses a newer service version), so names created on Linux round-trip faithfully. "+
+		"Disable (the default) keeps the existing behavior, in which the service trims them. Only affects Azure Files.")
+	rootCmd.PersistentFlags().StringArrayVar(&requestHeadersRaw, "request-header", nil, "Adds a header, in the form 'Name: value', to every request sent to the storage service. "+
+		"Use this, for example, to pass a routing tag to a gateway or API management layer in front of the service. Can be given more than once. "+
+		"Headers that carry credentials or that the service relies on, such as Authorization, Content-Length, Range and any header starting with x-ms-, cannot be set.")
+	rootCmd.PersistentFlags().StringVar(&bindInterfaceRaw, "bind-interface", "", "The network interface, given by its name (e.g. eth1) or one of its IP addresses, to make connections from. "+
+		"Use this on hosts with more than one network interface, such as one dedicated to storage traffic. An IPv4 address of the interface is preferred when it's given by name. "+
+		"Whether or not this is set, the summary reports how many bytes went through each interface.")
+	rootCmd.PersistentFlags().StringVar(&endpointSuffixRaw, "endpoint-suffix", "", "The endpoint suffix of the Azure cloud that the storage accounts are in, e.g. core.usgovcloudapi.net. "+
+		"Storage URLs written for another Azure cloud, such as https://[account].blob.core.windows.net, are redirected to it, so scripts needn't change from one cloud to another. "+
+		"Domains with this suffix are trusted for Azure Active Directory tokens.")
+	rootCmd.PersistentFlags().StringVar(&blobEndpointRaw, "blob-endpoint", "", "A URL, with only a scheme and a domain, to send all Blob storage requests to, such as https://privatelink.blob.core.windows.net for Private Link. "+
+		"Requests for standard Blob storage URLs (https://[account].blob.[suffix]) are sent to https://[account].[domain], so each account keeps its own host, "+
+		"and URLs with hosts in the domain are treated as Blob storage. This applies to every command, including remove and jobs resume. It takes precedence over endpoint-suffix, "+
+		"and hosts in its domain are trusted for Azure Active Directory tokens.")
+	rootCmd.PersistentFlags().StringVar(&fileEndpointRaw, "file-endpoint", "", "As blob-endpoint, for Azure Files.")
+	rootCmd.PersistentFlags().StringVar(&dfsEndpointRaw, "dfs-endpoint", "", "As blob-endpoint, for ADLS Gen2 (the dfs endpoint).")
+	rootCmd.PersistentFlags().BoolVar(&azcopyAppendOnly, "append-only", false, "Treats the destination as write-once: objects that already exist there are never overwritten, and their properties are never changed. "+
+		"Unlike --overwrite=false, which skips such objects, each attempt to modify one fails its transfer, so AzCopy exits with an error. "+
+		"The remove command, and sync with --delete-destination, --on-case-mismatch=rename, --compare-metadata, --compare-tags or --mirror, refuse to run.")
+	rootCmd.PersistentFlags().StringVar(&azcopyChunkTimelinePath, "export-chunk-timeline", "", "Writes, once per second, how many chunks of a copy or sync job are in each wait state (the same counts shown by AZCOPY_SHOW_PERF_STATES) to this file, for charting. "+
+		"Only the wait states that apply to the job's direction are included, and the direction is named in the file. A path ending in .json gets one JSON array per wait state, written when the job finishes. "+
+		"Any other path gets CSV, one row per second, written as the job runs.")
+
 	// Note: this is due to Windows not supporting signals properly
 	rootCmd.PersistentFlags().BoolVar(&cancelFromStdin, "cancel-from-stdin", false, "Used by partner teams to send in `cancel` through stdin to stop a job.")
 
@@ -178,6 +399,21 @@ func init() {
 	rootCmd.PersistentFlags().MarkHidden("await-open")
 }
 
+// validateChunkTimelinePath checks up front that the chunk timeline can be written, since the STE can only log
+// a failure to create it, by which time the job is under way
+func validateChunkTimelinePath(path string) error {
+	if path == "" {
+		return nil
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fmt.Errorf("the export-chunk-timeline path %q is a directory. Please give the path of a file", path)
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		return fmt.Errorf("the folder for the export-chunk-timeline file %q does not exist", path)
+	}
+	return nil
+}
+
 // always spins up a new goroutine, because sometimes the aka.ms URL can't be reached (e.g. a constrained environment where
 // aka.ms is not resolvable to a reachable IP address). In such cases, this routine will run for ever, and the caller should
 // just give up on it.
@@ -67,6 +67,9 @@ func inprocSend(rpcCmd common.RpcCmd, requestData interface{}, responseData inte
 	case common.ERpcCmd.GetJobFromTo():
 		*(responseData.(*common.GetJobFromToResponse)) = ste.GetJobFromTo(*requestData.(*common.GetJobFromToRequest))
 
+	case common.ERpcCmd.GetJobDetails():
+		*(responseData.(*common.GetJobDetailsResponse)) = ste.GetJobDetails(*requestData.(*common.GetJobDetailsRequest))
+
 	default:
 		panic(fmt.Errorf("Unrecognized RpcCmd: %q", rpcCmd.String()))
 	}
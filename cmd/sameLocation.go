@@ -0,0 +1,91 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// remoteLocationKey normalizes a remote URL to the host and name that it refers to, so that two URLs for the same place compare equal,
+// however they are written. The SAS and any other query parameters are dropped, and Blob and ADLS Gen2 endpoints of one account are treated as one.
+// ok is false if the URL can't be compared, e.g. because it names a snapshot or version, which is a different thing from the current object.
+func remoteLocationKey(rawURL string, location common.Location) (host string, name string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", false
+	}
+	for param := range u.Query() {
+		switch strings.ToLower(param) {
+		case "snapshot", "sharesnapshot", "versionid":
+			return "", "", false
+		}
+	}
+
+	host = strings.ToLower(u.Hostname())
+	host = strings.Replace(host, ".dfs.", ".blob.", 1)
+	if port := u.Port(); port != "" && port != "443" && port != "80" {
+		host += ":" + port
+	}
+
+	name = strings.Trim(path.Clean("/"+u.Path), "/")
+	if location == common.ELocation.File() {
+		name = strings.ToLower(name) // Azure Files names are case-insensitive
+	} else if i := strings.Index(name, "/"); i >= 0 {
+		name = strings.ToLower(name[:i]) + name[i:] // only the container name is case-insensitive
+	} else {
+		name = strings.ToLower(name)
+	}
+	return host, name, true
+}
+
+// checkNotSameLocation returns an error if the source and destination refer to the same place, so that nothing would be transferred
+// except onto itself. If intoFolder is true, the source is placed inside the destination when that's a folder, as copy does unless the
+// source ends in a wildcard, so a source copied into the folder it's already in is caught too.
+func checkNotSameLocation(src, dst string, fromTo common.FromTo, intoFolder bool) error {
+	if !fromTo.From().IsRemote() || !fromTo.To().IsRemote() {
+		return nil
+	}
+	srcHost, srcPath, srcOk := remoteLocationKey(src, fromTo.From())
+	dstHost, dstPath, dstOk := remoteLocationKey(dst, fromTo.To())
+	if !srcOk || !dstOk || srcHost != dstHost {
+		return nil
+	}
+
+	same := srcPath == dstPath
+	if !same && intoFolder && srcPath != "" {
+		// the destination is certainly a folder if it's a container or share, or if it ends with a slash
+		dstIsFolder := !strings.Contains(dstPath, "/")
+		if u, err := url.Parse(dst); err == nil && strings.HasSuffix(u.Path, "/") {
+			dstIsFolder = true
+		}
+		same = dstIsFolder && path.Dir("/"+srcPath) == path.Clean("/"+dstPath)
+	}
+	if same {
+		return fmt.Errorf("the source and destination are the same location (%s/%s), so the transfer would only overwrite each file with itself. "+
+			"If that's intended, e.g. to rewrite blobs with new properties, use --allow-same-location", srcHost, srcPath)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// validateSha256Manifest checks that the data of each file passes through AzCopy in a single ordered pass, from which
+// the hash can be computed. That's only when uploading or downloading, and not when decompressing, since then
+// the data read isn't the data saved
+func validateSha256Manifest(manifest string, fromTo common.FromTo, autoDecompress bool) error {
+	if manifest == "" {
+		return nil
+	}
+	if !(fromTo.From() == common.ELocation.Local() && fromTo.To().IsRemote()) &&
+		!(fromTo.From().IsRemote() && fromTo.To() == common.ELocation.Local()) {
+		return errors.New("sha256-manifest is only supported when uploading local files or downloading to local files")
+	}
+	if autoDecompress {
+		return errors.New("sha256-manifest cannot be used with decompress")
+	}
+	return nil
+}
+
+// manifestEntry is a file, and the SHA-256 of its data, as it's written in the manifest
+type manifestEntry struct {
+	path string
+	sum  []byte
+}
+
+// listSha256s returns the files that were transferred in the job, with the SHA-256 of each. Paths are relative
+// to the source, as in the failed list, except that when the source is the file itself, its local name is used
+func listSha256s(jm ste.IJobMgr, fromTo common.FromTo) []manifestEntry {
+	entries := make([]manifestEntry, 0)
+	for partNum := ste.PartNumber(0); true; partNum++ {
+		jpm, found := jm.JobPartMgr(partNum)
+		if !found {
+			break
+		}
+		plan := jpm.Plan()
+		for t := uint32(0); t < plan.NumTransfers; t++ {
+			jppt := plan.Transfer(t)
+			if jppt.TransferStatus() != common.ETransferStatus.Success() || jppt.EntityType != common.EEntityType.File() {
+				continue
+			}
+			sum, found := jpm.TransferSha256(t)
+			if !found {
+				continue
+			}
+			path := unescapeSourceRelativePath(plan.TransferSrcRelativePath(t), fromTo.From())
+			if path == "" {
+				src, dst, _ := plan.TransferSrcDstStrings(t)
+				if fromTo.IsUpload() {
+					path = filepath.Base(src)
+				} else {
+					path = filepath.Base(dst)
+				}
+			}
+			entries = append(entries, manifestEntry{path: path, sum: sum})
+		}
+	}
+	return entries
+}
+
+// writeManifestEntries writes one line per file, as sha256sum does: the hash, two spaces, and the path.
+// Like sha256sum, a path with a backslash or line break in it is escaped, and its line starts with a backslash
+func writeManifestEntries(w io.Writer, entries []manifestEntry) error {
+	escaper := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		line := hex.EncodeToString(e.sum) + "  " + e.path + "\n"
+		if strings.ContainsAny(e.path, "\\\n\r") {
+			line = `\` + hex.EncodeToString(e.sum) + "  " + escaper.Replace(e.path) + "\n"
+		}
+		if _, err := bw.WriteString(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeSha256Manifest writes the manifest of the files that the job transferred, once it's done
+func (cca *cookedCopyCmdArgs) writeSha256Manifest() error {
+	jm, exists := ste.JobsAdmin.JobMgr(cca.jobID)
+	if !exists {
+		return fmt.Errorf("job %s is not loaded", cca.jobID)
+	}
+	entries := listSha256s(jm, cca.fromTo)
+
+	f, err := os.Create(cca.sha256Manifest)
+	if err != nil {
+		return err
+	}
+	if err = writeManifestEntries(f, entries); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,268 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// how many blobs are restored at once by the undelete command
+const undeleteParallelism = 32
+
+// blobSoftDeleteRetention reads whether soft delete is enabled for the account that holds the given blob, container or directory,
+// and for how many days soft-deleted blobs are kept. Reading it needs a credential that's good for the whole account,
+// e.g. an account SAS or OAuth, since the setting belongs to the account's blob service.
+func blobSoftDeleteRetention(ctx context.Context, p pipeline.Pipeline, resourceURL url.URL) (enabled bool, days int32, err error) {
+	parts := azblob.NewBlobURLParts(resourceURL)
+	parts.ContainerName, parts.BlobName, parts.Snapshot, parts.VersionID = "", "", "", ""
+	props, err := azblob.NewServiceURL(parts.URL(), p).GetProperties(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	if props.DeleteRetentionPolicy == nil || !props.DeleteRetentionPolicy.Enabled {
+		return false, 0, nil
+	}
+	if props.DeleteRetentionPolicy.Days != nil {
+		days = *props.DeleteRetentionPolicy.Days
+	}
+	return true, days, nil
+}
+
+// softDeleteMessage says what the result of checking for soft delete means for a remove.
+// If soft delete is required, but isn't enabled or couldn't be checked, an error is returned instead, so that nothing is removed.
+func softDeleteMessage(enabled bool, days int32, checkErr error, required bool) (message string, err error) {
+	switch {
+	case checkErr != nil && required:
+		return "", fmt.Errorf("cannot confirm that soft delete is enabled, so nothing will be removed. "+
+			"Checking needs a credential for the whole account, such as an account SAS or OAuth: %w", checkErr)
+	case checkErr != nil:
+		return "Could not check whether soft delete is enabled for the account: " + checkErr.Error(), nil
+	case !enabled && required:
+		return "", errors.New("soft delete is not enabled for the account, so nothing will be removed, since the removals could not be undone")
+	case !enabled:
+		return "Soft delete is not enabled for the account, so the removals are permanent.", nil
+	default:
+		return fmt.Sprintf("Soft delete is enabled for the account. For %d days, removed blobs can be restored with 'azcopy undelete'.", days), nil
+	}
+}
+
+// checkSoftDelete reports, before blobs are removed, whether the removals could be undone.
+// If cca.requireSoftDelete is set, it fails unless soft delete is confirmed to be enabled.
+func (cca *cookedCopyCmdArgs) checkSoftDelete(ctx context.Context) error {
+	sourceURL, err := cca.source.FullURL()
+	if err != nil {
+		return err
+	}
+	p, err := createBlobPipeline(ctx, cca.credentialInfo)
+	if err != nil {
+		return err
+	}
+
+	enabled, days, checkErr := blobSoftDeleteRetention(ctx, p, *sourceURL)
+	message, err := softDeleteMessage(enabled, days, checkErr, cca.requireSoftDelete)
+	if err != nil {
+		return err
+	}
+	if checkErr != nil {
+		// most SAS tokens can't read the account's settings, so this isn't worth bothering the user with
+		if ste.JobsAdmin != nil {
+			ste.JobsAdmin.LogToJobLog(message, pipeline.LogWarning)
+		}
+	} else if !enabled {
+		WarnStdoutAndJobLog(message)
+	} else {
+		glcm.Info(message)
+	}
+	return nil
+}
+
+// holds raw input from user
+type rawUndeleteCmdArgs struct {
+	src       string
+	recursive bool
+}
+
+// holds processed/actionable args
+type cookedUndeleteCmdArgs struct {
+	containerURL url.URL // with the SAS, if any
+	prefix       string  // the blob name, or virtual directory, given by the user. Empty for the whole container
+	recursive    bool
+	source       common.ResourceString
+}
+
+func (raw rawUndeleteCmdArgs) cook() (cookedUndeleteCmdArgs, error) {
+	src := raw.src
+	if inferArgumentLocation(src) != common.ELocation.Blob() {
+		return cookedUndeleteCmdArgs{}, errors.New("undelete only supports Blob Storage, e.g. https://[account].blob.core.windows.net/[container]/[path/to/directory]")
+	}
+	parsedURL, err := url.Parse(src)
+	if err != nil {
+		return cookedUndeleteCmdArgs{}, err
+	}
+	parts := azblob.NewBlobURLParts(*parsedURL)
+	if parts.ContainerName == "" || strings.Contains(parts.ContainerName, "*") {
+		return cookedUndeleteCmdArgs{}, errors.New("please give the URL of a single container, or of a blob or virtual directory within it")
+	}
+	source, err := SplitResourceString(src, common.ELocation.Blob())
+	if err != nil {
+		return cookedUndeleteCmdArgs{}, err
+	}
+
+	prefix := parts.BlobName
+	parts.BlobName = ""
+	return cookedUndeleteCmdArgs{
+		containerURL: parts.URL(),
+		prefix:       prefix,
+		recursive:    raw.recursive,
+		source:       source,
+	}, nil
+}
+
+// isUnderUndeletePrefix says whether a blob was named by the prefix that the user gave. The prefix names either a blob,
+// or a virtual directory (with or without a trailing slash), or the whole container if it's empty.
+// Unless recursive, only blobs directly in the virtual directory count.
+func isUnderUndeletePrefix(blobName, prefix string, recursive bool) bool {
+	if blobName == prefix {
+		return true
+	}
+	dir := prefix
+	if dir != "" && !strings.HasSuffix(dir, common.AZCOPY_PATH_SEPARATOR_STRING) {
+		dir += common.AZCOPY_PATH_SEPARATOR_STRING
+	}
+	if !strings.HasPrefix(blobName, dir) {
+		return false
+	}
+	return recursive || !strings.Contains(blobName[len(dir):], common.AZCOPY_PATH_SEPARATOR_STRING)
+}
+
+// process restores each soft-deleted blob under the prefix, returning how many were restored, and how many could not be
+func (cooked cookedUndeleteCmdArgs) process() (restored, failed uint32, err error) {
+	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+
+	credentialInfo, _, err := getCredentialInfoForLocation(ctx, common.ELocation.Blob(), cooked.source.Value, cooked.source.SAS, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	p, err := createBlobPipeline(ctx, credentialInfo)
+	if err != nil {
+		return 0, 0, err
+	}
+	containerURL := azblob.NewContainerURL(cooked.containerURL, p)
+
+	if enabled, _, checkErr := blobSoftDeleteRetention(ctx, p, cooked.containerURL); checkErr == nil && !enabled {
+		glcm.Info("Soft delete is not enabled for the account, so only blobs that were removed while it was enabled can be restored.")
+	}
+
+	work := make(chan string)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < undeleteParallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range work {
+				if _, err := containerURL.NewBlobURL(name).Undelete(ctx); err != nil {
+					atomic.AddUint32(&failed, 1)
+					glcm.Info(fmt.Sprintf("Failed to restore %s: %s", name, err))
+				} else {
+					atomic.AddUint32(&restored, 1)
+				}
+			}
+		}()
+	}
+
+	listErr := func() error {
+		defer close(work)
+		for marker := (azblob.Marker{}); marker.NotDone(); {
+			resp, err := containerURL.ListBlobsFlatSegment(ctx, marker,
+				azblob.ListBlobsSegmentOptions{Details: azblob.BlobListingDetails{Deleted: true}, Prefix: cooked.prefix})
+			if err != nil {
+				return fmt.Errorf("cannot list the soft-deleted blobs: %w", err)
+			}
+			for _, blob := range resp.Segment.BlobItems {
+				if blob.Deleted && isUnderUndeletePrefix(blob.Name, cooked.prefix, cooked.recursive) {
+					work <- blob.Name
+				}
+			}
+			marker = resp.NextMarker
+		}
+		return nil
+	}()
+	wg.Wait()
+
+	return restored, failed, listErr
+}
+
+func init() {
+	raw := rawUndeleteCmdArgs{}
+
+	undeleteCmd := &cobra.Command{
+		Use:        "undelete [resourceURL]",
+		SuggestFor: []string{"restore", "recover"},
+		Short:      undeleteCmdShortDescription,
+		Long:       undeleteCmdLongDescription,
+		Example:    undeleteCmdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("please provide the resource URL as the only argument")
+			}
+			raw.src = args[0]
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			cooked, err := raw.cook()
+			if err != nil {
+				glcm.Error("failed to parse user input due to error: " + err.Error())
+			}
+
+			restored, failed, err := cooked.process()
+			if err != nil {
+				glcm.Error(fmt.Sprintf("failed to perform undelete command due to error: %s (%d blobs were restored before the error)", err, restored))
+			}
+
+			exitCode := common.EExitCode.Success()
+			if failed > 0 {
+				exitCode = common.EExitCode.Error()
+			}
+			glcm.Exit(func(format common.OutputFormat) string {
+				if restored == 0 && failed == 0 {
+					return "No soft-deleted blobs were found. Please verify that the recursive flag is set properly if targeting a virtual directory."
+				}
+				return fmt.Sprintf("Restored %d soft-deleted blobs. %d could not be restored.", restored, failed)
+			}, exitCode)
+		},
+	}
+	rootCmd.AddCommand(undeleteCmd)
+
+	undeleteCmd.PersistentFlags().BoolVar(&raw.recursive, "recursive", false, "Look into sub-directories recursively when restoring the blobs of a virtual directory.")
+}
@@ -0,0 +1,62 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// startJitterFlagHelp is shared by the commands that start a job
+const startJitterFlagHelp = "Waits a random time, up to this duration (e.g. 90s or 10m), before starting the job, so that many instances of AzCopy " +
+	"started at the same moment, e.g. by a fleet-wide scheduled task, don't all hit the same account at once. The wait only happens when the job is first started, not when it is resumed."
+
+func validateStartJitter(maxJitter time.Duration) error {
+	if maxJitter < 0 {
+		return errors.New("start-jitter cannot be negative")
+	}
+	return nil
+}
+
+// startJitterDelay picks how long to wait, from zero up to (but not including) maxJitter, using randInt63n to choose
+func startJitterDelay(maxJitter time.Duration, randInt63n func(int64) int64) time.Duration {
+	if maxJitter <= 0 {
+		return 0
+	}
+	return time.Duration(randInt63n(int64(maxJitter)))
+}
+
+// startJitterRand picks the wait. It has its own source, seeded with the time, so that the wait still differs between hosts
+// in deterministic mode, which seeds math/rand with the same value everywhere
+var startJitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// waitForStartJitter delays the start of a job by a random time up to maxJitter, if one was given.
+// It must only be called before a new job is scheduled, so that resuming a job never waits.
+func waitForStartJitter(maxJitter time.Duration) {
+	delay := startJitterDelay(maxJitter, startJitterRand.Int63n)
+	if delay == 0 {
+		return
+	}
+	glcm.Info(fmt.Sprintf("Waiting %v before starting, because of --start-jitter.", delay.Round(time.Millisecond)))
+	time.Sleep(delay)
+}
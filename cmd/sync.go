@@ -23,6 +23,7 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync/atomic"
@@ -44,8 +45,11 @@ type rawSyncCmdArgs struct {
 	// options from flags
 	blockSizeMB           float64
 	logVerbosity          string
+	logFormat             string
 	include               string
 	exclude               string
+	includeFrom           string // a file of patterns to include, one to a line, as well as those of include
+	excludeFrom           string // a file of patterns to exclude, one to a line, as well as those of exclude
 	excludePath           string
 	includeFileAttributes string
 	excludeFileAttributes string
@@ -55,18 +59,46 @@ type rawSyncCmdArgs struct {
 	preserveSMBPermissions bool
 	preserveOwner          bool
 	preserveSMBInfo        bool
+	preserveFileAttributes bool
+	preservePOSIX          bool
 	followSymlinks         bool
 	backupMode             bool
 	putMd5                 bool
+	deltaUpdate            bool
 	md5ValidationOption    string
 	// this flag indicates the user agreement with respect to deleting the extra files at the destination
 	// which do not exists at source. With this flag turned on/off, users will not be asked for permission.
 	// otherwise the user is prompted to make a decision
 	deleteDestination string
+	// destination-only files whose names match these patterns are kept, whatever delete-destination says
+	deleteDestinationExclude string
+	// used with delete-destination=tombstone, to really delete objects that have been marked as deleted for this many days
+	tombstoneRetentionDays int
+	// the most that the start of the job is randomly delayed by
+	startJitter time.Duration
+	// where the command is saved as a job definition, instead of being run, if anywhere
+	exportJobDefinition string
+	// whether the source and destination may be the same location
+	allowSameLocation bool
+	// the most transfers that scanning may get ahead of those that are done. Zero means no limit
+	lookahead int
+	// the most transfers in flight under each destination prefix, e.g. logs/=10,data/=100
+	prefixConcurrency string
+	// what to do about source and destination objects whose paths differ only in case
+	onCaseMismatch string
+	// which Unicode normalization form object names are converted to before they are compared
+	normalizeUnicode string
+	// whether attributes are compared, as well as content, so that the destination is an exact mirror of the source
+	mirror bool
+	// whether metadata and tags are compared, so that they're updated on their own when the content is up to date
+	compareMetadata bool
+	compareTags     bool
 
 	s2sPreserveAccessTier bool
 
 	forceIfReadOnly bool
+
+	progressBasis string
 }
 
 func (raw *rawSyncCmdArgs) parsePatterns(pattern string) (cookedPatterns []string) {
@@ -130,6 +162,12 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 		cooked.destination = common.ResourceString{Value: common.ToExtendedPath(cleanLocalPath(raw.dst))}
 	}
 
+	if !raw.allowSameLocation {
+		if err = checkNotSameLocation(raw.src, raw.dst, cooked.fromTo, false); err != nil {
+			return cooked, err
+		}
+	}
+
 	// we do not support service level sync yet
 	if cooked.fromTo.From().IsRemote() {
 		err = raw.validateURLIsNotServiceLevel(cooked.source.Value, cooked.fromTo.From())
@@ -174,6 +212,65 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	if err != nil {
 		return cooked, err
 	}
+	if cooked.deleteDestination == common.EDeleteDestination.Tombstone() &&
+		cooked.fromTo.To() != common.ELocation.Blob() && cooked.fromTo.To() != common.ELocation.File() {
+		return cooked, errors.New("delete-destination=tombstone is only supported when syncing to Blob storage or Azure Files, since the tombstone is kept in the object's metadata")
+	}
+	if raw.tombstoneRetentionDays < 0 {
+		return cooked, errors.New("tombstone-retention-days cannot be negative")
+	}
+	if raw.tombstoneRetentionDays > 0 && cooked.deleteDestination != common.EDeleteDestination.Tombstone() {
+		return cooked, errors.New("tombstone-retention-days is only supported with delete-destination=tombstone")
+	}
+	cooked.tombstoneRetention = time.Duration(raw.tombstoneRetentionDays) * 24 * time.Hour
+	cooked.deleteDestinationExclude = raw.parsePatterns(raw.deleteDestinationExclude)
+	if len(cooked.deleteDestinationExclude) > 0 && cooked.deleteDestination == common.EDeleteDestination.False() {
+		return cooked, errors.New("delete-destination-exclude is only supported when delete-destination is true, prompt or tombstone")
+	}
+
+	if err = validateStartJitter(raw.startJitter); err != nil {
+		return cooked, err
+	}
+	cooked.startJitter = raw.startJitter
+
+	if raw.lookahead < 0 {
+		return cooked, errors.New("enumeration-lookahead cannot be negative")
+	}
+	cooked.lookahead = raw.lookahead
+
+	if cooked.prefixConcurrency, err = parsePrefixConcurrency(raw.prefixConcurrency, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+
+	err = cooked.onCaseMismatch.Parse(raw.onCaseMismatch)
+	if err != nil {
+		return cooked, err
+	}
+
+	err = cooked.normalizeUnicode.Parse(raw.normalizeUnicode)
+	if err != nil {
+		return cooked, err
+	}
+
+	if err = validateMirror(raw.mirror, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	cooked.mirror = raw.mirror
+
+	if err = validateCompareAttributes(raw.compareMetadata, raw.compareTags, raw.mirror, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+	cooked.compareMetadata = raw.compareMetadata
+	cooked.compareTags = raw.compareTags
+
+	if err = validateSyncAppendOnly(azcopyAppendOnly, cooked.deleteDestination, cooked.onCaseMismatch, cooked.compareMetadata || cooked.compareTags, cooked.mirror); err != nil {
+		return cooked, err
+	}
+
+	err = cooked.progressBasis.Parse(raw.progressBasis)
+	if err != nil {
+		return cooked, err
+	}
 
 	// warn on legacy filters
 	if raw.legacyInclude != "" || raw.legacyExclude != "" {
@@ -184,6 +281,12 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	cooked.includePatterns = raw.parsePatterns(raw.include)
 	cooked.excludePatterns = raw.parsePatterns(raw.exclude)
 	cooked.excludePaths = raw.parsePatterns(raw.excludePath)
+	if cooked.includePatterns, err = addPatternsFromFile(cooked.includePatterns, raw.includeFrom, "include-from"); err != nil {
+		return cooked, err
+	}
+	if cooked.excludePatterns, err = addPatternsFromFile(cooked.excludePatterns, raw.excludeFrom, "exclude-from"); err != nil {
+		return cooked, err
+	}
 
 	// parse the attribute filter patterns
 	cooked.includeFileAttributes = raw.parsePatterns(raw.includeFileAttributes)
@@ -193,6 +296,10 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	if err != nil {
 		return cooked, err
 	}
+	err = cooked.logFormat.Parse(raw.logFormat)
+	if err != nil {
+		return cooked, err
+	}
 
 	if err = validatePreserveSMBPropertyOption(raw.preserveSMBPermissions, cooked.fromTo, nil, "preserve-smb-permissions"); err != nil {
 		return cooked, err
@@ -208,11 +315,25 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 		return cooked, err
 	}
 
+	if cooked.preserveFileAttributes, err = validatePreserveFileAttributes(raw.preserveFileAttributes, cooked.fromTo); err != nil {
+		return cooked, err
+	}
+
+	cooked.preservePOSIX = raw.preservePOSIX
+	if err = validatePreservePOSIX(cooked.preservePOSIX, cooked.fromTo, true); err != nil {
+		return cooked, err
+	}
+
 	cooked.putMd5 = raw.putMd5
 	if err = validatePutMd5(cooked.putMd5, cooked.fromTo); err != nil {
 		return cooked, err
 	}
 
+	cooked.deltaUpdate = raw.deltaUpdate
+	if err = validateDeltaUpdate(cooked.deltaUpdate, cooked.fromTo, common.EBlobType.Detect()); err != nil {
+		return cooked, err
+	}
+
 	err = cooked.md5ValidationOption.Parse(raw.md5ValidationOption)
 	if err != nil {
 		return cooked, err
@@ -228,6 +349,33 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	return cooked, nil
 }
 
+// validateMirror checks that both sides of the sync have attributes to compare.
+// Local files have no metadata, tier or tags of their own, so there is nothing for them to mirror.
+func validateMirror(mirror bool, fromTo common.FromTo) error {
+	if mirror && !fromTo.IsS2S() {
+		return errors.New("mirror is only supported when both the source and destination are remote, i.e. Blob to Blob or Azure Files to Azure Files")
+	}
+	return nil
+}
+
+// validateCompareAttributes checks that both sides of the sync have the attributes to compare, and that the destination's can be set on their own.
+// Mirroring already compares and updates the metadata and tags, along with the other attributes, so it can't be combined with these.
+func validateCompareAttributes(compareMetadata, compareTags, mirror bool, fromTo common.FromTo) error {
+	if !compareMetadata && !compareTags {
+		return nil
+	}
+	if mirror {
+		return errors.New("compare-metadata and compare-tags cannot be used with mirror, which already compares and updates metadata and tags")
+	}
+	if compareMetadata && (!fromTo.IsS2S() || (fromTo.To() != common.ELocation.Blob() && fromTo.To() != common.ELocation.File())) {
+		return errors.New("compare-metadata is only supported when both the source and destination are remote, and the destination is Blob storage or Azure Files")
+	}
+	if compareTags && fromTo != common.EFromTo.BlobBlob() {
+		return errors.New("compare-tags is only supported from Blob storage to Blob storage, since only blobs have index tags")
+	}
+	return nil
+}
+
 type cookedSyncCmdArgs struct {
 	// NOTE: for the 64 bit atomic functions to work on a 32 bit system, we have to guarantee the right 64-bit alignment
 	// so the 64 bit integers are placed first in the struct to avoid future breaks
@@ -245,6 +393,10 @@ type cookedSyncCmdArgs struct {
 
 	// deletion count keeps track of how many extra files from the destination were removed
 	atomicDeletionCount uint32
+	// keeps track of how many destination files had their metadata or tags updated, without being transferred
+	atomicAttributeUpdateCount uint32
+	// keeps track of how many of those updates failed
+	atomicAttributeUpdateFailureCount uint32
 
 	source         common.ResourceString
 	destination    common.ResourceString
@@ -263,10 +415,14 @@ type cookedSyncCmdArgs struct {
 	// options
 	preserveSMBPermissions common.PreservePermissionsOption
 	preserveSMBInfo        bool
+	preserveFileAttributes bool
+	preservePOSIX          bool
 	putMd5                 bool
+	deltaUpdate            bool
 	md5ValidationOption    common.HashValidationOption
 	blockSize              int64
 	logVerbosity           common.LogLevel
+	logFormat              common.LogFormat
 	forceIfReadOnly        bool
 	backupMode             bool
 
@@ -295,8 +451,30 @@ type cookedSyncCmdArgs struct {
 	// which do not exists at source. With this flag turned on/off, users will not be asked for permission.
 	// otherwise the user is prompted to make a decision
 	deleteDestination common.DeleteDestination
+	// the name patterns of destination-only files that are kept, rather than deleted
+	deleteDestinationExclude []string
+	// how long objects marked as deleted are kept before sync really deletes them. Zero means forever
+	tombstoneRetention time.Duration
+	// the most that the start of the job is randomly delayed by
+	startJitter time.Duration
+	// the most transfers that scanning may get ahead of those that are done. Zero means no limit
+	lookahead int
+	// the most transfers in flight under each destination prefix. Nil means no limits
+	prefixConcurrency map[string]int
+	// what to do about source and destination objects whose paths differ only in case
+	onCaseMismatch common.CaseMismatchOption
+	// which Unicode normalization form object names are converted to before they are compared
+	normalizeUnicode common.UnicodeNormalization
+	// whether objects whose content is up to date are transferred again when their attributes differ
+	mirror bool
+	// whether the metadata and tags of objects whose content is up to date are updated, on their own, when they differ
+	compareMetadata bool
+	compareTags     bool
 
 	preserveAccessTier bool
+
+	// what the percentage complete is measured against
+	progressBasis common.ProgressBasis
 }
 
 func (cca *cookedSyncCmdArgs) incrementDeletionCount() {
@@ -307,6 +485,22 @@ func (cca *cookedSyncCmdArgs) getDeletionCount() uint32 {
 	return atomic.LoadUint32(&cca.atomicDeletionCount)
 }
 
+func (cca *cookedSyncCmdArgs) incrementAttributeUpdateCount() {
+	atomic.AddUint32(&cca.atomicAttributeUpdateCount, 1)
+}
+
+func (cca *cookedSyncCmdArgs) getAttributeUpdateCount() uint32 {
+	return atomic.LoadUint32(&cca.atomicAttributeUpdateCount)
+}
+
+func (cca *cookedSyncCmdArgs) incrementAttributeUpdateFailureCount() {
+	atomic.AddUint32(&cca.atomicAttributeUpdateFailureCount, 1)
+}
+
+func (cca *cookedSyncCmdArgs) getAttributeUpdateFailureCount() uint32 {
+	return atomic.LoadUint32(&cca.atomicAttributeUpdateFailureCount)
+}
+
 // setFirstPartOrdered sets the value of atomicFirstPartOrdered to 1
 func (cca *cookedSyncCmdArgs) setFirstPartOrdered() {
 	atomic.StoreUint32(&cca.atomicFirstPartOrdered, 1)
@@ -409,6 +603,8 @@ func (cca *cookedSyncCmdArgs) getJsonOfSyncJobSummary(summary common.ListJobSumm
 	wrapped := common.ListSyncJobSummaryResponse{ListJobSummaryResponse: summary}
 	wrapped.DeleteTotalTransfers = cca.getDeletionCount()
 	wrapped.DeleteTransfersCompleted = cca.getDeletionCount()
+	wrapped.AttributeUpdates = cca.getAttributeUpdateCount()
+	wrapped.AttributeUpdatesFailed = cca.getAttributeUpdateFailureCount()
 	jsonOutput, err := json.Marshal(wrapped)
 	common.PanicIfErr(err)
 	return string(jsonOutput)
@@ -446,7 +642,7 @@ func (cca *cookedSyncCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 
 	if jobDone {
 		exitCode := common.EExitCode.Success()
-		if summary.TransfersFailed > 0 {
+		if summary.TransfersFailed > 0 || cca.getAttributeUpdateFailureCount() > 0 {
 			exitCode = common.EExitCode.Error()
 		}
 
@@ -468,9 +664,11 @@ Total Number Of Copy Transfers: %v
 Number of Copy Transfers Completed: %v
 Number of Copy Transfers Failed: %v
 Number of Deletions at Destination: %v
+Number of Attribute Updates at Destination: %v
+Number of Attribute Updates Failed at Destination: %v
 Total Number of Bytes Transferred: %v
-Total Number of Bytes Enumerated: %v
-Final Job Status: %v%s%s
+Total Number of Bytes Enumerated: %v%s
+Final Job Status: %v%s%s%s
 `,
 				summary.JobID.String(),
 				atomic.LoadUint64(&cca.atomicSourceFilesScanned),
@@ -482,9 +680,13 @@ Final Job Status: %v%s%s
 				summary.TransfersCompleted,
 				summary.TransfersFailed,
 				cca.atomicDeletionCount,
+				cca.getAttributeUpdateCount(),
+				cca.getAttributeUpdateFailureCount(),
 				summary.TotalBytesTransferred,
 				summary.TotalBytesEnumerated,
+				formatTransactionStats(summary),
 				summary.JobStatus,
+				formatChunkTimeBreakdown(summary.ChunkTimeBreakdown)+formatConcurrencyHistory(summary.ConcurrencyHistory)+formatInterfaceBytes(summary.BytesByInterface),
 				screenStats,
 				formatPerfAdvice(summary.PerformanceAdvice))
 
@@ -505,11 +707,13 @@ Final Job Status: %v%s%s
 		// indicate whether constrained by disk or not
 		perfString, diskString := getPerfDisplayText(summary.PerfStrings, summary.PerfConstraint, duration, false)
 
-		return fmt.Sprintf("%.1f %%, %v Done, %v Failed, %v Pending, %v Total%s, 2-sec Throughput (Mb/s): %v%s",
+		return fmt.Sprintf("%.1f %%%s, %v Done, %v Failed, %v Pending%s, %v Total%s, 2-sec Throughput (Mb/s): %v%s",
 			summary.PercentComplete,
+			formatProgressBasis(summary.ProgressBasis),
 			summary.TransfersCompleted,
 			summary.TransfersFailed,
 			summary.TotalTransfers-summary.TransfersCompleted-summary.TransfersFailed,
+			formatPrefixInFlight(summary),
 			summary.TotalTransfers, perfString, ste.ToFixed(throughput, 4), diskString)
 	})
 
@@ -590,15 +794,25 @@ func init() {
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
-			glcm.EnableInputWatcher()
-			if cancelFromStdin {
-				glcm.EnableCancelFromStdIn()
-			}
-
 			cooked, err := raw.cook()
 			if err != nil {
 				glcm.Error("error parsing the input given by the user. Failed with error " + err.Error())
 			}
+
+			// only a job that would run is saved
+			if raw.exportJobDefinition != "" {
+				msg, err := exportJobDefinition(cmd, args, raw.exportJobDefinition)
+				if err != nil {
+					glcm.Error("cannot save the job definition due to error: " + err.Error())
+				}
+				glcm.Exit(func(format common.OutputFormat) string { return msg }, common.EExitCode.Success())
+			}
+
+			glcm.EnableInputWatcher()
+			if cancelFromStdin {
+				glcm.EnableCancelFromStdIn()
+			}
+			waitForStartJitter(cooked.startJitter)
 			cooked.commandString = copyHandlerUtil{}.ConstructCommandStringFromArgs()
 			err = cooked.process()
 			if err != nil {
@@ -616,6 +830,10 @@ func init() {
 	// smb info/permissions can be persisted in the scenario of File -> File
 	syncCmd.PersistentFlags().BoolVar(&raw.preserveSMBPermissions, "preserve-smb-permissions", false, "False by default. Preserves SMB ACLs between aware resources (Azure Files). This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
 	syncCmd.PersistentFlags().BoolVar(&raw.preserveSMBInfo, "preserve-smb-info", false, "False by default. Preserves SMB property info (last write time, creation time, attribute bits) between SMB-aware resources (Azure Files). This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern). The info transferred for folders is the same as that for files, except for Last Write Time which is not preserved for folders. ")
+	syncCmd.PersistentFlags().BoolVar(&raw.preserveFileAttributes, common.PreserveFileAttributesFlagName, false, "False by default. When syncing from Windows to Blob storage, keeps each file's attributes in the blob's metadata; "+
+		"when syncing from Blob storage to Windows, restores them. Ignored, with a note, when syncing to other operating systems.")
+	syncCmd.PersistentFlags().BoolVar(&raw.preservePOSIX, common.PreservePOSIXFlagName, false, "False by default. Only on Linux and macOS, and only when uploading. Keeps each file's mode, owner and group (as numeric IDs), and modification time, "+
+		"in the same way as the copy command does: in metadata for Blob storage and Azure Files, and in the file's permissions and properties for ADLS Gen2.")
 
 	// TODO: enable when we support local <-> File
 	//syncCmd.PersistentFlags().BoolVar(&raw.forceIfReadOnly, "force-if-read-only", false, "When overwriting an existing file on Windows or Azure Files, force the overwrite to work even if the existing file has its read-only attribute set")
@@ -625,14 +843,50 @@ func init() {
 	syncCmd.PersistentFlags().Float64Var(&raw.blockSizeMB, "block-size-mb", 0, "Use this block size (specified in MiB) when uploading to Azure Storage or downloading from Azure Storage. Default is automatically calculated based on file size. Decimal fractions are allowed (For example: 0.25).")
 	syncCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
 	syncCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
+	syncCmd.PersistentFlags().StringVar(&raw.includeFrom, "include-from", "", "Include only files where the name matches one of the patterns in this file, as well as those of include-pattern. "+
+		"Each line holds one pattern, with the same syntax as include-pattern. Blank lines, and lines starting with #, are ignored.")
+	syncCmd.PersistentFlags().StringVar(&raw.excludeFrom, "exclude-from", "", "Exclude files where the name matches one of the patterns in this file, as well as those of exclude-pattern. "+
+		"Each line holds one pattern, with the same syntax as exclude-pattern. Blank lines, and lines starting with #, are ignored.")
 	syncCmd.PersistentFlags().StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when comparing the source against the destination. "+
 		"This option does not support wildcard characters (*). Checks relative path prefix(For example: myFolder;myFolder/subDirName/file.pdf).")
 	syncCmd.PersistentFlags().StringVar(&raw.includeFileAttributes, "include-attributes", "", "(Windows only) Include only files whose attributes match the attribute list. For example: A;S;R")
 	syncCmd.PersistentFlags().StringVar(&raw.excludeFileAttributes, "exclude-attributes", "", "(Windows only) Exclude files whose attributes match the attribute list. For example: A;S;R")
 	syncCmd.PersistentFlags().StringVar(&raw.logVerbosity, "log-level", "INFO", "Define the log verbosity for the log file, available levels: INFO(all requests and responses), WARNING(slow responses), ERROR(only failed requests), and NONE(no output logs). (default INFO).")
-	syncCmd.PersistentFlags().StringVar(&raw.deleteDestination, "delete-destination", "false", "Defines whether to delete extra files from the destination that are not present at the source. Could be set to true, false, or prompt. "+
-		"If set to prompt, the user will be asked a question before scheduling files and blobs for deletion. (default 'false').")
+	syncCmd.PersistentFlags().StringVar(&raw.logFormat, "log-format", "text", "Define the format of the log file, available formats: text, and json (one JSON object per entry, with level, timestamp, job ID, transfer path, request ID, error code and message fields). (default text).")
+	syncCmd.PersistentFlags().StringVar(&raw.deleteDestination, "delete-destination", "false", "Defines whether to delete extra files from the destination that are not present at the source. Could be set to true, false, prompt, or tombstone. "+
+		"If set to prompt, the user will be asked a question before scheduling files and blobs for deletion. "+
+		"If set to tombstone, extra blobs and files are not deleted, but marked as deleted with the time in their '"+tombstoneMetadataKey+"' metadata, so that they can be recovered. (default 'false').")
+	syncCmd.PersistentFlags().StringVar(&raw.deleteDestinationExclude, "delete-destination-exclude", "", "Used with delete-destination. Keep the extra files at the destination whose names match the pattern list, rather than deleting them. For example: *.gen.js;build.log. "+
+		"Unlike exclude-pattern, these files are still compared with the source, and are only spared when sync decides what to delete.")
+	syncCmd.PersistentFlags().BoolVar(&raw.allowSameLocation, "allow-same-location", false, "False by default. Allow the source and destination to be the same location. "+
+		"Otherwise, AzCopy refuses to sync a location with itself, since that's usually a mistake.")
+	syncCmd.PersistentFlags().IntVar(&raw.lookahead, "enumeration-lookahead", 0, enumerationLookaheadFlagHelp)
+	syncCmd.PersistentFlags().StringVar(&raw.prefixConcurrency, "prefix-concurrency", "", prefixConcurrencyFlagHelp)
+	syncCmd.PersistentFlags().DurationVar(&raw.startJitter, "start-jitter", 0, startJitterFlagHelp)
+	syncCmd.PersistentFlags().StringVar(&raw.exportJobDefinition, exportJobDefinitionFlag, "", exportJobDefinitionFlagHelp)
+	syncCmd.PersistentFlags().IntVar(&raw.tombstoneRetentionDays, "tombstone-retention-days", 0, "Used with delete-destination=tombstone. Blobs and files that were marked as deleted more than this many days ago, and are still absent from the source, are deleted for real. "+
+		"(default 0, which keeps them forever).")
+	syncCmd.PersistentFlags().StringVar(&raw.onCaseMismatch, "on-case-mismatch", common.ECaseMismatchOption.None().String(), "Defines what to do when a source file and a destination file have names that differ only in case, e.g. 'Foo' and 'foo', which on a case-insensitive destination are the same file. "+
+		"Could be set to none, rename, skip, or fail. If set to rename, the destination file is deleted and the source file is transferred under its own name. If set to skip, both are left alone. "+
+		"If set to fail, the sync fails after reporting every mismatch, and transfers already scheduled are cancelled as soon as the first is found. Every mismatch found is reported in the output. (default 'none', which treats them as unrelated files).")
+	syncCmd.PersistentFlags().StringVar(&raw.normalizeUnicode, "normalize-unicode", common.EUnicodeNormalization.None().String(), "Converts the names of source and destination files to one Unicode normalization form before they are compared, "+
+		"so that names which look the same but are encoded differently (e.g. the decomposed names written by macOS) are treated as the same file. The source's names are converted the same way when naming destination files. "+
+		"If two names on the same side are the same once converted, the sync fails rather than treating them as one file. "+
+		"Could be set to none, NFC, or NFD. (default 'none', which compares names exactly as found).")
+	syncCmd.PersistentFlags().BoolVar(&raw.mirror, "mirror", false, "Makes the destination an exact mirror of the source, by also comparing the content headers, metadata, access tier and (for blobs) index tags of each file. "+
+		"When the content of a file is up to date, but its attributes differ, they are set to the source's without transferring the file again. Only available when both the source and destination are remote, i.e. Blob to Blob or Azure Files to Azure Files. "+
+		"Permissions are not compared, but are replicated along with the rest when a file is transferred with preserve-smb-permissions. Listing blob index tags requires the tag permission ('t') on any SAS.")
+	syncCmd.PersistentFlags().BoolVar(&raw.compareMetadata, "compare-metadata", false, "Also compares the metadata of each file whose content is up to date, and when it differs, sets the destination's metadata to the source's without transferring the file again. "+
+		"Only available when both the source and destination are remote. Can't be combined with mirror.")
+	syncCmd.PersistentFlags().BoolVar(&raw.compareTags, "compare-tags", false, "Also compares the index tags of each blob whose content is up to date, and when they differ, sets the destination's tags to the source's without transferring the blob again. "+
+		"Only available from Blob storage to Blob storage. Can't be combined with mirror. Listing and setting index tags requires the tag permission ('t') on any SAS.")
+	syncCmd.PersistentFlags().StringVar(&raw.progressBasis, "progress-basis", common.EProgressBasis.Bytes().String(), "Specifies what the percentage complete is measured against. "+
+		"Available values include: Bytes, Files (the number of files, regardless of their size), and Auto (a blend of the two). (default 'Bytes')")
 	syncCmd.PersistentFlags().BoolVar(&raw.putMd5, "put-md5", false, "Create an MD5 hash of each file, and save the hash as the Content-MD5 property of the destination blob or file. (By default the hash is NOT created.) Only available when uploading.")
+	syncCmd.PersistentFlags().BoolVar(&raw.deltaUpdate, "upload-changed-blocks", false, "False by default. When a changed file is synced to an existing block blob, only upload the blocks whose content differs from the block at the same position in the blob. "+
+		"This suits large files that are modified in place, such as disk images and databases. Blocks are compared by IDs made from their content, and only at the same position, "+
+		"so inserting or removing bytes in a file causes every block after that point to be uploaded again. Only blobs that were uploaded with upload-changed-blocks can be compared; "+
+		"otherwise the whole file is uploaded. Only available when syncing from local to Blob storage.")
 	syncCmd.PersistentFlags().StringVar(&raw.md5ValidationOption, "check-md5", common.DefaultHashValidationOption.String(), "Specifies how strictly MD5 hashes should be validated when downloading. This option is only available when downloading. Available values include: NoCheck, LogOnly, FailIfDifferent, FailIfDifferentOrMissing. (default 'FailIfDifferent').")
 	syncCmd.PersistentFlags().BoolVar(&raw.s2sPreserveAccessTier, "s2s-preserve-access-tier", true, "Preserve access tier during service to service copy. "+
 		"Please refer to [Azure Blob storage: hot, cool, and archive access tiers](https://docs.microsoft.com/azure/storage/blobs/storage-blob-storage-tiers) to ensure destination storage account supports setting access tier. "+
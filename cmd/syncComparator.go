@@ -20,6 +20,12 @@
 
 package cmd
 
+import (
+	"fmt"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
 // with the help of an objectIndexer containing the source objects
 // find out the destination objects that should be transferred
 // in other words, this should be used when destination is being enumerated secondly
@@ -32,6 +38,10 @@ type syncDestinationComparator struct {
 
 	// storing the source objects
 	sourceIndex *objectIndexer
+
+	// deals with source objects whose path differs from that of a destination object only in case.
+	// Must be set if, and only if, the index tracks case mismatches
+	caseMismatches *caseMismatchHandler
 }
 
 func newSyncDestinationComparator(i *objectIndexer, copyScheduler, cleaner objectProcessor) *syncDestinationComparator {
@@ -56,6 +66,10 @@ func (f *syncDestinationComparator) processIfNecessary(destinationObject storedO
 				return err
 			}
 		}
+	} else if sourceObject, mismatched := f.sourceIndex.findCaseMismatch(destinationObject.relativePath); mismatched {
+		// the source object is dealt with here, so it must not be transferred at the end
+		delete(f.sourceIndex.indexMap, sourceObject.relativePath)
+		return f.caseMismatches.handle(sourceObject, destinationObject)
 	} else {
 		// purposefully ignore the error from destinationCleaner
 		// it's a tolerable error, since it just means some extra destination object might hang around a bit longer
@@ -74,6 +88,14 @@ type syncSourceComparator struct {
 
 	// storing the destination objects
 	destinationIndex *objectIndexer
+
+	// deals with destination objects whose path differs from that of a source object only in case.
+	// Must be set if, and only if, the index tracks case mismatches
+	caseMismatches *caseMismatchHandler
+
+	// brings the attributes of a destination object whose content is up to date into line with those of the source object,
+	// without transferring it again, when they are compared or mirrored. Nil otherwise
+	attributeUpdater func(sourceObject, destinationObject storedObject) error
 }
 
 func newSyncSourceComparator(i *objectIndexer, copyScheduler objectProcessor) *syncSourceComparator {
@@ -83,6 +105,7 @@ func newSyncSourceComparator(i *objectIndexer, copyScheduler objectProcessor) *s
 // it will only transfer source items that are:
 //	1. not present in the map
 //  2. present but is more recent than the entry in the map
+// source items that are present but not transferred may instead have the attributes of the entry in the map updated
 // note: we remove the storedObject if it is present so that when we have finished
 // the index will contain all objects which exist at the destination but were NOT seen at the source
 func (f *syncSourceComparator) processIfNecessary(sourceObject storedObject) error {
@@ -95,12 +118,87 @@ func (f *syncSourceComparator) processIfNecessary(sourceObject storedObject) err
 		if sourceObject.isMoreRecentThan(destinationObjectInMap) {
 			return f.copyTransferScheduler(sourceObject)
 
+		} else if f.attributeUpdater != nil {
+			// the content is up to date, but the attributes might not be
+			return f.attributeUpdater(sourceObject, destinationObjectInMap)
 		} else {
 			// skip if source is more recent
 			return nil
 		}
 	}
 
+	if destinationObject, mismatched := f.destinationIndex.findCaseMismatch(sourceObject.relativePath); mismatched {
+		// the destination object is dealt with here, so it must not be deleted as an extra at the end
+		delete(f.destinationIndex.indexMap, destinationObject.relativePath)
+		return f.caseMismatches.handle(sourceObject, destinationObject)
+	}
+
 	// if source does not exist at the destination, then schedule it for transfer
 	return f.copyTransferScheduler(sourceObject)
 }
+
+// caseMismatchHandler deals with a source object and a destination object whose paths differ only in case.
+// On a case-insensitive destination these are the same object, so transferring the source as usual
+// could update the wrong object, or have the update deleted as an extra.
+type caseMismatchHandler struct {
+	option common.CaseMismatchOption
+
+	// the processor responsible for scheduling copy transfers
+	copyTransferScheduler objectProcessor
+
+	// removes the destination object, when renaming
+	destinationDeleter objectProcessor
+
+	// the count of mismatches found, when the sync is to fail because of them
+	failures uint64
+
+	// stops the transfers that were scheduled before the first mismatch was found, when the sync is to fail
+	cancelJob func() error
+}
+
+func (h *caseMismatchHandler) handle(sourceObject, destinationObject storedObject) error {
+	msg := fmt.Sprintf("Case mismatch: the source '%s' and the destination '%s' differ only in case", sourceObject.relativePath, destinationObject.relativePath)
+
+	switch h.option {
+	case common.ECaseMismatchOption.Fail():
+		// report every mismatch before failing, so that they can all be resolved before the next attempt
+		WarnStdoutAndJobLog(msg)
+		h.failures++
+		if h.failures == 1 && h.cancelJob != nil {
+			if err := h.cancelJob(); err != nil {
+				return fmt.Errorf("cannot stop the transfers that were already scheduled: %s", err.Error())
+			}
+		}
+		return nil
+	case common.ECaseMismatchOption.Skip():
+		WarnStdoutAndJobLog(msg + "; both are left as they are")
+		return nil
+	case common.ECaseMismatchOption.Rename():
+		WarnStdoutAndJobLog(msg + "; the destination will be renamed to match the source")
+		// delete first, since on a case-insensitive destination, deleting afterwards would remove what we transferred
+		if err := h.destinationDeleter(destinationObject); err != nil {
+			return fmt.Errorf("cannot rename the destination '%s': %s", destinationObject.relativePath, err.Error())
+		}
+		return h.copyTransferScheduler(sourceObject)
+	default:
+		return fmt.Errorf("cannot handle the case mismatch of '%s' with the option %s", sourceObject.relativePath, h.option)
+	}
+}
+
+// unlessFailing wraps the processor so that, once the sync is bound to fail, nothing more is scheduled
+func (h *caseMismatchHandler) unlessFailing(processor objectProcessor) objectProcessor {
+	return func(object storedObject) error {
+		if h.failures > 0 {
+			return nil
+		}
+		return processor(object)
+	}
+}
+
+// err returns the error with which the sync must fail, if any
+func (h *caseMismatchHandler) err() error {
+	if h == nil || h.failures == 0 {
+		return nil
+	}
+	return fmt.Errorf("the sync was stopped because %d object(s) at the source and destination differ only in case", h.failures)
+}
@@ -67,6 +67,10 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	if err != nil {
 		return nil, err
 	}
+	if t, ok := sourceTraverser.(*blobTraverser); ok && (cca.mirror || cca.compareTags) {
+		t.includeTags = true // so that drift in the tags is noticed, and the tags are copied along with the rest
+	}
+	sourceTraverser = newUnicodeNormalizingTraverser(sourceTraverser, cca.normalizeUnicode)
 
 	// Because we can't trust cca.credinfo, given that it's for the overall job, not the individual traversers, we get cred info again here.
 	dstCredInfo, _, err := getCredentialInfoForLocation(ctx, cca.fromTo.To(), cca.destination.Value, cca.destination.SAS, false)
@@ -86,6 +90,10 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	if err != nil {
 		return nil, err
 	}
+	if t, ok := destinationTraverser.(*blobTraverser); ok && (cca.mirror || cca.compareTags) {
+		t.includeTags = true
+	}
+	destinationTraverser = newUnicodeNormalizingTraverser(destinationTraverser, cca.normalizeUnicode)
 
 	// verify that the traversers are targeting the same type of resources
 	if sourceTraverser.isDirectory(true) != destinationTraverser.isDirectory(true) {
@@ -129,6 +137,16 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	var comparator objectProcessor
 	var finalize func() error
 
+	copyScheduler := transferScheduler.scheduleCopyTransfer
+	caseMismatches, err := newSyncCaseMismatchHandler(cca, copyScheduler)
+	if err != nil {
+		return nil, fmt.Errorf("unable to instantiate case mismatch handler due to: %s", err.Error())
+	}
+	if caseMismatches != nil {
+		indexer.trackCaseMismatches()
+		copyScheduler = caseMismatches.unlessFailing(copyScheduler)
+	}
+
 	switch cca.fromTo {
 	case common.EFromTo.LocalBlob():
 		// upload implies transferring from a local disk to a remote resource
@@ -140,12 +158,21 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 			return nil, fmt.Errorf("unable to instantiate destination cleaner due to: %s", err.Error())
 		}
 		destCleanerFunc := newFpoAwareProcessor(fpo, destinationCleaner.removeImmediately)
+		if caseMismatches != nil {
+			destCleanerFunc = caseMismatches.unlessFailing(destCleanerFunc)
+		}
 
 		// when uploading, we can delete remote objects immediately, because as we traverse the remote location
 		// we ALREADY have available a complete map of everything that exists locally
 		// so as soon as we see a remote destination object we can know whether it exists in the local source
-		comparator = newSyncDestinationComparator(indexer, transferScheduler.scheduleCopyTransfer, destCleanerFunc).processIfNecessary
+		destinationComparator := newSyncDestinationComparator(indexer, copyScheduler, destCleanerFunc)
+		destinationComparator.caseMismatches = caseMismatches
+		comparator = destinationComparator.processIfNecessary
 		finalize = func() error {
+			if err := caseMismatches.err(); err != nil {
+				return err
+			}
+
 			// schedule every local file that doesn't exist at the destination
 			err = indexer.traverse(transferScheduler.scheduleCopyTransfer, filters)
 			if err != nil {
@@ -167,9 +194,22 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	default:
 		// in all other cases (download and S2S), the destination is scanned/indexed first
 		// then the source is scanned and filtered based on what the destination contains
-		comparator = newSyncSourceComparator(indexer, transferScheduler.scheduleCopyTransfer).processIfNecessary
+		sourceComparator := newSyncSourceComparator(indexer, copyScheduler)
+		sourceComparator.caseMismatches = caseMismatches
+		if cca.compareMetadata || cca.compareTags || cca.mirror {
+			updater, err := newSyncAttributeUpdater(cca)
+			if err != nil {
+				return nil, fmt.Errorf("unable to instantiate attribute updater due to: %s", err.Error())
+			}
+			sourceComparator.attributeUpdater = updater.update
+		}
+		comparator = sourceComparator.processIfNecessary
 
 		finalize = func() error {
+			if err := caseMismatches.err(); err != nil {
+				return err
+			}
+
 			// remove the extra files at the destination that were not present at the source
 			// we can only know what needs to be deleted when we have FINISHED traversing the remote source
 			// since only then can we know which local files definitely don't exist remotely
@@ -198,7 +238,7 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 				return err
 			}
 
-			quitIfInSync(jobInitiated, cca.getDeletionCount() > 0, cca)
+			quitIfInSync(jobInitiated, cca.getDeletionCount() > 0 || cca.getAttributeUpdateCount() > 0 || cca.getAttributeUpdateFailureCount() > 0, cca)
 			cca.setScanningComplete()
 			return nil
 		}
@@ -207,14 +247,20 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	}
 }
 
-func quitIfInSync(transferJobInitiated, anyDestinationFileDeleted bool, cca *cookedSyncCmdArgs) {
-	if !transferJobInitiated && !anyDestinationFileDeleted {
+func quitIfInSync(transferJobInitiated, anyDestinationFileChanged bool, cca *cookedSyncCmdArgs) {
+	if !transferJobInitiated && !anyDestinationFileChanged {
 		cca.reportScanningProgress(glcm, 0)
 		glcm.Exit(func(format common.OutputFormat) string {
 			return "The source and destination are already in sync."
 		}, common.EExitCode.Success())
-	} else if !transferJobInitiated && anyDestinationFileDeleted {
-		// some files were deleted but no transfer scheduled
+	} else if !transferJobInitiated && cca.getAttributeUpdateFailureCount() > 0 {
+		// nothing was transferred, but some attributes couldn't be updated, so the destination isn't fully in sync
+		cca.reportScanningProgress(glcm, 0)
+		glcm.Exit(func(format common.OutputFormat) string {
+			return fmt.Sprintf("The attributes of %v object(s) at the destination could not be updated.", cca.getAttributeUpdateFailureCount())
+		}, common.EExitCode.Error())
+	} else if !transferJobInitiated && anyDestinationFileChanged {
+		// some files were deleted, or had their attributes updated, but no transfer scheduled
 		cca.reportScanningProgress(glcm, 0)
 		glcm.Exit(func(format common.OutputFormat) string {
 			return "The source and destination are now in sync."
@@ -20,6 +20,8 @@
 
 package cmd
 
+import "strings"
+
 // the objectIndexer is essential for the generic sync enumerator to work
 // it can serve as a:
 // 		1. objectProcessor: accumulate a lookup map with given storedObjects
@@ -27,6 +29,9 @@ package cmd
 type objectIndexer struct {
 	indexMap map[string]storedObject
 	counter  int
+
+	// maps the lower case form of each relative path to the path as stored, when looking for case mismatches
+	foldedIndexMap map[string]string
 }
 
 func newObjectIndexer() *objectIndexer {
@@ -44,9 +49,30 @@ func (i *objectIndexer) store(storedObject storedObject) (err error) {
 
 	i.indexMap[storedObject.relativePath] = storedObject
 	i.counter += 1
+	if i.foldedIndexMap != nil {
+		i.foldedIndexMap[strings.ToLower(storedObject.relativePath)] = storedObject.relativePath
+	}
 	return
 }
 
+// trackCaseMismatches must be called before anything is stored, for findCaseMismatch to work
+func (i *objectIndexer) trackCaseMismatches() {
+	i.foldedIndexMap = make(map[string]string)
+}
+
+// findCaseMismatch returns the stored object whose relative path differs from the given one only in case, if there is one
+func (i *objectIndexer) findCaseMismatch(relativePath string) (storedObject, bool) {
+	if i.foldedIndexMap == nil {
+		return storedObject{}, false
+	}
+	storedPath, ok := i.foldedIndexMap[strings.ToLower(relativePath)]
+	if !ok || storedPath == relativePath {
+		return storedObject{}, false
+	}
+	object, ok := i.indexMap[storedPath] // it may have been removed from the index since
+	return object, ok
+}
+
 // go through the remaining stored objects in the map to process them
 func (i *objectIndexer) traverse(processor objectProcessor, filters []objectFilter) (err error) {
 	for _, value := range i.indexMap {
@@ -26,6 +26,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"time"
 
 	"github.com/Azure/azure-storage-file-go/azfile"
 
@@ -50,18 +51,29 @@ func newSyncTransferProcessor(cca *cookedSyncCmdArgs, numOfTransfersPerPart int,
 		BlobAttributes: common.BlobTransferAttributes{
 			PreserveLastModifiedTime: true, // must be true for sync so that future syncs have this information available
 			PutMd5:                   cca.putMd5,
+			DeltaUpdate:              cca.deltaUpdate,
 			MD5ValidationOption:      cca.md5ValidationOption,
 			BlockSizeInBytes:         cca.blockSize},
 		ForceWrite:                     common.EOverwriteOption.True(), // once we decide to transfer for a sync operation, we overwrite the destination regardless
 		ForceIfReadOnly:                cca.forceIfReadOnly,
+		ProgressBasis:                  cca.progressBasis,
+		TrailingDot:                    azcopyTrailingDot,
+		AppendOnly:                     azcopyAppendOnly,
+		ChunkTimelinePath:              azcopyChunkTimelinePath,
+		EnumerationLookahead:           cca.lookahead,
+		PrefixConcurrency:              cca.prefixConcurrency,
 		LogLevel:                       cca.logVerbosity,
+		LogFormat:                      cca.logFormat,
 		PreserveSMBPermissions:         cca.preserveSMBPermissions,
 		PreserveSMBInfo:                cca.preserveSMBInfo,
+		PreserveFileAttributes:         cca.preserveFileAttributes,
+		PreservePOSIX:                  cca.preservePOSIX,
 		S2SSourceChangeValidation:      true,
 		DestLengthValidation:           true,
 		S2SGetPropertiesInBackend:      true,
 		S2SInvalidMetadataHandleOption: common.EInvalidMetadataHandleOption.RenameIfInvalid(),
 	}
+	copyJobTemplate.CommandStartTime = timeAtPrestart
 
 	reportFirstPart := func(jobStarted bool) { cca.setFirstPartOrdered() } // for compatibility with the way sync has always worked, we don't check jobStarted here
 	reportFinalPart := func() { cca.isEnumerationComplete = true }
@@ -93,9 +105,19 @@ type interactiveDeleteProcessor struct {
 
 	// count the deletions that happened
 	incrementDeletionCount func()
+
+	// the objects that don't pass these filters are kept, rather than deleted
+	keep []objectFilter
 }
 
 func (d *interactiveDeleteProcessor) removeImmediately(object storedObject) (err error) {
+	for _, filter := range d.keep {
+		if !filter.doesPass(object) {
+			glcm.Info(fmt.Sprintf("Keeping extra %s, since it matches delete-destination-exclude: %s", d.objectTypeToDisplay, object.relativePath))
+			return nil
+		}
+	}
+
 	if d.shouldPromptUser {
 		d.shouldDelete, d.shouldPromptUser = d.promptForConfirmation(object) // note down the user's decision
 	}
@@ -158,13 +180,15 @@ func newInteractiveDeleteProcessor(deleter objectProcessor, deleteDestination co
 		objectLocationToDisplay: objectLocationToDisplay.Value,
 		incrementDeletionCount:  incrementDeletionCounter,
 		shouldPromptUser:        deleteDestination == common.EDeleteDestination.Prompt(),
-		shouldDelete:            deleteDestination == common.EDeleteDestination.True(), // if shouldPromptUser is true, this will start as false, but we will determine its value later
+		shouldDelete:            deleteDestination == common.EDeleteDestination.True() || deleteDestination == common.EDeleteDestination.Tombstone(), // if shouldPromptUser is true, this will start as false, but we will determine its value later
 	}
 }
 
 func newSyncLocalDeleteProcessor(cca *cookedSyncCmdArgs) *interactiveDeleteProcessor {
 	localDeleter := localFileDeleter{rootPath: cca.destination.ValueLocal()}
-	return newInteractiveDeleteProcessor(localDeleter.deleteFile, cca.deleteDestination, "local file", cca.destination, cca.incrementDeletionCount)
+	d := newInteractiveDeleteProcessor(localDeleter.deleteFile, cca.deleteDestination, "local file", cca.destination, cca.incrementDeletionCount)
+	d.keep = buildExcludeFilters(cca.deleteDestinationExclude, false)
+	return d
 }
 
 type localFileDeleter struct {
@@ -190,7 +214,7 @@ func shouldSyncRemoveFolders() bool {
 func (l *localFileDeleter) deleteFile(object storedObject) error {
 	if object.entityType == common.EEntityType.File() {
 		glcm.Info("Deleting extra file: " + object.relativePath)
-		return os.Remove(common.GenerateFullPath(l.rootPath, object.relativePath))
+		return os.Remove(common.GenerateFullPath(l.rootPath, object.addressableRelativePath()))
 	} else {
 		if shouldSyncRemoveFolders() {
 			panic("folder deletion enabled but not implemented")
@@ -200,6 +224,26 @@ func (l *localFileDeleter) deleteFile(object storedObject) error {
 }
 
 func newSyncDeleteProcessor(cca *cookedSyncCmdArgs) (*interactiveDeleteProcessor, error) {
+	deleter, err := newSyncRemoteResourceDeleter(cca)
+	if err != nil {
+		return nil, err
+	}
+	if cca.deleteDestination == common.EDeleteDestination.Tombstone() {
+		// the tombstoner counts deletions itself, since objects that are already marked as deleted are left alone
+		tombstoner := &remoteTombstoner{remoteResourceDeleter: deleter, retention: cca.tombstoneRetention, incrementDeletionCount: cca.incrementDeletionCount}
+		d := newInteractiveDeleteProcessor(tombstoner.tombstone,
+			cca.deleteDestination, cca.fromTo.To().String(), cca.destination, nil)
+		d.keep = buildExcludeFilters(cca.deleteDestinationExclude, false)
+		return d, nil
+	}
+
+	d := newInteractiveDeleteProcessor(deleter.delete,
+		cca.deleteDestination, cca.fromTo.To().String(), cca.destination, cca.incrementDeletionCount)
+	d.keep = buildExcludeFilters(cca.deleteDestinationExclude, false)
+	return d, nil
+}
+
+func newSyncRemoteResourceDeleter(cca *cookedSyncCmdArgs) (*remoteResourceDeleter, error) {
 	rawURL, err := cca.destination.FullURL()
 	if err != nil {
 		return nil, err
@@ -212,8 +256,38 @@ func newSyncDeleteProcessor(cca *cookedSyncCmdArgs) (*interactiveDeleteProcessor
 		return nil, err
 	}
 
-	return newInteractiveDeleteProcessor(newRemoteResourceDeleter(rawURL, p, ctx, cca.fromTo.To()).delete,
-		cca.deleteDestination, cca.fromTo.To().String(), cca.destination, cca.incrementDeletionCount), nil
+	return newRemoteResourceDeleter(rawURL, p, ctx, cca.fromTo.To()), nil
+}
+
+// newSyncCaseMismatchHandler returns nil if case mismatches are to be treated like any other difference in name
+func newSyncCaseMismatchHandler(cca *cookedSyncCmdArgs, copyScheduler objectProcessor) (*caseMismatchHandler, error) {
+	if cca.onCaseMismatch == common.ECaseMismatchOption.None() {
+		return nil, nil
+	}
+
+	handler := &caseMismatchHandler{option: cca.onCaseMismatch, copyTransferScheduler: copyScheduler}
+	if cca.onCaseMismatch == common.ECaseMismatchOption.Rename() {
+		// renaming deletes the destination object without prompting, whatever delete-destination says, since it is then replaced
+		if cca.fromTo.To() == common.ELocation.Local() {
+			localDeleter := localFileDeleter{rootPath: cca.destination.ValueLocal()}
+			handler.destinationDeleter = localDeleter.deleteFile
+		} else {
+			deleter, err := newSyncRemoteResourceDeleter(cca)
+			if err != nil {
+				return nil, err
+			}
+			handler.destinationDeleter = deleter.delete
+		}
+	}
+	if cca.onCaseMismatch == common.ECaseMismatchOption.Fail() {
+		handler.cancelJob = func() error {
+			if !cca.firstPartOrdered() {
+				return nil
+			}
+			return cookedCancelCmdArgs{jobID: cca.jobID}.process()
+		}
+	}
+	return handler, nil
 }
 
 type remoteResourceDeleter struct {
@@ -239,13 +313,13 @@ func (b *remoteResourceDeleter) delete(object storedObject) error {
 		switch b.targetLocation {
 		case common.ELocation.Blob():
 			blobURLParts := azblob.NewBlobURLParts(*b.rootURL)
-			blobURLParts.BlobName = path.Join(blobURLParts.BlobName, object.relativePath)
+			blobURLParts.BlobName = path.Join(blobURLParts.BlobName, object.addressableRelativePath())
 			blobURL := azblob.NewBlobURL(blobURLParts.URL(), b.p)
 			_, err := blobURL.Delete(b.ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
 			return err
 		case common.ELocation.File():
 			fileURLParts := azfile.NewFileURLParts(*b.rootURL)
-			fileURLParts.DirectoryOrFilePath = path.Join(fileURLParts.DirectoryOrFilePath, object.relativePath)
+			fileURLParts.DirectoryOrFilePath = path.Join(fileURLParts.DirectoryOrFilePath, object.addressableRelativePath())
 			fileURL := azfile.NewFileURL(fileURLParts.URL(), b.p)
 			_, err := fileURL.Delete(b.ctx)
 			return err
@@ -259,3 +333,142 @@ func (b *remoteResourceDeleter) delete(object storedObject) error {
 		return nil
 	}
 }
+
+// remoteAttributeUpdater sets the metadata and/or index tags of destination objects whose content is up to date
+// to those of their source objects, when they differ, so that the attributes are synced without transferring the content again.
+// When mirroring, the content headers and access tier are set too. Only the attributes that differ are set.
+type remoteAttributeUpdater struct {
+	*remoteResourceDeleter
+	compareMetadata       bool
+	compareTags           bool
+	mirror                bool
+	compareTier           bool // only when mirroring, and tiers are preserved
+	incrementUpdateCount  func()
+	incrementFailureCount func()
+}
+
+func newSyncAttributeUpdater(cca *cookedSyncCmdArgs) (*remoteAttributeUpdater, error) {
+	deleter, err := newSyncRemoteResourceDeleter(cca)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteAttributeUpdater{remoteResourceDeleter: deleter, compareMetadata: cca.compareMetadata, compareTags: cca.compareTags,
+		mirror: cca.mirror, compareTier: cca.mirror && cca.preserveAccessTier,
+		incrementUpdateCount: cca.incrementAttributeUpdateCount, incrementFailureCount: cca.incrementAttributeUpdateFailureCount}, nil
+}
+
+func (u *remoteAttributeUpdater) update(sourceObject, destinationObject storedObject) error {
+	if sourceObject.entityType != common.EEntityType.File() {
+		return nil
+	}
+	updateHeaders := u.mirror && !sourceObject.hasSameContentHeadersAs(destinationObject)
+	updateTier := u.compareTier && !sourceObject.hasSameTierAs(destinationObject)
+	updateMetadata := (u.compareMetadata || u.mirror) && !sameStringMaps(sourceObject.Metadata, destinationObject.Metadata, true)
+	updateTags := (u.compareTags || u.mirror) && !sameStringMaps(sourceObject.blobTags, destinationObject.blobTags, false)
+	if !updateHeaders && !updateTier && !updateMetadata && !updateTags {
+		return nil
+	}
+
+	glcm.Info("Updating the attributes of: " + destinationObject.relativePath)
+	var err error
+	switch u.targetLocation {
+	case common.ELocation.Blob():
+		blobURLParts := azblob.NewBlobURLParts(*u.rootURL)
+		blobURLParts.BlobName = path.Join(blobURLParts.BlobName, destinationObject.addressableRelativePath())
+		blobURL := azblob.NewBlobURL(blobURLParts.URL(), u.p)
+		if updateHeaders {
+			// all the headers are set at once, so the destination's MD5 is set again as it is
+			_, err = blobURL.SetHTTPHeaders(u.ctx, azblob.BlobHTTPHeaders{ContentType: sourceObject.contentType, ContentEncoding: sourceObject.contentEncoding,
+				ContentDisposition: sourceObject.contentDisposition, ContentLanguage: sourceObject.contentLanguage, CacheControl: sourceObject.cacheControl,
+				ContentMD5: destinationObject.md5}, azblob.BlobAccessConditions{})
+		}
+		if err == nil && updateTier {
+			_, err = blobURL.SetTier(u.ctx, sourceObject.blobAccessTier, azblob.LeaseAccessConditions{})
+		}
+		if err == nil && updateMetadata {
+			_, err = blobURL.SetMetadata(u.ctx, sourceObject.Metadata.ToAzBlobMetadata(), azblob.BlobAccessConditions{})
+		}
+		if err == nil && updateTags {
+			_, err = blobURL.SetTags(u.ctx, nil, nil, nil, nil, nil, nil, sourceObject.blobTags.ToAzBlobTagsMap())
+		}
+	case common.ELocation.File():
+		fileURLParts := azfile.NewFileURLParts(*u.rootURL)
+		fileURLParts.DirectoryOrFilePath = path.Join(fileURLParts.DirectoryOrFilePath, destinationObject.addressableRelativePath())
+		fileURL := azfile.NewFileURL(fileURLParts.URL(), u.p)
+		if updateHeaders {
+			// the SMB properties are left as they are
+			_, err = fileURL.SetHTTPHeaders(u.ctx, azfile.FileHTTPHeaders{ContentType: sourceObject.contentType, ContentEncoding: sourceObject.contentEncoding,
+				ContentDisposition: sourceObject.contentDisposition, ContentLanguage: sourceObject.contentLanguage, CacheControl: sourceObject.cacheControl,
+				ContentMD5: destinationObject.md5})
+		}
+		if err == nil && updateMetadata {
+			_, err = fileURL.SetMetadata(u.ctx, sourceObject.Metadata.ToAzFileMetadata())
+		}
+	default:
+		return fmt.Errorf("updating the attributes of objects at a %s destination is not supported", u.targetLocation.String())
+	}
+
+	// a failed update doesn't stop the sync, since the next one will try again, but it's counted so that the job reports it
+	if err != nil {
+		glcm.Info(fmt.Sprintf("error %s updating the attributes of the object %s", err.Error(), destinationObject.relativePath))
+		u.incrementFailureCount()
+		return nil
+	}
+	u.incrementUpdateCount()
+	return nil
+}
+
+// the metadata key under which sync records when an object was marked as deleted, with delete-destination=tombstone
+const tombstoneMetadataKey = "azcopy_tombstone"
+
+// remoteTombstoner marks extra objects at the destination as deleted, instead of deleting them,
+// so that a backup can recover them. Objects that have been marked for longer than the retention are deleted for real.
+type remoteTombstoner struct {
+	*remoteResourceDeleter
+	retention              time.Duration // zero keeps tombstones forever
+	incrementDeletionCount func()
+}
+
+func (t *remoteTombstoner) tombstone(object storedObject) error {
+	if object.entityType != common.EEntityType.File() {
+		// like deletion, tombstones only apply to files
+		return nil
+	}
+
+	if value, ok := object.Metadata[tombstoneMetadataKey]; ok {
+		// marked by an earlier sync. Keep it until the retention is up
+		deletedAt, err := time.Parse(time.RFC3339, value)
+		if t.retention == 0 || err != nil || time.Since(deletedAt) < t.retention {
+			return nil
+		}
+		t.incrementDeletionCount()
+		return t.delete(object)
+	}
+
+	glcm.Info("Marking extra object as deleted: " + object.relativePath)
+	metadata := common.Metadata{}
+	for k, v := range object.Metadata {
+		metadata[k] = v
+	}
+	metadata[tombstoneMetadataKey] = time.Now().UTC().Format(time.RFC3339)
+
+	var err error
+	switch t.targetLocation {
+	case common.ELocation.Blob():
+		blobURLParts := azblob.NewBlobURLParts(*t.rootURL)
+		blobURLParts.BlobName = path.Join(blobURLParts.BlobName, object.addressableRelativePath())
+		blobURL := azblob.NewBlobURL(blobURLParts.URL(), t.p)
+		_, err = blobURL.SetMetadata(t.ctx, metadata.ToAzBlobMetadata(), azblob.BlobAccessConditions{})
+	case common.ELocation.File():
+		fileURLParts := azfile.NewFileURLParts(*t.rootURL)
+		fileURLParts.DirectoryOrFilePath = path.Join(fileURLParts.DirectoryOrFilePath, object.addressableRelativePath())
+		fileURL := azfile.NewFileURL(fileURLParts.URL(), t.p)
+		_, err = fileURL.SetMetadata(t.ctx, metadata.ToAzFileMetadata())
+	default:
+		panic("not implemented, check your code")
+	}
+	if err == nil {
+		t.incrementDeletionCount()
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// the shortest window that throughput can be held to a floor over. Progress is only measured every couple of seconds,
+// so a shorter window would be decided by one or two measurements, which are too noisy to fail a job on
+const shortestThroughputWindow = 10 * time.Second
+
+const (
+	throughputFloorActionFail = "fail"
+	throughputFloorActionWarn = "warn"
+)
+
+// throughputFloor watches the throughput of a job, as measured by its progress reports,
+// and says when it has averaged less than a floor over a whole window
+type throughputFloor struct {
+	floorMbps float64
+	window    time.Duration
+	action    string // what to do when the floor is breached: fail the job, or only warn
+
+	samples  []throughputSample // oldest first. The oldest is the last one taken at or before the start of the window
+	breached bool               // whether the throughput is below the floor, so that a breach is only reported when it starts
+}
+
+type throughputSample struct {
+	at    time.Time
+	bytes uint64
+}
+
+// validateMinThroughput returns the floor to hold the job's throughput to, or nil if there's none
+func validateMinThroughput(floorMbps float64, window time.Duration, action string, fromTo common.FromTo) (*throughputFloor, error) {
+	if floorMbps < 0 {
+		return nil, errors.New("min-throughput-mbps cannot be negative")
+	}
+	if floorMbps == 0 {
+		return nil, nil
+	}
+	if fromTo.IsS2S() {
+		// the service copies the data, so AzCopy can't measure how quickly it's going
+		return nil, errors.New("min-throughput-mbps cannot be used for service to service copies, since their data doesn't pass through AzCopy")
+	}
+	if window < shortestThroughputWindow {
+		return nil, fmt.Errorf("min-throughput-window must be at least %v", shortestThroughputWindow)
+	}
+	action = strings.ToLower(strings.TrimSpace(action))
+	if action != throughputFloorActionFail && action != throughputFloorActionWarn {
+		return nil, fmt.Errorf("min-throughput-action must be %s or %s", throughputFloorActionFail, throughputFloorActionWarn)
+	}
+	return &throughputFloor{floorMbps: floorMbps, window: window, action: action}, nil
+}
+
+// observe records how many bytes the job has sent over the wire, as of now, and returns its average throughput over the window.
+// It returns true when that throughput has just fallen below the floor, which can only happen once the job has been measured for a whole window.
+// It won't return true again unless the throughput first recovers.
+// Time when the job isn't measured (e.g. while it's paused, or has nothing to transfer) isn't counted, so the window starts again after it
+func (f *throughputFloor) observe(now time.Time, bytesOverWire uint64, measured bool) (mbps float64, breached bool) {
+	if !measured {
+		f.samples = nil
+		return 0, false
+	}
+
+	f.samples = append(f.samples, throughputSample{at: now, bytes: bytesOverWire})
+	for len(f.samples) > 1 && !f.samples[1].at.After(now.Add(-f.window)) {
+		f.samples = f.samples[1:]
+	}
+
+	oldest := f.samples[0]
+	elapsed := now.Sub(oldest.at)
+	if elapsed < f.window {
+		return 0, false
+	}
+	mbps = float64(bytesOverWire-oldest.bytes) * 8 / base10Mega / elapsed.Seconds()
+
+	below := mbps < f.floorMbps
+	breached = below && !f.breached
+	f.breached = below
+	return mbps, breached
+}
+
+// isMeasured says whether the throughput of a job, as of the given summary, is held to the floor.
+// It's only measured while the job has transfers to do and is not paused, and not in its tail:
+// once everything is ordered, and what's left would be sent in less than a window at the floor,
+// the last few transfers can't keep the network busy, so their throughput says nothing about it
+func (f *throughputFloor) isMeasured(summary common.ListJobSummaryResponse) bool {
+	done := summary.TransfersCompleted + summary.TransfersFailed + summary.TransfersSkipped
+	if summary.JobStatus != common.EJobStatus.InProgress() || done >= summary.TotalTransfers {
+		return false
+	}
+	if !summary.CompleteJobOrdered || summary.TotalBytesExpected < summary.TotalBytesTransferred {
+		return true
+	}
+	remainingMb := float64(summary.TotalBytesExpected-summary.TotalBytesTransferred) * 8 / base10Mega
+	return remainingMb >= f.floorMbps*f.window.Seconds()
+}
+
+// checkThroughputFloor fails the job, or warns, if its throughput has just fallen below the floor that it's held to
+func (cca *cookedCopyCmdArgs) checkThroughputFloor(summary common.ListJobSummaryResponse) {
+	mbps, breached := cca.throughputFloor.observe(time.Now(), summary.BytesOverWire, cca.throughputFloor.isMeasured(summary))
+	if !breached {
+		return
+	}
+
+	msg := fmt.Sprintf("The throughput has averaged %v Mb/s over the last %v, which is below the minimum of %v Mb/s.",
+		ste.ToFixed(mbps, 2), cca.throughputFloor.window, cca.throughputFloor.floorMbps)
+	if cca.throughputFloor.action == throughputFloorActionFail {
+		msg += " Cancelling the job, since the network may be degraded."
+	}
+	if jobMan, exists := ste.JobsAdmin.JobMgr(cca.jobID); exists {
+		jobMan.Log(pipeline.LogWarning, msg)
+	}
+	glcm.Info(msg)
+
+	if cca.throughputFloor.action == throughputFloorActionFail {
+		cca.throughputFloorFailure = msg
+		if err := (cookedCancelCmdArgs{jobID: cca.jobID}).process(); err != nil {
+			glcm.Error("error occurred while cancelling the job " + cca.jobID.String() + ": " + err.Error())
+		}
+	}
+}
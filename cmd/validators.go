@@ -52,7 +52,7 @@ func validateFromTo(src, dst string, userSpecifiedFromTo string) (common.FromTo,
 }
 
 const fromToHelpText = "Valid values are two-word phases of the form BlobLocal, LocalBlob etc.  Use the word 'Blob' for Blob Storage, " +
-	"'Local' for the local file system, 'File' for Azure Files, and 'BlobFS' for ADLS Gen2. " +
+	"'Local' for the local file system, 'File' for Azure Files, 'BlobFS' for ADLS Gen2, and 'Http' for any other web server, as a source. " +
 	"If you need a combination that is not supported yet, please log an issue on the AzCopy GitHub issues list."
 
 func inferFromTo(src, dst string) common.FromTo {
@@ -129,6 +129,11 @@ func inferArgumentLocation(arg string) common.Location {
 		u, err := url.Parse(arg)
 		// NOTE: sometimes, a local path can also be parsed as a url. To avoid thinking it's a URL, check Scheme, Host, and Path
 		if err == nil && u.Scheme != "" && u.Host != "" {
+			// the endpoints given on the command line needn't look like those of the storage services
+			if loc, ok := azcopyEndpoints.locationOf(u.Host); ok {
+				return loc
+			}
+
 			// Is the argument a URL to blob storage?
 			switch host := strings.ToLower(u.Host); true {
 			// Azure Stack does not have the core.windows.net
@@ -72,6 +72,9 @@ type storedObject struct {
 	// (if the source is folder-aware). In this case relativePath is also empty.
 	// In this case isSourceRootFolder returns true.
 	relativePath string
+	// the relative path exactly as found, when relativePath has been converted to another Unicode normalization form
+	// (see --normalize-unicode). Empty otherwise. Use addressableRelativePath wherever the object itself must be found.
+	unnormalizedRelativePath string
 	// container source, only included by account traversers.
 	containerName string
 	// destination container name. Included in the processor after resolving container names.
@@ -81,6 +84,8 @@ type storedObject struct {
 	// metadata, included in S2S transfers
 	Metadata      common.Metadata
 	blobVersionID string
+	// index tags, only included by the blob traverser when it is asked for them
+	blobTags common.BlobTags
 }
 
 const (
@@ -91,6 +96,54 @@ func (s *storedObject) isMoreRecentThan(storedObject2 storedObject) bool {
 	return s.lastModifiedTime.After(storedObject2.lastModifiedTime)
 }
 
+// hasSameContentHeadersAs reports whether the content headers, which a transfer copies along with the content, are the same on both objects
+func (s *storedObject) hasSameContentHeadersAs(storedObject2 storedObject) bool {
+	return s.contentType == storedObject2.contentType &&
+		s.contentEncoding == storedObject2.contentEncoding &&
+		s.contentDisposition == storedObject2.contentDisposition &&
+		s.contentLanguage == storedObject2.contentLanguage &&
+		s.cacheControl == storedObject2.cacheControl
+}
+
+// hasSameTierAs reports whether the objects have the same access tier. Tiers that are unknown on either side are taken to be the same
+func (s *storedObject) hasSameTierAs(storedObject2 storedObject) bool {
+	return s.blobAccessTier == azblob.AccessTierNone || storedObject2.blobAccessTier == azblob.AccessTierNone ||
+		s.blobAccessTier == storedObject2.blobAccessTier
+}
+
+// sameStringMaps compares two maps, treating nil and empty ones alike. Metadata keys are case-insensitive, tag keys are not.
+func sameStringMaps(a, b map[string]string, caseInsensitiveKeys bool) bool {
+	if caseInsensitiveKeys {
+		a, b = lowerCaseKeys(a), lowerCaseKeys(b)
+	}
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if v2, ok := b[k]; !ok || v2 != v {
+			return false
+		}
+	}
+	return true
+}
+
+func lowerCaseKeys(m map[string]string) map[string]string {
+	lowered := make(map[string]string, len(m))
+	for k, v := range m {
+		lowered[strings.ToLower(k)] = v
+	}
+	return lowered
+}
+
+// addressableRelativePath returns the relative path by which the object can be found where it was enumerated.
+// That's relativePath, unless names are being normalized
+func (s *storedObject) addressableRelativePath() string {
+	if s.unnormalizedRelativePath != "" {
+		return s.unnormalizedRelativePath
+	}
+	return s.relativePath
+}
+
 func (s *storedObject) isSingleSourceFile() bool {
 	return s.relativePath == "" && s.entityType == common.EEntityType.File()
 }
@@ -166,6 +219,7 @@ func (s *storedObject) ToNewCopyTransfer(
 		Metadata:           s.Metadata,
 		BlobType:           s.blobType,
 		BlobVersionID:      s.blobVersionID,
+		BlobTags:           s.blobTags,
 		// set this below, conditionally: BlobTier
 	}
 
@@ -481,6 +535,17 @@ func initResourceTraverser(resource common.ResourceString, location common.Locat
 				return nil, err
 			}
 		}
+	case common.ELocation.Http():
+		resourceURL, err := resource.FullURL()
+		if err != nil {
+			return nil, err
+		}
+
+		if ctx == nil {
+			return nil, errors.New("a valid context must be supplied to create an HTTP traverser")
+		}
+
+		output = newHTTPTraverser(resourceURL, *ctx, incrementEnumerationCounter)
 	default:
 		return nil, errors.New("could not choose a traverser from currently available traversers")
 	}
@@ -627,7 +692,7 @@ func WarnStdoutAndJobLog(toLog string) {
 
 func (e *copyEnumerator) enumerate() (err error) {
 	err = e.traverser.traverse(noPreProccessor, e.objectDispatcher, e.filters)
-	if err != nil {
+	if err != nil && err != enumerationLimitReached {
 		return
 	}
 
@@ -676,6 +741,11 @@ func getProcessingError(errin error) (ignored bool, err error) {
 	return false, err
 }
 
+// This error should also be treated as a flag: the processor has seen all the objects it wants. The traversers that pass
+// processor errors back to their caller stop early, and it must be checked for there. The rest carry on listing, and the
+// processor ignores whatever else they find.
+var enumerationLimitReached = errors.New("EnumerationLimitReached")
+
 func processIfPassedFilters(filters []objectFilter, storedObject storedObject, processor objectProcessor) (err error) {
 	if passedFilters(filters, storedObject) {
 		err = processor(storedObject)
@@ -22,13 +22,17 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"path"
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 
 	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
 )
 
 // Design explanation:
@@ -58,6 +62,205 @@ func (f *excludeBlobTypeFilter) doesPass(object storedObject) bool {
 	return false
 }
 
+// accessTierFilter selects blobs by the access tier that List Blobs returns for them.
+// E.g. leaving out Archive blobs avoids failing (or, with rehydration, paying for) each one of them.
+// The tiers are keyed in lower case, so that they match regardless of how the user typed them
+type accessTierFilter struct {
+	tiers   map[string]bool
+	include bool // if true, only blobs in one of the tiers pass. Otherwise, only those that aren't in any of them
+}
+
+func newAccessTierFilter(tiers []azblob.AccessTierType, include bool) *accessTierFilter {
+	tierSet := make(map[string]bool, len(tiers))
+	for _, tier := range tiers {
+		tierSet[strings.ToLower(string(tier))] = true
+	}
+
+	return &accessTierFilter{tiers: tierSet, include: include}
+}
+
+func (f *accessTierFilter) doesSupportThisOS() (msg string, supported bool) {
+	return "", true
+}
+
+func (f *accessTierFilter) appliesOnlyToFiles() bool {
+	return true // there aren't any (real) folders in Blob Storage
+}
+
+func (f *accessTierFilter) doesPass(object storedObject) bool {
+	// a blob without a tier (e.g. a page blob in a standard account) is in none of them
+	inTiers := object.blobAccessTier != azblob.AccessTierNone && f.tiers[strings.ToLower(string(object.blobAccessTier))]
+	return inTiers == f.include
+}
+
+// contentTypeFilter only lets through files whose content type matches one of its patterns (e.g. image/*), and logs the
+// type of each one that it leaves out. Blobs are matched by the Content-Type they're stored with. Local files are matched by
+// the content type that uploading them gives them, which is guessed from the extension or, failing that, the file's first bytes
+type contentTypeFilter struct {
+	patterns  []string // in lower case
+	localRoot string   // the source, when it's local, so that files can be read. Empty otherwise
+}
+
+func (f *contentTypeFilter) doesSupportThisOS() (msg string, supported bool) {
+	return "", true
+}
+
+func (f *contentTypeFilter) appliesOnlyToFiles() bool {
+	return true // folders have no content type
+}
+
+func (f *contentTypeFilter) doesPass(object storedObject) bool {
+	contentType := object.contentType
+	if f.localRoot != "" {
+		contentType = inferLocalContentType(common.GenerateFullPath(f.localRoot, object.relativePath))
+	}
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0])) // parameters, like charset, don't matter
+
+	for _, pattern := range f.patterns {
+		if matched, _ := path.Match(pattern, contentType); matched {
+			return true
+		}
+	}
+
+	if ste.JobsAdmin != nil {
+		name := object.relativePath
+		if name == "" {
+			name = object.name
+		}
+		if contentType == "" {
+			contentType = "none"
+		}
+		ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Skipping %s: its content type (%s) is not one of those allowed by include-content-type", name, contentType), pipeline.LogInfo)
+	}
+	return false
+}
+
+// inferLocalContentType returns the content type that the file would be uploaded with, reading its start only when the extension isn't enough
+func inferLocalContentType(fullPath string) string {
+	if contentType := ste.InferContentType(fullPath, nil); contentType != "text/plain" {
+		return contentType // from the extension, since sniffing no content at all says it's text
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	start := make([]byte, 512)
+	n, err := io.ReadFull(file, start)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return ""
+	}
+	return ste.InferContentType(fullPath, start[:n])
+}
+
+// parseContentTypePatterns parses a list of content types, separated by ',' or ';', in which * matches any type or subtype (e.g. image/*)
+func parseContentTypePatterns(raw string) ([]string, error) {
+	patterns := make([]string, 0)
+	for _, pattern := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == ',' }) {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil || strings.Count(pattern, "/") != 1 {
+			return nil, fmt.Errorf("error parsing the content type %s provided with include-content-type flag: it must be of the form type/subtype, e.g. image/png or image/*", pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// excludeEmptyFilter leaves out files that have no content.
+// An unknown size (as from some HTTP sources) is negative, so those aren't excluded
+type excludeEmptyFilter struct{}
+
+func (f *excludeEmptyFilter) doesSupportThisOS() (msg string, supported bool) {
+	return "", true
+}
+
+func (f *excludeEmptyFilter) appliesOnlyToFiles() bool {
+	return true // folders have no content of their own
+}
+
+func (f *excludeEmptyFilter) doesPass(object storedObject) bool {
+	return object.size != 0
+}
+
+// sizeLimitFilter leaves out files that are too big for the destination to hold, warning about each one,
+// so that they aren't scheduled only to fail once their transfer is under way
+type sizeLimitFilter struct {
+	destination common.Location
+	blobType    common.BlobType // as requested with blob-type, so may be Detect
+	blockSize   int64           // as requested with block-size-mb, so 0 unless the user chose it
+	maxBlocks   uint16          // as requested with max-blocks, so 0 unless the user chose it
+}
+
+func (f *sizeLimitFilter) doesSupportThisOS() (msg string, supported bool) {
+	return "", true
+}
+
+func (f *sizeLimitFilter) appliesOnlyToFiles() bool {
+	// folders always pass, so it's safe to apply it to them. Claiming otherwise would stop folder properties being transferred
+	// by every job that has this filter, which is every one that goes to Blob storage or Azure Files
+	return false
+}
+
+func (f *sizeLimitFilter) doesPass(object storedObject) bool {
+	if object.entityType != common.EEntityType.File() {
+		return true // folders have no size of their own
+	}
+
+	limit, kind, suggestion := f.limitFor(object)
+	if limit == 0 || object.size <= limit {
+		return true // an unknown size is negative, so it always passes
+	}
+
+	name := object.relativePath
+	if name == "" {
+		name = object.name
+	}
+	msg := fmt.Sprintf("Skipping %s: its size of %s exceeds the maximum of %s for %s", name, byteSizeToString(object.size), byteSizeToString(limit), kind)
+	if suggestion != "" {
+		msg += ". " + suggestion
+	}
+	WarnStdoutAndJobLog(msg)
+	return false
+}
+
+// limitFor returns the largest size the destination can hold for the given object, with a description of the kind of destination
+// and, when there is another way the object would fit, a suggestion to use it. A limit of 0 means no limit is checked.
+func (f *sizeLimitFilter) limitFor(object storedObject) (limit int64, kind string, suggestion string) {
+	switch f.destination {
+	case common.ELocation.File():
+		return common.MaxAzureFileSize, "an Azure Files file", ""
+	case common.ELocation.Blob():
+		// the same choice of blob type the engine makes when it is left to detect it
+		blobType := f.blobType.ToAzBlobType()
+		if f.blobType == common.EBlobType.Detect() {
+			blobType = object.blobType
+			if blobType == blobTypeNA {
+				blobType = ste.InferBlobType(object.name, azblob.BlobBlockBlob)
+			}
+		}
+
+		switch blobType {
+		case azblob.BlobPageBlob:
+			return common.MaxPageBlobSize, "a page blob", ""
+		case azblob.BlobAppendBlob:
+			return common.MaxNumberOfBlocksPerBlob * common.MaxAppendBlobBlockSize, "an append blob", "Use a block blob instead, with blob-type BlockBlob"
+		case azblob.BlobBlockBlob:
+			if f.blockSize != 0 {
+				return common.MaxNumberOfBlocksPerBlob * f.blockSize, "a block blob with the chosen block size", "Use a larger block-size-mb"
+			}
+			if f.maxBlocks != 0 {
+				return int64(f.maxBlocks) * common.MaxBlockBlobBlockSize, "a block blob with the chosen max-blocks", "Use a larger max-blocks"
+			}
+			return common.MaxNumberOfBlocksPerBlob * common.MaxBlockBlobBlockSize, "a block blob", ""
+		}
+	}
+	return 0, "", ""
+}
+
 type excludeFilter struct {
 	pattern     string
 	targetsPath bool
@@ -21,8 +21,9 @@ func initPipeline(ctx context.Context, location common.Location, credential comm
 		p, err = createFilePipeline(ctx, credential)
 	case common.ELocation.BlobFS():
 		p, err = createBlobFSPipeline(ctx, credential)
-	case common.ELocation.S3():
-		// Gracefully return because pipelines aren't used for S3
+	case common.ELocation.S3(),
+		common.ELocation.Http():
+		// Gracefully return because pipelines aren't used for S3 or plain HTTP(S) sources
 		return nil, nil
 	default:
 		err = fmt.Errorf("can't produce new pipeline for location %s", location)
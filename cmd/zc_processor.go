@@ -64,7 +64,7 @@ func (s *copyTransferProcessor) scheduleCopyTransfer(storedObject storedObject)
 
 	// Escape paths on destinations where the characters are invalid
 	// And re-encode them where the characters are valid.
-	srcRelativePath := pathEncodeRules(storedObject.relativePath, s.copyJobTemplate.FromTo, true)
+	srcRelativePath := pathEncodeRules(storedObject.addressableRelativePath(), s.copyJobTemplate.FromTo, true)
 	dstRelativePath := pathEncodeRules(storedObject.relativePath, s.copyJobTemplate.FromTo, false)
 
 	copyTransfer, shouldSendToSte := storedObject.ToNewCopyTransfer(
@@ -48,6 +48,16 @@ type blobTraverser struct {
 	// whether to include blobs that have metadata 'hdi_isfolder = true'
 	includeDirectoryStubs bool
 
+	// whether to get the index tags of each blob, which costs an extra permission (and, for a single blob, an extra request)
+	includeTags bool
+
+	// if not nil, the parts of the listing that can't be got, even after retries, are skipped instead of failing the enumeration
+	listingErrors *listingErrorTolerance
+
+	// whether to list the previous versions of each blob too, and the most of each blob's versions to keep, newest first. Zero means all of them
+	includeVersions    bool
+	maxVersionsPerBlob int
+
 	// a generic function to notify that a new stored object has been enumerated
 	incrementEnumerationCounter enumerationCounterFunc
 }
@@ -134,6 +144,16 @@ func (t *blobTraverser) traverse(preprocessor objectMorpher, processor objectPro
 			blobUrlParts.ContainerName,
 		)
 
+		if t.includeTags {
+			tagsURLParts := blobUrlParts
+			tagsURLParts.BlobName = strings.TrimSuffix(tagsURLParts.BlobName, common.AZCOPY_PATH_SEPARATOR_STRING)
+			tags, err := azblob.NewBlobURL(tagsURLParts.URL(), t.p).GetTags(t.ctx, nil, nil, nil, nil, nil)
+			if err != nil {
+				return fmt.Errorf("cannot get the tags of the blob due to reason %s", err)
+			}
+			storedObject.blobTags = blobTagsFromAzBlobTags(tags)
+		}
+
 		if t.incrementEnumerationCounter != nil {
 			t.incrementEnumerationCounter(common.EEntityType.File())
 		}
@@ -178,10 +198,18 @@ func (t *blobTraverser) parallelList(containerURL azblob.ContainerURL, container
 	// This func must be thread safe/goroutine safe
 	enumerateOneDir := func(dir parallel.Directory, enqueueDir func(parallel.Directory), enqueueOutput func(parallel.DirectoryEntry, error)) error {
 		currentDirPath := dir.(string)
+		versions := t.newVersionSelector()
 		for marker := (azblob.Marker{}); marker.NotDone(); {
-			lResp, err := containerURL.ListBlobsHierarchySegment(t.ctx, marker, "/", azblob.ListBlobsSegmentOptions{Prefix: currentDirPath,
-				Details: azblob.BlobListingDetails{Metadata: true}})
+			var lResp *azblob.ListBlobsHierarchySegmentResponse
+			err := t.listingErrors.listPage(t.ctx, func() (err error) {
+				lResp, err = containerURL.ListBlobsHierarchySegment(t.ctx, marker, "/", azblob.ListBlobsSegmentOptions{Prefix: currentDirPath,
+					Details: azblob.BlobListingDetails{Metadata: true, Tags: t.includeTags, Versions: t.includeVersions}})
+				return err
+			})
 			if err != nil {
+				if t.listingErrors.skip(containerName, currentDirPath, marker, err) {
+					break // the rest of this virtual directory is skipped, but the others carry on
+				}
 				return fmt.Errorf("cannot list files due to reason %s", err)
 			}
 
@@ -199,12 +227,20 @@ func (t *blobTraverser) parallelList(containerURL azblob.ContainerURL, container
 					continue
 				}
 
-				storedObject := t.createStoredObjectForBlob(preprocessor, blobInfo, strings.TrimPrefix(blobInfo.Name, searchPrefix), containerName)
-				enqueueOutput(storedObject, nil)
+				for _, chosen := range versions.add(blobInfo) {
+					storedObject := t.createStoredObjectForBlob(preprocessor, chosen, strings.TrimPrefix(chosen.Name, searchPrefix), containerName)
+					enqueueOutput(storedObject, nil)
+				}
 			}
 
 			marker = lResp.NextMarker
 		}
+
+		// the versions of the last blob listed are all in by now
+		for _, chosen := range versions.flush() {
+			storedObject := t.createStoredObjectForBlob(preprocessor, chosen, strings.TrimPrefix(chosen.Name, searchPrefix), containerName)
+			enqueueOutput(storedObject, nil)
+		}
 		return nil
 	}
 
@@ -237,7 +273,7 @@ func (t *blobTraverser) parallelList(containerURL azblob.ContainerURL, container
 
 func (t *blobTraverser) createStoredObjectForBlob(preprocessor objectMorpher, blobInfo azblob.BlobItemInternal, relativePath string, containerName string) storedObject {
 	adapter := blobPropertiesAdapter{blobInfo.Properties}
-	return newStoredObject(
+	object := newStoredObject(
 		preprocessor,
 		getObjectNameOnly(blobInfo.Name),
 		relativePath,
@@ -249,6 +285,31 @@ func (t *blobTraverser) createStoredObjectForBlob(preprocessor objectMorpher, bl
 		common.FromAzBlobMetadataToCommonMetadata(blobInfo.Metadata),
 		containerName,
 	)
+	object.blobTags = blobTagsFromAzBlobTags(blobInfo.BlobTags)
+	if t.includeVersions && !isCurrentBlobVersion(blobInfo) {
+		object.blobVersionID = blobVersionID(blobInfo)
+	}
+	return object
+}
+
+// newVersionSelector returns what chooses the versions of each blob to transfer, or nil if only the blobs themselves are listed
+func (t *blobTraverser) newVersionSelector() *blobVersionSelector {
+	if !t.includeVersions {
+		return nil
+	}
+	return newBlobVersionSelector(t.maxVersionsPerBlob)
+}
+
+// blobTagsFromAzBlobTags converts the tags returned by the service, which are nil unless they were asked for
+func blobTagsFromAzBlobTags(tags *azblob.BlobTags) common.BlobTags {
+	if tags == nil || len(tags.BlobTagSet) == 0 {
+		return nil
+	}
+	blobTags := common.BlobTags{}
+	for _, tag := range tags.BlobTagSet {
+		blobTags[tag.Key] = tag.Value
+	}
+	return blobTags
 }
 
 func (t *blobTraverser) doesBlobRepresentAFolder(metadata azblob.Metadata) bool {
@@ -259,14 +320,33 @@ func (t *blobTraverser) doesBlobRepresentAFolder(metadata azblob.Metadata) bool
 func (t *blobTraverser) serialList(containerURL azblob.ContainerURL, containerName string, searchPrefix string,
 	extraSearchPrefix string, preprocessor objectMorpher, processor objectProcessor, filters []objectFilter) error {
 
+	versions := t.newVersionSelector()
+	processBlob := func(blobInfo azblob.BlobItemInternal, relativePath string) error {
+		storedObject := t.createStoredObjectForBlob(preprocessor, blobInfo, relativePath, containerName)
+		if t.incrementEnumerationCounter != nil {
+			t.incrementEnumerationCounter(common.EEntityType.File())
+		}
+
+		processErr := processIfPassedFilters(filters, storedObject, processor)
+		_, processErr = getProcessingError(processErr)
+		return processErr
+	}
+
 	for marker := (azblob.Marker{}); marker.NotDone(); {
 		// see the TO DO in GetEnumerationPreFilter if/when we make this more directory-aware
 
 		// look for all blobs that start with the prefix
 		// TODO optimize for the case where recursive is off
-		listBlob, err := containerURL.ListBlobsFlatSegment(t.ctx, marker,
-			azblob.ListBlobsSegmentOptions{Prefix: searchPrefix + extraSearchPrefix, Details: azblob.BlobListingDetails{Metadata: true}})
+		var listBlob *azblob.ListBlobsFlatSegmentResponse
+		err := t.listingErrors.listPage(t.ctx, func() (err error) {
+			listBlob, err = containerURL.ListBlobsFlatSegment(t.ctx, marker,
+				azblob.ListBlobsSegmentOptions{Prefix: searchPrefix + extraSearchPrefix, Details: azblob.BlobListingDetails{Metadata: true, Tags: t.includeTags, Versions: t.includeVersions}})
+			return err
+		})
 		if err != nil {
+			if t.listingErrors.skip(containerName, searchPrefix+extraSearchPrefix, marker, err) {
+				break // the rest of the listing is skipped, since where it carries on from is only known from the failed page
+			}
 			return fmt.Errorf("cannot list blobs. Failed with error %s", err.Error())
 		}
 
@@ -283,21 +363,23 @@ func (t *blobTraverser) serialList(containerURL azblob.ContainerURL, containerNa
 				continue
 			}
 
-			storedObject := t.createStoredObjectForBlob(preprocessor, blobInfo, relativePath, containerName)
-			if t.incrementEnumerationCounter != nil {
-				t.incrementEnumerationCounter(common.EEntityType.File())
-			}
-
-			processErr := processIfPassedFilters(filters, storedObject, processor)
-			_, processErr = getProcessingError(processErr)
-			if processErr != nil {
-				return processErr
+			for _, chosen := range versions.add(blobInfo) {
+				if processErr := processBlob(chosen, strings.TrimPrefix(chosen.Name, searchPrefix)); processErr != nil {
+					return processErr
+				}
 			}
 		}
 
 		marker = listBlob.NextMarker
 	}
 
+	// the versions of the last blob listed are all in by now
+	for _, chosen := range versions.flush() {
+		if processErr := processBlob(chosen, strings.TrimPrefix(chosen.Name, searchPrefix)); processErr != nil {
+			return processErr
+		}
+	}
+
 	return nil
 }
 
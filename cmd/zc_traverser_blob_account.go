@@ -38,6 +38,13 @@ type blobAccountTraverser struct {
 	cachedContainers      []string
 	includeDirectoryStubs bool
 
+	// if not nil, the parts of the listing of each container that can't be got are skipped instead of failing its enumeration
+	listingErrors *listingErrorTolerance
+
+	// whether to list the previous versions of each blob too, and the most of each blob's versions to keep
+	includeVersions    bool
+	maxVersionsPerBlob int
+
 	// a generic function to notify that a new stored object has been enumerated
 	incrementEnumerationCounter enumerationCounterFunc
 }
@@ -96,12 +103,17 @@ func (t *blobAccountTraverser) traverse(preprocessor objectMorpher, processor ob
 	for _, v := range cList {
 		containerURL := t.accountURL.NewContainerURL(v).URL()
 		containerTraverser := newBlobTraverser(&containerURL, t.p, t.ctx, true, t.includeDirectoryStubs, t.incrementEnumerationCounter)
+		containerTraverser.listingErrors = t.listingErrors
+		containerTraverser.includeVersions = t.includeVersions
+		containerTraverser.maxVersionsPerBlob = t.maxVersionsPerBlob
 
 		preprocessorForThisChild := preprocessor.FollowedBy(newContainerDecorator(v))
 
 		err = containerTraverser.traverse(preprocessorForThisChild, processor, filters)
 
-		if err != nil {
+		if err == enumerationLimitReached {
+			return err
+		} else if err != nil {
 			WarnStdoutAndJobLog(fmt.Sprintf("failed to list blobs in container %s: %s", v, err))
 			continue
 		}
@@ -111,7 +111,9 @@ func (t *BlobFSAccountTraverser) traverse(preprocessor objectMorpher, processor
 
 		err = fileSystemTraverser.traverse(preprocessorForThisChild, processor, filters)
 
-		if err != nil {
+		if err == enumerationLimitReached {
+			return err
+		} else if err != nil {
 			WarnStdoutAndJobLog(fmt.Sprintf("failed to list files in filesystem %s: %s", v, err))
 			continue
 		}
@@ -99,7 +99,9 @@ func (t *fileAccountTraverser) traverse(preprocessor objectMorpher, processor ob
 
 		err = shareTraverser.traverse(preprocessorForThisChild, processor, filters)
 
-		if err != nil {
+		if err == enumerationLimitReached {
+			return err
+		} else if err != nil {
 			WarnStdoutAndJobLog(fmt.Sprintf("failed to list files in share %s: %s", v, err))
 			continue
 		}
@@ -0,0 +1,91 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// httpTraverser "traverses" a plain HTTP(S) URL, which is always a single object, since web servers can't be listed
+type httpTraverser struct {
+	rawURL *url.URL
+	ctx    context.Context
+	client *http.Client
+
+	// A generic function to notify that a new stored object has been enumerated
+	incrementEnumerationCounter enumerationCounterFunc
+}
+
+func newHTTPTraverser(rawURL *url.URL, ctx context.Context, incrementEnumerationCounter enumerationCounterFunc) *httpTraverser {
+	return &httpTraverser{
+		rawURL:                      rawURL,
+		ctx:                         ctx,
+		client:                      ste.NewAzcopyHTTPClient(frontEndMaxIdleConnectionsPerHost),
+		incrementEnumerationCounter: incrementEnumerationCounter,
+	}
+}
+
+func (t *httpTraverser) isDirectory(bool) bool {
+	return false
+}
+
+func (t *httpTraverser) traverse(preprocessor objectMorpher, processor objectProcessor, filters []objectFilter) error {
+	info, err := common.ProbeHTTPSource(t.ctx, t.client, *t.rawURL)
+	if err != nil {
+		return fmt.Errorf("cannot get the properties of %s: %w", common.URLExtension{URL: *t.rawURL}.RedactSecretQueryParamForLogging(), err)
+	}
+
+	if t.incrementEnumerationCounter != nil {
+		t.incrementEnumerationCounter(common.EEntityType.File())
+	}
+
+	err = processIfPassedFilters(filters, newStoredObject(
+		preprocessor,
+		httpSourceName(t.rawURL),
+		"",
+		common.EEntityType.File(),
+		info.LastModified,
+		info.ContentLength,
+		info,
+		noBlobProps,
+		noMetdata,
+		""), processor)
+	_, err = getProcessingError(err)
+	return err
+}
+
+// httpSourceName is the name given to the content of an HTTP(S) URL when the destination is a folder.
+// It's taken from the URL as given, not from wherever that redirects, since redirect targets are often opaque.
+// URLs without a file name, e.g. a site's root, are named after the host.
+func httpSourceName(u *url.URL) string {
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return u.Hostname()
+	}
+	return name
+}
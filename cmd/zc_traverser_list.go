@@ -79,7 +79,9 @@ func (l *listTraverser) traverse(preprocessor objectMorpher, processor objectPro
 		preProcessorForThisChild := preprocessor.FollowedBy(childPreProcessor)
 
 		err = childTraverser.traverse(preProcessorForThisChild, processor, filters)
-		if err != nil {
+		if err == enumerationLimitReached {
+			return err
+		} else if err != nil {
 			glcm.Info(fmt.Sprintf("Skipping %s as it cannot be scanned due to error: %s", childPath, err))
 		}
 	}
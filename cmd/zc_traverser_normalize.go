@@ -0,0 +1,92 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// unicodeNormalizingTraverser wraps another traverser, converting the names of the objects it finds to one Unicode
+// normalization form. That way, names that differ only in their encoding (e.g. macOS's decomposed NFD names vs the
+// precomposed NFC names written by most other systems) compare as equal in sync, and are written consistently to the destination.
+// The name as originally found is kept in unnormalizedRelativePath, since that's what the object must be read or deleted by.
+type unicodeNormalizingTraverser struct {
+	inner resourceTraverser
+	form  norm.Form
+}
+
+// newUnicodeNormalizingTraverser returns t itself if no normalization is wanted
+func newUnicodeNormalizingTraverser(t resourceTraverser, normalization common.UnicodeNormalization) resourceTraverser {
+	switch normalization {
+	case common.EUnicodeNormalization.NFC():
+		return &unicodeNormalizingTraverser{inner: t, form: norm.NFC}
+	case common.EUnicodeNormalization.NFD():
+		return &unicodeNormalizingTraverser{inner: t, form: norm.NFD}
+	default:
+		return t
+	}
+}
+
+func (t *unicodeNormalizingTraverser) isDirectory(isSource bool) bool {
+	return t.inner.isDirectory(isSource)
+}
+
+// Normalization is applied in the processor, rather than the preprocessor, so that it happens after filtering (filters
+// must see real names, e.g. to look up file attributes) and after any other morphing of the relative path.
+// Two objects whose names differ only in their encoding would end up with the same name, and one would replace the other,
+// so the traversal fails if it finds any. That means keeping every normalized name until the traversal is done.
+func (t *unicodeNormalizingTraverser) traverse(preprocessor objectMorpher, processor objectProcessor, filters []objectFilter) error {
+	originals := make(map[string]string) // the name each normalized name was found with
+	var collision error                  // kept, since most traversers don't pass the errors of the processor back
+	err := t.inner.traverse(preprocessor, func(object storedObject) error {
+		if collision != nil {
+			return collision
+		}
+		normalized := t.form.String(object.relativePath)
+		if original, found := originals[normalized]; found && original != object.relativePath {
+			collision = fmt.Errorf("the names '%s' and '%s' are the same once normalized to %s, so they can't both be transferred", original, object.relativePath, t.formName())
+			return collision
+		}
+		originals[normalized] = object.relativePath
+
+		if normalized != object.relativePath {
+			object.unnormalizedRelativePath = object.relativePath
+			object.relativePath = normalized
+		}
+		object.name = t.form.String(object.name)
+		return processor(object)
+	}, filters)
+	if err == nil {
+		err = collision
+	}
+	return err
+}
+
+func (t *unicodeNormalizingTraverser) formName() string {
+	if t.form == norm.NFD {
+		return common.EUnicodeNormalization.NFD().String()
+	}
+	return common.EUnicodeNormalization.NFC().String()
+}
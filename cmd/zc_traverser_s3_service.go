@@ -103,7 +103,9 @@ func (t *s3ServiceTraverser) traverse(preprocessor objectMorpher, processor obje
 
 		err = bucketTraverser.traverse(preprocessorForThisChild, processor, filters)
 
-		if err != nil {
+		if err == enumerationLimitReached {
+			return err
+		} else if err != nil {
 			if strings.Contains(err.Error(), "301 response missing Location header") {
 				WarnStdoutAndJobLog(fmt.Sprintf("skip enumerating the bucket %q , as it's not in the region specified by source URL", v))
 				continue
@@ -0,0 +1,281 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+// shardedTraverser splits the traversal of a directory among a number of shards that run in parallel.
+// The directory's top-level subdirectories are dealt out to the shards, and each shard traverses its own subdirectories,
+// one after another, while the objects directly under the directory are listed alongside them.
+// Everything found is passed to the one processor, so all the shards add their transfers to the same job.
+// That way they share its job ID, its concurrency and its summary, and it can be resumed like any other job.
+type shardedTraverser struct {
+	ctx        context.Context
+	shardCount int
+
+	// top lists the objects directly under the directory, without recursing
+	top resourceTraverser
+	// dirs are the names of the directory's top-level subdirectories
+	dirs []string
+	// newShard returns a recursive traverser for the subdirectory with the given name
+	newShard func(ctx context.Context, dir string) (resourceTraverser, error)
+	// rootFolder returns the directory itself, for sources that have real folders. It's nil for the others.
+	rootFolder func(preprocessor objectMorpher) (storedObject, error)
+}
+
+func newShardedTraverser(ctx context.Context, source common.ResourceString, location common.Location, credential *common.CredentialInfo,
+	followSymlinks, getProperties, includeDirectoryStubs bool, shardCount int, incrementEnumerationCounter enumerationCounterFunc) (*shardedTraverser, error) {
+
+	t := &shardedTraverser{ctx: ctx, shardCount: shardCount}
+
+	var err error
+	t.top, err = initResourceTraverser(source, location, &ctx, credential, &followSymlinks, nil, false, getProperties, includeDirectoryStubs, incrementEnumerationCounter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var shardResource func(dir string) (common.ResourceString, error)
+	switch location {
+	case common.ELocation.Local():
+		root := cleanLocalPath(source.ValueLocal())
+		if t.dirs, err = listLocalTopLevelDirs(root, followSymlinks); err != nil {
+			return nil, err
+		}
+		shardResource = func(dir string) (common.ResourceString, error) {
+			return source.CloneWithValue(common.GenerateFullPath(root, dir)), nil
+		}
+		t.rootFolder = func(preprocessor objectMorpher) (storedObject, error) {
+			info, err := common.OSStat(root)
+			if err != nil {
+				return storedObject{}, err
+			}
+			return newStoredObject(preprocessor, info.Name(), "", common.EEntityType.Folder(), info.ModTime(), info.Size(),
+				noContentProps, noBlobProps, noMetdata, ""), nil
+		}
+	case common.ELocation.Blob():
+		if t.dirs, err = listBlobTopLevelDirs(ctx, source, *credential); err != nil {
+			return nil, err
+		}
+		shardResource = func(dir string) (common.ResourceString, error) {
+			sourceURL, err := url.Parse(source.Value)
+			if err != nil {
+				return common.ResourceString{}, err
+			}
+			parts := azblob.NewBlobURLParts(*sourceURL)
+			parts.BlobName = blobSearchPrefix(parts.BlobName) + dir + common.AZCOPY_PATH_SEPARATOR_STRING
+			shardURL := parts.URL()
+			return source.CloneWithValue(shardURL.String()), nil
+		}
+	default:
+		return nil, fmt.Errorf("shard-by-prefix is not supported for %v sources", location)
+	}
+
+	t.newShard = func(ctx context.Context, dir string) (resourceTraverser, error) {
+		resource, err := shardResource(dir)
+		if err != nil {
+			return nil, err
+		}
+		return initResourceTraverser(resource, location, &ctx, credential, &followSymlinks, nil, true, getProperties, includeDirectoryStubs, incrementEnumerationCounter, nil)
+	}
+
+	return t, nil
+}
+
+func (t *shardedTraverser) isDirectory(isSource bool) bool {
+	return t.top.isDirectory(isSource)
+}
+
+// Filters are applied here, once the relative path has been made relative to the directory being sharded
+// (rather than to the shard), since that is what they expect. Likewise, the processor is only ever called by one shard at a time.
+func (t *shardedTraverser) traverse(preprocessor objectMorpher, processor objectProcessor, filters []objectFilter) error {
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var firstErr error
+	process := func(dir string, found *int) objectProcessor {
+		return func(object storedObject) error {
+			if dir != "" {
+				if object.relativePath == "" {
+					object.name = dir // the shard's root may not know its own name, since it was listed as a root
+				}
+				object.relativePath = joinRelativePath(dir, object.relativePath)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if firstErr != nil {
+				return firstErr // another shard has stopped the traversal
+			}
+
+			*found++
+			// checked here, rather than with getProcessingError, since that drops the errors it doesn't ignore
+			err := processIfPassedFilters(filters, object, processor)
+			if err != nil && err != ignoredError {
+				firstErr = err
+				cancel()
+			}
+			return err
+		}
+	}
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	shardCount := t.shardCount
+	if shardCount > len(t.dirs) {
+		shardCount = len(t.dirs)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < shardCount; i++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			found := 0
+			dirCount := 0
+			for d := shard; d < len(t.dirs) && ctx.Err() == nil; d += shardCount {
+				dir := t.dirs[d]
+				traverser, err := t.newShard(ctx, dir)
+				if err == nil {
+					err = traverser.traverse(preprocessor, process(dir, &found), nil)
+				}
+				if err != nil {
+					fail(err)
+					return
+				}
+				dirCount++
+			}
+			if ste.JobsAdmin != nil {
+				ste.JobsAdmin.LogToJobLog(fmt.Sprintf("Shard %d of %d found %d objects in %d top-level directories", shard+1, shardCount, found, dirCount), pipeline.LogInfo)
+			}
+		}(i)
+	}
+
+	found := 0
+	topProcessor := process("", &found)
+	if t.rootFolder != nil {
+		root, err := t.rootFolder(preprocessor)
+		if err == nil {
+			if err = topProcessor(root); err == ignoredError {
+				err = nil
+			}
+		}
+		if err != nil {
+			fail(err)
+		}
+	}
+	if ctx.Err() == nil {
+		if err := t.top.traverse(preprocessor, topProcessor, nil); err != nil {
+			fail(err)
+		}
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+// joinRelativePath puts the relative path of an object found by a shard under the shard's directory.
+// The shard's own root (e.g. its folder, or its directory stub) has an empty relative path, and becomes the directory itself.
+func joinRelativePath(dir, relativePath string) string {
+	if relativePath == "" {
+		return dir
+	}
+	return dir + common.AZCOPY_PATH_SEPARATOR_STRING + relativePath
+}
+
+// blobSearchPrefix returns the prefix that the blobs under the given virtual directory share
+func blobSearchPrefix(blobName string) string {
+	if blobName != "" && !strings.HasSuffix(blobName, common.AZCOPY_PATH_SEPARATOR_STRING) {
+		blobName += common.AZCOPY_PATH_SEPARATOR_STRING
+	}
+	return blobName
+}
+
+func listLocalTopLevelDirs(root string, followSymlinks bool) ([]string, error) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, 0)
+	for _, entry := range entries {
+		if entry.Mode()&os.ModeSymlink != 0 {
+			if !followSymlinks {
+				continue
+			}
+			if entry, err = common.OSStat(common.GenerateFullPath(root, entry.Name())); err != nil {
+				continue // the top-level traversal reports broken links, so there's no need to do so here as well
+			}
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	return dirs, nil
+}
+
+func listBlobTopLevelDirs(ctx context.Context, source common.ResourceString, credential common.CredentialInfo) ([]string, error) {
+	sourceURL, err := source.FullURL()
+	if err != nil {
+		return nil, err
+	}
+	p, err := initPipeline(ctx, common.ELocation.Blob(), credential)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := azblob.NewBlobURLParts(*sourceURL)
+	searchPrefix := blobSearchPrefix(parts.BlobName)
+	containerURL := azblob.NewContainerURL(copyHandlerUtil{}.getContainerUrl(parts), p)
+
+	dirs := make([]string, 0)
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		resp, err := containerURL.ListBlobsHierarchySegment(ctx, marker, common.AZCOPY_PATH_SEPARATOR_STRING, azblob.ListBlobsSegmentOptions{Prefix: searchPrefix})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the top-level directories due to reason %s", err)
+		}
+		for _, prefix := range resp.Segment.BlobPrefixes {
+			dirs = append(dirs, strings.TrimSuffix(strings.TrimPrefix(prefix.Name, searchPrefix), common.AZCOPY_PATH_SEPARATOR_STRING))
+		}
+		marker = resp.NextMarker
+	}
+	return dirs, nil
+}
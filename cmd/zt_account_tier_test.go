@@ -0,0 +1,74 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type accountTierSuite struct{}
+
+var _ = chk.Suite(&accountTierSuite{})
+
+func (s *accountTierSuite) TestTierCapsLeaveHeadroom(c *chk.C) {
+	requestsPerSecond, mbps, err := resolveAccountTierCaps("Standard", 0, 0)
+	c.Assert(err, chk.IsNil)
+	c.Assert(requestsPerSecond, chk.Equals, int64(16000))
+	c.Assert(mbps, chk.Equals, float64(20000))
+
+	requestsPerSecond, _, err = resolveAccountTierCaps("premium", 0, 0)
+	c.Assert(err, chk.IsNil)
+	c.Assert(requestsPerSecond, chk.Equals, int64(80000))
+}
+
+func (s *accountTierSuite) TestExplicitCapsOverrideTier(c *chk.C) {
+	requestsPerSecond, mbps, err := resolveAccountTierCaps("standard", 500, 0)
+	c.Assert(err, chk.IsNil)
+	c.Assert(requestsPerSecond, chk.Equals, int64(500))
+	c.Assert(mbps, chk.Equals, float64(20000))
+
+	requestsPerSecond, mbps, err = resolveAccountTierCaps("standard", 0, 100)
+	c.Assert(err, chk.IsNil)
+	c.Assert(requestsPerSecond, chk.Equals, int64(16000))
+	c.Assert(mbps, chk.Equals, float64(100))
+
+	// with no tier, only the explicit caps apply
+	requestsPerSecond, mbps, err = resolveAccountTierCaps("", 0, 100)
+	c.Assert(err, chk.IsNil)
+	c.Assert(requestsPerSecond, chk.Equals, int64(0))
+	c.Assert(mbps, chk.Equals, float64(100))
+}
+
+func (s *accountTierSuite) TestUnknownTierIsRejected(c *chk.C) {
+	_, _, err := resolveAccountTierCaps("hot", 0, 0)
+	c.Assert(err, chk.ErrorMatches, ".*invalid account tier \"hot\".*")
+}
+
+func (s *accountTierSuite) TestRampUpNeedsCap(c *chk.C) {
+	c.Assert(validateRampUp(0, 0, 0), chk.IsNil)
+	c.Assert(validateRampUp(time.Minute, 100, 0), chk.IsNil)
+	c.Assert(validateRampUp(time.Minute, 0, 100), chk.IsNil)
+
+	c.Assert(validateRampUp(time.Minute, 0, 0), chk.NotNil)
+	c.Assert(validateRampUp(-time.Minute, 100, 0), chk.NotNil)
+}
@@ -0,0 +1,45 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type appendOnlySuite struct{}
+
+var _ = chk.Suite(&appendOnlySuite{})
+
+func (s *appendOnlySuite) TestValidateSyncAppendOnly(c *chk.C) {
+	c.Assert(validateSyncAppendOnly(false, common.EDeleteDestination.True(), common.ECaseMismatchOption.Rename(), false, false), chk.IsNil)
+	c.Assert(validateSyncAppendOnly(true, common.EDeleteDestination.False(), common.ECaseMismatchOption.Skip(), false, false), chk.IsNil)
+
+	for _, dd := range []common.DeleteDestination{common.EDeleteDestination.True(), common.EDeleteDestination.Prompt(), common.EDeleteDestination.Tombstone()} {
+		c.Assert(validateSyncAppendOnly(true, dd, common.ECaseMismatchOption.None(), false, false), chk.NotNil)
+	}
+	c.Assert(validateSyncAppendOnly(true, common.EDeleteDestination.False(), common.ECaseMismatchOption.Rename(), false, false), chk.NotNil)
+	c.Assert(validateSyncAppendOnly(true, common.EDeleteDestination.False(), common.ECaseMismatchOption.None(), true, false), chk.NotNil)
+	c.Assert(validateSyncAppendOnly(false, common.EDeleteDestination.False(), common.ECaseMismatchOption.None(), true, false), chk.IsNil)
+	c.Assert(validateSyncAppendOnly(true, common.EDeleteDestination.False(), common.ECaseMismatchOption.None(), false, true), chk.ErrorMatches, "mirror cannot be used.*")
+	c.Assert(validateSyncAppendOnly(false, common.EDeleteDestination.False(), common.ECaseMismatchOption.None(), false, true), chk.IsNil)
+}
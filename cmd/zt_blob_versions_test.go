@@ -0,0 +1,114 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type blobVersionsSuite struct{}
+
+var _ = chk.Suite(&blobVersionsSuite{})
+
+func blobVersionItem(name string, versionID string, current bool) azblob.BlobItemInternal {
+	return azblob.BlobItemInternal{Name: name, VersionID: &versionID, IsCurrentVersion: &current}
+}
+
+func versionIDsOf(items []azblob.BlobItemInternal) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Name+"@"+blobVersionID(item))
+	}
+	return ids
+}
+
+func (s *blobVersionsSuite) TestNewestBlobVersions(c *chk.C) {
+	// as listed, oldest first
+	versions := []azblob.BlobItemInternal{
+		blobVersionItem("a", "2021-01-01T00:00:00.0000000Z", false),
+		blobVersionItem("a", "2021-02-01T00:00:00.0000000Z", false),
+		blobVersionItem("a", "2021-03-01T00:00:00.0000000Z", false),
+		blobVersionItem("a", "2021-04-01T00:00:00.0000000Z", true),
+	}
+
+	c.Assert(versionIDsOf(newestBlobVersions(versions, 2)), chk.DeepEquals,
+		[]string{"a@2021-04-01T00:00:00.0000000Z", "a@2021-03-01T00:00:00.0000000Z"})
+	c.Assert(newestBlobVersions(versions, 0), chk.HasLen, 4)
+	c.Assert(newestBlobVersions(versions, 10), chk.HasLen, 4)
+
+	// a blob written before versioning was turned on is current, and comes first
+	unversioned := azblob.BlobItemInternal{Name: "a"}
+	chosen := newestBlobVersions(append(versions[:2:2], unversioned), 2)
+	c.Assert(versionIDsOf(chosen), chk.DeepEquals, []string{"a@", "a@2021-02-01T00:00:00.0000000Z"})
+
+	// a deleted blob keeps only its previous versions
+	c.Assert(versionIDsOf(newestBlobVersions(versions[:3], 1)), chk.DeepEquals, []string{"a@2021-03-01T00:00:00.0000000Z"})
+}
+
+func (s *blobVersionsSuite) TestBlobVersionSelectorGroupsByBlob(c *chk.C) {
+	selector := newBlobVersionSelector(1)
+
+	c.Assert(selector.add(blobVersionItem("a", "1", false)), chk.HasLen, 0)
+	c.Assert(selector.add(blobVersionItem("a", "2", true)), chk.HasLen, 0)
+
+	// moving on to the next blob hands on the previous one's chosen versions
+	c.Assert(versionIDsOf(selector.add(blobVersionItem("b", "3", false))), chk.DeepEquals, []string{"a@2"})
+	c.Assert(selector.add(blobVersionItem("b", "4", false)), chk.HasLen, 0)
+	c.Assert(versionIDsOf(selector.flush()), chk.DeepEquals, []string{"b@4"})
+	c.Assert(selector.flush(), chk.HasLen, 0)
+
+	// without versions, everything goes straight through
+	var off *blobVersionSelector
+	c.Assert(off.add(blobVersionItem("a", "1", true)), chk.HasLen, 1)
+	c.Assert(off.flush(), chk.HasLen, 0)
+}
+
+func (s *blobVersionsSuite) TestPreviousVersionsAreNamedForTheirVersion(c *chk.C) {
+	cca := &cookedCopyCmdArgs{
+		source:      common.ResourceString{Value: "https://account.blob.core.windows.net/container/dir"},
+		destination: common.ResourceString{Value: "/tmp/out"},
+		fromTo:      common.EFromTo.BlobLocal(),
+		stripTopDir: true,
+	}
+	object := storedObject{name: "file.txt", relativePath: "sub/file.txt", blobVersionID: "2021-01-01T00:00:00.0000000Z"}
+
+	c.Assert(cca.makeEscapedRelativePath(false, true, object), chk.Equals, "/sub/2021-01-01T00-00-00.0000000Z-file.txt")
+	// the version is still found by its own name at the source
+	c.Assert(cca.makeEscapedRelativePath(true, true, object), chk.Equals, "/sub/file.txt")
+
+	object.blobVersionID = ""
+	c.Assert(cca.makeEscapedRelativePath(false, true, object), chk.Equals, "/sub/file.txt")
+}
+
+func (s *blobVersionsSuite) TestValidateIncludeVersions(c *chk.C) {
+	c.Assert(validateIncludeVersions(false, 0, common.EFromTo.LocalBlob(), false, 0, ""), chk.IsNil)
+	c.Assert(validateIncludeVersions(true, 3, common.EFromTo.BlobBlob(), false, 0, ""), chk.IsNil)
+	c.Assert(validateIncludeVersions(true, 0, common.EFromTo.BlobLocal(), false, 0, ""), chk.IsNil)
+
+	c.Assert(validateIncludeVersions(false, 3, common.EFromTo.BlobBlob(), false, 0, ""), chk.NotNil)
+	c.Assert(validateIncludeVersions(true, -1, common.EFromTo.BlobBlob(), false, 0, ""), chk.NotNil)
+	c.Assert(validateIncludeVersions(true, 0, common.EFromTo.FileLocal(), false, 0, ""), chk.NotNil)
+	c.Assert(validateIncludeVersions(true, 0, common.EFromTo.BlobBlob(), true, 0, ""), chk.NotNil)
+	c.Assert(validateIncludeVersions(true, 0, common.EFromTo.BlobBlob(), false, 2, ""), chk.NotNil)
+	c.Assert(validateIncludeVersions(true, 0, common.EFromTo.BlobBlob(), false, 0, "{name}"), chk.NotNil)
+}
@@ -0,0 +1,83 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+type blockBlobTierSuite struct{}
+
+var _ = chk.Suite(&blockBlobTierSuite{})
+
+func (s *blockBlobTierSuite) TestParseBlockBlobTierName(c *chk.C) {
+	name, err := parseBlockBlobTierName("Cold")
+	c.Assert(err, chk.IsNil)
+	c.Assert(name, chk.Equals, "Cold")
+
+	for _, bad := range []string{"", "Cold Tier", "Hot;Cool", "2Hot", "AVeryLongTierName"} {
+		_, err = parseBlockBlobTierName(bad)
+		c.Assert(err, chk.NotNil, chk.Commentf(bad))
+	}
+}
+
+func (s *blockBlobTierSuite) TestBlockBlobAccessTier(c *chk.C) {
+	cca := cookedCopyCmdArgs{blockBlobTier: common.EBlockBlobTier.None(), pageBlobTier: common.EPageBlobTier.None()}
+	c.Assert(cca.setsBlobTier(), chk.Equals, false)
+	c.Assert(cca.blockBlobAccessTier(), chk.Equals, azblob.AccessTierNone)
+
+	cca.blockBlobTier = common.EBlockBlobTier.Cool()
+	c.Assert(cca.setsBlobTier(), chk.Equals, true)
+	c.Assert(cca.blockBlobAccessTier(), chk.Equals, azblob.AccessTierCool)
+
+	// a tier that AzCopy doesn't know of is passed on as it is
+	cca.blockBlobTier = common.EBlockBlobTier.None()
+	cca.blockBlobTierName = "Cold"
+	c.Assert(cca.setsBlobTier(), chk.Equals, true)
+	c.Assert(cca.blockBlobAccessTier(), chk.Equals, azblob.AccessTierType("Cold"))
+}
+
+func (s *blockBlobTierSuite) TestValidateTierByAccessTime(c *chk.C) {
+	rules, err := ste.ParseAccessTimeTiers("90d=Archive,30d=Cool")
+	c.Assert(err, chk.IsNil)
+	cooked := cookedCopyCmdArgs{fromTo: common.EFromTo.BlobBlob(), tierByAccessTime: rules}
+	c.Assert(validateTierByAccessTime(cooked), chk.IsNil)
+
+	cooked.fromTo = common.EFromTo.LocalBlob()
+	c.Assert(validateTierByAccessTime(cooked), chk.NotNil)
+
+	cooked.fromTo = common.EFromTo.BlobBlob()
+	cooked.blockBlobTierName = "Cold"
+	c.Assert(validateTierByAccessTime(cooked), chk.NotNil)
+
+	// the rules must fit in the plan
+	cooked.blockBlobTierName = ""
+	for i := 1; i <= 10; i++ {
+		cooked.tierByAccessTime = append(cooked.tierByAccessTime, ste.AccessTimeTier{MinAge: time.Duration(i*1000) * 24 * time.Hour, Tier: azblob.AccessTierCool})
+	}
+	c.Assert(validateTierByAccessTime(cooked), chk.NotNil)
+}
@@ -0,0 +1,45 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+	chk "gopkg.in/check.v1"
+)
+
+type blockIDPrefixSuite struct{}
+
+var _ = chk.Suite(&blockIDPrefixSuite{})
+
+func (s *blockIDPrefixSuite) TestValidateBlockIDPrefix(c *chk.C) {
+	// no prefix is always fine
+	c.Assert(validateBlockIDPrefix("", common.EFromTo.LocalFile()), chk.IsNil)
+
+	c.Assert(validateBlockIDPrefix("tool-v2_x.", common.EFromTo.LocalBlob()), chk.IsNil)
+	c.Assert(validateBlockIDPrefix(strings.Repeat("a", ste.BlockIDPrefixMaxBytes), common.EFromTo.BlobBlob()), chk.IsNil)
+
+	c.Assert(validateBlockIDPrefix("tool", common.EFromTo.LocalFile()), chk.ErrorMatches, ".*only supported when transferring to Blob storage.*")
+	c.Assert(validateBlockIDPrefix(strings.Repeat("a", ste.BlockIDPrefixMaxBytes+1), common.EFromTo.LocalBlob()), chk.ErrorMatches, ".*longer than.*")
+	c.Assert(validateBlockIDPrefix("my tool", common.EFromTo.LocalBlob()), chk.ErrorMatches, ".*only letters, digits.*")
+	c.Assert(validateBlockIDPrefix("tool/", common.EFromTo.LocalBlob()), chk.ErrorMatches, ".*only letters, digits.*")
+}
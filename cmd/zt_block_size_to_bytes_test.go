@@ -22,6 +22,8 @@ package cmd
 
 import (
 	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
 )
 
 type blockSizeFilterSuite struct{}
@@ -52,3 +54,71 @@ func (s *genericFilterSuite) TestConversions(c *chk.C) {
 		}
 	}
 }
+
+func (s *blockSizeFilterSuite) TestValidateMaxBlocks(c *chk.C) {
+	block := common.EBlobType.BlockBlob()
+
+	maxBlocks, err := validateMaxBlocks(0, 4*1024*1024, common.EBlobType.PageBlob(), common.EFromTo.LocalFile())
+	c.Assert(err, chk.IsNil)
+	c.Assert(maxBlocks, chk.Equals, uint16(0))
+
+	maxBlocks, err = validateMaxBlocks(common.MaxNumberOfBlocksPerBlob, 0, block, common.EFromTo.LocalBlob())
+	c.Assert(err, chk.IsNil)
+	c.Assert(maxBlocks, chk.Equals, uint16(common.MaxNumberOfBlocksPerBlob))
+
+	maxBlocks, err = validateMaxBlocks(100, 0, common.EBlobType.Detect(), common.EFromTo.BlobBlob())
+	c.Assert(err, chk.IsNil)
+	c.Assert(maxBlocks, chk.Equals, uint16(100))
+
+	_, err = validateMaxBlocks(-1, 0, block, common.EFromTo.LocalBlob())
+	c.Assert(err, chk.NotNil)
+	_, err = validateMaxBlocks(common.MaxNumberOfBlocksPerBlob+1, 0, block, common.EFromTo.LocalBlob())
+	c.Assert(err, chk.NotNil)
+	_, err = validateMaxBlocks(100, 8*1024*1024, block, common.EFromTo.LocalBlob())
+	c.Assert(err, chk.NotNil)
+	_, err = validateMaxBlocks(100, 0, block, common.EFromTo.LocalFile())
+	c.Assert(err, chk.NotNil)
+	_, err = validateMaxBlocks(100, 0, common.EBlobType.AppendBlob(), common.EFromTo.LocalBlob())
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *blockSizeFilterSuite) TestValidateBlockSizes(c *chk.C) {
+	const mib = 1024 * 1024
+	block := common.EBlobType.Detect()
+	prefer := common.ES2SPreference.Prefer()
+
+	// each kind of copy uses its own size, and server-side copies fall back to block-size-mb
+	size, err := validateBlockSizes(16*mib, 64*mib, block, common.EFromTo.BlobBlob(), prefer)
+	c.Assert(err, chk.IsNil)
+	c.Assert(size, chk.Equals, int64(64*mib))
+	size, err = validateBlockSizes(16*mib, 0, block, common.EFromTo.BlobBlob(), prefer)
+	c.Assert(err, chk.IsNil)
+	c.Assert(size, chk.Equals, int64(16*mib))
+	size, err = validateBlockSizes(16*mib, 0, block, common.EFromTo.LocalBlob(), prefer)
+	c.Assert(err, chk.IsNil)
+	c.Assert(size, chk.Equals, int64(16*mib))
+
+	// s2s-block-size-mb is only for server-side copies, and other jobs ignore it
+	size, err = validateBlockSizes(16*mib, 64*mib, block, common.EFromTo.LocalBlob(), prefer)
+	c.Assert(err, chk.IsNil)
+	c.Assert(size, chk.Equals, int64(16*mib))
+	size, err = validateBlockSizes(16*mib, common.MaxPutBlockFromURLBlockSize+1, block, common.EFromTo.BlobBlob(), common.ES2SPreference.Never())
+	c.Assert(err, chk.IsNil)
+	c.Assert(size, chk.Equals, int64(16*mib))
+
+	// both are checked against the service's limits
+	_, err = validateBlockSizes(common.MaxBlockBlobBlockSize+1, 0, block, common.EFromTo.LocalBlob(), prefer)
+	c.Assert(err, chk.NotNil)
+	_, err = validateBlockSizes(0, common.MaxPutBlockFromURLBlockSize+1, block, common.EFromTo.BlobBlob(), prefer)
+	c.Assert(err, chk.NotNil)
+
+	// and block-size-mb is checked against the limit of Put Block From URL when a server-side copy uses it
+	_, err = validateBlockSizes(common.MaxPutBlockFromURLBlockSize+1, 0, block, common.EFromTo.BlobBlob(), prefer)
+	c.Assert(err, chk.NotNil)
+	_, err = validateBlockSizes(common.MaxPutBlockFromURLBlockSize+1, 0, block, common.EFromTo.BlobBlob(), common.ES2SPreference.Never())
+	c.Assert(err, chk.IsNil)
+	_, err = validateBlockSizes(0, 8*mib, common.EBlobType.AppendBlob(), common.EFromTo.BlobBlob(), prefer)
+	c.Assert(err, chk.NotNil)
+	_, err = validateBlockSizes(8*mib, 0, common.EBlobType.AppendBlob(), common.EFromTo.LocalBlob(), prefer)
+	c.Assert(err, chk.NotNil)
+}
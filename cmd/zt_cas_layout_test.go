@@ -0,0 +1,78 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+type casLayoutSuite struct{}
+
+var _ = chk.Suite(&casLayoutSuite{})
+
+func (s *casLayoutSuite) TestIdenticalContentIsStoredOnce(c *chk.C) {
+	l := newCasLayout("unused")
+	sum := md5.Sum([]byte("same content"))
+	hash := hex.EncodeToString(sum[:])
+
+	dst, shouldDownload := l.place("/a/one.txt", sum[:])
+	c.Assert(shouldDownload, chk.Equals, true)
+	c.Assert(dst, chk.Equals, "/"+hash[:2]+"/"+hash)
+
+	_, shouldDownload = l.place("/b/two.txt", sum[:])
+	c.Assert(shouldDownload, chk.Equals, false)
+
+	_, shouldDownload = l.place("/c/nohash.txt", nil)
+	c.Assert(shouldDownload, chk.Equals, false)
+	c.Assert(l.missingHash, chk.Equals, uint64(1))
+
+	c.Assert(l.manifest, chk.DeepEquals, map[string]string{"a/one.txt": hash, "b/two.txt": hash})
+}
+
+func (s *casLayoutSuite) TestManifestHasOnlyTheFilesInTheStore(c *chk.C) {
+	dir, err := ioutil.TempDir("", "caslayout")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	l := newCasLayout(filepath.Join(dir, "manifest.json"))
+	downloaded, failed := md5.Sum([]byte("downloaded")), md5.Sum([]byte("failed"))
+	l.place("/a/one.txt", downloaded[:])
+	l.place("/b/two.txt", downloaded[:])
+	l.place("/c/three.txt", failed[:])
+
+	// the manifest is only written once the job is done, and the download of three.txt failed
+	_, err = os.Stat(l.manifestPath)
+	c.Assert(os.IsNotExist(err), chk.Equals, true)
+	c.Assert(l.writeManifest(map[string]bool{hex.EncodeToString(downloaded[:]): true}), chk.IsNil)
+
+	data, err := ioutil.ReadFile(l.manifestPath)
+	c.Assert(err, chk.IsNil)
+	var manifest map[string]string
+	c.Assert(json.Unmarshal(data, &manifest), chk.IsNil)
+	c.Assert(manifest, chk.DeepEquals, map[string]string{"a/one.txt": hex.EncodeToString(downloaded[:]), "b/two.txt": hex.EncodeToString(downloaded[:])})
+}
@@ -0,0 +1,61 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type changedSinceJobSuite struct{}
+
+var _ = chk.Suite(&changedSinceJobSuite{})
+
+func (s *changedSinceJobSuite) TestThresholdIsJustBeforeTheCommandStarted(c *chk.C) {
+	commandStart := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	details := common.GetJobDetailsResponse{
+		JobStatus:        common.EJobStatus.CompletedWithSkipped(),
+		StartTime:        commandStart.Add(time.Minute), // when the first part was created, which must not be used
+		CommandStartTime: commandStart,
+	}
+
+	threshold, err := changedSinceJobThreshold(common.NewJobID(), details)
+	c.Assert(err, chk.IsNil)
+	c.Assert(threshold, chk.Equals, commandStart.Add(-5*time.Second))
+}
+
+func (s *changedSinceJobSuite) TestUnsuitableJobsAreRefused(c *chk.C) {
+	commandStart := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	for _, details := range []common.GetJobDetailsResponse{
+		{ErrorMsg: "no job with JobID x exists"},
+		{JobStatus: common.EJobStatus.CompletedWithErrors(), CommandStartTime: commandStart},
+		{JobStatus: common.EJobStatus.Cancelled(), CommandStartTime: commandStart},
+		{JobStatus: common.EJobStatus.InProgress(), CommandStartTime: commandStart},
+		{JobStatus: common.EJobStatus.Completed(), StartTime: commandStart}, // from before the command start time was recorded
+	} {
+		_, err := changedSinceJobThreshold(common.NewJobID(), details)
+		c.Assert(err, chk.NotNil)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type clientSideEncryptionFlagSuite struct{}
+
+var _ = chk.Suite(&clientSideEncryptionFlagSuite{})
+
+func (s *clientSideEncryptionFlagSuite) TestValidateClientSideEncryption(c *chk.C) {
+	dir, err := ioutil.TempDir("", "csekey")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	keyPath := filepath.Join(dir, "mykey")
+	c.Assert(ioutil.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(make([]byte, 32))), 0600), chk.IsNil)
+
+	upload := cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), blobType: common.EBlobType.Detect()}
+	key, err := validateClientSideEncryption("", "", upload)
+	c.Assert(err, chk.IsNil)
+	c.Assert(key.IsSet(), chk.Equals, false)
+
+	key, err = validateClientSideEncryption(keyPath, "", upload)
+	c.Assert(err, chk.IsNil)
+	c.Assert(key.KeyID, chk.Equals, "mykey")
+
+	key, err = validateClientSideEncryption(keyPath, "https://myvault.vault.azure.net/keys/mykey/1", upload)
+	c.Assert(err, chk.IsNil)
+	c.Assert(key.KeyID, chk.Equals, "https://myvault.vault.azure.net/keys/mykey/1")
+
+	_, err = validateClientSideEncryption("", "mykey", upload)
+	c.Assert(err, chk.NotNil)
+
+	_, err = validateClientSideEncryption(keyPath, "", cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal()})
+	c.Assert(err, chk.IsNil)
+
+	_, err = validateClientSideEncryption(keyPath, "", cookedCopyCmdArgs{fromTo: common.EFromTo.LocalFile()})
+	c.Assert(err, chk.NotNil)
+
+	_, err = validateClientSideEncryption(keyPath, "", cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), blobType: common.EBlobType.PageBlob()})
+	c.Assert(err, chk.NotNil)
+
+	_, err = validateClientSideEncryption(keyPath, "", cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), blobType: common.EBlobType.Detect(), putMd5: true})
+	c.Assert(err, chk.NotNil)
+
+	_, err = validateClientSideEncryption("https://myvault.vault.azure.net/keys/mykey", "", upload)
+	c.Assert(err, chk.ErrorMatches, ".*Key Vault.*")
+}
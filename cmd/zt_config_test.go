@@ -0,0 +1,128 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/spf13/cobra"
+	chk "gopkg.in/check.v1"
+)
+
+type configShowSuite struct{}
+
+var _ = chk.Suite(&configShowSuite{})
+
+// findSetting returns the setting with the given name, or a setting with no name if there isn't one
+func findSetting(settings []configSetting, name string) configSetting {
+	for _, s := range settings {
+		if s.Name == name {
+			return s
+		}
+	}
+	return configSetting{}
+}
+
+func (s *configShowSuite) TestResolveConfigurationShowsTheSourceOfEachValue(c *chk.C) {
+	defer func(old endpointOverrides) { azcopyEndpoints = old }(azcopyEndpoints)
+	azcopyEndpoints = endpointOverrides{}
+
+	// a command of its own, so that parsing its flags doesn't affect the real ones
+	cmd := &cobra.Command{Use: "test"}
+	var blockSize float64
+	var logLevel string
+	cmd.Flags().Float64Var(&blockSize, "block-size-mb", 0, "")
+	cmd.Flags().StringVar(&logLevel, "log-level", "INFO", "")
+	c.Assert(cmd.ParseFlags([]string{"/local/dir", "https://acct.blob.core.windows.net/c?sv=x&sig=y", "--block-size-mb=4"}), chk.IsNil)
+
+	settings := resolveConfiguration(cmd, cmd.Flags().Args())
+	c.Assert(findSetting(settings, "Block size"), chk.Equals, configSetting{"Block size", "4 MiB", "flag --block-size-mb"})
+	c.Assert(findSetting(settings, "Log level"), chk.Equals, configSetting{"Log level", "INFO", "default"})
+	c.Assert(findSetting(settings, "Source endpoint").Value, chk.Equals, "local")
+	c.Assert(findSetting(settings, "Destination endpoint"), chk.Equals, configSetting{"Destination endpoint", "https://acct.blob.core.windows.net (Blob)", "argument"})
+	c.Assert(findSetting(settings, "Destination authentication"), chk.Equals, configSetting{"Destination authentication", "SAS token", "argument"})
+
+	// settings that don't apply to the command are left out
+	c.Assert(findSetting(settings, "Bandwidth cap").Name, chk.Equals, "")
+}
+
+func (s *configShowSuite) TestResourceSettingsNameTheEndpointFlag(c *chk.C) {
+	defer func(old endpointOverrides) { azcopyEndpoints = old }(azcopyEndpoints)
+
+	var err error
+	azcopyEndpoints, err = parseEndpointOverrides("core.usgovcloudapi.net", map[string]string{"file": "https://files.contoso.com"})
+	c.Assert(err, chk.IsNil)
+
+	settings := resourceSettings("Source", "https://acct.blob.core.windows.net/c?sig=x")
+	c.Assert(settings[0], chk.Equals, configSetting{"Source endpoint", "https://acct.blob.core.usgovcloudapi.net (Blob)", "flag --endpoint-suffix"})
+
+	settings = resourceSettings("Source", "https://acct.file.core.windows.net/share")
+	c.Assert(settings[0], chk.Equals, configSetting{"Source endpoint", "https://acct.files.contoso.com (File)", "flag --file-endpoint"})
+	c.Assert(settings[1].Value, chk.Equals, "none, since Azure Files needs a SAS token in the URL")
+}
+
+func (s *configShowSuite) TestAutoConcurrencyFlagTurnsOnTuning(c *chk.C) {
+	envVar := common.EEnvironmentVariable.ConcurrencyValue().Name
+	defer os.Setenv(envVar, os.Getenv(envVar))
+	os.Unsetenv(envVar)
+
+	cmd := &cobra.Command{Use: "test"}
+	var autoConcurrency bool
+	cmd.Flags().BoolVar(&autoConcurrency, "auto-concurrency", false, "")
+
+	c.Assert(cmd.ParseFlags([]string{}), chk.IsNil)
+	c.Assert(findSetting(resolveConfiguration(cmd, nil), "Concurrency").Source, chk.Not(chk.Equals), "flag --auto-concurrency")
+
+	c.Assert(cmd.ParseFlags([]string{"--auto-concurrency"}), chk.IsNil)
+	setting := findSetting(resolveConfiguration(cmd, nil), "Concurrency")
+	c.Assert(setting.Source, chk.Equals, "flag --auto-concurrency")
+	c.Assert(setting.Value, chk.Equals, "auto-tuned, from 4 up to 3000")
+}
+
+func (s *configShowSuite) TestAccountTierSetsCapsUnlessOverridden(c *chk.C) {
+	cmd := &cobra.Command{Use: "test"}
+	var capMbps float64
+	var capRequests int64
+	var tier string
+	cmd.Flags().Float64Var(&capMbps, "cap-mbps", 0, "")
+	cmd.Flags().Int64Var(&capRequests, "cap-requests-per-second", 0, "")
+	cmd.Flags().StringVar(&tier, "account-tier", "", "")
+	c.Assert(cmd.ParseFlags([]string{"--account-tier=standard", "--cap-mbps=100"}), chk.IsNil)
+
+	settings := resolveConfiguration(cmd, nil)
+	c.Assert(findSetting(settings, "Bandwidth cap"), chk.Equals, configSetting{"Bandwidth cap", "100 Mbps", "flag --cap-mbps"})
+	c.Assert(findSetting(settings, "Request rate cap"), chk.Equals, configSetting{"Request rate cap", "16000 per second", "flag --account-tier"})
+}
+
+func (s *configShowSuite) TestServerSideBlockSizeFallsBackToBlockSize(c *chk.C) {
+	cmd := &cobra.Command{Use: "test"}
+	var blockSize, s2sBlockSize float64
+	cmd.Flags().Float64Var(&blockSize, "block-size-mb", 0, "")
+	cmd.Flags().Float64Var(&s2sBlockSize, "s2s-block-size-mb", 0, "")
+
+	c.Assert(cmd.ParseFlags([]string{}), chk.IsNil)
+	c.Assert(findSetting(resolveConfiguration(cmd, nil), "Server-side copy block size").Value, chk.Equals, "the same as the block size")
+
+	c.Assert(cmd.ParseFlags([]string{"--s2s-block-size-mb=64"}), chk.IsNil)
+	c.Assert(findSetting(resolveConfiguration(cmd, nil), "Server-side copy block size"), chk.Equals,
+		configSetting{"Server-side copy block size", "64 MiB", "flag --s2s-block-size-mb"})
+}
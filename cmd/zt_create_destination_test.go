@@ -0,0 +1,131 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type createDestinationSuite struct{}
+
+var _ = chk.Suite(&createDestinationSuite{})
+
+func (s *createDestinationSuite) TestParseDestinationPublicAccess(c *chk.C) {
+	for raw, expected := range map[string]azblob.PublicAccessType{
+		"":          azblob.PublicAccessNone,
+		"none":      azblob.PublicAccessNone,
+		"Blob":      azblob.PublicAccessBlob,
+		"container": azblob.PublicAccessContainer,
+	} {
+		access, err := parseDestinationPublicAccess(raw)
+		c.Assert(err, chk.IsNil)
+		c.Assert(access, chk.Equals, expected, chk.Commentf(raw))
+	}
+
+	_, err := parseDestinationPublicAccess("public")
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *createDestinationSuite) TestCreateDestinationOptionsAreValidated(c *chk.C) {
+	raw := getDefaultCopyRawInput(c.MkDir(), "https://acct.blob.core.windows.net/container?sig=x")
+	raw.createDestination = true
+	raw.destinationPublicAccess = "blob"
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.createDestination, chk.Equals, true)
+	c.Assert(cooked.destinationPublicAccess, chk.Equals, azblob.PublicAccessBlob)
+
+	// there is no container to create for a download
+	raw = getDefaultCopyRawInput("https://acct.blob.core.windows.net/container?sig=x", c.MkDir())
+	raw.createDestination = true
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "create-destination is only supported .*")
+
+	// public access is only set on containers that are created, and only Blob storage has it
+	raw = getDefaultCopyRawInput(c.MkDir(), "https://acct.blob.core.windows.net/container?sig=x")
+	raw.destinationPublicAccess = "container"
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "destination-public-access is only supported .*")
+
+	raw = getDefaultCopyRawInput(c.MkDir(), "https://acct.file.core.windows.net/share?sig=x")
+	raw.createDestination = true
+	raw.destinationPublicAccess = "blob"
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "destination-public-access is only supported .*")
+}
+
+func (s *createDestinationSuite) TestMissingContainerIsCreatedWithItsPublicAccess(c *chk.C) {
+	// records the requests made for the container, which doesn't exist yet
+	var requests, publicAccess []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPut {
+			publicAccess = append(publicAccess, r.Header.Get("x-ms-blob-public-access"))
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("x-ms-error-code", string(azblob.ServiceCodeContainerNotFound))
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dst := common.ResourceString{Value: server.URL + "/account/container", SAS: "sig=x"}
+	cca := &cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), destination: dst, createDestination: true,
+		destinationPublicAccess: azblob.PublicAccessBlob}
+	existing := map[string]bool{}
+	c.Assert(cca.createDstContainer("container", dst, context.Background(), existing), chk.IsNil)
+	c.Assert(requests, chk.DeepEquals, []string{"GET /account/container", "PUT /account/container"})
+	c.Assert(publicAccess, chk.DeepEquals, []string{"blob"})
+
+	// it's only created once
+	requests = nil
+	c.Assert(cca.createDstContainer("container", dst, context.Background(), existing), chk.IsNil)
+	c.Assert(requests, chk.HasLen, 0)
+}
+
+// responseError is an error that carries the response it came from, as the storage errors of the SDKs do
+type responseError struct {
+	error
+	response *http.Response
+}
+
+func (e responseError) Response() *http.Response { return e.response }
+
+func (s *createDestinationSuite) TestContainerCreateFailureIsExplained(c *chk.C) {
+	forbidden := responseError{errors.New("AuthorizationPermissionMismatch"), &http.Response{StatusCode: http.StatusForbidden}}
+	err := explainDstContainerCreateFailure("container", forbidden)
+	c.Assert(err, chk.ErrorMatches, "the destination 'container' could not be created, because the credentials .* do not have permission to create it.*AuthorizationPermissionMismatch")
+
+	conflict := responseError{errors.New("ContainerBeingDeleted"), &http.Response{StatusCode: http.StatusConflict}}
+	err = explainDstContainerCreateFailure("container", conflict)
+	c.Assert(err, chk.ErrorMatches, "failed to create the destination 'container': ContainerBeingDeleted")
+
+	err = explainDstContainerCreateFailure("container", errors.New("no such host"))
+	c.Assert(err, chk.ErrorMatches, "failed to create the destination 'container': no such host")
+}
@@ -90,3 +90,12 @@ func (s *credentialUtilSuite) TestCheckAuthSafeForTargetIsCalledWhenGettingAuthT
 	c.Assert(strings.Contains(err.Error(), "If this URL is in fact an Azure service, you can enable Azure authentication to notblob.example.com."),
 		chk.Equals, true)
 }
+
+func (s *credentialUtilSuite) TestTokenSourceMessageNamesTenantAndAuthority(c *chk.C) {
+	c.Assert(loginCmdArgs{}.tokenSourceMessage(), chk.Equals, "Acquiring OAuth token from Azure Active Directory tenant '"+
+		common.DefaultTenantID+"' at authority '"+common.DefaultActiveDirectoryEndpoint+"'.")
+
+	lca := loginCmdArgs{tenantID: "contoso.onmicrosoft.com", aadEndpoint: "https://login.microsoftonline.us"}
+	c.Assert(lca.tokenSourceMessage(), chk.Equals,
+		"Acquiring OAuth token from Azure Active Directory tenant 'contoso.onmicrosoft.com' at authority 'https://login.microsoftonline.us'.")
+}
@@ -0,0 +1,56 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type deadlineSuite struct{}
+
+var _ = chk.Suite(&deadlineSuite{})
+
+func (s *deadlineSuite) TestParseDeadline(c *chk.C) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	deadline, err := parseDeadline("", now)
+	c.Assert(err, chk.IsNil)
+	c.Assert(deadline.IsZero(), chk.Equals, true)
+
+	deadline, err = parseDeadline("2024-06-01T02:00:00Z", now)
+	c.Assert(err, chk.IsNil)
+	c.Assert(deadline.Equal(now.Add(2*time.Hour)), chk.Equals, true)
+
+	_, err = parseDeadline("2024-05-31T23:00:00Z", now)
+	c.Assert(err, chk.ErrorMatches, ".*already passed.*")
+	_, err = parseDeadline("two o'clock", now)
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *deadlineSuite) TestFormatDeadlineStats(c *chk.C) {
+	jobID := common.NewJobID()
+	c.Assert(formatDeadlineStats(common.ListJobSummaryResponse{JobID: jobID}), chk.Equals, "")
+	c.Assert(formatDeadlineStats(common.ListJobSummaryResponse{JobID: jobID, TransfersNotStartedByDeadline: 7}), chk.Equals,
+		"\nNumber of Transfers Not Started (Deadline Reached): 7. Run 'azcopy jobs resume "+jobID.String()+"' to transfer them")
+}
@@ -0,0 +1,79 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type destTemplateSuite struct{}
+
+var _ = chk.Suite(&destTemplateSuite{})
+
+func (s *destTemplateSuite) TestParseDestTemplate(c *chk.C) {
+	for _, ok := range []string{"{year}/{month}/{name}", "/archive/{size}/{base}.{ext}/", `{dir}\{name}`} {
+		_, err := newDestTemplate(ok, time.Now())
+		c.Assert(err, chk.IsNil, chk.Commentf(ok))
+	}
+	for _, bad := range []string{"", "/", "{nope}/{name}", "{name", "name}", "../{name}", "{year}/./{name}"} {
+		_, err := newDestTemplate(bad, time.Now())
+		c.Assert(err, chk.NotNil, chk.Commentf(bad))
+	}
+}
+
+func (s *destTemplateSuite) TestResolveDestTemplate(c *chk.C) {
+	uploadDate := time.Date(2021, time.March, 7, 23, 0, 0, 0, time.UTC)
+	t, err := newDestTemplate("{year}/{month}/{day}/{size}/{dir}/{base}-copy.{ext}", uploadDate)
+	c.Assert(err, chk.IsNil)
+
+	dst, ok := t.resolve(storedObject{name: "report.pdf", relativePath: "docs/2020/report.pdf", size: 2 * 1024 * 1024})
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(dst, chk.Equals, "/2021/03/07/medium/docs/2020/report-copy.pdf")
+
+	// a file at the root has no {dir}, and that leaves no empty segment behind
+	dst, ok = t.resolve(storedObject{name: "a.txt", relativePath: "a.txt", size: 10})
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(dst, chk.Equals, "/2021/03/07/small/a-copy.txt")
+
+	// the destination container is kept, when copying a whole account
+	dst, ok = t.resolve(storedObject{name: "a.txt", relativePath: "a.txt", containerName: "src", dstContainerName: "dst"})
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(dst, chk.Equals, "/dst/2021/03/07/small/a-copy.txt")
+}
+
+func (s *destTemplateSuite) TestDestTemplateCollisionsAreSkipped(c *chk.C) {
+	t, err := newDestTemplate("flat/{name}", time.Now())
+	c.Assert(err, chk.IsNil)
+
+	dst, ok := t.resolve(storedObject{name: "a.txt", relativePath: "x/a.txt"})
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(dst, chk.Equals, "/flat/a.txt")
+
+	_, ok = t.resolve(storedObject{name: "a.txt", relativePath: "y/a.txt"})
+	c.Assert(ok, chk.Equals, false)
+	c.Assert(t.collisions, chk.Equals, uint64(1))
+	c.Assert(t.assigned["flat/a.txt"], chk.Equals, "x/a.txt")
+
+	_, ok = t.resolve(storedObject{name: "b.txt", relativePath: "y/b.txt"})
+	c.Assert(ok, chk.Equals, true)
+}
@@ -0,0 +1,89 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type destinationNamesSuite struct{}
+
+var _ = chk.Suite(&destinationNamesSuite{})
+
+func (s *destinationNamesSuite) TestDestinationPrefix(c *chk.C) {
+	r, err := newDestinationNameRules(common.ELocation.Blob(), "https://account.blob.core.windows.net/container/some/dir")
+	c.Assert(err, chk.IsNil)
+	c.Assert(r.prefix, chk.Equals, "some/dir")
+
+	r, err = newDestinationNameRules(common.ELocation.File(), "https://account.file.core.windows.net/share")
+	c.Assert(err, chk.IsNil)
+	c.Assert(r.prefix, chk.Equals, "")
+}
+
+func (s *destinationNamesSuite) TestBlobNameViolations(c *chk.C) {
+	r := destinationNameRules{location: common.ELocation.Blob(), prefix: "dir"}
+	c.Assert(r.violations("a/b c./d.txt"), chk.HasLen, 0) // a dot inside the name is fine for blobs
+	c.Assert(r.violations("a/b\tc.txt"), chk.HasLen, 1)
+	c.Assert(r.violations("a/../c.txt"), chk.HasLen, 1)
+	c.Assert(r.violations("a/c."), chk.HasLen, 1)
+	c.Assert(r.violations(strings.Repeat("a", 1020)), chk.HasLen, 0) // with "dir/", it's just short enough
+	c.Assert(r.violations(strings.Repeat("a", 1021)), chk.HasLen, 1)
+	c.Assert(r.violations(strings.Repeat("a", 1020)+"\x01."), chk.HasLen, 3)
+}
+
+func (s *destinationNamesSuite) TestFileNameViolations(c *chk.C) {
+	r := destinationNameRules{location: common.ELocation.File()}
+	c.Assert(r.violations("a/b/c.txt"), chk.HasLen, 0)
+	c.Assert(r.violations("a /c.txt"), chk.HasLen, 1)
+	c.Assert(r.violations("a./c.txt"), chk.HasLen, 1)
+	c.Assert(r.violations("a/"+strings.Repeat("b", 256)), chk.HasLen, 1)
+	c.Assert(r.violations(strings.Repeat(strings.Repeat("b", 200)+"/", 11)+"c"), chk.HasLen, 1)
+}
+
+func (s *destinationNamesSuite) TestSanitize(c *chk.C) {
+	blob := destinationNameRules{location: common.ELocation.Blob()}
+	c.Assert(blob.sanitize("a\tb/./c../d.."), chk.Equals, "a%09b/%2E/c../d%2E%2E")
+	c.Assert(blob.violations(blob.sanitize("a\tb/./c../d..")), chk.HasLen, 0)
+
+	file := destinationNameRules{location: common.ELocation.File()}
+	c.Assert(file.sanitize("a. /../c\x7f.txt "), chk.Equals, "a%2E%20/%2E%2E/c%7F.txt%20")
+	c.Assert(file.violations(file.sanitize("a. /../c\x7f.txt ")), chk.HasLen, 0)
+}
+
+func (s *destinationNamesSuite) TestSanitizeEscaped(c *chk.C) {
+	r := destinationNameRules{location: common.ELocation.File()}
+	c.Assert(r.sanitizeEscaped("/dir%20/a%09b.txt", storedObject{}), chk.Equals, "/dir%2520/a%2509b.txt")
+	c.Assert(r.sanitizeEscaped("/dst/dir./a.txt", storedObject{dstContainerName: "dst"}), chk.Equals, "/dst/dir%252E/a.txt")
+	c.Assert(r.sanitizeEscaped("", storedObject{}), chk.Equals, "")
+}
+
+func (s *destinationNamesSuite) TestValidateDestinationNameChecks(c *chk.C) {
+	c.Assert(validateDestinationNameChecks(false, false, cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal()}), chk.IsNil)
+	c.Assert(validateDestinationNameChecks(true, true, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalFile()}), chk.IsNil)
+	c.Assert(validateDestinationNameChecks(false, true, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), destTemplate: "{name}"}), chk.IsNil)
+
+	c.Assert(validateDestinationNameChecks(true, false, cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal()}), chk.NotNil)
+	c.Assert(validateDestinationNameChecks(true, false, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), destTemplate: "{name}"}), chk.NotNil)
+	c.Assert(validateDestinationNameChecks(true, false, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), listOfFilesChannel: make(chan string)}), chk.NotNil)
+}
@@ -0,0 +1,86 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+	chk "gopkg.in/check.v1"
+)
+
+type deterministicSuite struct{}
+
+var _ = chk.Suite(&deterministicSuite{})
+
+func autoTunedConcurrencySettings() ste.ConcurrencySettings {
+	return ste.ConcurrencySettings{
+		InitialMainPoolSize:        4,
+		MaxMainPoolSize:            &ste.ConfiguredInt{Value: 3000},
+		TransferInitiationPoolSize: &ste.ConfiguredInt{Value: 64},
+		EnumerationPoolSize:        &ste.ConfiguredInt{Value: 16},
+		ParallelStatFiles:          &ste.ConfiguredBool{Value: true},
+	}
+}
+
+func (s *deterministicSuite) TestDeterministicModeFixesConcurrency(c *chk.C) {
+	settings := autoTunedConcurrencySettings()
+	applyDeterministicMode(&settings)
+
+	c.Assert(settings.TransferInitiationPoolSize.Value, chk.Equals, 1)
+	c.Assert(settings.EnumerationPoolSize.Value, chk.Equals, 1)
+	c.Assert(settings.ParallelStatFiles.Value, chk.Equals, false)
+	c.Assert(settings.AutoTuneMainPool(), chk.Equals, false)
+	c.Assert(settings.MaxMainPoolSize.Value, chk.Equals, 4)
+}
+
+func (s *deterministicSuite) TestDeterministicModeShufflesTheSameWay(c *chk.C) {
+	shuffled := func() []string {
+		settings := autoTunedConcurrencySettings()
+		applyDeterministicMode(&settings)
+
+		transfers := make([]common.CopyTransfer, 20)
+		for i := range transfers {
+			transfers[i].Source = fmt.Sprintf("file%d", i)
+		}
+		shuffleTransfers(transfers)
+
+		order := make([]string, len(transfers))
+		for i, t := range transfers {
+			order[i] = t.Source
+		}
+		return order
+	}
+
+	c.Assert(shuffled(), chk.DeepEquals, shuffled())
+}
+
+func (s *deterministicSuite) TestValidateDeterministic(c *chk.C) {
+	c.Assert(validateDeterministic(true, false), chk.IsNil)
+	c.Assert(validateDeterministic(false, true), chk.IsNil)
+	c.Assert(validateDeterministic(true, true), chk.NotNil)
+
+	c.Assert(validateDeterministicCopy(true, cookedCopyCmdArgs{}), chk.IsNil)
+	c.Assert(validateDeterministicCopy(false, cookedCopyCmdArgs{shardByPrefix: 4}), chk.IsNil)
+	c.Assert(validateDeterministicCopy(true, cookedCopyCmdArgs{shardByPrefix: 4}), chk.NotNil)
+	c.Assert(validateDeterministicCopy(true, cookedCopyCmdArgs{precreateDirs: true}), chk.NotNil)
+}
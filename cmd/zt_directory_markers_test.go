@@ -0,0 +1,78 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type directoryMarkersSuite struct{}
+
+var _ = chk.Suite(&directoryMarkersSuite{})
+
+func (s *directoryMarkersSuite) TestIsDirectoryMarker(c *chk.C) {
+	c.Assert(isDirectoryMarker(storedObject{name: "dir/", relativePath: "a/dir/", entityType: common.EEntityType.File()}), chk.Equals, true)
+	c.Assert(isDirectoryMarker(storedObject{name: "dir", relativePath: "a/dir", entityType: common.EEntityType.File(),
+		Metadata: common.Metadata{directoryMarkerMetadataKey: "true"}}), chk.Equals, true)
+
+	c.Assert(isDirectoryMarker(storedObject{name: "dir", relativePath: "a/dir", entityType: common.EEntityType.File()}), chk.Equals, false)
+	c.Assert(isDirectoryMarker(storedObject{name: "dir/", relativePath: "a/dir/", size: 1, entityType: common.EEntityType.File()}), chk.Equals, false)
+	c.Assert(isDirectoryMarker(storedObject{name: "dir", relativePath: "a/dir", entityType: common.EEntityType.Folder()}), chk.Equals, false)
+}
+
+func (s *directoryMarkersSuite) TestDirectoryMarkerName(c *chk.C) {
+	name, err := directoryMarkerName("", "a/b%20c")
+	c.Assert(err, chk.IsNil)
+	c.Assert(name, chk.Equals, "a/b c/")
+
+	name, err = directoryMarkerName("/under/here/", "a")
+	c.Assert(err, chk.IsNil)
+	c.Assert(name, chk.Equals, "under/here/a/")
+}
+
+func (s *directoryMarkersSuite) TestValidateCreateDirectoryMarkers(c *chk.C) {
+	c.Assert(validateCreateDirectoryMarkers(false, cookedCopyCmdArgs{fromTo: common.EFromTo.FileLocal()}), chk.IsNil)
+	c.Assert(validateCreateDirectoryMarkers(true, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), recursive: true}), chk.IsNil)
+	c.Assert(validateCreateDirectoryMarkers(true, cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal(), recursive: true}), chk.IsNil)
+
+	c.Assert(validateCreateDirectoryMarkers(true, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalFile(), recursive: true}), chk.NotNil)
+	c.Assert(validateCreateDirectoryMarkers(true, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob()}), chk.NotNil)
+	c.Assert(validateCreateDirectoryMarkers(true, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), recursive: true, partitionBy: "mtime:daily"}), chk.NotNil)
+}
+
+func (s *directoryMarkersSuite) TestRecreateMarkedDirectory(c *chk.C) {
+	root, err := ioutil.TempDir("", "markers")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(root)
+
+	cca := cookedCopyCmdArgs{destination: common.ResourceString{Value: root}}
+	c.Assert(cca.recreateMarkedDirectory("/src/empty/"), chk.IsNil)
+	c.Assert(cca.recreateMarkedDirectory("/src/empty/"), chk.IsNil) // already there
+
+	info, err := os.Stat(filepath.Join(root, "src", "empty"))
+	c.Assert(err, chk.IsNil)
+	c.Assert(info.IsDir(), chk.Equals, true)
+}
@@ -0,0 +1,60 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type directoryPrecreatorSuite struct{}
+
+var _ = chk.Suite(&directoryPrecreatorSuite{})
+
+func (s *directoryPrecreatorSuite) TestDirectoriesAreGroupedByDepth(c *chk.C) {
+	dirs := make(map[string]bool)
+	addDirectoryAndParents(dirs, "/root/a/b/")
+	addDirectoryAndParents(dirs, "/root/a/c")
+	addDirectoryAndParents(dirs, "/root/d%20e/")
+	addDirectoryAndParents(dirs, "/")
+	addDirectoryAndParents(dirs, "")
+
+	c.Assert(dirs, chk.DeepEquals, map[string]bool{
+		"root": true, "root/a": true, "root/a/b": true, "root/a/c": true, "root/d%20e": true,
+	})
+	c.Assert(directoriesByDepth(dirs), chk.DeepEquals, [][]string{
+		{"root"},
+		{"root/a", "root/d%20e"},
+		{"root/a/b", "root/a/c"},
+	})
+}
+
+func (s *directoryPrecreatorSuite) TestPrecreateDirectoriesIsNoOpForBlob(c *chk.C) {
+	// the destination isn't contacted, so there's nothing to fail
+	cca := &cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), precreateDirs: true}
+	traverser := &fixedTraverser{objects: []storedObject{
+		{name: "a.txt", relativePath: "dir/a.txt", entityType: common.EEntityType.File()},
+	}}
+	c.Assert(cca.precreateDirectories(context.Background(), traverser, nil, true), chk.IsNil)
+}
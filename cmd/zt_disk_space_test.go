@@ -0,0 +1,113 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type diskSpaceSuite struct{}
+
+var _ = chk.Suite(&diskSpaceSuite{})
+
+func (s *diskSpaceSuite) TestSpaceNeededAllowsForExistingFiles(c *chk.C) {
+	src, err := ioutil.TempDir("", "diskspacesrc")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "diskspacedst")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dst)
+
+	c.Assert(os.MkdirAll(filepath.Join(src, "sub"), os.ModePerm), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(src, "a"), make([]byte, 100), 0644), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(src, "sub", "b"), make([]byte, 50), 0644), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(src, "c"), make([]byte, 10), 0644), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dst, "a"), make([]byte, 60), 0644), chk.IsNil) // only 40 more needed
+	c.Assert(ioutil.WriteFile(filepath.Join(dst, "c"), make([]byte, 20), 0644), chk.IsNil) // shrinks, so nothing more needed
+
+	cca := &cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal(), destination: common.ResourceString{Value: dst}}
+	traverser := newLocalTraverser(src, true, false, func(common.EntityType) {})
+	needed, err := cca.spaceNeededForDownload(traverser, nil, true, true)
+	c.Assert(err, chk.IsNil)
+	c.Assert(needed, chk.Equals, int64(90))
+
+	// without looking for existing files, everything is counted
+	needed, err = cca.spaceNeededForDownload(traverser, nil, true, false)
+	c.Assert(err, chk.IsNil)
+	c.Assert(needed, chk.Equals, int64(160))
+
+	// the reserved space counts against what's free
+	c.Assert(cca.ensureDiskSpace(traverser, nil, true, true), chk.IsNil)
+	cca.reserveSpace = 1 << 62
+	err = cca.ensureDiskSpace(traverser, nil, true, true)
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "reserve-space-mb"), chk.Equals, true)
+}
+
+func (s *diskSpaceSuite) TestExistingAncestor(c *chk.C) {
+	dir, err := ioutil.TempDir("", "diskspace")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	c.Assert(existingAncestor(dir), chk.Equals, filepath.Clean(dir))
+	c.Assert(existingAncestor(filepath.Join(dir, "not", "yet", "created")), chk.Equals, filepath.Clean(dir))
+}
+
+func (s *diskSpaceSuite) TestCheckSkippedWhenItCannotBeDone(c *chk.C) {
+	dst, err := ioutil.TempDir("", "diskspacedst")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dst)
+
+	src := "https://account.blob.core.windows.net/container/dir?sig=xyz"
+	raw := getDefaultCopyRawInput(src, dst)
+	raw.recursive = true
+	raw.normalizeUnicode = common.EUnicodeNormalization.None().String()
+	raw.logFormat = common.ELogFormat.Text().String()
+	raw.s2sPreference = "prefer"
+	raw.checkDiskSpace = true
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.checkDiskSpace, chk.Equals, true)
+
+	// nothing is written to the null device, so its volume's free space doesn't matter
+	raw.dst = common.Dev_Null
+	cooked, err = raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.checkDiskSpace, chk.Equals, false)
+
+	// a list of files can only be read once, and it's needed for the transfers
+	raw.dst = dst
+	raw.includePath = "a.txt;b.txt"
+	cooked, err = raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.checkDiskSpace, chk.Equals, false)
+
+	raw.reserveSpaceMB = 10
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "reserve-space-mb cannot be combined with list-of-files.*")
+}
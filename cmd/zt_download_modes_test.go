@@ -0,0 +1,60 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"runtime"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type downloadModesSuite struct{}
+
+var _ = chk.Suite(&downloadModesSuite{})
+
+func (s *downloadModesSuite) TestParseDownloadMode(c *chk.C) {
+	for mode, expected := range map[string]uint16{"": 0, "0640": 0640, "750": 0750, "0777": 0777} {
+		bits, err := parseDownloadMode("file-mode", mode)
+		c.Assert(err, chk.IsNil)
+		c.Assert(bits, chk.Equals, expected)
+	}
+
+	for _, mode := range []string{"0", "0000", "1777", "4755", "0648", "rw-r-----", "-1"} {
+		_, err := parseDownloadMode("file-mode", mode)
+		c.Assert(err, chk.NotNil, chk.Commentf(mode))
+	}
+}
+
+func (s *downloadModesSuite) TestValidateDownloadModes(c *chk.C) {
+	c.Assert(validateDownloadModes(cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob()}), chk.IsNil)
+
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		c.Assert(validateDownloadModes(cookedCopyCmdArgs{fileMode: 0640, fromTo: common.EFromTo.BlobLocal()}), chk.NotNil)
+		return
+	}
+	c.Assert(validateDownloadModes(cookedCopyCmdArgs{fileMode: 0640, fromTo: common.EFromTo.BlobLocal()}), chk.IsNil)
+	c.Assert(validateDownloadModes(cookedCopyCmdArgs{dirMode: 0750, fromTo: common.EFromTo.FileLocal()}), chk.IsNil)
+
+	c.Assert(validateDownloadModes(cookedCopyCmdArgs{fileMode: 0640, fromTo: common.EFromTo.LocalBlob()}), chk.NotNil)
+	c.Assert(validateDownloadModes(cookedCopyCmdArgs{dirMode: 0750, fromTo: common.EFromTo.BlobBlob()}), chk.NotNil)
+	c.Assert(validateDownloadModes(cookedCopyCmdArgs{fileMode: 0640, fromTo: common.EFromTo.BlobLocal(), preservePOSIX: true}), chk.NotNil)
+}
@@ -0,0 +1,51 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type encryptionScopeSuite struct{}
+
+var _ = chk.Suite(&encryptionScopeSuite{})
+
+func (s *encryptionScopeSuite) TestPreflightDescribesTheDefaultEncryptionScope(c *chk.C) {
+	// a container that reports no default scope encrypts with the account's key, which needs no mention
+	c.Assert(describeDefaultEncryptionScope("container", "", ""), chk.Equals, "")
+
+	c.Assert(describeDefaultEncryptionScope("container", "scope1", "false"), chk.Equals,
+		"Preflight: the blobs copied to the destination container container will be encrypted with its default encryption scope scope1")
+	c.Assert(describeDefaultEncryptionScope("container", "scope1", "true"), chk.Matches,
+		".*default encryption scope scope1. The container doesn't allow its blobs to have any other scope")
+}
+
+func (s *encryptionScopeSuite) TestDefaultEncryptionScopeMustBeTheOneExpected(c *chk.C) {
+	c.Assert(checkDefaultEncryptionScope("container", "scope1", "scope1", "true"), chk.IsNil)
+
+	// a container that reports no default scope is left for each transfer to check
+	c.Assert(checkDefaultEncryptionScope("container", "scope1", "", ""), chk.IsNil)
+
+	// blobs get the default scope whether or not it can be overridden, since AzCopy never asks for another one
+	c.Assert(checkDefaultEncryptionScope("container", "scope1", "$account-encryption-key", "false"), chk.ErrorMatches,
+		".*default encryption scope \\$account-encryption-key, rather than scope1.*")
+	c.Assert(checkDefaultEncryptionScope("container", "scope1", "scope2", "true"), chk.ErrorMatches,
+		".*doesn't allow its blobs to have any other scope.*")
+}
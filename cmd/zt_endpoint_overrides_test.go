@@ -0,0 +1,123 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+type endpointOverridesSuite struct{}
+
+var _ = chk.Suite(&endpointOverridesSuite{})
+
+func (s *endpointOverridesSuite) TestEndpointSuffixRedirectsStandardHosts(c *chk.C) {
+	o, err := parseEndpointOverrides(".core.usgovcloudapi.net", nil)
+	c.Assert(err, chk.IsNil)
+
+	c.Assert(o.apply("https://acct.blob.core.windows.net/c/a%20b.txt?sv=x"), chk.Equals, "https://acct.blob.core.usgovcloudapi.net/c/a%20b.txt?sv=x")
+	c.Assert(o.apply("https://acct.dfs.core.chinacloudapi.cn"), chk.Equals, "https://acct.dfs.core.usgovcloudapi.net")
+
+	// anything that isn't a standard host is left alone
+	for _, resource := range []string{
+		"/local/path",
+		"https://acct.privatelink.blob.core.windows.net/c",
+		"https://storage.contoso.com/c",
+		"https://acct.blob.local.azurestack.external/c",
+		"https://s3.amazonaws.com/bucket",
+	} {
+		c.Assert(o.apply(resource), chk.Equals, resource)
+	}
+}
+
+func (s *endpointOverridesSuite) TestServiceEndpointTakesPrecedence(c *chk.C) {
+	o, err := parseEndpointOverrides("core.usgovcloudapi.net", map[string]string{"blob": "https://PrivateLink.blob.core.windows.net/", "file": ""})
+	c.Assert(err, chk.IsNil)
+
+	c.Assert(o.apply("https://acct.blob.core.windows.net/c/d"), chk.Equals, "https://acct.privatelink.blob.core.windows.net/c/d")
+	c.Assert(o.apply("https://acct.file.core.windows.net/s"), chk.Equals, "https://acct.file.core.usgovcloudapi.net/s")
+
+	// each account keeps its own host, so that both sides of a service to service copy aren't sent to the same place
+	c.Assert(o.apply("https://other.blob.core.windows.net/c"), chk.Equals, "https://other.privatelink.blob.core.windows.net/c")
+
+	loc, ok := o.locationOf("acct.privatelink.blob.core.windows.net")
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(loc, chk.Equals, common.ELocation.Blob())
+	_, ok = o.locationOf("acct.file.core.usgovcloudapi.net")
+	c.Assert(ok, chk.Equals, false)
+
+	c.Assert(o.withTrustedSuffixes("*.contoso.com"), chk.Equals, "*.contoso.com;*.core.usgovcloudapi.net;*.privatelink.blob.core.windows.net")
+}
+
+func (s *endpointOverridesSuite) TestCustomEndpointIsInferredAsItsService(c *chk.C) {
+	defer func(old endpointOverrides) { azcopyEndpoints = old }(azcopyEndpoints)
+
+	var err error
+	azcopyEndpoints, err = parseEndpointOverrides("", map[string]string{"dfs": "https://lake.contoso.com"})
+	c.Assert(err, chk.IsNil)
+	c.Assert(inferArgumentLocation("https://acct.lake.contoso.com/fs/dir"), chk.Equals, common.ELocation.BlobFS())
+	c.Assert(inferArgumentLocation("https://acct.other.contoso.com/fs/dir"), chk.Equals, common.ELocation.Local())
+}
+
+func (s *endpointOverridesSuite) TestPipelinesSendRequestsToTheOverride(c *chk.C) {
+	o, err := parseEndpointOverrides("", map[string]string{"blob": "https://privatelink.blob.core.windows.net"})
+	c.Assert(err, chk.IsNil)
+	ste.SetEndpointRewriter(o.apply)
+	defer ste.SetEndpointRewriter(nil)
+
+	var sent *http.Request
+	p := pipeline.NewPipeline([]pipeline.Factory{ste.NewEndpointPolicyFactory()}, pipeline.Options{
+		HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+			return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+				sent = request.Request
+				return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusAccepted}), nil
+			}
+		}),
+	})
+
+	u, _ := url.Parse("https://dst.blob.core.windows.net/c/a%20b.txt?sig=x")
+	req, err := pipeline.NewRequest(http.MethodPut, *u, nil)
+	c.Assert(err, chk.IsNil)
+	req.Header.Set("x-ms-copy-source", "https://src.blob.core.windows.net/c/a.txt?sig=y")
+	_, err = p.Do(context.Background(), nil, req)
+	c.Assert(err, chk.IsNil)
+
+	c.Assert(sent.URL.String(), chk.Equals, "https://dst.privatelink.blob.core.windows.net/c/a%20b.txt?sig=x")
+	c.Assert(sent.Host, chk.Equals, "dst.privatelink.blob.core.windows.net")
+	c.Assert(sent.Header.Get("x-ms-copy-source"), chk.Equals, "https://src.privatelink.blob.core.windows.net/c/a.txt?sig=y")
+}
+
+func (s *endpointOverridesSuite) TestParseEndpointOverridesRejectsInvalid(c *chk.C) {
+	_, err := parseEndpointOverrides("https://core.usgovcloudapi.net", nil)
+	c.Assert(err, chk.NotNil)
+
+	for _, raw := range []string{"acct.blob.core.windows.net", "ftp://acct.blob.core.windows.net", "https://privatelink.blob.core.windows.net/container", "https://privatelink.blob.core.windows.net?sv=x"} {
+		_, err = parseEndpointOverrides("", map[string]string{"blob": raw})
+		c.Assert(err, chk.NotNil, chk.Commentf("%q", raw))
+	}
+}
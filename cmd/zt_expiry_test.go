@@ -0,0 +1,79 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type expirySuite struct{}
+
+var _ = chk.Suite(&expirySuite{})
+
+func (s *expirySuite) TestParseExpiry(c *chk.C) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	option, expiryTime, err := parseExpiry("", now)
+	c.Assert(err, chk.IsNil)
+	c.Assert(option, chk.Equals, common.EBlobExpiryOption.None())
+
+	option, expiryTime, err = parseExpiry("RelativeToNow:30d", now)
+	c.Assert(err, chk.IsNil)
+	c.Assert(option, chk.Equals, common.EBlobExpiryOption.RelativeToNow())
+	c.Assert(expiryTime, chk.Equals, int64(30*24*time.Hour/time.Millisecond))
+
+	option, expiryTime, err = parseExpiry("relativetonow:90m", now)
+	c.Assert(err, chk.IsNil)
+	c.Assert(option, chk.Equals, common.EBlobExpiryOption.RelativeToNow())
+	c.Assert(expiryTime, chk.Equals, int64(90*time.Minute/time.Millisecond))
+
+	expected := time.Date(2026, 6, 30, 12, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	for _, absolute := range []string{"Absolute:2026-06-30T12:00:00Z", "2026-06-30T12:00:00Z"} {
+		option, expiryTime, err = parseExpiry(absolute, now)
+		c.Assert(err, chk.IsNil)
+		c.Assert(option, chk.Equals, common.EBlobExpiryOption.Absolute())
+		c.Assert(expiryTime, chk.Equals, expected)
+	}
+
+	option, _, err = parseExpiry("NeverExpire", now)
+	c.Assert(err, chk.IsNil)
+	c.Assert(option, chk.Equals, common.EBlobExpiryOption.NeverExpire())
+
+	for _, invalid := range []string{"RelativeToNow:", "RelativeToNow:-1d", "RelativeToNow:soon", "Absolute:2025-06-30T12:00:00Z",
+		"NeverExpire:30d", "PreserveSource", "None", "tomorrow"} {
+		_, _, err = parseExpiry(invalid, now)
+		c.Assert(err, chk.NotNil, chk.Commentf(invalid))
+	}
+}
+
+func (s *expirySuite) TestValidateExpiry(c *chk.C) {
+	c.Assert(validateExpiry(common.EBlobExpiryOption.None(), false, common.EFromTo.LocalFile()), chk.IsNil)
+	c.Assert(validateExpiry(common.EBlobExpiryOption.RelativeToNow(), false, common.EFromTo.LocalBlob()), chk.IsNil)
+	c.Assert(validateExpiry(common.EBlobExpiryOption.None(), true, common.EFromTo.BlobBlob()), chk.IsNil)
+
+	c.Assert(validateExpiry(common.EBlobExpiryOption.Absolute(), false, common.EFromTo.LocalBlobFS()), chk.NotNil)
+	c.Assert(validateExpiry(common.EBlobExpiryOption.None(), true, common.EFromTo.LocalBlob()), chk.NotNil)
+	c.Assert(validateExpiry(common.EBlobExpiryOption.NeverExpire(), true, common.EFromTo.BlobBlob()), chk.NotNil)
+}
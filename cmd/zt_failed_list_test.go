@@ -0,0 +1,57 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type failedListSuite struct{}
+
+var _ = chk.Suite(&failedListSuite{})
+
+func (s *failedListSuite) TestUnescapeSourceRelativePath(c *chk.C) {
+	c.Assert(unescapeSourceRelativePath("/dir/a%20b%25.txt", common.ELocation.Blob()), chk.Equals, "dir/a b%.txt")
+	c.Assert(unescapeSourceRelativePath("/dir/a%20b.txt", common.ELocation.Local()), chk.Equals, "dir/a%20b.txt")
+	c.Assert(unescapeSourceRelativePath("", common.ELocation.File()), chk.Equals, "")
+}
+
+func (s *failedListSuite) TestWriteFailedTransfers(c *chk.C) {
+	failed := []failedTransfer{
+		{relativePath: "dir/a.txt", status: common.ETransferStatus.Failed(), errorCode: 403},
+		{relativePath: "b, c.txt", status: common.ETransferStatus.BlobTierFailure(), errorCode: 409},
+	}
+
+	var buf bytes.Buffer
+	c.Assert(writeFailedTransfers(&buf, failed, false), chk.IsNil)
+	c.Assert(buf.String(), chk.Equals, "dir/a.txt\nb, c.txt\n")
+
+	buf.Reset()
+	c.Assert(writeFailedTransfers(&buf, failed, true), chk.IsNil)
+	c.Assert(buf.String(), chk.Equals, "path,status,error_code\ndir/a.txt,Failed,403\n\"b, c.txt\",BlobTierFailure,409\n")
+
+	// nothing failed, so there's nothing to retry
+	buf.Reset()
+	c.Assert(writeFailedTransfers(&buf, nil, false), chk.IsNil)
+	c.Assert(buf.Len(), chk.Equals, 0)
+}
@@ -24,8 +24,15 @@ import (
 	"errors"
 	"fmt"
 	chk "gopkg.in/check.v1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
 )
 
 type genericFilterSuite struct{}
@@ -78,6 +85,123 @@ func (s *genericFilterSuite) TestExcludeFilter(c *chk.C) {
 	}
 }
 
+func (s *genericFilterSuite) TestExcludeEmptyFilter(c *chk.C) {
+	filters := []objectFilter{&excludeEmptyFilter{}}
+
+	for _, o := range []storedObject{
+		{name: "hasContent", size: 1, entityType: common.EEntityType.File()},
+		{name: "unknownSize", size: -1, entityType: common.EEntityType.File()},
+		{name: "folder", size: 0, entityType: common.EEntityType.Folder()},
+	} {
+		dummyProcessor := &dummyProcessor{}
+		err := processIfPassedFilters(filters, o, dummyProcessor.process)
+		c.Assert(err, chk.IsNil)
+		c.Assert(len(dummyProcessor.record), chk.Equals, 1)
+	}
+
+	dummyProcessor := &dummyProcessor{}
+	err := processIfPassedFilters(filters, storedObject{name: "empty", size: 0, entityType: common.EEntityType.File()}, dummyProcessor.process)
+	c.Assert(err, chk.Equals, ignoredError)
+	c.Assert(len(dummyProcessor.record), chk.Equals, 0)
+}
+
+func (s *genericFilterSuite) TestSizeLimitFilter(c *chk.C) {
+	const gib = 1024 * 1024 * 1024
+	file := func(name string, size int64) storedObject {
+		return storedObject{name: name, relativePath: name, size: size, entityType: common.EEntityType.File()}
+	}
+
+	// the blob type is detected the same way the engine does it
+	detect := &sizeLimitFilter{destination: common.ELocation.Blob(), blobType: common.EBlobType.Detect()}
+	c.Assert(detect.doesPass(file("big.bin", 9*1024*gib)), chk.Equals, true)
+	c.Assert(detect.doesPass(file("big.vhd", 9*1024*gib)), chk.Equals, false)
+	c.Assert(detect.doesPass(file("small.vhd", gib)), chk.Equals, true)
+	c.Assert(detect.doesPass(file("unknown.vhd", -1)), chk.Equals, true)
+	appendSource := file("log.bin", 200*gib)
+	appendSource.blobType = azblob.BlobAppendBlob
+	c.Assert(detect.doesPass(appendSource), chk.Equals, false)
+
+	// a chosen block size or number of blocks lowers the limit for block blobs
+	blockSize := &sizeLimitFilter{destination: common.ELocation.Blob(), blobType: common.EBlobType.BlockBlob(), blockSize: 4 * 1024 * 1024}
+	c.Assert(blockSize.doesPass(file("a", 195*gib)), chk.Equals, true)
+	c.Assert(blockSize.doesPass(file("a", 196*gib)), chk.Equals, false)
+	maxBlocks := &sizeLimitFilter{destination: common.ELocation.Blob(), blobType: common.EBlobType.BlockBlob(), maxBlocks: 10}
+	c.Assert(maxBlocks.doesPass(file("a", 39*gib)), chk.Equals, true)
+	c.Assert(maxBlocks.doesPass(file("a", 40*gib)), chk.Equals, false)
+
+	azureFiles := &sizeLimitFilter{destination: common.ELocation.File()}
+	c.Assert(azureFiles.doesPass(file("a", 4*1024*gib)), chk.Equals, true)
+	c.Assert(azureFiles.doesPass(file("a", 4*1024*gib+1)), chk.Equals, false)
+
+	// folders always pass, so the filter doesn't stop folder properties from being transferred
+	folder := storedObject{name: "dir", relativePath: "dir", size: 8 * 1024 * gib, entityType: common.EEntityType.Folder()}
+	c.Assert(azureFiles.doesPass(folder), chk.Equals, true)
+	fpo, _ := newFolderPropertyOption(common.EFromTo.LocalFile(), true, false, []objectFilter{azureFiles}, true, false)
+	c.Assert(fpo, chk.Equals, common.EFolderPropertiesOption.AllFolders())
+}
+
+func (s *genericFilterSuite) TestAccessTierFilter(c *chk.C) {
+	blob := func(tier azblob.AccessTierType) storedObject {
+		return storedObject{name: string(tier), blobAccessTier: tier, entityType: common.EEntityType.File()}
+	}
+
+	tiers, err := parseAccessTiers("hot, Cool", "include-tier")
+	c.Assert(err, chk.IsNil)
+	c.Assert(tiers, chk.DeepEquals, []azblob.AccessTierType{azblob.AccessTierHot, azblob.AccessTierCool})
+	include := newAccessTierFilter(tiers, true)
+	c.Assert(include.doesPass(blob(azblob.AccessTierHot)), chk.Equals, true)
+	c.Assert(include.doesPass(blob(azblob.AccessTierCool)), chk.Equals, true)
+	c.Assert(include.doesPass(blob(azblob.AccessTierArchive)), chk.Equals, false)
+	c.Assert(include.doesPass(blob(azblob.AccessTierNone)), chk.Equals, false)
+
+	tiers, err = parseAccessTiers("Archive", "exclude-tier")
+	c.Assert(err, chk.IsNil)
+	exclude := newAccessTierFilter(tiers, false)
+	c.Assert(exclude.doesPass(blob(azblob.AccessTierHot)), chk.Equals, true)
+	c.Assert(exclude.doesPass(blob(azblob.AccessTierNone)), chk.Equals, true)
+	c.Assert(exclude.doesPass(blob(azblob.AccessTierArchive)), chk.Equals, false)
+
+	_, err = parseAccessTiers("Hot;Frozen", "include-tier")
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "Frozen"), chk.Equals, true)
+}
+
+func (s *genericFilterSuite) TestContentTypeFilter(c *chk.C) {
+	patterns, err := parseContentTypePatterns("image/*, Application/PDF")
+	c.Assert(err, chk.IsNil)
+	c.Assert(patterns, chk.DeepEquals, []string{"image/*", "application/pdf"})
+
+	// blobs are matched by their stored content type, ignoring its parameters and case
+	blob := func(contentType string) storedObject {
+		return storedObject{name: "b", contentType: contentType, entityType: common.EEntityType.File()}
+	}
+	f := &contentTypeFilter{patterns: patterns}
+	c.Assert(f.doesPass(blob("image/png")), chk.Equals, true)
+	c.Assert(f.doesPass(blob("Application/pdf; charset=binary")), chk.Equals, true)
+	c.Assert(f.doesPass(blob("text/html")), chk.Equals, false)
+	c.Assert(f.doesPass(blob("")), chk.Equals, false)
+
+	// local files get the type that uploading them would give them, from the extension or else the content
+	dir, err := ioutil.TempDir("", "contenttype")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "photo.png"), []byte("not really"), 0644), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "noext"), []byte("%PDF-1.4 content"), 0644), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "page.html"), []byte("<html></html>"), 0644), chk.IsNil)
+	local := &contentTypeFilter{patterns: patterns, localRoot: dir}
+	file := func(name string) storedObject {
+		return storedObject{name: name, relativePath: name, entityType: common.EEntityType.File()}
+	}
+	c.Assert(local.doesPass(file("photo.png")), chk.Equals, true)
+	c.Assert(local.doesPass(file("noext")), chk.Equals, true)
+	c.Assert(local.doesPass(file("page.html")), chk.Equals, false)
+
+	_, err = parseContentTypePatterns("image")
+	c.Assert(err, chk.NotNil)
+	_, err = parseContentTypePatterns("image/[")
+	c.Assert(err, chk.NotNil)
+}
+
 func (s *genericFilterSuite) TestDateParsingForIncludeAfter(c *chk.C) {
 	examples := []struct {
 		input                 string // ISO 8601
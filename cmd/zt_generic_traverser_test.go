@@ -824,3 +824,55 @@ func (s *genericTraverserSuite) TestSerialAndParallelBlobTraverser(c *chk.C) {
 		}
 	}
 }
+
+// The sharded traverser should find exactly what the plain one does, with relative paths that are relative to the source,
+// so that filters on paths work the same with and without sharding.
+func (s *genericTraverserSuite) TestShardedTraverserMatchesLocalTraverser(c *chk.C) {
+	fileNames := []string{"top.txt", "a/1.txt", "a/b/2.txt", "c/3.txt", "d/e/4.txt", "f/g/h/5.txt"}
+	tmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(tmpDir)
+	scenarioHelper{}.generateLocalFilesFromList(c, tmpDir, fileNames)
+
+	filters := []objectFilter{&excludeFilter{pattern: "a/b", targetsPath: true}}
+
+	plainIndexer := newObjectIndexer()
+	plainTraverser := newLocalTraverser(tmpDir, true, false, nil)
+	c.Assert(plainTraverser.traverse(noPreProccessor, plainIndexer.store, filters), chk.IsNil)
+
+	for _, shardCount := range []int{1, 2, 10} {
+		shardedIndexer := newObjectIndexer()
+		shardedTraverser, err := newShardedTraverser(context.TODO(), common.ResourceString{Value: tmpDir}, common.ELocation.Local(), nil, false, false, false, shardCount, nil)
+		c.Assert(err, chk.IsNil)
+		c.Assert(shardedTraverser.isDirectory(true), chk.Equals, true)
+		c.Assert(shardedTraverser.traverse(noPreProccessor, shardedIndexer.store, filters), chk.IsNil)
+
+		c.Assert(len(shardedIndexer.indexMap), chk.Equals, len(plainIndexer.indexMap))
+		for relativePath, plainObject := range plainIndexer.indexMap {
+			shardedObject, found := shardedIndexer.indexMap[relativePath]
+			c.Assert(found, chk.Equals, true, chk.Commentf("%s is missing with %d shards", relativePath, shardCount))
+			c.Assert(shardedObject.entityType, chk.Equals, plainObject.entityType)
+			c.Assert(shardedObject.name, chk.Equals, plainObject.name)
+		}
+		_, excluded := shardedIndexer.indexMap["a/b/2.txt"]
+		c.Assert(excluded, chk.Equals, false)
+	}
+}
+
+// Once the processor returns an error, every shard should stop, and the error should be returned
+func (s *genericTraverserSuite) TestShardedTraverserStopsOnError(c *chk.C) {
+	fileNames := []string{"a/1.txt", "b/2.txt", "c/3.txt", "d/4.txt"}
+	tmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(tmpDir)
+	scenarioHelper{}.generateLocalFilesFromList(c, tmpDir, fileNames)
+
+	shardedTraverser, err := newShardedTraverser(context.TODO(), common.ResourceString{Value: tmpDir}, common.ELocation.Local(), nil, false, false, false, 4, nil)
+	c.Assert(err, chk.IsNil)
+
+	processed := 0
+	err = shardedTraverser.traverse(noPreProccessor, func(object storedObject) error {
+		processed++
+		return enumerationLimitReached
+	}, nil)
+	c.Assert(err, chk.Equals, enumerationLimitReached)
+	c.Assert(processed, chk.Equals, 1)
+}
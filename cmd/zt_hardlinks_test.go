@@ -0,0 +1,115 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type hardLinksSuite struct{}
+
+var _ = chk.Suite(&hardLinksSuite{})
+
+func (s *hardLinksSuite) TestRelativeLinkTarget(c *chk.C) {
+	c.Assert(relativeLinkTarget("/src/a/link", "/src/a/file"), chk.Equals, "file")
+	c.Assert(relativeLinkTarget("/src/a/b/link", "/src/a/file"), chk.Equals, "../file")
+	c.Assert(relativeLinkTarget("/src/a/link", "/src/c/d/file"), chk.Equals, "../c/d/file")
+	c.Assert(relativeLinkTarget("/link", "/src/file"), chk.Equals, "src/file")
+	c.Assert(relativeLinkTarget("/src/link", "/file"), chk.Equals, "../file")
+}
+
+func (s *hardLinksSuite) TestFindUploadLink(c *chk.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("hard links are only found on Linux and macOS")
+	}
+	root, err := ioutil.TempDir("", "hardlinks")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(root)
+
+	c.Assert(ioutil.WriteFile(filepath.Join(root, "file"), []byte("data"), 0644), chk.IsNil)
+	c.Assert(os.Mkdir(filepath.Join(root, "dir"), 0755), chk.IsNil)
+	c.Assert(os.Link(filepath.Join(root, "file"), filepath.Join(root, "dir", "link")), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(root, "other"), []byte("data"), 0644), chk.IsNil)
+
+	h := newHardLinks(root)
+	file := storedObject{name: "file", relativePath: "file", entityType: common.EEntityType.File()}
+	link := storedObject{name: "link", relativePath: "dir/link", entityType: common.EEntityType.File()}
+	other := storedObject{name: "other", relativePath: "other", entityType: common.EEntityType.File()}
+
+	// the first of the linked files is uploaded, and the second recorded as a link to it
+	c.Assert(h.findUploadLink(file, "/src/file"), chk.Equals, false)
+	c.Assert(h.findUploadLink(other, "/src/other"), chk.Equals, false)
+	c.Assert(h.findUploadLink(link, "/src/dir/link"), chk.Equals, true)
+	c.Assert(h.links, chk.DeepEquals, []hardLink{{path: "/src/dir/link", target: "../file"}})
+}
+
+func (s *hardLinksSuite) TestRecreateLinks(c *chk.C) {
+	defer func(old common.LifecycleMgr) { glcm = old }(glcm)
+	mockedLcm := &mockedLifecycleManager{infoLog: make(chan string, 50)}
+	glcm = mockedLcm
+
+	root, err := ioutil.TempDir("", "hardlinks")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(root)
+	c.Assert(os.MkdirAll(filepath.Join(root, "src", "a"), 0755), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(root, "src", "a", "file"), []byte("data"), 0644), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(root, "src", "stale"), []byte("old"), 0644), chk.IsNil)
+
+	h := newHardLinks("")
+	linkBlob := storedObject{name: "link", relativePath: "b/link", entityType: common.EEntityType.File(),
+		Metadata: common.Metadata{hardLinkMetadataKey: "../a/file"}}
+	c.Assert(h.findDownloadLink(linkBlob, "/src/b/link%20one"), chk.Equals, true)
+	c.Assert(h.findDownloadLink(storedObject{name: "file", relativePath: "a/file", entityType: common.EEntityType.File()}, "/src/a/file"), chk.Equals, false)
+	h.links = append(h.links,
+		hardLink{path: "/src/stale", target: "a/file"},      // replaces what's there
+		hardLink{path: "/src/missing", target: "a/nothing"}, // the file it's linked to wasn't downloaded
+		hardLink{path: "/src/escape", target: "../../file"}) // outside the destination
+
+	c.Assert(h.recreateLinks(root, common.EOverwriteOption.True()), chk.Equals, 2)
+	c.Assert(len(mockedLcm.infoLog), chk.Equals, 2)
+
+	target, err := os.Stat(filepath.Join(root, "src", "a", "file"))
+	c.Assert(err, chk.IsNil)
+	for _, linkPath := range []string{filepath.Join(root, "src", "b", "link one"), filepath.Join(root, "src", "stale")} {
+		link, err := os.Stat(linkPath)
+		c.Assert(err, chk.IsNil)
+		c.Assert(os.SameFile(link, target), chk.Equals, true)
+	}
+
+	// linking again is harmless
+	h.links = h.links[:1]
+	c.Assert(h.recreateLinks(root, common.EOverwriteOption.False()), chk.Equals, 0)
+}
+
+func (s *hardLinksSuite) TestValidatePreserveHardLinks(c *chk.C) {
+	c.Assert(validatePreserveHardLinks(false, cookedCopyCmdArgs{fromTo: common.EFromTo.FileLocal()}), chk.IsNil)
+	c.Assert(validatePreserveHardLinks(true, cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal(), recursive: true}), chk.IsNil)
+
+	c.Assert(validatePreserveHardLinks(true, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalFile(), recursive: true}), chk.NotNil)
+	c.Assert(validatePreserveHardLinks(true, cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal()}), chk.NotNil)
+	c.Assert(validatePreserveHardLinks(true, cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal(), recursive: true, destTemplate: "{name}"}), chk.NotNil)
+}
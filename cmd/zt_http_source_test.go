@@ -0,0 +1,91 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type httpSourceSuite struct{}
+
+var _ = chk.Suite(&httpSourceSuite{})
+
+func (s *httpSourceSuite) TestHttpSourceMustBeGivenExplicitly(c *chk.C) {
+	// a URL that isn't of a storage service is taken for a local path, as it always was, rather than for a web server
+	c.Assert(inferArgumentLocation("https://example.com/data.csv"), chk.Equals, common.ELocation.Local())
+	c.Assert(inferArgumentLocation("https://account.blob.core.windows.net/container/blob"), chk.Equals, common.ELocation.Blob())
+	c.Assert(inferArgumentLocation("https://s3.amazonaws.com/bucket/object"), chk.Equals, common.ELocation.S3())
+
+	fromTo, err := validateFromTo("https://example.com/data.csv", "https://account.blob.core.windows.net/container", "HttpBlob")
+	c.Assert(err, chk.IsNil)
+	c.Assert(fromTo, chk.Equals, common.EFromTo.HttpBlob())
+}
+
+func (s *httpSourceSuite) TestFlagsUnsupportedByHttpStream(c *chk.C) {
+	defer func(mbps float64, rps int64) { cmdLineCapMegaBitsPerSecond, cmdLineCapRequestsPerSecond = mbps, rps }(cmdLineCapMegaBitsPerSecond, cmdLineCapRequestsPerSecond)
+	cmdLineCapMegaBitsPerSecond, cmdLineCapRequestsPerSecond = 0, 0
+
+	cca := &cookedCopyCmdArgs{blobType: common.EBlobType.BlockBlob()}
+	c.Assert(cca.flagsUnsupportedByHttpStream(), chk.HasLen, 0)
+
+	cca = &cookedCopyCmdArgs{blobType: common.EBlobType.PageBlob(), blobTags: common.BlobTags{"project": "data"}}
+	cmdLineCapMegaBitsPerSecond = 100
+	c.Assert(cca.flagsUnsupportedByHttpStream(), chk.DeepEquals, []string{"blob-tags", "blob-type PageBlob", "cap-mbps"})
+}
+
+func (s *httpSourceSuite) TestHttpStreamIsRefusedWhenS2SIsRequired(c *chk.C) {
+	// the server doesn't accept range requests, so the service couldn't fetch the source itself
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cca := &cookedCopyCmdArgs{
+		source:        common.ResourceString{Value: server.URL + "/data.csv"},
+		destination:   common.ResourceString{Value: "https://account.blob.core.windows.net/container"},
+		fromTo:        common.EFromTo.HttpBlob(),
+		s2sPreference: common.ES2SPreference.Require(),
+	}
+	streamed, err := cca.processHttpStreamCopyIfNeeded()
+	c.Assert(streamed, chk.Equals, true)
+	c.Assert(err, chk.NotNil)
+	c.Assert(strings.Contains(err.Error(), "s2s=require"), chk.Equals, true, chk.Commentf(err.Error()))
+}
+
+func (s *httpSourceSuite) TestHttpSourceName(c *chk.C) {
+	for raw, expected := range map[string]string{
+		"https://example.com/datasets/data.csv?token=abc": "data.csv",
+		"https://example.com/datasets/":                   "datasets",
+		"https://example.com/":                            "example.com",
+		"https://example.com:8443":                        "example.com",
+	} {
+		u, err := url.Parse(raw)
+		c.Assert(err, chk.IsNil)
+		c.Assert(httpSourceName(u), chk.Equals, expected, chk.Commentf(raw))
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"net/http"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type immutabilitySuite struct{}
+
+var _ = chk.Suite(&immutabilitySuite{})
+
+func (s *immutabilitySuite) TestValidatePreserveImmutability(c *chk.C) {
+	c.Assert(validatePreserveImmutability(true, common.EFromTo.BlobBlob()), chk.IsNil)
+	c.Assert(validatePreserveImmutability(true, common.EFromTo.LocalBlob()), chk.NotNil)
+	c.Assert(validatePreserveImmutability(true, common.EFromTo.FileBlob()), chk.NotNil)
+	c.Assert(validatePreserveImmutability(false, common.EFromTo.LocalBlob()), chk.IsNil)
+}
+
+func (s *immutabilitySuite) TestAcceptsImmutability(c *chk.C) {
+	h := http.Header{}
+	c.Assert(acceptsImmutability(h), chk.Equals, false)
+	h.Set("x-ms-immutable-storage-with-versioning-enabled", "false")
+	c.Assert(acceptsImmutability(h), chk.Equals, false)
+	h.Set("x-ms-immutable-storage-with-versioning-enabled", "true")
+	c.Assert(acceptsImmutability(h), chk.Equals, true)
+}
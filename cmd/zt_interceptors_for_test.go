@@ -55,6 +55,8 @@ func (i *interceptor) intercept(cmd common.RpcCmd, request interface{}, response
 	case common.ERpcCmd.ResumeJob():
 	case common.ERpcCmd.GetJobFromTo():
 		fallthrough
+	case common.ERpcCmd.GetJobDetails():
+		fallthrough
 	default:
 		panic("RPC mock not implemented")
 	}
@@ -93,6 +95,12 @@ func (m *mockedLifecycleManager) Info(msg string) {
 	default:
 	}
 }
+func (m *mockedLifecycleManager) Output(o common.OutputBuilder) {
+	select {
+	case m.infoLog <- o(common.EOutputFormat.Text()):
+	default:
+	}
+}
 func (*mockedLifecycleManager) Prompt(message string, details common.PromptDetails) common.ResponseOption {
 	return common.EResponseOption.Default()
 }
@@ -122,8 +130,13 @@ func (*mockedLifecycleManager) GetEnvironmentVariable(env common.EnvironmentVari
 	return value
 }
 func (*mockedLifecycleManager) SetOutputFormat(common.OutputFormat) {}
-func (*mockedLifecycleManager) EnableInputWatcher()                 {}
-func (*mockedLifecycleManager) EnableCancelFromStdIn()              {}
+func (*mockedLifecycleManager) SetQuiet(bool)                       {}
+func (*mockedLifecycleManager) SetSummaryFile(string)               {}
+func (*mockedLifecycleManager) SetProgressSocket(string) error {
+	return nil
+}
+func (*mockedLifecycleManager) EnableInputWatcher()    {}
+func (*mockedLifecycleManager) EnableCancelFromStdIn() {}
 func (*mockedLifecycleManager) AddUserAgentPrefix(userAgent string) string {
 	return userAgent
 }
@@ -0,0 +1,117 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	chk "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+type jobDefinitionSuite struct{}
+
+var _ = chk.Suite(&jobDefinitionSuite{})
+
+func newJobDefinitionTestCmd(c *chk.C, flags ...string) *cobra.Command {
+	cmd := &cobra.Command{Use: "copy"}
+	cmd.Flags().Bool("recursive", false, "")
+	cmd.Flags().String("include-pattern", "", "")
+	cmd.Flags().String("log-level", "INFO", "")
+	cmd.Flags().StringArray("request-header", nil, "")
+	cmd.Flags().String(exportJobDefinitionFlag, "", "")
+	c.Assert(cmd.ParseFlags(flags), chk.IsNil)
+	return cmd
+}
+
+func (s *jobDefinitionSuite) TestJobDefinitionRoundTrip(c *chk.C) {
+	cmd := newJobDefinitionTestCmd(c, "--recursive", "--include-pattern=*.jpg", "--request-header=x-a: 1", "--request-header=x-b: 2", "--export-job-definition=job.yaml")
+	def, err := newJobDefinition(cmd, []string{"/data/photos", "https://acct.blob.core.windows.net/c/dir?sv=2019-12-12&sig=secret"})
+	c.Assert(err, chk.IsNil)
+
+	// the SAS is not saved, only the name of the variable to read it from, and nor is the flag that saved the definition
+	c.Assert(def.Source, chk.Equals, jobDefinitionResource{Location: "/data/photos"})
+	c.Assert(def.Destination, chk.Equals, jobDefinitionResource{Location: "https://acct.blob.core.windows.net/c/dir", SASFromEnv: destinationSASEnvVar})
+	c.Assert(def.Flags, chk.HasLen, 3) // log-level was not set, so the default is left to the run
+
+	content, err := yaml.Marshal(def)
+	c.Assert(err, chk.IsNil)
+	var read jobDefinition
+	c.Assert(yaml.UnmarshalStrict(content, &read), chk.IsNil)
+
+	env := map[string]string{destinationSASEnvVar: "?sv=2019-12-12&sig=secret"}
+	args, err := read.commandLine(func(name string) string { return env[name] })
+	c.Assert(err, chk.IsNil)
+	c.Assert(args, chk.DeepEquals, []string{"copy", "/data/photos", "https://acct.blob.core.windows.net/c/dir?sv=2019-12-12&sig=secret",
+		"--include-pattern=*.jpg", "--recursive=true", "--request-header=x-a: 1", "--request-header=x-b: 2"})
+}
+
+func (s *jobDefinitionSuite) TestJobDefinitionSavesAbsoluteLocalPaths(c *chk.C) {
+	wd, err := os.Getwd()
+	c.Assert(err, chk.IsNil)
+
+	// so that the job copies the same files wherever it's run from
+	r, err := newJobDefinitionResource(filepath.Join("photos", "2020"), sourceSASEnvVar)
+	c.Assert(err, chk.IsNil)
+	c.Assert(r, chk.Equals, jobDefinitionResource{Location: filepath.Join(wd, "photos", "2020")})
+
+	// a trailing separator is kept
+	r, err = newJobDefinitionResource("photos"+string(os.PathSeparator), sourceSASEnvVar)
+	c.Assert(err, chk.IsNil)
+	c.Assert(r.Location, chk.Equals, filepath.Join(wd, "photos")+string(os.PathSeparator))
+}
+
+func (s *jobDefinitionSuite) TestJobDefinitionNeedsSASInEnvironment(c *chk.C) {
+	def := jobDefinition{
+		Command:     "sync",
+		Source:      jobDefinitionResource{Location: "https://acct.blob.core.windows.net/c?snapshot=1", SASFromEnv: sourceSASEnvVar},
+		Destination: jobDefinitionResource{Location: "/data"},
+	}
+	_, err := def.commandLine(func(string) string { return "" })
+	c.Assert(err, chk.NotNil)
+	c.Assert(err.Error(), chk.Matches, ".*"+sourceSASEnvVar+".*not set.*")
+
+	args, err := def.commandLine(func(string) string { return "sig=secret" })
+	c.Assert(err, chk.IsNil)
+	c.Assert(args, chk.DeepEquals, []string{"sync", "https://acct.blob.core.windows.net/c?snapshot=1&sig=secret", "/data"})
+}
+
+func (s *jobDefinitionSuite) TestJobDefinitionRefusesInlineSAS(c *chk.C) {
+	def := jobDefinition{
+		Command:     "copy",
+		Source:      jobDefinitionResource{Location: "/data"},
+		Destination: jobDefinitionResource{Location: "https://acct.blob.core.windows.net/c?sv=2019-12-12&sig=secret"},
+	}
+	_, err := def.commandLine(func(string) string { return "" })
+	c.Assert(err, chk.NotNil)
+
+	def.Command = "remove"
+	def.Destination.Location = "https://acct.blob.core.windows.net/c"
+	_, err = def.commandLine(func(string) string { return "" })
+	c.Assert(err, chk.NotNil)
+
+	// nor is a SAS saved from the value of a flag
+	cmd := newJobDefinitionTestCmd(c, "--include-pattern=https://acct.blob.core.windows.net/c?sig=secret")
+	_, err = newJobDefinition(cmd, []string{"/data", "https://acct.blob.core.windows.net/c"})
+	c.Assert(err, chk.NotNil)
+}
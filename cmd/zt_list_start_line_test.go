@@ -0,0 +1,58 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	chk "gopkg.in/check.v1"
+)
+
+type listStartLineSuite struct{}
+
+var _ = chk.Suite(&listStartLineSuite{})
+
+func (s *listStartLineSuite) TestValidateListStartLine(c *chk.C) {
+	list, err := ioutil.TempFile("", "liststartline")
+	c.Assert(err, chk.IsNil)
+	defer os.Remove(list.Name())
+	defer list.Close()
+	_, err = list.WriteString("a\nb\nc\n")
+	c.Assert(err, chk.IsNil)
+
+	for _, startLine := range []int{0, 1, 2} {
+		_, err = list.Seek(0, 0)
+		c.Assert(err, chk.IsNil)
+		c.Assert(validateListStartLine(list, startLine), chk.IsNil)
+
+		// the list is left at its start, ready to be read
+		all, err := ioutil.ReadAll(list)
+		c.Assert(err, chk.IsNil)
+		c.Assert(string(all), chk.Equals, "a\nb\nc\n")
+	}
+
+	for _, startLine := range []int{-1, 3, 4} {
+		_, err = list.Seek(0, 0)
+		c.Assert(err, chk.IsNil)
+		c.Assert(validateListStartLine(list, startLine), chk.NotNil, chk.Commentf("%d", startLine))
+	}
+}
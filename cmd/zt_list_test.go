@@ -0,0 +1,104 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type listSuite struct{}
+
+var _ = chk.Suite(&listSuite{})
+
+func (s *listSuite) TestParseListColumns(c *chk.C) {
+	columns, err := parseListColumns("Tier, name,size,name")
+	c.Assert(err, chk.IsNil)
+	c.Assert(columns, chk.DeepEquals, []string{listColumnTier, listColumnName, listColumnSize})
+
+	_, err = parseListColumns("name,owner")
+	c.Assert(err, chk.NotNil)
+
+	_, err = parseListColumns(" , ")
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *listSuite) TestPropertiesAreReadOnlyWhenLastModifiedTimeIsNeeded(c *chk.C) {
+	c.Assert(ListParameters{Columns: defaultListColumns}.needsLastModifiedTime(), chk.Equals, false)
+	c.Assert(ListParameters{Columns: []string{listColumnName, listColumnTier}, SortBy: common.EListSortOption.Size()}.needsLastModifiedTime(), chk.Equals, false)
+
+	c.Assert(ListParameters{Columns: []string{listColumnName, listColumnMTime}}.needsLastModifiedTime(), chk.Equals, true)
+	c.Assert(ListParameters{Columns: defaultListColumns, SortBy: common.EListSortOption.MTime()}.needsLastModifiedTime(), chk.Equals, true)
+}
+
+func (s *listSuite) TestListEntryFormatting(c *chk.C) {
+	parameters = ListParameters{MachineReadable: true}
+	defer func() { parameters = ListParameters{} }()
+
+	lmt := time.Date(2020, 5, 1, 10, 30, 0, 0, time.UTC)
+	e := listEntry{path: "dir/a.txt", size: 2048, lastModified: lmt, tier: "Cool"}
+
+	c.Assert(e.text(defaultListColumns), chk.Equals, "dir/a.txt; Content Length: 2048")
+	c.Assert(e.text([]string{listColumnMTime, listColumnName, listColumnTier}), chk.Equals,
+		"Last Modified: 2020-05-01T10:30:00Z; dir/a.txt; Access Tier: Cool")
+
+	c.Assert(e.json(defaultListColumns), chk.Equals, `{"Path":"dir/a.txt","ContentLength":"2048"}`)
+	c.Assert(e.json([]string{listColumnMTime, listColumnTier}), chk.Equals,
+		`{"LastModifiedTime":"2020-05-01T10:30:00Z","BlobAccessTier":"Cool"}`)
+}
+
+func (s *listSuite) TestSortListEntries(c *chk.C) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := func() []listEntry {
+		return []listEntry{
+			{path: "c", size: 10, lastModified: t0.Add(time.Hour)},
+			{path: "a", size: 30, lastModified: t0},
+			{path: "b", size: 10, lastModified: t0.Add(2 * time.Hour)},
+		}
+	}
+	paths := func(es []listEntry) []string {
+		out := make([]string, len(es))
+		for i, e := range es {
+			out[i] = e.path
+		}
+		return out
+	}
+
+	es := entries()
+	sortListEntries(es, common.EListSortOption.Name(), false)
+	c.Assert(paths(es), chk.DeepEquals, []string{"a", "b", "c"})
+
+	// equal sizes fall back to the path
+	es = entries()
+	sortListEntries(es, common.EListSortOption.Size(), false)
+	c.Assert(paths(es), chk.DeepEquals, []string{"b", "c", "a"})
+
+	es = entries()
+	sortListEntries(es, common.EListSortOption.Size(), true)
+	c.Assert(paths(es), chk.DeepEquals, []string{"a", "c", "b"})
+
+	es = entries()
+	sortListEntries(es, common.EListSortOption.MTime(), true)
+	c.Assert(paths(es), chk.DeepEquals, []string{"b", "c", "a"})
+}
@@ -0,0 +1,108 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type listingErrorsSuite struct{}
+
+var _ = chk.Suite(&listingErrorsSuite{})
+
+var errTransientListing = &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+func (s *listingErrorsSuite) TestListPageRetriesTransientErrors(c *chk.C) {
+	t := &listingErrorTolerance{retries: 3}
+
+	calls := 0
+	err := t.listPage(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errTransientListing
+		}
+		return nil
+	})
+	c.Assert(err, chk.IsNil)
+	c.Assert(calls, chk.Equals, 3)
+
+	// retries run out
+	calls = 0
+	err = t.listPage(context.Background(), func() error { calls++; return errTransientListing })
+	c.Assert(err, chk.Equals, errTransientListing)
+	c.Assert(calls, chk.Equals, 4)
+
+	// errors that won't go away aren't retried
+	calls = 0
+	err = t.listPage(context.Background(), func() error { calls++; return errors.New("denied") })
+	c.Assert(err, chk.NotNil)
+	c.Assert(calls, chk.Equals, 1)
+
+	// nor is anything, without the tolerance
+	var off *listingErrorTolerance
+	calls = 0
+	err = off.listPage(context.Background(), func() error { calls++; return errTransientListing })
+	c.Assert(err, chk.Equals, errTransientListing)
+	c.Assert(calls, chk.Equals, 1)
+}
+
+func (s *listingErrorsSuite) TestSkipRecordsTransientErrors(c *chk.C) {
+	defer func(old common.LifecycleMgr) { glcm = old }(glcm)
+	mockedLcm := &mockedLifecycleManager{infoLog: make(chan string, 50)}
+	glcm = mockedLcm
+
+	t := newListingErrorTolerance()
+	marker := "2!80!MDAwMDE2"
+	c.Assert(t.skip("cont", "dir/", azblob.Marker{Val: &marker}, errTransientListing), chk.Equals, true)
+	c.Assert(t.skip("cont", "", azblob.Marker{}, errTransientListing), chk.Equals, true)
+	c.Assert(t.skip("cont", "other/", azblob.Marker{}, errors.New("denied")), chk.Equals, false)
+
+	var off *listingErrorTolerance
+	c.Assert(off.skip("cont", "dir/", azblob.Marker{}, errTransientListing), chk.Equals, false)
+
+	skipped := t.skippedRanges()
+	c.Assert(skipped, chk.HasLen, 2)
+	c.Assert(skipped[0].marker, chk.Equals, marker)
+	c.Assert(strings.HasPrefix(skipped[0].String(), "container cont, prefix 'dir/', from marker "+marker), chk.Equals, true)
+	c.Assert(strings.HasPrefix(skipped[1].String(), "container cont, prefix '', from the start"), chk.Equals, true)
+	c.Assert(len(mockedLcm.infoLog), chk.Equals, 2) // each skip is logged as it happens
+
+	report := formatSkippedListingRanges(skipped)
+	c.Assert(strings.HasPrefix(report, "2 part(s) of the source could not be listed"), chk.Equals, true)
+	c.Assert(strings.Count(report, "\n"), chk.Equals, 2)
+	c.Assert(formatSkippedListingRanges(nil), chk.Equals, "")
+}
+
+func (s *listingErrorsSuite) TestValidateContinueOnEnumerationError(c *chk.C) {
+	c.Assert(validateContinueOnEnumerationError(false, common.EFromTo.LocalBlob(), true, 0), chk.IsNil)
+	c.Assert(validateContinueOnEnumerationError(true, common.EFromTo.BlobLocal(), false, 0), chk.IsNil)
+	c.Assert(validateContinueOnEnumerationError(true, common.EFromTo.BlobBlob(), false, 0), chk.IsNil)
+	c.Assert(validateContinueOnEnumerationError(true, common.EFromTo.FileLocal(), false, 0), chk.NotNil)
+	c.Assert(validateContinueOnEnumerationError(true, common.EFromTo.BlobLocal(), true, 0), chk.NotNil)
+	c.Assert(validateContinueOnEnumerationError(true, common.EFromTo.BlobLocal(), false, 4), chk.NotNil)
+}
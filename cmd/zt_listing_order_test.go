@@ -0,0 +1,103 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type listingOrderSuite struct{}
+
+var _ = chk.Suite(&listingOrderSuite{})
+
+func (s *listingOrderSuite) TestPositionsFollowTheListing(c *chk.C) {
+	dir, err := ioutil.TempDir("", "listingorder")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"c.txt", "a.txt", "b.txt"} {
+		c.Assert(ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644), chk.IsNil)
+	}
+	listed, err := readDirInListingOrder(dir)
+	c.Assert(err, chk.IsNil)
+
+	order := newListingOrder(dir)
+	for i, name := range listed {
+		transfer := common.CopyTransfer{Metadata: common.Metadata{"owner": "me"}}
+		order.apply(&transfer, storedObject{name: name, relativePath: name, entityType: common.EEntityType.File()})
+		c.Assert(transfer.Metadata[listingOrderMetadataKey], chk.Equals, strconv.Itoa(i))
+		c.Assert(transfer.Metadata["owner"], chk.Equals, "me")
+	}
+
+	// a file that has gone since the directory was read is left without a position
+	transfer := common.CopyTransfer{}
+	order.apply(&transfer, storedObject{name: "gone.txt", relativePath: "gone.txt", entityType: common.EEntityType.File()})
+	c.Assert(transfer.Metadata, chk.IsNil)
+}
+
+func (s *listingOrderSuite) TestCreateInListingOrder(c *chk.C) {
+	dir, err := ioutil.TempDir("", "listingorder")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	sub := filepath.Join(dir, "sub")
+	c.Assert(os.MkdirAll(sub, os.ModePerm), chk.IsNil)
+	existing := filepath.Join(sub, "existing.txt")
+	c.Assert(ioutil.WriteFile(existing, []byte("keep"), 0644), chk.IsNil)
+
+	created, err := createInListingOrder(map[string][]listedFile{
+		dir:                          {{path: filepath.Join(dir, "second.txt"), position: 1}, {path: filepath.Join(dir, "first.txt"), position: 0}},
+		sub:                          {{path: existing, position: 0}, {path: filepath.Join(sub, "new.txt"), position: 1}},
+		filepath.Join(dir, "deeper"): {{path: filepath.Join(dir, "deeper", "only.txt"), position: 0}},
+	})
+	c.Assert(err, chk.IsNil)
+	c.Assert(created, chk.Equals, 4)
+
+	for _, name := range []string{"first.txt", "second.txt", filepath.Join("sub", "new.txt"), filepath.Join("deeper", "only.txt")} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		c.Assert(err, chk.IsNil)
+		c.Assert(info.Size(), chk.Equals, int64(0))
+	}
+	data, err := ioutil.ReadFile(existing)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(data), chk.Equals, "keep")
+}
+
+func (s *listingOrderSuite) TestValidatePreserveListingOrder(c *chk.C) {
+	cooked := cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal(), forceWrite: common.EOverwriteOption.True()}
+	c.Assert(validatePreserveListingOrder(true, cooked), chk.IsNil)
+
+	cooked.forceWrite = common.EOverwriteOption.IfSourceNewer()
+	c.Assert(validatePreserveListingOrder(true, cooked), chk.NotNil)
+	c.Assert(validatePreserveListingOrder(false, cooked), chk.IsNil)
+
+	cooked = cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlobFS()}
+	c.Assert(validatePreserveListingOrder(true, cooked), chk.NotNil)
+
+	cooked = cookedCopyCmdArgs{fromTo: common.EFromTo.LocalFile(), source: common.ResourceString{Value: "/data/*.txt"}}
+	c.Assert(validatePreserveListingOrder(true, cooked), chk.NotNil)
+	cooked.source = common.ResourceString{Value: "/data"}
+	c.Assert(validatePreserveListingOrder(true, cooked), chk.IsNil)
+}
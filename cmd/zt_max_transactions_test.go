@@ -0,0 +1,39 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type maxTransactionsSuite struct{}
+
+var _ = chk.Suite(&maxTransactionsSuite{})
+
+func (s *maxTransactionsSuite) TestFormatTransactionStats(c *chk.C) {
+	jobID := common.NewJobID()
+	c.Assert(formatTransactionStats(common.ListJobSummaryResponse{JobID: jobID}), chk.Equals, "")
+	c.Assert(formatTransactionStats(common.ListJobSummaryResponse{JobID: jobID, TransactionsIssued: 40, MaxTransactions: 100}), chk.Equals,
+		"\nTransactions Issued: 40 of 100")
+	c.Assert(formatTransactionStats(common.ListJobSummaryResponse{JobID: jobID, TransactionsIssued: 100, MaxTransactions: 100}), chk.Equals,
+		"\nTransactions Issued: 100 of 100 (Limit Reached). Run 'azcopy jobs resume "+jobID.String()+"' to transfer the rest")
+}
@@ -0,0 +1,116 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type maxTransfersSuite struct{}
+
+var _ = chk.Suite(&maxTransfersSuite{})
+
+func (s *maxTransfersSuite) TestSymlinkedDirectoryIsNotWalkedPastTheLimit(c *chk.C) {
+	tmpDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(tmpDir)
+	linkedDir := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(linkedDir)
+	scenarioHelper{}.generateLocalFilesFromList(c, tmpDir, []string{"a.txt"})
+	scenarioHelper{}.generateLocalFilesFromList(c, linkedDir, []string{"c.txt"})
+	trySymlink(linkedDir, filepath.Join(tmpDir, "link"), c)
+
+	// as the copy processor does once the limit has been reached: whatever else is found is ignored, including the
+	// symlinked directory, which then isn't walked. The root comes first
+	var seen []string
+	err := WalkWithSymlinks(tmpDir, func(path string, fi os.FileInfo, err error) error {
+		c.Assert(err, chk.IsNil)
+		seen = append(seen, fi.Name())
+		if len(seen) == 1 {
+			return nil
+		}
+		return ignoredError
+	}, true)
+	c.Assert(err, chk.IsNil)
+	c.Assert(seen[1:], chk.HasLen, 2)
+	for _, name := range seen {
+		c.Assert(name, chk.Not(chk.Equals), "c.txt")
+	}
+}
+
+func (s *maxTransfersSuite) TestEnumerationLimitFinalizesJob(c *chk.C) {
+	inner := &fixedTraverser{objects: []storedObject{
+		{name: "a", relativePath: "a", entityType: common.EEntityType.File()},
+		{name: "b", relativePath: "b", entityType: common.EEntityType.File()},
+		{name: "c", relativePath: "c", entityType: common.EEntityType.File()},
+	}}
+
+	var processed []string
+	finalized := false
+	e := newCopyEnumerator(inner, nil, func(o storedObject) error {
+		processed = append(processed, o.relativePath)
+		if len(processed) == 2 {
+			return enumerationLimitReached
+		}
+		return nil
+	}, func() error {
+		finalized = true
+		return nil
+	})
+
+	c.Assert(e.enumerate(), chk.IsNil)
+	c.Assert(processed, chk.DeepEquals, []string{"a", "b"})
+	c.Assert(finalized, chk.Equals, true)
+
+	// other errors still fail the enumeration, without finalizing
+	finalized = false
+	e.objectDispatcher = func(storedObject) error { return errors.New("boom") }
+	c.Assert(e.enumerate(), chk.NotNil)
+	c.Assert(finalized, chk.Equals, false)
+}
+
+func (s *maxTransfersSuite) TestListTraverserStopsAtLimit(c *chk.C) {
+	listChan := make(chan string, 3)
+	listChan <- "one"
+	listChan <- "two"
+	listChan <- "three"
+	close(listChan)
+
+	visited := 0
+	t := &listTraverser{
+		listReader: listChan,
+		recursive:  true,
+		childTraverserGenerator: func(childPath string) (resourceTraverser, error) {
+			return &fixedTraverser{objects: []storedObject{{name: childPath, entityType: common.EEntityType.File()}}}, nil
+		},
+	}
+	err := t.traverse(noPreProccessor, func(storedObject) error {
+		visited++
+		return enumerationLimitReached
+	}, nil)
+	c.Assert(err, chk.Equals, enumerationLimitReached)
+	c.Assert(visited, chk.Equals, 1)
+}
@@ -0,0 +1,92 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type metadataManifestSuite struct{}
+
+var _ = chk.Suite(&metadataManifestSuite{})
+
+func (s *metadataManifestSuite) TestManifestEntriesAreAppliedPerFile(c *chk.C) {
+	dir, err := ioutil.TempDir("", "metadatamanifest")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "manifest.json")
+	c.Assert(ioutil.WriteFile(fileName, []byte(`{
+		"images/cat.jpg": {"animal": "cat"},
+		"/top.txt": {"level": "top"},
+		"bad/number.txt": {"count": 1},
+		"bad/list.txt": ["a"],
+		"bad/key.txt": {"not-valid": "x"},
+		"dup.txt": {"a": "1"},
+		"/dup.txt": {"a": "2"}
+	}`), 0644), chk.IsNil)
+
+	m, err := loadMetadataManifest(fileName)
+	c.Assert(err, chk.IsNil)
+
+	file := func(relativePath string) storedObject {
+		return storedObject{name: filepath.Base(relativePath), relativePath: relativePath, entityType: common.EEntityType.File()}
+	}
+
+	transfer := common.CopyTransfer{}
+	c.Assert(m.apply(&transfer, file("images/cat.jpg")), chk.Equals, true)
+	c.Assert(transfer.Metadata, chk.DeepEquals, common.Metadata{"animal": "cat"})
+
+	transfer = common.CopyTransfer{}
+	c.Assert(m.apply(&transfer, file("top.txt")), chk.Equals, true)
+	c.Assert(transfer.Metadata, chk.DeepEquals, common.Metadata{"level": "top"})
+
+	// files without an entry keep the job's metadata
+	transfer = common.CopyTransfer{}
+	c.Assert(m.apply(&transfer, file("images/dog.jpg")), chk.Equals, true)
+	c.Assert(transfer.Metadata, chk.IsNil)
+
+	// malformed entries fail just their own files
+	for _, bad := range []string{"bad/number.txt", "bad/list.txt", "bad/key.txt", "dup.txt"} {
+		c.Assert(m.apply(&common.CopyTransfer{}, file(bad)), chk.Equals, false, chk.Commentf(bad))
+	}
+	c.Assert(m.malformedCount(), chk.Equals, uint32(4))
+}
+
+func (s *metadataManifestSuite) TestUnreadableManifest(c *chk.C) {
+	dir, err := ioutil.TempDir("", "metadatamanifest")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "manifest.json")
+	c.Assert(ioutil.WriteFile(fileName, []byte(`[{"images/cat.jpg": {"animal": "cat"}}]`), 0644), chk.IsNil)
+	_, err = loadMetadataManifest(fileName)
+	c.Assert(err, chk.NotNil)
+
+	_, err = loadMetadataManifest(filepath.Join(dir, "missing.json"))
+	c.Assert(err, chk.NotNil)
+}
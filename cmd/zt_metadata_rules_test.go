@@ -0,0 +1,103 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type metadataRulesSuite struct{}
+
+var _ = chk.Suite(&metadataRulesSuite{})
+
+func (s *metadataRulesSuite) TestFirstMatchWins(c *chk.C) {
+	dir, err := ioutil.TempDir("", "metadatarules")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "rules.yaml")
+	err = ioutil.WriteFile(fileName, []byte(`
+- pattern: "assets/*"
+  cacheControl: "public, max-age=31536000"
+- pattern: "*.html"
+  contentType: text/html
+  cacheControl: no-cache
+  contentLanguage: en-US
+- pattern: "*"
+  cacheControl: max-age=60
+`), 0644)
+	c.Assert(err, chk.IsNil)
+
+	rules, err := loadMetadataRules(fileName)
+	c.Assert(err, chk.IsNil)
+	c.Assert(rules, chk.HasLen, 3)
+
+	c.Assert(rules.match("index.html", "index.html").CacheControl, chk.Equals, "no-cache")
+	c.Assert(rules.match("app.html", "assets/app.html").CacheControl, chk.Equals, "public, max-age=31536000")
+	c.Assert(rules.match("readme.txt", "docs/readme.txt").CacheControl, chk.Equals, "max-age=60")
+
+	// explicit flags win over the rule
+	cca := &cookedCopyCmdArgs{cacheControl: "private"}
+	transfer := common.CopyTransfer{}
+	object := storedObject{name: "index.html", relativePath: "index.html", entityType: common.EEntityType.File()}
+	rules.apply(&transfer, object, cca)
+	c.Assert(transfer.ContentType, chk.Equals, "text/html")
+	c.Assert(transfer.CacheControl, chk.Equals, "")
+	c.Assert(transfer.ContentLanguage, chk.Equals, "en-US")
+}
+
+func (s *metadataRulesSuite) TestInvalidRules(c *chk.C) {
+	dir, err := ioutil.TempDir("", "metadatarules")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "rules.json")
+	c.Assert(ioutil.WriteFile(fileName, []byte(`[{"pattern": "*.css"}]`), 0644), chk.IsNil)
+	_, err = loadMetadataRules(fileName)
+	c.Assert(err, chk.NotNil)
+
+	c.Assert(ioutil.WriteFile(fileName, []byte(`[{"pattern": "[", "contentType": "text/css"}]`), 0644), chk.IsNil)
+	_, err = loadMetadataRules(fileName)
+	c.Assert(err, chk.NotNil)
+
+	c.Assert(ioutil.WriteFile(fileName, []byte(`[{"pattern": "*.fr.html", "contentLanguage": "french"}]`), 0644), chk.IsNil)
+	_, err = loadMetadataRules(fileName)
+	c.Assert(err, chk.IsNil)
+
+	c.Assert(ioutil.WriteFile(fileName, []byte(`[{"pattern": "*.fr.html", "contentLanguage": "fr_FR"}]`), 0644), chk.IsNil)
+	_, err = loadMetadataRules(fileName)
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *metadataRulesSuite) TestValidateContentLanguage(c *chk.C) {
+	for _, valid := range []string{"", "en", "en-US", "zh-Hant-TW", "de-DE, en-CA", "es-419"} {
+		c.Assert(validateContentLanguage(valid), chk.IsNil, chk.Commentf(valid))
+	}
+	for _, invalid := range []string{"en_US", "portuguese-BR", "1en", "en-", "en,,fr", "-US"} {
+		c.Assert(validateContentLanguage(invalid), chk.NotNil, chk.Commentf(invalid))
+	}
+}
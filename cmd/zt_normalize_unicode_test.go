@@ -0,0 +1,187 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type normalizeUnicodeSuite struct{}
+
+var _ = chk.Suite(&normalizeUnicodeSuite{})
+
+// fixedTraverser hands a fixed list of objects to its processor, after applying the filters like a real traverser
+type fixedTraverser struct {
+	objects []storedObject
+}
+
+func (t *fixedTraverser) isDirectory(bool) bool { return true }
+
+func (t *fixedTraverser) traverse(_ objectMorpher, processor objectProcessor, filters []objectFilter) error {
+	for _, o := range t.objects {
+		if err := processIfPassedFilters(filters, o, processor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorDroppingTraverser drops the errors of the processor, as most traversers do with getProcessingError
+type errorDroppingTraverser struct {
+	*fixedTraverser
+}
+
+func (t *errorDroppingTraverser) traverse(_ objectMorpher, processor objectProcessor, filters []objectFilter) error {
+	for _, o := range t.objects {
+		_, _ = getProcessingError(processIfPassedFilters(filters, o, processor))
+	}
+	return nil
+}
+
+func (s *normalizeUnicodeSuite) TestNormalizingTraverser(c *chk.C) {
+	composed := "dir/café.txt"
+	decomposed := norm.NFD.String("dir/crème.txt")
+	c.Assert(decomposed, chk.Not(chk.Equals), "dir/crème.txt")
+
+	inner := &fixedTraverser{objects: []storedObject{
+		{name: "café.txt", relativePath: composed, entityType: common.EEntityType.File()},
+		{name: norm.NFD.String("crème.txt"), relativePath: decomposed, entityType: common.EEntityType.File()},
+	}}
+
+	// none leaves the traverser alone
+	c.Assert(newUnicodeNormalizingTraverser(inner, common.EUnicodeNormalization.None()), chk.Equals, inner)
+
+	var found []storedObject
+	t := newUnicodeNormalizingTraverser(inner, common.EUnicodeNormalization.NFC())
+	err := t.traverse(noPreProccessor, func(o storedObject) error {
+		found = append(found, o)
+		return nil
+	}, nil)
+	c.Assert(err, chk.IsNil)
+	c.Assert(found, chk.HasLen, 2)
+
+	// an already-normalized name is untouched
+	c.Assert(found[0].relativePath, chk.Equals, composed)
+	c.Assert(found[0].addressableRelativePath(), chk.Equals, composed)
+
+	// the other is renamed, but can still be found by its original name
+	c.Assert(found[1].relativePath, chk.Equals, "dir/crème.txt")
+	c.Assert(found[1].name, chk.Equals, "crème.txt")
+	c.Assert(found[1].addressableRelativePath(), chk.Equals, decomposed)
+}
+
+func (s *normalizeUnicodeSuite) TestNamesThatCollideOnceNormalizedFailTheTraversal(c *chk.C) {
+	composed := "dir/café.txt"
+	decomposed := norm.NFD.String(composed)
+	inner := &fixedTraverser{objects: []storedObject{
+		{name: "café.txt", relativePath: composed, entityType: common.EEntityType.File()},
+		{name: "other.txt", relativePath: "dir/other.txt", entityType: common.EEntityType.File()},
+		{name: norm.NFD.String("café.txt"), relativePath: decomposed, entityType: common.EEntityType.File()},
+	}}
+
+	count := 0
+	err := newUnicodeNormalizingTraverser(inner, common.EUnicodeNormalization.NFC()).traverse(noPreProccessor, func(o storedObject) error {
+		count++
+		return nil
+	}, nil)
+	c.Assert(err, chk.NotNil)
+	c.Assert(err.Error(), chk.Matches, ".*same once normalized to NFC.*")
+	c.Assert(count, chk.Equals, 2)
+
+	// most traversers don't pass the errors of the processor back, but the collision still fails the traversal
+	count = 0
+	err = newUnicodeNormalizingTraverser(&errorDroppingTraverser{inner}, common.EUnicodeNormalization.NFC()).traverse(noPreProccessor, func(o storedObject) error {
+		count++
+		return nil
+	}, nil)
+	c.Assert(err, chk.NotNil)
+	c.Assert(count, chk.Equals, 2)
+
+	// without normalization, they are different names
+	count = 0
+	err = newUnicodeNormalizingTraverser(inner, common.EUnicodeNormalization.None()).traverse(noPreProccessor, func(o storedObject) error {
+		count++
+		return nil
+	}, nil)
+	c.Assert(err, chk.IsNil)
+	c.Assert(count, chk.Equals, 3)
+}
+
+func (s *normalizeUnicodeSuite) TestSyncComparesNormalizedNames(c *chk.C) {
+	// the destination was written by a system that uses precomposed names, and the source is on macOS, which decomposes them
+	now := time.Now()
+	destination := &fixedTraverser{objects: []storedObject{{name: "café.txt", relativePath: "dir/café.txt", lastModifiedTime: now, entityType: common.EEntityType.File()}}}
+	source := &fixedTraverser{objects: []storedObject{{name: norm.NFD.String("café.txt"), relativePath: norm.NFD.String("dir/café.txt"),
+		lastModifiedTime: now.Add(-time.Hour), entityType: common.EEntityType.File()}}}
+
+	sync := func(normalization common.UnicodeNormalization) (scheduled []storedObject, deleted []storedObject) {
+		copyScheduler, cleaner := dummyProcessor{}, dummyProcessor{}
+		indexer := newObjectIndexer()
+		c.Assert(newUnicodeNormalizingTraverser(destination, normalization).traverse(noPreProccessor, indexer.store, nil), chk.IsNil)
+		comparator := newSyncSourceComparator(indexer, copyScheduler.process)
+		c.Assert(newUnicodeNormalizingTraverser(source, normalization).traverse(noPreProccessor, comparator.processIfNecessary, nil), chk.IsNil)
+		c.Assert(indexer.traverse(cleaner.process, nil), chk.IsNil)
+		return copyScheduler.record, cleaner.record
+	}
+
+	// the source is older than the file at the destination, so with both names normalized, there's nothing to do
+	scheduled, deleted := sync(common.EUnicodeNormalization.NFC())
+	c.Assert(scheduled, chk.HasLen, 0)
+	c.Assert(deleted, chk.HasLen, 0)
+
+	// otherwise they look like different files, so the source is copied, and a mirroring sync would delete the destination
+	scheduled, deleted = sync(common.EUnicodeNormalization.None())
+	c.Assert(scheduled, chk.HasLen, 1)
+	c.Assert(deleted, chk.HasLen, 1)
+}
+
+func (s *normalizeUnicodeSuite) TestNormalizingTraverserFiltersOriginalNames(c *chk.C) {
+	decomposed := norm.NFD.String("café.txt")
+	inner := &fixedTraverser{objects: []storedObject{{name: decomposed, relativePath: decomposed, entityType: common.EEntityType.File()}}}
+
+	// filters run before normalization, so they see the names as they really are
+	var filtered []string
+	filter := &testNameRecorder{seen: &filtered}
+	count := 0
+	err := newUnicodeNormalizingTraverser(inner, common.EUnicodeNormalization.NFC()).traverse(noPreProccessor, func(o storedObject) error {
+		count++
+		return nil
+	}, []objectFilter{filter})
+	c.Assert(err, chk.IsNil)
+	c.Assert(count, chk.Equals, 1)
+	c.Assert(filtered, chk.DeepEquals, []string{decomposed})
+}
+
+type testNameRecorder struct {
+	seen *[]string
+}
+
+func (f *testNameRecorder) doesSupportThisOS() (msg string, supported bool) { return "", true }
+func (f *testNameRecorder) appliesOnlyToFiles() bool                        { return false }
+func (f *testNameRecorder) doesPass(o storedObject) bool {
+	*f.seen = append(*f.seen, o.relativePath)
+	return true
+}
@@ -0,0 +1,78 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type partitionBySuite struct{}
+
+var _ = chk.Suite(&partitionBySuite{})
+
+func (s *partitionBySuite) TestParsePartitionBy(c *chk.C) {
+	for _, ok := range []string{"mtime:daily", "upload-time:hourly", "MTime:Monthly"} {
+		_, err := newPartitionBy(ok, time.Now())
+		c.Assert(err, chk.IsNil, chk.Commentf(ok))
+	}
+	for _, bad := range []string{"", "mtime", "daily", "ctime:daily", "mtime:weekly", "mtime:daily:x"} {
+		_, err := newPartitionBy(bad, time.Now())
+		c.Assert(err, chk.NotNil, chk.Commentf(bad))
+	}
+}
+
+func (s *partitionBySuite) TestApplyPartitionBy(c *chk.C) {
+	uploadTime := time.Date(2021, time.March, 7, 23, 0, 0, 0, time.UTC)
+	// 01:30 on the 1st of January in UTC+2 is still the previous year in UTC
+	mtime := time.Date(2020, time.January, 1, 1, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	object := storedObject{name: "app.log", relativePath: "logs/app.log", lastModifiedTime: mtime}
+
+	for value, expected := range map[string]string{
+		"mtime:hourly":        "/year=2019/month=12/day=31/hour=23/src/logs/app.log",
+		"mtime:daily":         "/year=2019/month=12/day=31/src/logs/app.log",
+		"mtime:monthly":       "/year=2019/month=12/src/logs/app.log",
+		"upload-time:daily":   "/year=2021/month=03/day=07/src/logs/app.log",
+		"upload-time:monthly": "/year=2021/month=03/src/logs/app.log",
+	} {
+		p, err := newPartitionBy(value, uploadTime)
+		c.Assert(err, chk.IsNil)
+		c.Assert(p.apply("/src/logs/app.log", object), chk.Equals, expected, chk.Commentf(value))
+	}
+
+	// the destination container stays first, when there is one
+	p, err := newPartitionBy("mtime:monthly", uploadTime)
+	c.Assert(err, chk.IsNil)
+	object.dstContainerName = "dst"
+	c.Assert(p.apply("/dst/logs/app.log", object), chk.Equals, "/dst/year=2019/month=12/logs/app.log")
+}
+
+func (s *partitionBySuite) TestValidatePartitionBy(c *chk.C) {
+	c.Assert(validatePartitionBy("", cookedCopyCmdArgs{fromTo: common.EFromTo.BlobLocal()}), chk.IsNil)
+	c.Assert(validatePartitionBy("mtime:daily", cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob()}), chk.IsNil)
+
+	c.Assert(validatePartitionBy("mtime:weekly", cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob()}), chk.NotNil)
+	c.Assert(validatePartitionBy("mtime:daily", cookedCopyCmdArgs{fromTo: common.EFromTo.BlobBlob()}), chk.NotNil)
+	c.Assert(validatePartitionBy("mtime:daily", cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), destTemplate: "{name}"}), chk.NotNil)
+	c.Assert(validatePrecreateDirectories(true, cookedCopyCmdArgs{fromTo: common.EFromTo.LocalBlob(), partitionBy: "mtime:daily"}), chk.NotNil)
+}
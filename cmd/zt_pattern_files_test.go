@@ -0,0 +1,68 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+type patternFilesSuite struct{}
+
+var _ = chk.Suite(&patternFilesSuite{})
+
+func (s *patternFilesSuite) TestReadPatternFile(c *chk.C) {
+	dir, err := ioutil.TempDir("", "patternfiles")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "patterns.txt")
+	contents := "# build outputs\n*.obj\n\n   \nbin*\r\n  # indented comment\nname;with;semicolons\n"
+	c.Assert(ioutil.WriteFile(path, []byte(contents), 0644), chk.IsNil)
+
+	patterns, err := readPatternFile(path)
+	c.Assert(err, chk.IsNil)
+	c.Assert(patterns, chk.DeepEquals, []string{"*.obj", "bin*", "name;with;semicolons"})
+}
+
+func (s *patternFilesSuite) TestAddPatternsFromFile(c *chk.C) {
+	dir, err := ioutil.TempDir("", "patternfiles")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "patterns.txt")
+	c.Assert(ioutil.WriteFile(path, []byte("*.tmp\n"), 0644), chk.IsNil)
+
+	// the patterns in the file are applied alongside those given inline
+	patterns, err := addPatternsFromFile([]string{"*.log"}, path, "exclude-from")
+	c.Assert(err, chk.IsNil)
+	c.Assert(patterns, chk.DeepEquals, []string{"*.log", "*.tmp"})
+
+	patterns, err = addPatternsFromFile([]string{"*.log"}, "", "exclude-from")
+	c.Assert(err, chk.IsNil)
+	c.Assert(patterns, chk.DeepEquals, []string{"*.log"})
+
+	_, err = addPatternsFromFile(nil, filepath.Join(dir, "missing.txt"), "exclude-from")
+	c.Assert(err, chk.ErrorMatches, "cannot read the patterns for exclude-from from .*")
+}
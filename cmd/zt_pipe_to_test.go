@@ -0,0 +1,111 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type pipeToSuite struct{}
+
+var _ = chk.Suite(&pipeToSuite{})
+
+// slowBlocks returns the blocks of content, each after a random delay, so that they finish out of order
+func slowBlocks(content []byte) func(ctx context.Context, offset, count int64) ([]byte, error) {
+	return func(ctx context.Context, offset, count int64) ([]byte, error) {
+		time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+		return content[offset : offset+count], nil
+	}
+}
+
+func (s *pipeToSuite) TestDownloadInOrder(c *chk.C) {
+	content := make([]byte, 1000)
+	rand.Read(content)
+
+	var out bytes.Buffer
+	c.Assert(downloadInOrder(context.Background(), int64(len(content)), 64, 5, slowBlocks(content), &out), chk.IsNil)
+	c.Assert(out.Bytes(), chk.DeepEquals, content)
+
+	// an empty blob has no blocks
+	out.Reset()
+	c.Assert(downloadInOrder(context.Background(), 0, 64, 5, slowBlocks(nil), &out), chk.IsNil)
+	c.Assert(out.Len(), chk.Equals, 0)
+}
+
+func (s *pipeToSuite) TestDownloadInOrderStopsAtFailedBlock(c *chk.C) {
+	content := make([]byte, 1000)
+	failure := errors.New("block failed")
+	download := func(ctx context.Context, offset, count int64) ([]byte, error) {
+		if offset == 256 {
+			return nil, failure
+		}
+		return slowBlocks(content)(ctx, offset, count)
+	}
+
+	var out bytes.Buffer
+	c.Assert(downloadInOrder(context.Background(), int64(len(content)), 64, 5, download, &out), chk.Equals, failure)
+	c.Assert(out.Len(), chk.Equals, 256) // only the blocks before the failed one were written
+}
+
+func (s *pipeToSuite) TestPipeToCommand(c *chk.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("not running since the commands are for sh")
+	}
+	dir, err := ioutil.TempDir("", "pipeto")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	outPath := filepath.Join(dir, "out")
+
+	write := func(w io.Writer) error {
+		_, err := w.Write([]byte("hello"))
+		return err
+	}
+	c.Assert(pipeToCommand("cat > "+outPath, write), chk.IsNil)
+	written, err := ioutil.ReadFile(outPath)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(written), chk.Equals, "hello")
+
+	// the command's exit status decides whether it succeeded
+	c.Assert(pipeToCommand("cat > /dev/null; exit 3", write), chk.ErrorMatches, ".*exited with status 3.*")
+
+	// a failed download fails, whatever the command does with what it was given
+	failure := errors.New("download failed")
+	c.Assert(pipeToCommand("cat > /dev/null", func(w io.Writer) error { return failure }), chk.Equals, failure)
+}
+
+func (s *pipeToSuite) TestValidatePipeTo(c *chk.C) {
+	c.Assert(validatePipeTo("", common.EFromTo.BlobLocal()), chk.IsNil)
+	c.Assert(validatePipeTo("gzip -d > out", common.EFromTo.BlobPipe()), chk.IsNil)
+	c.Assert(validatePipeTo("gzip -d > out", common.EFromTo.BlobLocal()), chk.NotNil)
+}
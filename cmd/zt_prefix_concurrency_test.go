@@ -0,0 +1,59 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"strings"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-azcopy/ste"
+)
+
+type prefixConcurrencySuite struct{}
+
+var _ = chk.Suite(&prefixConcurrencySuite{})
+
+func (s *prefixConcurrencySuite) TestParsePrefixConcurrencyNeedsRemoteDestination(c *chk.C) {
+	limits, err := parsePrefixConcurrency("", common.EFromTo.BlobLocal())
+	c.Assert(err, chk.IsNil)
+	c.Assert(limits, chk.IsNil)
+
+	_, err = parsePrefixConcurrency("logs/=10", common.EFromTo.BlobLocal())
+	c.Assert(err, chk.NotNil)
+
+	limits, err = parsePrefixConcurrency("logs/=10,data/=100", common.EFromTo.LocalBlob())
+	c.Assert(err, chk.IsNil)
+	c.Assert(limits, chk.DeepEquals, map[string]int{"logs/": 10, "data/": 100})
+
+	// the limits must fit in the job's plan, where they're kept for when it's resumed
+	_, err = parsePrefixConcurrency(strings.Repeat("a", ste.PrefixConcurrencyMaxBytes)+"/=10", common.EFromTo.LocalBlob())
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *prefixConcurrencySuite) TestFormatPrefixInFlight(c *chk.C) {
+	c.Assert(formatPrefixInFlight(common.ListJobSummaryResponse{}), chk.Equals, "")
+	c.Assert(formatPrefixInFlight(common.ListJobSummaryResponse{TransfersInFlightByPrefix: []common.PrefixTransfersInFlight{
+		{Prefix: "data/", Limit: 100, InFlight: 37},
+		{Prefix: "logs/", Limit: 10, InFlight: 10, Waiting: 52},
+	}}), chk.Equals, " (In flight: data/ 37/100, logs/ 10/10 +52 waiting)")
+}
@@ -0,0 +1,45 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type priorityPatternSuite struct{}
+
+var _ = chk.Suite(&priorityPatternSuite{})
+
+func (s *priorityPatternSuite) TestTransferPriority(c *chk.C) {
+	patterns := []string{"index.json", "*.idx"}
+	file := func(name string) storedObject {
+		return storedObject{name: name, relativePath: "dir/" + name, entityType: common.EEntityType.File()}
+	}
+
+	c.Assert(transferPriority(patterns, file("index.json")), chk.Equals, common.EJobPriority.High())
+	c.Assert(transferPriority(patterns, file("data.idx")), chk.Equals, common.EJobPriority.High())
+	c.Assert(transferPriority(patterns, file("data.bin")), chk.Equals, common.EJobPriority.Normal())
+
+	// without patterns, nothing has priority, and folders never do
+	c.Assert(transferPriority(nil, file("index.json")), chk.Equals, common.EJobPriority.Normal())
+	folder := storedObject{name: "index.json", entityType: common.EEntityType.Folder()}
+	c.Assert(transferPriority(patterns, folder), chk.Equals, common.EJobPriority.Normal())
+}
@@ -0,0 +1,71 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type requestHeadersSuite struct{}
+
+var _ = chk.Suite(&requestHeadersSuite{})
+
+func (s *requestHeadersSuite) TestParseRequestHeaders(c *chk.C) {
+	headers, err := parseRequestHeaders([]string{"X-Route: team-a", "x-tag:one", "X-Tag: two, three"})
+	c.Assert(err, chk.IsNil)
+	c.Assert(headers.Get("X-Route"), chk.Equals, "team-a")
+	c.Assert(headers["X-Tag"], chk.DeepEquals, []string{"one", "two, three"})
+
+	headers, err = parseRequestHeaders(nil)
+	c.Assert(err, chk.IsNil)
+	c.Assert(headers, chk.IsNil)
+}
+
+func (s *requestHeadersSuite) TestParseRequestHeadersRejectsReservedAndInvalid(c *chk.C) {
+	for _, raw := range []string{
+		"Authorization: Bearer abc",
+		"authorization: Bearer abc",
+		"Content-Length: 0",
+		"x-ms-version: 2019-12-12",
+		"X-MS-Client-Request-ID: abc",
+		"X-Route",
+		": no-name",
+		"X Route: team-a",
+		"X-Route: team-a\r\nAuthorization: abc",
+	} {
+		_, err := parseRequestHeaders([]string{raw})
+		c.Assert(err, chk.NotNil, chk.Commentf("%q", raw))
+	}
+}
+
+func (s *requestHeadersSuite) TestParseRequestHeadersRejectsConnectionHeaders(c *chk.C) {
+	// these would change how the request body is sent, which the pipeline and the service must agree on
+	for _, raw := range []string{
+		"Connection: close",
+		"Transfer-Encoding: chunked",
+		"transfer-encoding: chunked",
+		"Expect: 100-continue",
+	} {
+		_, err := parseRequestHeaders([]string{raw})
+		c.Assert(err, chk.NotNil, chk.Commentf("%q", raw))
+		c.Assert(err.Error(), chk.Matches, ".*reserved.*")
+	}
+}
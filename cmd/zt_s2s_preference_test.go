@@ -0,0 +1,60 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type s2sPreferenceSuite struct{}
+
+var _ = chk.Suite(&s2sPreferenceSuite{})
+
+func (s *s2sPreferenceSuite) TestValidateS2SPreference(c *chk.C) {
+	for _, fromTo := range []common.FromTo{common.EFromTo.BlobBlob(), common.EFromTo.LocalBlob(), common.EFromTo.FileLocal()} {
+		c.Assert(validateS2SPreference(common.ES2SPreference.Prefer(), fromTo), chk.IsNil)
+		c.Assert(validateS2SPreference(common.ES2SPreference.Never(), fromTo), chk.IsNil)
+	}
+	c.Assert(validateS2SPreference(common.ES2SPreference.Never(), common.EFromTo.S3Blob()), chk.IsNil)
+	c.Assert(validateS2SPreference(common.ES2SPreference.Never(), common.EFromTo.FileFile()), chk.IsNil)
+
+	c.Assert(validateS2SPreference(common.ES2SPreference.Require(), common.EFromTo.BlobFile()), chk.IsNil)
+	c.Assert(validateS2SPreference(common.ES2SPreference.Require(), common.EFromTo.LocalBlob()), chk.NotNil)
+	c.Assert(validateS2SPreference(common.ES2SPreference.Require(), common.EFromTo.BlobLocal()), chk.NotNil)
+
+	for _, fromTo := range []common.FromTo{common.EFromTo.S3Blob(), common.EFromTo.HttpBlob(), common.EFromTo.FileBlob()} {
+		c.Assert(validateS2SPreference(common.ES2SPreference.Require(), fromTo), chk.IsNil, chk.Commentf(fromTo.String()))
+	}
+	// the service has nothing to copy from when the data is made up, or comes from a pipe
+	for _, fromTo := range []common.FromTo{common.EFromTo.BenchmarkBlob(), common.EFromTo.PipeBlob(), common.EFromTo.BlobFSLocal()} {
+		c.Assert(validateS2SPreference(common.ES2SPreference.Require(), fromTo), chk.NotNil, chk.Commentf(fromTo.String()))
+	}
+}
+
+func (s *s2sPreferenceSuite) TestParseS2SPreference(c *chk.C) {
+	var preference common.S2SPreference
+	c.Assert(preference.Parse("never"), chk.IsNil)
+	c.Assert(preference, chk.Equals, common.ES2SPreference.Never())
+	c.Assert(preference.Parse(""), chk.IsNil)
+	c.Assert(preference, chk.Equals, common.ES2SPreference.Prefer())
+	c.Assert(preference.Parse("always"), chk.NotNil)
+}
@@ -0,0 +1,65 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type sameLocationSuite struct{}
+
+var _ = chk.Suite(&sameLocationSuite{})
+
+func (s *sameLocationSuite) TestSameLocationIsFoundHoweverTheURLIsWritten(c *chk.C) {
+	blobBlob := common.EFromTo.BlobBlob()
+
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/dir?sv=1&sig=a", "https://ACCT.blob.core.windows.net:443/C/dir/?sv=2&sig=b", blobBlob, false),
+		chk.ErrorMatches, ".*same location \\(acct.blob.core.windows.net/c/dir\\).*--allow-same-location.*")
+	c.Assert(checkNotSameLocation("https://acct.dfs.core.windows.net/c/dir", "https://acct.blob.core.windows.net/c//dir", blobBlob, false), chk.NotNil)
+
+	// Azure Files names are case-insensitive, but blob names aren't
+	c.Assert(checkNotSameLocation("https://acct.file.core.windows.net/s/Dir", "https://acct.file.core.windows.net/s/dir", common.EFromTo.FileFile(), false), chk.NotNil)
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/Dir", "https://acct.blob.core.windows.net/c/dir", blobBlob, false), chk.IsNil)
+}
+
+func (s *sameLocationSuite) TestCopyIntoTheFolderItIsAlreadyIn(c *chk.C) {
+	blobBlob := common.EFromTo.BlobBlob()
+
+	// the blob would be copied to c/dir/a.txt
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/dir/a.txt", "https://acct.blob.core.windows.net/c/dir/", blobBlob, true), chk.NotNil)
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/dir", "https://acct.blob.core.windows.net/c", blobBlob, true), chk.NotNil)
+
+	// without a trailing slash, c/dir might be a blob, which a.txt would be copied onto
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/dir/a.txt", "https://acct.blob.core.windows.net/c/dir", blobBlob, true), chk.IsNil)
+	// with a wildcard, only the contents of the source are copied, so they go somewhere new
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/dir", "https://acct.blob.core.windows.net/c", blobBlob, false), chk.IsNil)
+}
+
+func (s *sameLocationSuite) TestDifferentLocationsAreAllowed(c *chk.C) {
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/dir", "https://other.blob.core.windows.net/c/dir", common.EFromTo.BlobBlob(), true), chk.IsNil)
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/dir", "https://acct.file.core.windows.net/c/dir", common.EFromTo.BlobFile(), true), chk.IsNil)
+	c.Assert(checkNotSameLocation("/c/dir", "https://acct.blob.core.windows.net/c/dir", common.EFromTo.LocalBlob(), true), chk.IsNil)
+
+	// a snapshot or version is a different thing from the blob it was taken of, e.g. when restoring from it
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/a.txt?snapshot=2020-01-01T00:00:00.0000000Z", "https://acct.blob.core.windows.net/c/a.txt", common.EFromTo.BlobBlob(), true), chk.IsNil)
+	c.Assert(checkNotSameLocation("https://acct.blob.core.windows.net/c/a.txt?versionId=2020-01-01T00:00:00.0000000Z", "https://acct.blob.core.windows.net/c/a.txt", common.EFromTo.BlobBlob(), true), chk.IsNil)
+}
@@ -752,6 +752,8 @@ func getDefaultSyncRawInput(src, dst string) rawSyncCmdArgs {
 		logVerbosity:        defaultLogVerbosityForSync,
 		deleteDestination:   deleteDestination.String(),
 		md5ValidationOption: common.DefaultHashValidationOption.String(),
+		onCaseMismatch:      common.ECaseMismatchOption.None().String(),
+		normalizeUnicode:    common.EUnicodeNormalization.None().String(),
 	}
 }
 
@@ -767,6 +769,7 @@ func getDefaultCopyRawInput(src string, dst string) rawCopyCmdArgs {
 		s2sInvalidMetadataHandleOption: defaultS2SInvalideMetadataHandleOption.String(),
 		forceWrite:                     common.EOverwriteOption.True().String(),
 		preserveOwner:                  common.PreserveOwnerDefault,
+		normalizeUnicode:               common.EUnicodeNormalization.None().String(),
 	}
 }
 
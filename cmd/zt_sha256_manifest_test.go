@@ -0,0 +1,64 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type sha256ManifestSuite struct{}
+
+var _ = chk.Suite(&sha256ManifestSuite{})
+
+func (s *sha256ManifestSuite) TestValidateSha256Manifest(c *chk.C) {
+	c.Assert(validateSha256Manifest("", common.EFromTo.BlobBlob(), false), chk.IsNil)
+	c.Assert(validateSha256Manifest("m.sha256", common.EFromTo.LocalBlob(), false), chk.IsNil)
+	c.Assert(validateSha256Manifest("m.sha256", common.EFromTo.FileLocal(), false), chk.IsNil)
+
+	// the data doesn't pass through AzCopy, or isn't from or to files
+	c.Assert(validateSha256Manifest("m.sha256", common.EFromTo.BlobBlob(), false), chk.NotNil)
+	c.Assert(validateSha256Manifest("m.sha256", common.EFromTo.PipeBlob(), false), chk.NotNil)
+	c.Assert(validateSha256Manifest("m.sha256", common.EFromTo.BlobPipe(), false), chk.NotNil)
+
+	// the data saved isn't the data read
+	c.Assert(validateSha256Manifest("m.sha256", common.EFromTo.BlobLocal(), true), chk.NotNil)
+}
+
+func (s *sha256ManifestSuite) TestWriteManifestEntries(c *chk.C) {
+	empty := sha256.Sum256(nil)
+	abc := sha256.Sum256([]byte("abc"))
+	entries := []manifestEntry{
+		{path: "dir/abc.txt", sum: abc[:]},
+		{path: "empty", sum: empty[:]},
+		{path: `a\b` + "\nc", sum: abc[:]},
+	}
+
+	var buf bytes.Buffer
+	c.Assert(writeManifestEntries(&buf, entries), chk.IsNil)
+	c.Assert(buf.String(), chk.Equals,
+		"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad  dir/abc.txt\n"+
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  empty\n"+
+			`\ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad  a\\b\nc`+"\n")
+}
@@ -0,0 +1,88 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	chk "gopkg.in/check.v1"
+)
+
+type softDeleteSuite struct{}
+
+var _ = chk.Suite(&softDeleteSuite{})
+
+func (s *softDeleteSuite) TestRequiredSoftDeleteMustBeConfirmed(c *chk.C) {
+	checkErr := errors.New("403 AuthorizationPermissionMismatch")
+
+	message, err := softDeleteMessage(true, 7, nil, true)
+	c.Assert(err, chk.IsNil)
+	c.Assert(strings.Contains(message, "7 days"), chk.Equals, true)
+
+	_, err = softDeleteMessage(false, 0, nil, true)
+	c.Assert(err, chk.NotNil)
+	_, err = softDeleteMessage(false, 0, checkErr, true)
+	c.Assert(err, chk.NotNil)
+
+	// without the flag, the removal goes ahead whatever the result
+	message, err = softDeleteMessage(false, 0, nil, false)
+	c.Assert(err, chk.IsNil)
+	c.Assert(strings.Contains(message, "permanent"), chk.Equals, true)
+	_, err = softDeleteMessage(false, 0, checkErr, false)
+	c.Assert(err, chk.IsNil)
+}
+
+func (s *softDeleteSuite) TestUndeletePrefix(c *chk.C) {
+	cases := []struct {
+		blobName  string
+		prefix    string
+		recursive bool
+		expected  bool
+	}{
+		{"a.txt", "", false, true},
+		{"dir/a.txt", "", false, false},
+		{"dir/a.txt", "", true, true},
+		{"dir/a.txt", "dir", false, true},
+		{"dir/a.txt", "dir/", false, true},
+		{"dir/sub/a.txt", "dir", false, false},
+		{"dir/sub/a.txt", "dir", true, true},
+		{"dir2/a.txt", "dir", true, false},
+		{"dir.txt", "dir", true, false},
+		{"dir/a.txt", "dir/a.txt", false, true},
+	}
+	for _, x := range cases {
+		c.Assert(isUnderUndeletePrefix(x.blobName, x.prefix, x.recursive), chk.Equals, x.expected, chk.Commentf("%+v", x))
+	}
+}
+
+func (s *softDeleteSuite) TestUndeleteNeedsAContainer(c *chk.C) {
+	_, err := rawUndeleteCmdArgs{src: "https://acct.blob.core.windows.net/"}.cook()
+	c.Assert(err, chk.NotNil)
+	_, err = rawUndeleteCmdArgs{src: "https://acct.file.core.windows.net/share/dir"}.cook()
+	c.Assert(err, chk.NotNil)
+
+	cooked, err := rawUndeleteCmdArgs{src: "https://acct.blob.core.windows.net/c/dir/sub?sv=x&sig=y", recursive: true}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.prefix, chk.Equals, "dir/sub")
+	c.Assert(cooked.containerURL.Path, chk.Equals, "/c")
+	c.Assert(cooked.containerURL.RawQuery, chk.Equals, "sig=y&sv=x")
+}
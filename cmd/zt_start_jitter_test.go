@@ -0,0 +1,60 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"math/rand"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type startJitterSuite struct{}
+
+var _ = chk.Suite(&startJitterSuite{})
+
+func (s *startJitterSuite) TestDelayIsBelowTheMaximum(c *chk.C) {
+	var asked int64
+	delay := startJitterDelay(time.Minute, func(n int64) int64 { asked = n; return n - 1 })
+	c.Assert(asked, chk.Equals, int64(time.Minute))
+	c.Assert(delay < time.Minute, chk.Equals, true)
+
+	// with no maximum, there's nothing to choose
+	delay = startJitterDelay(0, func(n int64) int64 { panic("should not be called") })
+	c.Assert(delay, chk.Equals, time.Duration(0))
+}
+
+func (s *startJitterSuite) TestNegativeJitterIsRejected(c *chk.C) {
+	c.Assert(validateStartJitter(-time.Second), chk.NotNil)
+	c.Assert(validateStartJitter(0), chk.IsNil)
+	c.Assert(validateStartJitter(time.Hour), chk.IsNil)
+}
+
+func (s *startJitterSuite) TestDeterministicModeDoesNotFixTheJitter(c *chk.C) {
+	// what deterministic mode picks at random is the same everywhere, but the jitter isn't picked that way
+	rand.Seed(deterministicSeed)
+	seeded := rand.New(rand.NewSource(deterministicSeed))
+	same := true
+	for i := 0; i < 3; i++ {
+		same = same && startJitterRand.Int63() == seeded.Int63()
+	}
+	c.Assert(same, chk.Equals, false)
+}
@@ -23,6 +23,14 @@ package cmd
 import (
 	chk "gopkg.in/check.v1"
 	"time"
+
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
 )
 
 type syncComparatorSuite struct{}
@@ -137,3 +145,178 @@ func (s *syncComparatorSuite) TestSyncDestinationComparator(c *chk.C) {
 	c.Assert(dummyCopyScheduler.record[0].md5, chk.DeepEquals, srcMD5)
 	c.Assert(len(dummyCleaner.record), chk.Equals, 0)
 }
+
+func (s *syncComparatorSuite) TestSyncComparatorsCaseMismatch(c *chk.C) {
+	dummyCopyScheduler := dummyProcessor{}
+	dummyDeleter := dummyProcessor{}
+	sourceObject := storedObject{name: "Foo", relativePath: "dir/Foo", lastModifiedTime: time.Now()}
+	destinationObject := storedObject{name: "foo", relativePath: "dir/foo", lastModifiedTime: time.Now()}
+
+	// renaming removes the destination object before the source object is transferred
+	indexer := newObjectIndexer()
+	indexer.trackCaseMismatches()
+	c.Assert(indexer.store(destinationObject), chk.IsNil)
+	sourceComparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process)
+	sourceComparator.caseMismatches = &caseMismatchHandler{option: common.ECaseMismatchOption.Rename(),
+		copyTransferScheduler: dummyCopyScheduler.process, destinationDeleter: dummyDeleter.process}
+	c.Assert(sourceComparator.processIfNecessary(sourceObject), chk.IsNil)
+	c.Assert(len(dummyDeleter.record), chk.Equals, 1)
+	c.Assert(dummyDeleter.record[0].relativePath, chk.Equals, "dir/foo")
+	c.Assert(len(dummyCopyScheduler.record), chk.Equals, 1)
+	c.Assert(dummyCopyScheduler.record[0].relativePath, chk.Equals, "dir/Foo")
+	c.Assert(len(indexer.indexMap), chk.Equals, 0) // so it won't be deleted again as an extra
+
+	// skipping leaves both alone, and keeps the source object from being transferred at the end
+	dummyCopyScheduler = dummyProcessor{}
+	dummyCleaner := dummyProcessor{}
+	indexer = newObjectIndexer()
+	indexer.trackCaseMismatches()
+	c.Assert(indexer.store(sourceObject), chk.IsNil)
+	destinationComparator := newSyncDestinationComparator(indexer, dummyCopyScheduler.process, dummyCleaner.process)
+	destinationComparator.caseMismatches = &caseMismatchHandler{option: common.ECaseMismatchOption.Skip(), copyTransferScheduler: dummyCopyScheduler.process}
+	c.Assert(destinationComparator.processIfNecessary(destinationObject), chk.IsNil)
+	c.Assert(len(dummyCopyScheduler.record), chk.Equals, 0)
+	c.Assert(len(dummyCleaner.record), chk.Equals, 0)
+	c.Assert(len(indexer.indexMap), chk.Equals, 0)
+
+	// failing reports the mismatch, and fails once they have all been found
+	indexer = newObjectIndexer()
+	indexer.trackCaseMismatches()
+	c.Assert(indexer.store(sourceObject), chk.IsNil)
+	cancelled := 0
+	handler := &caseMismatchHandler{option: common.ECaseMismatchOption.Fail(), cancelJob: func() error { cancelled++; return nil }}
+	dummyCopyScheduler = dummyProcessor{}
+	destinationComparator.sourceIndex = indexer
+	destinationComparator.caseMismatches = handler
+	destinationComparator.copyTransferScheduler = handler.unlessFailing(dummyCopyScheduler.process)
+	c.Assert(destinationComparator.processIfNecessary(destinationObject), chk.IsNil)
+	c.Assert(handler.err(), chk.NotNil)
+
+	// what was already scheduled is cancelled, once, and nothing more is scheduled
+	c.Assert(cancelled, chk.Equals, 1)
+	c.Assert(handler.handle(sourceObject, destinationObject), chk.IsNil)
+	c.Assert(cancelled, chk.Equals, 1)
+	c.Assert(destinationComparator.copyTransferScheduler(sourceObject), chk.IsNil)
+	c.Assert(len(dummyCopyScheduler.record), chk.Equals, 0)
+
+	// a handler without an option is an error, rather than a crash
+	handler = &caseMismatchHandler{option: common.ECaseMismatchOption.None()}
+	c.Assert(handler.handle(sourceObject, destinationObject), chk.NotNil)
+}
+
+func (s *syncComparatorSuite) TestMirrorUpdatesDriftedAttributes(c *chk.C) {
+	// records the properties set on the destination
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("comp"))
+	}))
+	defer server.Close()
+	rootURL, err := url.Parse(server.URL + "/account/container")
+	c.Assert(err, chk.IsNil)
+	p := azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{})
+
+	cca := &cookedSyncCmdArgs{}
+	updater := &remoteAttributeUpdater{
+		remoteResourceDeleter: newRemoteResourceDeleter(rootURL, p, context.Background(), common.ELocation.Blob()),
+		mirror:                true,
+		compareTier:           true,
+		incrementUpdateCount:  cca.incrementAttributeUpdateCount,
+		incrementFailureCount: cca.incrementAttributeUpdateFailureCount,
+	}
+	dummyCopyScheduler := dummyProcessor{}
+	indexer := newObjectIndexer()
+	sourceComparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process)
+	sourceComparator.attributeUpdater = updater.update
+
+	lmt := time.Now()
+	destinationObject := storedObject{name: "test", relativePath: "test", entityType: common.EEntityType.File(), lastModifiedTime: lmt, contentType: "text/plain",
+		blobAccessTier: azblob.AccessTierHot, Metadata: common.Metadata{"Owner": "a"}, blobTags: common.BlobTags{"team": "x"}}
+
+	// with the same attributes, an up to date object is left alone, even if the metadata keys differ in case
+	sourceObject := destinationObject
+	sourceObject.Metadata = common.Metadata{"owner": "a"}
+	c.Assert(indexer.store(destinationObject), chk.IsNil)
+	c.Assert(sourceComparator.processIfNecessary(sourceObject), chk.IsNil)
+	c.Assert(requests, chk.HasLen, 0)
+
+	// each kind of drift has just that attribute set, without transferring the object again
+	drifts := []struct {
+		drift func(o *storedObject)
+		comp  string
+	}{
+		{func(o *storedObject) { o.contentType = "text/html" }, "properties"},
+		{func(o *storedObject) { o.cacheControl = "no-cache" }, "properties"},
+		{func(o *storedObject) { o.blobAccessTier = azblob.AccessTierCool }, "tier"},
+		{func(o *storedObject) { o.Metadata = common.Metadata{"owner": "b"} }, "metadata"},
+		{func(o *storedObject) { o.Metadata = nil }, "metadata"},
+		{func(o *storedObject) { o.blobTags = common.BlobTags{"team": "y"} }, "tags"},
+		{func(o *storedObject) { o.blobTags = nil }, "tags"},
+	}
+	for i, d := range drifts {
+		requests = nil
+		sourceObject := destinationObject
+		d.drift(&sourceObject)
+		c.Assert(indexer.store(destinationObject), chk.IsNil)
+		c.Assert(sourceComparator.processIfNecessary(sourceObject), chk.IsNil)
+		c.Assert(requests, chk.DeepEquals, []string{d.comp}, chk.Commentf("drift %d", i))
+	}
+	c.Assert(len(dummyCopyScheduler.record), chk.Equals, 0)
+	c.Assert(cca.getAttributeUpdateCount(), chk.Equals, uint32(len(drifts)))
+	c.Assert(len(indexer.indexMap), chk.Equals, 0)
+
+	// tiers that are unknown on either side, or not being preserved, are not compared
+	requests = nil
+	sourceObject = destinationObject
+	sourceObject.blobAccessTier = azblob.AccessTierNone
+	c.Assert(updater.update(sourceObject, destinationObject), chk.IsNil)
+	sourceObject.blobAccessTier = azblob.AccessTierArchive
+	updater.compareTier = false
+	c.Assert(updater.update(sourceObject, destinationObject), chk.IsNil)
+	c.Assert(requests, chk.HasLen, 0)
+
+	// a stale object is transferred, along with its attributes
+	sourceObject.lastModifiedTime = lmt.Add(time.Hour)
+	c.Assert(indexer.store(destinationObject), chk.IsNil)
+	c.Assert(sourceComparator.processIfNecessary(sourceObject), chk.IsNil)
+	c.Assert(len(dummyCopyScheduler.record), chk.Equals, 1)
+}
+
+func (s *syncComparatorSuite) TestSyncSourceComparatorUpdatesAttributesOfUpToDateObjects(c *chk.C) {
+	dummyCopyScheduler := dummyProcessor{}
+	indexer := newObjectIndexer()
+	sourceComparator := newSyncSourceComparator(indexer, dummyCopyScheduler.process)
+	var updated []string
+	sourceComparator.attributeUpdater = func(sourceObject, destinationObject storedObject) error {
+		updated = append(updated, destinationObject.relativePath)
+		return nil
+	}
+
+	lmt := time.Now()
+	destinationObject := storedObject{name: "test", relativePath: "test", lastModifiedTime: lmt, Metadata: common.Metadata{"owner": "a"}}
+
+	// an up to date object goes to the updater, rather than being transferred
+	sourceObject := destinationObject
+	sourceObject.Metadata = common.Metadata{"owner": "b"}
+	c.Assert(indexer.store(destinationObject), chk.IsNil)
+	c.Assert(sourceComparator.processIfNecessary(sourceObject), chk.IsNil)
+	c.Assert(len(dummyCopyScheduler.record), chk.Equals, 0)
+	c.Assert(updated, chk.DeepEquals, []string{"test"})
+
+	// a stale one is transferred, along with its attributes, so it isn't updated as well
+	updated = nil
+	sourceObject.lastModifiedTime = lmt.Add(time.Hour)
+	c.Assert(indexer.store(destinationObject), chk.IsNil)
+	c.Assert(sourceComparator.processIfNecessary(sourceObject), chk.IsNil)
+	c.Assert(len(dummyCopyScheduler.record), chk.Equals, 1)
+	c.Assert(updated, chk.HasLen, 0)
+}
+
+func (s *syncComparatorSuite) TestValidateCompareAttributes(c *chk.C) {
+	c.Assert(validateCompareAttributes(false, false, true, common.EFromTo.LocalBlob()), chk.IsNil)
+	c.Assert(validateCompareAttributes(true, true, false, common.EFromTo.BlobBlob()), chk.IsNil)
+	c.Assert(validateCompareAttributes(true, false, false, common.EFromTo.FileFile()), chk.IsNil)
+
+	c.Assert(validateCompareAttributes(true, false, true, common.EFromTo.BlobBlob()), chk.ErrorMatches, ".*cannot be used with mirror.*")
+	c.Assert(validateCompareAttributes(true, false, false, common.EFromTo.LocalBlob()), chk.ErrorMatches, "compare-metadata is only supported.*")
+	c.Assert(validateCompareAttributes(false, true, false, common.EFromTo.FileFile()), chk.ErrorMatches, "compare-tags is only supported.*")
+}
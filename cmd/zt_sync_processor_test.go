@@ -22,6 +22,9 @@ package cmd
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 
@@ -63,6 +66,62 @@ func (s *syncProcessorSuite) TestLocalDeleter(c *chk.C) {
 	c.Assert(err, chk.NotNil)
 }
 
+func (s *syncProcessorSuite) TestLocalDeleterKeepsExcludedFiles(c *chk.C) {
+	dstDirName := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(dstDirName)
+	keptFileName, extraFileName := "bundle.gen.js", "extraFile.txt"
+	scenarioHelper{}.generateLocalFilesFromList(c, dstDirName, []string{keptFileName, extraFileName})
+
+	cca := &cookedSyncCmdArgs{
+		destination:              newLocalRes(dstDirName),
+		deleteDestination:        common.EDeleteDestination.True(),
+		deleteDestinationExclude: []string{"*.gen.js"},
+	}
+	deleter := newSyncLocalDeleteProcessor(cca)
+
+	for _, name := range []string{keptFileName, extraFileName} {
+		c.Assert(deleter.removeImmediately(storedObject{name: name, relativePath: name}), chk.IsNil)
+	}
+
+	// only the file that doesn't match the pattern is deleted
+	_, err := os.Stat(filepath.Join(dstDirName, keptFileName))
+	c.Assert(err, chk.IsNil)
+	_, err = os.Stat(filepath.Join(dstDirName, extraFileName))
+	c.Assert(err, chk.NotNil)
+	c.Assert(cca.getDeletionCount(), chk.Equals, uint32(1))
+}
+
+func (s *syncProcessorSuite) TestAttributeUpdaterCountsFailures(c *chk.C) {
+	// the service refuses every request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	rootURL, err := url.Parse(server.URL + "/account/container")
+	c.Assert(err, chk.IsNil)
+	p := azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{})
+
+	cca := &cookedSyncCmdArgs{}
+	updater := &remoteAttributeUpdater{
+		remoteResourceDeleter: newRemoteResourceDeleter(rootURL, p, context.Background(), common.ELocation.Blob()),
+		compareMetadata:       true,
+		incrementUpdateCount:  cca.incrementAttributeUpdateCount,
+		incrementFailureCount: cca.incrementAttributeUpdateFailureCount,
+	}
+	destinationObject := storedObject{name: "test", relativePath: "test", entityType: common.EEntityType.File(), Metadata: common.Metadata{"owner": "a"}}
+	sourceObject := destinationObject
+	sourceObject.Metadata = common.Metadata{"owner": "b"}
+
+	// the failure doesn't stop the sync, but it's counted
+	c.Assert(updater.update(sourceObject, destinationObject), chk.IsNil)
+	c.Assert(cca.getAttributeUpdateCount(), chk.Equals, uint32(0))
+	c.Assert(cca.getAttributeUpdateFailureCount(), chk.Equals, uint32(1))
+
+	// a destination whose attributes can't be updated is an error, rather than a panic
+	updater.targetLocation = common.ELocation.Local()
+	c.Assert(updater.update(sourceObject, destinationObject), chk.NotNil)
+}
+
 func (s *syncProcessorSuite) TestBlobDeleter(c *chk.C) {
 	bsu := getBSU()
 	blobName := "extraBlob.pdf"
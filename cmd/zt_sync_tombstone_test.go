@@ -0,0 +1,141 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type syncTombstoneSuite struct{}
+
+var _ = chk.Suite(&syncTombstoneSuite{})
+
+func (s *syncTombstoneSuite) TestTombstoneOptionsAreValidated(c *chk.C) {
+	raw := getDefaultSyncRawInput(c.MkDir(), "https://acct.blob.core.windows.net/container")
+	raw.deleteDestination = common.EDeleteDestination.Tombstone().String()
+	raw.tombstoneRetentionDays = 7
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.tombstoneRetention, chk.Equals, 7*24*time.Hour)
+
+	// the tombstone is kept in metadata, which local files don't have
+	raw = getDefaultSyncRawInput("https://acct.blob.core.windows.net/container", c.MkDir())
+	raw.deleteDestination = common.EDeleteDestination.Tombstone().String()
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "delete-destination=tombstone is only supported .*")
+
+	raw = getDefaultSyncRawInput(c.MkDir(), "https://acct.blob.core.windows.net/container")
+	raw.deleteDestination = common.EDeleteDestination.Tombstone().String()
+	raw.tombstoneRetentionDays = -1
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "tombstone-retention-days cannot be negative")
+
+	raw = getDefaultSyncRawInput(c.MkDir(), "https://acct.blob.core.windows.net/container")
+	raw.tombstoneRetentionDays = 7
+	_, err = raw.cook()
+	c.Assert(err, chk.ErrorMatches, "tombstone-retention-days is only supported with delete-destination=tombstone")
+}
+
+func (s *syncTombstoneSuite) TestExtraObjectsAreTombstonedAndLaterDeleted(c *chk.C) {
+	// records the requests made to the destination, and the tombstones they set
+	var requests []string
+	var tombstones, owners []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.URL.Query().Get("comp"))
+		if r.Method == http.MethodPut {
+			tombstones = append(tombstones, r.Header.Get("x-ms-meta-"+tombstoneMetadataKey))
+			owners = append(owners, r.Header.Get("x-ms-meta-owner"))
+		}
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+	rootURL, err := url.Parse(server.URL + "/account/container")
+	c.Assert(err, chk.IsNil)
+	p := azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{})
+
+	cca := &cookedSyncCmdArgs{}
+	tombstoner := &remoteTombstoner{
+		remoteResourceDeleter:  newRemoteResourceDeleter(rootURL, p, context.Background(), common.ELocation.Blob()),
+		retention:              24 * time.Hour,
+		incrementDeletionCount: cca.incrementDeletionCount,
+	}
+
+	// an extra object is marked as deleted, and keeps the rest of its metadata
+	before := time.Now().UTC().Add(-time.Second)
+	c.Assert(tombstoner.tombstone(storedObject{name: "a.txt", relativePath: "a.txt", entityType: common.EEntityType.File(),
+		Metadata: common.Metadata{"owner": "a"}}), chk.IsNil)
+	c.Assert(requests, chk.DeepEquals, []string{"PUT /account/container/a.txt metadata"})
+	c.Assert(tombstones, chk.HasLen, 1)
+	c.Assert(owners, chk.DeepEquals, []string{"a"})
+	deletedAt, err := time.Parse(time.RFC3339, tombstones[0])
+	c.Assert(err, chk.IsNil)
+	c.Assert(deletedAt.Before(before), chk.Equals, false)
+	c.Assert(cca.getDeletionCount(), chk.Equals, uint32(1))
+
+	// one that was marked within the retention is left alone, as are folders
+	requests = nil
+	recent := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	c.Assert(tombstoner.tombstone(storedObject{name: "b.txt", relativePath: "b.txt", entityType: common.EEntityType.File(),
+		Metadata: common.Metadata{tombstoneMetadataKey: recent}}), chk.IsNil)
+	c.Assert(tombstoner.tombstone(storedObject{name: "dir", relativePath: "dir", entityType: common.EEntityType.Folder()}), chk.IsNil)
+	c.Assert(requests, chk.HasLen, 0)
+	c.Assert(cca.getDeletionCount(), chk.Equals, uint32(1))
+
+	// and one that was marked before the retention is deleted for real
+	old := time.Now().UTC().Add(-48 * time.Hour).Format(time.RFC3339)
+	c.Assert(tombstoner.tombstone(storedObject{name: "c.txt", relativePath: "c.txt", entityType: common.EEntityType.File(),
+		Metadata: common.Metadata{tombstoneMetadataKey: old}}), chk.IsNil)
+	c.Assert(requests, chk.DeepEquals, []string{"DELETE /account/container/c.txt "})
+	c.Assert(cca.getDeletionCount(), chk.Equals, uint32(2))
+}
+
+func (s *syncTombstoneSuite) TestTombstonesAreKeptForeverWithoutRetention(c *chk.C) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method)
+	}))
+	defer server.Close()
+	rootURL, err := url.Parse(server.URL + "/account/container")
+	c.Assert(err, chk.IsNil)
+	p := azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{})
+
+	cca := &cookedSyncCmdArgs{}
+	tombstoner := &remoteTombstoner{
+		remoteResourceDeleter:  newRemoteResourceDeleter(rootURL, p, context.Background(), common.ELocation.Blob()),
+		incrementDeletionCount: cca.incrementDeletionCount,
+	}
+	old := time.Now().UTC().Add(-365 * 24 * time.Hour).Format(time.RFC3339)
+	c.Assert(tombstoner.tombstone(storedObject{name: "c.txt", relativePath: "c.txt", entityType: common.EEntityType.File(),
+		Metadata: common.Metadata{tombstoneMetadataKey: old}}), chk.IsNil)
+	c.Assert(requests, chk.HasLen, 0)
+	c.Assert(cca.getDeletionCount(), chk.Equals, uint32(0))
+}
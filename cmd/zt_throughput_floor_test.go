@@ -0,0 +1,124 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type throughputFloorSuite struct{}
+
+var _ = chk.Suite(&throughputFloorSuite{})
+
+func (s *throughputFloorSuite) TestValidateMinThroughput(c *chk.C) {
+	f, err := validateMinThroughput(0, time.Minute, "fail", common.EFromTo.LocalBlob())
+	c.Assert(err, chk.IsNil)
+	c.Assert(f, chk.IsNil)
+
+	f, err = validateMinThroughput(100, time.Minute, " Warn ", common.EFromTo.LocalBlob())
+	c.Assert(err, chk.IsNil)
+	c.Assert(f.action, chk.Equals, throughputFloorActionWarn)
+
+	_, err = validateMinThroughput(-1, time.Minute, "fail", common.EFromTo.LocalBlob())
+	c.Assert(err, chk.NotNil)
+	_, err = validateMinThroughput(100, time.Second, "fail", common.EFromTo.LocalBlob())
+	c.Assert(err, chk.ErrorMatches, ".*must be at least.*")
+	_, err = validateMinThroughput(100, time.Minute, "pause", common.EFromTo.LocalBlob())
+	c.Assert(err, chk.ErrorMatches, ".*must be fail or warn.*")
+	_, err = validateMinThroughput(100, time.Minute, "fail", common.EFromTo.BlobBlob())
+	c.Assert(err, chk.ErrorMatches, ".*service to service.*")
+}
+
+func (s *throughputFloorSuite) TestFloorIsBreachedOnlyOverAWholeWindow(c *chk.C) {
+	f, err := validateMinThroughput(8, 10*time.Second, "fail", common.EFromTo.LocalBlob()) // 8 Mb/s is 1 MB/s
+	c.Assert(err, chk.IsNil)
+	start := time.Now()
+	at := func(secs int) time.Time { return start.Add(time.Duration(secs) * time.Second) }
+
+	// nothing is sent, but the window hasn't passed yet
+	for secs := 0; secs < 10; secs += 2 {
+		_, breached := f.observe(at(secs), 0, true)
+		c.Assert(breached, chk.Equals, false)
+	}
+
+	// a burst keeps the average over the window up, for as long as it's in the window
+	_, breached := f.observe(at(10), 20*base10Mega, true)
+	c.Assert(breached, chk.Equals, false)
+	mbps, breached := f.observe(at(18), 20*base10Mega, true)
+	c.Assert(breached, chk.Equals, false)
+	c.Assert(mbps, chk.Equals, 16.0)
+
+	// once it has left the window, the floor is breached, and that's only reported once
+	mbps, breached = f.observe(at(22), 20*base10Mega, true)
+	c.Assert(breached, chk.Equals, true)
+	c.Assert(mbps, chk.Equals, 0.0)
+	_, breached = f.observe(at(24), 20*base10Mega, true)
+	c.Assert(breached, chk.Equals, false)
+
+	// until the throughput recovers, and falls again
+	_, breached = f.observe(at(34), 40*base10Mega, true)
+	c.Assert(breached, chk.Equals, false)
+	_, breached = f.observe(at(46), 40*base10Mega, true)
+	c.Assert(breached, chk.Equals, true)
+}
+
+func (s *throughputFloorSuite) TestTimeNotMeasuredIsLeftOut(c *chk.C) {
+	f, err := validateMinThroughput(8, 10*time.Second, "fail", common.EFromTo.LocalBlob())
+	c.Assert(err, chk.IsNil)
+	start := time.Now()
+	at := func(secs int) time.Time { return start.Add(time.Duration(secs) * time.Second) }
+
+	// a pause of a whole window, with nothing sent, isn't a breach, and the window starts again once it's over
+	_, breached := f.observe(at(0), 0, true)
+	c.Assert(breached, chk.Equals, false)
+	_, breached = f.observe(at(20), 0, false)
+	c.Assert(breached, chk.Equals, false)
+	_, breached = f.observe(at(22), 0, true)
+	c.Assert(breached, chk.Equals, false)
+	_, breached = f.observe(at(30), 0, true)
+	c.Assert(breached, chk.Equals, false)
+	_, breached = f.observe(at(32), 0, true)
+	c.Assert(breached, chk.Equals, true)
+}
+
+func (s *throughputFloorSuite) TestThroughputIsMeasuredOnlyWhileTransfersAreUnderWay(c *chk.C) {
+	f, err := validateMinThroughput(8, 10*time.Second, "fail", common.EFromTo.LocalBlob()) // 10 MB in a window
+	c.Assert(err, chk.IsNil)
+	summary := common.ListJobSummaryResponse{JobStatus: common.EJobStatus.InProgress(), TotalTransfers: 10, TransfersCompleted: 5,
+		TotalBytesExpected: 100 * base10Mega, TotalBytesTransferred: 50 * base10Mega}
+	c.Assert(f.isMeasured(summary), chk.Equals, true)
+
+	// not while paused, or with nothing left to transfer, e.g. while the next part is enumerated
+	paused := summary
+	paused.JobStatus = common.EJobStatus.Paused()
+	c.Assert(f.isMeasured(paused), chk.Equals, false)
+	idle := summary
+	idle.TransfersCompleted = 10
+	c.Assert(f.isMeasured(idle), chk.Equals, false)
+
+	// nor in the tail, once everything is ordered and less than a window's worth is left
+	summary.CompleteJobOrdered = true
+	c.Assert(f.isMeasured(summary), chk.Equals, true)
+	summary.TotalBytesTransferred = 95 * base10Mega
+	c.Assert(f.isMeasured(summary), chk.Equals, false)
+}
@@ -33,6 +33,7 @@ import (
 // Identifies a chunk. Always create with NewChunkID
 type ChunkID struct {
 	Name         string
+	TransferID   string // identifies the chunk's transfer in the job log, so the chunk log can be cross-referenced with it. Empty if not known
 	offsetInFile int64
 	length       int64
 
@@ -50,32 +51,41 @@ type ChunkID struct {
 	// waitReasonIndex isn't yet ready to go to "Done" at that time.
 	completionNotifiedToJptm *int32
 
+	// When the chunk entered its current wait state, in Unix nanoseconds. A pointer, for the same reason as
+	// waitReasonIndex. Used to total up how long chunks spend in each state
+	waitStartNanos *int64
+
 	// TODO: it's a bit odd having two pointers in a struct like this.  Review, maybe we should always work
 	//   with pointers to chunk ids, with nocopy?  If we do that, the two fields that are currently pointers
 	//   can become non-pointers
 	//   And maybe at that point, we would also put Length into chunkID, and use that in jptm.ReportChunkDone
 }
 
-func NewChunkID(name string, offsetInFile int64, length int64) ChunkID {
+func NewChunkID(name string, transferID string, offsetInFile int64, length int64) ChunkID {
 	dummyWaitReasonIndex := int32(0)
 	zeroNotificationState := int32(0)
+	zeroWaitStart := int64(0)
 	return ChunkID{
 		Name:                     name,
+		TransferID:               transferID,
 		offsetInFile:             offsetInFile,
 		length:                   length,
 		waitReasonIndex:          &dummyWaitReasonIndex, // must initialize, so don't get nil pointer on usage
 		completionNotifiedToJptm: &zeroNotificationState,
+		waitStartNanos:           &zeroWaitStart,
 	}
 }
 
 func NewPseudoChunkIDForWholeFile(name string) ChunkID {
 	dummyWaitReasonIndex := int32(0)
 	alreadyNotifiedNotificationState := int32(1) // so that these can never be notified to jptm's (doing so would be an error, because they are not real chunks)
+	zeroWaitStart := int64(0)
 	return ChunkID{
 		Name:                     name,
 		offsetInFile:             math.MinInt64,         // very negative, clearly not a real offset
 		waitReasonIndex:          &dummyWaitReasonIndex, // must initialize, so don't get nil pointer on usage
 		completionNotifiedToJptm: &alreadyNotifiedNotificationState,
+		waitStartNanos:           &zeroWaitStart,
 	}
 }
 
@@ -100,6 +110,12 @@ func (id ChunkID) Length() int64 {
 	return id.length
 }
 
+// IsEmptyFileChunk says whether this is the one, zero-length, chunk that is scheduled for an empty file.
+// (We always schedule a chunk, even for empty files, since that keeps the code structure simpler.)
+func (id ChunkID) IsEmptyFileChunk() bool {
+	return !id.IsPseudoChunk() && id.length == 0
+}
+
 var EWaitReason = WaitReason{0, ""}
 
 // WaitReason identifies the one thing that a given chunk is waiting on, at a given moment.
@@ -240,6 +256,7 @@ type ChunkStatusLoggerCloser interface {
 	ChunkStatusLogger
 	GetCounts(td TransferDirection) []chunkStatusCount
 	GetPrimaryPerfConstraint(td TransferDirection, rc RetryCounter) PerfConstraint
+	GetTimeBreakdown() ChunkTimeBreakdown
 	FlushLog() // not close, because we had issues with writes coming in after this // TODO: see if that issue still exists
 }
 
@@ -253,15 +270,17 @@ type chunkStatusLogger struct {
 	atomicLastRetryCount            int64
 	atomicIsWaitingOnFinalBodyReads int32
 	counts                          []int64
+	durations                       []int64 // total nanoseconds that real (not pseudo) chunks have spent in each state
 	outputEnabled                   bool
 	unsavedEntries                  chan *chunkWaitState
 	flushDone                       chan struct{}
 	cpuMonitor                      CPUMonitor
 }
 
-func NewChunkStatusLogger(jobID JobID, cpuMon CPUMonitor, logFileFolder string, enableOutput bool) ChunkStatusLoggerCloser {
+func NewChunkStatusLogger(jobID JobID, cpuMon CPUMonitor, logFileFolder string, enableOutput bool, rotation LogRotationPolicy) ChunkStatusLoggerCloser {
 	logger := &chunkStatusLogger{
 		counts:         make([]int64, numWaitReasons()),
+		durations:      make([]int64, numWaitReasons()),
 		outputEnabled:  enableOutput,
 		unsavedEntries: make(chan *chunkWaitState, 1000000),
 		flushDone:      make(chan struct{}),
@@ -269,7 +288,7 @@ func NewChunkStatusLogger(jobID JobID, cpuMon CPUMonitor, logFileFolder string,
 	}
 	if enableOutput {
 		chunkLogPath := path.Join(logFileFolder, jobID.String()+"-chunks.log") // its a CSV, but using log extension for consistency with other files in the directory
-		go logger.main(chunkLogPath)
+		go logger.main(chunkLogPath, rotation)
 	}
 	return logger
 }
@@ -292,6 +311,12 @@ type chunkWaitState struct {
 ////////////////////////////////////  basic functionality //////////////////////////////////
 
 func (csl *chunkStatusLogger) LogChunkStatus(id ChunkID, reason WaitReason) {
+	// the chunk of an empty file has no data to wait for, so it goes straight to done. Logging the states it
+	// passes through on the way would only make it look like it was held up somewhere
+	if id.IsEmptyFileChunk() && reason != EWaitReason.ChunkDone() && reason != EWaitReason.Cancelled() {
+		return
+	}
+
 	// always update the in-memory stats, even if output is disabled
 	csl.countStateTransition(id, reason)
 
@@ -315,15 +340,15 @@ func (csl *chunkStatusLogger) FlushLog() {
 	}
 }
 
-func (csl *chunkStatusLogger) main(chunkLogPath string) {
-	f, err := os.Create(chunkLogPath)
+func (csl *chunkStatusLogger) main(chunkLogPath string, rotation LogRotationPolicy) {
+	_ = os.Remove(chunkLogPath) // each run starts the chunk log afresh
+	f, err := newRotatingLogFile(chunkLogPath, rotation, "Name,Offset,State,StateStartTime,TransferID\n")
 	if err != nil {
 		panic(err.Error())
 	}
 	defer func() { _ = f.Close() }()
 
 	w := bufio.NewWriter(f)
-	_, _ = w.WriteString("Name,Offset,State,StateStartTime\n")
 
 	doFlush := func() {
 		_ = w.Flush()
@@ -339,7 +364,7 @@ func (csl *chunkStatusLogger) main(chunkLogPath string) {
 			csl.flushDone <- struct{}{}
 			continue // TODO can become break (or be moved to later if we close unsaved entries, once we figure out how we got stuff written to us after CloseLog was called)
 		}
-		_, _ = w.WriteString(fmt.Sprintf("%s,%d,%s,%s\n", x.Name, x.OffsetInFile(), x.reason, x.waitStart))
+		_, _ = w.WriteString(fmt.Sprintf("%s,%d,%s,%s,%s\n", x.Name, x.OffsetInFile(), x.reason, x.waitStart, x.TransferID))
 		if alwaysFlushFromNowOn {
 			// TODO: remove when we figure out how we got stuff written to us after CloseLog was called. For now, this should handle those cases (if they still exist)
 			doFlush()
@@ -371,12 +396,67 @@ func (csl *chunkStatusLogger) countStateTransition(id ChunkID, newReason WaitRea
 	if newReason.index < int32(len(csl.counts)) {
 		atomic.AddInt64(&csl.counts[newReason.index], 1)
 	}
+
+	// Add the time spent in the old state to its total. Pseudo chunks are left out, since their states
+	// span the whole life of a file and would swamp the time spent by the real chunks
+	now := time.Now().UnixNano()
+	oldStart := atomic.SwapInt64(id.waitStartNanos, now)
+	if !id.IsPseudoChunk() && oldStart > 0 && oldReasonIndex > 0 && oldReasonIndex < int32(len(csl.durations)) {
+		atomic.AddInt64(&csl.durations[oldReasonIndex], now-oldStart)
+	}
 }
 
 func (csl *chunkStatusLogger) getCount(reason WaitReason) int64 {
 	return atomic.LoadInt64(&csl.counts[reason.index])
 }
 
+// ChunkTimeBreakdown says what share of the total time that chunks spent in all their states was spent
+// waiting on the network, on the disk, and for RAM. Values are percentages. Whatever is left over
+// was spent on other things, such as waiting for a worker goroutine
+type ChunkTimeBreakdown struct {
+	NetworkPercentage float32 `json:",string"`
+	DiskPercentage    float32 `json:",string"`
+	RAMPercentage     float32 `json:",string"`
+}
+
+// IsEmpty reports whether no chunk time has been recorded
+func (b ChunkTimeBreakdown) IsEmpty() bool {
+	return b.NetworkPercentage == 0 && b.DiskPercentage == 0 && b.RAMPercentage == 0
+}
+
+// GetTimeBreakdown sums the time that chunks have spent in each state so far, and groups it by resource
+func (csl *chunkStatusLogger) GetTimeBreakdown() ChunkTimeBreakdown {
+	sum := func(reasons ...WaitReason) int64 {
+		total := int64(0)
+		for _, r := range reasons {
+			total += atomic.LoadInt64(&csl.durations[r.index])
+		}
+		return total
+	}
+
+	total := int64(0)
+	for i := range csl.durations {
+		total += atomic.LoadInt64(&csl.durations[i])
+	}
+	if total == 0 {
+		return ChunkTimeBreakdown{}
+	}
+
+	network := sum(EWaitReason.HeaderResponse(), EWaitReason.Body(), EWaitReason.BodyReReadDueToMem(),
+		EWaitReason.BodyReReadDueToSpeed(), EWaitReason.S2SCopyOnWire())
+	disk := sum(EWaitReason.DiskIO(), EWaitReason.Sorting(), EWaitReason.QueueToWrite())
+	ram := sum(EWaitReason.RAMToSchedule())
+
+	percent := func(d int64) float32 {
+		return float32(float64(d) * 100 / float64(total))
+	}
+	return ChunkTimeBreakdown{
+		NetworkPercentage: percent(network),
+		DiskPercentage:    percent(disk),
+		RAMPercentage:     percent(ram),
+	}
+}
+
 // Gets the current counts of chunks in each wait state
 // Intended for performance diagnostics and reporting
 func (csl *chunkStatusLogger) GetCounts(td TransferDirection) []chunkStatusCount {
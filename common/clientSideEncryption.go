@@ -0,0 +1,321 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ClientSideEncryptionMetadataKey is the metadata of a blob encrypted client-side that holds its envelope: its content key,
+// wrapped by the user's key, and how its content was encrypted. The format is version 2 of Azure Storage client-side encryption,
+// so that the Azure Storage SDKs can decrypt what AzCopy encrypts, and vice versa
+const ClientSideEncryptionMetadataKey = "encryptiondata"
+
+const (
+	// ClientSideEncryptionRegionSize is how much of the plaintext is encrypted together, as a region. Each region is stored as its
+	// nonce, followed by its ciphertext and its authentication tag, so that a blob can be decrypted a region at a time
+	ClientSideEncryptionRegionSize = 4 * 1024 * 1024
+
+	clientSideEncryptionProtocol         = "2.0"
+	clientSideEncryptionAlgorithm        = "AES_GCM_256"
+	clientSideEncryptionKeyWrapAlgorithm = "A256KW"
+	clientSideEncryptionNonceSize        = 12
+	clientSideEncryptionTagSize          = 16
+	clientSideEncryptionRegionOverhead   = clientSideEncryptionNonceSize + clientSideEncryptionTagSize
+)
+
+// ClientSideEncryptionKey is the user's key, which wraps the content key of each blob encrypted client-side. It's never sent anywhere
+type ClientSideEncryptionKey struct {
+	KeyID string // kept in the envelope of each blob, to say which key its content key was wrapped by. It isn't checked when decrypting
+	Key   []byte // a 256 bit AES key
+}
+
+func (k ClientSideEncryptionKey) IsSet() bool {
+	return len(k.Key) > 0
+}
+
+// LoadClientSideEncryptionKey reads a key from a file holding a 256 bit AES key, base64 encoded, as made by e.g. openssl rand -base64 32.
+// Its key ID is the one given, or else the name of the file
+func LoadClientSideEncryptionKey(path string, keyID string) (ClientSideEncryptionKey, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ClientSideEncryptionKey{}, fmt.Errorf("cannot read the client-side encryption key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != 32 {
+		return ClientSideEncryptionKey{}, fmt.Errorf("%s does not hold a client-side encryption key, which is a 256 bit AES key, base64 encoded", path)
+	}
+	if keyID == "" {
+		keyID = filepath.Base(path)
+	}
+	return ClientSideEncryptionKey{KeyID: keyID, Key: key}, nil
+}
+
+// clientSideEncryptionData is the envelope of a blob encrypted client-side, as the Azure Storage SDKs write it
+type clientSideEncryptionData struct {
+	WrappedContentKey struct {
+		KeyId        string
+		EncryptedKey []byte
+		Algorithm    string
+	}
+	EncryptionAgent struct {
+		Protocol            string
+		EncryptionAlgorithm string
+	}
+	EncryptedRegionInfo struct {
+		DataLength  int
+		NonceLength int
+	}
+	KeyWrappingMetadata map[string]string `json:",omitempty"`
+}
+
+// the content key is wrapped along with the protocol version, padded to 8 bytes, so that the version can't be tampered with
+var clientSideEncryptionWrappedKeyPrefix = []byte(clientSideEncryptionProtocol + "\x00\x00\x00\x00\x00")
+
+// ClientSideEncryptor encrypts the content of one blob, with a content key of its own
+type ClientSideEncryptor struct {
+	gcm      cipher.AEAD
+	envelope string
+}
+
+// NewClientSideEncryptor makes a new content key, and the envelope that holds it, wrapped by the user's key
+func NewClientSideEncryptor(key ClientSideEncryptionKey) (*ClientSideEncryptor, error) {
+	contentKey := make([]byte, 32)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, err
+	}
+	gcm, err := newClientSideGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := aesKeyWrap(key.Key, append(append([]byte{}, clientSideEncryptionWrappedKeyPrefix...), contentKey...))
+	if err != nil {
+		return nil, err
+	}
+
+	var data clientSideEncryptionData
+	data.WrappedContentKey.KeyId = key.KeyID
+	data.WrappedContentKey.EncryptedKey = wrapped
+	data.WrappedContentKey.Algorithm = clientSideEncryptionKeyWrapAlgorithm
+	data.EncryptionAgent.Protocol = clientSideEncryptionProtocol
+	data.EncryptionAgent.EncryptionAlgorithm = clientSideEncryptionAlgorithm
+	data.EncryptedRegionInfo.DataLength = ClientSideEncryptionRegionSize
+	data.EncryptedRegionInfo.NonceLength = clientSideEncryptionNonceSize
+	data.KeyWrappingMetadata = map[string]string{"EncryptionLibrary": "AzCopy " + AzcopyVersion}
+	envelope, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientSideEncryptor{gcm: gcm, envelope: string(envelope)}, nil
+}
+
+// Envelope is the value of the blob's ClientSideEncryptionMetadataKey metadata
+func (e *ClientSideEncryptor) Envelope() string {
+	return e.envelope
+}
+
+// Encrypt encrypts part of the blob's content, which must start at a region boundary: at a multiple of ClientSideEncryptionRegionSize
+func (e *ClientSideEncryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	ciphertext := make([]byte, 0, ClientSideEncryptedLength(int64(len(plaintext))))
+	for start := 0; start < len(plaintext); start += ClientSideEncryptionRegionSize {
+		end := start + ClientSideEncryptionRegionSize
+		if end > len(plaintext) {
+			end = len(plaintext)
+		}
+		nonce := make([]byte, clientSideEncryptionNonceSize)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		ciphertext = append(ciphertext, nonce...)
+		ciphertext = e.gcm.Seal(ciphertext, nonce, plaintext[start:end], nil)
+	}
+	return ciphertext, nil
+}
+
+// ClientSideEncryptedLength is the length of the encrypted form of content of the given length
+func ClientSideEncryptedLength(plaintextLength int64) int64 {
+	regions := (plaintextLength + ClientSideEncryptionRegionSize - 1) / ClientSideEncryptionRegionSize
+	return plaintextLength + regions*clientSideEncryptionRegionOverhead
+}
+
+func newClientSideGCM(contentKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// clientSideDecryptingWriter decrypts the content of a blob, written to it in order, a region at a time
+type clientSideDecryptingWriter struct {
+	destination io.WriteCloser
+	gcm         cipher.AEAD
+	regionSize  int // the size of an encrypted region, except maybe the last
+	buffer      []byte
+}
+
+// NewClientSideDecryptingWriter returns a WriteCloser which decrypts the content of a blob encrypted client-side, with the envelope
+// given, as the content is written to it in order, before passing the plaintext on to a final destination
+func NewClientSideDecryptingWriter(destination io.WriteCloser, envelope string, key ClientSideEncryptionKey) (io.WriteCloser, error) {
+	var data clientSideEncryptionData
+	if err := json.Unmarshal([]byte(envelope), &data); err != nil {
+		return nil, fmt.Errorf("cannot read the client-side encryption envelope in the %s metadata: %w", ClientSideEncryptionMetadataKey, err)
+	}
+	if data.EncryptionAgent.Protocol != clientSideEncryptionProtocol || data.EncryptionAgent.EncryptionAlgorithm != clientSideEncryptionAlgorithm {
+		return nil, fmt.Errorf("the blob is encrypted client-side with version %s of the protocol, and %s, while AzCopy supports only version %s, with %s",
+			data.EncryptionAgent.Protocol, data.EncryptionAgent.EncryptionAlgorithm, clientSideEncryptionProtocol, clientSideEncryptionAlgorithm)
+	}
+	if data.WrappedContentKey.Algorithm != clientSideEncryptionKeyWrapAlgorithm {
+		return nil, fmt.Errorf("the content key of the blob is wrapped with %s, while AzCopy supports only %s", data.WrappedContentKey.Algorithm, clientSideEncryptionKeyWrapAlgorithm)
+	}
+	if data.EncryptedRegionInfo.DataLength <= 0 || data.EncryptedRegionInfo.NonceLength != clientSideEncryptionNonceSize {
+		return nil, errors.New("the client-side encryption envelope of the blob gives an invalid region size or nonce length")
+	}
+
+	// the key ID in the envelope is only a label, which may name a key the way another tool does (e.g. by its Key Vault URL),
+	// so it isn't compared with ours. The integrity check of the key unwrap is what tells us whether we have the right key
+	unwrapped, err := aesKeyUnwrap(key.Key, data.WrappedContentKey.EncryptedKey)
+	if err != nil || len(unwrapped) != len(clientSideEncryptionWrappedKeyPrefix)+32 ||
+		string(unwrapped[:len(clientSideEncryptionWrappedKeyPrefix)]) != string(clientSideEncryptionWrappedKeyPrefix) {
+		return nil, fmt.Errorf("cannot unwrap the content key of the blob, which was wrapped by the key %q: the key given is a different one, or the envelope was tampered with",
+			data.WrappedContentKey.KeyId)
+	}
+	gcm, err := newClientSideGCM(unwrapped[len(clientSideEncryptionWrappedKeyPrefix):])
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientSideDecryptingWriter{
+		destination: destination,
+		gcm:         gcm,
+		regionSize:  data.EncryptedRegionInfo.NonceLength + data.EncryptedRegionInfo.DataLength + clientSideEncryptionTagSize,
+	}, nil
+}
+
+func (w *clientSideDecryptingWriter) Write(p []byte) (n int, err error) {
+	w.buffer = append(w.buffer, p...)
+	consumed := 0
+	for len(w.buffer)-consumed >= w.regionSize {
+		if err = w.decryptRegion(w.buffer[consumed : consumed+w.regionSize]); err != nil {
+			return 0, err
+		}
+		consumed += w.regionSize
+	}
+	w.buffer = w.buffer[:copy(w.buffer, w.buffer[consumed:])]
+	return len(p), nil
+}
+
+func (w *clientSideDecryptingWriter) decryptRegion(region []byte) error {
+	if len(region) < clientSideEncryptionRegionOverhead {
+		return errors.New("the encrypted content of the blob is truncated")
+	}
+	nonce := region[:clientSideEncryptionNonceSize]
+	plaintext, err := w.gcm.Open(nil, nonce, region[clientSideEncryptionNonceSize:], nil)
+	if err != nil {
+		return errors.New("cannot decrypt the content of the blob: it was changed after it was encrypted, or isn't the content the envelope is for")
+	}
+	_, err = w.destination.Write(plaintext)
+	return err
+}
+
+// Close decrypts the last region, which may be shorter than the rest, and closes the destination
+func (w *clientSideDecryptingWriter) Close() error {
+	var err error
+	if len(w.buffer) > 0 {
+		err = w.decryptRegion(w.buffer)
+		w.buffer = nil
+	}
+	if closeErr := w.destination.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// the initial value of AES key wrap, from RFC 3394
+const aesKeyWrapIV = 0xA6A6A6A6A6A6A6A6
+
+// aesKeyWrap wraps the key data, which must be a multiple of 8 bytes long, and at least 16, with AES key wrap (RFC 3394)
+func aesKeyWrap(kek []byte, keyData []byte) ([]byte, error) {
+	if len(keyData)%8 != 0 || len(keyData) < 16 {
+		return nil, errors.New("key data to wrap must be a multiple of 8 bytes long, and at least 16")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(keyData) / 8
+	wrapped := make([]byte, 8+len(keyData)) // A, followed by R[1] to R[n]
+	binary.BigEndian.PutUint64(wrapped, aesKeyWrapIV)
+	copy(wrapped[8:], keyData)
+	b := make([]byte, 16)
+	for j := 0; j <= 5; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b, wrapped[:8])
+			copy(b[8:], wrapped[i*8:i*8+8])
+			block.Encrypt(b, b)
+			binary.BigEndian.PutUint64(wrapped, binary.BigEndian.Uint64(b)^uint64(n*j+i))
+			copy(wrapped[i*8:], b[8:])
+		}
+	}
+	return wrapped, nil
+}
+
+// aesKeyUnwrap unwraps key data wrapped with AES key wrap (RFC 3394), and checks its integrity
+func aesKeyUnwrap(kek []byte, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, errors.New("wrapped key data must be a multiple of 8 bytes long, and at least 24")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(wrapped)/8 - 1
+	a := binary.BigEndian.Uint64(wrapped)
+	keyData := make([]byte, n*8)
+	copy(keyData, wrapped[8:])
+	b := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			binary.BigEndian.PutUint64(b, a^uint64(n*j+i))
+			copy(b[8:], keyData[(i-1)*8:i*8])
+			block.Decrypt(b, b)
+			a = binary.BigEndian.Uint64(b)
+			copy(keyData[(i-1)*8:], b[8:])
+		}
+	}
+	if a != aesKeyWrapIV {
+		return nil, errors.New("the wrapped key data failed its integrity check")
+	}
+	return keyData, nil
+}
@@ -157,6 +157,9 @@ func (DeleteDestination) False() DeleteDestination  { return DeleteDestination(0
 func (DeleteDestination) Prompt() DeleteDestination { return DeleteDestination(1) }
 func (DeleteDestination) True() DeleteDestination   { return DeleteDestination(2) }
 
+// Tombstone marks extra destination objects as deleted, in their metadata, rather than removing them
+func (DeleteDestination) Tombstone() DeleteDestination { return DeleteDestination(3) }
+
 func (dd *DeleteDestination) Parse(s string) error {
 	val, err := enum.Parse(reflect.TypeOf(dd), s, true)
 	if err == nil {
@@ -171,6 +174,121 @@ func (dd DeleteDestination) String() string {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// UnicodeNormalization says which Unicode normalization form, if any, object names are converted to before they are
+// compared and used at the destination, so that names which look the same are treated as the same
+type UnicodeNormalization uint8
+
+var EUnicodeNormalization = UnicodeNormalization(0)
+
+// None leaves names exactly as they are found
+func (UnicodeNormalization) None() UnicodeNormalization { return UnicodeNormalization(0) }
+
+// NFC composes accented characters into single code points, as Windows and Linux generally do
+func (UnicodeNormalization) NFC() UnicodeNormalization { return UnicodeNormalization(1) }
+
+// NFD decomposes accented characters into a base character followed by combining marks, as macOS does
+func (UnicodeNormalization) NFD() UnicodeNormalization { return UnicodeNormalization(2) }
+
+func (n *UnicodeNormalization) Parse(s string) error {
+	val, err := enum.Parse(reflect.TypeOf(n), s, true)
+	if err == nil {
+		*n = val.(UnicodeNormalization)
+	}
+	return err
+}
+
+func (n UnicodeNormalization) String() string {
+	return enum.StringInt(n, reflect.TypeOf(n))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// CaseMismatchOption says what sync does when a source object and a destination object have paths that differ only in case
+type CaseMismatchOption uint32
+
+var ECaseMismatchOption = CaseMismatchOption(0)
+
+// None treats the two as unrelated objects, which is how sync has always behaved
+func (CaseMismatchOption) None() CaseMismatchOption { return CaseMismatchOption(0) }
+
+// Rename removes the destination object, and transfers the source object under its own name
+func (CaseMismatchOption) Rename() CaseMismatchOption { return CaseMismatchOption(1) }
+
+// Skip leaves both alone
+func (CaseMismatchOption) Skip() CaseMismatchOption { return CaseMismatchOption(2) }
+
+// Fail stops the sync
+func (CaseMismatchOption) Fail() CaseMismatchOption { return CaseMismatchOption(3) }
+
+func (o *CaseMismatchOption) Parse(s string) error {
+	val, err := enum.Parse(reflect.TypeOf(o), s, true)
+	if err == nil {
+		*o = val.(CaseMismatchOption)
+	}
+	return err
+}
+
+func (o CaseMismatchOption) String() string {
+	return enum.StringInt(o, reflect.TypeOf(o))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ChunkFairness says how the STE picks the next chunk to run, from those that have been scheduled
+type ChunkFairness uint32
+
+var EChunkFairness = ChunkFairness(0)
+
+// None runs chunks in the order they were scheduled, so a transfer with many chunks can hold up those scheduled after it
+func (ChunkFairness) None() ChunkFairness { return ChunkFairness(0) }
+
+// RoundRobin rotates among the transfers that have chunks waiting, taking one chunk from each in turn
+func (ChunkFairness) RoundRobin() ChunkFairness { return ChunkFairness(1) }
+
+func (f *ChunkFairness) Parse(s string) error {
+	// accept the hyphenated form, round-robin, which is how the flag is documented
+	val, err := enum.Parse(reflect.TypeOf(f), strings.Replace(s, "-", "", -1), true)
+	if err == nil {
+		*f = val.(ChunkFairness)
+	}
+	return err
+}
+
+func (f ChunkFairness) String() string {
+	return enum.StringInt(f, reflect.TypeOf(f))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ListSortOption says how the list command orders its output
+type ListSortOption uint32
+
+var EListSortOption = ListSortOption(0)
+
+// None lists the objects in the order in which the service returns them
+func (ListSortOption) None() ListSortOption { return ListSortOption(0) }
+
+func (ListSortOption) Name() ListSortOption { return ListSortOption(1) }
+
+func (ListSortOption) Size() ListSortOption { return ListSortOption(2) }
+
+// MTime orders the objects by their last modified time
+func (ListSortOption) MTime() ListSortOption { return ListSortOption(3) }
+
+func (o *ListSortOption) Parse(s string) error {
+	val, err := enum.Parse(reflect.TypeOf(o), s, true)
+	if err == nil {
+		*o = val.(ListSortOption)
+	}
+	return err
+}
+
+func (o ListSortOption) String() string {
+	return enum.StringInt(o, reflect.TypeOf(o))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
 // represents one possible response
 var EResponseOption = ResponseOption{ResponseType: "", UserFriendlyResponseType: "", ResponseString: ""}
 
@@ -257,6 +375,9 @@ type ExitCode uint32
 func (ExitCode) Success() ExitCode { return ExitCode(0) }
 func (ExitCode) Error() ExitCode   { return ExitCode(1) }
 
+// DeadlineReached is for jobs whose only failures are the transfers that weren't started because the job's deadline passed
+func (ExitCode) DeadlineReached() ExitCode { return ExitCode(3) }
+
 // note: if AzCopy exits due to a panic, we don't directly control what the exit code will be. The Go runtime seems to be
 // hard-coded to give an exit code of 2 in that case, but there is discussion of changing it to 1, so it may become
 // impossible to tell from exit code alone whether AzCopy panic or return EExitCode.Error.
@@ -315,14 +436,58 @@ func (ll LogLevel) ToPipelineLogLevel() pipeline.LogLevel {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+var ELogFormat = LogFormat(0)
+
+// LogFormat specifies how entries are written to the job's log file
+type LogFormat uint8
+
+// Text writes each entry as a line of free-form text. This is the default.
+func (LogFormat) Text() LogFormat { return LogFormat(0) }
+
+// Json writes each entry as a JSON object on a line of its own, for ingestion into log systems.
+func (LogFormat) Json() LogFormat { return LogFormat(1) }
+
+func (lf LogFormat) String() string {
+	return enum.StringInt(lf, reflect.TypeOf(lf))
+}
+
+func (lf *LogFormat) Parse(s string) error {
+	// allow empty to mean "Text"
+	if s == "" {
+		*lf = ELogFormat.Text()
+		return nil
+	}
+
+	val, err := enum.ParseInt(reflect.TypeOf(lf), s, true, true)
+	if err == nil {
+		*lf = val.(LogFormat)
+	}
+	return err
+}
+
+func (lf LogFormat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(lf.String())
+}
+
+func (lf *LogFormat) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return lf.Parse(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
 var EJobPriority = JobPriority(0)
 
 // JobPriority defines the transfer priorities supported by the Storage Transfer Engine's channels
-// The default priority is Normal
+// The default priority is Normal. High is given to individual transfers, whose work then goes ahead of that of all the others
 type JobPriority uint8
 
 func (JobPriority) Normal() JobPriority { return JobPriority(0) }
 func (JobPriority) Low() JobPriority    { return JobPriority(1) }
+func (JobPriority) High() JobPriority   { return JobPriority(2) }
 func (jp JobPriority) String() string {
 	return enum.StringInt(uint8(jp), reflect.TypeOf(jp))
 }
@@ -415,12 +580,13 @@ func (Location) File() Location      { return Location(4) }
 func (Location) BlobFS() Location    { return Location(5) }
 func (Location) S3() Location        { return Location(6) }
 func (Location) Benchmark() Location { return Location(7) }
+func (Location) Http() Location      { return Location(8) } // any plain HTTP(S) URL, e.g. web-hosted content. Only supported as a source
 
 func (l Location) String() string {
 	return enum.StringInt(l, reflect.TypeOf(l))
 }
 
-// AllStandardLocations returns all locations that are "normal" for testing purposes. Excludes the likes of Unknown, Benchmark and Pipe
+// AllStandardLocations returns all locations that are "normal" for testing purposes. Excludes the likes of Unknown, Benchmark, Pipe and Http
 func (Location) AllStandardLocations() []Location {
 	return []Location{
 		ELocation.Local(),
@@ -441,7 +607,7 @@ func fromToValue(from Location, to Location) FromTo {
 
 func (l Location) IsRemote() bool {
 	switch l {
-	case ELocation.BlobFS(), ELocation.Blob(), ELocation.File(), ELocation.S3():
+	case ELocation.BlobFS(), ELocation.Blob(), ELocation.File(), ELocation.S3(), ELocation.Http():
 		return true
 	case ELocation.Local(), ELocation.Benchmark(), ELocation.Pipe(), ELocation.Unknown():
 		return false
@@ -464,7 +630,7 @@ func (l Location) IsFolderAware() bool {
 	switch l {
 	case ELocation.BlobFS(), ELocation.File(), ELocation.Local():
 		return true
-	case ELocation.Blob(), ELocation.S3(), ELocation.Benchmark(), ELocation.Pipe(), ELocation.Unknown():
+	case ELocation.Blob(), ELocation.S3(), ELocation.Http(), ELocation.Benchmark(), ELocation.Pipe(), ELocation.Unknown():
 		return false
 	default:
 		panic("unexpected location, please specify if it is folder-aware")
@@ -501,6 +667,7 @@ func (FromTo) FileBlob() FromTo    { return FromTo(fromToValue(ELocation.File(),
 func (FromTo) BlobFile() FromTo    { return FromTo(fromToValue(ELocation.Blob(), ELocation.File())) }
 func (FromTo) FileFile() FromTo    { return FromTo(fromToValue(ELocation.File(), ELocation.File())) }
 func (FromTo) S3Blob() FromTo      { return FromTo(fromToValue(ELocation.S3(), ELocation.Blob())) }
+func (FromTo) HttpBlob() FromTo    { return FromTo(fromToValue(ELocation.Http(), ELocation.Blob())) }
 
 // todo: to we really want these?  Starts to look like a bit of a combinatorial explosion
 func (FromTo) BenchmarkBlob() FromTo {
@@ -639,10 +806,18 @@ func (TransferStatus) TierAvailabilityCheckFailure() TransferStatus { return Tra
 
 func (TransferStatus) Cancelled() TransferStatus { return TransferStatus(-6) }
 
+// Transfer was skipped because the source file was locked by another process (see --skip-locked)
+func (TransferStatus) SkippedFileLocked() TransferStatus { return TransferStatus(-7) }
+
 func (ts TransferStatus) ShouldTransfer() bool {
 	return ts == ETransferStatus.NotStarted() || ts == ETransferStatus.Started()
 }
 
+// IsFailure returns whether the transfer ended in one of the failed states, as opposed to succeeding, being skipped or being cancelled
+func (ts TransferStatus) IsFailure() bool {
+	return ts == ETransferStatus.Failed() || ts == ETransferStatus.BlobTierFailure() || ts == ETransferStatus.TierAvailabilityCheckFailure()
+}
+
 // Transfer is any of the three possible state (InProgress, Completer or Failed)
 func (TransferStatus) All() TransferStatus { return TransferStatus(math.MaxInt8) }
 func (ts TransferStatus) String() string {
@@ -882,15 +1057,213 @@ func (i *InvalidMetadataHandleOption) UnmarshalJSON(b []byte) error {
 	return i.Parse(s)
 }
 
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var EProgressBasis = ProgressBasis(0)
+
+// ProgressBasis specifies what the percentage complete of a job is measured against
+type ProgressBasis uint8
+
+// Bytes measures progress by the bytes transferred. This is the default.
+func (ProgressBasis) Bytes() ProgressBasis { return ProgressBasis(0) }
+
+// Files measures progress by the number of transfers completed, regardless of their size.
+func (ProgressBasis) Files() ProgressBasis { return ProgressBasis(1) }
+
+// Auto blends the two, by treating each transfer as costing a fixed overhead in addition to its bytes.
+// Jobs of many small files are then measured mostly by file count, and jobs of large files mostly by bytes.
+func (ProgressBasis) Auto() ProgressBasis { return ProgressBasis(2) }
+
+// the number of bytes that one transfer is considered to be worth, on top of its size, when the basis is Auto
+const autoProgressBasisBytesPerTransfer = 1024 * 1024
+
+func (p ProgressBasis) String() string {
+	return enum.StringInt(p, reflect.TypeOf(p))
+}
+
+func (p *ProgressBasis) Parse(s string) error {
+	// allow empty to mean "Bytes"
+	if s == "" {
+		*p = EProgressBasis.Bytes()
+		return nil
+	}
+
+	val, err := enum.ParseInt(reflect.TypeOf(p), s, true, true)
+	if err == nil {
+		*p = val.(ProgressBasis)
+	}
+	return err
+}
+
+func (p ProgressBasis) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *ProgressBasis) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return p.Parse(s)
+}
+
+// PercentComplete computes the percentage complete, given how much of the job is done and how much is expected in total
+func (p ProgressBasis) PercentComplete(bytesDone, bytesExpected uint64, transfersDone, transfersExpected uint32) float32 {
+	var done, expected float64
+	switch p {
+	case EProgressBasis.Files():
+		done, expected = float64(transfersDone), float64(transfersExpected)
+	case EProgressBasis.Auto():
+		done = float64(bytesDone) + float64(transfersDone)*autoProgressBasisBytesPerTransfer
+		expected = float64(bytesExpected) + float64(transfersExpected)*autoProgressBasisBytesPerTransfer
+	default:
+		done, expected = float64(bytesDone), float64(bytesExpected)
+	}
+
+	if expected == 0 {
+		// if nothing is expected, we should avoid dividing by 0 (which results in NaN)
+		return 100
+	}
+	return float32(100 * done / expected)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var ETrailingDotOption = TrailingDotOption(0)
+
+// TrailingDotOption specifies how Azure Files treats names that end with a dot
+type TrailingDotOption uint8
+
+// Disable leaves the service to trim trailing dots from file and directory names, as it always has. This is the default.
+func (TrailingDotOption) Disable() TrailingDotOption { return TrailingDotOption(0) }
+
+// Enable asks the service to keep trailing dots, by sending the x-ms-allow-trailing-dot header on File requests.
+func (TrailingDotOption) Enable() TrailingDotOption { return TrailingDotOption(1) }
+
+func (t TrailingDotOption) String() string {
+	return enum.StringInt(t, reflect.TypeOf(t))
+}
+
+func (t *TrailingDotOption) Parse(s string) error {
+	// allow empty to mean "Disable"
+	if s == "" {
+		*t = ETrailingDotOption.Disable()
+		return nil
+	}
+
+	val, err := enum.ParseInt(reflect.TypeOf(t), s, true, true)
+	if err == nil {
+		*t = val.(TrailingDotOption)
+	}
+	return err
+}
+
+func (t TrailingDotOption) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *TrailingDotOption) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return t.Parse(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var ES2SPreference = S2SPreference(0)
+
+// S2SPreference says whether a copy between two remote locations is done by the service (server-to-server),
+// or by downloading the data through AzCopy and uploading it again
+type S2SPreference uint8
+
+// Prefer copies server-to-server wherever the locations allow it, as AzCopy always has. This is the default.
+func (S2SPreference) Prefer() S2SPreference { return S2SPreference(0) }
+
+// Never routes the data through AzCopy, even though the service could copy it.
+func (S2SPreference) Never() S2SPreference { return S2SPreference(1) }
+
+// Require refuses to run a copy that the service can't do server-to-server.
+func (S2SPreference) Require() S2SPreference { return S2SPreference(2) }
+
+func (p S2SPreference) String() string {
+	return enum.StringInt(p, reflect.TypeOf(p))
+}
+
+func (p *S2SPreference) Parse(s string) error {
+	// allow empty to mean "Prefer"
+	if s == "" {
+		*p = ES2SPreference.Prefer()
+		return nil
+	}
+
+	val, err := enum.ParseInt(reflect.TypeOf(p), s, true, true)
+	if err == nil {
+		*p = val.(S2SPreference)
+	}
+	return err
+}
+
+func (p S2SPreference) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *S2SPreference) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return p.Parse(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var EBlobExpiryOption = BlobExpiryOption(0)
+
+// BlobExpiryOption specifies how the expiry of each blob that a job writes is set, once its content has been written.
+// Only accounts with a hierarchical namespace support blob expiry.
+type BlobExpiryOption uint8
+
+// None leaves the expiry of the blobs alone. This is the default.
+func (BlobExpiryOption) None() BlobExpiryOption { return BlobExpiryOption(0) }
+
+// RelativeToNow makes each blob expire a given number of milliseconds after its expiry is set.
+func (BlobExpiryOption) RelativeToNow() BlobExpiryOption { return BlobExpiryOption(1) }
+
+// Absolute makes each blob expire at a given time.
+func (BlobExpiryOption) Absolute() BlobExpiryOption { return BlobExpiryOption(2) }
+
+// NeverExpire removes any expiry that the blobs already had.
+func (BlobExpiryOption) NeverExpire() BlobExpiryOption { return BlobExpiryOption(3) }
+
+// PreserveSource makes each blob expire when its source blob does, if the source blob has an expiry.
+func (BlobExpiryOption) PreserveSource() BlobExpiryOption { return BlobExpiryOption(4) }
+
+func (o BlobExpiryOption) String() string {
+	return enum.StringInt(o, reflect.TypeOf(o))
+}
+
+func (o *BlobExpiryOption) Parse(s string) error {
+	val, err := enum.ParseInt(reflect.TypeOf(o), s, true, true)
+	if err == nil {
+		*o = val.(BlobExpiryOption)
+	}
+	return err
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 const (
 	DefaultBlockBlobBlockSize      = 8 * 1024 * 1024
 	MaxBlockBlobBlockSize          = 4000 * 1024 * 1024
+	MaxPutBlockFromURLBlockSize    = 100 * 1024 * 1024 // for the service version that AzCopy uses
 	MaxAppendBlobBlockSize         = 4 * 1024 * 1024
 	DefaultPageBlobChunkSize       = 4 * 1024 * 1024
 	DefaultAzureFileChunkSize      = 4 * 1024 * 1024
 	MaxNumberOfBlocksPerBlob       = 50000
 	BlockSizeThreshold             = 256 * 1024 * 1024
+	MaxPageBlobSize                = 8 * 1024 * 1024 * 1024 * 1024
+	MaxAzureFileSize               = 4 * 1024 * 1024 * 1024 * 1024
 	MinParallelChunkCountThreshold = 4 /* minimum number of chunks in parallel for AzCopy to be performant. */
 )
 
@@ -918,6 +1291,9 @@ type CopyTransfer struct {
 	BlobVersionID string
 	// Blob index tags categorize data in your storage account utilizing key-value tag attributes
 	BlobTags BlobTags
+
+	// Priority is High if the transfer should be picked up ahead of those waiting. Otherwise, the transfer has the priority of its job
+	Priority JobPriority
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -925,6 +1301,10 @@ type CopyTransfer struct {
 // Metadata used in AzCopy.
 type Metadata map[string]string
 
+// FileAttributesMetadataKey is the metadata key under which the Windows attributes of an uploaded file are kept,
+// when the destination has no native place for them (i.e. blobs)
+const FileAttributesMetadataKey = "azcopy_file_attributes"
+
 // ToAzBlobMetadata converts metadata to azblob's metadata.
 func (m Metadata) ToAzBlobMetadata() azblob.Metadata {
 	return azblob.Metadata(m)
@@ -1278,11 +1658,13 @@ func (ct CompressionType) String() string {
 	return enum.StringInt(ct, reflect.TypeOf(ct))
 }
 
+// GetCompressionType returns the compression that a content encoding indicates. Identity is the same as no encoding at all,
+// and x-gzip is the old name of gzip, which HTTP still accepts.
 func GetCompressionType(contentEncoding string) (CompressionType, error) {
-	switch strings.ToLower(contentEncoding) {
-	case "":
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
 		return ECompressionType.None(), nil
-	case "gzip":
+	case "gzip", "x-gzip":
 		return ECompressionType.GZip(), nil
 	case "deflate":
 		return ECompressionType.ZLib(), nil
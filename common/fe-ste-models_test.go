@@ -173,3 +173,22 @@ func (s *feSteModelsTestSuite) TestMetadataResolveInvalidKeyNegative(c *chk.C) {
 	_, err = mNegative3.ResolveInvalidKey()
 	c.Assert(err, chk.NotNil)
 }
+
+func (s *feSteModelsTestSuite) TestProgressBasisPercentComplete(c *chk.C) {
+	// 1 MiB of 10 MiB done, in 9 of 10 files (i.e. nine tiny files done, and one big file still to go)
+	bytesDone, bytesExpected := uint64(1024*1024), uint64(10*1024*1024)
+	filesDone, filesExpected := uint32(9), uint32(10)
+
+	c.Assert(common.EProgressBasis.Bytes().PercentComplete(bytesDone, bytesExpected, filesDone, filesExpected), chk.Equals, float32(10))
+	c.Assert(common.EProgressBasis.Files().PercentComplete(bytesDone, bytesExpected, filesDone, filesExpected), chk.Equals, float32(90))
+	c.Assert(common.EProgressBasis.Auto().PercentComplete(bytesDone, bytesExpected, filesDone, filesExpected), chk.Equals, float32(50))
+
+	// nothing expected means nothing left to do
+	c.Assert(common.EProgressBasis.Files().PercentComplete(0, 0, 0, 0), chk.Equals, float32(100))
+
+	var basis common.ProgressBasis
+	c.Assert(basis.Parse("files"), chk.IsNil)
+	c.Assert(basis, chk.Equals, common.EProgressBasis.Files())
+	c.Assert(basis.Parse(""), chk.IsNil)
+	c.Assert(basis, chk.Equals, common.EProgressBasis.Bytes())
+}
@@ -0,0 +1,143 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPSourceInfo describes a plain HTTP(S) source, e.g. web-hosted content, as reported by the server's response headers
+type HTTPSourceInfo struct {
+	URL           url.URL // where the content actually is, after following any redirects
+	ContentLength int64   // -1 if the server did not say
+	AcceptsRanges bool
+	LastModified  time.Time // zero if the server did not say
+	Header        http.Header
+}
+
+// SupportsServerSideCopy returns true if the Azure service can fetch the source itself, in ranges, as it does for other S2S copies.
+// Otherwise the content must be streamed through AzCopy.
+func (i HTTPSourceInfo) SupportsServerSideCopy() bool {
+	return i.ContentLength >= 0 && i.AcceptsRanges
+}
+
+// CacheControl returns the value for header Cache-Control.
+func (i HTTPSourceInfo) CacheControl() string {
+	return i.Header.Get("Cache-Control")
+}
+
+// ContentDisposition returns the value for header Content-Disposition.
+func (i HTTPSourceInfo) ContentDisposition() string {
+	return i.Header.Get("Content-Disposition")
+}
+
+// ContentEncoding returns the value for header Content-Encoding.
+func (i HTTPSourceInfo) ContentEncoding() string {
+	return i.Header.Get("Content-Encoding")
+}
+
+// ContentLanguage returns the value for header Content-Language.
+func (i HTTPSourceInfo) ContentLanguage() string {
+	return i.Header.Get("Content-Language")
+}
+
+// ContentType returns the value for header Content-Type.
+func (i HTTPSourceInfo) ContentType() string {
+	return i.Header.Get("Content-Type")
+}
+
+// ContentMD5 returns the value for header Content-MD5.
+func (i HTTPSourceInfo) ContentMD5() []byte {
+	s := i.Header.Get("Content-MD5")
+	if s == "" {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		b = nil
+	}
+	return b
+}
+
+// ProbeHTTPSource finds out, without reading the content, where an HTTP(S) source really is and what it is.
+// Servers that don't allow HEAD are probed with a GET whose body is discarded unread.
+func ProbeHTTPSource(ctx context.Context, client *http.Client, source url.URL) (HTTPSourceInfo, error) {
+	resp, err := sendHTTPSourceRequest(ctx, client, http.MethodHead, source)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = sendHTTPSourceRequest(ctx, client, http.MethodGet, source)
+	}
+	if err != nil {
+		return HTTPSourceInfo{}, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return HTTPSourceInfo{}, fmt.Errorf("the source returned %s", resp.Status)
+	}
+
+	info := HTTPSourceInfo{
+		URL:           *resp.Request.URL,
+		ContentLength: resp.ContentLength,
+		AcceptsRanges: strings.EqualFold(strings.TrimSpace(resp.Header.Get("Accept-Ranges")), "bytes"),
+		Header:        resp.Header,
+	}
+	if lmt := resp.Header.Get("Last-Modified"); lmt != "" {
+		if t, err := http.ParseTime(lmt); err == nil {
+			info.LastModified = t
+		}
+	}
+	return info, nil
+}
+
+// OpenHTTPSource starts reading the content of an HTTP(S) source. The caller must close the returned body.
+func OpenHTTPSource(ctx context.Context, client *http.Client, source url.URL) (io.ReadCloser, error) {
+	resp, err := sendHTTPSourceRequest(ctx, client, http.MethodGet, source)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("the source returned %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func sendHTTPSourceRequest(ctx context.Context, client *http.Client, method string, source url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, source.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// ask for the content exactly as stored, as the service will when it fetches the source itself,
+	// rather than letting the client transparently decompress it (which would also hide its length)
+	req.Header.Set("Accept-Encoding", "identity")
+	req.Header.Set("User-Agent", UserAgent)
+	return client.Do(req) // redirects are followed by the client
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"runtime"
@@ -48,6 +49,7 @@ type LifecycleMgr interface {
 	Progress(OutputBuilder)                                      // print on the same line over and over again, not allowed to float up
 	Exit(OutputBuilder, ExitCode)                                // indicates successful execution exit after printing, allow user to specify exit code
 	Info(string)                                                 // simple print, allowed to float up
+	Output(OutputBuilder)                                        // print output that is built for the current format, such as the entries of a listing. It's info, allowed to float up
 	Error(string)                                                // indicates fatal error, exit after printing, exit code is always Failed (1)
 	Prompt(message string, details PromptDetails) ResponseOption // ask the user a question(after erasing the progress), then return the response
 	SurrenderControl()                                           // give up control, this should never return
@@ -56,6 +58,9 @@ type LifecycleMgr interface {
 	GetEnvironmentVariable(EnvironmentVariable) string           // get the environment variable or its default value
 	ClearEnvironmentVariable(EnvironmentVariable)                // clears the environment variable
 	SetOutputFormat(OutputFormat)                                // change the output format of the entire application
+	SetQuiet(bool)                                               // print nothing but errors, and the summary of a job that failed, to stderr
+	SetSummaryFile(string)                                       // also write the summary printed at the end of the command to this file
+	SetProgressSocket(string) error                              // also stream the JSON output records to a Unix domain socket or named pipe at this path
 	EnableInputWatcher()                                         // depending on the command, we may allow user to give input through Stdin
 	EnableCancelFromStdIn()                                      // allow user to send in `cancel` to stop the job
 	AddUserAgentPrefix(string) string                            // append the global user agent prefix, if applicable
@@ -78,11 +83,14 @@ type lifecycleMgr struct {
 	waitEverCalled        int32
 	outputFormat          OutputFormat
 	logSanitizer          pipeline.LogSanitizer
-	inputQueue            chan userInput // msgs from the user
-	allowWatchInput       bool           // accept user inputs and place then in the inputQueue
-	allowCancelFromStdIn  bool           // allow user to send in 'cancel' from the stdin to stop the current job
-	e2eAllowAwaitContinue bool           // allow the user to send 'continue' from stdin to start the current job
-	e2eAllowAwaitOpen     bool           // allow the user to send 'open' from stdin to allow the opening of the first file
+	inputQueue            chan userInput  // msgs from the user
+	allowWatchInput       bool            // accept user inputs and place then in the inputQueue
+	allowCancelFromStdIn  bool            // allow user to send in 'cancel' from the stdin to stop the current job
+	e2eAllowAwaitContinue bool            // allow the user to send 'continue' from stdin to start the current job
+	e2eAllowAwaitOpen     bool            // allow the user to send 'open' from stdin to allow the opening of the first file
+	quiet                 bool            // print nothing but errors, and the summary of a job that failed, to stderr
+	summaryFile           string          // where the summary printed at the end of the command is also written, if anywhere
+	progressSocket        *progressSocket // where the JSON output records are also streamed, if anywhere
 }
 
 type userInput struct {
@@ -154,6 +162,32 @@ func (lcm *lifecycleMgr) SetOutputFormat(format OutputFormat) {
 	lcm.outputFormat = format
 }
 
+func (lcm *lifecycleMgr) SetQuiet(quiet bool) {
+	lcm.quiet = quiet
+}
+
+func (lcm *lifecycleMgr) SetSummaryFile(path string) {
+	lcm.summaryFile = path
+}
+
+func (lcm *lifecycleMgr) SetProgressSocket(path string) error {
+	socket, err := newProgressSocket(path)
+	if err != nil {
+		return err
+	}
+	lcm.progressSocket = socket
+	return nil
+}
+
+// sendToProgressSocket streams the JSON form of a message to the progress socket, if there is one,
+// regardless of the output format chosen for the console
+func (lcm *lifecycleMgr) sendToProgressSocket(msgType outputMessageType, content func() string) {
+	if lcm.progressSocket == nil {
+		return
+	}
+	lcm.progressSocket.send(GetJsonStringFromTemplate(newJsonOutputTemplate(msgType, content(), PromptDetails{})))
+}
+
 func (lcm *lifecycleMgr) checkAndStartCPUProfiling() {
 	// CPU Profiling add-on. Set AZCOPY_PROFILE_CPU to enable CPU profiling,
 	// the value AZCOPY_PROFILE_CPU indicates the path to save CPU profiling data.
@@ -200,6 +234,8 @@ func (lcm *lifecycleMgr) checkAndTriggerMemoryProfiling() {
 }
 
 func (lcm *lifecycleMgr) Init(o OutputBuilder) {
+	lcm.sendToProgressSocket(eOutputMessageType.Init(), func() string { return o(EOutputFormat.Json()) })
+
 	lcm.msgQueue <- outputMessage{
 		msgContent: o(lcm.outputFormat),
 		msgType:    eOutputMessageType.Init(),
@@ -210,6 +246,7 @@ func (lcm *lifecycleMgr) Progress(o OutputBuilder) {
 	messageContent := ""
 	if o != nil {
 		messageContent = o(lcm.outputFormat)
+		lcm.sendToProgressSocket(eOutputMessageType.Progress(), func() string { return o(EOutputFormat.Json()) })
 	}
 
 	lcm.msgQueue <- outputMessage{
@@ -223,6 +260,7 @@ func (lcm *lifecycleMgr) Info(msg string) {
 	msg = lcm.logSanitizer.SanitizeLogMessage(msg) // sometimes error-like text comes through Info, before the final "we've failed, please stop now" signal comes to Error. So we sanitize in both places.
 
 	infoMsg := fmt.Sprintf("INFO: %v", msg)
+	lcm.sendToProgressSocket(eOutputMessageType.Info(), func() string { return infoMsg })
 
 	lcm.msgQueue <- outputMessage{
 		msgContent: infoMsg,
@@ -230,6 +268,15 @@ func (lcm *lifecycleMgr) Info(msg string) {
 	}
 }
 
+func (lcm *lifecycleMgr) Output(o OutputBuilder) {
+	lcm.sendToProgressSocket(eOutputMessageType.Info(), func() string { return o(EOutputFormat.Json()) })
+
+	lcm.msgQueue <- outputMessage{
+		msgContent: o(lcm.outputFormat),
+		msgType:    eOutputMessageType.Info(),
+	}
+}
+
 func (lcm *lifecycleMgr) Prompt(message string, details PromptDetails) ResponseOption {
 	expectedInputChannel := make(chan string, 1)
 	lcm.msgQueue <- outputMessage{
@@ -268,6 +315,9 @@ func (lcm *lifecycleMgr) Error(msg string) {
 	// Check if there is ongoing CPU profiling, and stop CPU profiling.
 	lcm.checkAndStopCPUProfiling()
 
+	lcm.sendToProgressSocket(eOutputMessageType.Error(), func() string { return msg })
+	lcm.closeProgressSocket()
+
 	lcm.msgQueue <- outputMessage{
 		msgContent: msg,
 		msgType:    eOutputMessageType.Error(),
@@ -290,6 +340,20 @@ func (lcm *lifecycleMgr) Exit(o OutputBuilder, applicationExitCode ExitCode) {
 	messageContent := ""
 	if o != nil {
 		messageContent = o(lcm.outputFormat)
+		lcm.sendToProgressSocket(eOutputMessageType.EndOfJob(), func() string { return o(EOutputFormat.Json()) })
+	}
+	if applicationExitCode != EExitCode.NoExit() {
+		lcm.closeProgressSocket()
+	}
+
+	if lcm.summaryFile != "" && messageContent != "" {
+		if err := ioutil.WriteFile(lcm.summaryFile, []byte(messageContent+"\n"), 0644); err != nil {
+			// the summary isn't where the caller expects it, so the command can't be said to have succeeded
+			fmt.Fprintf(os.Stderr, "cannot write the summary to %s: %s\n", lcm.summaryFile, err.Error())
+			if applicationExitCode == EExitCode.Success() {
+				applicationExitCode = EExitCode.Error()
+			}
+		}
 	}
 
 	lcm.msgQueue <- outputMessage{
@@ -304,6 +368,13 @@ func (lcm *lifecycleMgr) Exit(o OutputBuilder, applicationExitCode ExitCode) {
 	}
 }
 
+// closeProgressSocket lets the consumers know there is nothing more to come, and removes the socket
+func (lcm *lifecycleMgr) closeProgressSocket() {
+	if lcm.progressSocket != nil {
+		lcm.progressSocket.close()
+	}
+}
+
 // this is used by commands that wish to stall forever to wait for the operations to complete
 func (lcm *lifecycleMgr) SurrenderControl() {
 	// stall forever
@@ -316,6 +387,11 @@ func (lcm *lifecycleMgr) processOutputMessage() {
 	for {
 		msgToPrint := <-lcm.msgQueue
 
+		if lcm.quiet && lcm.outputFormat != EOutputFormat.None() {
+			lcm.processQuietOutput(msgToPrint)
+			continue
+		}
+
 		switch lcm.outputFormat {
 		case EOutputFormat.Json():
 			lcm.processJSONOutput(msgToPrint)
@@ -340,6 +416,30 @@ func (lcm *lifecycleMgr) processNoneOutput(msgToOutput outputMessage) {
 	return
 }
 
+// processQuietOutput prints only what a script needs to see, to stderr: errors, and the summary of a job that failed.
+// Prompts are still shown, as usual, since they must be answered.
+func (lcm *lifecycleMgr) processQuietOutput(msgToOutput outputMessage) {
+	switch msgToOutput.msgType {
+	case eOutputMessageType.Prompt():
+		if lcm.outputFormat == EOutputFormat.Json() {
+			lcm.processJSONOutput(msgToOutput)
+		} else {
+			lcm.processTextOutput(msgToOutput)
+		}
+		return
+	case eOutputMessageType.Error():
+		fmt.Fprintln(os.Stderr, msgToOutput.msgContent)
+	case eOutputMessageType.EndOfJob():
+		if (msgToOutput.exitCode == EExitCode.Error() || msgToOutput.exitCode == EExitCode.DeadlineReached()) && msgToOutput.msgContent != "" {
+			fmt.Fprintln(os.Stderr, msgToOutput.msgContent)
+		}
+	}
+
+	if msgToOutput.shouldExitProcess() {
+		os.Exit(int(msgToOutput.exitCode))
+	}
+}
+
 func (lcm *lifecycleMgr) processJSONOutput(msgToOutput outputMessage) {
 	msgType := msgToOutput.msgType
 	questionTime := time.Now()
@@ -21,11 +21,13 @@
 package common
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/url"
 	"os"
 	"path"
+	"regexp"
 	"runtime"
 	"strings"
 	"time"
@@ -47,6 +49,8 @@ type ILoggerCloser interface {
 type ILoggerResetable interface {
 	OpenLog()
 	MinimumLogLevel() pipeline.LogLevel
+	// LogForTransfer is like Log, but also records the path of the transfer that the message relates to
+	LogForTransfer(level pipeline.LogLevel, transferPath string, msg string)
 	ILoggerCloser
 }
 
@@ -107,14 +111,18 @@ type jobLogger struct {
 	// any message with severity higher than this will be ignored.
 	jobID             JobID
 	minimumLevelToLog pipeline.LogLevel // The maximum customer-desired log level for this job
-	file              *os.File          // The job's log file
+	format            LogFormat         // Whether entries are written as text or as JSON objects
+	file              *rotatingLogFile  // The job's log file
 	logFileFolder     string            // The log file's parent folder, needed for opening the file at the right place
+	rotation          LogRotationPolicy // When the log file is rotated, to keep the disk space it takes bounded
 	logger            *log.Logger       // The Job's logger
 	appLogger         ILogger
 	sanitizer         pipeline.LogSanitizer
+	remoteTarget      *url.URL       // Where entries are also sent, if anywhere
+	remote            *remoteLogSink // Sends entries to remoteTarget, once the log is open
 }
 
-func NewJobLogger(jobID JobID, minimumLevelToLog LogLevel, appLogger ILogger, logFileFolder string) ILoggerResetable {
+func NewJobLogger(jobID JobID, minimumLevelToLog LogLevel, format LogFormat, appLogger ILogger, logFileFolder string, rotation LogRotationPolicy, remoteTarget *url.URL) ILoggerResetable {
 	if appLogger == nil {
 		panic("You must pass a appLogger when creating a JobLogger")
 	}
@@ -123,8 +131,11 @@ func NewJobLogger(jobID JobID, minimumLevelToLog LogLevel, appLogger ILogger, lo
 		jobID:             jobID,
 		appLogger:         appLogger, // Panics are recorded in the job log AND in the app log
 		minimumLevelToLog: minimumLevelToLog.ToPipelineLogLevel(),
+		format:            format,
 		logFileFolder:     logFileFolder,
+		rotation:          rotation,
 		sanitizer:         NewAzCopyLogSanitizer(),
+		remoteTarget:      remoteTarget,
 	}
 }
 
@@ -133,22 +144,33 @@ func (jl *jobLogger) OpenLog() {
 		return
 	}
 
-	file, err := os.OpenFile(path.Join(jl.logFileFolder, jl.jobID.String()+".log"),
-		os.O_RDWR|os.O_CREATE|os.O_APPEND, DEFAULT_FILE_PERM)
+	file, err := newRotatingLogFile(path.Join(jl.logFileFolder, jl.jobID.String()+".log"), jl.rotation, "")
 	PanicIfErr(err)
 
 	jl.file = file
 
 	flags := log.LstdFlags | log.LUTC
+	if jl.format == ELogFormat.Json() {
+		flags = 0 // each entry carries its own timestamp
+	}
 	utcMessage := fmt.Sprintf("Log times are in UTC. Local time is " + time.Now().Format("2 Jan 2006 15:04:05"))
 
 	jl.logger = log.New(jl.file, "", flags)
+	if jl.remoteTarget != nil {
+		jl.remote = newRemoteLogSink(jl.remoteTarget, jl.jobID, jl.format)
+	}
 	// Log the Azcopy Version
-	jl.logger.Println("AzcopyVersion ", AzcopyVersion)
+	jl.println(pipeline.LogInfo, logHeaderLine("AzcopyVersion ", AzcopyVersion))
 	// Log the OS Environment and OS Architecture
-	jl.logger.Println("OS-Environment ", runtime.GOOS)
-	jl.logger.Println("OS-Architecture ", runtime.GOARCH)
-	jl.logger.Println(utcMessage)
+	jl.println(pipeline.LogInfo, logHeaderLine("OS-Environment ", runtime.GOOS))
+	jl.println(pipeline.LogInfo, logHeaderLine("OS-Architecture ", runtime.GOARCH))
+	jl.println(pipeline.LogInfo, utcMessage)
+}
+
+// logHeaderLine formats the operands the way log.Println did when it wrote the header, which is with a space between
+// every operand, but without the line ending, which println adds
+func logHeaderLine(v ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(v...), "\n")
 }
 
 func (jl *jobLogger) MinimumLogLevel() pipeline.LogLevel {
@@ -167,34 +189,108 @@ func (jl *jobLogger) CloseLog() {
 		return
 	}
 
-	jl.logger.Println("Closing Log")
+	jl.println(pipeline.LogInfo, "Closing Log")
+	if jl.remote != nil {
+		// nothing more may be sent once the sink is closed, so anything written after this only goes to the file
+		remote := jl.remote
+		jl.remote = nil
+		if dropped := remote.Close(); dropped > 0 {
+			jl.println(pipeline.LogWarning, fmt.Sprintf("%d log entries could not be sent to %s", dropped, jl.remoteTarget.Redacted()))
+		}
+	}
 	err := jl.file.Close()
 	PanicIfErr(err)
 }
 
 func (jl jobLogger) Log(loglevel pipeline.LogLevel, msg string) {
+	jl.LogForTransfer(loglevel, "", msg)
+}
+
+func (jl jobLogger) LogForTransfer(loglevel pipeline.LogLevel, transferPath string, msg string) {
 	// If the logger for Job is not initialized i.e file is not open
 	// or logger instance is not initialized, then initialize it
 
 	// ensure all secrets are redacted
 	msg = jl.sanitizer.SanitizeLogMessage(msg)
 
-	// Go, and therefore the sdk, defaults to \n for line endings, so if the platform has a different line ending,
-	// we should replace them to ensure readability on the given platform.
-	if lineEnding != "\n" {
-		msg = strings.Replace(msg, "\n", lineEnding, -1)
-	}
 	if jl.ShouldLog(loglevel) {
+		if jl.format == ELogFormat.Json() {
+			entry := jl.jsonEntry(loglevel, jl.sanitizer.SanitizeLogMessage(transferPath), msg)
+			jl.logger.Println(entry)
+			jl.sendToRemote(loglevel, entry)
+			return
+		}
+
+		// Go, and therefore the sdk, defaults to \n for line endings, so if the platform has a different line ending,
+		// we should replace them to ensure readability on the given platform.
+		if lineEnding != "\n" {
+			msg = strings.Replace(msg, "\n", lineEnding, -1)
+		}
 		jl.logger.Println(msg)
+		jl.sendToRemote(loglevel, msg)
+	}
+}
+
+// sendToRemote also sends the entry to the remote log, if there is one. It never blocks.
+func (jl jobLogger) sendToRemote(level pipeline.LogLevel, entry string) {
+	if jl.remote != nil {
+		jl.remote.Log(level, entry)
 	}
 }
 
 func (jl jobLogger) Panic(err error) {
-	jl.logger.Println(err)  // We do NOT panic here as the app would terminate; we just log it
-	jl.appLogger.Panic(err) // We panic here that it logs and the app terminates
+	jl.println(pipeline.LogPanic, err.Error()) // We do NOT panic here as the app would terminate; we just log it
+	jl.appLogger.Panic(err)                    // We panic here that it logs and the app terminates
 	// We should never reach this line of code!
 }
 
+// println writes the message regardless of the log level, in the job's log format
+func (jl jobLogger) println(level pipeline.LogLevel, msg string) {
+	if jl.format == ELogFormat.Json() {
+		msg = jl.jsonEntry(level, "", msg)
+	}
+	jl.logger.Println(msg)
+	jl.sendToRemote(level, msg)
+}
+
+// jobLogEntry is one line of a log written in the JSON format.
+// The request ID and error code are lifted out of the message, so that entries can be filtered by them.
+type jobLogEntry struct {
+	Level        string `json:"level"`
+	Timestamp    string `json:"timestamp"`
+	JobID        string `json:"jobId"`
+	TransferPath string `json:"transferPath,omitempty"`
+	RequestID    string `json:"requestId,omitempty"`
+	ErrorCode    string `json:"errorCode,omitempty"`
+	Message      string `json:"message"`
+}
+
+// these match the way the SDKs and our request log policy write response headers and storage errors into log messages
+var requestIDInLogRegex = regexp.MustCompile(`X-Ms-Request-Id: \[([^\]]+)\]`)
+var errorCodeInLogRegex = regexp.MustCompile(`X-Ms-Error-Code: \[([^\]]+)\]|ServiceCode=([A-Za-z]+)`)
+
+func (jl jobLogger) jsonEntry(level pipeline.LogLevel, transferPath string, msg string) string {
+	entry := jobLogEntry{
+		Level:        LogLevel(level).String(),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+		JobID:        jl.jobID.String(),
+		TransferPath: transferPath,
+		Message:      strings.TrimRight(msg, "\n"),
+	}
+	if m := requestIDInLogRegex.FindStringSubmatch(msg); m != nil {
+		entry.RequestID = m[1]
+	}
+	if m := errorCodeInLogRegex.FindStringSubmatch(msg); m != nil {
+		entry.ErrorCode = m[1] + m[2] // only one of the alternatives matches
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return msg // cannot happen, since the entry contains only strings
+	}
+	return string(b)
+}
+
 const TryEquals string = "Try=" // TODO: refactor so that this can be used by the retry policies too?  So that when you search the logs for Try= you are guaranteed to find both types of retry (i.e. request send retries, and body read retries)
 
 func NewReadLogFunc(logger ILogger, fullUrl *url.URL) func(int, error, int64, int64, bool) {
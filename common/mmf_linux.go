@@ -26,6 +26,8 @@ import (
 	"os"
 	"sync"
 	"syscall"
+
+	"golang.org/x/sys/unix"
 )
 
 const lineEnding = "\n"
@@ -90,3 +92,13 @@ func (m *MMF) UnuseMMF() {
 func (m *MMF) Slice() []byte {
 	return m.slice
 }
+
+// Flush writes any modified pages of the MMF back to the underlying file, and waits until they have been written.
+// It does nothing if the MMF has already been unmapped.
+func (m *MMF) Flush() error {
+	if !m.UseMMF() {
+		return nil
+	}
+	defer m.UnuseMMF()
+	return unix.Msync(m.slice, unix.MS_SYNC)
+}
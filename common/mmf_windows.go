@@ -150,3 +150,14 @@ func prefetchVirtualMemory(virtualAddresses *memoryRangeEntry) (err error) {
 	}
 	return nil
 }
+
+// Flush writes any modified pages of the MMF back to the underlying file.
+// It does nothing if the MMF has already been unmapped.
+func (m *MMF) Flush() error {
+	if !m.UseMMF() {
+		return nil
+	}
+	defer m.UnuseMMF()
+	addr := uintptr(unsafe.Pointer(&(([]byte)(m.slice)[0])))
+	return syscall.FlushViewOfFile(addr, uintptr(m.length))
+}
@@ -631,22 +631,23 @@ type SPNInfo struct {
 
 // Validate validates identity info, at most only one of clientID, objectID or MSI resource ID could be set.
 func (identityInfo *IdentityInfo) Validate() error {
-	v := make(map[string]bool, 3)
-	if identityInfo.ClientID != "" {
-		v[identityInfo.ClientID] = true
-	}
-	if identityInfo.ObjectID != "" {
-		v[identityInfo.ObjectID] = true
-	}
-	if identityInfo.MSIResID != "" {
-		v[identityInfo.MSIResID] = true
+	count := 0
+	for _, selector := range []string{identityInfo.ClientID, identityInfo.ObjectID, identityInfo.MSIResID} {
+		if selector != "" {
+			count++
+		}
 	}
-	if len(v) > 1 {
+	if count > 1 {
 		return errors.New("client ID, object ID and MSI resource ID are mutually exclusive")
 	}
 	return nil
 }
 
+// isUserAssigned returns true if a particular identity has been selected, rather than the system-assigned one
+func (identityInfo *IdentityInfo) isUserAssigned() bool {
+	return identityInfo.ClientID != "" || identityInfo.ObjectID != "" || identityInfo.MSIResID != ""
+}
+
 // Refresh gets new token with token info.
 func (credInfo *OAuthTokenInfo) Refresh(ctx context.Context) (*adal.Token, error) {
 	if credInfo.TokenRefreshSource == TokenRefreshSourceTokenStore {
@@ -716,7 +717,7 @@ func (credInfo *OAuthTokenInfo) GetNewTokenFromMSI(ctx context.Context) (*adal.T
 	req.URL.RawQuery = params.Encode()
 	req.Header.Set("Metadata", "true")
 	// Set context.
-	req.WithContext(ctx)
+	req = req.WithContext(ctx)
 
 	// Send request
 	resp, err := msiTokenHTTPClient.Do(req)
@@ -731,7 +732,13 @@ func (credInfo *OAuthTokenInfo) GetNewTokenFromMSI(ctx context.Context) (*adal.T
 	// Check if the status code indicates success
 	// The request returns 200 currently, add 201 and 202 as well for possible extension.
 	if !(HTTPResponseExtension{Response: resp}).IsSuccessStatusCode(http.StatusOK, http.StatusCreated, http.StatusAccepted) {
-		return nil, fmt.Errorf("failed to get token from msi, status code: %v", resp.StatusCode)
+		body, _ := ioutil.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusBadRequest && !credInfo.IdentityInfo.isUserAssigned() {
+			// IMDS can't choose for us when the VM has more than one user-assigned identity
+			return nil, fmt.Errorf("failed to get token from msi, status code: %v. If this machine has more than one managed identity, "+
+				"select one with --identity-client-id, --identity-object-id or --identity-resource-id. (Error details: %s)", resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("failed to get token from msi, status code: %v. (Error details: %s)", resp.StatusCode, string(body))
 	}
 
 	b, err := ioutil.ReadAll(resp.Body)
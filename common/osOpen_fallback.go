@@ -16,3 +16,9 @@ func OSOpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
 func OSStat(name string) (os.FileInfo, error) {
 	return os.Stat(name)
 }
+
+// IsLockedFileError always returns false on this OS, since locks taken by other processes are advisory
+// and do not prevent us from opening the file
+func IsLockedFileError(err error) bool {
+	return false
+}
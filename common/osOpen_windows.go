@@ -3,7 +3,10 @@
 package common
 
 import (
+	"errors"
 	"os"
+
+	"golang.org/x/sys/windows"
 )
 
 // NOTE: this is not safe to use on directories.  It returns an os.File that points at a directory, but thinks it points to a file.
@@ -34,3 +37,9 @@ func OSOpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
 func OSStat(name string) (os.FileInfo, error) {
 	return os.Stat(name) // this is safe even with our --backup mode, because it uses FILE_FLAG_BACKUP_SEMANTICS (whereas os.File.Stat() does not)
 }
+
+// IsLockedFileError returns true if the error indicates that the file could not be opened because another
+// process holds it open with an incompatible sharing mode, or has a byte-range lock on it.
+func IsLockedFileError(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
@@ -0,0 +1,457 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// how many ended spans can be waiting to be sent before new ones are dropped
+	otelQueueLength = 10000
+
+	// spans are sent when this many have ended, or when otelBatchInterval has passed, whichever is sooner
+	otelBatchSize     = 512
+	otelBatchInterval = 5 * time.Second
+
+	// how long each request to the collector may take
+	otelSendTimeout = 10 * time.Second
+
+	// where spans are sent if the environment doesn't say otherwise: a collector on this machine, as the OpenTelemetry specification says
+	defaultOTLPTracesEndpoint = "http://localhost:4318/v1/traces"
+)
+
+// TraceContext identifies a span, and the trace that it belongs to, as in the W3C Trace Context recommendation
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid says whether the context identifies a span. The zero TraceContext doesn't.
+func (tc TraceContext) IsValid() bool {
+	return tc.TraceID != [16]byte{} && tc.SpanID != [8]byte{}
+}
+
+// TraceParent formats the context as the value of a W3C traceparent header
+func (tc TraceContext) TraceParent() string {
+	return fmt.Sprintf("00-%x-%x-01", tc.TraceID, tc.SpanID)
+}
+
+// ParseTraceParent reads the value of a W3C traceparent header, such as the TRACEPARENT environment variable
+// that CI systems and tracing tools set for the processes that they start.
+func ParseTraceParent(traceParent string) (TraceContext, error) {
+	var tc TraceContext
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || strings.EqualFold(parts[0], "ff") || (parts[0] == "00" && len(parts) != 4) ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tc, fmt.Errorf("%q is not a valid traceparent", traceParent)
+	}
+	if _, err := hex.Decode(tc.TraceID[:], []byte(parts[1])); err != nil {
+		return tc, fmt.Errorf("%q is not a valid traceparent: %w", traceParent, err)
+	}
+	if _, err := hex.Decode(tc.SpanID[:], []byte(parts[2])); err != nil {
+		return tc, fmt.Errorf("%q is not a valid traceparent: %w", traceParent, err)
+	}
+	if !tc.IsValid() {
+		return tc, fmt.Errorf("%q is not a valid traceparent, since its IDs are zero", traceParent)
+	}
+	return tc, nil
+}
+
+// OTLPTracer sends spans, in batches and in the background, to an OpenTelemetry collector, using OTLP over HTTP with JSON encoding.
+// Like the remote log sink, it is best effort: spans are dropped, rather than ever slowing down a job, if the collector can't keep up
+// or can't be reached. A nil *OTLPTracer is valid, and creates no spans, so callers need not check whether tracing is on.
+type OTLPTracer struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	httpClient  *http.Client
+	spans       chan otlpSpan
+	flushes     chan chan struct{}
+
+	atomicDropped int64
+}
+
+// NewOTLPTracerFromEnv creates a tracer that is configured by the standard OpenTelemetry environment variables, as read by getenv:
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_TRACES_HEADERS or OTEL_EXPORTER_OTLP_HEADERS,
+// OTEL_EXPORTER_OTLP_TRACES_PROTOCOL or OTEL_EXPORTER_OTLP_PROTOCOL, and OTEL_SERVICE_NAME.
+// Spans are always sent as JSON, which the OTLP/HTTP specification requires collectors to accept, so the gRPC protocol is not supported.
+func NewOTLPTracerFromEnv(getenv func(string) string) (*OTLPTracer, error) {
+	firstOf := func(names ...string) string {
+		for _, name := range names {
+			if v := strings.TrimSpace(getenv(name)); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	if protocol := firstOf("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/json" && protocol != "http/protobuf" {
+		return nil, fmt.Errorf("the OTLP protocol %q is not supported. AzCopy sends spans over HTTP, so use http/json or http/protobuf", protocol)
+	}
+
+	endpoint := firstOf("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := firstOf("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces" // the base endpoint is for all signals, so the path for traces is added to it
+		} else {
+			endpoint = defaultOTLPTracesEndpoint
+		}
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("the OTLP endpoint %q must be an http:// or https:// URL", endpoint)
+	}
+
+	headers, err := parseOTLPHeaders(firstOf("OTEL_EXPORTER_OTLP_TRACES_HEADERS", "OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := firstOf("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "azcopy"
+	}
+
+	t := &OTLPTracer{
+		endpoint:    endpoint,
+		headers:     headers,
+		serviceName: serviceName,
+		httpClient:  &http.Client{Timeout: otelSendTimeout},
+		spans:       make(chan otlpSpan, otelQueueLength),
+		flushes:     make(chan chan struct{}),
+	}
+	go t.sendAll()
+	return t, nil
+}
+
+// parseOTLPHeaders reads headers in the form of the OTEL_EXPORTER_OTLP_HEADERS environment variable: key1=value1,key2=value2,
+// in which the values are URL encoded
+func parseOTLPHeaders(raw string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" {
+			return nil, fmt.Errorf("the OTLP header %q must be in the form key=value", strings.TrimSpace(pair))
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("the value of the OTLP header %q is not URL encoded correctly: %w", key, err)
+		}
+		headers[key] = value
+	}
+	return headers, nil
+}
+
+// StartSpan starts a span with the given name, as a child of parent if that is valid, or otherwise at the root of a new trace
+func (t *OTLPTracer) StartSpan(name string, parent TraceContext) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{tracer: t, name: name, start: time.Now()}
+	if parent.IsValid() {
+		s.context.TraceID = parent.TraceID
+		s.parentSpanID = parent.SpanID
+	} else {
+		_, _ = rand.Read(s.context.TraceID[:])
+	}
+	_, _ = rand.Read(s.context.SpanID[:])
+	return s
+}
+
+// Flush sends the spans that have ended so far, and waits up to timeout for that to be done
+func (t *OTLPTracer) Flush(timeout time.Duration) {
+	if t == nil {
+		return
+	}
+	done := make(chan struct{})
+	select {
+	case t.flushes <- done:
+		select {
+		case <-done:
+		case <-time.After(timeout):
+		}
+	case <-time.After(timeout):
+	}
+}
+
+// Dropped returns the number of spans that could not be sent
+func (t *OTLPTracer) Dropped() int64 {
+	if t == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&t.atomicDropped)
+}
+
+func (t *OTLPTracer) enqueue(s otlpSpan) {
+	select {
+	case t.spans <- s:
+	default:
+		atomic.AddInt64(&t.atomicDropped, 1)
+	}
+}
+
+func (t *OTLPTracer) sendAll() {
+	ticker := time.NewTicker(otelBatchInterval)
+	defer ticker.Stop()
+
+	var batch []otlpSpan
+	for {
+		select {
+		case s := <-t.spans:
+			batch = append(batch, s)
+			if len(batch) >= otelBatchSize {
+				t.send(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			t.send(batch)
+			batch = nil
+		case done := <-t.flushes:
+		drain:
+			for {
+				select {
+				case s := <-t.spans:
+					batch = append(batch, s)
+				default:
+					break drain
+				}
+			}
+			t.send(batch)
+			batch = nil
+			close(done)
+		}
+	}
+}
+
+func (t *OTLPTracer) send(batch []otlpSpan) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(t.request(batch))
+	if err == nil {
+		var req *http.Request
+		req, err = http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			for k, v := range t.headers {
+				req.Header.Set(k, v)
+			}
+			var resp *http.Response
+			resp, err = t.httpClient.Do(req)
+			if err == nil {
+				_ = resp.Body.Close()
+				if resp.StatusCode >= 300 {
+					err = fmt.Errorf("the collector returned %s", resp.Status)
+				}
+			}
+		}
+	}
+	if err != nil {
+		atomic.AddInt64(&t.atomicDropped, int64(len(batch)))
+	}
+}
+
+func (t *OTLPTracer) request(batch []otlpSpan) otlpTraceRequest {
+	return otlpTraceRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			newOTLPAttribute("service.name", t.serviceName),
+			newOTLPAttribute("service.version", AzcopyVersion),
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/Azure/azure-storage-azcopy", Version: AzcopyVersion},
+			Spans: batch,
+		}},
+	}}}
+}
+
+// Span is an operation that is traced, such as a job or a transfer. A nil *Span is valid, and does nothing.
+type Span struct {
+	tracer       *OTLPTracer
+	name         string
+	context      TraceContext
+	parentSpanID [8]byte
+	start        time.Time
+
+	mu           sync.Mutex
+	attributes   []otlpAttribute
+	errorMessage string
+	failed       bool
+	ended        bool
+}
+
+// Context returns the span's context, for starting child spans
+func (s *Span) Context() TraceContext {
+	if s == nil {
+		return TraceContext{}
+	}
+	return s.context
+}
+
+// SetAttribute records a string, integer or boolean attribute of the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes = append(s.attributes, newOTLPAttribute(key, value))
+}
+
+// SetError marks the span as failed, with the given description
+func (s *Span) SetError(message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.errorMessage = message
+}
+
+// End ends the span, and queues it to be sent. Only the first call has any effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.context.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.context.SpanID[:]),
+		Name:              s.name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(time.Now().UnixNano(), 10),
+		Attributes:        s.attributes,
+		Status:            otlpStatus{Code: otlpStatusCodeOk},
+	}
+	if s.parentSpanID != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parentSpanID[:])
+	}
+	if s.failed {
+		span.Status = otlpStatus{Code: otlpStatusCodeError, Message: s.errorMessage}
+	}
+	s.tracer.enqueue(span)
+}
+
+// The types below are the JSON encoding of an OTLP ExportTraceServiceRequest. As that encoding requires,
+// IDs are hex strings, and 64 bit integers are decimal strings.
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeOk     = 1
+	otlpStatusCodeError  = 2
+)
+
+type otlpTraceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func newOTLPAttribute(key string, value interface{}) otlpAttribute {
+	var v otlpAnyValue
+	switch value := value.(type) {
+	case bool:
+		v.BoolValue = &value
+	case int:
+		s := strconv.FormatInt(int64(value), 10)
+		v.IntValue = &s
+	case int32:
+		s := strconv.FormatInt(int64(value), 10)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		v.IntValue = &s
+	case uint32:
+		s := strconv.FormatUint(uint64(value), 10)
+		v.IntValue = &s
+	default:
+		s := fmt.Sprint(value)
+		v.StringValue = &s
+	}
+	return otlpAttribute{Key: key, Value: v}
+}
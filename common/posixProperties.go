@@ -0,0 +1,102 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata keys under which the POSIX properties of an uploaded file are kept, when the destination has no native place for them
+const (
+	POSIXModeMetadataKey    = "azcopy_posix_mode"  // the permission bits, including setuid, setgid and sticky, in octal
+	POSIXUIDMetadataKey     = "azcopy_posix_uid"   // the numeric ID of the owning user
+	POSIXGIDMetadataKey     = "azcopy_posix_gid"   // the numeric ID of the owning group
+	POSIXModTimeMetadataKey = "azcopy_posix_mtime" // the modification time, in nanoseconds since the Unix epoch
+)
+
+// POSIXMetadataKeys lists all the keys that POSIX properties are kept under. They are always written together
+var POSIXMetadataKeys = []string{POSIXModeMetadataKey, POSIXUIDMetadataKey, POSIXGIDMetadataKey, POSIXModTimeMetadataKey}
+
+// POSIXProperties are the properties of a file on a POSIX file system that --preserve-posix keeps
+type POSIXProperties struct {
+	Mode    uint32 // the permission bits, including setuid, setgid and sticky (i.e. 07777 at most)
+	UID     uint32
+	GID     uint32
+	ModTime time.Time
+}
+
+const posixModeMask = 07777
+
+// OctalMode returns the permission bits in the 4-digit octal form used by chmod, and by ADLS Gen2
+func (p POSIXProperties) OctalMode() string {
+	return fmt.Sprintf("%04o", p.Mode&posixModeMask)
+}
+
+// AddToMetadata returns a copy of m, with the properties added to it
+func (p POSIXProperties) AddToMetadata(m Metadata) Metadata {
+	result := make(Metadata, len(m)+4)
+	for k, v := range m {
+		result[k] = v
+	}
+	result[POSIXModeMetadataKey] = p.OctalMode()
+	result[POSIXUIDMetadataKey] = strconv.FormatUint(uint64(p.UID), 10)
+	result[POSIXGIDMetadataKey] = strconv.FormatUint(uint64(p.GID), 10)
+	result[POSIXModTimeMetadataKey] = strconv.FormatInt(p.ModTime.UnixNano(), 10)
+	return result
+}
+
+// POSIXPropertiesFromMetadata reads back the properties that AddToMetadata kept.
+// Found is false if the metadata has none of them, e.g. because the object was not uploaded with --preserve-posix,
+// and it's an error for only some of them to be present.
+func POSIXPropertiesFromMetadata(m Metadata) (p POSIXProperties, found bool, err error) {
+	values := make([]string, len(POSIXMetadataKeys))
+	present := 0
+	for i, k := range POSIXMetadataKeys {
+		if v, ok := m[k]; ok {
+			values[i] = v
+			present++
+		}
+	}
+	if present == 0 {
+		return POSIXProperties{}, false, nil
+	} else if present != len(values) {
+		return POSIXProperties{}, false, fmt.Errorf("the metadata holds only some of the POSIX properties (%s)", strings.Join(POSIXMetadataKeys, ", "))
+	}
+
+	mode, err := strconv.ParseUint(values[0], 8, 32)
+	if err == nil {
+		var uid, gid uint64
+		var nanos int64
+		if uid, err = strconv.ParseUint(values[1], 10, 32); err == nil {
+			if gid, err = strconv.ParseUint(values[2], 10, 32); err == nil {
+				nanos, err = strconv.ParseInt(values[3], 10, 64)
+			}
+		}
+		p = POSIXProperties{Mode: uint32(mode) & posixModeMask, UID: uint32(uid), GID: uint32(gid), ModTime: time.Unix(0, nanos)}
+	}
+	if err != nil {
+		return POSIXProperties{}, false, fmt.Errorf("invalid POSIX properties in metadata: %w", err)
+	}
+	return p, true, nil
+}
@@ -0,0 +1,69 @@
+//go:build linux || darwin
+// +build linux darwin
+
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// GetPOSIXProperties reads the POSIX properties of the file (or folder) at path, following symlinks
+func GetPOSIXProperties(path string) (POSIXProperties, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return POSIXProperties{}, err
+	}
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return POSIXProperties{}, errors.New("the file system did not report the POSIX properties of " + path)
+	}
+	return POSIXProperties{
+		Mode:    uint32(stat.Mode) & posixModeMask,
+		UID:     stat.Uid,
+		GID:     stat.Gid,
+		ModTime: fi.ModTime(),
+	}, nil
+}
+
+// SetPOSIXProperties applies p to the file (or folder) at path.
+// Only root can give a file away, so if we're not allowed to change the owner, the rest is still applied
+// and ownerSet is false.
+func SetPOSIXProperties(path string, p POSIXProperties) (ownerSet bool, err error) {
+	// the owner goes first, since changing it can clear the setuid and setgid bits
+	ownerSet = true
+	if err = os.Chown(path, int(p.UID), int(p.GID)); err != nil {
+		if !os.IsPermission(err) {
+			return false, err
+		}
+		ownerSet = false
+	}
+	if err = syscall.Chmod(path, p.Mode&posixModeMask); err != nil {
+		return ownerSet, &os.PathError{Op: "chmod", Path: path, Err: err}
+	}
+	if err = os.Chtimes(path, p.ModTime, p.ModTime); err != nil {
+		return ownerSet, err
+	}
+	return ownerSet, nil
+}
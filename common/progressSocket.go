@@ -0,0 +1,128 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// how long a consumer of the progress socket may keep us waiting on a single record before we give up on it
+const progressSocketWriteTimeout = time.Second
+
+// progressSocket streams the JSON output records (the same ones printed when --output-type=json) to whoever is
+// listening on a Unix domain socket or reading from a named pipe, so that a GUI can follow a job without scraping stdout.
+// Consumers may come and go at any time; one that disconnects, or falls behind, is simply dropped,
+// and nothing about the transfer depends on anyone listening.
+type progressSocket struct {
+	path     string
+	listener net.Listener // nil when the path is a named pipe, which we write to rather than serve
+
+	mu      sync.Mutex
+	writers []progressWriter
+}
+
+// what we need from a connected socket client, or from the write end of a named pipe
+type progressWriter interface {
+	io.WriteCloser
+	SetWriteDeadline(t time.Time) error
+}
+
+// newProgressSocket starts serving progress records at path. If path is an existing named pipe, records are written to it
+// whenever it has a reader. Otherwise a Unix domain socket is created there, replacing a stale one left by an earlier run.
+func newProgressSocket(path string) (*progressSocket, error) {
+	s := &progressSocket{path: path}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeNamedPipe != 0 {
+			return s, nil
+		}
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s already exists, and is neither a socket nor a named pipe", path)
+		}
+		_ = os.Remove(path) // most likely left behind by a run that didn't exit cleanly
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	s.listener = listener
+	go s.acceptConsumers()
+
+	return s, nil
+}
+
+func (s *progressSocket) acceptConsumers() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return // the listener was closed
+		}
+
+		s.mu.Lock()
+		s.writers = append(s.writers, conn.(*net.UnixConn))
+		s.mu.Unlock()
+	}
+}
+
+// send writes one record, as a line, to every current consumer
+func (s *progressSocket) send(record string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil && len(s.writers) == 0 {
+		// a named pipe can only be opened once someone is reading it; until then the record has no audience
+		if w, err := openPipeForWriting(s.path); err == nil {
+			s.writers = append(s.writers, w)
+		}
+	}
+
+	line := []byte(record + "\n")
+	remaining := s.writers[:0]
+	for _, w := range s.writers {
+		_ = w.SetWriteDeadline(time.Now().Add(progressSocketWriteTimeout))
+		if _, err := w.Write(line); err != nil {
+			_ = w.Close() // gone, or not keeping up; it can reconnect for later records
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	s.writers = remaining
+}
+
+func (s *progressSocket) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range s.writers {
+		_ = w.Close()
+	}
+	s.writers = nil
+
+	if s.listener != nil {
+		_ = s.listener.Close() // also removes the socket file
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+)
+
+const (
+	// how many entries can be waiting to be sent before new ones are dropped
+	remoteLogQueueLength = 10000
+
+	// how long each attempt to connect to, or send to, the remote endpoint may take
+	remoteLogSendTimeout = 5 * time.Second
+
+	// how long closing the sink waits for the entries already queued to be sent
+	remoteLogDrainTimeout = 2 * time.Second
+)
+
+// ParseRemoteLogTarget checks the value of --remote-log, which is a syslog server (udp://host:port or tcp://host:port)
+// or an HTTP endpoint (http:// or https://) that each entry is posted to.
+func ParseRemoteLogTarget(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("the remote log target %q is not a valid URL: %w", raw, err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "udp", "tcp":
+		if u.Port() == "" {
+			return nil, fmt.Errorf("the remote log target %q must include the syslog server's port, e.g. udp://%s:514", raw, u.Hostname())
+		}
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("the remote log target %q must start with udp://, tcp://, http:// or https://", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("the remote log target %q has no host", raw)
+	}
+	return u, nil
+}
+
+type remoteLogEntry struct {
+	level pipeline.LogLevel
+	line  string
+}
+
+// remoteLogSink sends log entries, in the background, to a syslog server or an HTTP endpoint.
+// It is best effort: entries are dropped, rather than ever blocking the caller, if the queue is full or the endpoint can't be reached.
+type remoteLogSink struct {
+	target      *url.URL
+	jobID       JobID
+	contentType string // used when posting to an HTTP endpoint
+	hostname    string
+	entries     chan remoteLogEntry
+	done        chan struct{}
+	conn        net.Conn
+	httpClient  *http.Client
+
+	atomicDropped int64
+}
+
+func newRemoteLogSink(target *url.URL, jobID JobID, format LogFormat) *remoteLogSink {
+	hostname, _ := os.Hostname()
+	s := &remoteLogSink{
+		target:      target,
+		jobID:       jobID,
+		contentType: "text/plain; charset=utf-8",
+		hostname:    hostname,
+		entries:     make(chan remoteLogEntry, remoteLogQueueLength),
+		done:        make(chan struct{}),
+		httpClient:  &http.Client{Timeout: remoteLogSendTimeout},
+	}
+	if format == ELogFormat.Json() {
+		s.contentType = "application/json"
+	}
+	if s.hostname == "" {
+		s.hostname = "-"
+	}
+	go s.sendAll()
+	return s
+}
+
+// Log queues the entry to be sent. It never blocks.
+func (s *remoteLogSink) Log(level pipeline.LogLevel, line string) {
+	select {
+	case s.entries <- remoteLogEntry{level: level, line: strings.TrimRight(line, "\n")}:
+	default:
+		atomic.AddInt64(&s.atomicDropped, 1)
+	}
+}
+
+// Close waits, for a short time, for the queued entries to be sent, and returns how many entries were dropped
+func (s *remoteLogSink) Close() int64 {
+	close(s.entries)
+	select {
+	case <-s.done:
+	case <-time.After(remoteLogDrainTimeout):
+	}
+	return atomic.LoadInt64(&s.atomicDropped)
+}
+
+func (s *remoteLogSink) sendAll() {
+	defer close(s.done)
+	for e := range s.entries {
+		if err := s.send(e); err != nil {
+			atomic.AddInt64(&s.atomicDropped, 1)
+		}
+	}
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+}
+
+func (s *remoteLogSink) send(e remoteLogEntry) error {
+	switch strings.ToLower(s.target.Scheme) {
+	case "http", "https":
+		resp, err := s.httpClient.Post(s.target.String(), s.contentType, strings.NewReader(e.line))
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("the log endpoint returned %s", resp.Status)
+		}
+		return nil
+	default:
+		if s.conn == nil {
+			conn, err := net.DialTimeout(strings.ToLower(s.target.Scheme), s.target.Host, remoteLogSendTimeout)
+			if err != nil {
+				return err
+			}
+			s.conn = conn
+		}
+		_ = s.conn.SetWriteDeadline(time.Now().Add(remoteLogSendTimeout))
+		if _, err := s.conn.Write([]byte(s.syslogMessage(e))); err != nil {
+			// reconnect for the next entry, in case the server went away
+			_ = s.conn.Close()
+			s.conn = nil
+			return err
+		}
+		return nil
+	}
+}
+
+// syslogMessage formats the entry as an RFC 5424 syslog message, from the "user" facility, with the job ID as the message ID.
+// Over TCP, the message is framed by octet counting (RFC 6587), since entries may span lines.
+func (s *remoteLogSink) syslogMessage(e remoteLogEntry) string {
+	const userFacility = 1
+	msg := fmt.Sprintf("<%d>1 %s %s azcopy %d %s - %s",
+		userFacility*8+syslogSeverity(e.level), time.Now().UTC().Format(time.RFC3339Nano), s.hostname, os.Getpid(), s.jobID.String(), e.line)
+	if strings.EqualFold(s.target.Scheme, "tcp") {
+		return fmt.Sprintf("%d %s", len(msg), msg)
+	}
+	return msg
+}
+
+func syslogSeverity(level pipeline.LogLevel) int {
+	switch level {
+	case pipeline.LogFatal, pipeline.LogPanic:
+		return 2 // critical
+	case pipeline.LogError:
+		return 3
+	case pipeline.LogWarning:
+		return 4
+	case pipeline.LogInfo:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
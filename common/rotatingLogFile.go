@@ -0,0 +1,159 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LogRotationPolicy says when a log file is rotated, and how many of the rotated files are kept
+type LogRotationPolicy struct {
+	MaxSizeBytes int64 // a log file is rotated when writing to it would take it past this size. Zero means logs are never rotated
+	MaxFiles     int   // the number of rotated files kept, in addition to the one being written. Older ones are deleted
+}
+
+func (p LogRotationPolicy) Enabled() bool {
+	return p.MaxSizeBytes > 0
+}
+
+// rotatingLogFile is a log file which, once it reaches the size limit of its policy, is renamed so that writing can continue in a new file.
+// Rotated files are numbered from the newest, so that azcopy.log is rotated to azcopy.1.log, azcopy.1.log to azcopy.2.log and so on.
+// Files are only ever rotated between lines, so no entry is split across two files.
+// It is safe for concurrent use, although each logger that uses it already serializes its own writes.
+type rotatingLogFile struct {
+	mu          sync.Mutex
+	path        string
+	policy      LogRotationPolicy
+	header      string // written at the start of every file, e.g. the column names of a CSV
+	file        *os.File
+	size        int64
+	atLineStart bool
+}
+
+func newRotatingLogFile(path string, policy LogRotationPolicy, header string) (*rotatingLogFile, error) {
+	r := &rotatingLogFile{path: path, policy: policy, header: header}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingLogFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, DEFAULT_FILE_PERM)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.atLineStart = true
+	if r.size == 0 && r.header != "" {
+		n, err := file.WriteString(r.header)
+		r.size += int64(n)
+		return err
+	}
+	return nil
+}
+
+// rotatedName returns the name of the i'th newest rotated file
+func (r *rotatingLogFile) rotatedName(i int) string {
+	ext := filepath.Ext(r.path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(r.path, ext), i, ext)
+}
+
+func (r *rotatingLogFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	// make room by deleting the oldest, then move each of the others one place along
+	_ = os.Remove(r.rotatedName(r.policy.MaxFiles))
+	for i := r.policy.MaxFiles - 1; i >= 1; i-- {
+		_ = os.Rename(r.rotatedName(i), r.rotatedName(i+1))
+	}
+	if r.policy.MaxFiles > 0 {
+		if err := os.Rename(r.path, r.rotatedName(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+
+	return r.open()
+}
+
+func (r *rotatingLogFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	written := 0
+	if r.policy.Enabled() && r.size+int64(len(p)) > r.policy.MaxSizeBytes && r.size > int64(len(r.header)) {
+		if !r.atLineStart {
+			// finish the current line in this file, and rotate after it
+			i := bytes.IndexByte(p, '\n')
+			if i < 0 {
+				return r.write(p)
+			}
+			n, err := r.write(p[:i+1])
+			if err != nil {
+				return n, err
+			}
+			written, p = n, p[i+1:]
+		}
+		if err := r.rotate(); err != nil {
+			return written, err
+		}
+	}
+
+	n, err := r.write(p)
+	return written + n, err
+}
+
+func (r *rotatingLogFile) write(p []byte) (int, error) {
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	if n > 0 {
+		r.atLineStart = p[n-1] == '\n'
+	}
+	return n, err
+}
+
+func (r *rotatingLogFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+func (r *rotatingLogFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
@@ -26,6 +26,7 @@ func (RpcCmd) CancelJob() RpcCmd          { return RpcCmd("Cancel") }
 func (RpcCmd) PauseJob() RpcCmd           { return RpcCmd("PauseJob") }
 func (RpcCmd) ResumeJob() RpcCmd          { return RpcCmd("ResumeJob") }
 func (RpcCmd) GetJobFromTo() RpcCmd       { return RpcCmd("GetJobFromTo") }
+func (RpcCmd) GetJobDetails() RpcCmd      { return RpcCmd("GetJobDetails") }
 
 func (c RpcCmd) String() string {
 	return enum.String(c, reflect.TypeOf(c))
@@ -115,10 +116,16 @@ type CopyJobPartOrderRequest struct {
 	IsFinalPart     bool            // to determine the final part for a specific job
 	ForceWrite      OverwriteOption // to determine if the existing needs to be overwritten or not. If set to true, existing blobs are overwritten
 	ForceIfReadOnly bool            // Supplements ForceWrite with addition setting for Azure Files objects with read-only attribute
+	AppendOnly      bool            // if true, objects that already exist at the destination are never modified or removed, whatever ForceWrite says
+	Transactional   bool            // if true, and any transfer fails, the blobs that the job created are deleted once it is done
 	AutoDecompress  bool            // if true, source data with encodings that represent compression are automatically decompressed when downloading
+	SkipLocked      bool            // if true, source files that are locked by another process are skipped instead of failed
+	RetryLocked     bool            // if true, locked source files are retried once, after the other transfers, before being skipped
+	ProgressBasis   ProgressBasis   // what the percentage complete of the job is measured against
 	Priority        JobPriority     // priority of the task
 	FromTo          FromTo
 	Fpo             FolderPropertyOption // passed in from front-end to ensure that front-end and STE agree on the desired behaviour for the job
+	TrailingDot     TrailingDotOption    // whether Azure Files should keep trailing dots in file and directory names
 	// list of blobTypes to exclude.
 	ExcludeBlobType []azblob.BlobType
 
@@ -127,16 +134,52 @@ type CopyJobPartOrderRequest struct {
 
 	Transfers      []CopyTransfer
 	LogLevel       LogLevel
+	LogFormat      LogFormat
 	BlobAttributes BlobTransferAttributes
 	CommandString  string // commandString hold the user given command which is logged to the Job log file
-	CredentialInfo CredentialInfo
+	// CommandStartTime is when the command started, before anything was listed. Files changed since may not have been transferred
+	CommandStartTime time.Time
+	CredentialInfo   CredentialInfo
+	// ChunkTimelinePath, if set, is where to write the per-second chunk wait-state counts. Like CredentialInfo, it is not saved in the plan
+	ChunkTimelinePath string
+	// EnumerationLookahead, if not zero, is the most transfers that may be ordered but not yet done, before ordering this part waits.
+	// It's not saved in the plan, since a resumed job doesn't enumerate
+	EnumerationLookahead int
+	// Deadline, if not zero, is when the job stops starting transfers, leaving the rest to be resumed. Like ChunkTimelinePath,
+	// it is not saved in the plan, so a resumed job has no deadline
+	Deadline time.Time
+	// PrefixConcurrency, if not empty, is the most transfers that may be in flight at once under each destination prefix.
+	// Like Deadline, it is not saved in the plan, so a resumed job has no such limits
+	PrefixConcurrency map[string]int
+	// ClientSideEncryptionKey, if set, is the key that uploads are encrypted, and downloads decrypted, with. Like CredentialInfo,
+	// it is not saved in the plan, so it must be given again to resume the job
+	ClientSideEncryptionKey ClientSideEncryptionKey
 
 	PreserveSMBPermissions         PreservePermissionsOption
 	PreserveSMBInfo                bool
+	PreserveFileAttributes         bool // when uploading to/downloading from blobs, keep Windows file attributes in the blob's metadata
+	SetReadOnly                    bool // when copying to Azure Files, set the read-only attribute of each file once its content has been written
+	SkipSMBInfo                    bool // when copying to Azure Files, make no calls to get or set SMB properties or permissions, beyond those that create each file or folder
+	PreservePOSIX                  bool // when uploading from/downloading to Linux or macOS, keep each file's mode, owner, group and modification time
+	PreserveDirectoryTimestamps    bool // when downloading, set the modification time of each directory from its source once the job is done
+	ComputeSha256                  bool // when uploading or downloading, compute the SHA-256 of each file from its data as it's read or saved
 	S2SGetPropertiesInBackend      bool
 	S2SSourceChangeValidation      bool
 	DestLengthValidation           bool
 	S2SInvalidMetadataHandleOption InvalidMetadataHandleOption
+
+	// FileMode and DirMode, if not zero, are the permission bits set on each file downloaded, and each directory created for them
+	FileMode uint16
+	DirMode  uint16
+
+	// S2SPreference says whether a copy between remote locations is done by the service, or through AzCopy
+	S2SPreference S2SPreference
+
+	// RehydrateAndCopy says whether archived Blob sources are rehydrated, and each transferred once it's online
+	RehydrateAndCopy bool
+
+	// TransactionalFailureThreshold is how many transfers of a Transactional job may fail without it being rolled back
+	TransactionalFailureThreshold uint32
 }
 
 // CredentialInfo contains essential credential info which need be transited between modules,
@@ -182,6 +225,7 @@ type BlobTransferAttributes struct {
 	ContentDisposition       string                // Specifies the content disposition
 	CacheControl             string                // Specifies the cache control header
 	BlockBlobTier            BlockBlobTier         // Specifies the tier to set on the block blobs.
+	BlockBlobTierName        string                // when not empty, a tier that BlockBlobTier has no value for, which is set on the block blobs as it is
 	PageBlobTier             PageBlobTier          // Specifies the tier to set on the page blobs.
 	Metadata                 string                // User-defined Name-value pairs associated with the blob
 	NoGuessMimeType          bool                  // represents user decision to interpret the content-encoding from source file
@@ -189,8 +233,23 @@ type BlobTransferAttributes struct {
 	PutMd5                   bool                  // when uploading, should we create and PUT Content-MD5 hashes
 	MD5ValidationOption      HashValidationOption  // when downloading, how strictly should we validate MD5 hashes?
 	BlockSizeInBytes         int64                 // when uploading/downloading/copying, specify the size of each chunk
+	MaxBlocksPerBlob         uint16                // when BlockSizeInBytes is 0, derive the size of each chunk so that no blob needs more blocks than this
 	DeleteSnapshotsOption    DeleteSnapshotsOption // when deleting, specify what to do with the snapshots
+	BatchDelete              bool                  // when deleting, group the deletions into Blob Batch requests
+	DeltaUpdate              bool                  // when uploading over an existing block blob, only send the blocks that have changed
 	BlobTagsString           string
+	ExpiryOption             BlobExpiryOption // when writing blobs, how their expiry is set
+	ExpiryTime               int64            // in milliseconds: the time to expiry for RelativeToNow, or the Unix time of expiry for Absolute
+	VerifyEncryption         bool             // when writing blobs, check that the service reports each one as encrypted
+	ExpectedEncryptionScope  string           // with VerifyEncryption, the encryption scope that each blob must be encrypted with
+	PageBlobSequenceNumber   int64            // the sequence number that page blobs are created with
+	BlockIDPrefix            string           // when staging blocks, the prefix of their IDs, which are then followed by the block index
+
+	// when copying from Blob storage, give each blob the immutability policy and legal hold of its source
+	PreserveImmutability bool
+
+	// when copying from Blob storage, the rules that choose each block blob's tier by when its source was last accessed, e.g. 90d=Archive,30d=Cool
+	TierByAccessTime string
 }
 
 type JobIDDetails struct {
@@ -211,6 +270,30 @@ type ListContainerResponse struct {
 	Blobs []string
 }
 
+// ConcurrencyChange records one concurrency value chosen by the auto-tuner, and why it was chosen
+type ConcurrencyChange struct {
+	ElapsedSeconds int `json:",string"` // since the tuner started
+	Concurrency    int `json:",string"`
+	Reason         string
+}
+
+// PrefixTransfersInFlight is how many transfers are in flight under a destination prefix, of the most that may be,
+// and how many are waiting for one of them to be done before they start
+type PrefixTransfersInFlight struct {
+	Prefix   string
+	Limit    int `json:",string"`
+	InFlight int `json:",string"`
+	Waiting  int `json:",string"`
+}
+
+// InterfaceBytes is how many bytes were sent and received from one local address. Interface is empty if the address
+// no longer belongs to any network interface
+type InterfaceBytes struct {
+	Interface string
+	Address   string
+	Bytes     uint64 `json:",string"`
+}
+
 // represents the JobProgressPercentage Summary response for list command when requested the Job Progress Summary for given JobId
 type ListJobSummaryResponse struct {
 	ErrorMsg  string
@@ -233,6 +316,15 @@ type ListJobSummaryResponse struct {
 	TransfersCompleted uint32 `json:",string"`
 	TransfersFailed    uint32 `json:",string"`
 	TransfersSkipped   uint32 `json:",string"`
+	// the subset of TransfersSkipped that were skipped because the source file was locked
+	TransfersSkippedLocked uint32 `json:",string"`
+	// the subset of TransfersFailed that weren't started because the job's deadline had passed. Resuming the job starts them
+	TransfersNotStartedByDeadline uint32 `json:",string"`
+	// the requests the transfers have sent, and the most that max-transactions allows. Both zero if transactions aren't capped
+	TransactionsIssued int64 `json:",string"`
+	MaxTransactions    int64 `json:",string"`
+	// the transfers not yet done that are waiting for their archived source to be rehydrated, before they are started
+	TransfersRehydrating uint32 `json:",string"`
 
 	// includes bytes sent in retries (i.e. has double counting, if there are retries) and in failed transfers
 	BytesOverWire uint64 `json:",string"`
@@ -247,6 +339,9 @@ type ListJobSummaryResponse struct {
 
 	PercentComplete float32 `json:",string"`
 
+	// what PercentComplete is measured against
+	ProgressBasis ProgressBasis
+
 	// Stats measured from the network pipeline
 	// Values are all-time values, for the duration of the job.
 	// Will be zero if read outside the process running the job (e.g. with 'jobs show' command)
@@ -255,6 +350,17 @@ type ListJobSummaryResponse struct {
 	ServerBusyPercentage   float32 `json:",string"`
 	NetworkErrorPercentage float32 `json:",string"`
 
+	// Share of the chunks' time spent waiting on network, disk and RAM. Only available in the process running the job
+	ChunkTimeBreakdown ChunkTimeBreakdown
+
+	// Concurrency values chosen over time when auto-tuning. Only available in the process running the job
+	ConcurrencyHistory []ConcurrencyChange
+
+	// Bytes sent and received through each network interface. Only available in the process running the job
+	BytesByInterface []InterfaceBytes
+	// the transfers in flight, and waiting for a slot, under each destination prefix that the job limits
+	TransfersInFlightByPrefix []PrefixTransfersInFlight
+
 	FailedTransfers  []TransferDetail
 	SkippedTransfers []TransferDetail
 	PerfConstraint   PerfConstraint
@@ -269,6 +375,10 @@ type ListSyncJobSummaryResponse struct {
 	ListJobSummaryResponse
 	DeleteTotalTransfers     uint32 `json:",string"`
 	DeleteTransfersCompleted uint32 `json:",string"`
+
+	// the number of destination objects whose metadata or tags were updated, without being transferred
+	AttributeUpdates       uint32 `json:",string"`
+	AttributeUpdatesFailed uint32 `json:",string"`
 }
 
 type ListJobTransfersRequest struct {
@@ -282,7 +392,10 @@ type ResumeJobRequest struct {
 	DestinationSAS  string
 	IncludeTransfer map[string]int
 	ExcludeTransfer map[string]int
+	FailedOnly      bool // only retry the transfers that failed, leaving the ones that never finished for other reasons alone
 	CredentialInfo  CredentialInfo
+	// the key of a job that encrypts or decrypts client-side, which isn't kept in its plan
+	ClientSideEncryptionKey ClientSideEncryptionKey
 }
 
 // represents the Details and details of a single transfer
@@ -306,6 +419,21 @@ type ListJobTransfersResponse struct {
 	Details  []TransferDetail
 }
 
+// GetJobDetailsRequest asks for the status and timing of a job, from its job part plan header
+type GetJobDetailsRequest struct {
+	JobID JobID
+}
+
+// GetJobDetailsResponse is the status and timing of a job
+type GetJobDetailsResponse struct {
+	ErrorMsg  string
+	JobStatus JobStatus
+	// StartTime is when the job's first part was created, which may be some time after the source started to be listed
+	StartTime time.Time
+	// CommandStartTime is when the command that created the job started. It's zero for jobs created by older versions of AzCopy
+	CommandStartTime time.Time
+}
+
 // GetJobFromToRequest indicates request to get job's FromTo info from job part plan header
 type GetJobFromToRequest struct {
 	JobID JobID
@@ -0,0 +1,47 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"hash"
+	"io"
+)
+
+// teeHasher is a hash.Hash that also writes everything it hashes to another writer, such as a second hash.
+// Its sum, size and block size are those of the hash it wraps
+type teeHasher struct {
+	hash.Hash
+	also io.Writer
+}
+
+// NewTeeHasher returns a hash.Hash that is h, except that everything written to it is also written to also,
+// so that a second hash can be computed in the same pass through the data
+func NewTeeHasher(h hash.Hash, also io.Writer) hash.Hash {
+	return &teeHasher{Hash: h, also: also}
+}
+
+func (t *teeHasher) Write(p []byte) (n int, err error) {
+	// hash writers never return errors, but pass any on all the same
+	if n, err = t.Hash.Write(p); err != nil {
+		return n, err
+	}
+	return t.also.Write(p)
+}
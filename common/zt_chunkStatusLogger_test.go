@@ -0,0 +1,117 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	chk "gopkg.in/check.v1"
+)
+
+type chunkStatusLoggerSuite struct{}
+
+var _ = chk.Suite(&chunkStatusLoggerSuite{})
+
+func (s *chunkStatusLoggerSuite) TestEmptyFileChunkGoesStraightToDone(c *chk.C) {
+	csl := NewChunkStatusLogger(NewJobID(), nil, "", false, LogRotationPolicy{}).(*chunkStatusLogger)
+
+	empty := NewChunkID("empty", "", 0, 0)
+	c.Assert(empty.IsEmptyFileChunk(), chk.Equals, true)
+	for _, reason := range []WaitReason{EWaitReason.WorkerGR(), EWaitReason.RAMToSchedule(), EWaitReason.DiskIO(), EWaitReason.Body()} {
+		csl.LogChunkStatus(empty, reason)
+		c.Assert(csl.getCount(reason), chk.Equals, int64(0))
+	}
+	csl.LogChunkStatus(empty, EWaitReason.ChunkDone())
+	c.Assert(csl.getCount(EWaitReason.ChunkDone()), chk.Equals, int64(1))
+
+	// chunks with content, and whole-file pseudo chunks, go through every state as before
+	full := NewChunkID("full", "", 0, 10)
+	c.Assert(full.IsEmptyFileChunk(), chk.Equals, false)
+	csl.LogChunkStatus(full, EWaitReason.Body())
+	c.Assert(csl.getCount(EWaitReason.Body()), chk.Equals, int64(1))
+
+	pseudo := NewPseudoChunkIDForWholeFile("empty")
+	c.Assert(pseudo.IsEmptyFileChunk(), chk.Equals, false)
+	csl.LogChunkStatus(pseudo, EWaitReason.XferStart())
+	c.Assert(csl.getCount(EWaitReason.XferStart()), chk.Equals, int64(1))
+}
+
+func (s *chunkStatusLoggerSuite) TestChunkLogIncludesTransferID(c *chk.C) {
+	dir, err := ioutil.TempDir("", "chunklog")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	jobID := NewJobID()
+	csl := NewChunkStatusLogger(jobID, nil, dir, true, LogRotationPolicy{})
+	csl.LogChunkStatus(NewChunkID("file", "P#0-T#12", 0, 10), EWaitReason.Body())
+	csl.LogChunkStatus(NewChunkID("other", "", 10, 10), EWaitReason.Body())
+	csl.FlushLog()
+
+	content, err := ioutil.ReadFile(path.Join(dir, jobID.String()+"-chunks.log"))
+	c.Assert(err, chk.IsNil)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	c.Assert(lines, chk.HasLen, 3)
+	c.Assert(lines[0], chk.Equals, "Name,Offset,State,StateStartTime,TransferID")
+	c.Assert(strings.HasPrefix(lines[1], "file,0,Body,"), chk.Equals, true)
+	c.Assert(strings.HasSuffix(lines[1], ",P#0-T#12"), chk.Equals, true)
+	c.Assert(strings.HasSuffix(lines[2], ","), chk.Equals, true) // an unknown transfer leaves the column empty
+}
+
+func (s *chunkStatusLoggerSuite) TestTimeBreakdownSumsStateDurations(c *chk.C) {
+	csl := NewChunkStatusLogger(NewJobID(), nil, "", false, LogRotationPolicy{}).(*chunkStatusLogger)
+	c.Assert(csl.GetTimeBreakdown().IsEmpty(), chk.Equals, true)
+
+	id := NewChunkID("file", "", 0, 10)
+	csl.LogChunkStatus(id, EWaitReason.RAMToSchedule())
+	csl.LogChunkStatus(id, EWaitReason.Body())
+	csl.LogChunkStatus(id, EWaitReason.DiskIO())
+	csl.LogChunkStatus(id, EWaitReason.ChunkDone())
+
+	// replace the measured times with known ones, so the percentages are predictable
+	for i := range csl.durations {
+		csl.durations[i] = 0
+	}
+	csl.durations[EWaitReason.RAMToSchedule().index] = 10
+	csl.durations[EWaitReason.Body().index] = 50
+	csl.durations[EWaitReason.HeaderResponse().index] = 10
+	csl.durations[EWaitReason.DiskIO().index] = 20
+	csl.durations[EWaitReason.WorkerGR().index] = 10
+
+	b := csl.GetTimeBreakdown()
+	c.Assert(b.NetworkPercentage, chk.Equals, float32(60))
+	c.Assert(b.DiskPercentage, chk.Equals, float32(20))
+	c.Assert(b.RAMPercentage, chk.Equals, float32(10))
+}
+
+func (s *chunkStatusLoggerSuite) TestTimeBreakdownIgnoresPseudoChunks(c *chk.C) {
+	csl := NewChunkStatusLogger(NewJobID(), nil, "", false, LogRotationPolicy{}).(*chunkStatusLogger)
+
+	pseudo := NewPseudoChunkIDForWholeFile("file")
+	csl.LogChunkStatus(pseudo, EWaitReason.XferStart())
+	csl.LogChunkStatus(pseudo, EWaitReason.Epilogue())
+	csl.LogChunkStatus(pseudo, EWaitReason.ChunkDone())
+	for i := range csl.durations {
+		c.Assert(csl.durations[i], chk.Equals, int64(0))
+	}
+}
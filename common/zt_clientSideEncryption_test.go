@@ -0,0 +1,146 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+type clientSideEncryptionSuite struct{}
+
+var _ = chk.Suite(&clientSideEncryptionSuite{})
+
+func mustDecodeHex(c *chk.C, s string) []byte {
+	b, err := hex.DecodeString(s)
+	c.Assert(err, chk.IsNil)
+	return b
+}
+
+func (s *clientSideEncryptionSuite) TestAESKeyWrapMatchesRFC3394(c *chk.C) {
+	kek := mustDecodeHex(c, "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F")
+	for _, v := range []struct{ keyData, wrapped string }{
+		{"00112233445566778899AABBCCDDEEFF", "64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7"},
+		{"00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F", "28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21"},
+	} {
+		wrapped, err := aesKeyWrap(kek, mustDecodeHex(c, v.keyData))
+		c.Assert(err, chk.IsNil)
+		c.Assert(wrapped, chk.DeepEquals, mustDecodeHex(c, v.wrapped))
+
+		unwrapped, err := aesKeyUnwrap(kek, wrapped)
+		c.Assert(err, chk.IsNil)
+		c.Assert(unwrapped, chk.DeepEquals, mustDecodeHex(c, v.keyData))
+
+		wrapped[3] ^= 1
+		_, err = aesKeyUnwrap(kek, wrapped)
+		c.Assert(err, chk.NotNil)
+	}
+}
+
+func (s *clientSideEncryptionSuite) TestLoadClientSideEncryptionKey(c *chk.C) {
+	dir, err := ioutil.TempDir("", "csekey")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "mykey")
+	c.Assert(ioutil.WriteFile(path, []byte("AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=\n"), 0600), chk.IsNil)
+	key, err := LoadClientSideEncryptionKey(path, "")
+	c.Assert(err, chk.IsNil)
+	c.Assert(key.KeyID, chk.Equals, "mykey")
+	c.Assert(key.Key, chk.HasLen, 32)
+
+	// the key ID can be given, rather than taken from the name of the file
+	key, err = LoadClientSideEncryptionKey(path, "https://myvault.vault.azure.net/keys/mykey/1")
+	c.Assert(err, chk.IsNil)
+	c.Assert(key.KeyID, chk.Equals, "https://myvault.vault.azure.net/keys/mykey/1")
+
+	c.Assert(ioutil.WriteFile(path, []byte("AAECAwQFBgcICQoLDA0ODw=="), 0600), chk.IsNil) // only 128 bits
+	_, err = LoadClientSideEncryptionKey(path, "")
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *clientSideEncryptionSuite) TestClientSideEncryptionRoundTrip(c *chk.C) {
+	key := ClientSideEncryptionKey{KeyID: "mykey", Key: bytes.Repeat([]byte{7}, 32)}
+	e, err := NewClientSideEncryptor(key)
+	c.Assert(err, chk.IsNil)
+
+	var envelope map[string]map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(e.Envelope()), &envelope), chk.IsNil)
+	c.Assert(envelope["EncryptionAgent"]["Protocol"], chk.Equals, "2.0")
+	c.Assert(envelope["EncryptionAgent"]["EncryptionAlgorithm"], chk.Equals, "AES_GCM_256")
+	c.Assert(envelope["WrappedContentKey"]["KeyId"], chk.Equals, "mykey")
+	c.Assert(envelope["WrappedContentKey"]["Algorithm"], chk.Equals, "A256KW")
+	c.Assert(envelope["EncryptedRegionInfo"]["DataLength"], chk.Equals, float64(ClientSideEncryptionRegionSize))
+
+	// encrypted in two parts, as two chunks would be, each starting at a region boundary
+	plaintext := make([]byte, 2*ClientSideEncryptionRegionSize+1000)
+	rand.Read(plaintext)
+	first, err := e.Encrypt(plaintext[:ClientSideEncryptionRegionSize])
+	c.Assert(err, chk.IsNil)
+	rest, err := e.Encrypt(plaintext[ClientSideEncryptionRegionSize:])
+	c.Assert(err, chk.IsNil)
+	ciphertext := append(first, rest...)
+	c.Assert(int64(len(ciphertext)), chk.Equals, ClientSideEncryptedLength(int64(len(plaintext))))
+
+	decrypt := func(ciphertext []byte, key ClientSideEncryptionKey) ([]byte, error) {
+		out := &closeableBuffer{Buffer: &bytes.Buffer{}}
+		w, err := NewClientSideDecryptingWriter(out, e.Envelope(), key)
+		if err != nil {
+			return nil, err
+		}
+		for len(ciphertext) > 0 { // in writes that don't line up with the regions
+			n := rand.Intn(1024 * 1024)
+			if n > len(ciphertext) {
+				n = len(ciphertext)
+			}
+			if _, err = w.Write(ciphertext[:n]); err != nil {
+				return nil, err
+			}
+			ciphertext = ciphertext[n:]
+		}
+		err = w.Close()
+		c.Assert(out.closeWasCalled(), chk.Equals, true)
+		return out.Bytes(), err
+	}
+
+	decrypted, err := decrypt(ciphertext, key)
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(decrypted, plaintext), chk.Equals, true)
+
+	// the key ID is only a label, so the right key decrypts the blob whatever it's called
+	decrypted, err = decrypt(ciphertext, ClientSideEncryptionKey{KeyID: "renamed", Key: key.Key})
+	c.Assert(err, chk.IsNil)
+	c.Assert(bytes.Equal(decrypted, plaintext), chk.Equals, true)
+
+	// while the wrong key is detected, whatever it's called, and so is tampering
+	_, err = decrypt(ciphertext, ClientSideEncryptionKey{KeyID: "mykey", Key: bytes.Repeat([]byte{8}, 32)})
+	c.Assert(err, chk.NotNil)
+	ciphertext[len(ciphertext)-100] ^= 1
+	_, err = decrypt(ciphertext, key)
+	c.Assert(err, chk.NotNil)
+}
@@ -118,6 +118,24 @@ func (d *decompressingWriterSuite) TestDecompressingWriter_EarlyClose(c *chk.C)
 	}
 }
 
+func (d *decompressingWriterSuite) TestGetCompressionType(c *chk.C) {
+	cases := map[string]CompressionType{
+		"":         ECompressionType.None(),
+		"identity": ECompressionType.None(), // not compressed, so downloaded unchanged
+		"gzip":     ECompressionType.GZip(),
+		" GZIP ":   ECompressionType.GZip(),
+		"x-gzip":   ECompressionType.GZip(),
+		"deflate":  ECompressionType.ZLib(),
+		"br":       ECompressionType.Unsupported(),
+		"gzip, br": ECompressionType.Unsupported(),
+	}
+	for encoding, expected := range cases {
+		ct, err := GetCompressionType(encoding)
+		c.Assert(ct, chk.Equals, expected, chk.Commentf(encoding))
+		c.Assert(err != nil, chk.Equals, expected == ECompressionType.Unsupported(), chk.Commentf(encoding))
+	}
+}
+
 func (d *decompressingWriterSuite) getTestData(c *chk.C, tp CompressionType, originalSize int) (original []byte, compressed []byte) {
 	// we have original uncompressed data
 	originalData := d.genCompressibleTestData(originalSize)
@@ -0,0 +1,99 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	chk "gopkg.in/check.v1"
+)
+
+type httpSourceSuite struct{}
+
+var _ = chk.Suite(&httpSourceSuite{})
+
+func (s *httpSourceSuite) TestProbeFollowsRedirectsAndReadsHeaders(c *chk.C) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/data-v2.csv", http.StatusFound)
+	})
+	mux.HandleFunc("/data-v2.csv", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", "11")
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, "a,b\n1,2\n3,4")
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL + "/latest")
+	info, err := ProbeHTTPSource(context.Background(), server.Client(), *u)
+	c.Assert(err, chk.IsNil)
+	c.Assert(info.URL.Path, chk.Equals, "/data-v2.csv")
+	c.Assert(info.ContentLength, chk.Equals, int64(11))
+	c.Assert(info.ContentType(), chk.Equals, "text/csv")
+	c.Assert(info.LastModified.Year(), chk.Equals, 2015)
+	c.Assert(info.SupportsServerSideCopy(), chk.Equals, true)
+}
+
+func (s *httpSourceSuite) TestProbeFallsBackToGetAndDetectsUnknownLength(c *chk.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		// flushing before the body is complete means the length can't be sent, so the response is chunked
+		fmt.Fprint(w, "part one,")
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, "part two")
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL + "/stream")
+	info, err := ProbeHTTPSource(context.Background(), server.Client(), *u)
+	c.Assert(err, chk.IsNil)
+	c.Assert(info.ContentLength, chk.Equals, int64(-1))
+	c.Assert(info.SupportsServerSideCopy(), chk.Equals, false)
+
+	body, err := OpenHTTPSource(context.Background(), server.Client(), info.URL)
+	c.Assert(err, chk.IsNil)
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(data), chk.Equals, "part one,part two")
+}
+
+func (s *httpSourceSuite) TestProbeReportsErrorStatus(c *chk.C) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL + "/missing")
+	_, err := ProbeHTTPSource(context.Background(), server.Client(), *u)
+	c.Assert(err, chk.ErrorMatches, ".*404 Not Found.*")
+}
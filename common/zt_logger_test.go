@@ -0,0 +1,83 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type jobLoggerSuite struct{}
+
+var _ = chk.Suite(&jobLoggerSuite{})
+
+func (s *jobLoggerSuite) TestJsonLogEntry(c *chk.C) {
+	jl := jobLogger{jobID: NewJobID(), format: ELogFormat.Json()}
+
+	raw := jl.jsonEntry(pipeline.LogError, "https://acct.blob.core.windows.net/c/a.txt",
+		"==> REQUEST/RESPONSE (Try=1/10ms, OpTime=10ms) -- RESPONSE STATUS CODE ERROR\n"+
+			"   X-Ms-Error-Code: [AuthorizationFailure]\n"+
+			"   X-Ms-Request-Id: [c0f0e5bc-901e-0042-5d1c-aaaaaaaaaaaa]\n")
+
+	var entry jobLogEntry
+	c.Assert(json.Unmarshal([]byte(raw), &entry), chk.IsNil)
+	c.Assert(entry.Level, chk.Equals, "ERR")
+	c.Assert(entry.JobID, chk.Equals, jl.jobID.String())
+	c.Assert(entry.TransferPath, chk.Equals, "https://acct.blob.core.windows.net/c/a.txt")
+	c.Assert(entry.RequestID, chk.Equals, "c0f0e5bc-901e-0042-5d1c-aaaaaaaaaaaa")
+	c.Assert(entry.ErrorCode, chk.Equals, "AuthorizationFailure")
+	c.Assert(entry.Timestamp, chk.Not(chk.Equals), "")
+
+	// storage errors name their code in the text of the error, rather than in a header
+	raw = jl.jsonEntry(pipeline.LogInfo, "", "===== RESPONSE ERROR (ServiceCode=BlobNotFound) =====")
+	entry = jobLogEntry{}
+	c.Assert(json.Unmarshal([]byte(raw), &entry), chk.IsNil)
+	c.Assert(entry.Level, chk.Equals, "INFO")
+	c.Assert(entry.TransferPath, chk.Equals, "")
+	c.Assert(entry.RequestID, chk.Equals, "")
+	c.Assert(entry.ErrorCode, chk.Equals, "BlobNotFound")
+}
+
+func (s *jobLoggerSuite) TestTextLogHeader(c *chk.C) {
+	folder := c.MkDir()
+	jobID := NewJobID()
+	logger := NewJobLogger(jobID, ELogLevel.Info(), ELogFormat.Text(), NewAppLogger(pipeline.LogNone, ""), folder, LogRotationPolicy{}, nil)
+	logger.OpenLog()
+	logger.CloseLog()
+
+	raw, err := ioutil.ReadFile(path.Join(folder, jobID.String()+".log"))
+	c.Assert(err, chk.IsNil)
+	lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	c.Assert(lines, chk.HasLen, 5)
+
+	// each line starts with the date and time, which are 20 characters long with the space after them
+	c.Assert(lines[0][20:], chk.Equals, "AzcopyVersion  "+AzcopyVersion)
+	c.Assert(lines[1][20:], chk.Equals, "OS-Environment  "+runtime.GOOS)
+	c.Assert(lines[2][20:], chk.Equals, "OS-Architecture  "+runtime.GOARCH)
+	c.Assert(lines[3][20:], chk.Matches, "Log times are in UTC. Local time is .*")
+	c.Assert(lines[4][20:], chk.Equals, "Closing Log")
+}
@@ -0,0 +1,42 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type identityInfoSuite struct{}
+
+var _ = chk.Suite(&identityInfoSuite{})
+
+func (s *identityInfoSuite) TestIdentityInfoValidate(c *chk.C) {
+	// none, i.e. the system-assigned identity, or any one selector are fine
+	c.Assert((&IdentityInfo{}).Validate(), chk.IsNil)
+	c.Assert((&IdentityInfo{ClientID: "a"}).Validate(), chk.IsNil)
+	c.Assert((&IdentityInfo{ObjectID: "a"}).Validate(), chk.IsNil)
+	c.Assert((&IdentityInfo{MSIResID: "a"}).Validate(), chk.IsNil)
+
+	// more than one is ambiguous, even if the values happen to be the same
+	c.Assert((&IdentityInfo{ClientID: "a", ObjectID: "b"}).Validate(), chk.NotNil)
+	c.Assert((&IdentityInfo{ClientID: "a", MSIResID: "a"}).Validate(), chk.NotNil)
+	c.Assert((&IdentityInfo{ClientID: "a", ObjectID: "b", MSIResID: "c"}).Validate(), chk.NotNil)
+}
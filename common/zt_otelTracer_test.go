@@ -0,0 +1,156 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type otelTracerSuite struct{}
+
+var _ = chk.Suite(&otelTracerSuite{})
+
+func (s *otelTracerSuite) TestParseTraceParent(c *chk.C) {
+	tc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	c.Assert(err, chk.IsNil)
+	c.Assert(tc.IsValid(), chk.Equals, true)
+	c.Assert(tc.TraceParent(), chk.Equals, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	for _, bad := range []string{
+		"garbage",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",    // no flags
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01", // zero trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", // zero span ID
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01", // not hex
+	} {
+		_, err = ParseTraceParent(bad)
+		c.Assert(err, chk.NotNil, chk.Commentf(bad))
+	}
+}
+
+func (s *otelTracerSuite) TestTracerIsConfiguredFromEnv(c *chk.C) {
+	getenv := func(env map[string]string) func(string) string {
+		return func(name string) string { return env[name] }
+	}
+
+	t, err := NewOTLPTracerFromEnv(getenv(nil))
+	c.Assert(err, chk.IsNil)
+	c.Assert(t.endpoint, chk.Equals, "http://localhost:4318/v1/traces")
+	c.Assert(t.serviceName, chk.Equals, "azcopy")
+
+	t, err = NewOTLPTracerFromEnv(getenv(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "https://collector.contoso.com:4318/",
+		"OTEL_EXPORTER_OTLP_HEADERS":  "api-key=abc%3D%3D, x-tenant=contoso",
+		"OTEL_SERVICE_NAME":           "nightly-sync",
+	}))
+	c.Assert(err, chk.IsNil)
+	c.Assert(t.endpoint, chk.Equals, "https://collector.contoso.com:4318/v1/traces")
+	c.Assert(t.headers, chk.DeepEquals, map[string]string{"api-key": "abc==", "x-tenant": "contoso"})
+	c.Assert(t.serviceName, chk.Equals, "nightly-sync")
+
+	// the endpoint for traces is used as is
+	t, err = NewOTLPTracerFromEnv(getenv(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT":        "https://collector.contoso.com:4318",
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "https://traces.contoso.com/ingest",
+	}))
+	c.Assert(err, chk.IsNil)
+	c.Assert(t.endpoint, chk.Equals, "https://traces.contoso.com/ingest")
+
+	_, err = NewOTLPTracerFromEnv(getenv(map[string]string{"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"}))
+	c.Assert(err, chk.ErrorMatches, ".*protocol \"grpc\" is not supported.*")
+	_, err = NewOTLPTracerFromEnv(getenv(map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "collector:4317"}))
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *otelTracerSuite) TestNilTracerAndSpanDoNothing(c *chk.C) {
+	var t *OTLPTracer
+	span := t.StartSpan("azcopy job", TraceContext{})
+	c.Assert(span, chk.IsNil)
+	span.SetAttribute("azcopy.size", int64(1))
+	span.SetError("failed")
+	span.End()
+	c.Assert(span.Context().IsValid(), chk.Equals, false)
+	t.Flush(time.Second)
+	c.Assert(t.Dropped(), chk.Equals, int64(0))
+}
+
+func (s *otelTracerSuite) TestSpansAreSentOnFlush(c *chk.C) {
+	received := make(chan otlpTraceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		c.Check(r.URL.Path, chk.Equals, "/v1/traces")
+		c.Check(r.Header.Get("Content-Type"), chk.Equals, "application/json")
+		c.Check(r.Header.Get("api-key"), chk.Equals, "secret")
+		var req otlpTraceRequest
+		c.Check(json.Unmarshal(body, &req), chk.IsNil)
+		received <- req
+	}))
+	defer server.Close()
+
+	t, err := NewOTLPTracerFromEnv(func(name string) string {
+		return map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": server.URL, "OTEL_EXPORTER_OTLP_HEADERS": "api-key=secret"}[name]
+	})
+	c.Assert(err, chk.IsNil)
+
+	parent, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	job := t.StartSpan("azcopy job", parent)
+	transfer := t.StartSpan("azcopy transfer", job.Context())
+	transfer.SetAttribute("azcopy.size", int64(1024))
+	transfer.SetAttribute("azcopy.status", "Failed")
+	transfer.SetError("the transfer failed")
+	transfer.End()
+	transfer.End() // ending twice sends the span once
+	job.End()
+	t.Flush(5 * time.Second)
+
+	var req otlpTraceRequest
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		c.Fatal("no spans were sent")
+	}
+	c.Assert(req.ResourceSpans, chk.HasLen, 1)
+	c.Assert(req.ResourceSpans[0].ScopeSpans, chk.HasLen, 1)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	c.Assert(spans, chk.HasLen, 2)
+
+	sentTransfer, sentJob := spans[0], spans[1]
+	c.Assert(sentJob.TraceID, chk.Equals, "4bf92f3577b34da6a3ce929d0e0e4736")
+	c.Assert(sentJob.ParentSpanID, chk.Equals, "00f067aa0ba902b7")
+	c.Assert(sentTransfer.TraceID, chk.Equals, sentJob.TraceID)
+	c.Assert(sentTransfer.ParentSpanID, chk.Equals, sentJob.SpanID)
+	c.Assert(sentTransfer.SpanID, chk.HasLen, 16)
+	c.Assert(sentTransfer.Status.Code, chk.Equals, otlpStatusCodeError)
+	c.Assert(sentTransfer.Status.Message, chk.Equals, "the transfer failed")
+
+	attributes := make(map[string]otlpAnyValue)
+	for _, a := range sentTransfer.Attributes {
+		attributes[a.Key] = a.Value
+	}
+	c.Assert(*attributes["azcopy.size"].IntValue, chk.Equals, "1024")
+	c.Assert(*attributes["azcopy.status"].StringValue, chk.Equals, "Failed")
+	c.Assert(t.Dropped(), chk.Equals, int64(0))
+}
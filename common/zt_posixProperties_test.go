@@ -0,0 +1,102 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type posixPropertiesSuite struct{}
+
+var _ = chk.Suite(&posixPropertiesSuite{})
+
+func (s *posixPropertiesSuite) TestMetadataRoundTrip(c *chk.C) {
+	props := POSIXProperties{Mode: 04750, UID: 1001, GID: 50, ModTime: time.Unix(1600000000, 123456789)}
+	original := Metadata{"other": "value"}
+
+	m := props.AddToMetadata(original)
+	c.Assert(original, chk.HasLen, 1) // the original is left alone
+	c.Assert(m["other"], chk.Equals, "value")
+	c.Assert(m[POSIXModeMetadataKey], chk.Equals, "4750")
+	c.Assert(props.OctalMode(), chk.Equals, "4750")
+
+	back, found, err := POSIXPropertiesFromMetadata(m)
+	c.Assert(err, chk.IsNil)
+	c.Assert(found, chk.Equals, true)
+	c.Assert(back.Mode, chk.Equals, props.Mode)
+	c.Assert(back.UID, chk.Equals, props.UID)
+	c.Assert(back.GID, chk.Equals, props.GID)
+	c.Assert(back.ModTime.Equal(props.ModTime), chk.Equals, true)
+
+	// times before the epoch work too
+	old := POSIXProperties{Mode: 0644, ModTime: time.Unix(-1000, 0)}
+	back, _, err = POSIXPropertiesFromMetadata(old.AddToMetadata(nil))
+	c.Assert(err, chk.IsNil)
+	c.Assert(back.ModTime.Equal(old.ModTime), chk.Equals, true)
+	c.Assert(old.OctalMode(), chk.Equals, "0644")
+}
+
+func (s *posixPropertiesSuite) TestMetadataWithoutProperties(c *chk.C) {
+	_, found, err := POSIXPropertiesFromMetadata(Metadata{"other": "value"})
+	c.Assert(err, chk.IsNil)
+	c.Assert(found, chk.Equals, false)
+
+	m := POSIXProperties{Mode: 0644}.AddToMetadata(nil)
+	delete(m, POSIXGIDMetadataKey)
+	_, _, err = POSIXPropertiesFromMetadata(m)
+	c.Assert(err, chk.NotNil)
+
+	m = POSIXProperties{Mode: 0644}.AddToMetadata(nil)
+	m[POSIXModeMetadataKey] = "rw-r--r--"
+	_, _, err = POSIXPropertiesFromMetadata(m)
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *posixPropertiesSuite) TestGetAndSetLocalFile(c *chk.C) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		c.Skip("POSIX properties are only supported on Linux and macOS")
+	}
+
+	f, err := ioutil.TempFile("", "posixprops")
+	c.Assert(err, chk.IsNil)
+	_ = f.Close()
+	defer os.Remove(f.Name())
+
+	current, err := GetPOSIXProperties(f.Name())
+	c.Assert(err, chk.IsNil)
+	c.Assert(current.UID, chk.Equals, uint32(os.Getuid()))
+
+	// keep the current owner, since only root can change it
+	wanted := POSIXProperties{Mode: 0751, UID: current.UID, GID: current.GID, ModTime: time.Unix(1500000000, 0)}
+	ownerSet, err := SetPOSIXProperties(f.Name(), wanted)
+	c.Assert(err, chk.IsNil)
+	c.Assert(ownerSet, chk.Equals, true)
+
+	got, err := GetPOSIXProperties(f.Name())
+	c.Assert(err, chk.IsNil)
+	c.Assert(got.OctalMode(), chk.Equals, "0751")
+	c.Assert(got.ModTime.Equal(wanted.ModTime), chk.Equals, true)
+}
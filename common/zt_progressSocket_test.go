@@ -0,0 +1,141 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type progressSocketSuite struct{}
+
+var _ = chk.Suite(&progressSocketSuite{})
+
+// waitForConsumers waits until the socket has accepted the given number of consumers
+func waitForConsumers(c *chk.C, s *progressSocket, count int) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		s.mu.Lock()
+		accepted := len(s.writers)
+		s.mu.Unlock()
+		if accepted == count {
+			return
+		}
+	}
+	c.Fatalf("the progress socket never had %d consumers", count)
+}
+
+func (s *progressSocketSuite) TestProgressIsStreamedAsJson(c *chk.C) {
+	dir, err := ioutil.TempDir("", "progresssocket")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "azcopy.sock")
+	socket, err := newProgressSocket(socketPath)
+	c.Assert(err, chk.IsNil)
+	defer socket.close()
+
+	conn, err := net.Dial("unix", socketPath)
+	c.Assert(err, chk.IsNil)
+	defer conn.Close()
+	waitForConsumers(c, socket, 1)
+
+	// the console gets text, while the socket gets the same record as JSON output would
+	mgr := &lifecycleMgr{msgQueue: make(chan outputMessage, 1), outputFormat: EOutputFormat.Text(), progressSocket: socket}
+	mgr.Progress(func(format OutputFormat) string {
+		if format == EOutputFormat.Json() {
+			return `{"PercentComplete":"50"}`
+		}
+		return "50.0 %"
+	})
+	c.Assert((<-mgr.msgQueue).msgContent, chk.Equals, "50.0 %")
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	c.Assert(err, chk.IsNil)
+	var record JsonOutputTemplate
+	c.Assert(json.Unmarshal([]byte(line), &record), chk.IsNil)
+	c.Assert(record.MessageType, chk.Equals, eOutputMessageType.Progress().String())
+	c.Assert(record.MessageContent, chk.Equals, `{"PercentComplete":"50"}`)
+}
+
+func (s *progressSocketSuite) TestDisconnectedConsumerIsDropped(c *chk.C) {
+	dir, err := ioutil.TempDir("", "progresssocket")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "azcopy.sock")
+	socket, err := newProgressSocket(socketPath)
+	c.Assert(err, chk.IsNil)
+
+	conn, err := net.Dial("unix", socketPath)
+	c.Assert(err, chk.IsNil)
+	waitForConsumers(c, socket, 1)
+	c.Assert(conn.Close(), chk.IsNil)
+
+	// writing to a consumer that has gone away fails, at the latest on the second record, and the consumer is forgotten
+	socket.send(`{"n":1}`)
+	socket.send(`{"n":2}`)
+	waitForConsumers(c, socket, 0)
+
+	// and a new consumer picks up from there
+	conn, err = net.Dial("unix", socketPath)
+	c.Assert(err, chk.IsNil)
+	defer conn.Close()
+	waitForConsumers(c, socket, 1)
+	socket.send(`{"n":3}`)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	c.Assert(err, chk.IsNil)
+	c.Assert(line, chk.Equals, "{\"n\":3}\n")
+
+	// closing removes the socket
+	socket.close()
+	_, err = os.Stat(socketPath)
+	c.Assert(os.IsNotExist(err), chk.Equals, true)
+}
+
+func (s *progressSocketSuite) TestStaleSocketIsReplaced(c *chk.C) {
+	dir, err := ioutil.TempDir("", "progresssocket")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	// a regular file is never replaced
+	filePath := filepath.Join(dir, "notasocket")
+	c.Assert(ioutil.WriteFile(filePath, []byte("data"), 0644), chk.IsNil)
+	_, err = newProgressSocket(filePath)
+	c.Assert(err, chk.NotNil)
+
+	// a socket left behind by an earlier run is
+	socketPath := filepath.Join(dir, "azcopy.sock")
+	listener, err := net.Listen("unix", socketPath)
+	c.Assert(err, chk.IsNil)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	c.Assert(listener.Close(), chk.IsNil)
+
+	socket, err := newProgressSocket(socketPath)
+	c.Assert(err, chk.IsNil)
+	socket.close()
+}
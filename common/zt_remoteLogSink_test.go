@@ -0,0 +1,122 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type remoteLogSinkSuite struct{}
+
+var _ = chk.Suite(&remoteLogSinkSuite{})
+
+func (s *remoteLogSinkSuite) TestParseRemoteLogTarget(c *chk.C) {
+	u, err := ParseRemoteLogTarget("")
+	c.Assert(err, chk.IsNil)
+	c.Assert(u, chk.IsNil)
+
+	for _, good := range []string{"udp://logs.contoso.com:514", "tcp://10.0.0.1:6514", "https://logs.contoso.com/ingest"} {
+		_, err = ParseRemoteLogTarget(good)
+		c.Assert(err, chk.IsNil)
+	}
+
+	_, err = ParseRemoteLogTarget("udp://logs.contoso.com")
+	c.Assert(err, chk.ErrorMatches, ".*must include the syslog server's port.*")
+	_, err = ParseRemoteLogTarget("ftp://logs.contoso.com:21")
+	c.Assert(err, chk.ErrorMatches, ".*must start with udp://.*")
+}
+
+func (s *remoteLogSinkSuite) TestEntriesAreSentAsSyslogMessages(c *chk.C) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, chk.IsNil)
+	defer server.Close()
+
+	jobID := NewJobID()
+	sink := newRemoteLogSink(&url.URL{Scheme: "udp", Host: server.LocalAddr().String()}, jobID, ELogFormat.Text())
+	sink.Log(pipeline.LogError, "upload failed\n")
+	c.Assert(sink.Close(), chk.Equals, int64(0))
+
+	buf := make([]byte, 4096)
+	n, _, err := server.ReadFrom(buf)
+	c.Assert(err, chk.IsNil)
+	msg := string(buf[:n])
+	c.Assert(strings.HasPrefix(msg, "<11>1 "), chk.Equals, true) // user facility, error severity
+	c.Assert(strings.Contains(msg, " azcopy "), chk.Equals, true)
+	c.Assert(strings.HasSuffix(msg, " "+jobID.String()+" - upload failed"), chk.Equals, true)
+}
+
+func (s *remoteLogSinkSuite) TestJsonEntriesArePostedAsIs(c *chk.C) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		c.Check(r.Header.Get("Content-Type"), chk.Equals, "application/json")
+		received <- string(body)
+	}))
+	defer server.Close()
+
+	target, err := ParseRemoteLogTarget(server.URL)
+	c.Assert(err, chk.IsNil)
+	sink := newRemoteLogSink(target, NewJobID(), ELogFormat.Json())
+	sink.Log(pipeline.LogInfo, `{"level":"INFO","message":"hello"}`)
+	c.Assert(sink.Close(), chk.Equals, int64(0))
+	c.Assert(<-received, chk.Equals, `{"level":"INFO","message":"hello"}`)
+}
+
+func (s *remoteLogSinkSuite) TestLoggingNeverBlocks(c *chk.C) {
+	// nothing reads from the queue, as if the endpoint had stopped responding
+	sink := &remoteLogSink{entries: make(chan remoteLogEntry, 1)}
+	sink.Log(pipeline.LogInfo, "first")
+	sink.Log(pipeline.LogInfo, "second")
+	c.Assert(sink.atomicDropped, chk.Equals, int64(1))
+}
+
+func (s *remoteLogSinkSuite) TestClosingJobLoggerWithRemoteSink(c *chk.C) {
+	var received []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	target, err := ParseRemoteLogTarget(server.URL)
+	c.Assert(err, chk.IsNil)
+	logger := NewJobLogger(NewJobID(), ELogLevel.Info(), ELogFormat.Text(), NewAppLogger(pipeline.LogNone, ""), c.MkDir(), LogRotationPolicy{}, target)
+	logger.OpenLog()
+	logger.Log(pipeline.LogInfo, "hello")
+	logger.CloseLog() // must not send on the closed sink
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.Assert(len(received) > 0, chk.Equals, true)
+	c.Assert(received[len(received)-1], chk.Equals, "Closing Log")
+}
@@ -0,0 +1,87 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	chk "gopkg.in/check.v1"
+)
+
+type rotatingLogFileSuite struct{}
+
+var _ = chk.Suite(&rotatingLogFileSuite{})
+
+func (s *rotatingLogFileSuite) TestRotatesBetweenLinesAndKeepsBoundedFiles(c *chk.C) {
+	dir, err := ioutil.TempDir("", "rotatinglog")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "job.log")
+	r, err := newRotatingLogFile(logPath, LogRotationPolicy{MaxSizeBytes: 20, MaxFiles: 2}, "H\n")
+	c.Assert(err, chk.IsNil)
+
+	// a line written in two parts is kept whole, even though the limit is passed part way through it
+	for _, w := range []string{"line-1 aaaaa\n", "line-2 ", "bbbbb\n", "line-3 ccccc\n", "line-4 ddddd\n"} {
+		n, err := r.Write([]byte(w))
+		c.Assert(err, chk.IsNil)
+		c.Assert(n, chk.Equals, len(w))
+	}
+	c.Assert(r.Close(), chk.IsNil)
+
+	read := func(name string) string {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		c.Assert(err, chk.IsNil)
+		return string(b)
+	}
+	c.Assert(read("job.log"), chk.Equals, "H\nline-4 ddddd\n")
+	c.Assert(read("job.1.log"), chk.Equals, "H\nline-3 ccccc\n")
+	c.Assert(read("job.2.log"), chk.Equals, "H\nline-2 bbbbb\n")
+
+	// the oldest was deleted, since only two rotated files are kept
+	_, err = os.Stat(filepath.Join(dir, "job.3.log"))
+	c.Assert(os.IsNotExist(err), chk.Equals, true)
+}
+
+func (s *rotatingLogFileSuite) TestNoRotationWhenDisabled(c *chk.C) {
+	dir, err := ioutil.TempDir("", "rotatinglog")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "job.log")
+	r, err := newRotatingLogFile(logPath, LogRotationPolicy{}, "")
+	c.Assert(err, chk.IsNil)
+	for i := 0; i < 100; i++ {
+		_, err = r.Write([]byte("some log line\n"))
+		c.Assert(err, chk.IsNil)
+	}
+	c.Assert(r.Close(), chk.IsNil)
+
+	b, err := ioutil.ReadFile(logPath)
+	c.Assert(err, chk.IsNil)
+	c.Assert(strings.Count(string(b), "\n"), chk.Equals, 100)
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, chk.IsNil)
+	c.Assert(files, chk.HasLen, 1)
+}
@@ -0,0 +1,58 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+type summaryFileSuite struct{}
+
+var _ = chk.Suite(&summaryFileSuite{})
+
+func (s *summaryFileSuite) TestExitWritesSummaryFile(c *chk.C) {
+	dir, err := ioutil.TempDir("", "summaryfile")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	summaryPath := filepath.Join(dir, "summary.txt")
+	mgr := &lifecycleMgr{msgQueue: make(chan outputMessage, 1), outputFormat: EOutputFormat.Text(), quiet: true, summaryFile: summaryPath}
+	mgr.Exit(func(OutputFormat) string { return "Job Status: Completed" }, EExitCode.NoExit())
+
+	// the summary is written even though quiet keeps it off the screen
+	written, err := ioutil.ReadFile(summaryPath)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(written), chk.Equals, "Job Status: Completed\n")
+
+	msg := <-mgr.msgQueue
+	c.Assert(msg.msgContent, chk.Equals, "Job Status: Completed")
+
+	// nothing is written when there's nothing to summarize
+	c.Assert(os.Remove(summaryPath), chk.IsNil)
+	mgr.Exit(nil, EExitCode.NoExit())
+	<-mgr.msgQueue
+	_, err = os.Stat(summaryPath)
+	c.Assert(os.IsNotExist(err), chk.Equals, true)
+}
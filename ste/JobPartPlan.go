@@ -14,15 +14,21 @@ import (
 // dataSchemaVersion defines the data schema version of JobPart order files supported by
 // current version of azcopy
 // To be Incremented every time when we release azcopy with changed dataSchema
-const DataSchemaVersion common.Version = 16
+const DataSchemaVersion common.Version = 47
 
 const (
-	CustomHeaderMaxBytes = 256
-	MetadataMaxBytes     = 1000 // If > 65536, then jobPartPlanBlobData's MetadataLength field's type must change
-	BlobTagsMaxByte      = 4000
-	BlobTierMaxBytes     = 10
+	CustomHeaderMaxBytes    = 256
+	MetadataMaxBytes        = 1000 // If > 65536, then jobPartPlanBlobData's MetadataLength field's type must change
+	BlobTagsMaxByte         = 4000
+	BlobTierMaxBytes        = 10
+	EncryptionScopeMaxBytes = 64 // encryption scope names are at most 63 characters
+	BlockIDPrefixMaxBytes   = 22 // a block ID is at most 64 bytes, and after the prefix come 5 digits of block index, a dash, and a 36 character unique part
+	AccessTimeTiersMaxBytes = 64 // room for several rules, e.g. 365d=Archive,90d=Cold,30d=Cool
 )
 
+// PrefixConcurrencyMaxBytes is room for the limits of a dozen or so prefixes, e.g. logs/=10,data/=100
+const PrefixConcurrencyMaxBytes = 254
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 type JobPartPlanMMF common.MMF
@@ -32,7 +38,8 @@ func (mmf *JobPartPlanMMF) Plan() *JobPartPlanHeader {
 	// casting the mmf slice's address  to JobPartPlanHeader Pointer
 	return (*JobPartPlanHeader)(unsafe.Pointer((*reflect.SliceHeader)(unsafe.Pointer(mmf)).Data))
 }
-func (mmf *JobPartPlanMMF) Unmap() { (*common.MMF)(mmf).Unmap() }
+func (mmf *JobPartPlanMMF) Unmap()       { (*common.MMF)(mmf).Unmap() }
+func (mmf *JobPartPlanMMF) Flush() error { return (*common.MMF)(mmf).Flush() }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
@@ -55,6 +62,9 @@ type JobPartPlanHeader struct {
 	ForceWrite             common.OverwriteOption      // True if the existing blobs needs to be overwritten.
 	ForceIfReadOnly        bool                        // Supplements ForceWrite with an additional setting for Azure Files. If true, the read-only attribute will be cleared before we overwrite
 	AutoDecompress         bool                        // if true, source data with encodings that represent compression are automatically decompressed when downloading
+	SkipLocked             bool                        // if true, source files that are locked by another process are skipped instead of failed
+	RetryLocked            bool                        // if true, locked source files are retried once (after the other transfers) before being skipped
+	ProgressBasis          common.ProgressBasis        // what the percentage complete of the job is measured against
 	Priority               common.JobPriority          // The Job Part's priority
 	TTLAfterCompletion     uint32                      // Time to live after completion is used to persists the file on disk of specified time after the completion of JobPartOrder
 	FromTo                 common.FromTo               // The location of the transfer's source & destination
@@ -62,11 +72,16 @@ type JobPartPlanHeader struct {
 	CommandStringLength    uint32
 	NumTransfers           uint32              // The number of transfers in the Job part
 	LogLevel               common.LogLevel     // This Job Part's minimal log level
+	LogFormat              common.LogFormat    // How entries are written to the job's log file
 	DstBlobData            JobPartPlanDstBlob  // Additional data for blob destinations
 	DstLocalData           JobPartPlanDstLocal // Additional data for local destinations
 
 	PreserveSMBPermissions common.PreservePermissionsOption
 	PreserveSMBInfo        bool
+	// PreserveFileAttributes represents whether Windows file attributes are kept in blob metadata on upload, and restored from it on download
+	PreserveFileAttributes bool
+	// TrailingDot represents whether Azure Files is asked to keep trailing dots in file and directory names
+	TrailingDot common.TrailingDotOption
 	// S2SGetPropertiesInBackend represents whether to enable get S3 objects' or Azure files' properties during s2s copy in backend.
 	S2SGetPropertiesInBackend bool
 	// S2SSourceChangeValidation represents whether user wants to check if source has changed after enumerating.
@@ -75,6 +90,18 @@ type JobPartPlanHeader struct {
 	DestLengthValidation bool
 	// S2SInvalidMetadataHandleOption represents how user wants to handle invalid metadata.
 	S2SInvalidMetadataHandleOption common.InvalidMetadataHandleOption
+	// AppendOnly represents whether transfers that would modify or remove an object which already exists at the destination are failed instead
+	AppendOnly bool
+	// SetReadOnly represents whether the read-only attribute is set on each Azure Files file once its content has been written
+	SetReadOnly bool
+	// PreservePOSIX represents whether the mode, owner, group and modification time of each local file are kept on upload, and restored on download
+	PreservePOSIX bool
+	// PreserveDirectoryTimestamps represents whether the modification time of each downloaded directory is set from its source, once the job is done
+	PreserveDirectoryTimestamps bool
+	// Transactional represents whether the blobs that the job created are deleted once it is done, if any of its transfers failed
+	Transactional bool
+	// PreserveImmutability represents whether each blob copied gets the immutability policy and legal hold of its source, once it has been written
+	PreserveImmutability bool
 
 	// Any fields below this comment are NOT constants; they may change over as the job part is processed.
 	// Care must be taken to read/write to these fields in a thread-safe way!
@@ -86,6 +113,68 @@ type JobPartPlanHeader struct {
 
 	// For delete operation specify what to do with snapshots
 	DeleteSnapshotsOption common.DeleteSnapshotsOption
+
+	// For delete operation, whether to send the deletions in Blob Batch requests
+	BatchDelete bool
+
+	// ExpiryOption says how the expiry of each blob written is set, once its content has been written
+	ExpiryOption common.BlobExpiryOption
+	// ExpiryTime is in milliseconds: the time to expiry when ExpiryOption is RelativeToNow, or the Unix time of expiry when it is Absolute
+	ExpiryTime int64
+
+	// VerifyEncryption says whether each blob written is checked, once its content has been written, to be encrypted by the service
+	VerifyEncryption bool
+	// ExpectedEncryptionScope, if set, is the encryption scope that VerifyEncryption checks each blob for
+	ExpectedEncryptionScopeLength uint8
+	ExpectedEncryptionScope       [EncryptionScopeMaxBytes]byte
+
+	// CommandStartTime is when the command that created the job started, as Unix nanoseconds, before anything was listed.
+	// Unlike StartTime, it's safe to use for finding files changed since the job. It's zero in plans from before version 33
+	CommandStartTime int64
+
+	// PageBlobSequenceNumber is the sequence number that page blobs are created with. Zero, the service's default, unless the user chose otherwise
+	PageBlobSequenceNumber int64
+
+	// BlockIDPrefix, if set, is the start of the ID of each block staged, which is followed by the block index so that the IDs sort in order
+	BlockIDPrefixLength uint8
+	BlockIDPrefix       [BlockIDPrefixMaxBytes]byte
+
+	// ComputeSha256 represents whether the SHA-256 of each file uploaded or downloaded is computed, from its data as it's read or saved
+	ComputeSha256 bool
+
+	// BlockBlobTierName, if set, is the tier set on block blobs when it's one that DstBlobData.BlockBlobTier has no value for,
+	// such as a tier added to the service since. It's passed to the service as it is
+	BlockBlobTierNameLength uint8
+	BlockBlobTierName       [BlobTierMaxBytes]byte
+
+	// SkipSMBInfo represents whether no calls are made to get or set SMB properties or permissions, beyond those that create each file or folder
+	SkipSMBInfo bool
+
+	// FileMode and DirMode, if not zero, are the permission bits set on each file downloaded, and on each directory created
+	// for them, overriding the process umask
+	FileMode uint16
+	DirMode  uint16
+
+	// S2SPreference says whether a copy between remote locations is done by the service, or by downloading and uploading through AzCopy
+	S2SPreference common.S2SPreference
+
+	// RehydrateAndCopy represents whether the archived sources are rehydrated, and each is transferred once it's online
+	RehydrateAndCopy bool
+
+	// TierByAccessTime, if set, holds the rules that choose the tier of each block blob by when its source was last accessed, as AccessTimeTiers.String gives them
+	TierByAccessTimeLength uint8
+	TierByAccessTime       [AccessTimeTiersMaxBytes]byte
+
+	// ClientSideEncryption represents whether uploads are encrypted, and downloads decrypted, client-side. The key isn't kept in the plan
+	ClientSideEncryption bool
+
+	// TransactionalFailureThreshold is how many transfers of a Transactional job may fail without the job being rolled back
+	TransactionalFailureThreshold uint32
+
+	// PrefixConcurrency, if set, holds the limits on the transfers in flight under each destination prefix, as FormatPrefixConcurrency gives them,
+	// so that a resumed job keeps them
+	PrefixConcurrencyLength uint16
+	PrefixConcurrency       [PrefixConcurrencyMaxBytes]byte
 }
 
 // Status returns the job status stored in JobPartPlanHeader in thread-safe manner
@@ -150,6 +239,13 @@ func (jpph *JobPartPlanHeader) TransferSrcDstStrings(transferIndex uint32) (sour
 		isFolder
 }
 
+// TransferSrcRelativePath returns the path of the transfer's source relative to the source root, as it's recorded in the plan,
+// i.e. escaped when the source is remote
+func (jpph *JobPartPlanHeader) TransferSrcRelativePath(transferIndex uint32) string {
+	jppt := jpph.Transfer(transferIndex)
+	return jpph.getString(jppt.SrcOffset, jppt.SrcLength)
+}
+
 func (jpph *JobPartPlanHeader) getString(offset int64, length int16) string {
 	tempSlice := []byte{}
 	sh := (*reflect.SliceHeader)(unsafe.Pointer(&tempSlice))
@@ -275,12 +371,18 @@ type JobPartPlanDstBlob struct {
 	// Controls uploading of MD5 hashes
 	PutMd5 bool
 
+	// Controls whether only the blocks that differ from the existing destination blob are uploaded
+	DeltaUpdate bool
+
 	MetadataLength uint16
 	Metadata       [MetadataMaxBytes]byte
 
 	BlobTagsLength uint16
 	BlobTags       [BlobTagsMaxByte]byte
 
+	// Specifies the maximum number of blocks per blob; when BlockSize is 0 and this is not, the block size is derived from it
+	MaxBlocks uint16
+
 	// Specifies the maximum size of block which determines the number of chunks and chunk size of a transfer
 	BlockSize int64
 }
@@ -315,6 +417,8 @@ type JobPartPlanTransfer struct {
 	// EntityType indicates whether this is a file or a folder
 	// We use a dedicated field for this because the alternative (of doing something fancy the names) was too complex and error-prone
 	EntityType common.EntityType
+	// Priority is High if the transfer's queued work is picked up ahead of that of other transfers. Otherwise, the transfer has the priority of its job part
+	Priority common.JobPriority
 	// ModifiedTime represents the last time at which source was modified before start of transfer stored as nanoseconds.
 	ModifiedTime int64
 	// SourceSize represents the actual size of the source on disk
@@ -336,6 +440,10 @@ type JobPartPlanTransfer struct {
 	SrcBlobVersionIDLength      int16
 	SrcBlobTagsLength           int16
 
+	// DstCreated says whether the destination did not exist before the transfer wrote it. It is only recorded for transactional jobs,
+	// by the transfer before it writes anything, and only read once the job is done, so it needs no synchronization.
+	DstCreated bool
+
 	// Any fields below this comment are NOT constants; they may change over as the transfer is processed.
 	// Care must be taken to read/write to these fields in a thread-safe way!
 
@@ -347,6 +455,11 @@ type JobPartPlanTransfer struct {
 	// atomicErrorCode has a default value (0) which means either there was no error or transfer failed because some non storageError.
 	// atomicErrorCode should not be directly accessed anywhere except by transferStatus and setTransferStatus
 	atomicErrorCode int32
+
+	// atomicCheckpointedBytes is the length of the leading part of the source that is known to have been sent.
+	// It is only maintained when checkpointing is enabled, so that a resumed upload can skip that part.
+	// atomicCheckpointedBytes should not be directly accessed anywhere except by CheckpointedBytes and SetCheckpointedBytes
+	atomicCheckpointedBytes int64
 }
 
 // TransferStatus returns the transfer's status
@@ -391,3 +504,14 @@ func (jppt *JobPartPlanTransfer) SetErrorCode(errorCode int32, overwrite bool) {
 		atomic.StoreInt32(&jppt.atomicErrorCode, errorCode)
 	}
 }
+
+// CheckpointedBytes returns the length of the leading part of the source that was known to have been sent,
+// as at the last checkpoint.
+func (jppt *JobPartPlanTransfer) CheckpointedBytes() int64 {
+	return atomic.LoadInt64(&jppt.atomicCheckpointedBytes)
+}
+
+// SetCheckpointedBytes records the length of the leading part of the source that is known to have been sent.
+func (jppt *JobPartPlanTransfer) SetCheckpointedBytes(n int64) {
+	atomic.StoreInt64(&jppt.atomicCheckpointedBytes, n)
+}
@@ -83,6 +83,22 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 	if len(order.DestinationRoot.ExtraQuery) > len(JobPartPlanHeader{}.DestExtraQuery) {
 		panic(fmt.Errorf("destination extra query strings too large: %q", order.DestinationRoot.ExtraQuery))
 	}
+	if len(order.BlobAttributes.ExpectedEncryptionScope) > len(JobPartPlanHeader{}.ExpectedEncryptionScope) {
+		panic(fmt.Errorf("encryption scope name is too large: %q", order.BlobAttributes.ExpectedEncryptionScope))
+	}
+	if len(order.BlobAttributes.BlockIDPrefix) > len(JobPartPlanHeader{}.BlockIDPrefix) {
+		panic(fmt.Errorf("block ID prefix is too large: %q", order.BlobAttributes.BlockIDPrefix))
+	}
+	if len(order.BlobAttributes.TierByAccessTime) > len(JobPartPlanHeader{}.TierByAccessTime) {
+		panic(fmt.Errorf("tier-by-access-time rules are too large: %q", order.BlobAttributes.TierByAccessTime))
+	}
+	prefixConcurrency := FormatPrefixConcurrency(order.PrefixConcurrency)
+	if len(prefixConcurrency) > len(JobPartPlanHeader{}.PrefixConcurrency) {
+		panic(fmt.Errorf("prefix concurrency limits are too large: %q", prefixConcurrency))
+	}
+	if len(order.BlobAttributes.BlockBlobTierName) > len(JobPartPlanHeader{}.BlockBlobTierName) {
+		panic(fmt.Errorf("block blob tier name is too large: %q", order.BlobAttributes.BlockBlobTierName))
+	}
 	if len(order.BlobAttributes.ContentType) > len(JobPartPlanDstBlob{}.ContentType) {
 		panic(fmt.Errorf("content type string is too large: %q", order.BlobAttributes.ContentType))
 	}
@@ -177,6 +193,9 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 		ForceWrite:             order.ForceWrite,
 		ForceIfReadOnly:        order.ForceIfReadOnly,
 		AutoDecompress:         order.AutoDecompress,
+		SkipLocked:             order.SkipLocked,
+		RetryLocked:            order.RetryLocked,
+		ProgressBasis:          order.ProgressBasis,
 		Priority:               order.Priority,
 		TTLAfterCompletion:     uint32(time.Time{}.Nanosecond()),
 		FromTo:                 order.FromTo,
@@ -184,6 +203,7 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 		CommandStringLength:    uint32(len(order.CommandString)),
 		NumTransfers:           uint32(len(order.Transfers)),
 		LogLevel:               order.LogLevel,
+		LogFormat:              order.LogFormat,
 		DstBlobData: JobPartPlanDstBlob{
 			BlobType:                 order.BlobAttributes.BlobType,
 			NoGuessMimeType:          order.BlobAttributes.NoGuessMimeType,
@@ -193,10 +213,12 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 			ContentLanguageLength:    uint16(len(order.BlobAttributes.ContentLanguage)),
 			CacheControlLength:       uint16(len(order.BlobAttributes.CacheControl)),
 			PutMd5:                   order.BlobAttributes.PutMd5, // here because it relates to uploads (blob destination)
+			DeltaUpdate:              order.BlobAttributes.DeltaUpdate,
 			BlockBlobTier:            order.BlobAttributes.BlockBlobTier,
 			PageBlobTier:             order.BlobAttributes.PageBlobTier,
 			MetadataLength:           uint16(len(order.BlobAttributes.Metadata)),
 			BlockSize:                blockSize,
+			MaxBlocks:                order.BlobAttributes.MaxBlocksPerBlob,
 			BlobTagsLength:           uint16(len(order.BlobAttributes.BlobTagsString)),
 		},
 		DstLocalData: JobPartPlanDstLocal{
@@ -205,13 +227,39 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 		},
 		PreserveSMBPermissions: order.PreserveSMBPermissions,
 		PreserveSMBInfo:        order.PreserveSMBInfo,
+		PreserveFileAttributes: order.PreserveFileAttributes,
+		TrailingDot:            order.TrailingDot,
 		// For S2S copy, per JobPartPlan info
 		S2SGetPropertiesInBackend:      order.S2SGetPropertiesInBackend,
 		S2SSourceChangeValidation:      order.S2SSourceChangeValidation,
 		S2SInvalidMetadataHandleOption: order.S2SInvalidMetadataHandleOption,
 		DestLengthValidation:           order.DestLengthValidation,
+		AppendOnly:                     order.AppendOnly,
+		Transactional:                  order.Transactional,
+		TransactionalFailureThreshold:  order.TransactionalFailureThreshold,
+		SetReadOnly:                    order.SetReadOnly,
+		PreservePOSIX:                  order.PreservePOSIX,
+		PreserveDirectoryTimestamps:    order.PreserveDirectoryTimestamps,
 		atomicJobStatus:                common.EJobStatus.InProgress(), // We default to InProgress
 		DeleteSnapshotsOption:          order.BlobAttributes.DeleteSnapshotsOption,
+		BatchDelete:                    order.BlobAttributes.BatchDelete,
+		ExpiryOption:                   order.BlobAttributes.ExpiryOption,
+		ExpiryTime:                     order.BlobAttributes.ExpiryTime,
+		VerifyEncryption:               order.BlobAttributes.VerifyEncryption,
+		ExpectedEncryptionScopeLength:  uint8(len(order.BlobAttributes.ExpectedEncryptionScope)),
+		PageBlobSequenceNumber:         order.BlobAttributes.PageBlobSequenceNumber,
+		BlockIDPrefixLength:            uint8(len(order.BlobAttributes.BlockIDPrefix)),
+		PreserveImmutability:           order.BlobAttributes.PreserveImmutability,
+		ComputeSha256:                  order.ComputeSha256,
+		BlockBlobTierNameLength:        uint8(len(order.BlobAttributes.BlockBlobTierName)),
+		SkipSMBInfo:                    order.SkipSMBInfo,
+		FileMode:                       order.FileMode,
+		DirMode:                        order.DirMode,
+		S2SPreference:                  order.S2SPreference,
+		RehydrateAndCopy:               order.RehydrateAndCopy,
+		ClientSideEncryption:           order.ClientSideEncryptionKey.IsSet(),
+		TierByAccessTimeLength:         uint8(len(order.BlobAttributes.TierByAccessTime)),
+		PrefixConcurrencyLength:        uint16(len(prefixConcurrency)),
 	}
 
 	// Copy any strings into their respective fields
@@ -220,6 +268,14 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 	copy(jpph.SourceExtraQuery[:], order.SourceRoot.ExtraQuery)
 	copy(jpph.DestinationRoot[:], order.DestinationRoot.Value)
 	copy(jpph.DestExtraQuery[:], order.DestinationRoot.ExtraQuery)
+	copy(jpph.ExpectedEncryptionScope[:], order.BlobAttributes.ExpectedEncryptionScope)
+	copy(jpph.BlockIDPrefix[:], order.BlobAttributes.BlockIDPrefix)
+	copy(jpph.BlockBlobTierName[:], order.BlobAttributes.BlockBlobTierName)
+	copy(jpph.TierByAccessTime[:], order.BlobAttributes.TierByAccessTime)
+	copy(jpph.PrefixConcurrency[:], prefixConcurrency)
+	if !order.CommandStartTime.IsZero() {
+		jpph.CommandStartTime = order.CommandStartTime.UnixNano()
+	}
 	copy(jpph.DstBlobData.ContentType[:], order.BlobAttributes.ContentType)
 	copy(jpph.DstBlobData.ContentEncoding[:], order.BlobAttributes.ContentEncoding)
 	copy(jpph.DstBlobData.ContentLanguage[:], order.BlobAttributes.ContentLanguage)
@@ -278,6 +334,7 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 			SrcLength:      int16(len(order.Transfers[t].Source)),
 			DstLength:      int16(len(order.Transfers[t].Destination)),
 			EntityType:     order.Transfers[t].EntityType,
+			Priority:       order.Transfers[t].Priority,
 			ModifiedTime:   order.Transfers[t].LastModifiedTime.UnixNano(),
 			SourceSize:     order.Transfers[t].SourceSize,
 			CompletionTime: 0,
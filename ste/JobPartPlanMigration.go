@@ -0,0 +1,651 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// planMigration converts the content of a job part plan file from one data schema version to the next one
+type planMigration func(plan []byte) ([]byte, error)
+
+// planMigrations holds, for each data schema version that can be migrated, the migration to the version after it.
+// When DataSchemaVersion is incremented, add the migration from the previous version here if the change allows it,
+// so that jobs which were in flight when AzCopy was upgraded can still be resumed.
+var planMigrations = map[common.Version]planMigration{
+	16: migratePlanFromV16,
+	17: migratePlanFromV17,
+	18: migratePlanFromV18,
+	19: migratePlanFromV19,
+	20: migratePlanFromV20,
+	21: migratePlanFromV21,
+	22: migratePlanFromV22,
+	23: migratePlanFromV23,
+	24: migratePlanFromV24,
+	25: migratePlanFromV25,
+	26: migratePlanFromV26,
+	27: migratePlanFromV27,
+	28: migratePlanFromV28,
+	29: migratePlanFromV29,
+	30: migratePlanFromV30,
+	31: migratePlanFromV31,
+	32: migratePlanFromV32,
+	33: migratePlanFromV33,
+	34: migratePlanFromV34,
+	35: migratePlanFromV35,
+	36: migratePlanFromV36,
+	37: migratePlanFromV37,
+	38: migratePlanFromV38,
+	39: migratePlanFromV39,
+	40: migratePlanFromV40,
+	41: migratePlanFromV41,
+	42: migratePlanFromV42,
+	43: migratePlanFromV43,
+	44: migratePlanFromV44,
+	45: migratePlanFromV45,
+	46: migratePlanFromV46,
+}
+
+// JobPartPlanFileVersion returns the data schema version that a job part plan file was written with, according to its name
+func JobPartPlanFileVersion(fileName string) (common.Version, error) {
+	i := strings.LastIndex(fileName, ".steV")
+	if i < 0 {
+		return 0, fmt.Errorf("%s is not a job part plan file", fileName)
+	}
+	var version common.Version
+	if _, err := fmt.Sscanf(fileName[i:], ".steV%d", &version); err != nil {
+		return 0, fmt.Errorf("%s is not a job part plan file", fileName)
+	}
+	return version, nil
+}
+
+// MigrateJobPlan rewrites the plan files of one job, which were written by an earlier version of AzCopy, into the current format.
+// Either all the parts are migrated, or none are: the files are only replaced once every part has been converted.
+func MigrateJobPlan(planFolder string, fileNames []string) error {
+	migrated := make(map[string][]byte, len(fileNames))
+	for _, fileName := range fileNames {
+		version, err := JobPartPlanFileVersion(fileName)
+		if err != nil {
+			return err
+		}
+		if version > DataSchemaVersion {
+			return fmt.Errorf("its plan was written by a newer version of AzCopy (data schema version %d, while this version uses %d)", version, DataSchemaVersion)
+		}
+
+		plan, err := ioutil.ReadFile(filepath.Join(planFolder, fileName))
+		if err != nil {
+			return err
+		}
+		for ; version < DataSchemaVersion; version++ {
+			migrate, ok := planMigrations[version]
+			if !ok {
+				return fmt.Errorf("its plan has data schema version %d, and the changes made to the format since then cannot be converted", version)
+			}
+			if plan, err = migrate(plan); err != nil {
+				return fmt.Errorf("part %s could not be converted from data schema version %d: %s", fileName, version, err.Error())
+			}
+		}
+		migrated[fileName] = plan
+	}
+
+	for fileName, plan := range migrated {
+		newFileName := fileName[:strings.LastIndex(fileName, ".steV")] + fmt.Sprintf(".steV%d", DataSchemaVersion)
+		if err := ioutil.WriteFile(filepath.Join(planFolder, newFileName), plan, common.DEFAULT_FILE_PERM); err != nil {
+			return err
+		}
+	}
+	for fileName := range migrated {
+		if err := os.Remove(filepath.Join(planFolder, fileName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migratePlanFromV16 converts a plan from data schema version 16, which the last release before plans could be migrated wrote, to 17.
+// Version 17 added JobPartPlanHeader.SkipLocked and RetryLocked before Priority, which moved along by 2 bytes into what used to be padding,
+// so nothing after it moves. The flags are cleared, since jobs created before they existed failed the transfers of locked files.
+func migratePlanFromV16(plan []byte) ([]byte, error) {
+	const (
+		headerSize        = 10400 // the size of JobPartPlanHeader
+		skipLockedOffset  = 4048  // the offset of JobPartPlanHeader.SkipLocked, which RetryLocked follows
+		oldPriorityOffset = 4048  // the offset of JobPartPlanHeader.Priority in version 16
+		newPriorityOffset = 4050  // ... and in version 17
+	)
+	migrated, err := clearPlanHeaderBytes(plan, headerSize, skipLockedOffset, newPriorityOffset, 17)
+	if err != nil {
+		return nil, err
+	}
+	migrated[newPriorityOffset] = plan[oldPriorityOffset]
+	return migrated, nil
+}
+
+// migratePlanFromV17 converts a plan from data schema version 17 to 18. Version 18 added JobPartPlanHeader.BatchDelete
+// after DeleteSnapshotsOption, in what used to be the padding at the end of the header, so only the version and that padding need updating.
+func migratePlanFromV17(plan []byte) ([]byte, error) {
+	const (
+		headerSize        = 10400 // the size of JobPartPlanHeader
+		batchDeleteOffset = 10397 // the offset of JobPartPlanHeader.BatchDelete
+	)
+	// off, since jobs created before it existed deleted blobs one at a time
+	return clearPlanHeaderBytes(plan, headerSize, batchDeleteOffset, batchDeleteOffset+1, 18)
+}
+
+// migratePlanFromV18 converts a plan from data schema version 18 to 19. Version 19 added JobPartPlanHeader.ProgressBasis
+// where Priority was, and Priority moved along by a byte into what used to be padding, so nothing after it moves.
+func migratePlanFromV18(plan []byte) ([]byte, error) {
+	const (
+		headerSize          = 10400 // the size of JobPartPlanHeader
+		progressBasisOffset = 4050  // the offset of JobPartPlanHeader.ProgressBasis, and of Priority in version 18
+		newPriorityOffset   = 4051  // the offset of JobPartPlanHeader.Priority in version 19
+	)
+	// Bytes, which is what jobs created before it existed measured their progress by
+	migrated, err := clearPlanHeaderBytes(plan, headerSize, progressBasisOffset, newPriorityOffset, 19)
+	if err != nil {
+		return nil, err
+	}
+	migrated[newPriorityOffset] = plan[progressBasisOffset]
+	return migrated, nil
+}
+
+// migratePlanFromV19 converts a plan from data schema version 19 to 20. Version 20 added JobPartPlanHeader.PreserveFileAttributes
+// after PreserveSMBInfo. The fields after it move along: the four one-byte flags and options by a byte, and the job status,
+// DeleteSnapshotsOption and BatchDelete by 4 bytes (the alignment of the job status), which grew the header by 8 bytes.
+// So everything after the header moves along by 8 bytes, and so does the SrcOffset of each transfer.
+func migratePlanFromV19(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize                = 10400 // the size of JobPartPlanHeader in version 19
+		addedHeaderBytes             = 8
+		transferSize                 = 72    // the size of JobPartPlanTransfer before version 24
+		preserveFileAttributesOffset = 10388 // the offset of JobPartPlanHeader.PreserveFileAttributes
+		oldFlagsOffset               = 10388 // the offset of S2SGetPropertiesInBackend, S2SSourceChangeValidation, DestLengthValidation and S2SInvalidMetadataHandleOption in version 19
+		newFlagsOffset               = 10389 // ... and in version 20
+		oldStatusOffset              = 10392 // the offset of atomicJobStatus, DeleteSnapshotsOption and BatchDelete in version 19
+		newStatusOffset              = 10396 // ... and in version 20
+	)
+	migrated, err := growPlanHeaderOf(plan, oldHeaderSize, transferSize, preserveFileAttributesOffset, addedHeaderBytes, 20)
+	if err != nil {
+		return nil, err
+	}
+	// PreserveFileAttributes is off, since jobs created before it existed never kept file attributes
+	copy(migrated[newFlagsOffset:newFlagsOffset+4], plan[oldFlagsOffset:oldFlagsOffset+4])
+	copy(migrated[newStatusOffset:newStatusOffset+6], plan[oldStatusOffset:oldStatusOffset+6])
+	return migrated, nil
+}
+
+// migratePlanFromV20 converts a plan from data schema version 20 to 21. Version 21 added JobPartPlanHeader.TrailingDot
+// after PreserveFileAttributes, and the four one-byte fields after it moved along by a byte into what used to be padding,
+// so nothing else moves.
+func migratePlanFromV20(plan []byte) ([]byte, error) {
+	const (
+		headerSize        = 10408 // the size of JobPartPlanHeader
+		trailingDotOffset = 10389 // the offset of JobPartPlanHeader.TrailingDot, and of the moved fields in version 20
+		newFlagsOffset    = 10390 // the offset of the moved fields in version 21
+	)
+	// Disable, since jobs created before it existed never asked the service to keep trailing dots
+	migrated, err := clearPlanHeaderBytes(plan, headerSize, trailingDotOffset, newFlagsOffset, 21)
+	if err != nil {
+		return nil, err
+	}
+	copy(migrated[newFlagsOffset:newFlagsOffset+4], plan[trailingDotOffset:trailingDotOffset+4])
+	return migrated, nil
+}
+
+// migratePlanFromV21 converts a plan from data schema version 21 to 22. Version 22 added JobPartPlanHeader.LogFormat
+// after LogLevel, in what used to be padding, so only the version and that padding need updating.
+func migratePlanFromV21(plan []byte) ([]byte, error) {
+	const (
+		headerSize      = 10408 // the size of JobPartPlanHeader
+		logFormatOffset = 4069  // the offset of JobPartPlanHeader.LogFormat
+	)
+	// Text, which is how jobs created before it existed wrote their log
+	return clearPlanHeaderBytes(plan, headerSize, logFormatOffset, logFormatOffset+1, 22)
+}
+
+// migratePlanFromV22 converts a plan from data schema version 22 to 23. Version 23 added JobPartPlanDstBlob.DeltaUpdate
+// after PutMd5, in what used to be padding, so only the version and that padding need updating.
+func migratePlanFromV22(plan []byte) ([]byte, error) {
+	const (
+		headerSize        = 10408 // the size of JobPartPlanHeader
+		deltaUpdateOffset = 5367  // the offset of JobPartPlanHeader.DstBlobData.DeltaUpdate
+	)
+	// off, since jobs created before it existed always uploaded whole files
+	return clearPlanHeaderBytes(plan, headerSize, deltaUpdateOffset, deltaUpdateOffset+1, 23)
+}
+
+// migratePlanFromV23 converts a plan from data schema version 23 to 24. Version 24 added the checkpointed byte count at the end of
+// each transfer, which is zero for a transfer that has never been checkpointed. So each transfer grows by 8 bytes,
+// and the strings which follow the transfers move along by the total of that growth.
+// The layout of version 23 is spelled out here, rather than taken from the current structs, because those will keep changing.
+func migratePlanFromV23(plan []byte) ([]byte, error) {
+	const (
+		headerSize                = 10408 // the size of JobPartPlanHeader
+		commandStringLengthOffset = 4060  // the offset of JobPartPlanHeader.CommandStringLength
+		numTransfersOffset        = 4064  // the offset of JobPartPlanHeader.NumTransfers
+		oldTransferSize           = 72    // the size of JobPartPlanTransfer in version 23
+		addedTransferBytes        = 8     // JobPartPlanTransfer.atomicCheckpointedBytes
+	)
+	if len(plan) < headerSize {
+		return nil, fmt.Errorf("the file is too short to hold a plan")
+	}
+
+	commandStringLength := int64(*(*uint32)(unsafe.Pointer(&plan[commandStringLengthOffset])))
+	numTransfers := int64(*(*uint32)(unsafe.Pointer(&plan[numTransfersOffset])))
+	transfersStart := headerSize + commandStringLength
+	stringsStart := transfersStart + numTransfers*oldTransferSize
+	if int64(len(plan)) < stringsStart {
+		return nil, fmt.Errorf("the file is too short to hold %d transfers", numTransfers)
+	}
+
+	growth := numTransfers * addedTransferBytes
+	migrated := make([]byte, int64(len(plan))+growth)
+	copy(migrated, plan[:transfersStart])
+	*(*common.Version)(unsafe.Pointer(&migrated[0])) = 24
+
+	for t := int64(0); t < numTransfers; t++ {
+		oldOffset := transfersStart + t*oldTransferSize
+		newOffset := transfersStart + t*(oldTransferSize+addedTransferBytes)
+		copy(migrated[newOffset:], plan[oldOffset:oldOffset+oldTransferSize])
+
+		// SrcOffset, the first field of the transfer, is the position of the transfer's strings in the file
+		*(*int64)(unsafe.Pointer(&migrated[newOffset])) += growth
+	}
+	copy(migrated[stringsStart+growth:], plan[stringsStart:])
+	return migrated, nil
+}
+
+// growPlanHeader returns a copy of the plan, with the given version, in which the header has grown by addedHeaderBytes.
+// It's for changes made since version 24, when transfers took the size that they still have; see growPlanHeaderOf.
+func growPlanHeader(plan []byte, oldHeaderSize, clearFrom, addedHeaderBytes int64, version common.Version) ([]byte, error) {
+	const transferSize = 80 // the size of JobPartPlanTransfer
+	return growPlanHeaderOf(plan, oldHeaderSize, transferSize, clearFrom, addedHeaderBytes, version)
+}
+
+// growPlanHeaderOf returns a copy of the plan, with the given version, in which the header has grown by addedHeaderBytes.
+// The header is kept up to clearFrom, and is zero from there to its new end, so clearFrom is where the new fields start,
+// or the first of the padding that they take over. Everything after the header (the command string, the transfers and their strings)
+// moves along, and so does SrcOffset, the first field of each transfer, which is the position of the transfer's strings in the file.
+// transferSize is the size of the transfers in the plan's version.
+func growPlanHeaderOf(plan []byte, oldHeaderSize, transferSize, clearFrom, addedHeaderBytes int64, version common.Version) ([]byte, error) {
+	const (
+		commandStringLengthOffset = 4060 // the offset of JobPartPlanHeader.CommandStringLength
+		numTransfersOffset        = 4064 // the offset of JobPartPlanHeader.NumTransfers
+	)
+	if int64(len(plan)) < oldHeaderSize {
+		return nil, fmt.Errorf("the file is too short to hold a plan")
+	}
+
+	commandStringLength := int64(*(*uint32)(unsafe.Pointer(&plan[commandStringLengthOffset])))
+	numTransfers := int64(*(*uint32)(unsafe.Pointer(&plan[numTransfersOffset])))
+	oldTransfersStart := oldHeaderSize + commandStringLength
+	if int64(len(plan)) < oldTransfersStart+numTransfers*transferSize {
+		return nil, fmt.Errorf("the file is too short to hold %d transfers", numTransfers)
+	}
+
+	migrated := make([]byte, int64(len(plan))+addedHeaderBytes)
+	copy(migrated, plan[:clearFrom])
+	copy(migrated[oldHeaderSize+addedHeaderBytes:], plan[oldHeaderSize:])
+	*(*common.Version)(unsafe.Pointer(&migrated[0])) = version
+
+	newTransfersStart := oldTransfersStart + addedHeaderBytes
+	for t := int64(0); t < numTransfers; t++ {
+		*(*int64)(unsafe.Pointer(&migrated[newTransfersStart+t*transferSize])) += addedHeaderBytes
+	}
+	return migrated, nil
+}
+
+// clearPlanHeaderBytes returns a copy of the plan, with the given version, in which the header bytes from clearFrom up to clearTo are zero.
+// It's for changes that added fields to what used to be padding in the header, so that nothing moves.
+func clearPlanHeaderBytes(plan []byte, headerSize, clearFrom, clearTo int, version common.Version) ([]byte, error) {
+	if len(plan) < headerSize {
+		return nil, fmt.Errorf("the file is too short to hold a plan")
+	}
+
+	migrated := make([]byte, len(plan))
+	copy(migrated, plan)
+	*(*common.Version)(unsafe.Pointer(&migrated[0])) = version
+	for i := clearFrom; i < clearTo; i++ {
+		migrated[i] = 0
+	}
+	return migrated, nil
+}
+
+// migratePlanFromV24 converts a plan from data schema version 24 to 25. Version 25 added JobPartPlanHeader.AppendOnly
+// into what used to be padding in the header, so nothing moves; the flag is cleared, since jobs created before it existed were never append-only.
+func migratePlanFromV24(plan []byte) ([]byte, error) {
+	const (
+		headerSize       = 10408 // the size of JobPartPlanHeader
+		appendOnlyOffset = 10394 // the offset of JobPartPlanHeader.AppendOnly
+	)
+	return clearPlanHeaderBytes(plan, headerSize, appendOnlyOffset, appendOnlyOffset+1, 25)
+}
+
+// migratePlanFromV25 converts a plan from data schema version 25 to 26. Version 26 added JobPartPlanHeader.SetReadOnly
+// into the last byte of the padding that AppendOnly was added to, so as with version 25, nothing moves.
+func migratePlanFromV25(plan []byte) ([]byte, error) {
+	const (
+		headerSize        = 10408 // the size of JobPartPlanHeader
+		setReadOnlyOffset = 10395 // the offset of JobPartPlanHeader.SetReadOnly
+	)
+	return clearPlanHeaderBytes(plan, headerSize, setReadOnlyOffset, setReadOnlyOffset+1, 26)
+}
+
+// migratePlanFromV26 converts a plan from data schema version 26 to 27. Version 27 added JobPartPlanHeader.PreservePOSIX
+// after SetReadOnly. There was no padding left there, so the fields after it move along by 4 bytes (the alignment of the job status),
+// into what used to be padding at the end of the header. So the header, and everything after it, stays where it was.
+func migratePlanFromV26(plan []byte) ([]byte, error) {
+	const (
+		headerSize          = 10408 // the size of JobPartPlanHeader
+		preservePOSIXOffset = 10396 // the offset of JobPartPlanHeader.PreservePOSIX
+		oldMovedOffset      = 10396 // the offset of atomicJobStatus, DeleteSnapshotsOption and BatchDelete in version 26
+		newMovedOffset      = 10400 // ... and in version 27
+		movedBytes          = 6
+	)
+	if len(plan) < headerSize {
+		return nil, fmt.Errorf("the file is too short to hold a plan")
+	}
+
+	migrated := make([]byte, len(plan))
+	copy(migrated, plan)
+	*(*common.Version)(unsafe.Pointer(&migrated[0])) = 27
+	copy(migrated[newMovedOffset:newMovedOffset+movedBytes], plan[oldMovedOffset:oldMovedOffset+movedBytes])
+	for i := preservePOSIXOffset; i < newMovedOffset; i++ {
+		migrated[i] = 0 // PreservePOSIX is off, since jobs created before it existed never preserved POSIX properties
+	}
+	return migrated, nil
+}
+
+// migratePlanFromV27 converts a plan from data schema version 27 to 28. Version 28 added JobPartPlanDstBlob.MaxBlocks,
+// which fits into what used to be padding before BlockSize, so only the version and that padding need updating.
+func migratePlanFromV27(plan []byte) ([]byte, error) {
+	const (
+		headerSize      = 10408 // the size of JobPartPlanHeader
+		maxBlocksOffset = 10372 // the offset of JobPartPlanHeader.DstBlobData.MaxBlocks
+	)
+	// no maximum, since jobs created before it existed always picked the block size themselves
+	return clearPlanHeaderBytes(plan, headerSize, maxBlocksOffset, maxBlocksOffset+2, 28)
+}
+
+// migratePlanFromV28 converts a plan from data schema version 28 to 29. Version 29 added JobPartPlanHeader.PreserveDirectoryTimestamps
+// after PreservePOSIX, in what used to be padding, so only the version and that padding need updating.
+func migratePlanFromV28(plan []byte) ([]byte, error) {
+	const (
+		headerSize                        = 10408 // the size of JobPartPlanHeader
+		preserveDirectoryTimestampsOffset = 10397 // the offset of JobPartPlanHeader.PreserveDirectoryTimestamps
+	)
+	// off, since jobs created before it existed never preserved directory timestamps
+	return clearPlanHeaderBytes(plan, headerSize, preserveDirectoryTimestampsOffset, preserveDirectoryTimestampsOffset+1, 29)
+}
+
+// migratePlanFromV29 converts a plan from data schema version 29 to 30. Version 30 added JobPartPlanHeader.Transactional
+// after PreserveDirectoryTimestamps, and JobPartPlanTransfer.DstCreated after SrcBlobTagsLength. Both went into what used to be padding,
+// so nothing moves; the flags are cleared, since jobs created before they existed were never transactional.
+func migratePlanFromV29(plan []byte) ([]byte, error) {
+	const (
+		headerSize                = 10408 // the size of JobPartPlanHeader
+		commandStringLengthOffset = 4060  // the offset of JobPartPlanHeader.CommandStringLength
+		numTransfersOffset        = 4064  // the offset of JobPartPlanHeader.NumTransfers
+		transactionalOffset       = 10398 // the offset of JobPartPlanHeader.Transactional
+		transferSize              = 80    // the size of JobPartPlanTransfer
+		dstCreatedOffset          = 62    // the offset of JobPartPlanTransfer.DstCreated
+	)
+	if len(plan) < headerSize {
+		return nil, fmt.Errorf("the file is too short to hold a plan")
+	}
+
+	commandStringLength := int64(*(*uint32)(unsafe.Pointer(&plan[commandStringLengthOffset])))
+	numTransfers := int64(*(*uint32)(unsafe.Pointer(&plan[numTransfersOffset])))
+	transfersStart := headerSize + commandStringLength
+	if int64(len(plan)) < transfersStart+numTransfers*transferSize {
+		return nil, fmt.Errorf("the file is too short to hold %d transfers", numTransfers)
+	}
+
+	migrated := make([]byte, len(plan))
+	copy(migrated, plan)
+	*(*common.Version)(unsafe.Pointer(&migrated[0])) = 30
+	migrated[transactionalOffset] = 0
+	for t := int64(0); t < numTransfers; t++ {
+		migrated[transfersStart+t*transferSize+dstCreatedOffset] = 0
+	}
+	return migrated, nil
+}
+
+// migratePlanFromV30 converts a plan from data schema version 30 to 31. Version 31 added JobPartPlanHeader.ExpiryOption and ExpiryTime
+// at the end of the header. The option went into what used to be padding, but the time grew the header by 8 bytes,
+// so everything after the header (the command string, the transfers and their strings) moves along by 8 bytes.
+// SrcOffset, the first field of each transfer, is the position of the transfer's strings in the file, so it moves along too.
+func migratePlanFromV30(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize      = 10408 // the size of JobPartPlanHeader in version 30
+		addedHeaderBytes   = 8     // JobPartPlanHeader.ExpiryTime
+		expiryOptionOffset = 10406 // the offset of JobPartPlanHeader.ExpiryOption
+	)
+	// ExpiryOption is None, since jobs created before it existed never set the expiry of blobs
+	return growPlanHeader(plan, oldHeaderSize, expiryOptionOffset, addedHeaderBytes, 31)
+}
+
+// migratePlanFromV31 converts a plan from data schema version 31 to 32. Version 32 added JobPartPlanHeader.VerifyEncryption,
+// ExpectedEncryptionScopeLength and ExpectedEncryptionScope at the end of the header, which grew it by 72 bytes.
+// As for version 31, everything after the header moves along, and so does the SrcOffset of each transfer.
+// The new fields are left zero, so that the blobs of older jobs are not checked.
+func migratePlanFromV31(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize    = 10416 // the size of JobPartPlanHeader in version 31
+		addedHeaderBytes = 72    // VerifyEncryption, ExpectedEncryptionScopeLength, ExpectedEncryptionScope, and padding
+	)
+	return growPlanHeader(plan, oldHeaderSize, oldHeaderSize, addedHeaderBytes, 32)
+}
+
+// migratePlanFromV32 converts a plan from data schema version 32 to 33. Version 33 added JobPartPlanHeader.CommandStartTime
+// at the end of the header, which grew it by 8 bytes. As for version 32, everything after the header moves along,
+// and so does the SrcOffset of each transfer.
+// CommandStartTime is left zero, since the time the command started was not recorded.
+func migratePlanFromV32(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize    = 10488 // the size of JobPartPlanHeader in version 32
+		addedHeaderBytes = 8     // CommandStartTime
+	)
+	return growPlanHeader(plan, oldHeaderSize, oldHeaderSize, addedHeaderBytes, 33)
+}
+
+// migratePlanFromV33 converts a plan from data schema version 33 to 34. Version 34 added JobPartPlanHeader.PageBlobSequenceNumber
+// at the end of the header, which grew it by 8 bytes. As for version 33, everything after the header moves along,
+// and so does the SrcOffset of each transfer.
+// PageBlobSequenceNumber is left zero, which is the sequence number that page blobs were always created with before.
+func migratePlanFromV33(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize    = 10496 // the size of JobPartPlanHeader in version 33
+		addedHeaderBytes = 8     // PageBlobSequenceNumber
+	)
+	return growPlanHeader(plan, oldHeaderSize, oldHeaderSize, addedHeaderBytes, 34)
+}
+
+// migratePlanFromV34 converts a plan from data schema version 34 to 35. Version 35 added JobPartPlanHeader.BlockIDPrefixLength
+// and BlockIDPrefix at the end of the header, which grew it by 24 bytes. As for version 34, everything after the header moves along,
+// and so does the SrcOffset of each transfer.
+// The prefix is left empty, so blocks keep being staged with AzCopy's usual IDs.
+func migratePlanFromV34(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize    = 10504 // the size of JobPartPlanHeader in version 34
+		addedHeaderBytes = 24    // BlockIDPrefixLength, BlockIDPrefix, and padding
+	)
+	return growPlanHeader(plan, oldHeaderSize, oldHeaderSize, addedHeaderBytes, 35)
+}
+
+// migratePlanFromV35 converts a plan from data schema version 35 to 36. Version 36 added JobPartPlanTransfer.Priority after EntityType,
+// in what used to be padding, so nothing moves. It's cleared, so that the transfers of older jobs keep the priority of their job part.
+func migratePlanFromV35(plan []byte) ([]byte, error) {
+	const (
+		headerSize                = 10528 // the size of JobPartPlanHeader
+		commandStringLengthOffset = 4060  // the offset of JobPartPlanHeader.CommandStringLength
+		numTransfersOffset        = 4064  // the offset of JobPartPlanHeader.NumTransfers
+		transferSize              = 80    // the size of JobPartPlanTransfer
+		priorityOffset            = 13    // the offset of JobPartPlanTransfer.Priority
+	)
+	if len(plan) < headerSize {
+		return nil, fmt.Errorf("the file is too short to hold a plan")
+	}
+
+	commandStringLength := int64(*(*uint32)(unsafe.Pointer(&plan[commandStringLengthOffset])))
+	numTransfers := int64(*(*uint32)(unsafe.Pointer(&plan[numTransfersOffset])))
+	transfersStart := headerSize + commandStringLength
+	if int64(len(plan)) < transfersStart+numTransfers*transferSize {
+		return nil, fmt.Errorf("the file is too short to hold %d transfers", numTransfers)
+	}
+
+	migrated := make([]byte, len(plan))
+	copy(migrated, plan)
+	*(*common.Version)(unsafe.Pointer(&migrated[0])) = 36
+	for t := int64(0); t < numTransfers; t++ {
+		migrated[transfersStart+t*transferSize+priorityOffset] = 0
+	}
+	return migrated, nil
+}
+
+// migratePlanFromV36 converts a plan from data schema version 36 to 37. Version 37 added JobPartPlanHeader.PreserveImmutability
+// after Transactional, in what used to be padding, so only the version and that padding need updating.
+func migratePlanFromV36(plan []byte) ([]byte, error) {
+	const (
+		headerSize                 = 10528 // the size of JobPartPlanHeader
+		preserveImmutabilityOffset = 10399 // the offset of JobPartPlanHeader.PreserveImmutability
+	)
+	// off, since jobs created before it existed never preserved immutability
+	return clearPlanHeaderBytes(plan, headerSize, preserveImmutabilityOffset, preserveImmutabilityOffset+1, 37)
+}
+
+// migratePlanFromV37 converts a plan from data schema version 37 to 38. Version 38 added JobPartPlanHeader.ComputeSha256
+// after BlockIDPrefix, in what used to be the padding at the end of the header, so only the version and that padding need updating.
+func migratePlanFromV37(plan []byte) ([]byte, error) {
+	const (
+		headerSize          = 10528 // the size of JobPartPlanHeader
+		computeSha256Offset = 10527 // the offset of JobPartPlanHeader.ComputeSha256
+	)
+	// off, since there's no manifest to write for a job that's resumed
+	return clearPlanHeaderBytes(plan, headerSize, computeSha256Offset, computeSha256Offset+1, 38)
+}
+
+// migratePlanFromV38 converts a plan from data schema version 38 to 39. Version 39 added JobPartPlanHeader.BlockBlobTierNameLength
+// and BlockBlobTierName at the end of the header, which grew it by 16 bytes. As for version 34, everything after the header moves along,
+// and so does the SrcOffset of each transfer.
+// The name is left empty, since jobs created before it existed could only set the tiers that DstBlobData.BlockBlobTier has values for.
+func migratePlanFromV38(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize    = 10528 // the size of JobPartPlanHeader in version 38
+		addedHeaderBytes = 16    // BlockBlobTierNameLength, BlockBlobTierName, and padding
+	)
+	return growPlanHeader(plan, oldHeaderSize, oldHeaderSize, addedHeaderBytes, 39)
+}
+
+// migratePlanFromV39 converts a plan from data schema version 39 to 40. Version 40 added JobPartPlanHeader.SkipSMBInfo
+// after BlockBlobTierName, in what used to be the padding at the end of the header, so only the version and that padding need updating.
+func migratePlanFromV39(plan []byte) ([]byte, error) {
+	const (
+		headerSize        = 10544 // the size of JobPartPlanHeader
+		skipSMBInfoOffset = 10539 // the offset of JobPartPlanHeader.SkipSMBInfo
+	)
+	// off, since jobs created before it existed made the calls as usual
+	return clearPlanHeaderBytes(plan, headerSize, skipSMBInfoOffset, skipSMBInfoOffset+1, 40)
+}
+
+// migratePlanFromV40 converts a plan from data schema version 40 to 41. Version 41 added JobPartPlanHeader.FileMode and DirMode
+// after SkipSMBInfo, in the last of the padding at the end of the header, so only the version and that padding need updating.
+func migratePlanFromV40(plan []byte) ([]byte, error) {
+	const (
+		headerSize     = 10544 // the size of JobPartPlanHeader
+		fileModeOffset = 10540 // the offset of JobPartPlanHeader.FileMode, which DirMode follows
+	)
+	// not set, since jobs created before they existed left the modes to the process umask
+	return clearPlanHeaderBytes(plan, headerSize, fileModeOffset, headerSize, 41)
+}
+
+// migratePlanFromV41 converts a plan from data schema version 41 to 42. Version 42 added JobPartPlanHeader.S2SPreference
+// at the end of the header, which grew it by 8 bytes. As for version 38, everything after the header moves along,
+// and so does the SrcOffset of each transfer. The added bytes are zero, which is Prefer, as jobs created before it existed always did.
+func migratePlanFromV41(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize    = 10544 // the size of JobPartPlanHeader in version 41
+		addedHeaderBytes = 8     // S2SPreference, and padding
+	)
+	return growPlanHeader(plan, oldHeaderSize, oldHeaderSize, addedHeaderBytes, 42)
+}
+
+// migratePlanFromV42 converts a plan from data schema version 42 to 43. Version 43 added JobPartPlanHeader.RehydrateAndCopy
+// after S2SPreference, in what used to be the padding at the end of the header, so only the version and that padding need updating.
+func migratePlanFromV42(plan []byte) ([]byte, error) {
+	const (
+		headerSize             = 10552 // the size of JobPartPlanHeader
+		rehydrateAndCopyOffset = 10545 // the offset of JobPartPlanHeader.RehydrateAndCopy
+	)
+	// not set, since jobs created before it existed failed the transfers of archived sources
+	return clearPlanHeaderBytes(plan, headerSize, rehydrateAndCopyOffset, headerSize, 43)
+}
+
+// migratePlanFromV43 converts a plan from data schema version 43 to 44. Version 44 added JobPartPlanHeader.TierByAccessTimeLength
+// and TierByAccessTime after RehydrateAndCopy, which grew the header by 64 bytes. As for version 41, everything after the header moves along,
+// and so does the SrcOffset of each transfer. The new fields, and what used to be the padding before them, are zero, so older jobs have no rules.
+func migratePlanFromV43(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize          = 10552 // the size of JobPartPlanHeader in version 43
+		addedHeaderBytes       = 64    // TierByAccessTimeLength, TierByAccessTime, and padding
+		tierByAccessTimeOffset = 10546 // the offset of JobPartPlanHeader.TierByAccessTimeLength
+	)
+	return growPlanHeader(plan, oldHeaderSize, tierByAccessTimeOffset, addedHeaderBytes, 44)
+}
+
+// migratePlanFromV44 converts a plan from data schema version 44 to 45. Version 45 added JobPartPlanHeader.ClientSideEncryption
+// after TierByAccessTime, in what used to be the padding at the end of the header, so only the version and that padding need updating.
+func migratePlanFromV44(plan []byte) ([]byte, error) {
+	const (
+		headerSize                 = 10616 // the size of JobPartPlanHeader
+		clientSideEncryptionOffset = 10611 // the offset of JobPartPlanHeader.ClientSideEncryption
+	)
+	// not set, since jobs created before it existed never encrypted anything
+	return clearPlanHeaderBytes(plan, headerSize, clientSideEncryptionOffset, headerSize, 45)
+}
+
+// migratePlanFromV45 converts a plan from data schema version 45 to 46. Version 46 added JobPartPlanHeader.TransactionalFailureThreshold
+// after ClientSideEncryption, in what used to be the padding at the end of the header, so only the version and that padding need updating.
+func migratePlanFromV45(plan []byte) ([]byte, error) {
+	const (
+		headerSize                   = 10616 // the size of JobPartPlanHeader
+		transactionalThresholdOffset = 10612 // the offset of JobPartPlanHeader.TransactionalFailureThreshold
+	)
+	// no failures allowed, as for transactional jobs created before it existed
+	return clearPlanHeaderBytes(plan, headerSize, transactionalThresholdOffset, headerSize, 46)
+}
+
+// migratePlanFromV46 converts a plan from data schema version 46 to 47. Version 47 added JobPartPlanHeader.PrefixConcurrencyLength
+// and PrefixConcurrency at the end of the header, which grew it by 256 bytes. As for version 43, everything after the header moves along,
+// and so does the SrcOffset of each transfer. The added bytes are zero, so older jobs have no limits, as when they were created.
+func migratePlanFromV46(plan []byte) ([]byte, error) {
+	const (
+		oldHeaderSize    = 10616 // the size of JobPartPlanHeader in version 46
+		addedHeaderBytes = 256   // PrefixConcurrencyLength and PrefixConcurrency
+	)
+	return growPlanHeader(plan, oldHeaderSize, oldHeaderSize, addedHeaderBytes, 47)
+}
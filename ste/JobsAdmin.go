@@ -71,12 +71,12 @@ var JobsAdmin interface {
 
 	// JobMgr returns the specified JobID's JobMgr
 	JobMgr(jobID common.JobID) (IJobMgr, bool)
-	JobMgrEnsureExists(jobID common.JobID, level common.LogLevel, commandString string) IJobMgr
+	JobMgrEnsureExists(jobID common.JobID, level common.LogLevel, format common.LogFormat, commandString string) IJobMgr
 
 	// AddJobPartMgr associates the specified JobPartMgr with the Jobs Administrator
 	//AddJobPartMgr(appContext context.Context, planFile JobPartPlanFileName) IJobPartMgr
 	/*ScheduleTransfer(jptm IJobPartTransferMgr)*/
-	ScheduleChunk(priority common.JobPriority, chunkFunc chunkFunc)
+	ScheduleChunk(priority common.JobPriority, jptm IJobPartTransferMgr, chunkFunc chunkFunc)
 
 	ResurrectJob(jobId common.JobID, sourceSAS string, destinationSAS string) bool
 
@@ -107,10 +107,13 @@ var JobsAdmin interface {
 
 	CurrentMainPoolSize() int
 
+	// ConcurrencyHistory returns the concurrency values chosen by the auto-tuner so far
+	ConcurrencyHistory() []common.ConcurrencyChange
+
 	RequestTuneSlowly()
 }
 
-func initJobsAdmin(appCtx context.Context, concurrency ConcurrencySettings, targetRateInMegaBitsPerSec float64, azcopyJobPlanFolder string, azcopyLogPathFolder string, providePerfAdvice bool) {
+func initJobsAdmin(appCtx context.Context, concurrency ConcurrencySettings, targetRateInMegaBitsPerSec float64, diskReadRateInMegaBitsPerSec float64, readAheadBytes int64, maxRetriesPerFile int32, maxTotalRetries int64, maxTransactions int64, requestsPerSecond int64, rampUp time.Duration, checkpointInterval time.Duration, chunkFairness common.ChunkFairness, logRotation common.LogRotationPolicy, azcopyJobPlanFolder string, azcopyLogPathFolder string, providePerfAdvice bool) {
 	if JobsAdmin != nil {
 		panic("initJobsAdmin was already called once")
 	}
@@ -137,12 +140,21 @@ func initJobsAdmin(appCtx context.Context, concurrency ConcurrencySettings, targ
 	// from which each part is picked up one by one
 	// and transfers of that JobPart are scheduled
 	partsCh := make(chan IJobPartMgr, PartsChannelSize)
-	// Create normal & low transfer/chunk channels
+	// Create high, normal & low transfer/chunk channels
+	highTransferCh, highChunkCh := make(chan IJobPartTransferMgr, channelSize), make(chan chunkFunc, channelSize)
 	normalTransferCh, normalChunkCh := make(chan IJobPartTransferMgr, channelSize), make(chan chunkFunc, channelSize)
 	lowTransferCh, lowChunkCh := make(chan IJobPartTransferMgr, channelSize), make(chan chunkFunc, channelSize)
 
 	maxRamBytesToUse := getMaxRamForChunks()
 
+	// requests are paced by treating each one as a single unit of traffic.
+	// Unlike the pacers below, this one stays nil when there's no cap, since there's nothing to count.
+	// Both it and the network pacer ramp up to their caps, if asked to, so as not to go from nothing to full rate at once
+	var requestPacer pacer
+	if requestsPerSecond > 0 {
+		requestPacer = newRampedTokenBucketPacer(requestsPerSecond, 0, rampUp)
+	}
+
 	// default to a pacer that doesn't actually control the rate
 	// (it just records total throughput, since for historical reasons we do that in the pacer)
 	var pacer pacerAdmin = newNullAutoPacer()
@@ -150,11 +162,18 @@ func initJobsAdmin(appCtx context.Context, concurrency ConcurrencySettings, targ
 		// use the "networking mega" (based on powers of 10, not powers of 2, since that's what mega means in networking context)
 		targetRateInBytesPerSec := int64(targetRateInMegaBitsPerSec * 1000 * 1000 / 8)
 		unusedExpectedCoarseRequestByteCount := int64(0)
-		pacer = newTokenBucketPacer(targetRateInBytesPerSec, unusedExpectedCoarseRequestByteCount)
+		pacer = newRampedTokenBucketPacer(targetRateInBytesPerSec, unusedExpectedCoarseRequestByteCount, rampUp)
 		// Note: as at July 2019, we don't currently have a shutdown method/event on JobsAdmin where this pacer
 		// could be shut down. But, it's global anyway, so we just leave it running until application exit.
 	}
 
+	// reads from local disk get a pacer of their own, so that they can be capped independently of the network.
+	// As above, the null pacer still counts the bytes, which lets us report the rate at which we read from disk.
+	var diskReadPacer pacerAdmin = newNullAutoPacer()
+	if diskReadRateInMegaBitsPerSec > 0 {
+		diskReadPacer = newTokenBucketPacer(int64(diskReadRateInMegaBitsPerSec*1000*1000/8), pacedDiskReadSize)
+	}
+
 	ja := &jobsAdmin{
 		concurrency:             concurrency,
 		logger:                  common.NewAppLogger(pipeline.LogInfo, azcopyLogPathFolder),
@@ -162,6 +181,14 @@ func initJobsAdmin(appCtx context.Context, concurrency ConcurrencySettings, targ
 		logDir:                  azcopyLogPathFolder,
 		planDir:                 azcopyJobPlanFolder,
 		pacer:                   pacer,
+		diskReadPacer:           diskReadPacer,
+		readAhead:               newReadAhead(readAheadBytes),
+		retryBudget:             newRetryBudget(maxRetriesPerFile, maxTotalRetries),
+		transactionBudget:       newTransactionBudget(maxTransactions),
+		requestPacer:            requestPacer,
+		checkpointInterval:      checkpointInterval,
+		chunkFairness:           chunkFairness,
+		logRotation:             logRotation,
 		slicePool:               common.NewMultiSizeSlicePool(common.MaxBlockBlobBlockSize),
 		cacheLimiter:            common.NewCacheLimiter(maxRamBytesToUse),
 		fileCountLimiter:        common.NewCacheLimiter(int64(concurrency.MaxOpenDownloadFiles)),
@@ -171,13 +198,16 @@ func initJobsAdmin(appCtx context.Context, concurrency ConcurrencySettings, targ
 		provideBenchmarkResults: providePerfAdvice,
 		coordinatorChannels: CoordinatorChannels{
 			partsChannel:     partsCh,
+			highTransferCh:   highTransferCh,
 			normalTransferCh: normalTransferCh,
 			lowTransferCh:    lowTransferCh,
 		},
 		xferChannels: XferChannels{
 			partsChannel:     partsCh,
+			highTransferCh:   highTransferCh,
 			normalTransferCh: normalTransferCh,
 			lowTransferCh:    lowTransferCh,
+			highChunkCh:      highChunkCh,
 			normalChunckCh:   normalChunkCh,
 			lowChunkCh:       lowChunkCh,
 		},
@@ -192,6 +222,10 @@ func initJobsAdmin(appCtx context.Context, concurrency ConcurrencySettings, targ
 			pipeline.LogLevel
 		}, 1000), // workaround to support logging from JobsAdmin
 	}
+	if chunkFairness == common.EChunkFairness.RoundRobin() {
+		ja.highFairChunks, ja.normalFairChunks, ja.lowFairChunks = newFairChunkQueue(channelSize), newFairChunkQueue(channelSize), newFairChunkQueue(channelSize)
+	}
+
 	// create new context with the defaultService api version set as value to serviceAPIVersionOverride in the app context.
 	ja.appCtx = context.WithValue(ja.appCtx, ServiceAPIVersionOverride, DefaultServiceApiVersion)
 
@@ -207,6 +241,11 @@ func initJobsAdmin(appCtx context.Context, concurrency ConcurrencySettings, targ
 	// Spin up slice pool pruner
 	go ja.slicePoolPruneLoop()
 
+	// Periodically write the job plans to disk, if the user asked us to
+	if checkpointInterval > 0 {
+		go ja.checkpointLoop()
+	}
+
 	// One routine constantly monitors the partsChannel.  It takes the JobPartManager from
 	// the Channel and schedules the transfers of that JobPart.
 	go ja.scheduleJobParts()
@@ -325,6 +364,7 @@ func (ja *jobsAdmin) recordTuningCompleted(showOutput bool) {
 
 // worker that sizes the chunkProcessor pool, dynamically if necessary
 func (ja *jobsAdmin) poolSizer(tuner ConcurrencyTuner) {
+	sizingStartTime := time.Now()
 
 	logConcurrency := func(targetConcurrency int, reason string) {
 		switch reason {
@@ -336,6 +376,7 @@ func (ja *jobsAdmin) poolSizer(tuner ConcurrencyTuner) {
 			msg := fmt.Sprintf("Trying %d concurrent connections (%s)", targetConcurrency, reason)
 			common.GetLifecycleMgr().Info(msg)
 			ja.LogToJobLog(msg, pipeline.LogInfo)
+			ja.recordConcurrencyChange(sizingStartTime, targetConcurrency, reason)
 		}
 	}
 
@@ -405,6 +446,27 @@ func (ja *jobsAdmin) poolSizer(tuner ConcurrencyTuner) {
 	}
 }
 
+// recordConcurrencyChange remembers a concurrency value chosen by the tuner, so that the job summary can show how the tuning went
+func (ja *jobsAdmin) recordConcurrencyChange(sizingStartTime time.Time, concurrency int, reason string) {
+	ja.concurrencyHistoryLock.Lock()
+	defer ja.concurrencyHistoryLock.Unlock()
+
+	ja.concurrencyHistory = append(ja.concurrencyHistory, common.ConcurrencyChange{
+		ElapsedSeconds: int(time.Since(sizingStartTime).Seconds()),
+		Concurrency:    concurrency,
+		Reason:         reason,
+	})
+}
+
+// ConcurrencyHistory returns the concurrency values chosen by the tuner so far, oldest first.
+// It is empty if concurrency is not being auto-tuned.
+func (ja *jobsAdmin) ConcurrencyHistory() []common.ConcurrencyChange {
+	ja.concurrencyHistoryLock.Lock()
+	defer ja.concurrencyHistoryLock.Unlock()
+
+	return append([]common.ConcurrencyChange(nil), ja.concurrencyHistory...)
+}
+
 // RequestTuneSlowly is used to ask for a slower rate of auto-concurrency tuning.
 // Necessary because if there's a download or S2S transfer going on, we need to measure throughputs over longer intervals to make
 // the auto tuning work.
@@ -423,30 +485,52 @@ func (ja *jobsAdmin) chunkProcessor(workerID int) {
 
 	for {
 		// We check for scalebacks first to shrink goroutine pool
-		// Then, we check chunks: normal & low priority
+		// Then, we check chunks: high, normal & low priority
 		select {
 		case <-ja.poolSizingChannels.scalebackRequestCh:
 			return
 		default:
+			if ja.chunkFairness == common.EChunkFairness.RoundRobin() {
+				ja.processFairChunk(workerID)
+				continue
+			}
 			select {
-			case chunkFunc := <-ja.xferChannels.normalChunckCh:
+			case chunkFunc := <-ja.xferChannels.highChunkCh:
 				chunkFunc(workerID)
 			default:
 				select {
-				case chunkFunc := <-ja.xferChannels.lowChunkCh:
+				case chunkFunc := <-ja.xferChannels.normalChunckCh:
 					chunkFunc(workerID)
 				default:
-					time.Sleep(100 * time.Millisecond) // Sleep before looping around
-					// TODO: Question: In order to safely support high goroutine counts,
-					// do we need to review sleep duration, or find an approach that does not require waking every x milliseconds
-					// For now, duration has been increased substantially from the previous 1 ms, to reduce cost of
-					// the wake-ups.
+					select {
+					case chunkFunc := <-ja.xferChannels.lowChunkCh:
+						chunkFunc(workerID)
+					default:
+						time.Sleep(100 * time.Millisecond) // Sleep before looping around
+						// TODO: Question: In order to safely support high goroutine counts,
+						// do we need to review sleep duration, or find an approach that does not require waking every x milliseconds
+						// For now, duration has been increased substantially from the previous 1 ms, to reduce cost of
+						// the wake-ups.
+					}
 				}
 			}
 		}
 	}
 }
 
+// processFairChunk is the round-robin counterpart of the channel reads in chunkProcessor, with the same priorities
+func (ja *jobsAdmin) processFairChunk(workerID int) {
+	if chunkFunc, ok := ja.highFairChunks.tryDequeue(); ok {
+		chunkFunc(workerID)
+	} else if chunkFunc, ok := ja.normalFairChunks.tryDequeue(); ok {
+		chunkFunc(workerID)
+	} else if chunkFunc, ok := ja.lowFairChunks.tryDequeue(); ok {
+		chunkFunc(workerID)
+	} else {
+		time.Sleep(100 * time.Millisecond) // Sleep before looping around, as chunkProcessor does
+	}
+}
+
 // separate from the chunkProcessor, this dedicated worker that reads in and executes transfer initiation jobs
 // (which in turn schedule chunks that get picked up by chunkProcessor)
 func (ja *jobsAdmin) transferProcessor(workerID int) {
@@ -457,6 +541,24 @@ func (ja *jobsAdmin) transferProcessor(workerID int) {
 			}
 			jptm.SetStatus(common.ETransferStatus.Cancelled())
 			jptm.ReportTransferDone()
+		} else if ja.retryBudget.exhausted() {
+			// failed, rather than cancelled, so that resuming the job starts it
+			jptm.LogError(jptm.Info().Source, "NOT STARTED ", errRetriesExhausted)
+			jptm.SetStatus(common.ETransferStatus.Failed())
+			jptm.ReportTransferDone()
+		} else if ja.transactionBudget.exhausted() {
+			// likewise failed, so that resuming the job starts it
+			jptm.LogError(jptm.Info().Source, "NOT STARTED ", errTransactionsExhausted)
+			jptm.SetStatus(common.ETransferStatus.Failed())
+			jptm.ReportTransferDone()
+		} else if jptm.PastJobDeadline() {
+			// likewise failed, so that resuming the job starts it
+			jptm.LogError(jptm.Info().Source, "NOT STARTED ", errDeadlineReached)
+			jptm.SetStatus(common.ETransferStatus.Failed())
+			jptm.ReportTransferDone()
+		} else if !jptm.TakePrefixSlot() {
+			// its destination prefix has as many transfers in flight as it may, so it waits, without holding this worker,
+			// and is rescheduled when one of them is done
 		} else {
 			// TODO fix preceding space
 			if jptm.ShouldLog(pipeline.LogInfo) {
@@ -469,14 +571,19 @@ func (ja *jobsAdmin) transferProcessor(workerID int) {
 	for {
 		// No scaleback check here, because this routine runs only in a small number of goroutines, so no need to kill them off
 		select {
-		case jptm := <-ja.xferChannels.normalTransferCh:
+		case jptm := <-ja.xferChannels.highTransferCh:
 			startTransfer(jptm)
 		default:
 			select {
-			case jptm := <-ja.xferChannels.lowTransferCh:
+			case jptm := <-ja.xferChannels.normalTransferCh:
 				startTransfer(jptm)
 			default:
-				time.Sleep(10 * time.Millisecond) // Sleep before looping around
+				select {
+				case jptm := <-ja.xferChannels.lowTransferCh:
+					startTransfer(jptm)
+				default:
+					time.Sleep(10 * time.Millisecond) // Sleep before looping around
+				}
 			}
 		}
 	}
@@ -502,6 +609,17 @@ type jobsAdmin struct {
 	poolSizingChannels          poolSizingChannels
 	appCtx                      context.Context
 	pacer                       pacerAdmin
+	diskReadPacer               pacerAdmin
+	readAhead                   *readAhead         // nil unless local files are read ahead of their chunks being scheduled
+	retryBudget                 *retryBudget       // nil unless retries are capped
+	transactionBudget           *transactionBudget // nil unless transactions are capped
+	requestPacer                pacer
+	checkpointInterval          time.Duration // how often the job plans are written to disk. Zero means they are left to the OS
+	chunkFairness               common.ChunkFairness
+	highFairChunks              *fairChunkQueue // with round-robin fairness, these take the place of the chunk channels
+	normalFairChunks            *fairChunkQueue
+	lowFairChunks               *fairChunkQueue
+	logRotation                 common.LogRotationPolicy // applies to the job logs and chunk logs
 	slicePool                   common.ByteSlicePooler
 	cacheLimiter                common.CacheLimiter
 	fileCountLimiter            common.CacheLimiter
@@ -510,6 +628,8 @@ type jobsAdmin struct {
 		pipeline.LogLevel
 	}
 	concurrencyTuner        ConcurrencyTuner
+	concurrencyHistory      []common.ConcurrencyChange
+	concurrencyHistoryLock  sync.Mutex
 	commandLineMbpsCap      float64
 	provideBenchmarkResults bool
 	cpuMonitor              common.CPUMonitor
@@ -517,14 +637,17 @@ type jobsAdmin struct {
 
 type CoordinatorChannels struct {
 	partsChannel     chan<- IJobPartMgr         // Write Only
+	highTransferCh   chan<- IJobPartTransferMgr // Write-only
 	normalTransferCh chan<- IJobPartTransferMgr // Write-only
 	lowTransferCh    chan<- IJobPartTransferMgr // Write-only
 }
 
 type XferChannels struct {
 	partsChannel     <-chan IJobPartMgr         // Read only
+	highTransferCh   <-chan IJobPartTransferMgr // Read-only
 	normalTransferCh <-chan IJobPartTransferMgr // Read-only
 	lowTransferCh    <-chan IJobPartTransferMgr // Read-only
+	highChunkCh      chan chunkFunc             // Read-write
 	normalChunckCh   chan chunkFunc             // Read-write
 	lowChunkCh       chan chunkFunc             // Read-write
 }
@@ -569,17 +692,19 @@ func (ja *jobsAdmin) AppPathFolder() string {
 // JobMgrEnsureExists returns the specified JobID's IJobMgr if it exists or creates it if it doesn't already exit
 // If it does exist, then the appCtx argument is ignored.
 func (ja *jobsAdmin) JobMgrEnsureExists(jobID common.JobID,
-	level common.LogLevel, commandString string) IJobMgr {
+	level common.LogLevel, format common.LogFormat, commandString string) IJobMgr {
 
 	return ja.jobIDToJobMgr.EnsureExists(jobID,
 		func() IJobMgr {
 			// Return existing or new IJobMgr to caller
-			return newJobMgr(ja.concurrency, ja.logger, jobID, ja.appCtx, ja.cpuMonitor, level, commandString, ja.logDir)
+			return newJobMgr(ja.concurrency, ja.logger, jobID, ja.appCtx, ja.cpuMonitor, level, format, commandString, ja.logDir)
 		})
 }
 
 func (ja *jobsAdmin) ScheduleTransfer(priority common.JobPriority, jptm IJobPartTransferMgr) {
 	switch priority { // priority determines which channel handles the job part's transfers
+	case common.EJobPriority.High():
+		ja.coordinatorChannels.highTransferCh <- jptm
 	case common.EJobPriority.Normal():
 		//jptm.SetChunkChannel(ja.xferChannels.normalChunckCh)
 		ja.coordinatorChannels.normalTransferCh <- jptm
@@ -591,8 +716,15 @@ func (ja *jobsAdmin) ScheduleTransfer(priority common.JobPriority, jptm IJobPart
 	}
 }
 
-func (ja *jobsAdmin) ScheduleChunk(priority common.JobPriority, chunkFunc chunkFunc) {
+func (ja *jobsAdmin) ScheduleChunk(priority common.JobPriority, jptm IJobPartTransferMgr, chunkFunc chunkFunc) {
+	if ja.chunkFairness == common.EChunkFairness.RoundRobin() {
+		ja.scheduleFairChunk(priority, jptm, chunkFunc)
+		return
+	}
+
 	switch priority { // priority determines which channel handles the job part's transfers
+	case common.EJobPriority.High():
+		ja.xferChannels.highChunkCh <- chunkFunc
 	case common.EJobPriority.Normal():
 		ja.xferChannels.normalChunckCh <- chunkFunc
 	case common.EJobPriority.Low():
@@ -602,10 +734,27 @@ func (ja *jobsAdmin) ScheduleChunk(priority common.JobPriority, chunkFunc chunkF
 	}
 }
 
+func (ja *jobsAdmin) scheduleFairChunk(priority common.JobPriority, jptm IJobPartTransferMgr, chunkFunc chunkFunc) {
+	switch priority {
+	case common.EJobPriority.High():
+		ja.highFairChunks.enqueue(jptm, chunkFunc)
+	case common.EJobPriority.Normal():
+		ja.normalFairChunks.enqueue(jptm, chunkFunc)
+	case common.EJobPriority.Low():
+		ja.lowFairChunks.enqueue(jptm, chunkFunc)
+	default:
+		ja.Panic(fmt.Errorf("invalid priority: %q", priority))
+	}
+}
+
 func (ja *jobsAdmin) BytesOverWire() int64 {
 	return ja.pacer.GetTotalTraffic()
 }
 
+func (ja *jobsAdmin) BytesReadFromDisk() int64 {
+	return ja.diskReadPacer.GetTotalTraffic()
+}
+
 func (ja *jobsAdmin) AddSuccessfulBytesInActiveFiles(n int64) {
 	atomic.AddInt64(&ja.atomicSuccessfulBytesInActiveFiles, n)
 }
@@ -645,7 +794,7 @@ func (ja *jobsAdmin) ResurrectJob(jobId common.JobID, sourceSAS string, destinat
 			continue
 		}
 		mmf := planFile.Map()
-		jm := ja.JobMgrEnsureExists(jobID, mmf.Plan().LogLevel, "")
+		jm := ja.JobMgrEnsureExists(jobID, mmf.Plan().LogLevel, mmf.Plan().LogFormat, "")
 		jm.AddJobPart(partNum, planFile, mmf, sourceSAS, destinationSAS, false)
 	}
 	return true
@@ -674,7 +823,7 @@ func (ja *jobsAdmin) ResurrectJobParts() {
 		}
 		mmf := planFile.Map()
 		//todo : call the compute transfer function here for each job.
-		jm := ja.JobMgrEnsureExists(jobID, mmf.Plan().LogLevel, "")
+		jm := ja.JobMgrEnsureExists(jobID, mmf.Plan().LogLevel, mmf.Plan().LogFormat, "")
 		jm.AddJobPart(partNum, planFile, mmf, EMPTY_SAS_STRING, EMPTY_SAS_STRING, false)
 	}
 }
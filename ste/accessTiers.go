@@ -0,0 +1,165 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// accessTierServiceVersion is the first service version that knows of the Cold tier, which came after those in the SDK.
+// Tiers that the SDK doesn't know of are set, and reported, with it
+const accessTierServiceVersion = "2021-12-02"
+
+// ServiceVersionForAccessTiers returns the service version to use when setting or reporting tiers that AzCopy may not know of.
+// It's the default service version, unless that's AzCopy's own, and too old to know of them. A version that the user chose
+// is kept, since the service they're using, such as Azure Stack, may not understand newer ones
+func ServiceVersionForAccessTiers() string {
+	if DefaultServiceApiVersion == common.EEnvironmentVariable.DefaultServiceApiVersion().DefaultValue &&
+		DefaultServiceApiVersion < accessTierServiceVersion {
+		return accessTierServiceVersion
+	}
+	return DefaultServiceApiVersion
+}
+
+// isAccessTierKnownToSDK says whether the tier is one that the SDK, and so AzCopy's default service version, knows of
+func isAccessTierKnownToSDK(tier azblob.AccessTierType) bool {
+	for _, known := range azblob.PossibleAccessTierTypeValues() {
+		if strings.EqualFold(string(tier), string(known)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContextForAccessTier returns the context to send a request that sets the tier with. If it's a tier that the default service version
+// doesn't know of, the request is sent with one that does, so that the service accepts it
+func ContextForAccessTier(ctx context.Context, tier azblob.AccessTierType) context.Context {
+	if tier == azblob.AccessTierNone || isAccessTierKnownToSDK(tier) {
+		return ctx
+	}
+	return context.WithValue(ctx, ServiceAPIVersionOverride, ServiceVersionForAccessTiers())
+}
+
+// AccessTimeTier is one of the rules of tier-by-access-time: a block blob whose source was last accessed at least MinAge ago is given Tier
+type AccessTimeTier struct {
+	MinAge time.Duration
+	Tier   azblob.AccessTierType
+}
+
+// AccessTimeTiers are the rules of tier-by-access-time, longest MinAge first, which is the order they're tried in
+type AccessTimeTiers []AccessTimeTier
+
+// ParseAccessTimeTiers parses rules such as 90d=Archive,30d=Cool, in which each age is a whole number of days.
+// Tiers that common.BlockBlobTier knows of are given their usual names, and others, such as Cold, are passed on for the service to validate
+func ParseAccessTimeTiers(s string) (AccessTimeTiers, error) {
+	var rules AccessTimeTiers
+	for _, rule := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(rule), "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rule %q: each rule is an age in days and a tier, e.g. 90d=Archive", rule)
+		}
+		days, err := strconv.Atoi(strings.TrimSuffix(parts[0], "d"))
+		if err != nil || days < 0 || !strings.HasSuffix(parts[0], "d") {
+			return nil, fmt.Errorf("invalid age %q: an age is a whole number of days, e.g. 90d", parts[0])
+		}
+		tier, err := parseAccessTimeTier(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		minAge := time.Duration(days) * 24 * time.Hour
+		for _, r := range rules {
+			if r.MinAge == minAge {
+				return nil, fmt.Errorf("the age %s is given more than one tier", parts[0])
+			}
+		}
+		rules = append(rules, AccessTimeTier{MinAge: minAge, Tier: tier})
+	}
+
+	// the rules are tried longest age first, whatever the order they're given in
+	sort.Slice(rules, func(i, j int) bool { return rules[i].MinAge > rules[j].MinAge })
+	return rules, nil
+}
+
+func parseAccessTimeTier(name string) (azblob.AccessTierType, error) {
+	var known common.BlockBlobTier
+	if err := known.Parse(name); err == nil && known != common.EBlockBlobTier.None() {
+		return known.ToAccessTierType(), nil
+	}
+	if name == "" || len(name) > BlobTierMaxBytes || strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) >= 0 {
+		return azblob.AccessTierNone, fmt.Errorf("invalid tier %q: a tier is a name of letters and digits, at most %d long", name, BlobTierMaxBytes)
+	}
+	return azblob.AccessTierType(name), nil
+}
+
+// String returns the rules in the form that ParseAccessTimeTiers parses, longest age first
+func (t AccessTimeTiers) String() string {
+	rules := make([]string, len(t))
+	for i, r := range t {
+		rules[i] = fmt.Sprintf("%dd=%s", int64(r.MinAge/(24*time.Hour)), r.Tier)
+	}
+	return strings.Join(rules, ",")
+}
+
+// TierFor returns the tier of the first rule, longest age first, that the time since lastAccessed meets, or AccessTierNone if none do
+func (t AccessTimeTiers) TierFor(lastAccessed time.Time, now time.Time) azblob.AccessTierType {
+	age := now.Sub(lastAccessed)
+	for _, r := range t {
+		if age >= r.MinAge {
+			return r.Tier
+		}
+	}
+	return azblob.AccessTierNone
+}
+
+// tierByAccessTime returns the tier that the rules give the block blob by when its source was last accessed,
+// or inferred, the tier it would otherwise be given, when no rule applies or the source isn't a blob
+func tierByAccessTime(jptm IJobPartTransferMgr, srcInfoProvider ISourceInfoProvider, rules AccessTimeTiers, inferred azblob.AccessTierType) (azblob.AccessTierType, error) {
+	blobSource, ok := srcInfoProvider.(IBlobSourceInfoProvider)
+	if !ok {
+		return inferred, nil
+	}
+	lastAccessed, err := blobSource.LastAccessTime()
+	if err != nil {
+		return inferred, errors.New("cannot find when the source was last accessed, to choose its tier: " + err.Error())
+	}
+
+	tier := rules.TierFor(lastAccessed, time.Now())
+	if tier == azblob.AccessTierNone {
+		return inferred, nil
+	}
+	if jptm.ShouldLog(pipeline.LogDebug) {
+		jptm.Log(pipeline.LogDebug, fmt.Sprintf("Setting the tier %s, since the source was last accessed at %s", tier, lastAccessed.Format(time.RFC3339)))
+	}
+	return tier, nil
+}
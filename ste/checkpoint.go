@@ -0,0 +1,111 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// transferCheckpoint tracks how much of the source of one transfer is known to have been sent.
+// Chunks complete in any order, so only the leading part of the source in which every chunk has completed is counted.
+// That length is stored straight into the (memory-mapped) plan, which costs no more than any other plan update.
+// Getting it onto disk is left to the checkpoint loop, so that the chunks never wait for the disk.
+type transferCheckpoint struct {
+	// the number of bytes that had been checkpointed when this transfer was scheduled, i.e. by a previous run of the job
+	resumeFrom int64
+
+	mu    sync.Mutex
+	sent  int64           // the length of the leading part of the source that has been sent by this run
+	ahead map[int64]int64 // offset -> length, of chunks that completed before some chunk in front of them
+}
+
+func newTransferCheckpoint(jppt *JobPartPlanTransfer) *transferCheckpoint {
+	return &transferCheckpoint{resumeFrom: jppt.CheckpointedBytes()}
+}
+
+// chunkSent records the completion of a chunk, and returns the new length of the leading part of the source that has been sent
+func (c *transferCheckpoint) chunkSent(offset int64, length int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if offset != c.sent {
+		if offset > c.sent {
+			if c.ahead == nil {
+				c.ahead = make(map[int64]int64)
+			}
+			c.ahead[offset] = length
+		}
+		return c.sent
+	}
+
+	c.sent += length
+	for {
+		next, ok := c.ahead[c.sent]
+		if !ok {
+			break
+		}
+		delete(c.ahead, c.sent)
+		c.sent += next
+	}
+	return c.sent
+}
+
+// checkpointBlockID returns the block ID to use for the given chunk when checkpointing.
+// Unlike the usual random IDs, it is the same every time the same chunk of the same source is sent,
+// so that a resumed upload can recognize the blocks that were staged before the job was interrupted.
+// It has the same length as the usual IDs, since the service requires all the block IDs of a blob to be the same length,
+// and it has the same block ID prefix, if any.
+func checkpointBlockID(info TransferInfo, lastModified time.Time, id common.ChunkID, prefix string, blockIndex int32) string {
+	h := md5.Sum([]byte(fmt.Sprintf("%s|%s|%d|%d|%d", info.Source, info.Destination, lastModified.UnixNano(), id.OffsetInFile(), id.Length())))
+	return encodeBlockID(prefix, blockIndex, hashedBlockIDPart(h))
+}
+
+// checkpointLoop periodically writes the plans of all jobs to disk, so that, if the process or its host crashes,
+// a resumed job loses no more than one interval of progress
+func (ja *jobsAdmin) checkpointLoop() {
+	ticker := time.NewTicker(ja.checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ja.flushPlans()
+		case <-ja.appCtx.Done():
+			return
+		}
+	}
+}
+
+func (ja *jobsAdmin) flushPlans() {
+	ja.jobIDToJobMgr.Iterate(false, func(jobID common.JobID, jm IJobMgr) {
+		jm.(*jobMgr).jobPartMgrs.Iterate(false, func(partNum common.PartNumber, jpm IJobPartMgr) {
+			if err := jpm.(*jobPartMgr).planMMF.Flush(); err != nil {
+				ja.LogToJobLog(fmt.Sprintf("Could not checkpoint part %d of job %s: %s", partNum, jobID, err.Error()), pipeline.LogWarning)
+			}
+		})
+	})
+}
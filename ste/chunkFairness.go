@@ -0,0 +1,90 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"container/list"
+	"sync"
+)
+
+// fairChunkQueue holds the scheduled chunks of each transfer in a queue of its own, and hands them out by rotating
+// among the transfers that have chunks waiting. So a transfer with thousands of chunks gets one turn in each rotation,
+// just like a small file with only one chunk, instead of running all its chunks before the small file gets a turn.
+type fairChunkQueue struct {
+	slots     chan struct{} // holds one entry per queued chunk, so that enqueue blocks when the queue is full, just as a channel send would
+	mu        sync.Mutex
+	rotation  *list.List // of *transferChunks, in the order in which they will be served
+	transfers map[interface{}]*list.Element
+}
+
+// transferChunks are the queued chunks of one transfer
+type transferChunks struct {
+	transfer interface{}
+	chunks   []chunkFunc
+}
+
+func newFairChunkQueue(capacity int) *fairChunkQueue {
+	return &fairChunkQueue{
+		slots:     make(chan struct{}, capacity),
+		rotation:  list.New(),
+		transfers: make(map[interface{}]*list.Element),
+	}
+}
+
+// enqueue adds a chunk of the given transfer. A transfer that had nothing queued joins the end of the rotation.
+func (q *fairChunkQueue) enqueue(transfer interface{}, cf chunkFunc) {
+	q.slots <- struct{}{}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if e, ok := q.transfers[transfer]; ok {
+		tc := e.Value.(*transferChunks)
+		tc.chunks = append(tc.chunks, cf)
+		return
+	}
+	q.transfers[transfer] = q.rotation.PushBack(&transferChunks{transfer: transfer, chunks: []chunkFunc{cf}})
+}
+
+// tryDequeue returns the next chunk of the transfer whose turn it is, or false if nothing is queued.
+// The transfer then goes to the end of the rotation, or leaves it if that was its last queued chunk.
+func (q *fairChunkQueue) tryDequeue() (chunkFunc, bool) {
+	q.mu.Lock()
+	e := q.rotation.Front()
+	if e == nil {
+		q.mu.Unlock()
+		return nil, false
+	}
+
+	tc := e.Value.(*transferChunks)
+	next := tc.chunks[0]
+	tc.chunks[0] = nil // don't keep the chunk alive once it has been run
+	tc.chunks = tc.chunks[1:]
+	if len(tc.chunks) == 0 {
+		q.rotation.Remove(e)
+		delete(q.transfers, tc.transfer)
+	} else {
+		q.rotation.MoveToBack(e)
+	}
+	q.mu.Unlock()
+
+	<-q.slots
+	return next, true
+}
@@ -0,0 +1,172 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// chunkTimelineSample is the state of the chunks at one point in the job, as reported by the chunk status logger
+type chunkTimelineSample func() (direction common.TransferDirection, reasons []string, counts []int64)
+
+// chunkTimeline records, once per second, how many chunks are in each wait state, and writes the resulting
+// time series to a file so that it can be charted after the job.
+// Files ending in .json get a single JSON object, written when the job finishes, with one array per wait reason.
+// Any other file gets CSV, one row per second, written as the job runs.
+// In both formats only the wait reasons that apply to the job's direction are included, and the direction is named.
+type chunkTimeline struct {
+	file   *os.File
+	writer *bufio.Writer
+	asJSON bool
+	sample chunkTimelineSample
+
+	// the direction and reasons are fixed by the first sample taken once the direction is known
+	direction common.TransferDirection
+	reasons   []string
+	seconds   []int64
+	counts    [][]int64 // counts[i] is the series for reasons[i]. Only kept for JSON, since CSV is written as we go
+
+	started  bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type chunkTimelineJSON struct {
+	Direction      string
+	WaitReasons    []string
+	ElapsedSeconds []int64
+	Counts         [][]int64 // Counts[i] is the series for WaitReasons[i]
+}
+
+func newChunkTimeline(path string, sample chunkTimelineSample) (*chunkTimeline, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create chunk timeline file: %w", err)
+	}
+	return &chunkTimeline{
+		file:   f,
+		writer: bufio.NewWriter(f),
+		asJSON: strings.EqualFold(filepath.Ext(path), ".json"),
+		sample: sample,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// start samples once per second until close is called
+func (t *chunkTimeline) start() {
+	t.started = true
+	go func() {
+		defer close(t.done)
+		startTime := time.Now()
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				// take a final sample, so that the series always covers the end of the job
+				t.record(int64(time.Since(startTime).Round(time.Second) / time.Second))
+				return
+			case now := <-ticker.C:
+				t.record(int64(now.Sub(startTime).Round(time.Second) / time.Second))
+			}
+		}
+	}()
+}
+
+// record takes one sample and adds it to the series
+func (t *chunkTimeline) record(elapsedSeconds int64) {
+	direction, reasons, counts := t.sample()
+	if t.reasons == nil {
+		if direction == common.ETransferDirection.UnKnown() || len(reasons) == 0 {
+			return // nothing has been scheduled yet, so we don't know which reasons apply
+		}
+		t.direction = direction
+		t.reasons = reasons
+		t.counts = make([][]int64, len(reasons))
+		if !t.asJSON {
+			t.writeCSVRow("ElapsedSeconds", "Direction", reasons)
+		}
+	}
+	if direction != t.direction || len(counts) != len(t.reasons) {
+		return // don't mix reasons from different directions in the same series
+	}
+
+	if t.asJSON {
+		t.seconds = append(t.seconds, elapsedSeconds)
+		for i, c := range counts {
+			t.counts[i] = append(t.counts[i], c)
+		}
+		return
+	}
+
+	values := make([]string, len(counts))
+	for i, c := range counts {
+		values[i] = fmt.Sprint(c)
+	}
+	t.writeCSVRow(fmt.Sprint(elapsedSeconds), direction.String(), values)
+	_ = t.writer.Flush() // so the file is useful even if the process does not exit cleanly
+}
+
+func (t *chunkTimeline) writeCSVRow(first, second string, rest []string) {
+	_, _ = t.writer.WriteString(strings.Join(append([]string{first, second}, rest...), ",") + "\n")
+}
+
+// close stops sampling and finishes the file. It is safe to call more than once.
+func (t *chunkTimeline) close() error {
+	var err error
+	t.stopOnce.Do(func() {
+		if t.started {
+			close(t.stop)
+			<-t.done
+		}
+
+		if t.asJSON {
+			out := chunkTimelineJSON{
+				Direction:      t.direction.String(),
+				WaitReasons:    t.reasons,
+				ElapsedSeconds: t.seconds,
+				Counts:         t.counts,
+			}
+			if out.WaitReasons == nil {
+				out.WaitReasons, out.ElapsedSeconds, out.Counts = []string{}, []int64{}, [][]int64{}
+			}
+			err = json.NewEncoder(t.writer).Encode(out)
+		}
+		if flushErr := t.writer.Flush(); err == nil {
+			err = flushErr
+		}
+		if closeErr := t.file.Close(); err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
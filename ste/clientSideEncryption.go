@@ -0,0 +1,118 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+var errClientSideEncryptionKeyMissing = errors.New("the job encrypts client-side, and its key isn't kept in its plan. Resume it with the client-side-encryption-key flag")
+
+// ClientSideEncryptionKey returns the key that the job encrypts uploads, and decrypts downloads, with, if it encrypts client-side.
+// The key isn't kept in the plan, so a resumed job has it only if it was given again
+func (jptm *jobPartTransferMgr) ClientSideEncryptionKey() (key common.ClientSideEncryptionKey, enabled bool, err error) {
+	if !jptm.jobPartMgr.Plan().ClientSideEncryption {
+		return key, false, nil
+	}
+	key = jptm.jobPartMgr.(*jobPartMgr).jobMgr.getInMemoryTransitJobState().clientSideEncryptionKey
+	if !key.IsSet() {
+		return key, true, errClientSideEncryptionKeyMissing
+	}
+	return key, true, nil
+}
+
+// prepareClientSideEncryption makes the content key of the blob, and keeps it, wrapped, in the blob's metadata.
+// Each chunk is encrypted on its own, so the chunks must start at region boundaries, and the chunk size is rounded up to suit
+func (u *blockBlobUploader) prepareClientSideEncryption(key common.ClientSideEncryptionKey) error {
+	encryptor, err := common.NewClientSideEncryptor(key)
+	if err != nil {
+		return fmt.Errorf("cannot make the content key for client-side encryption: %w", err)
+	}
+	if u.metadataToApply == nil {
+		u.metadataToApply = azblob.Metadata{}
+	}
+	u.metadataToApply[common.ClientSideEncryptionMetadataKey] = encryptor.Envelope()
+
+	chunkSize := u.chunkSize
+	if remainder := chunkSize % common.ClientSideEncryptionRegionSize; remainder != 0 {
+		chunkSize += common.ClientSideEncryptionRegionSize - remainder
+	}
+	if common.ClientSideEncryptedLength(chunkSize) > common.MaxBlockBlobBlockSize {
+		chunkSize -= common.ClientSideEncryptionRegionSize // so that each encrypted block is still small enough
+	}
+	numChunks := getNumChunks(u.jptm.Info().SourceSize, chunkSize)
+	if numChunks > common.MaxNumberOfBlocksPerBlob {
+		return fmt.Errorf("block size %d, rounded to suit client-side encryption, would need more than %d blocks", chunkSize, common.MaxNumberOfBlocksPerBlob)
+	}
+
+	u.encryptor = encryptor
+	u.chunkSize = chunkSize
+	u.numChunks = numChunks
+	u.blockIDs = make([]string, numChunks)
+	return nil
+}
+
+// chunkBody is what's sent for the chunk: the chunk itself, or, if the blob is encrypted client-side, its encrypted form
+func (u *blockBlobUploader) chunkBody(reader common.SingleChunkReader) (io.ReadSeeker, error) {
+	if u.encryptor == nil {
+		return reader, nil
+	}
+	plaintext, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := u.encryptor.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(ciphertext), nil
+}
+
+// expectedDestinationLength is the length that the destination should have once the source has been uploaded to it
+func expectedDestinationLength(jptm IJobPartTransferMgr, s sender) int64 {
+	if u, ok := s.(*blockBlobUploader); ok && u.encryptor != nil {
+		return common.ClientSideEncryptedLength(jptm.Info().SourceSize)
+	}
+	return jptm.Info().SourceSize
+}
+
+// shouldDecryptClientSide returns the envelope of the blob being downloaded, if it was encrypted client-side and the job decrypts
+func shouldDecryptClientSide(jptm IJobPartTransferMgr) (envelope string, key common.ClientSideEncryptionKey, decrypt bool, err error) {
+	key, enabled, err := jptm.ClientSideEncryptionKey()
+	if !enabled || err != nil {
+		return "", key, false, err
+	}
+	for k, v := range jptm.Info().SrcMetadata {
+		if strings.EqualFold(k, common.ClientSideEncryptionMetadataKey) {
+			return v, key, true, nil
+		}
+	}
+	return "", key, false, nil
+}
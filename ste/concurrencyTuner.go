@@ -132,6 +132,7 @@ const (
 	concurrencyReasonInitial       = "initial starting point"
 	concurrencyReasonSeeking       = "seeking optimum"
 	concurrencyReasonBackoff       = "backing off"
+	concurrencyReasonThrottled     = "backing off, because the service is throttling"
 	concurrencyReasonHitMax        = "hit max concurrency limit"
 	concurrencyReasonHighCpu       = "at optimum, but may be limited by CPU"
 	concurrencyReasonAtOptimum     = "at optimum"
@@ -189,21 +190,25 @@ func (t *autoConcurrencyTuner) worker() {
 			everSawHighCpu = true // this doesn't stop us probing higher concurrency, since sometimes that works even when CPU looks high, but it does change the way we report the result
 		}
 
+		sawRetry := atomic.SwapInt64(&t.atomicRetryCount, 0) > 0
+		throttled := false
 		if t.isBenchmarking {
 			// Be a little more aggressive if we are tuning for benchmarking purposes (as opposed to day to day use)
 
 			// If we are seeing retries (within "normal" concurrency range) then for benchmarking purposes we don't want to back off.
 			// (Since if we back off the retries might stop and then they won't be reported on as a limiting factor.)
-			sawRetry := atomic.SwapInt64(&t.atomicRetryCount, 0) > 0
 			dontBackoffRegardless = sawRetry && concurrency <= 256
 
 			// Workaround for variable throughput when targeting 20 Gbps account limit (concurrency around 64 didn't seem to give stable throughput in some tests)
 			// TODO: review this, and look for root cause/better solution
 			probeHigherRegardless = sawHighMultiGbps && concurrency >= 32 && concurrency < 128 && multiplier >= standardMultiplier
+		} else {
+			// In day to day use, the service telling us it's busy means we've gone too far, even if throughput went up
+			throttled = sawRetry
 		}
 
 		// decide what to do based on the measurement
-		if lastSpeed > desiredNewSpeed || probeHigherRegardless {
+		if (lastSpeed > desiredNewSpeed || probeHigherRegardless) && !throttled {
 			// Our concurrency change gave the hoped-for speed increase, so loop around and see if another increase will also work,
 			// unless already at max
 			if atMax {
@@ -235,7 +240,11 @@ func (t *autoConcurrencyTuner) worker() {
 			if multiplier < minMulitplier {
 				break // no point in tuning any more
 			} else {
-				lastReason = t.setConcurrency(concurrency, concurrencyReasonBackoff)
+				backoffReason := concurrencyReasonBackoff
+				if throttled {
+					backoffReason = concurrencyReasonThrottled
+				}
+				lastReason = t.setConcurrency(concurrency, backoffReason)
 				lastSpeed, _ = t.getCurrentSpeed() // must re-measure immediately after backing off
 			}
 		}
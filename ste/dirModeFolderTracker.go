@@ -0,0 +1,50 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// dirModeFolderTracker sets the given mode on each local directory as its creation is recorded, so that every directory
+// created by a download gets it, whether it was created for a folder transfer or as the parent of a file
+type dirModeFolderTracker struct {
+	common.FolderCreationTracker
+	mode   os.FileMode
+	logger common.ILogger
+}
+
+func newDirModeFolderTracker(inner common.FolderCreationTracker, mode os.FileMode, logger common.ILogger) common.FolderCreationTracker {
+	return &dirModeFolderTracker{FolderCreationTracker: inner, mode: mode, logger: logger}
+}
+
+func (t *dirModeFolderTracker) RecordCreation(folder string) {
+	// A failure is only logged, since the directory may hold the files of many transfers, none of which it belongs to
+	if err := os.Chmod(folder, t.mode); err != nil {
+		t.logger.Log(pipeline.LogError, fmt.Sprintf("Could not set the mode of directory %s to %04o: %s", folder, t.mode, err))
+	}
+	t.FolderCreationTracker.RecordCreation(folder)
+}
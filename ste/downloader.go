@@ -54,6 +54,13 @@ type smbPropertyAwareDownloader interface {
 	PutSMBProperties(sip ISMBPropertyBearingSourceInfoProvider, txInfo TransferInfo) error
 }
 
+// fileAttributeAwareDownloader is a windows-triggered interface, for downloaders that restore the Windows file attributes
+// that were kept in the metadata of the source (see --preserve-file-attributes).
+// Code outside of windows-specific files shouldn't implement this ever.
+type fileAttributeAwareDownloader interface {
+	PutFileAttributes(attribs uint32, txInfo TransferInfo) error
+}
+
 type downloaderFactory func() downloader
 
 func createDownloadChunkFunc(jptm IJobPartTransferMgr, id common.ChunkID, body func()) chunkFunc {
@@ -50,9 +50,12 @@ func ToFixed(num float64, precision int) float64 {
 }
 
 // MainSTE initializes the Storage Transfer Engine
-func MainSTE(concurrency ConcurrencySettings, targetRateInMegaBitsPerSec float64, azcopyJobPlanFolder, azcopyLogPathFolder string, providePerfAdvice bool) error {
+func MainSTE(concurrency ConcurrencySettings, targetRateInMegaBitsPerSec float64, diskReadRateInMegaBitsPerSec float64, readAheadBytes int64, maxRetriesPerFile int32, maxTotalRetries int64, maxTransactions int64, requestsPerSecond int64, rampUp time.Duration, checkpointInterval time.Duration, chunkFairness common.ChunkFairness, logRotation common.LogRotationPolicy, azcopyJobPlanFolder, azcopyLogPathFolder string, providePerfAdvice bool) error {
+	if err := validateReadAhead(readAheadBytes, getMaxRamForChunks()); err != nil {
+		return err
+	}
 	// Initialize the JobsAdmin, resurrect Job plan files
-	initJobsAdmin(steCtx, concurrency, targetRateInMegaBitsPerSec, azcopyJobPlanFolder, azcopyLogPathFolder, providePerfAdvice)
+	initJobsAdmin(steCtx, concurrency, targetRateInMegaBitsPerSec, diskReadRateInMegaBitsPerSec, readAheadBytes, maxRetriesPerFile, maxTotalRetries, maxTransactions, requestsPerSecond, rampUp, checkpointInterval, chunkFairness, logRotation, azcopyJobPlanFolder, azcopyLogPathFolder, providePerfAdvice)
 	// No need to read the existing JobPartPlan files since Azcopy is running in process
 	//JobsAdmin.ResurrectJobParts()
 	// TODO: We may want to list listen first and terminate if there is already an instance listening
@@ -126,6 +129,13 @@ func MainSTE(concurrency ConcurrencySettings, targetRateInMegaBitsPerSec float64
 			serialize(GetJobFromTo(payload), writer)
 		})
 
+	http.HandleFunc(common.ERpcCmd.GetJobDetails().Pattern(),
+		func(writer http.ResponseWriter, request *http.Request) {
+			var payload common.GetJobDetailsRequest
+			deserialize(request, &payload)
+			serialize(GetJobDetails(payload), writer)
+		})
+
 	// Listen for front-end requests
 	//if err := http.ListenAndServe("localhost:1337", nil); err != nil {
 	//	fmt.Print("Server already initialized")
@@ -138,10 +148,13 @@ func MainSTE(concurrency ConcurrencySettings, targetRateInMegaBitsPerSec float64
 
 // ExecuteNewCopyJobPartOrder api executes a new job part order
 func ExecuteNewCopyJobPartOrder(order common.CopyJobPartOrderRequest) common.CopyJobPartOrderResponse {
+	jpm := JobsAdmin.JobMgrEnsureExists(order.JobID, order.LogLevel, order.LogFormat, order.CommandString) // Get a this job part's job manager (create it if it doesn't exist)
+	// pause the enumerator, if it has got too far ahead of the transfers, before the part takes up any resources
+	jpm.waitForEnumerationLookahead(len(order.Transfers), order.EnumerationLookahead)
+
 	// Get the file name for this Job Part's Plan
 	jppfn := JobsAdmin.NewJobPartPlanFileName(order.JobID, order.PartNum)
-	jppfn.Create(order)                                                                   // Convert the order to a plan file
-	jpm := JobsAdmin.JobMgrEnsureExists(order.JobID, order.LogLevel, order.CommandString) // Get a this job part's job manager (create it if it doesn't exist)
+	jppfn.Create(order) // Convert the order to a plan file
 
 	if len(order.Transfers) == 0 && order.IsFinalPart {
 		/*
@@ -154,8 +167,18 @@ func ExecuteNewCopyJobPartOrder(order common.CopyJobPartOrderRequest) common.Cop
 	// Get credential info from RPC request order, and set in InMemoryTransitJobState.
 	jpm.setInMemoryTransitJobState(
 		InMemoryTransitJobState{
-			credentialInfo: order.CredentialInfo,
+			credentialInfo:          order.CredentialInfo,
+			clientSideEncryptionKey: order.ClientSideEncryptionKey,
 		})
+	if order.PartNum == 0 && order.ChunkTimelinePath != "" {
+		jpm.startChunkTimeline(order.ChunkTimelinePath)
+	}
+	if order.PartNum == 0 && !order.Deadline.IsZero() {
+		jpm.setDeadline(order.Deadline)
+	}
+	if order.PartNum == 0 && len(order.PrefixConcurrency) > 0 {
+		jpm.setPrefixConcurrency(order.PrefixConcurrency)
+	}
 	// Supply no plan MMF because we don't have one, and AddJobPart will create one on its own.
 	jpm.AddJobPart(order.PartNum, jppfn, nil, order.SourceRoot.SAS, order.DestinationRoot.SAS, true) // Add this part to the Job and schedule its transfers
 	return common.CopyJobPartOrderResponse{JobStarted: true}
@@ -293,7 +316,8 @@ func ResumeJobOrder(req common.ResumeJobRequest) common.CancelPauseResumeRespons
 		switch jpm.Plan().FromTo {
 		case common.EFromTo.LocalBlob(),
 			common.EFromTo.LocalFile(),
-			common.EFromTo.S3Blob():
+			common.EFromTo.S3Blob(),
+			common.EFromTo.HttpBlob():
 			if len(req.DestinationSAS) == 0 {
 				errorMsg = "The destination-sas switch must be provided to resume the job"
 			}
@@ -321,7 +345,9 @@ func ResumeJobOrder(req common.ResumeJobRequest) common.CancelPauseResumeRespons
 
 	// After creating the Job mgr, set the include / exclude list of transfer.
 	jm.SetIncludeExclude(req.IncludeTransfer, req.ExcludeTransfer)
+	jm.SetFailedOnly(req.FailedOnly)
 	jpp0 := jpm.Plan()
+	jm.restorePrefixConcurrency(jpp0)
 	switch jpp0.JobStatus() {
 	// Cannot resume a Job which is in Cancelling state
 	// Cancelling is an intermediary state. The reason we accept and process it here, rather than returning an error,
@@ -344,7 +370,8 @@ func ResumeJobOrder(req common.ResumeJobRequest) common.CancelPauseResumeRespons
 		// Get credential info from RPC request, and set in InMemoryTransitJobState.
 		jm.setInMemoryTransitJobState(
 			InMemoryTransitJobState{
-				credentialInfo: req.CredentialInfo,
+				credentialInfo:          req.CredentialInfo,
+				clientSideEncryptionKey: req.ClientSideEncryptionKey,
 			})
 
 		jpp0.SetJobStatus(common.EJobStatus.InProgress())
@@ -362,7 +389,9 @@ func ResumeJobOrder(req common.ResumeJobRequest) common.CancelPauseResumeRespons
 				jppt := jpp.Transfer(t)
 				// If the transfer status is less than -1, it means the transfer failed because of some reason.
 				// Transfer Status needs to reset.
-				if jppt.TransferStatus() <= common.ETransferStatus.Failed() {
+				// When only the failed transfers are resumed, the statuses are kept, so that ScheduleTransfers can tell the failed transfers apart
+				// (it resets those itself).
+				if !req.FailedOnly && jppt.TransferStatus() <= common.ETransferStatus.Failed() {
 					jppt.SetTransferStatus(common.ETransferStatus.Started(), true)
 					jppt.SetErrorCode(0, true)
 				}
@@ -414,6 +443,12 @@ func GetJobSummary(jobID common.JobID) common.ListJobSummaryResponse {
 		JobStatus:          common.EJobStatus.InProgress(), // Default
 		CompleteJobOrdered: false,                          // default to false; returns true if ALL job parts have been ordered
 		FailedTransfers:    []common.TransferDetail{},
+
+		TransfersNotStartedByDeadline: jm.TransfersNotStartedByDeadline(),
+		TransfersRehydrating:          jm.TransfersRehydrating(),
+		TransfersInFlightByPrefix:     jm.TransfersInFlightByPrefix(),
+		TransactionsIssued:            JobsAdmin.(*jobsAdmin).transactionBudget.issued(),
+		MaxTransactions:               JobsAdmin.(*jobsAdmin).transactionBudget.limit(),
 	}
 
 	// To avoid race condition: get overall status BEFORE we get counts of completed files)
@@ -426,6 +461,10 @@ func GetJobSummary(jobID common.JobID) common.ListJobSummaryResponse {
 	}
 	part0PlanStatus := part0.Plan().JobStatus()
 
+	// the number of transfers that are expected to be successful, which is what PercentComplete is measured against when counting files
+	transfersExpected := uint32(0)
+	js.ProgressBasis = part0.Plan().ProgressBasis
+
 	// Now iterate and count things up
 	jm.(*jobMgr).jobPartMgrs.Iterate(true, func(partNum common.PartNumber, jpm IJobPartMgr) {
 		jpp := jpm.Plan()
@@ -449,10 +488,12 @@ func GetJobSummary(jobID common.JobID) common.ListJobSummaryResponse {
 			case common.ETransferStatus.NotStarted(),
 				common.ETransferStatus.Started():
 				js.TotalBytesExpected += uint64(jppt.SourceSize)
+				transfersExpected++
 			case common.ETransferStatus.Success():
 				js.TransfersCompleted++
 				js.TotalBytesTransferred += uint64(jppt.SourceSize)
 				js.TotalBytesExpected += uint64(jppt.SourceSize)
+				transfersExpected++
 			case common.ETransferStatus.Failed(),
 				common.ETransferStatus.TierAvailabilityCheckFailure(),
 				common.ETransferStatus.BlobTierFailure():
@@ -468,8 +509,12 @@ func GetJobSummary(jobID common.JobID) common.ListJobSummaryResponse {
 						TransferStatus:     common.ETransferStatus.Failed(),
 						ErrorCode:          jppt.ErrorCode()}) // TODO: Optimize
 			case common.ETransferStatus.SkippedEntityAlreadyExists(),
-				common.ETransferStatus.SkippedBlobHasSnapshots():
+				common.ETransferStatus.SkippedBlobHasSnapshots(),
+				common.ETransferStatus.SkippedFileLocked():
 				js.TransfersSkipped++
+				if jppt.TransferStatus() == common.ETransferStatus.SkippedFileLocked() {
+					js.TransfersSkippedLocked++
+				}
 				// getting the source and destination for skipped transfer at position - index
 				src, dst, isFolder := jpp.TransferSrcDstStrings(t)
 				js.SkippedTransfers = append(js.SkippedTransfers,
@@ -485,12 +530,7 @@ func GetJobSummary(jobID common.JobID) common.ListJobSummaryResponse {
 
 	// Add on byte count from files in flight, to get a more accurate running total
 	js.TotalBytesTransferred += JobsAdmin.SuccessfulBytesInActiveFiles()
-	if js.TotalBytesExpected == 0 {
-		// if no bytes expected, and we should avoid dividing by 0 (which results in NaN)
-		js.PercentComplete = 100
-	} else {
-		js.PercentComplete = 100 * float32(js.TotalBytesTransferred) / float32(js.TotalBytesExpected)
-	}
+	js.PercentComplete = js.ProgressBasis.PercentComplete(js.TotalBytesTransferred, js.TotalBytesExpected, js.TransfersCompleted, transfersExpected)
 
 	// This is added to let FE to continue fetching the Job Progress Summary
 	// in case of resume. In case of resume, the Job is already completely
@@ -505,6 +545,9 @@ func GetJobSummary(jobID common.JobID) common.ListJobSummaryResponse {
 	js.ActiveConnections = jm.ActiveConnections()
 
 	js.PerfStrings, js.PerfConstraint = jm.GetPerfInfo()
+	js.ChunkTimeBreakdown = jm.GetChunkTimeBreakdown()
+	js.ConcurrencyHistory = JobsAdmin.ConcurrencyHistory()
+	js.BytesByInterface = NetworkInterfaceBytes()
 
 	pipeStats := jm.PipelineNetworkStats()
 	if pipeStats != nil {
@@ -639,6 +682,37 @@ func ListJobs(givenStatus common.JobStatus) common.ListJobsResponse {
 	return listJobResponse
 }
 
+// GetJobDetails api returns the status of a job, and when it started
+func GetJobDetails(r common.GetJobDetailsRequest) common.GetJobDetailsResponse {
+	jm, found := JobsAdmin.JobMgr(r.JobID)
+	if !found {
+		// Search the plan files in Azcopy folder and resurrect the Job.
+		if !JobsAdmin.ResurrectJob(r.JobID, EMPTY_SAS_STRING, EMPTY_SAS_STRING) {
+			return common.GetJobDetailsResponse{
+				ErrorMsg: fmt.Sprintf("no job with JobID %v exists", r.JobID),
+			}
+		}
+		jm, _ = JobsAdmin.JobMgr(r.JobID)
+	}
+
+	jp0, ok := jm.JobPartMgr(0)
+	if !ok {
+		return common.GetJobDetailsResponse{
+			ErrorMsg: fmt.Sprintf("error getting the details of the job with JobID %v", r.JobID),
+		}
+	}
+
+	plan := jp0.Plan()
+	resp := common.GetJobDetailsResponse{
+		JobStatus: plan.JobStatus(),
+		StartTime: time.Unix(0, plan.StartTime),
+	}
+	if plan.CommandStartTime != 0 {
+		resp.CommandStartTime = time.Unix(0, plan.CommandStartTime)
+	}
+	return resp
+}
+
 // GetJobFromTo api returns the job FromTo info.
 func GetJobFromTo(r common.GetJobFromToRequest) common.GetJobFromToResponse {
 	jm, found := JobsAdmin.JobMgr(r.JobID)
@@ -0,0 +1,57 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+)
+
+// errDeadlineReached is the reason given for the transfers that aren't started once the job's deadline has passed
+var errDeadlineReached = errors.New("the job's deadline has passed, so no more transfers are started. Resume the job to transfer the rest")
+
+// setDeadline sets when the job stops starting transfers. The transfers in progress at the time carry on, and the rest
+// are failed, rather than cancelled, so that resuming the job starts them
+func (jm *jobMgr) setDeadline(deadline time.Time) {
+	atomic.StoreInt64(&jm.atomicDeadlineNanos, deadline.UnixNano())
+}
+
+// pastDeadline says whether the job has a deadline that has passed, and if so, counts a transfer as not started because of it
+func (jm *jobMgr) pastDeadline() bool {
+	deadline := atomic.LoadInt64(&jm.atomicDeadlineNanos)
+	if deadline == 0 || time.Now().UnixNano() < deadline {
+		return false
+	}
+	if atomic.AddUint32(&jm.atomicTransfersNotStartedByDeadline, 1) == 1 {
+		jm.Log(pipeline.LogWarning, fmt.Sprintf("The deadline of %s has passed, so no more transfers will be started. Those in progress will finish",
+			time.Unix(0, deadline).UTC().Format(time.RFC3339)))
+	}
+	return true
+}
+
+// TransfersNotStartedByDeadline is how many transfers weren't started because the job's deadline had passed
+func (jm *jobMgr) TransfersNotStartedByDeadline() uint32 {
+	return atomic.LoadUint32(&jm.atomicTransfersNotStartedByDeadline)
+}
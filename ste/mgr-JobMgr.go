@@ -24,6 +24,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
 	"runtime"
 	"strings"
 	"sync"
@@ -45,7 +47,8 @@ type PartNumber = common.PartNumber
 // i.e. different jobs could have different OAuth tokens requested from FE, and these jobs can run at same time in STE.
 // This can be optimized if FE would no more be another module vs STE module.
 type InMemoryTransitJobState struct {
-	credentialInfo common.CredentialInfo
+	credentialInfo          common.CredentialInfo
+	clientSideEncryptionKey common.ClientSideEncryptionKey
 }
 
 type IJobMgr interface {
@@ -57,6 +60,8 @@ type IJobMgr interface {
 		destinationSAS string, scheduleTransfers bool) IJobPartMgr
 	SetIncludeExclude(map[string]int, map[string]int)
 	IncludeExclude() (map[string]int, map[string]int)
+	SetFailedOnly(bool)
+	FailedOnly() bool
 	ResumeTransfers(appCtx context.Context)
 	AllTransfersScheduled() bool
 	ConfirmAllTransfersScheduled()
@@ -72,27 +77,49 @@ type IJobMgr interface {
 	// TODO: added for debugging purpose. remove later
 	ActiveConnections() int64
 	GetPerfInfo() (displayStrings []string, constraint common.PerfConstraint)
+	GetChunkTimeBreakdown() common.ChunkTimeBreakdown
 	TryGetPerformanceAdvice(bytesInJob uint64, filesInJob uint32, fromTo common.FromTo) []common.PerformanceAdvice
 	//Close()
 	getInMemoryTransitJobState() InMemoryTransitJobState      // get in memory transit job state saved in this job.
 	setInMemoryTransitJobState(state InMemoryTransitJobState) // set in memory transit job state saved in this job.
+	startChunkTimeline(path string)
+	waitForEnumerationLookahead(transfers int, lookahead int)
+	setDeadline(deadline time.Time)
+	pastDeadline() bool
+	TransfersNotStartedByDeadline() uint32
+	setPrefixConcurrency(limits map[string]int)
+	prefixConcurrency() *prefixLimiter
+	restorePrefixConcurrency(plan *JobPartPlanHeader)
+	TransfersInFlightByPrefix() []common.PrefixTransfersInFlight
+	addTransfersRehydrating(delta int32)
+	TransfersRehydrating() uint32
+	reportTransferDone()
 	ChunkStatusLogger() common.ChunkStatusLogger
 	HttpClient() *http.Client
 	PipelineNetworkStats() *pipelineNetworkStats
 	getOverwritePrompter() *overwritePrompter
+	LogForTransfer(level pipeline.LogLevel, transferPath string, msg string)
 	common.ILoggerCloser
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func newJobMgr(concurrency ConcurrencySettings, appLogger common.ILogger, jobID common.JobID, appCtx context.Context, cpuMon common.CPUMonitor, level common.LogLevel, commandString string, logFileFolder string) IJobMgr {
+// remoteLogTarget is where job log entries are also sent, if the user asked for that
+var remoteLogTarget *url.URL
+
+// SetRemoteLogTarget sets where the logs of jobs started after this call also send their entries. Nil means nowhere.
+func SetRemoteLogTarget(target *url.URL) {
+	remoteLogTarget = target
+}
+
+func newJobMgr(concurrency ConcurrencySettings, appLogger common.ILogger, jobID common.JobID, appCtx context.Context, cpuMon common.CPUMonitor, level common.LogLevel, format common.LogFormat, commandString string, logFileFolder string) IJobMgr {
 	// atomicAllTransfersScheduled is set to 1 since this api is also called when new job part is ordered.
 	enableChunkLogOutput := level.ToPipelineLogLevel() == pipeline.LogDebug
 	jobPartProgressCh := make(chan jobPartProgressInfo)
 	jm := jobMgr{jobID: jobID, jobPartMgrs: newJobPartToJobPartMgr(), include: map[string]int{}, exclude: map[string]int{},
 		httpClient:                    NewAzcopyHTTPClient(concurrency.MaxIdleConnections),
-		logger:                        common.NewJobLogger(jobID, level, appLogger, logFileFolder),
-		chunkStatusLogger:             common.NewChunkStatusLogger(jobID, cpuMon, logFileFolder, enableChunkLogOutput),
+		logger:                        common.NewJobLogger(jobID, level, format, appLogger, logFileFolder, JobsAdmin.(*jobsAdmin).logRotation, remoteLogTarget),
+		chunkStatusLogger:             common.NewChunkStatusLogger(jobID, cpuMon, logFileFolder, enableChunkLogOutput, JobsAdmin.(*jobsAdmin).logRotation),
 		concurrency:                   concurrency,
 		overwritePrompter:             newOverwritePrompter(),
 		pipelineNetworkStats:          newPipelineNetworkStats(JobsAdmin.(*jobsAdmin).concurrencyTuner), // let the stats coordinate with the concurrency tuner
@@ -177,9 +204,17 @@ type jobMgr struct {
 	// refer to: https://golang.org/pkg/sync/atomic/#pkg-note-BUG
 	atomicNumberOfBytesCovered uint64
 	atomicTotalBytesToXfer     uint64
+	// atomicPendingTransfers is the number of transfers that have been ordered, but are not yet done. Used to bound enumeration lookahead
+	atomicPendingTransfers int64
 	// atomicCurrentConcurrentConnections defines the number of active goroutines performing the transfer / executing the chunk func
 	// TODO: added for debugging purpose. remove later
 	atomicCurrentConcurrentConnections int64
+	// atomicDeadlineNanos is when the job stops starting transfers, as Unix nanoseconds. Zero if it has no deadline
+	atomicDeadlineNanos int64
+	// atomicTransfersNotStartedByDeadline is the number of transfers that weren't started because the deadline had passed
+	atomicTransfersNotStartedByDeadline uint32
+	// atomicTransfersRehydrating is the number of transfers that are waiting for their archived source to be rehydrated
+	atomicTransfersRehydrating int32
 	// atomicAllTransfersScheduled defines whether all job parts have been iterated and resumed or not
 	atomicAllTransfersScheduled     int32
 	atomicFinalPartOrderedIndicator int32
@@ -195,6 +230,9 @@ type jobMgr struct {
 
 	exclusiveDestinationMapHolder *atomic.Value
 
+	// prefixLimiter holds the *prefixLimiter that bounds the transfers in flight under each destination prefix, if the job has one
+	prefixLimiter atomic.Value
+
 	// Share the same HTTP Client across all job parts, so that the we maximize re-use of
 	// its internal connection pool
 	httpClient *http.Client
@@ -209,6 +247,8 @@ type jobMgr struct {
 	include map[string]int
 	// list of transfer mentioned to exclude while resuming the job
 	exclude map[string]int
+	// whether only the failed transfers are rescheduled while resuming the job
+	failedOnly bool
 
 	// only a single instance of the prompter is needed for all transfers
 	overwritePrompter *overwritePrompter
@@ -219,7 +259,16 @@ type jobMgr struct {
 	initMu    *sync.Mutex
 	initState *jobMgrInitState
 
+	span *common.Span // nil unless the job is traced
+
 	jobPartProgress chan jobPartProgressInfo
+
+	// the count of bytes read from local disk, and when, as at the previous perf report
+	lastDiskReadBytes int64
+	lastDiskReadTime  time.Time
+
+	// optional per-second record of chunk wait states, exported to a file for charting
+	chunkTimeline *chunkTimeline
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -282,6 +331,11 @@ func (jm *jobMgr) GetPerfInfo() (displayStrings []string, constraint common.Perf
 	// or not, especially if we are dynamically tuning the pool size.
 	result[len(result)-1] = fmt.Sprintf(strings.Replace(format, "%c", "%s", -1), "GRs", JobsAdmin.CurrentMainPoolSize())
 
+	// and how full the read-ahead windows are, if files are read ahead
+	if ra := JobsAdmin.(*jobsAdmin).readAhead; ra != nil {
+		result = append(result, ra.perfString())
+	}
+
 	con := jm.chunkStatusLogger.GetPrimaryPerfConstraint(atomicTransferDirection, jm.PipelineNetworkStats())
 
 	// logging from here is a bit of a hack
@@ -292,12 +346,41 @@ func (jm *jobMgr) GetPerfInfo() (displayStrings []string, constraint common.Perf
 	return result, con
 }
 
+// GetChunkTimeBreakdown returns how the time that chunks have spent so far splits between network, disk and RAM
+func (jm *jobMgr) GetChunkTimeBreakdown() common.ChunkTimeBreakdown {
+	return jm.chunkStatusLogger.GetTimeBreakdown()
+}
+
 func (jm *jobMgr) logPerfInfo(displayStrings []string, constraint common.PerfConstraint) {
 	constraintString := fmt.Sprintf("primary performance constraint is %s", constraint)
 	msg := fmt.Sprintf("PERF: %s. States: %s", constraintString, strings.Join(displayStrings, ", "))
+	if diskRead := jm.diskReadRateString(); diskRead != "" {
+		msg += ". " + diskRead
+	}
 	jm.Log(pipeline.LogInfo, msg)
 }
 
+// diskReadRateString describes the rate at which local files have been read since the previous perf report,
+// or returns an empty string if nothing has been read.
+// Like GetPerfInfo, from which it is called, it is not safe for concurrent use.
+func (jm *jobMgr) diskReadRateString() string {
+	ja := JobsAdmin.(*jobsAdmin)
+	bytes := ja.BytesReadFromDisk()
+	now := time.Now()
+	previousBytes, previousTime := jm.lastDiskReadBytes, jm.lastDiskReadTime
+	jm.lastDiskReadBytes, jm.lastDiskReadTime = bytes, now
+
+	if bytes == 0 || previousTime.IsZero() {
+		return ""
+	}
+	megabitsPerSec := (8 * float64(bytes-previousBytes) / now.Sub(previousTime).Seconds()) / (1000 * 1000)
+	if capped, ok := ja.diskReadPacer.(*tokenBucketPacer); ok {
+		capMegabitsPerSec := 8 * float64(capped.targetBytesPerSecond()) / (1000 * 1000)
+		return fmt.Sprintf("Disk read: %.1f Mbps (capped at %.1f Mbps)", megabitsPerSec, capMegabitsPerSec)
+	}
+	return fmt.Sprintf("Disk read: %.1f Mbps", megabitsPerSec)
+}
+
 func (jm *jobMgr) TryGetPerformanceAdvice(bytesInJob uint64, filesInJob uint32, fromTo common.FromTo) []common.PerformanceAdvice {
 	ja := JobsAdmin.(*jobsAdmin)
 	if !ja.provideBenchmarkResults {
@@ -338,9 +421,15 @@ func (jm *jobMgr) AddJobPart(partNum PartNumber, planFile JobPartPlanFileName, e
 	destinationSAS string, scheduleTransfers bool) IJobPartMgr {
 	jpm := &jobPartMgr{jobMgr: jm, filename: planFile, sourceSAS: sourceSAS,
 		destinationSAS: destinationSAS, pacer: JobsAdmin.(*jobsAdmin).pacer,
-		slicePool:        JobsAdmin.(*jobsAdmin).slicePool,
-		cacheLimiter:     JobsAdmin.(*jobsAdmin).cacheLimiter,
-		fileCountLimiter: JobsAdmin.(*jobsAdmin).fileCountLimiter}
+		slicePool:         JobsAdmin.(*jobsAdmin).slicePool,
+		cacheLimiter:      JobsAdmin.(*jobsAdmin).cacheLimiter,
+		diskReadPacer:     JobsAdmin.(*jobsAdmin).diskReadPacer,
+		readAhead:         JobsAdmin.(*jobsAdmin).readAhead,
+		retryBudget:       JobsAdmin.(*jobsAdmin).retryBudget,
+		transactionBudget: JobsAdmin.(*jobsAdmin).transactionBudget,
+		requestPacer:      JobsAdmin.(*jobsAdmin).requestPacer,
+		checkpointing:     JobsAdmin.(*jobsAdmin).checkpointInterval > 0,
+		fileCountLimiter:  JobsAdmin.(*jobsAdmin).fileCountLimiter}
 	// If an existing plan MMF was supplied, re use it. Otherwise, init a new one.
 	if existingPlanMMF == nil {
 		jpm.planMMF = jpm.filename.Map()
@@ -356,10 +445,15 @@ func (jm *jobMgr) AddJobPart(partNum PartNumber, planFile JobPartPlanFileName, e
 	jm.initMu.Lock()
 	defer jm.initMu.Unlock()
 	if jm.initState == nil {
+		jm.startSpan(jpm.Plan())
 		var logger common.ILogger = jm
+		folderCreationTracker := common.NewFolderCreationTracker(jpm.Plan().Fpo)
+		if jpm.Plan().DirMode != 0 && jpm.Plan().FromTo.To() == common.ELocation.Local() {
+			folderCreationTracker = newDirModeFolderTracker(folderCreationTracker, os.FileMode(jpm.Plan().DirMode), logger)
+		}
 		jm.initState = &jobMgrInitState{
 			securityInfoPersistenceManager: newSecurityInfoPersistenceManager(jm.ctx),
-			folderCreationTracker:          common.NewFolderCreationTracker(jpm.Plan().Fpo),
+			folderCreationTracker:          folderCreationTracker,
 			folderDeletionManager:          common.NewFolderDeletionManager(jm.ctx, jpm.Plan().Fpo, logger),
 		}
 	}
@@ -444,6 +538,17 @@ func (jm *jobMgr) IncludeExclude() (map[string]int, map[string]int) {
 	return jm.include, jm.exclude
 }
 
+// SetFailedOnly sets whether resuming the job reschedules only its failed transfers,
+// as requested with the failed-only flag of the resume command
+func (jm *jobMgr) SetFailedOnly(failedOnly bool) {
+	jm.failedOnly = failedOnly
+}
+
+// Returns whether only the failed transfers are rescheduled
+func (jm *jobMgr) FailedOnly() bool {
+	return jm.failedOnly
+}
+
 // ScheduleTransfers schedules this job part's transfers. It is called when a new job part is ordered & is also called to resume a paused Job
 func (jm *jobMgr) ResumeTransfers(appCtx context.Context) {
 	jm.reset(appCtx, "")
@@ -471,6 +576,38 @@ func (jm *jobMgr) ResetAllTransfersScheduled() {
 	atomic.StoreInt32(&jm.atomicAllTransfersScheduled, 0)
 }
 
+// enumerationLookaheadPollInterval is how often a paused enumeration checks whether enough transfers have finished for it to continue
+const enumerationLookaheadPollInterval = 200 * time.Millisecond
+
+// waitForEnumerationLookahead blocks the ordering of a new job part, of the given number of transfers, until there's room for them
+// within the lookahead, so that the enumerator can't get more than that many transfers ahead of those that are done.
+// A part is always let through when nothing else is pending, so that a part larger than the lookahead can't block forever.
+// If lookahead is zero, it doesn't wait.
+func (jm *jobMgr) waitForEnumerationLookahead(transfers int, lookahead int) {
+	loggedPause := false
+	for lookahead > 0 {
+		pending := atomic.LoadInt64(&jm.atomicPendingTransfers)
+		if pending <= 0 || pending+int64(transfers) <= int64(lookahead) {
+			break
+		}
+		if !loggedPause {
+			jm.Log(pipeline.LogInfo, fmt.Sprintf("Enumeration paused, since %d transfers are waiting to finish and the lookahead is %d", pending, lookahead))
+			loggedPause = true
+		}
+		select {
+		case <-jm.ctx.Done():
+			return
+		case <-time.After(enumerationLookaheadPollInterval):
+		}
+	}
+	atomic.AddInt64(&jm.atomicPendingTransfers, int64(transfers))
+}
+
+// reportTransferDone records that one of the pending transfers is done, whether it succeeded or not
+func (jm *jobMgr) reportTransferDone() {
+	atomic.AddInt64(&jm.atomicPendingTransfers, -1)
+}
+
 // ReportJobPartDone is called to report that a job part completed or failed
 func (jm *jobMgr) ReportJobPartDone(progressInfo jobPartProgressInfo) {
 	jm.jobPartProgress <- progressInfo
@@ -493,6 +630,7 @@ func (jm *jobMgr) reportJobPartDoneHandler() {
 		jobProgressInfo.transfersCompleted += partProgressInfo.transfersCompleted
 		jobProgressInfo.transfersSkipped += partProgressInfo.transfersSkipped
 		jobProgressInfo.transfersFailed += partProgressInfo.transfersFailed
+		jobProgressInfo.transfersLeft += partProgressInfo.transfersLeft
 
 		// If the last part is still awaited or other parts all still not complete,
 		// JobPart 0 status is not changed (unless we are cancelling)
@@ -520,6 +658,25 @@ func (jm *jobMgr) reportJobPartDoneHandler() {
 		jm.Log(pipeline.LogInfo, fmt.Sprintf("%s %s successfully completed, cancelled or paused", partDescription, jm.jobID.String()))
 	}
 
+	// finish the timeline before the final status is set, since the front end may exit as soon as it sees that status
+	jm.closeChunkTimeline()
+
+	// a transactional job must not leave a partial result behind, so undo what it created if too much failed.
+	// The blobs that are deleted are counted as failed from then on, in the spans and status below as in the plan
+	if needsRollBack(part0Plan, jobProgressInfo) {
+		rolledBack := jm.rollBack()
+		jobProgressInfo.transfersCompleted -= rolledBack
+		jobProgressInfo.transfersFailed += rolledBack
+	}
+
+	// likewise for the spans of the job and its transfers
+	jm.endSpan(part0Plan.JobStatus() == common.EJobStatus.Cancelling(), jobProgressInfo)
+
+	// likewise for directory timestamps, which can only be set once every file in the directories has been written
+	if part0Plan.PreserveDirectoryTimestamps && part0Plan.JobStatus() == common.EJobStatus.InProgress() {
+		jm.restoreDirectoryTimestamps()
+	}
+
 	switch part0Plan.JobStatus() {
 	case common.EJobStatus.Cancelling():
 		part0Plan.SetJobStatus(common.EJobStatus.Cancelled())
@@ -527,14 +684,116 @@ func (jm *jobMgr) reportJobPartDoneHandler() {
 			jm.Log(pipeline.LogInfo, fmt.Sprintf("%s %v successfully cancelled", partDescription, jm.jobID))
 		}
 	case common.EJobStatus.InProgress():
-		part0Plan.SetJobStatus((common.EJobStatus).EnhanceJobStatusInfo(jobProgressInfo.transfersSkipped > 0,
-			jobProgressInfo.transfersFailed > 0,
-			jobProgressInfo.transfersCompleted > 0))
+		if jobProgressInfo.transfersLeft > 0 {
+			jm.Log(pipeline.LogWarning, fmt.Sprintf("%d transfers that hadn't failed were left for a later resume, since only the failed transfers were resumed",
+				jobProgressInfo.transfersLeft))
+		}
+		part0Plan.SetJobStatus(finalJobStatus(jobProgressInfo))
 	}
 
 	jm.chunkStatusLogger.FlushLog() // TODO: remove once we sort out what will be calling CloseLog (currently nothing)
 }
 
+// finalJobStatus is the status of a job that ran to its end. A job with transfers left for a later resume didn't complete,
+// so it's given the status of a cancelled job, which says as much, and can still be resumed
+func finalJobStatus(progress jobPartProgressInfo) common.JobStatus {
+	if progress.transfersLeft > 0 {
+		return common.EJobStatus.Cancelled()
+	}
+	return (common.EJobStatus).EnhanceJobStatusInfo(progress.transfersSkipped > 0, progress.transfersFailed > 0, progress.transfersCompleted > 0)
+}
+
+// startChunkTimeline begins recording the chunk wait states of this job, once per second, to the given file
+func (jm *jobMgr) startChunkTimeline(path string) {
+	if jm.chunkTimeline != nil {
+		return // already started by an earlier part of this job
+	}
+	t, err := newChunkTimeline(path, jm.chunkStateSample)
+	if err != nil {
+		jm.Log(pipeline.LogError, err.Error())
+		common.GetLifecycleMgr().Info(err.Error())
+		return
+	}
+	jm.chunkTimeline = t
+	t.start()
+}
+
+// chunkStateSample returns the current count of chunks in each wait state that applies to this job's direction
+func (jm *jobMgr) chunkStateSample() (common.TransferDirection, []string, []int64) {
+	direction := jm.atomicTransferDirection.AtomicLoad()
+	chunkStateCounts := jm.chunkStatusLogger.GetCounts(direction)
+	reasons := make([]string, len(chunkStateCounts))
+	counts := make([]int64, len(chunkStateCounts))
+	for i, c := range chunkStateCounts {
+		reasons[i] = c.WaitReason.Name
+		counts[i] = c.Count
+	}
+	return direction, reasons, counts
+}
+
+func (jm *jobMgr) closeChunkTimeline() {
+	if jm.chunkTimeline == nil {
+		return
+	}
+	if err := jm.chunkTimeline.close(); err != nil {
+		jm.Log(pipeline.LogError, "failed to write chunk timeline: "+err.Error())
+	}
+}
+
+// restoreDirectoryTimestamps sets the modification time of each directory that was downloaded, from that of its source.
+// This is done once the job is done, since writing the files in a directory changes its modification time.
+// Setting a directory's timestamps doesn't change those of its parent, so the order doesn't matter.
+func (jm *jobMgr) restoreDirectoryTimestamps() {
+	restored := 0
+	jm.jobPartMgrs.Iterate(true, func(partNum common.PartNumber, jpm IJobPartMgr) {
+		plan := jpm.Plan()
+		for t := uint32(0); t < plan.NumTransfers; t++ {
+			jppt := plan.Transfer(t)
+			// a source without a modification time, like the root of a file system, has no sensible time to set
+			if jppt.EntityType != common.EEntityType.Folder() || jppt.TransferStatus() != common.ETransferStatus.Success() || jppt.ModifiedTime <= 0 {
+				continue
+			}
+
+			_, dst, _ := plan.TransferSrcDstStrings(t)
+			lmt := time.Unix(0, jppt.ModifiedTime)
+			if err := os.Chtimes(dst, lmt, lmt); err != nil {
+				jm.Log(pipeline.LogWarning, fmt.Sprintf("cannot set the modification time of directory %s: %s", dst, err.Error()))
+				continue
+			}
+			restored++
+		}
+	})
+
+	if jm.ShouldLog(pipeline.LogInfo) {
+		jm.Log(pipeline.LogInfo, fmt.Sprintf("set the modification time of %d directories from their source", restored))
+	}
+}
+
+// needsRollBack says whether the job is transactional, and ran to its end with more transfers failed than it allows
+func needsRollBack(part0Plan *JobPartPlanHeader, progress jobPartProgressInfo) bool {
+	return part0Plan.Transactional && progress.transfersFailed > int(part0Plan.TransactionalFailureThreshold) &&
+		part0Plan.JobStatus() == common.EJobStatus.InProgress()
+}
+
+// rollBack deletes the blobs that a transactional job created, once it is done, because too many of its transfers failed.
+// It returns how many of the transfers that had succeeded have been marked as failed, since their blobs were deleted
+func (jm *jobMgr) rollBack() (rolledBack int) {
+	failed := 0
+	jm.jobPartMgrs.Iterate(true, func(partNum common.PartNumber, jpm IJobPartMgr) {
+		r, f := jpm.rollBackCreatedBlobs(jm.ctx)
+		rolledBack += r
+		failed += f
+	})
+
+	msg := fmt.Sprintf("Too many transfers failed, so the job was rolled back: the blobs that it created were deleted, and the %d transfers of them that had succeeded are counted as failed", rolledBack)
+	if failed > 0 {
+		msg += fmt.Sprintf(". %d could not be deleted (see the log for details)", failed)
+	}
+	jm.Log(pipeline.LogWarning, msg)
+	common.GetLifecycleMgr().Info(msg)
+	return rolledBack
+}
+
 func (jm *jobMgr) getInMemoryTransitJobState() InMemoryTransitJobState {
 	return jm.inMemoryTransitJobState
 }
@@ -549,6 +808,9 @@ func (jm *jobMgr) Context() context.Context                { return jm.ctx }
 func (jm *jobMgr) Cancel()                                 { jm.cancel() }
 func (jm *jobMgr) ShouldLog(level pipeline.LogLevel) bool  { return jm.logger.ShouldLog(level) }
 func (jm *jobMgr) Log(level pipeline.LogLevel, msg string) { jm.logger.Log(level, msg) }
+func (jm *jobMgr) LogForTransfer(level pipeline.LogLevel, transferPath string, msg string) {
+	jm.logger.LogForTransfer(level, transferPath, msg)
+}
 func (jm *jobMgr) PipelineLogInfo() pipeline.LogOptions {
 	return pipeline.LogOptions{
 		Log:       jm.Log,
@@ -557,6 +819,7 @@ func (jm *jobMgr) PipelineLogInfo() pipeline.LogOptions {
 }
 func (jm *jobMgr) Panic(err error) { jm.logger.Panic(err) }
 func (jm *jobMgr) CloseLog() {
+	jm.closeChunkTimeline()
 	jm.logger.CloseLog()
 	jm.chunkStatusLogger.FlushLog()
 }
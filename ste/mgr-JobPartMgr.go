@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -30,8 +31,12 @@ type IJobPartMgr interface {
 	ReportTransferDone(status common.TransferStatus) uint32
 	GetOverwriteOption() common.OverwriteOption
 	GetForceIfReadOnly() bool
+	IsAppendOnly() bool
+	IsTransactional() bool
+	GetSkipLocked() bool
+	GetRetryLocked() bool
 	AutoDecompress() bool
-	ScheduleChunks(chunkFunc chunkFunc)
+	ScheduleChunks(jptm IJobPartTransferMgr, chunkFunc chunkFunc)
 	RescheduleTransfer(jptm IJobPartTransferMgr)
 	BlobTypeOverride() common.BlobType
 	BlobTiers() (blockBlobTier common.BlockBlobTier, pageBlobTier common.PageBlobTier)
@@ -49,11 +54,15 @@ type IJobPartMgr interface {
 	ExclusiveDestinationMap() *common.ExclusiveStringMap
 	ChunkStatusLogger() common.ChunkStatusLogger
 	common.ILogger
+	LogForTransfer(level pipeline.LogLevel, transferPath string, msg string)
 	SourceProviderPipeline() pipeline.Pipeline
 	getOverwritePrompter() *overwritePrompter
 	getFolderCreationTracker() common.FolderCreationTracker
+	pastJobDeadline() bool
 	SecurityInfoPersistenceManager() *securityInfoPersistenceManager
 	FolderDeletionManager() common.FolderDeletionManager
+	rollBackCreatedBlobs(ctx context.Context) (rolledBack int, failed int)
+	TransferSha256(transferIndex uint32) (sum []byte, found bool)
 }
 
 type serviceAPIVersionOverride struct{}
@@ -81,20 +90,114 @@ func NewVersionPolicyFactory() pipeline.Factory {
 	})
 }
 
+// the first service version that understands the trailing dot headers
+const trailingDotServiceVersion = "2022-11-02"
+
+// NewTrailingDotPolicyFactory creates a factory that asks Azure Files to keep trailing dots in file and directory names.
+// When the option is Disable, requests are left untouched, so the service trims trailing dots as it always has.
+// Only the requests that name a path with a trailing dot get the headers, and the newer service version they need,
+// so that all the others are sent with the same version as before. It must come after the version policy.
+func NewTrailingDotPolicyFactory(option common.TrailingDotOption) pipeline.Factory {
+	return pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			if option == common.ETrailingDotOption.Enable() {
+				if hasTrailingDot(request.URL.Path) {
+					request.Header.Set("x-ms-version", trailingDotServiceVersion)
+					request.Header.Set("x-ms-allow-trailing-dot", "true")
+				}
+				// server-side copies read the source with the same rules as the destination
+				if source, err := url.Parse(request.Header.Get("x-ms-copy-source")); err == nil && hasTrailingDot(source.Path) {
+					request.Header.Set("x-ms-version", trailingDotServiceVersion)
+					request.Header.Set("x-ms-source-allow-trailing-dot", "true")
+				}
+			}
+			return next.Do(ctx, request)
+		}
+	})
+}
+
+// hasTrailingDot returns true if any of the names in the path ends with a dot, which the service would otherwise trim
+func hasTrailingDot(path string) bool {
+	for _, name := range strings.Split(path, "/") {
+		if strings.HasSuffix(name, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// requestHeaders are the headers which the user asked us to add to every request (e.g. for routing by a gateway)
+var requestHeaders http.Header
+
+// SetRequestHeaders sets the headers that are added to every request. It must be called before any pipeline is used.
+// The caller is responsible for making sure that they don't include any of the headers that the service or our own policies rely on.
+func SetRequestHeaders(h http.Header) {
+	requestHeaders = h
+}
+
+// NewRequestHeaderPolicyFactory creates a factory that adds the user's headers to every request.
+// It must come before the credential, so that any header which the credential signs is signed as it is sent.
+func NewRequestHeaderPolicyFactory() pipeline.Factory {
+	return pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			for name, values := range requestHeaders {
+				request.Header[name] = values
+			}
+			return next.Do(ctx, request)
+		}
+	})
+}
+
+// endpointRewriter redirects the URL of a storage resource to the endpoint that the user asked for, if any (e.g. for sovereign clouds and Private Link)
+var endpointRewriter func(resource string) string
+
+// SetEndpointRewriter sets how the URLs of requests, and of the sources of service to service copies, are redirected.
+// It must be called before any pipeline is used.
+func SetEndpointRewriter(rewrite func(resource string) string) {
+	endpointRewriter = rewrite
+}
+
+// NewEndpointPolicyFactory creates a factory that sends each request to the endpoint that the user asked for.
+// Since it's done as the request is sent, every command, and every resumed job, addresses the services the same way.
+// The source of a service to service copy is redirected too, since the service itself reads from it.
+func NewEndpointPolicyFactory() pipeline.Factory {
+	return pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			if endpointRewriter != nil {
+				endpoint := request.URL.Scheme + "://" + request.URL.Host
+				if redirected := endpointRewriter(endpoint); redirected != endpoint {
+					if u, err := url.Parse(redirected); err == nil {
+						request.URL.Scheme, request.URL.Host = u.Scheme, u.Host
+						request.Host = u.Host
+					}
+				}
+				if source := request.Header.Get("x-ms-copy-source"); source != "" {
+					request.Header.Set("x-ms-copy-source", endpointRewriter(source))
+				}
+			}
+			return next.Do(ctx, request)
+		}
+	})
+}
+
 // NewAzcopyHTTPClient creates a new HTTP client.
 // We must minimize use of this, and instead maximize re-use of the returned client object.
 // Why? Because that makes our connection pooling more efficient, and prevents us exhausting the
 // number of available network sockets on resource-constrained Linux systems. (E.g. when
 // 'ulimit -Hn' is low).
 func NewAzcopyHTTPClient(maxIdleConns int) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		DualStack: true,
+	}
+	if bindAddress != nil {
+		dialer.LocalAddr = bindAddress
+	}
 	return &http.Client{
 		Transport: &http.Transport{
-			Proxy: common.GlobalProxyLookup,
-			DialContext: newDialRateLimiter(&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-				DualStack: true,
-			}).DialContext,
+			Proxy:                  common.GlobalProxyLookup,
+			DialContext:            newDialRateLimiter(dialer).DialContext,
 			MaxIdleConns:           0, // No limit
 			MaxIdleConnsPerHost:    maxIdleConns,
 			IdleConnTimeout:        180 * time.Second,
@@ -133,7 +236,26 @@ func (d *dialRateLimiter) DialContext(ctx context.Context, network, address stri
 	}
 	defer d.sem.Release(1)
 
-	return d.dialer.DialContext(ctx, network, address)
+	conn, err := d.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, markDNSErrorTemporary(err)
+	}
+	return networkTraffic.track(conn), nil
+}
+
+// markDNSErrorTemporary makes a failure to resolve a host name say that it's temporary, so that it's retried, with the usual
+// backoff and up to the usual number of tries. Name resolution often fails briefly in cloud environments, but the retry
+// policies of the SDKs only retry it if the error says it's temporary. Failing a whole transfer for a brief failure costs
+// more than being slower to report a host name that really doesn't exist.
+func markDNSErrorTemporary(err error) error {
+	if opErr, ok := err.(*net.OpError); ok {
+		if dnsErr, ok := opErr.Err.(*net.DNSError); ok && !dnsErr.IsTemporary {
+			temporary := *dnsErr // copy, since the resolver may share the error between lookups of the same name
+			temporary.IsTemporary = true
+			opErr.Err = &temporary
+		}
+	}
+	return err
 }
 
 // newAzcopyHTTPClientFactory creates a HTTPClientPolicyFactory object that sends HTTP requests to a Go's default http.Client.
@@ -158,11 +280,14 @@ func NewBlobPipeline(c azblob.Credential, o azblob.PipelineOptions, r XferRetryO
 	f := []pipeline.Factory{
 		azblob.NewTelemetryPolicyFactory(o.Telemetry),
 		azblob.NewUniqueRequestIDPolicyFactory(),
+		NewRequestHeaderPolicyFactory(),
+		NewEndpointPolicyFactory(),
 		NewBlobXferRetryPolicyFactory(r),    // actually retry the operation
 		newRetryNotificationPolicyFactory(), // record that a retry status was returned
 		c,
 		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
-		//NewPacerPolicyFactory(p),
+		newRequestPacerPolicyFactory(p),
+		newTransactionBudgetPolicyFactory(),
 		NewVersionPolicyFactory(),
 		NewRequestLogPolicyFactory(RequestLogOptions{LogWarningIfTryOverThreshold: o.RequestLog.LogWarningIfTryOverThreshold}),
 		newXferStatsPolicyFactory(statsAcc),
@@ -180,6 +305,8 @@ func NewBlobFSPipeline(c azbfs.Credential, o azbfs.PipelineOptions, r XferRetryO
 	f := []pipeline.Factory{
 		azbfs.NewTelemetryPolicyFactory(o.Telemetry),
 		azbfs.NewUniqueRequestIDPolicyFactory(),
+		NewRequestHeaderPolicyFactory(),
+		NewEndpointPolicyFactory(),
 		NewBFSXferRetryPolicyFactory(r),     // actually retry the operation
 		newRetryNotificationPolicyFactory(), // record that a retry status was returned
 	}
@@ -188,6 +315,8 @@ func NewBlobFSPipeline(c azbfs.Credential, o azbfs.PipelineOptions, r XferRetryO
 
 	f = append(f,
 		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
+		newRequestPacerPolicyFactory(p),
+		newTransactionBudgetPolicyFactory(),
 		NewRequestLogPolicyFactory(RequestLogOptions{LogWarningIfTryOverThreshold: o.RequestLog.LogWarningIfTryOverThreshold}),
 		newXferStatsPolicyFactory(statsAcc))
 
@@ -195,7 +324,7 @@ func NewBlobFSPipeline(c azbfs.Credential, o azbfs.PipelineOptions, r XferRetryO
 }
 
 // NewFilePipeline creates a Pipeline using the specified credentials and options.
-func NewFilePipeline(c azfile.Credential, o azfile.PipelineOptions, r azfile.RetryOptions, p pacer, client *http.Client, statsAcc *pipelineNetworkStats) pipeline.Pipeline {
+func NewFilePipeline(c azfile.Credential, o azfile.PipelineOptions, r azfile.RetryOptions, p pacer, client *http.Client, statsAcc *pipelineNetworkStats, trailingDot common.TrailingDotOption) pipeline.Pipeline {
 	if c == nil {
 		panic("c can't be nil")
 	}
@@ -203,11 +332,16 @@ func NewFilePipeline(c azfile.Credential, o azfile.PipelineOptions, r azfile.Ret
 	f := []pipeline.Factory{
 		azfile.NewTelemetryPolicyFactory(o.Telemetry),
 		azfile.NewUniqueRequestIDPolicyFactory(),
+		NewRequestHeaderPolicyFactory(),
+		NewEndpointPolicyFactory(),
 		azfile.NewRetryPolicyFactory(r),     // actually retry the operation
 		newRetryNotificationPolicyFactory(), // record that a retry status was returned
 		c,
 		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
+		newRequestPacerPolicyFactory(p),
+		newTransactionBudgetPolicyFactory(),
 		NewVersionPolicyFactory(),
+		NewTrailingDotPolicyFactory(trailingDot),
 		NewRequestLogPolicyFactory(RequestLogOptions{LogWarningIfTryOverThreshold: o.RequestLog.LogWarningIfTryOverThreshold}),
 		newXferStatsPolicyFactory(statsAcc),
 	}
@@ -221,6 +355,7 @@ type jobPartProgressInfo struct {
 	transfersCompleted int
 	transfersSkipped   int
 	transfersFailed    int
+	transfersLeft      int // neither run nor done, since only the failed transfers were resumed
 }
 
 // jobPartMgr represents the runtime information for a Job's Part
@@ -266,6 +401,23 @@ type jobPartMgr struct {
 
 	pacer pacer // Pacer is used to cap throughput
 
+	diskReadPacer pacer // used to cap the rate at which local source files are read
+
+	readAhead *readAhead // nil unless local source files are read ahead of their chunks being scheduled
+
+	retryBudget *retryBudget // nil unless retries are capped
+
+	transactionBudget *transactionBudget // nil unless transactions are capped
+
+	requestPacer pacer // used to cap the rate at which requests are sent to the service. Nil if not capped
+
+	checkpointing bool // if true, the transfers record their progress in the plan, so that an interrupted upload can be resumed part way through
+
+	// the SHA-256 of each file whose data was read or saved in full, by transfer index. They're only kept in memory,
+	// for the manifest that the copy command writes once the job is done
+	sha256Mu sync.Mutex
+	sha256s  map[uint32][]byte
+
 	slicePool common.ByteSlicePooler
 
 	cacheLimiter            common.CacheLimiter
@@ -286,12 +438,17 @@ type jobPartMgr struct {
 	atomicTransfersCompleted uint32
 	atomicTransfersFailed    uint32
 	atomicTransfersSkipped   uint32
+	atomicTransfersLeft      uint32
 }
 
 func (jpm *jobPartMgr) getOverwritePrompter() *overwritePrompter {
 	return jpm.jobMgr.getOverwritePrompter()
 }
 
+func (jpm *jobPartMgr) pastJobDeadline() bool {
+	return jpm.jobMgr.pastDeadline()
+}
+
 func (jpm *jobPartMgr) getFolderCreationTracker() common.FolderCreationTracker {
 	if jpm.jobMgrInitState == nil || jpm.jobMgrInitState.folderCreationTracker == nil {
 		panic("folderCreationTracker should have been initialized already")
@@ -305,6 +462,7 @@ func (jpm *jobPartMgr) Plan() *JobPartPlanHeader { return jpm.planMMF.Plan() }
 // ScheduleTransfers schedules this job part's transfers. It is called when a new job part is ordered & is also called to resume a paused Job
 func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 	jpm.atomicTransfersDone = 0 // Reset the # of transfers done back to 0
+	jpm.atomicTransfersLeft = 0
 	// partplan file is opened and mapped when job part is added
 	//jpm.planMMF = jpm.filename.Map() // Open the job part plan file & memory-map it in
 	plan := jpm.planMMF.Plan()
@@ -358,7 +516,10 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 	jpm.preserveLastModifiedTime = plan.DstLocalData.PreserveLastModifiedTime
 
 	jpm.blobTypeOverride = plan.DstBlobData.BlobType
-	jpm.newJobXfer = computeJobXfer(plan.FromTo, plan.DstBlobData.BlobType)
+	jpm.newJobXfer = computeJobXfer(plan.FromTo, plan.DstBlobData.BlobType, plan.S2SPreference)
+	if plan.RehydrateAndCopy {
+		jpm.newJobXfer = rehydrateBeforeTransfer(jpm.newJobXfer)
+	}
 
 	jpm.priority = plan.Priority
 
@@ -373,6 +534,20 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 			continue
 		}
 
+		// When only the failed transfers are resumed, everything else is left as it was. Those that neither failed nor finished
+		// aren't run, and their statuses are kept, so that the job stays resumable. They're only counted, so that this part can finish
+		if jpm.jobMgr.FailedOnly() {
+			if !ts.IsFailure() {
+				if leftByFailedOnlyResume(ts) {
+					atomic.AddUint32(&jpm.atomicTransfersLeft, 1)
+				}
+				jpm.ReportTransferDone(ts)
+				continue
+			}
+			jppt.SetTransferStatus(common.ETransferStatus.Started(), true)
+			jppt.SetErrorCode(0, true)
+		}
+
 		// If the transfer was failed, then while rescheduling the transfer marking it Started.
 		if ts == common.ETransferStatus.Failed() {
 			jppt.SetTransferStatus(common.ETransferStatus.Started(), true)
@@ -390,11 +565,17 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 			//TODO: insert the factory func interface in jptm.
 			// numChunks will be set by the transfer's prologue method
 		}
+		jptm.ctx = withRetryCounter(jptm.ctx, &jptm.atomicRetryCount, jpm.retryBudget)
+		jptm.ctx = withTransactionBudget(jptm.ctx, jpm.transactionBudget)
+		if jpm.checkpointing {
+			jptm.checkpoint = newTransferCheckpoint(jppt)
+		}
+		priority := jpm.transferPriority(jptm)
 		if jpm.ShouldLog(pipeline.LogInfo) {
-			jpm.Log(pipeline.LogInfo, fmt.Sprintf("scheduling JobID=%v, Part#=%d, Transfer#=%d, priority=%v", plan.JobID, plan.PartNum, t, plan.Priority))
+			jpm.Log(pipeline.LogInfo, fmt.Sprintf("scheduling JobID=%v, Part#=%d, Transfer#=%d, priority=%v", plan.JobID, plan.PartNum, t, priority))
 		}
 
-		JobsAdmin.(*jobsAdmin).ScheduleTransfer(jpm.priority, jptm)
+		JobsAdmin.(*jobsAdmin).ScheduleTransfer(priority, jptm)
 
 		// This sets the atomic variable atomicAllTransfersScheduled to 1
 		// atomicAllTransfersScheduled variables is used in case of resume job
@@ -410,12 +591,21 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 	}
 }
 
-func (jpm *jobPartMgr) ScheduleChunks(chunkFunc chunkFunc) {
-	JobsAdmin.ScheduleChunk(jpm.priority, chunkFunc)
+func (jpm *jobPartMgr) ScheduleChunks(jptm IJobPartTransferMgr, chunkFunc chunkFunc) {
+	JobsAdmin.ScheduleChunk(jpm.transferPriority(jptm), jptm, chunkFunc)
 }
 
 func (jpm *jobPartMgr) RescheduleTransfer(jptm IJobPartTransferMgr) {
-	JobsAdmin.(*jobsAdmin).ScheduleTransfer(jpm.priority, jptm)
+	JobsAdmin.(*jobsAdmin).ScheduleTransfer(jpm.transferPriority(jptm), jptm)
+}
+
+// transferPriority returns the priority that the transfer, and each of its chunks, are scheduled with.
+// A transfer that was given high priority has it, whatever the priority of its job part; all others have that of the job part
+func (jpm *jobPartMgr) transferPriority(jptm IJobPartTransferMgr) common.JobPriority {
+	if jptm.IsHighPriority() {
+		return common.EJobPriority.High()
+	}
+	return jpm.priority
 }
 
 func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
@@ -461,7 +651,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 				},
 			},
 			xferRetryOption,
-			jpm.pacer,
+			nil,
 			jpm.jobMgr.HttpClient(),
 			statsAccForSip)
 	}
@@ -482,7 +672,24 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 				RetryDelay:    UploadRetryDelay,
 				MaxRetryDelay: UploadMaxRetryDelay,
 			},
-			jpm.pacer,
+			nil,
+			jpm.jobMgr.HttpClient(),
+			statsAccForSip,
+			jpm.Plan().TrailingDot)
+	}
+	// Other remote sources, such as S3, are only read through a pipeline when the copy is routed through AzCopy,
+	// which reads each source by its pre-signed URL
+	if jpm.sourceProviderPipeline == nil && fromTo.IsS2S() && jpm.Plan().S2SPreference == common.ES2SPreference.Never() {
+		jpm.sourceProviderPipeline = NewBlobPipeline(
+			azblob.NewAnonymousCredential(),
+			azblob.PipelineOptions{
+				Log: jpm.jobMgr.PipelineLogInfo(),
+				Telemetry: azblob.TelemetryOptions{
+					Value: userAgent,
+				},
+			},
+			xferRetryOption,
+			nil,
 			jpm.jobMgr.HttpClient(),
 			statsAccForSip)
 	}
@@ -490,7 +697,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 	// Create pipeline for data transfer.
 	switch fromTo {
 	case common.EFromTo.BlobTrash(), common.EFromTo.BlobLocal(), common.EFromTo.LocalBlob(), common.EFromTo.BenchmarkBlob(),
-		common.EFromTo.BlobBlob(), common.EFromTo.FileBlob(), common.EFromTo.S3Blob():
+		common.EFromTo.BlobBlob(), common.EFromTo.FileBlob(), common.EFromTo.S3Blob(), common.EFromTo.HttpBlob():
 		credential := common.CreateBlobCredential(ctx, credInfo, credOption)
 		jpm.Log(pipeline.LogInfo, fmt.Sprintf("JobID=%v, credential type: %v", jpm.Plan().JobID, credInfo.CredentialType))
 		jpm.pipeline = NewBlobPipeline(
@@ -502,7 +709,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 				},
 			},
 			xferRetryOption,
-			jpm.pacer,
+			jpm.requestPacer,
 			jpm.jobMgr.HttpClient(),
 			jpm.jobMgr.PipelineNetworkStats())
 	// Create pipeline for Azure BlobFS.
@@ -519,7 +726,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 				},
 			},
 			xferRetryOption,
-			jpm.pacer,
+			jpm.requestPacer,
 			jpm.jobMgr.HttpClient(),
 			jpm.jobMgr.PipelineNetworkStats())
 	// Create pipeline for Azure File.
@@ -540,9 +747,10 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 				RetryDelay:    UploadRetryDelay,
 				MaxRetryDelay: UploadMaxRetryDelay,
 			},
-			jpm.pacer,
+			jpm.requestPacer,
 			jpm.jobMgr.HttpClient(),
-			jpm.jobMgr.PipelineNetworkStats())
+			jpm.jobMgr.PipelineNetworkStats(),
+			jpm.Plan().TrailingDot)
 	default:
 		panic(fmt.Errorf("Unrecognized from-to: %q", fromTo.String()))
 	}
@@ -576,6 +784,106 @@ func (jpm *jobPartMgr) GetForceIfReadOnly() bool {
 	return jpm.Plan().ForceIfReadOnly
 }
 
+func (jpm *jobPartMgr) IsAppendOnly() bool {
+	return jpm.Plan().AppendOnly
+}
+
+func (jpm *jobPartMgr) IsTransactional() bool {
+	return jpm.Plan().Transactional
+}
+
+func (jpm *jobPartMgr) preserveImmutability() bool {
+	return jpm.Plan().PreserveImmutability
+}
+
+func (jpm *jobPartMgr) computeSha256() bool {
+	return jpm.Plan().ComputeSha256
+}
+
+func (jpm *jobPartMgr) recordSha256(transferIndex uint32, sum []byte) {
+	jpm.sha256Mu.Lock()
+	defer jpm.sha256Mu.Unlock()
+	if jpm.sha256s == nil {
+		jpm.sha256s = make(map[uint32][]byte)
+	}
+	jpm.sha256s[transferIndex] = sum
+}
+
+// TransferSha256 returns the SHA-256 of the transfer's file, if it was computed while the file's data was read or saved in this process
+func (jpm *jobPartMgr) TransferSha256(transferIndex uint32) (sum []byte, found bool) {
+	jpm.sha256Mu.Lock()
+	defer jpm.sha256Mu.Unlock()
+	sum, found = jpm.sha256s[transferIndex]
+	return
+}
+
+// rollBackParallelism is how many of the blobs that a transactional job created are deleted at once, when it's rolled back
+const rollBackParallelism = 32
+
+// rollBackCreatedBlobs deletes the blobs that this part's transfers created, for a transactional job in which too many transfers failed.
+// That includes the blobs of transfers that failed, since a transfer may fail after its blob was created. Each successful transfer
+// whose blob is deleted is marked as failed, so that the summary shows it isn't at the destination, and resuming the job uploads it again.
+// Blobs that existed before the job are left as they are, since what they held before can't be restored.
+func (jpm *jobPartMgr) rollBackCreatedBlobs(ctx context.Context) (rolledBack int, failed int) {
+	plan := jpm.Plan()
+	_, dstSAS := jpm.SAS()
+	var atomicRolledBack, atomicFailed int32
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, rollBackParallelism)
+	for t := uint32(0); t < plan.NumTransfers; t++ {
+		if !plan.Transfer(t).DstCreated {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(t uint32) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			jppt := plan.Transfer(t)
+			_, dst, _ := plan.TransferSrcDstStrings(t)
+			dstURL, err := url.Parse(dst)
+			if err == nil {
+				if len(dstSAS) > 0 {
+					if len(dstURL.RawQuery) > 0 {
+						dstURL.RawQuery += "&" + dstSAS
+					} else {
+						dstURL.RawQuery = dstSAS
+					}
+				}
+				_, err = azblob.NewBlobURL(*dstURL, jpm.pipeline).Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+			}
+			if stErr, ok := err.(azblob.StorageError); ok && stErr.Response().StatusCode == http.StatusNotFound {
+				err = nil // already gone, or a failed transfer never got as far as creating it, which is what we wanted
+			}
+			if err != nil {
+				jpm.Log(pipeline.LogError, fmt.Sprintf("could not roll back %s, which the job created: %s", dst, err.Error()))
+				atomic.AddInt32(&atomicFailed, 1)
+				return
+			}
+
+			jpm.LogForTransfer(pipeline.LogWarning, dst, "deleted, to roll back the job")
+			if jppt.TransferStatus() == common.ETransferStatus.Success() {
+				jppt.SetTransferStatus(common.ETransferStatus.Failed(), true)
+				atomic.AddInt32(&atomicRolledBack, 1)
+			}
+		}(t)
+	}
+	wg.Wait()
+	return int(atomicRolledBack), int(atomicFailed)
+}
+
+func (jpm *jobPartMgr) GetSkipLocked() bool {
+	return jpm.Plan().SkipLocked
+}
+
+func (jpm *jobPartMgr) GetRetryLocked() bool {
+	return jpm.Plan().RetryLocked
+}
+
 func (jpm *jobPartMgr) AutoDecompress() bool {
 	return jpm.Plan().AutoDecompress
 }
@@ -612,6 +920,12 @@ var builtinTypes = map[string]string{
 }
 
 func (jpm *jobPartMgr) inferContentType(fullFilePath string, dataFileToXfer []byte) string {
+	return InferContentType(fullFilePath, dataFileToXfer)
+}
+
+// InferContentType returns the content type that an upload gives a file, from its extension or, failing that, from its
+// first bytes (of which at most 512 are looked at)
+func InferContentType(fullFilePath string, dataFileToXfer []byte) string {
 	fileExtension := filepath.Ext(fullFilePath)
 
 	// short-circuit for common static website files
@@ -672,15 +986,67 @@ func (jpm *jobPartMgr) deleteSnapshotsOption() common.DeleteSnapshotsOption {
 	return jpm.Plan().DeleteSnapshotsOption
 }
 
+func (jpm *jobPartMgr) batchDelete() bool {
+	return jpm.Plan().BatchDelete
+}
+
+func (jpm *jobPartMgr) blobExpiry() (common.BlobExpiryOption, int64) {
+	return jpm.Plan().ExpiryOption, jpm.Plan().ExpiryTime
+}
+
+func (jpm *jobPartMgr) encryptionVerification() (verify bool, expectedScope string) {
+	plan := jpm.Plan()
+	return plan.VerifyEncryption, string(plan.ExpectedEncryptionScope[:plan.ExpectedEncryptionScopeLength])
+}
+
+func (jpm *jobPartMgr) pageBlobSequenceNumber() int64 {
+	return jpm.Plan().PageBlobSequenceNumber
+}
+
+func (jpm *jobPartMgr) blockIDPrefix() string {
+	plan := jpm.Plan()
+	return string(plan.BlockIDPrefix[:plan.BlockIDPrefixLength])
+}
+
+func (jpm *jobPartMgr) blockBlobTierName() string {
+	plan := jpm.Plan()
+	return string(plan.BlockBlobTierName[:plan.BlockBlobTierNameLength])
+}
+
+// tierByAccessTime returns the rules that choose the tier of each block blob by when its source was last accessed, if any
+func (jpm *jobPartMgr) tierByAccessTime() AccessTimeTiers {
+	plan := jpm.Plan()
+	if plan.TierByAccessTimeLength == 0 {
+		return nil
+	}
+	rules, err := ParseAccessTimeTiers(string(plan.TierByAccessTime[:plan.TierByAccessTimeLength]))
+	if err != nil {
+		return nil // the rules were checked before the job was created
+	}
+	return rules
+}
+
+func (jpm *jobPartMgr) deltaUpdate() bool {
+	return jpm.Plan().DstBlobData.DeltaUpdate
+}
+
+// leftByFailedOnlyResume says whether a resume of only the failed transfers leaves a transfer with the given status for a later resume,
+// because it neither failed nor finished
+func leftByFailedOnlyResume(ts common.TransferStatus) bool {
+	return ts.ShouldTransfer() || ts == common.ETransferStatus.Cancelled()
+}
+
 func (jpm *jobPartMgr) updateJobPartProgress(status common.TransferStatus) {
 	switch status {
 	case common.ETransferStatus.Success():
 		atomic.AddUint32(&jpm.atomicTransfersCompleted, 1)
 	case common.ETransferStatus.Failed(), common.ETransferStatus.BlobTierFailure():
 		atomic.AddUint32(&jpm.atomicTransfersFailed, 1)
-	case common.ETransferStatus.SkippedEntityAlreadyExists(), common.ETransferStatus.SkippedBlobHasSnapshots():
+	case common.ETransferStatus.SkippedEntityAlreadyExists(), common.ETransferStatus.SkippedBlobHasSnapshots(), common.ETransferStatus.SkippedFileLocked():
 		atomic.AddUint32(&jpm.atomicTransfersSkipped, 1)
 	case common.ETransferStatus.Cancelled():
+	case common.ETransferStatus.NotStarted(), common.ETransferStatus.Started():
+		// left unfinished by a resume of only the failed transfers, and counted as such by ScheduleTransfers
 	default:
 		jpm.Log(pipeline.LogError, fmt.Sprintf("Unexpected status: %v", status.String()))
 	}
@@ -690,6 +1056,7 @@ func (jpm *jobPartMgr) updateJobPartProgress(status common.TransferStatus) {
 func (jpm *jobPartMgr) ReportTransferDone(status common.TransferStatus) (transfersDone uint32) {
 	transfersDone = atomic.AddUint32(&jpm.atomicTransfersDone, 1)
 	jpm.updateJobPartProgress(status)
+	jpm.jobMgr.reportTransferDone()
 
 	//Add a safety count-check
 
@@ -702,6 +1069,7 @@ func (jpm *jobPartMgr) ReportTransferDone(status common.TransferStatus) (transfe
 			transfersCompleted: int(atomic.LoadUint32(&jpm.atomicTransfersCompleted)),
 			transfersSkipped:   int(atomic.LoadUint32(&jpm.atomicTransfersSkipped)),
 			transfersFailed:    int(atomic.LoadUint32(&jpm.atomicTransfersFailed)),
+			transfersLeft:      int(atomic.LoadUint32(&jpm.atomicTransfersLeft)),
 		}
 		jpm.jobMgr.ReportJobPartDone(jppi)
 	}
@@ -736,6 +1104,9 @@ func (jpm *jobPartMgr) ReleaseAConnection() {
 func (jpm *jobPartMgr) ShouldLog(level pipeline.LogLevel) bool  { return jpm.jobMgr.ShouldLog(level) }
 func (jpm *jobPartMgr) Log(level pipeline.LogLevel, msg string) { jpm.jobMgr.Log(level, msg) }
 func (jpm *jobPartMgr) Panic(err error)                         { jpm.jobMgr.Panic(err) }
+func (jpm *jobPartMgr) LogForTransfer(level pipeline.LogLevel, transferPath string, msg string) {
+	jpm.jobMgr.LogForTransfer(level, transferPath, msg)
+}
 func (jpm *jobPartMgr) ChunkStatusLogger() common.ChunkStatusLogger {
 	return jpm.jobMgr.ChunkStatusLogger()
 }
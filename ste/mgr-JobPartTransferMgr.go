@@ -20,6 +20,7 @@ import (
 type IJobPartTransferMgr interface {
 	FromTo() common.FromTo
 	Info() TransferInfo
+	TransferID() string
 	ResourceDstData(dataFileToXfer []byte) (headers common.ResourceHTTPHeaders, metadata common.Metadata, blobTags common.BlobTags)
 	LastModifiedTime() time.Time
 	PreserveLastModifiedTime() (time.Time, bool)
@@ -32,13 +33,21 @@ type IJobPartTransferMgr interface {
 	Context() context.Context
 	SlicePool() common.ByteSlicePooler
 	CacheLimiter() common.CacheLimiter
+	DiskReadPacer() pacer
+	ReadAhead() *readAhead
 	WaitUntilLockDestination(ctx context.Context) error
 	EnsureDestinationUnlocked()
 	HoldsDestinationLock() bool
 	StartJobXfer()
 	GetOverwriteOption() common.OverwriteOption
 	GetForceIfReadOnly() bool
+	IsAppendOnly() bool
+	IsTransactional() bool
+	RecordDestinationCreated()
+	ShouldSkipLocked() bool
+	TryClaimLockedRetry() bool
 	ShouldDecompress() bool
+	ClientSideEncryptionKey() (key common.ClientSideEncryptionKey, enabled bool, err error)
 	GetSourceCompressionType() (common.CompressionType, error)
 	ReportChunkDone(id common.ChunkID) (lastChunk bool, chunksDone uint32)
 	TransferStatusIgnoringCancellation() common.TransferStatus
@@ -81,8 +90,26 @@ type IJobPartTransferMgr interface {
 	LogAtLevelForCurrentTransfer(level pipeline.LogLevel, msg string)
 	GetOverwritePrompter() *overwritePrompter
 	GetFolderCreationTracker() common.FolderCreationTracker
+	PastJobDeadline() bool
+	TakePrefixSlot() bool
+	ReleasePrefixSlot()
+	SetRehydrating(rehydrating bool)
 	common.ILogger
 	DeleteSnapshotsOption() common.DeleteSnapshotsOption
+	ShouldBatchDelete() bool
+	BlobExpiry() (option common.BlobExpiryOption, expiryTime int64)
+	PreserveImmutability() bool
+	ShouldComputeSha256() bool
+	RecordSha256(sum []byte)
+	EncryptionVerification() (verify bool, expectedScope string)
+	ShouldDeltaUpdate() bool
+	IsHighPriority() bool
+	PageBlobSequenceNumber() int64
+	BlockIDPrefix() string
+	BlockBlobTierOverride() azblob.AccessTierType
+	TierByAccessTime() AccessTimeTiers
+	IsCheckpointing() bool
+	CheckpointedBytes() int64
 	SecurityInfoPersistenceManager() *securityInfoPersistenceManager
 	FolderDeletionManager() common.FolderDeletionManager
 	GetDestinationRoot() string
@@ -97,6 +124,11 @@ type TransferInfo struct {
 	EntityType             common.EntityType
 	PreserveSMBPermissions common.PreservePermissionsOption
 	PreserveSMBInfo        bool
+	PreserveFileAttributes bool
+	SetReadOnly            bool
+	SkipSMBInfo            bool
+	PreservePOSIX          bool
+	FileMode               uint16
 
 	// Transfer info for S2S copy
 	SrcProperties
@@ -143,6 +175,15 @@ func (i TransferInfo) entityTypeLogIndicator() string {
 	}
 }
 
+// blockSizeForMaxBlocks returns the smallest whole number of MiB that lets a source of the given size fit in maxBlocks blocks.
+// It is never less than 1 MiB, so small files are not split into needlessly tiny blocks.
+func blockSizeForMaxBlocks(sourceSize int64, maxBlocks uint16) int64 {
+	const mib = 1024 * 1024
+	blockSize := (sourceSize + int64(maxBlocks) - 1) / int64(maxBlocks)
+	blockSize = (blockSize + mib - 1) / mib * mib
+	return common.Iffint64(blockSize < mib, mib, blockSize)
+}
+
 type SrcProperties struct {
 	SrcHTTPHeaders common.ResourceHTTPHeaders // User for S2S copy, where per transfer's src properties need be set in destination.
 	SrcMetadata    common.Metadata
@@ -173,6 +214,18 @@ type jobPartTransferMgr struct {
 	// used to show whether THIS jptm holds the destination lock
 	atomicDestLockHeldIndicator uint32
 
+	// used to show whether this transfer has already been re-queued because its source was locked
+	atomicLockedRetryIndicator uint32
+
+	// atomicRehydrating is 1 while the transfer waits for its archived source to be rehydrated, and is counted by the job as doing so
+	atomicRehydrating uint32
+
+	// atomicPrefixSlotIndicator is 1 while the transfer holds one of the slots of its destination prefix, which limit the transfers in flight under it
+	atomicPrefixSlotIndicator uint32
+
+	// how many times the requests of this transfer have been retried
+	atomicRetryCount int32
+
 	jobPartMgr          IJobPartMgr // Refers to the "owning" Job Part
 	jobPartPlanTransfer *JobPartPlanTransfer
 	transferIndex       uint32
@@ -189,6 +242,10 @@ type jobPartTransferMgr struct {
 
 	actionAfterLastChunk func()
 
+	checkpoint *transferCheckpoint // nil unless checkpointing is enabled
+
+	span *common.Span // nil unless the job is traced
+
 	/*
 		@Parteek removed 3/23 morning, as jeff ad equivalent
 		// transfer chunks are put into this channel and execution engine takes chunk out of this channel.
@@ -199,6 +256,23 @@ func (jptm *jobPartTransferMgr) GetOverwritePrompter() *overwritePrompter {
 	return jptm.jobPartMgr.getOverwritePrompter()
 }
 
+// PastJobDeadline says whether the job's deadline, if it has one, has passed, in which case the transfer mustn't be started.
+// It's asked once per transfer, as the transfer is about to start, and counts each transfer it says so for
+func (jptm *jobPartTransferMgr) PastJobDeadline() bool {
+	return jptm.jobPartMgr.pastJobDeadline()
+}
+
+// SetRehydrating records whether the transfer is waiting for its archived source to be rehydrated, for the job's count of such transfers
+func (jptm *jobPartTransferMgr) SetRehydrating(rehydrating bool) {
+	if rehydrating {
+		if atomic.CompareAndSwapUint32(&jptm.atomicRehydrating, 0, 1) {
+			jptm.jobPartMgr.(*jobPartMgr).jobMgr.addTransfersRehydrating(1)
+		}
+	} else if atomic.CompareAndSwapUint32(&jptm.atomicRehydrating, 1, 0) {
+		jptm.jobPartMgr.(*jobPartMgr).jobMgr.addTransfersRehydrating(-1)
+	}
+}
+
 func (jptm *jobPartTransferMgr) GetFolderCreationTracker() common.FolderCreationTracker {
 	return jptm.jobPartMgr.getFolderCreationTracker()
 }
@@ -208,6 +282,7 @@ func (jptm *jobPartTransferMgr) FromTo() common.FromTo {
 }
 
 func (jptm *jobPartTransferMgr) StartJobXfer() {
+	jptm.startSpan()
 	jptm.jobPartMgr.StartJobXfer(jptm)
 }
 
@@ -219,6 +294,33 @@ func (jptm *jobPartTransferMgr) GetForceIfReadOnly() bool {
 	return jptm.jobPartMgr.GetForceIfReadOnly()
 }
 
+func (jptm *jobPartTransferMgr) IsAppendOnly() bool {
+	return jptm.jobPartMgr.IsAppendOnly()
+}
+
+func (jptm *jobPartTransferMgr) IsTransactional() bool {
+	return jptm.jobPartMgr.IsTransactional()
+}
+
+// RecordDestinationCreated notes in the plan that the destination did not exist before this transfer,
+// so that, if the job has to be rolled back, deleting the destination loses nothing that was there before
+func (jptm *jobPartTransferMgr) RecordDestinationCreated() {
+	jptm.jobPartPlanTransfer.DstCreated = true
+}
+
+func (jptm *jobPartTransferMgr) ShouldSkipLocked() bool {
+	return jptm.jobPartMgr.GetSkipLocked()
+}
+
+// TryClaimLockedRetry returns true if the transfer should be re-queued because its source was locked.
+// It only returns true once per transfer, so that the second encounter with a locked source results in a skip.
+func (jptm *jobPartTransferMgr) TryClaimLockedRetry() bool {
+	if !jptm.jobPartMgr.GetRetryLocked() {
+		return false
+	}
+	return atomic.CompareAndSwapUint32(&jptm.atomicLockedRetryIndicator, 0, 1)
+}
+
 func (jptm *jobPartTransferMgr) ShouldDecompress() bool {
 	if jptm.jobPartMgr.AutoDecompress() {
 		ct, _ := jptm.GetSourceCompressionType()
@@ -299,7 +401,10 @@ func (jptm *jobPartTransferMgr) Info() TransferInfo {
 	// If the blockSize is 0, then User didn't provide any blockSize
 	// We need to set the blockSize in such way that number of blocks per blob
 	// does not exceeds 50000 (max number of block per blob)
-	if blockSize == 0 {
+	sizedForMaxBlocks := blockSize == 0 && dstBlobData.MaxBlocks > 0
+	if sizedForMaxBlocks {
+		blockSize = blockSizeForMaxBlocks(sourceSize, dstBlobData.MaxBlocks)
+	} else if blockSize == 0 {
 		blockSize = common.DefaultBlockBlobBlockSize
 		for ; uint32(sourceSize/blockSize) > common.MaxNumberOfBlocksPerBlob; blockSize = 2 * blockSize {
 			if blockSize > common.BlockSizeThreshold {
@@ -313,7 +418,8 @@ func (jptm *jobPartTransferMgr) Info() TransferInfo {
 			}
 		}
 	}
-	blockSize = common.Iffint64(blockSize > common.MaxBlockBlobBlockSize, common.MaxBlockBlobBlockSize, blockSize)
+	clampedBlockSize := blockSize > common.MaxBlockBlobBlockSize
+	blockSize = common.Iffint64(clampedBlockSize, common.MaxBlockBlobBlockSize, blockSize)
 
 	var srcBlobTags common.BlobTags
 	if blobTags != nil {
@@ -333,6 +439,11 @@ func (jptm *jobPartTransferMgr) Info() TransferInfo {
 		EntityType:                     entityType,
 		PreserveSMBPermissions:         plan.PreserveSMBPermissions,
 		PreserveSMBInfo:                plan.PreserveSMBInfo,
+		PreserveFileAttributes:         plan.PreserveFileAttributes,
+		SetReadOnly:                    plan.SetReadOnly,
+		SkipSMBInfo:                    plan.SkipSMBInfo,
+		PreservePOSIX:                  plan.PreservePOSIX,
+		FileMode:                       plan.FileMode,
 		S2SGetPropertiesInBackend:      s2sGetPropertiesInBackend,
 		S2SSourceChangeValidation:      s2sSourceChangeValidation,
 		S2SInvalidMetadataHandleOption: s2sInvalidMetadataHandleOption,
@@ -346,6 +457,15 @@ func (jptm *jobPartTransferMgr) Info() TransferInfo {
 		S2SSrcBlobTier: srcBlobTier,
 	}
 
+	if sizedForMaxBlocks && clampedBlockSize {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, fmt.Sprintf("The file is too big to fit in %d blocks, since the largest block the service accepts is %d MiB, so it will be uploaded in more blocks than max-blocks allows.",
+			dstBlobData.MaxBlocks, common.MaxBlockBlobBlockSize/(1024*1024)))
+	} else if sizedForMaxBlocks {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Using a block size of %d MiB, so that the file fits in %d blocks.", blockSize/(1024*1024), dstBlobData.MaxBlocks))
+	} else if clampedBlockSize {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, fmt.Sprintf("The block size was reduced to %d MiB, the largest block that the service accepts.", common.MaxBlockBlobBlockSize/(1024*1024)))
+	}
+
 	return *jptm.transferInfo
 }
 
@@ -361,6 +481,15 @@ func (jptm *jobPartTransferMgr) CacheLimiter() common.CacheLimiter {
 	return jptm.jobPartMgr.CacheLimiter()
 }
 
+func (jptm *jobPartTransferMgr) DiskReadPacer() pacer {
+	return jptm.jobPartMgr.(*jobPartMgr).diskReadPacer
+}
+
+// ReadAhead returns how local files are read ahead of their chunks being scheduled, or nil if they aren't
+func (jptm *jobPartTransferMgr) ReadAhead() *readAhead {
+	return jptm.jobPartMgr.(*jobPartMgr).readAhead
+}
+
 func (jptm *jobPartTransferMgr) FileCountLimiter() common.CacheLimiter {
 	return jptm.jobPartMgr.FileCountLimiter()
 }
@@ -438,11 +567,43 @@ func (jptm *jobPartTransferMgr) RescheduleTransfer() {
 }
 
 func (jptm *jobPartTransferMgr) ScheduleChunks(chunkFunc chunkFunc) {
-	jptm.jobPartMgr.ScheduleChunks(chunkFunc)
+	jptm.jobPartMgr.ScheduleChunks(jptm, chunkFunc)
 }
 
 func (jptm *jobPartTransferMgr) ResourceDstData(dataFileToXfer []byte) (headers common.ResourceHTTPHeaders, metadata common.Metadata, blobTags common.BlobTags) {
-	return jptm.jobPartMgr.(*jobPartMgr).resourceDstData(jptm.Info().Source, dataFileToXfer)
+	info := jptm.Info()
+	headers, metadata, blobTags = jptm.jobPartMgr.(*jobPartMgr).resourceDstData(info.Source, dataFileToXfer)
+
+	// local files have no properties of their own, so any the front end recorded for this transfer come from the metadata rules,
+	// and they win over the ones for the whole job part (the front end has already left out those given explicitly as flags)
+	fromTo := jptm.FromTo()
+	if fromTo.From() == common.ELocation.Local() {
+		if info.SrcHTTPHeaders.ContentType != "" {
+			headers.ContentType = info.SrcHTTPHeaders.ContentType
+		}
+		if info.SrcHTTPHeaders.CacheControl != "" {
+			headers.CacheControl = info.SrcHTTPHeaders.CacheControl
+		}
+		if info.SrcHTTPHeaders.ContentEncoding != "" {
+			headers.ContentEncoding = info.SrcHTTPHeaders.ContentEncoding
+		}
+		if info.SrcHTTPHeaders.ContentLanguage != "" {
+			headers.ContentLanguage = info.SrcHTTPHeaders.ContentLanguage
+		}
+
+		// likewise, any metadata recorded for the transfer comes from the metadata manifest, and goes on top of the job part's
+		if len(info.SrcMetadata) > 0 {
+			merged := make(common.Metadata, len(metadata)+len(info.SrcMetadata)) // a copy, so as not to modify the metadata shared by the other transfers
+			for k, v := range metadata {
+				merged[k] = v
+			}
+			for k, v := range info.SrcMetadata {
+				merged[k] = v
+			}
+			metadata = merged
+		}
+	}
+	return
 }
 
 // TODO refactor into something like jptm.IsLastModifiedTimeEqual() so that there is NO LastModifiedTime method and people therefore CAN'T do it wrong due to time zone
@@ -472,6 +633,82 @@ func (jptm *jobPartTransferMgr) DeleteSnapshotsOption() common.DeleteSnapshotsOp
 	return jptm.jobPartMgr.(*jobPartMgr).deleteSnapshotsOption()
 }
 
+func (jptm *jobPartTransferMgr) ShouldBatchDelete() bool {
+	return jptm.jobPartMgr.(*jobPartMgr).batchDelete()
+}
+
+func (jptm *jobPartTransferMgr) BlobExpiry() (option common.BlobExpiryOption, expiryTime int64) {
+	return jptm.jobPartMgr.(*jobPartMgr).blobExpiry()
+}
+
+// PreserveImmutability says whether each blob copied gets the immutability policy and legal hold of its source
+func (jptm *jobPartTransferMgr) PreserveImmutability() bool {
+	return jptm.jobPartMgr.(*jobPartMgr).preserveImmutability()
+}
+
+// ShouldComputeSha256 says whether the SHA-256 of the file is computed, as its data is read for upload or saved on download
+func (jptm *jobPartTransferMgr) ShouldComputeSha256() bool {
+	return jptm.jobPartMgr.(*jobPartMgr).computeSha256()
+}
+
+// RecordSha256 keeps the SHA-256 of the file, once all of its data has been read or saved, for the manifest
+func (jptm *jobPartTransferMgr) RecordSha256(sum []byte) {
+	jptm.jobPartMgr.(*jobPartMgr).recordSha256(jptm.transferIndex, sum)
+}
+
+func (jptm *jobPartTransferMgr) EncryptionVerification() (verify bool, expectedScope string) {
+	return jptm.jobPartMgr.(*jobPartMgr).encryptionVerification()
+}
+
+// PageBlobSequenceNumber returns the sequence number that page blobs are created with
+func (jptm *jobPartTransferMgr) PageBlobSequenceNumber() int64 {
+	return jptm.jobPartMgr.(*jobPartMgr).pageBlobSequenceNumber()
+}
+
+// BlockIDPrefix returns the prefix of the IDs of the blocks staged, or "" for AzCopy's usual block IDs
+func (jptm *jobPartTransferMgr) BlockIDPrefix() string {
+	return jptm.jobPartMgr.(*jobPartMgr).blockIDPrefix()
+}
+
+// BlockBlobTierOverride returns the tier that the user chose for block blobs, whether or not AzCopy knows of it, or AccessTierNone if they didn't choose one
+func (jptm *jobPartTransferMgr) BlockBlobTierOverride() azblob.AccessTierType {
+	if name := jptm.jobPartMgr.(*jobPartMgr).blockBlobTierName(); name != "" {
+		return azblob.AccessTierType(name)
+	}
+	if blockBlobTier, _ := jptm.BlobTiers(); blockBlobTier != common.EBlockBlobTier.None() {
+		return blockBlobTier.ToAccessTierType()
+	}
+	return azblob.AccessTierNone
+}
+
+// TierByAccessTime returns the rules that choose the tier of each block blob by when its source was last accessed, or nil if there are none
+func (jptm *jobPartTransferMgr) TierByAccessTime() AccessTimeTiers {
+	return jptm.jobPartMgr.(*jobPartMgr).tierByAccessTime()
+}
+
+// IsHighPriority says whether the transfer's queued work is picked up ahead of that of transfers that don't have high priority
+func (jptm *jobPartTransferMgr) IsHighPriority() bool {
+	return jptm.jobPartPlanTransfer.Priority == common.EJobPriority.High()
+}
+
+func (jptm *jobPartTransferMgr) ShouldDeltaUpdate() bool {
+	return jptm.jobPartMgr.(*jobPartMgr).deltaUpdate()
+}
+
+// IsCheckpointing returns true if the progress of this transfer is being recorded in the plan
+func (jptm *jobPartTransferMgr) IsCheckpointing() bool {
+	return jptm.checkpoint != nil
+}
+
+// CheckpointedBytes returns the length of the leading part of the source that a previous run of the job
+// had recorded as sent, when it last checkpointed
+func (jptm *jobPartTransferMgr) CheckpointedBytes() int64 {
+	if jptm.checkpoint == nil {
+		return 0
+	}
+	return jptm.checkpoint.resumeFrom
+}
+
 func (jptm *jobPartTransferMgr) BlobTypeOverride() common.BlobType {
 	return jptm.jobPartMgr.BlobTypeOverride()
 }
@@ -512,6 +749,9 @@ func (jptm *jobPartTransferMgr) ReportChunkDone(id common.ChunkID) (lastChunk bo
 	if jptm.IsLive() {
 		atomic.AddInt64(&jptm.atomicSuccessfulBytes, id.Length())
 		JobsAdmin.AddSuccessfulBytesInActiveFiles(id.Length())
+		if jptm.checkpoint != nil {
+			jptm.jobPartPlanTransfer.SetCheckpointedBytes(jptm.checkpoint.chunkSent(id.OffsetInFile(), id.Length()))
+		}
 	}
 
 	// Do our actual processing
@@ -752,9 +992,20 @@ func (jptm *jobPartTransferMgr) PipelineLogInfo() pipeline.LogOptions {
 	return jptm.jobPartMgr.(*jobPartMgr).jobMgr.(*jobMgr).PipelineLogInfo()
 }
 
+// TransferID identifies the transfer as the log does, e.g. P#0-T#12, so other outputs (like the chunk log) can be cross-referenced with it
+func (jptm *jobPartTransferMgr) TransferID() string {
+	return fmt.Sprintf("P#%d-T#%d", jptm.jobPartMgr.Plan().PartNum, jptm.transferIndex)
+}
+
 func (jptm *jobPartTransferMgr) Log(level pipeline.LogLevel, msg string) {
 	plan := jptm.jobPartMgr.Plan()
-	jptm.jobPartMgr.Log(level, fmt.Sprintf("%s: [P#%d-T#%d] ", common.LogLevel(level), plan.PartNum, jptm.transferIndex)+msg)
+	if plan.LogFormat == common.ELogFormat.Json() {
+		// the level is a field of its own in JSON entries, and so is the transfer's path
+		src, _, _ := plan.TransferSrcDstStrings(jptm.transferIndex)
+		jptm.jobPartMgr.LogForTransfer(level, src, "["+jptm.TransferID()+"] "+msg)
+		return
+	}
+	jptm.jobPartMgr.Log(level, fmt.Sprintf("%s: [%s] ", common.LogLevel(level), jptm.TransferID())+msg)
 }
 
 func (jptm *jobPartTransferMgr) ErrorCodeAndString(err error) (int, string) {
@@ -862,6 +1113,9 @@ func (jptm *jobPartTransferMgr) ReportTransferDone() uint32 {
 	if atomic.SwapUint32(&jptm.atomicCompletionIndicator, 1) != 0 {
 		panic("cannot report the same transfer done twice")
 	}
+	jptm.endSpan()
+	jptm.SetRehydrating(false) // however it ended, e.g. by cancellation, it's no longer waiting
+	jptm.ReleasePrefixSlot()
 
 	return jptm.jobPartMgr.ReportTransferDone(jptm.jobPartPlanTransfer.TransferStatus())
 }
@@ -0,0 +1,185 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// bindAddress is the local address that connections are made from, when the user asked for a particular network interface.
+// Like requestHeaders, it must be set before any HTTP client is created
+var bindAddress *net.TCPAddr
+
+// SetBindInterface makes connections go out through the given network interface, which is named either by its name (e.g. eth1)
+// or by one of its IP addresses. It must be called before any HTTP client is created.
+func SetBindInterface(nameOrIP string) error {
+	ip, err := resolveBindInterface(nameOrIP)
+	if err != nil {
+		return fmt.Errorf("cannot use the network interface %q: %w", nameOrIP, err)
+	}
+	bindAddress = &net.TCPAddr{IP: ip}
+	return nil
+}
+
+// resolveBindInterface returns the IP address to bind to, for an interface given by name or by address
+func resolveBindInterface(nameOrIP string) (net.IP, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("the network interfaces could not be listed: %w", err)
+	}
+
+	if ip := net.ParseIP(nameOrIP); ip != nil {
+		for _, i := range interfaces {
+			for _, a := range interfaceIPs(i) {
+				if a.Equal(ip) {
+					if i.Flags&net.FlagUp == 0 {
+						return nil, fmt.Errorf("the interface that has this address, %s, is down", i.Name)
+					}
+					return ip, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("no network interface on this machine has this address")
+	}
+
+	for _, i := range interfaces {
+		if i.Name != nameOrIP {
+			continue
+		}
+		if i.Flags&net.FlagUp == 0 {
+			return nil, fmt.Errorf("the interface is down")
+		}
+		ips := interfaceIPs(i)
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("the interface has no IP address")
+		}
+		for _, ip := range ips {
+			if ip.To4() != nil {
+				return ip, nil // prefer IPv4, since that's what storage endpoints are most often reached over
+			}
+		}
+		return ips[0], nil
+	}
+	return nil, fmt.Errorf("there is no network interface with this name")
+}
+
+// interfaceIPs returns the addresses of the interface that can be bound to. Link-local IPv6 addresses are left out,
+// since they can't reach the service
+func interfaceIPs(i net.Interface) []net.IP {
+	addrs, err := i.Addrs()
+	if err != nil {
+		return nil
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLinkLocalUnicast() {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips
+}
+
+// interfaceTraffic counts the bytes sent and received from each local address, so that the summary can say which
+// network interfaces the job's traffic used
+type interfaceTraffic struct {
+	mu     sync.Mutex
+	counts map[string]*int64 // by local IP address
+}
+
+var networkTraffic = newInterfaceTraffic()
+
+func newInterfaceTraffic() *interfaceTraffic {
+	return &interfaceTraffic{counts: make(map[string]*int64)}
+}
+
+// track returns a connection that counts the bytes that pass through it against its local address
+func (t *interfaceTraffic) track(conn net.Conn) net.Conn {
+	addr, ok := conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		return conn
+	}
+	key := addr.IP.String()
+
+	t.mu.Lock()
+	count, ok := t.counts[key]
+	if !ok {
+		count = new(int64)
+		t.counts[key] = count
+	}
+	t.mu.Unlock()
+
+	return &countingConn{Conn: conn, atomicCount: count}
+}
+
+// bytes returns the bytes counted for each local address, with the name of the interface that has it (where that's still known), in name order
+func (t *interfaceTraffic) bytes() []common.InterfaceBytes {
+	names := make(map[string]string)
+	if interfaces, err := net.Interfaces(); err == nil {
+		for _, i := range interfaces {
+			for _, ip := range interfaceIPs(i) {
+				names[ip.String()] = i.Name
+			}
+		}
+	}
+
+	t.mu.Lock()
+	result := make([]common.InterfaceBytes, 0, len(t.counts))
+	for ip, count := range t.counts {
+		result = append(result, common.InterfaceBytes{Interface: names[ip], Address: ip, Bytes: uint64(atomic.LoadInt64(count))})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Interface != result[j].Interface {
+			return result[i].Interface < result[j].Interface
+		}
+		return result[i].Address < result[j].Address
+	})
+	return result
+}
+
+// NetworkInterfaceBytes returns how many bytes have been sent and received through each network interface, by this process
+func NetworkInterfaceBytes() []common.InterfaceBytes {
+	return networkTraffic.bytes()
+}
+
+type countingConn struct {
+	net.Conn
+	atomicCount *int64
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(c.atomicCount, int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(c.atomicCount, int64(n))
+	return n, err
+}
@@ -22,8 +22,13 @@ package ste
 
 import (
 	"fmt"
+	"net/url"
+	"reflect"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/JeffreyRichter/enum/enum"
 
 	"github.com/Azure/azure-storage-azcopy/common"
 )
@@ -115,3 +120,82 @@ func newOverwritePrompter() *overwritePrompter {
 		},
 	}
 }
+
+var EOverwriteDecision = OverwriteDecision(0)
+
+// OverwriteDecision is the answer that an OverwriteDecider gives for a file that already exists at the destination
+type OverwriteDecision uint8
+
+// Prompt leaves the decision to the usual overwrite prompt
+func (OverwriteDecision) Prompt() OverwriteDecision    { return OverwriteDecision(0) }
+func (OverwriteDecision) Overwrite() OverwriteDecision { return OverwriteDecision(1) }
+func (OverwriteDecision) Skip() OverwriteDecision      { return OverwriteDecision(2) }
+
+func (d OverwriteDecision) String() string {
+	return enum.StringInt(d, reflect.TypeOf(d))
+}
+
+// OverwriteCandidate describes the source, or the existing destination, of a file that may be overwritten.
+// Remote paths have no query string, so the decider never sees a SAS token.
+type OverwriteCandidate struct {
+	Path             string
+	Size             int64 // -1 if it could not be read
+	LastModifiedTime time.Time
+}
+
+// OverwriteDecider decides what to do about a single file that already exists at the destination.
+// It is called from many goroutines at once, so must be safe for concurrent use.
+type OverwriteDecider func(src, dst OverwriteCandidate) OverwriteDecision
+
+var overwriteDecider OverwriteDecider
+
+// SetOverwriteDecider lets an application that embeds AzCopy answer overwrite prompts itself, one file at a time.
+// The decider is only consulted when the overwrite option is prompt. It must be called before any job is started.
+func SetOverwriteDecider(d OverwriteDecider) {
+	overwriteDecider = d
+}
+
+// shouldOverwriteFile is used, when the overwrite option is prompt, to decide whether a file that exists at the destination
+// is overwritten. If there's a decider, it is asked first, and the user is only prompted if it says so.
+// dstLength is only called if there's a decider, since for some destinations it costs a request.
+func shouldOverwriteFile(jptm IJobPartTransferMgr, dstPath string, dstLmt time.Time, dstLength func() (int64, error)) bool {
+	if overwriteDecider != nil {
+		info := jptm.Info()
+		src := OverwriteCandidate{Path: info.Source, Size: info.SourceSize, LastModifiedTime: jptm.LastModifiedTime()}
+		if fromTo := jptm.FromTo(); fromTo.From().IsRemote() {
+			src.Path = withoutQuery(src.Path)
+		}
+		dst := OverwriteCandidate{Path: dstPath, Size: -1, LastModifiedTime: dstLmt}
+		if length, err := dstLength(); err == nil {
+			dst.Size = length
+		}
+
+		if shouldOverwrite, decided := askOverwriteDecider(overwriteDecider, src, dst); decided {
+			return shouldOverwrite
+		}
+	}
+
+	return jptm.GetOverwritePrompter().ShouldOverwrite(dstPath, common.EEntityType.File())
+}
+
+// askOverwriteDecider returns the decider's answer, with decided false if it wants the user to be prompted instead
+func askOverwriteDecider(decider OverwriteDecider, src, dst OverwriteCandidate) (shouldOverwrite bool, decided bool) {
+	switch decider(src, dst) {
+	case EOverwriteDecision.Overwrite():
+		return true, true
+	case EOverwriteDecision.Skip():
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// withoutQuery removes the query string, and so any SAS, from a URL
+func withoutQuery(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsed.RawQuery = ""
+	return parsed.String()
+}
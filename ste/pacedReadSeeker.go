@@ -23,6 +23,8 @@ package ste
 import (
 	"context"
 	"io"
+
+	"github.com/Azure/azure-storage-azcopy/common"
 )
 
 // pacedReadSeeker implements read/seek/close with pacing. (Formerly in file pacer-lite)
@@ -83,3 +85,62 @@ func (prs *pacedReadSeeker) Close() error {
 	}
 	return nil
 }
+
+// pacedDiskReadSize is the most that a pacedReaderAt asks the disk read pacer for at a time.
+// The pacer is made to hold at least this much, however low its cap, so that every piece can be allocated
+const pacedDiskReadSize = 1024 * 1024
+
+// pacedReaderAt paces the reads from a local source file, so that the rate at which we read from disk can be capped
+type pacedReaderAt struct {
+	ctx  context.Context
+	file common.CloseableReaderAt
+	p    pacer
+}
+
+// newPacedSourceFileFactory returns a factory that wraps each file opened by the given factory in a pacedReaderAt
+func newPacedSourceFileFactory(ctx context.Context, factory common.ChunkReaderSourceFactory, p pacer) common.ChunkReaderSourceFactory {
+	if p == nil {
+		panic("p must not be nil")
+	}
+	return func() (common.CloseableReaderAt, error) {
+		file, err := factory()
+		if err != nil {
+			return nil, err // returned as-is, since callers check it for locked files
+		}
+		return &pacedReaderAt{ctx: ctx, file: file, p: p}, nil
+	}
+}
+
+func (pra *pacedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+
+	// a whole chunk can be more than the pacer's bucket ever holds at a low cap, so it's read a piece at a time
+	for total < len(p) {
+		piece := p[total:]
+		if len(piece) > pacedDiskReadSize {
+			piece = piece[:pacedDiskReadSize]
+		}
+
+		// blocks until we are allowed to read the bytes
+		err := pra.p.RequestTrafficAllocation(pra.ctx, int64(len(piece)))
+		if err != nil {
+			return total, err
+		}
+
+		n, err := pra.file.ReadAt(piece, off+int64(total))
+		total += n
+
+		// "return" any unused tokens to the pacer (e.g. if we hit eof before the end of the piece)
+		pra.p.UndoRequest(int64(len(piece) - n))
+
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+func (pra *pacedReaderAt) Close() error {
+	return pra.file.Close()
+}
@@ -59,25 +59,39 @@ const (
 
 	// Controls the max amount by which the contents of the token bucket can build up, unused.
 	maxSecondsToOverpopulateBucket = 2.5 // had 5, when doing coarse-grained pacing. TODO: find best all-round value, or parameterize
+
+	// The fraction of the target rate that a pacer with a ramp up starts at
+	rampUpBaselineFraction = 0.1
 )
 
 // tokenBucketPacer allows us to control the pace of an activity, using a basic token bucket algorithm.
-// The target rate is fixed, but can be modified at any time through SetTargetBytesPerSecond
+// The target rate is fixed, but can be modified at any time through SetTargetBytesPerSecond.
+// If it has a ramp up, the rate actually allowed starts at a fraction of the target, and rises linearly to it
+// over the ramp up, timed from the first request
 type tokenBucketPacer struct {
 	atomicTokenBucket          int64
 	atomicTargetBytesPerSecond int64
 	atomicGrandTotal           int64
 	atomicWaitCount            int64
+	atomicRampStartNanos       int64 // when the first request was made, if there's a ramp up. Zero until then
 	expectedBytesPerRequest    int64
+	rampUp                     time.Duration
 	done                       chan struct{}
 }
 
 func newTokenBucketPacer(bytesPerSecond int64, expectedBytesPerCoarseRequest int64) *tokenBucketPacer {
-	p := &tokenBucketPacer{atomicTokenBucket: bytesPerSecond / 4, // seed it immediately with part-of-a-second's worth, to avoid a sluggish start
+	return newRampedTokenBucketPacer(bytesPerSecond, expectedBytesPerCoarseRequest, 0)
+}
+
+// newRampedTokenBucketPacer makes a pacer whose rate rises to bytesPerSecond over rampUp, starting from its first request
+func newRampedTokenBucketPacer(bytesPerSecond int64, expectedBytesPerCoarseRequest int64, rampUp time.Duration) *tokenBucketPacer {
+	p := &tokenBucketPacer{
 		atomicTargetBytesPerSecond: bytesPerSecond,
 		expectedBytesPerRequest:    int64(expectedBytesPerCoarseRequest),
+		rampUp:                     rampUp,
 		done:                       make(chan struct{}),
 	}
+	p.atomicTokenBucket = p.effectiveBytesPerSecond(time.Now()) / 4 // seed it immediately with part-of-a-second's worth, to avoid a sluggish start
 
 	go p.pacerBody()
 
@@ -88,6 +102,11 @@ func newTokenBucketPacer(bytesPerSecond int64, expectedBytesPerCoarseRequest int
 // It controls their rate by blocking until they are allowed to proceed
 func (p *tokenBucketPacer) RequestTrafficAllocation(ctx context.Context, byteCount int64) error {
 
+	// the ramp up starts with the first request, rather than when the pacer is made, since listing may take a while before then
+	if p.rampUp > 0 && atomic.LoadInt64(&p.atomicRampStartNanos) == 0 {
+		atomic.CompareAndSwapInt64(&p.atomicRampStartNanos, 0, time.Now().UnixNano())
+	}
+
 	// block until tokens are available
 	for atomic.AddInt64(&p.atomicTokenBucket, -byteCount) < 0 {
 
@@ -137,7 +156,7 @@ func (p *tokenBucketPacer) pacerBody() {
 		default:
 		}
 
-		currentTarget := atomic.LoadInt64(&p.atomicTargetBytesPerSecond)
+		currentTarget := p.effectiveBytesPerSecond(time.Now())
 		time.Sleep(bucketFillSleepDuration)
 		elapsedSeconds := time.Since(lastTime).Seconds()
 		bytesToRelease := int64(float64(currentTarget) * elapsedSeconds)
@@ -167,6 +186,28 @@ func (p *tokenBucketPacer) targetBytesPerSecond() int64 {
 	return atomic.LoadInt64(&p.atomicTargetBytesPerSecond)
 }
 
+// effectiveBytesPerSecond is the rate allowed at the given time, which is the target rate unless it's still being ramped up to
+func (p *tokenBucketPacer) effectiveBytesPerSecond(now time.Time) int64 {
+	target := atomic.LoadInt64(&p.atomicTargetBytesPerSecond)
+	if p.rampUp <= 0 {
+		return target
+	}
+
+	var elapsed time.Duration
+	if start := atomic.LoadInt64(&p.atomicRampStartNanos); start != 0 {
+		elapsed = now.Sub(time.Unix(0, start))
+	}
+	if elapsed >= p.rampUp {
+		return target
+	}
+
+	baseline := int64(float64(target) * rampUpBaselineFraction)
+	if baseline < 1 {
+		baseline = 1
+	}
+	return baseline + int64(float64(target-baseline)*elapsed.Seconds()/p.rampUp.Seconds())
+}
+
 func (p *tokenBucketPacer) setTargetBytesPerSecond(value int64) {
 	atomic.StoreInt64(&p.atomicTargetBytesPerSecond, value)
 }
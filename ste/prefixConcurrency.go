@@ -0,0 +1,231 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// ParsePrefixConcurrency parses limits on the transfers in flight under each destination prefix, such as logs/=10,data/=100.
+// A prefix is the start of the names of the blobs or files in their container or share
+func ParsePrefixConcurrency(s string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, rule := range strings.Split(s, ",") {
+		i := strings.LastIndex(rule, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid rule %q: each rule is a prefix and the most transfers in flight under it, e.g. logs/=10", rule)
+		}
+		prefix := strings.TrimPrefix(strings.TrimSpace(rule[:i]), "/")
+		limit, err := strconv.Atoi(strings.TrimSpace(rule[i+1:]))
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid limit %q for the prefix %q: a limit is a whole number of transfers, at least 1", rule[i+1:], prefix)
+		}
+		if _, ok := limits[prefix]; ok {
+			return nil, fmt.Errorf("the prefix %q is given more than one limit", prefix)
+		}
+		limits[prefix] = limit
+	}
+	return limits, nil
+}
+
+// FormatPrefixConcurrency gives the limits in the form that ParsePrefixConcurrency parses, with the prefixes in order
+func FormatPrefixConcurrency(limits map[string]int) string {
+	rules := make([]string, 0, len(limits))
+	for prefix, limit := range limits {
+		rules = append(rules, prefix+"="+strconv.Itoa(limit))
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, ",")
+}
+
+// prefixLimiter bounds the transfers in flight under each of the job's destination prefixes, so that prefixes that map to
+// different storage partitions are paced independently, and one that's throttled doesn't hold back the rest.
+// A transfer that would exceed the limit of its prefix isn't started, and doesn't hold a worker while it waits. It's handed
+// the slot of the next of the prefix's transfers to be done
+type prefixLimiter struct {
+	mu     sync.Mutex
+	limits []*prefixLimit // longest prefix first, so that the most specific prefix that matches applies
+}
+
+type prefixLimit struct {
+	prefix   string
+	limit    int
+	inFlight int
+	waiting  []func() // each starts a transfer that was handed a slot, in the order they came
+}
+
+func newPrefixLimiter(limits map[string]int) *prefixLimiter {
+	l := &prefixLimiter{}
+	for prefix, limit := range limits {
+		l.limits = append(l.limits, &prefixLimit{prefix: prefix, limit: limit})
+	}
+	sort.Slice(l.limits, func(i, j int) bool {
+		if len(l.limits[i].prefix) != len(l.limits[j].prefix) {
+			return len(l.limits[i].prefix) > len(l.limits[j].prefix)
+		}
+		return l.limits[i].prefix < l.limits[j].prefix
+	})
+	return l
+}
+
+func (l *prefixLimiter) limitFor(name string) *prefixLimit {
+	for _, p := range l.limits {
+		if strings.HasPrefix(name, p.prefix) {
+			return p
+		}
+	}
+	return nil
+}
+
+// tryStart takes a slot for a transfer of the named blob or file, if its prefix has one free, and says whether it did.
+// If it didn't, start is called once the transfer has been handed a slot
+func (l *prefixLimiter) tryStart(name string, start func()) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := l.limitFor(name)
+	if p == nil {
+		return true
+	}
+	if p.inFlight < p.limit {
+		p.inFlight++
+		return true
+	}
+	p.waiting = append(p.waiting, start)
+	return false
+}
+
+// done frees the slot of a transfer of the named blob or file, handing it to the transfer that has waited longest, if any,
+// and returns what starts that transfer
+func (l *prefixLimiter) done(name string) (start func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := l.limitFor(name)
+	if p == nil {
+		return nil
+	}
+	if len(p.waiting) > 0 {
+		start, p.waiting = p.waiting[0], p.waiting[1:]
+		return start // the slot stays in use
+	}
+	p.inFlight--
+	return nil
+}
+
+func (l *prefixLimiter) transfersInFlight() []common.PrefixTransfersInFlight {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]common.PrefixTransfersInFlight, 0, len(l.limits))
+	for _, p := range l.limits {
+		result = append(result, common.PrefixTransfersInFlight{Prefix: p.prefix, Limit: p.limit, InFlight: p.inFlight, Waiting: len(p.waiting)})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Prefix < result[j].Prefix })
+	return result
+}
+
+// setPrefixConcurrency sets the limits on the transfers in flight under each destination prefix. It must be called before
+// any of the job's transfers are scheduled
+func (jm *jobMgr) setPrefixConcurrency(limits map[string]int) {
+	jm.prefixLimiter.Store(newPrefixLimiter(limits))
+}
+
+// restorePrefixConcurrency sets the limits kept in the plan of the job's first part, when the job is resumed,
+// unless it still has the limiter it was run with
+func (jm *jobMgr) restorePrefixConcurrency(plan *JobPartPlanHeader) {
+	if plan.PrefixConcurrencyLength == 0 || jm.prefixConcurrency() != nil {
+		return
+	}
+	limits, err := ParsePrefixConcurrency(string(plan.PrefixConcurrency[:plan.PrefixConcurrencyLength]))
+	if err != nil {
+		return // the limits were checked before the job was created
+	}
+	jm.setPrefixConcurrency(limits)
+}
+
+func (jm *jobMgr) prefixConcurrency() *prefixLimiter {
+	l, _ := jm.prefixLimiter.Load().(*prefixLimiter)
+	return l
+}
+
+// TransfersInFlightByPrefix is how many transfers are in flight, and how many are waiting, under each prefix that the job limits.
+// Nil if it has no such limits
+func (jm *jobMgr) TransfersInFlightByPrefix() []common.PrefixTransfersInFlight {
+	if l := jm.prefixConcurrency(); l != nil {
+		return l.transfersInFlight()
+	}
+	return nil
+}
+
+// TakePrefixSlot says whether the transfer may start now, given the job's limits on the transfers in flight under each
+// destination prefix. If it may not, it mustn't be started: it's rescheduled once it's handed the slot of another transfer
+func (jptm *jobPartTransferMgr) TakePrefixSlot() bool {
+	if atomic.LoadUint32(&jptm.atomicPrefixSlotIndicator) == 1 {
+		return true // it was handed a slot, and rescheduled
+	}
+	limiter := jptm.jobPartMgr.(*jobPartMgr).jobMgr.prefixConcurrency()
+	if fromTo := jptm.FromTo(); limiter == nil || !fromTo.To().IsRemote() {
+		return true
+	}
+
+	started := limiter.tryStart(jptm.destinationNameInContainer(), func() {
+		atomic.StoreUint32(&jptm.atomicPrefixSlotIndicator, 1)
+		// from a goroutine of its own, since this is called as another transfer is done, maybe by a worker that the
+		// transfer channels need, to have room for it
+		go jptm.RescheduleTransfer()
+	})
+	if started {
+		atomic.StoreUint32(&jptm.atomicPrefixSlotIndicator, 1)
+	}
+	return started
+}
+
+// ReleasePrefixSlot frees the slot that the transfer took under its destination prefix, if it took one.
+// A transfer that's put back in the queue to wait, rather than done, takes a slot again when it's next started
+func (jptm *jobPartTransferMgr) ReleasePrefixSlot() {
+	if atomic.SwapUint32(&jptm.atomicPrefixSlotIndicator, 0) == 0 {
+		return
+	}
+	if start := jptm.jobPartMgr.(*jobPartMgr).jobMgr.prefixConcurrency().done(jptm.destinationNameInContainer()); start != nil {
+		start()
+	}
+}
+
+// destinationNameInContainer is the name of the destination blob or file in its container or share, which is what
+// prefix limits are matched against
+func (jptm *jobPartTransferMgr) destinationNameInContainer() string {
+	u, err := url.Parse(jptm.Info().Destination)
+	if err != nil {
+		return ""
+	}
+	return azblob.NewBlobURLParts(*u).BlobName
+}
@@ -0,0 +1,156 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// readAhead, when set, has uploads read the chunks of each local file from disk ahead of scheduling them, up to a window of several chunks.
+// Otherwise each chunk is read just before it's scheduled, one after the other, which for one or a few huge files on a fast link
+// can leave the network waiting on the disk. It's shared by all transfers, so that how full their windows are can be reported.
+type readAhead struct {
+	windowBytes int64 // how much of each file may be read ahead of the chunk being scheduled
+
+	atomicChunks      int64 // the chunks in the transfers' windows, whether they're still being read or not
+	atomicReadyChunks int64 // those that have been read, and are waiting to be scheduled
+}
+
+// newReadAhead returns nil, which reads each chunk just before it's scheduled, if windowBytes is zero
+func newReadAhead(windowBytes int64) *readAhead {
+	if windowBytes <= 0 {
+		return nil
+	}
+	return &readAhead{windowBytes: windowBytes}
+}
+
+// perfString reports how many of the chunks in the windows have been read. When they mostly have, the disk is keeping ahead of the network
+func (ra *readAhead) perfString() string {
+	return fmt.Sprintf("RA: %d/%d", atomic.LoadInt64(&ra.atomicReadyChunks), atomic.LoadInt64(&ra.atomicChunks))
+}
+
+// newWindow returns the window that a file's chunks are read through, or nil if there's no read-ahead
+func (ra *readAhead) newWindow(jptm IJobPartTransferMgr, srcPath string, srcSize int64, chunkSize int64,
+	sourceFileFactory common.ChunkReaderSourceFactory, srcFile common.CloseableReaderAt) *readAheadWindow {
+	if ra == nil {
+		return nil
+	}
+	maxChunks := ra.windowBytes / chunkSize
+	if maxChunks < 1 {
+		maxChunks = 1
+	}
+	return &readAheadWindow{ra: ra, jptm: jptm, srcPath: srcPath, srcSize: srcSize, chunkSize: chunkSize,
+		sourceFileFactory: sourceFileFactory, srcFile: srcFile, maxChunks: int(maxChunks)}
+}
+
+// readAheadWindow reads the chunks of one file, several at a time, and hands them out in file order,
+// so that the file's MD5 hash can still be computed as they're scheduled
+type readAheadWindow struct {
+	ra                *readAhead
+	jptm              IJobPartTransferMgr
+	srcPath           string
+	srcSize           int64
+	chunkSize         int64
+	sourceFileFactory common.ChunkReaderSourceFactory
+	srcFile           common.CloseableReaderAt
+	maxChunks         int
+
+	nextStart int64             // where the next chunk to be read starts
+	pending   []*readAheadChunk // the chunks being read, or read, but not yet handed out. Oldest first
+	lastRead  chan struct{}     // closed once the last chunk started has been read
+}
+
+type readAheadChunk struct {
+	id     common.ChunkID
+	reader common.SingleChunkReader
+	done   chan error // receives the result of the read
+}
+
+// next starts reading as many of the following chunks as fit in the window, then returns the next chunk once it has been read.
+// The chunk must be used under the ID returned with it
+func (w *readAheadWindow) next() (common.ChunkID, common.SingleChunkReader, error) {
+	for len(w.pending) < w.maxChunks && w.nextStart < w.srcSize {
+		w.start()
+	}
+	c := w.pending[0]
+	w.pending = w.pending[1:]
+	err := <-c.done
+	atomic.AddInt64(&w.ra.atomicReadyChunks, -1)
+	atomic.AddInt64(&w.ra.atomicChunks, -1)
+	return c.id, c.reader, err
+}
+
+func (w *readAheadWindow) start() {
+	length := w.chunkSize
+	if w.nextStart+length > w.srcSize {
+		length = w.srcSize - w.nextStart
+	}
+	id := common.NewChunkID(w.srcPath, w.jptm.TransferID(), w.nextStart, length)
+	c := &readAheadChunk{id: id, reader: createPopulatedChunkReader(w.jptm, w.sourceFileFactory, id, length, w.srcFile), done: make(chan error, 1)}
+	w.pending = append(w.pending, c)
+	w.nextStart += length
+
+	atomic.AddInt64(&w.ra.atomicChunks, 1)
+	previous, read := w.lastRead, make(chan struct{})
+	w.lastRead = read
+	go func() {
+		// The chunks are read in file order, each waiting for RAM, like any other prefetch, so the read-ahead can't take more than AzCopy is allowed.
+		// Since waiting for RAM isn't first come first served, reading them all at once could let later chunks take the RAM
+		// that the chunk to be handed out next is waiting for, which would then never get it.
+		if previous != nil {
+			<-previous
+		}
+		err := c.reader.BlockingPrefetch(w.srcFile, false)
+		close(read)
+		atomic.AddInt64(&w.ra.atomicReadyChunks, 1)
+		c.done <- err
+	}()
+}
+
+// abandon releases the chunks that have been read ahead, once they're not going to be sent, e.g. because an earlier chunk couldn't be read
+func (w *readAheadWindow) abandon() {
+	if w == nil {
+		return
+	}
+	for _, c := range w.pending {
+		<-c.done
+		atomic.AddInt64(&w.ra.atomicReadyChunks, -1)
+		atomic.AddInt64(&w.ra.atomicChunks, -1)
+		_ = c.reader.Close()
+	}
+	w.pending = nil
+	w.nextStart = w.srcSize
+}
+
+// the read-ahead of each file may take at most this share of the RAM that AzCopy may use, so that the files being read ahead
+// leave room for each other, and for the chunks already scheduled
+const maxReadAheadShareOfRAM = 4
+
+// validateReadAhead checks that the read-ahead window is well within the RAM that AzCopy may use
+func validateReadAhead(windowBytes int64, maxRamBytes int64) error {
+	if windowBytes > maxRamBytes/maxReadAheadShareOfRAM {
+		return fmt.Errorf("read-ahead-mb must be at most a quarter of the RAM that AzCopy may use, which is %.0f MiB. Lower it, or raise %s",
+			float64(maxRamBytes)/(1024*1024), common.EEnvironmentVariable.BufferGB().Name)
+	}
+	return nil
+}
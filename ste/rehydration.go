@@ -0,0 +1,119 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// rehydrationTier is the tier that archived sources are rehydrated to
+const rehydrationTier = azblob.AccessTierHot
+
+// rehydrationPollInterval is how long a transfer waits, while its source is rehydrated, before the source is checked again.
+// Rehydration takes hours, so there is no point in checking often
+var rehydrationPollInterval = 5 * time.Minute
+
+// rehydrateBeforeTransfer wraps a transfer from Blob storage so that, if its source is in the archive tier, the source is rehydrated first.
+// Rehydration is asked for, and the transfer is put back in the queue to check again later, until the blob is online; then the transfer
+// runs as usual. Nothing about the rehydration is kept but the blob's own archive status, so a resumed job carries on where it left off.
+func rehydrateBeforeTransfer(xfer newJobXfer) newJobXfer {
+	return func(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer) {
+		info := jptm.Info()
+		if info.IsFolderPropertiesTransfer() {
+			xfer(jptm, p, pacer)
+			return
+		}
+
+		online, err := rehydrateIfArchived(jptm, p)
+		if err != nil {
+			jptm.LogError(info.Source, "REHYDRATION ", err)
+			jptm.SetStatus(common.ETransferStatus.Failed())
+			jptm.ReportTransferDone()
+			return
+		}
+		if !online {
+			jptm.SetRehydrating(true)
+			// rehydration takes hours, so the slot under the destination prefix goes to a transfer that can use it
+			jptm.ReleasePrefixSlot()
+			go func() {
+				// on cancellation, it's rescheduled at once, to be cancelled like any other transfer
+				select {
+				case <-time.After(rehydrationPollInterval):
+				case <-jptm.Context().Done():
+				}
+				jptm.RescheduleTransfer()
+			}()
+			return
+		}
+
+		jptm.SetRehydrating(false)
+		xfer(jptm, p, pacer)
+	}
+}
+
+// rehydrateIfArchived says whether the transfer's source is online. If it's archived, and not already being rehydrated, rehydration is asked for
+func rehydrateIfArchived(jptm IJobPartTransferMgr, p pipeline.Pipeline) (online bool, err error) {
+	source, err := url.Parse(jptm.Info().Source)
+	if err != nil {
+		return false, err
+	}
+	// a download's pipeline is for its source; otherwise the source has its own
+	if fromTo := jptm.FromTo(); !fromTo.IsDownload() {
+		p = jptm.SourceProviderPipeline()
+	}
+	blobURL := azblob.NewBlobURL(*source, p)
+
+	props, err := blobURL.GetProperties(jptm.Context(), azblob.BlobAccessConditions{})
+	if err != nil {
+		return false, err
+	}
+	if !strings.EqualFold(props.AccessTier(), string(azblob.AccessTierArchive)) {
+		return true, nil
+	}
+	if status := props.ArchiveStatus(); status != "" {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Source is still being rehydrated (%s), so the transfer will wait", status))
+		return false, nil
+	}
+
+	if _, err = blobURL.SetTier(jptm.Context(), rehydrationTier, azblob.LeaseAccessConditions{}); err != nil {
+		return false, fmt.Errorf("cannot rehydrate the archived source: %w", err)
+	}
+	jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Source is archived, so rehydration to the %s tier was asked for. The transfer will start once it's done", rehydrationTier))
+	return false, nil
+}
+
+func (jm *jobMgr) addTransfersRehydrating(delta int32) {
+	atomic.AddInt32(&jm.atomicTransfersRehydrating, delta)
+}
+
+// TransfersRehydrating is how many transfers are waiting for their archived source to be rehydrated
+func (jm *jobMgr) TransfersRehydrating() uint32 {
+	return uint32(atomic.LoadInt32(&jm.atomicTransfersRehydrating))
+}
@@ -0,0 +1,71 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+)
+
+// errRetriesExhausted is the reason given for the transfers that aren't started once the job has used all of its retries
+var errRetriesExhausted = errors.New("the job has used all the retries allowed by max-total-retries, so no more transfers are started")
+
+// retryBudget caps the retries of requests, for each file and for the job as a whole, on top of the MaxTries of the retry policies.
+// It goes by the same counts as the rest of the retry accounting: the retries of each transfer, and their total.
+// A file that has used its retries fails with the error of its last try. Once the job has used its retries, the transfers
+// that are in progress carry on, but no more are started.
+type retryBudget struct {
+	atomicTotal     int64
+	maxTotal        int64 // zero means no cap
+	maxPerFile      int32 // zero means no cap
+	atomicExhausted int32
+}
+
+// newRetryBudget returns nil, which caps nothing, if neither cap is set
+func newRetryBudget(maxPerFile int32, maxTotal int64) *retryBudget {
+	if maxPerFile <= 0 && maxTotal <= 0 {
+		return nil
+	}
+	return &retryBudget{maxPerFile: maxPerFile, maxTotal: maxTotal}
+}
+
+// count adds a retry to the job's total
+func (b *retryBudget) count() {
+	if b == nil {
+		return
+	}
+	total := atomic.AddInt64(&b.atomicTotal, 1)
+	if b.maxTotal > 0 && total >= b.maxTotal && atomic.CompareAndSwapInt32(&b.atomicExhausted, 0, 1) && JobsAdmin != nil {
+		JobsAdmin.LogToJobLog(fmt.Sprintf("The job has used all of its %d retries, so no more transfers will be started", b.maxTotal), pipeline.LogWarning)
+	}
+}
+
+// fileMayRetry says whether a file whose requests have been retried fileRetries times so far may be retried again
+func (b *retryBudget) fileMayRetry(fileRetries int32) bool {
+	return b == nil || b.maxPerFile <= 0 || fileRetries < b.maxPerFile
+}
+
+// exhausted says whether the job has used all of its retries
+func (b *retryBudget) exhausted() bool {
+	return b != nil && atomic.LoadInt32(&b.atomicExhausted) == 1
+}
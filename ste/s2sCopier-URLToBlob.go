@@ -61,7 +61,7 @@ func newURLToBlobCopier(jptm IJobPartTransferMgr, destination string, p pipeline
 
 			fileName := srcURL.Path
 
-			targetBlobType = inferBlobType(fileName, azblob.BlobBlockBlob)
+			targetBlobType = InferBlobType(fileName, azblob.BlobBlockBlob)
 		}
 
 		if targetBlobType != azblob.BlobBlockBlob {
@@ -47,6 +47,10 @@ type appendBlobSenderBase struct {
 	blobTagsToApply azblob.BlobTagsMap
 
 	soleChunkFuncSemaphore *semaphore.Weighted
+
+	// for reading the immutability of the source, and setting it on the destination, when preserving it
+	p               pipeline.Pipeline
+	srcInfoProvider ISourceInfoProvider
 }
 
 type appendBlockFunc = func()
@@ -87,7 +91,9 @@ func newAppendBlobSenderBase(jptm IJobPartTransferMgr, destination string, p pip
 		headersToApply:         props.SrcHTTPHeaders.ToAzBlobHTTPHeaders(),
 		metadataToApply:        props.SrcMetadata.ToAzBlobMetadata(),
 		blobTagsToApply:        props.SrcBlobTags.ToAzBlobTagsMap(),
-		soleChunkFuncSemaphore: semaphore.NewWeighted(1)}, nil
+		soleChunkFuncSemaphore: semaphore.NewWeighted(1),
+		p:                      p,
+		srcInfoProvider:        srcInfoProvider}, nil
 }
 
 func (s *appendBlobSenderBase) SendableEntityType() common.EntityType {
@@ -162,7 +168,13 @@ func (s *appendBlobSenderBase) Prologue(ps common.PrologueState) (destinationMod
 }
 
 func (s *appendBlobSenderBase) Epilogue() {
-	// Empty function because you don't have to commit on an append blob
+	// There's no commit on an append blob. All that's left is to check its encryption, if asked to
+	if s.jptm.IsLive() {
+		verifyBlobEncryption(s.jptm, s.destAppendBlobURL.BlobURL)
+	}
+	if s.jptm.IsLive() {
+		preserveBlobImmutability(s.jptm, s.srcInfoProvider, s.destAppendBlobURL.BlobURL, s.p)
+	}
 }
 
 func (s *appendBlobSenderBase) Cleanup() {
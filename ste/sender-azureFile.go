@@ -235,7 +235,7 @@ func (*azureFileSenderBase) DoWithOverrideReadOnly(ctx context.Context, action f
 }
 
 func (u *azureFileSenderBase) addPermissionsToHeaders(info TransferInfo, destUrl url.URL) (stage string, err error) {
-	if !info.PreserveSMBPermissions.IsTruthy() {
+	if !info.PreserveSMBPermissions.IsTruthy() || info.SkipSMBInfo {
 		return "", nil
 	}
 
@@ -284,7 +284,7 @@ func (u *azureFileSenderBase) addPermissionsToHeaders(info TransferInfo, destUrl
 }
 
 func (u *azureFileSenderBase) addSMBPropertiesToHeaders(info TransferInfo, destUrl url.URL) (stage string, err error) {
-	if !info.PreserveSMBInfo {
+	if !info.PreserveSMBInfo || info.SkipSMBInfo {
 		return "", nil
 	}
 	if smbSIP, ok := u.sip.(ISMBPropertyBearingSourceInfoProvider); ok {
@@ -318,7 +318,19 @@ func (u *azureFileSenderBase) Epilogue() {
 	resendArchive := u.headersToApply.FileAttributes != nil &&
 		u.headersToApply.FileAttributes.Has(azfile.FileAttributeArchive) == false
 
-	if u.jptm.IsLive() && (resendReadOnly || resendArchive) && u.jptm.Info().PreserveSMBInfo {
+	// when the user asked for read-only files, that too can only be set once the content has been written
+	setReadOnly := u.jptm.Info().SetReadOnly
+	if setReadOnly {
+		attribs := azfile.FileAttributeArchive // what the service gives a new file, when no attributes are preserved
+		if u.headersToApply.FileAttributes != nil {
+			attribs = *u.headersToApply.FileAttributes
+		}
+		attribs = attribs.Add(azfile.FileAttributeReadonly)
+		u.headersToApply.FileAttributes = &attribs
+	}
+
+	// with skip-smb-info, the file keeps the attributes that it was created with
+	if u.jptm.IsLive() && !u.jptm.Info().SkipSMBInfo && ((resendReadOnly || resendArchive) && u.jptm.Info().PreserveSMBInfo || setReadOnly) {
 		//This is an extra round trip, but we can live with that for these relatively rare cases
 		_, err := u.fileURL().SetHTTPHeaders(u.ctx, u.headersToApply)
 		if err != nil {
@@ -376,6 +388,10 @@ func (u *azureFileSenderBase) SetFolderProperties() error {
 		return err
 	}
 
+	if info.SkipSMBInfo {
+		return nil // the folder keeps the SMB properties that it was created with
+	}
+
 	err = u.DoWithOverrideReadOnly(u.ctx,
 		func() (interface{}, error) { return u.dirURL().SetProperties(u.ctx, u.headersToApply.SMBProperties) },
 		u.fileOrDirURL,
@@ -0,0 +1,64 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// verifyBlobEncryption checks, once the content of a blob has been written, that the service reports it as encrypted,
+// and with the encryption scope that the job expects, if any. A blob that isn't is failed, since the point of the check
+// is to be sure that nothing was left encrypted other than as intended.
+func verifyBlobEncryption(jptm IJobPartTransferMgr, blobURL azblob.BlobURL) {
+	verify, expectedScope := jptm.EncryptionVerification()
+	if !verify {
+		return
+	}
+
+	props, err := blobURL.GetProperties(jptm.Context(), azblob.BlobAccessConditions{})
+	if err != nil {
+		jptm.FailActiveSend("Verifying encryption", err)
+		return
+	}
+	if err = checkBlobEncryption(props.IsServerEncrypted(), props.EncryptionScope(), expectedScope); err != nil {
+		jptm.FailActiveSend("Verifying encryption", err)
+		return
+	}
+	jptm.Log(pipeline.LogDebug, fmt.Sprintf("Verified encryption (scope %q, key SHA256 %q)", props.EncryptionScope(), props.EncryptionKeySha256()))
+}
+
+// checkBlobEncryption compares the encryption that the service reports for a blob with the encryption expected of it
+func checkBlobEncryption(isServerEncrypted string, scope string, expectedScope string) error {
+	if isServerEncrypted != "true" {
+		return errors.New("the service does not report the blob as encrypted")
+	}
+	if expectedScope != "" && scope != expectedScope {
+		if scope == "" {
+			return fmt.Errorf("the blob is not encrypted with encryption scope %s", expectedScope)
+		}
+		return fmt.Errorf("the blob is encrypted with encryption scope %s, instead of %s", scope, expectedScope)
+	}
+	return nil
+}
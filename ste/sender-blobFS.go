@@ -41,6 +41,11 @@ type blobFSSenderBase struct {
 	pacer               pacer
 	creationTimeHeaders *azbfs.BlobFSHTTPHeaders
 	flushThreshold      int64
+
+	// set only when preserving POSIX properties: the mode goes into the file's ACL, and the properties kept in metadata
+	// on other destinations (including the mode, so that it can be read back the same way) go into its path properties
+	creationTimeProperties  map[string]string
+	creationTimePermissions string
 }
 
 func newBlobFSSenderBase(jptm IJobPartTransferMgr, destination string, p pipeline.Pipeline, pacer pacer, sip ISourceInfoProvider) (*blobFSSenderBase, error) {
@@ -63,6 +68,22 @@ func newBlobFSSenderBase(jptm IJobPartTransferMgr, destination string, p pipelin
 	}
 	headers := props.SrcHTTPHeaders.ToBlobFSHTTPHeaders()
 
+	var posixProperties map[string]string
+	var posixPermissions string
+	if info.PreservePOSIX && !info.IsFolderPropertiesTransfer() {
+		posix, found, err := common.POSIXPropertiesFromMetadata(props.SrcMetadata)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			posixProperties = make(map[string]string, len(common.POSIXMetadataKeys))
+			for _, k := range common.POSIXMetadataKeys {
+				posixProperties[k] = props.SrcMetadata[k]
+			}
+			posixPermissions = posix.OctalMode()
+		}
+	}
+
 	var h URLHolder
 	if info.IsFolderPropertiesTransfer() {
 		h = azbfs.NewDirectoryURL(*destURL, p)
@@ -70,14 +91,16 @@ func newBlobFSSenderBase(jptm IJobPartTransferMgr, destination string, p pipelin
 		h = azbfs.NewFileURL(*destURL, p)
 	}
 	return &blobFSSenderBase{
-		jptm:                jptm,
-		fileOrDirURL:        h,
-		chunkSize:           chunkSize,
-		numChunks:           numChunks,
-		pipeline:            p,
-		pacer:               pacer,
-		creationTimeHeaders: &headers,
-		flushThreshold:      chunkSize * int64(ADLSFlushThreshold),
+		jptm:                    jptm,
+		fileOrDirURL:            h,
+		chunkSize:               chunkSize,
+		numChunks:               numChunks,
+		pipeline:                p,
+		pacer:                   pacer,
+		creationTimeHeaders:     &headers,
+		flushThreshold:          chunkSize * int64(ADLSFlushThreshold),
+		creationTimeProperties:  posixProperties,
+		creationTimePermissions: posixPermissions,
 	}, nil
 }
 
@@ -155,7 +178,7 @@ func (u *blobFSSenderBase) Prologue(state common.PrologueState) (destinationModi
 	}
 
 	// Create file with the source size
-	_, err = u.fileURL().Create(u.jptm.Context(), *u.creationTimeHeaders) // "create" actually calls "create path", so if we didn't need to track folder creation, we could just let this call create the folder as needed
+	_, err = u.fileURL().CreateWithOptions(u.jptm.Context(), *u.creationTimeHeaders, u.creationTimeProperties, u.creationTimePermissions) // "create" actually calls "create path", so if we didn't need to track folder creation, we could just let this call create the folder as needed
 	if err != nil {
 		u.jptm.FailActiveUpload("Creating file", err)
 		return
@@ -0,0 +1,129 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// BlobImmutabilityServiceVersion is the first service version that reports and sets the immutability policies and legal holds of individual blobs.
+// The SDK predates it, so requests that need it have their version overridden.
+const BlobImmutabilityServiceVersion = "2020-10-02"
+
+// blobImmutability is the immutability policy and legal hold of a blob
+type blobImmutability struct {
+	policyExpiry time.Time // the zero time if the blob has no immutability policy
+	policyMode   string    // Unlocked or Locked
+	legalHold    bool
+}
+
+// immutabilityFromHeaders reads the immutability of a blob from the response to Get Blob Properties
+func immutabilityFromHeaders(h http.Header) (blobImmutability, error) {
+	var i blobImmutability
+	if until := h.Get("x-ms-immutability-policy-until-date"); until != "" {
+		t, err := time.Parse(http.TimeFormat, until)
+		if err != nil {
+			return i, fmt.Errorf("the immutability policy expiry %q is not a valid time", until)
+		}
+		i.policyExpiry = t
+		// the service reports the mode in lower case, but only accepts it capitalized
+		switch mode := h.Get("x-ms-immutability-policy-mode"); strings.ToLower(mode) {
+		case "locked":
+			i.policyMode = "Locked"
+		case "unlocked", "":
+			i.policyMode = "Unlocked"
+		default:
+			return i, fmt.Errorf("the immutability policy mode %q is not known", mode)
+		}
+	}
+	i.legalHold = strings.EqualFold(h.Get("x-ms-legal-hold"), "true")
+	return i, nil
+}
+
+// preserveBlobImmutability gives the destination blob the immutability policy and legal hold of its source blob, if the job asks.
+// It must come after everything else is written, since the blob can't be modified once they are set.
+// The front end has checked that the destination container accepts them, so a blob that doesn't get them is failed.
+func preserveBlobImmutability(jptm IJobPartTransferMgr, sip ISourceInfoProvider, blobURL azblob.BlobURL, p pipeline.Pipeline) {
+	if !jptm.PreserveImmutability() {
+		return
+	}
+	blobSource, ok := sip.(IBlobSourceInfoProvider)
+	if !ok {
+		return // only blobs have immutability to preserve
+	}
+	immutability, err := blobSource.Immutability()
+	if err != nil {
+		jptm.FailActiveSend("Getting the immutability of the source", err)
+		return
+	}
+
+	if !immutability.policyExpiry.IsZero() {
+		err = setBlobImmutability(jptm.Context(), blobURL, p, "immutabilityPolicies", map[string]string{
+			"x-ms-immutability-policy-until-date": immutability.policyExpiry.UTC().Format(http.TimeFormat),
+			"x-ms-immutability-policy-mode":       immutability.policyMode,
+		})
+		if err != nil {
+			jptm.FailActiveSend("Setting the immutability policy", err)
+			return
+		}
+	}
+	if immutability.legalHold {
+		if err = setBlobImmutability(jptm.Context(), blobURL, p, "legalhold", map[string]string{"x-ms-legal-hold": "true"}); err != nil {
+			jptm.FailActiveSend("Setting the legal hold", err)
+			return
+		}
+	}
+}
+
+// setBlobImmutability sends one of the requests that set the immutability of a blob, which the SDK doesn't have
+func setBlobImmutability(ctx context.Context, blobURL azblob.BlobURL, p pipeline.Pipeline, comp string, headers map[string]string) error {
+	u := blobURL.URL()
+	if u.RawQuery == "" {
+		u.RawQuery = "comp=" + comp
+	} else {
+		u.RawQuery = "comp=" + comp + "&" + u.RawQuery
+	}
+	req, err := pipeline.NewRequest(http.MethodPut, u, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	ctx = context.WithValue(ctx, ServiceAPIVersionOverride, BlobImmutabilityServiceVersion)
+	resp, err := p.Do(ctx, passThroughResponder, req)
+	if err != nil {
+		return err
+	}
+	httpResp := resp.Response()
+	_ = httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the destination did not accept it: %s (%s)", httpResp.Status, httpResp.Header.Get("x-ms-error-code"))
+	}
+	return nil
+}
@@ -22,10 +22,13 @@ package ste
 
 import (
 	"context"
+	"crypto/md5"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -36,6 +39,7 @@ import (
 )
 
 var lowMemoryLimitAdvice sync.Once
+var expiryNotSupported sync.Once
 
 type blockBlobSenderBase struct {
 	jptm             IJobPartTransferMgr
@@ -44,6 +48,7 @@ type blockBlobSenderBase struct {
 	numChunks        uint32
 	pacer            pacer
 	blockIDs         []string
+	blockIDPrefix    string
 	destBlobTier     azblob.AccessTierType
 
 	// Headers and other info that we will apply to the destination
@@ -56,6 +61,10 @@ type blockBlobSenderBase struct {
 
 	atomicPutListIndicator int32
 	muBlockIDs             *sync.Mutex
+
+	// for setting the expiry and immutability of the destination, and reading those of the source when preserving them
+	p               pipeline.Pipeline
+	srcInfoProvider ISourceInfoProvider
 }
 
 func getVerifiedChunkParams(transferInfo TransferInfo, memLimit int64) (chunkSize int64, numChunks uint32, err error) {
@@ -121,9 +130,13 @@ func newBlockBlobSenderBase(jptm IJobPartTransferMgr, destination string, p pipe
 	// If user set blob tier explicitly, override any value that our caller
 	// may have guessed.
 	destBlobTier := inferredAccessTierType
-	blockBlobTierOverride, _ := jptm.BlobTiers()
-	if blockBlobTierOverride != common.EBlockBlobTier.None() {
-		destBlobTier = blockBlobTierOverride.ToAccessTierType()
+	if blockBlobTierOverride := jptm.BlockBlobTierOverride(); blockBlobTierOverride != azblob.AccessTierNone {
+		destBlobTier = blockBlobTierOverride
+	}
+	if rules := jptm.TierByAccessTime(); len(rules) > 0 {
+		if destBlobTier, err = tierByAccessTime(jptm, srcInfoProvider, rules, destBlobTier); err != nil {
+			return nil, err
+		}
 	}
 
 	return &blockBlobSenderBase{
@@ -133,11 +146,14 @@ func newBlockBlobSenderBase(jptm IJobPartTransferMgr, destination string, p pipe
 		numChunks:        numChunks,
 		pacer:            pacer,
 		blockIDs:         make([]string, numChunks),
+		blockIDPrefix:    jptm.BlockIDPrefix(),
 		headersToApply:   props.SrcHTTPHeaders.ToAzBlobHTTPHeaders(),
 		metadataToApply:  props.SrcMetadata.ToAzBlobMetadata(),
 		blobTagsToApply:  props.SrcBlobTags.ToAzBlobTagsMap(),
 		destBlobTier:     destBlobTier,
-		muBlockIDs:       &sync.Mutex{}}, nil
+		muBlockIDs:       &sync.Mutex{},
+		p:                p,
+		srcInfoProvider:  srcInfoProvider}, nil
 }
 
 func (s *blockBlobSenderBase) SendableEntityType() common.EntityType {
@@ -191,7 +207,7 @@ func (s *blockBlobSenderBase) Epilogue() {
 			blobTags = nil
 		}
 
-		if _, err := s.destBlockBlobURL.CommitBlockList(jptm.Context(), blockIDs, s.headersToApply, s.metadataToApply, azblob.BlobAccessConditions{}, s.destBlobTier, blobTags); err != nil {
+		if _, err := s.destBlockBlobURL.CommitBlockList(ContextForAccessTier(jptm.Context(), s.destBlobTier), blockIDs, s.headersToApply, s.metadataToApply, azblob.BlobAccessConditions{}, s.destBlobTier, blobTags); err != nil {
 			jptm.FailActiveSend("Committing block list", err)
 			return
 		}
@@ -202,6 +218,89 @@ func (s *blockBlobSenderBase) Epilogue() {
 			}
 		}
 	}
+
+	// whether the blob was written by one Put Blob, or by committing its blocks, it exists now
+	if jptm.IsLive() {
+		s.setExpiry()
+	}
+	if jptm.IsLive() {
+		verifyBlobEncryption(jptm, s.destBlockBlobURL.BlobURL)
+	}
+	if jptm.IsLive() {
+		preserveBlobImmutability(jptm, s.srcInfoProvider, s.destBlockBlobURL.BlobURL, s.p)
+	}
+}
+
+// setExpiry sets the expiry of the destination blob, as the job asks. The SDK has no Set Blob Expiry operation, so the request is made here.
+// Only accounts with a hierarchical namespace support expiry. Elsewhere, the user is told so once, and the blobs are left without it.
+func (s *blockBlobSenderBase) setExpiry() {
+	jptm := s.jptm
+	option, expiryTime := jptm.BlobExpiry()
+
+	var serviceOption, serviceTime string
+	switch option {
+	case common.EBlobExpiryOption.None():
+		return
+	case common.EBlobExpiryOption.RelativeToNow():
+		serviceOption, serviceTime = "RelativeToNow", strconv.FormatInt(expiryTime, 10)
+	case common.EBlobExpiryOption.Absolute():
+		serviceOption, serviceTime = "Absolute", time.Unix(0, expiryTime*int64(time.Millisecond)).UTC().Format(http.TimeFormat)
+	case common.EBlobExpiryOption.NeverExpire():
+		serviceOption = "NeverExpire"
+	case common.EBlobExpiryOption.PreserveSource():
+		blobSource, ok := s.srcInfoProvider.(IBlobSourceInfoProvider)
+		if !ok {
+			return // only blobs have an expiry to preserve
+		}
+		expiresOn, err := blobSource.Expiry()
+		if err != nil {
+			jptm.FailActiveSend("Getting the expiry of the source", err)
+			return
+		}
+		if expiresOn.IsZero() {
+			return
+		}
+		serviceOption, serviceTime = "Absolute", expiresOn.UTC().Format(http.TimeFormat)
+	}
+
+	expiryURL := s.destBlockBlobURL.URL()
+	if expiryURL.RawQuery == "" {
+		expiryURL.RawQuery = "comp=expiry"
+	} else {
+		expiryURL.RawQuery = "comp=expiry&" + expiryURL.RawQuery
+	}
+	req, err := pipeline.NewRequest(http.MethodPut, expiryURL, nil)
+	if err != nil {
+		jptm.FailActiveSend("Setting expiry", err)
+		return
+	}
+	req.Header.Set("x-ms-expiry-option", serviceOption)
+	if serviceTime != "" {
+		req.Header.Set("x-ms-expiry-time", serviceTime)
+	}
+
+	ctx := context.WithValue(jptm.Context(), ServiceAPIVersionOverride, azblob.ServiceVersion)
+	resp, err := s.p.Do(ctx, passThroughResponder, req)
+	if err != nil {
+		jptm.FailActiveSend("Setting expiry", err)
+		return
+	}
+	httpResp := resp.Response()
+	_ = httpResp.Body.Close()
+
+	switch {
+	case httpResp.StatusCode == http.StatusOK:
+	case httpResp.StatusCode == http.StatusBadRequest:
+		// the account (or this blob) doesn't support expiry, which isn't worth failing a transfer whose content arrived safely
+		errorCode := httpResp.Header.Get("x-ms-error-code")
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "The destination did not accept the expiry: "+errorCode)
+		expiryNotSupported.Do(func() {
+			common.GetLifecycleMgr().Info(fmt.Sprintf("The destination does not support blob expiry (%s), so the blobs are written without it. "+
+				"Only accounts with a hierarchical namespace (ADLS Gen2) support it.", errorCode))
+		})
+	default:
+		jptm.FailActiveSend("Setting expiry", fmt.Errorf("unexpected status %s (%s)", httpResp.Status, httpResp.Header.Get("x-ms-error-code")))
+	}
 }
 
 func (s *blockBlobSenderBase) Cleanup() {
@@ -242,7 +341,23 @@ func (s *blockBlobSenderBase) setBlockID(index int32, value string) {
 	s.blockIDs[index] = value
 }
 
-func (s *blockBlobSenderBase) generateEncodedBlockID() string {
-	blockID := common.NewUUID().String()
+func (s *blockBlobSenderBase) generateEncodedBlockID(blockIndex int32) string {
+	return encodeBlockID(s.blockIDPrefix, blockIndex, common.NewUUID().String())
+}
+
+// encodeBlockID returns the base64 encoded ID of the block at blockIndex, made from a unique part that is 36 characters long.
+// With a prefix, the ID is the prefix, the block index with leading zeros and a dash, and then the unique part, so that tools
+// which read the block lists of blobs can both recognize our blocks and sort them into the order they belong in.
+// All the IDs of one blob have the same length, as the service requires.
+func encodeBlockID(prefix string, blockIndex int32, unique string) string {
+	blockID := unique
+	if prefix != "" {
+		blockID = fmt.Sprintf("%s%05d-%s", prefix, blockIndex, unique)
+	}
 	return base64.StdEncoding.EncodeToString([]byte(blockID))
 }
+
+// hashedBlockIDPart formats an MD5 hash like a UUID, so that it can be the unique part of a block ID
+func hashedBlockIDPart(h [md5.Size]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
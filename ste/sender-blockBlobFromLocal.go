@@ -22,6 +22,11 @@ package ste
 
 import (
 	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-blob-go/azblob"
@@ -33,6 +38,20 @@ type blockBlobUploader struct {
 	blockBlobSenderBase
 
 	md5Channel chan []byte
+
+	// for a delta update, the ID of each block is made from its content, and the committed blocks of the existing
+	// destination blob are those we compare with. existingBlocks is nil when none of them can be kept.
+	deltaUpdate          bool
+	existingBlocks       []azblob.Block
+	atomicUnchangedCount int32
+
+	// when resuming a checkpointed upload, the IDs of the blocks that were staged before the job was interrupted.
+	// Nil when there is nothing to resume.
+	stagedBlockIDs     map[string]bool
+	atomicResumedCount int32
+
+	// encrypts the content of the blob, if it's encrypted client-side. Nil otherwise
+	encryptor *common.ClientSideEncryptor
 }
 
 func newBlockBlobUploader(jptm IJobPartTransferMgr, destination string, p pipeline.Pipeline, pacer pacer, sip ISourceInfoProvider) (sender, error) {
@@ -41,7 +60,89 @@ func newBlockBlobUploader(jptm IJobPartTransferMgr, destination string, p pipeli
 		return nil, err
 	}
 
-	return &blockBlobUploader{blockBlobSenderBase: *senderBase, md5Channel: newMd5Channel()}, nil
+	u := &blockBlobUploader{blockBlobSenderBase: *senderBase, md5Channel: newMd5Channel()}
+	if key, encrypt, err := jptm.ClientSideEncryptionKey(); err != nil {
+		return nil, err
+	} else if encrypt {
+		// blocks encrypted before are no use, since each upload has a content key of its own
+		return u, u.prepareClientSideEncryption(key)
+	}
+	if jptm.ShouldDeltaUpdate() {
+		u.deltaUpdate = true
+		u.prepareDeltaUpdate()
+	}
+	if u.existingBlocks == nil && jptm.CheckpointedBytes() > 0 {
+		u.prepareCheckpointResume()
+	}
+	return u, nil
+}
+
+// prepareCheckpointResume reads the uncommitted blocks of the destination, so that the chunks which a previous run of the
+// job staged before it was interrupted are not uploaded again. The block IDs tell us which chunk of which version of the
+// source each block holds, so any block that doesn't match is simply ignored and its chunk is uploaded as usual.
+func (u *blockBlobUploader) prepareCheckpointResume() {
+	jptm := u.jptm
+	blockList, err := u.destBlockBlobURL.GetBlockList(jptm.Context(), azblob.BlockListUncommitted, azblob.LeaseAccessConditions{})
+	if err != nil {
+		if stgErr, ok := err.(azblob.StorageError); !ok || stgErr.Response().StatusCode != http.StatusNotFound {
+			jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Could not read the staged blocks of the destination, so the whole file will be uploaded. "+err.Error())
+		}
+		return
+	}
+
+	u.stagedBlockIDs = make(map[string]bool, len(blockList.UncommittedBlocks))
+	for _, b := range blockList.UncommittedBlocks {
+		u.stagedBlockIDs[b.Name] = true
+	}
+}
+
+// prepareDeltaUpdate reads the block list of the existing destination blob, so that blocks which have not changed
+// can be kept instead of being uploaded again. That only works if our chunks line up with those blocks, so we adopt
+// the destination's block size. If the destination doesn't exist, its blocks are not all the same size, or it wasn't
+// uploaded by a delta update, the whole file is uploaded, with IDs that the next delta update can compare with.
+func (u *blockBlobUploader) prepareDeltaUpdate() {
+	jptm := u.jptm
+	blockList, err := u.destBlockBlobURL.GetBlockList(jptm.Context(), azblob.BlockListCommitted, azblob.LeaseAccessConditions{})
+	if err != nil {
+		if stgErr, ok := err.(azblob.StorageError); !ok || stgErr.Response().StatusCode != http.StatusNotFound {
+			jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Could not read the blocks of the destination, so the whole file will be uploaded. "+err.Error())
+		}
+		return
+	}
+
+	blocks := blockList.CommittedBlocks
+	if len(blocks) < 2 {
+		// a blob that was uploaded in one piece has no blocks that we could keep
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "The destination was not uploaded in blocks, so the whole file will be uploaded.")
+		return
+	}
+	blockSize := blocks[0].Size
+	for i, b := range blocks {
+		if b.Size > blockSize || (b.Size < blockSize && i != len(blocks)-1) {
+			jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "The blocks of the destination are not all the same size, so the whole file will be uploaded.")
+			return
+		}
+	}
+
+	// the blocks are only compared by their IDs, so any that weren't staged by a delta update can't be kept.
+	// That also keeps us from mixing IDs of different lengths, which the service rejects
+	for _, b := range blocks {
+		if len(b.Name) != len(u.generateEncodedBlockID(0)) {
+			jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "The destination was not uploaded with upload-changed-blocks and the same block ID prefix, so the whole file will be uploaded.")
+			return
+		}
+	}
+
+	numChunks := getNumChunks(jptm.Info().SourceSize, blockSize)
+	if blockSize >= jptm.CacheLimiter().Limit() || numChunks > common.MaxNumberOfBlocksPerBlob {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("The block size of the destination (%d) cannot be used for this file, so the whole file will be uploaded.", blockSize))
+		return
+	}
+
+	u.chunkSize = blockSize
+	u.numChunks = numChunks
+	u.blockIDs = make([]string, numChunks)
+	u.existingBlocks = blocks
 }
 
 func (u *blockBlobUploader) Md5Channel() chan<- []byte {
@@ -66,15 +167,44 @@ func (u *blockBlobUploader) GenerateUploadFunc(id common.ChunkID, blockIndex int
 func (u *blockBlobUploader) generatePutBlock(id common.ChunkID, blockIndex int32, reader common.SingleChunkReader) chunkFunc {
 	return createSendToRemoteChunkFunc(u.jptm, id, func() {
 		// step 1: generate block ID
-		encodedBlockID := u.generateEncodedBlockID()
+		var encodedBlockID string
+		if u.deltaUpdate {
+			var err error
+			if encodedBlockID, err = deltaBlockID(u.blockIDPrefix, blockIndex, reader); err != nil {
+				u.jptm.FailActiveUpload("Hashing block", err)
+				return
+			}
+			// a block that is the same as the one already at the destination is kept as it is
+			if u.blockIsUnchanged(blockIndex, encodedBlockID) {
+				atomic.AddInt32(&u.atomicUnchangedCount, 1)
+				u.setBlockID(blockIndex, encodedBlockID)
+				return
+			}
+		} else if u.jptm.IsCheckpointing() {
+			encodedBlockID = checkpointBlockID(u.jptm.Info(), u.jptm.LastModifiedTime(), id, u.blockIDPrefix, blockIndex)
+		} else {
+			encodedBlockID = u.generateEncodedBlockID(blockIndex)
+		}
+
+		// when resuming, a block that was staged before the job was interrupted is kept as it is
+		if u.blockWasStaged(encodedBlockID, id) {
+			atomic.AddInt32(&u.atomicResumedCount, 1)
+			u.setBlockID(blockIndex, encodedBlockID)
+			return
+		}
 
 		// step 2: save the block ID into the list of block IDs
 		u.setBlockID(blockIndex, encodedBlockID)
 
 		// step 3: put block to remote
 		u.jptm.LogChunkStatus(id, common.EWaitReason.Body())
-		body := newPacedRequestBody(u.jptm.Context(), reader, u.pacer)
-		_, err := u.destBlockBlobURL.StageBlock(u.jptm.Context(), encodedBlockID, body, azblob.LeaseAccessConditions{}, nil)
+		chunkBody, err := u.chunkBody(reader)
+		if err != nil {
+			u.jptm.FailActiveUpload("Encrypting block", err)
+			return
+		}
+		body := newPacedRequestBody(u.jptm.Context(), chunkBody, u.pacer)
+		_, err = u.destBlockBlobURL.StageBlock(u.jptm.Context(), encodedBlockID, body, azblob.LeaseAccessConditions{}, nil)
 		if err != nil {
 			u.jptm.FailActiveUpload("Staging block", err)
 			return
@@ -82,6 +212,38 @@ func (u *blockBlobUploader) generatePutBlock(id common.ChunkID, blockIndex int32
 	})
 }
 
+// deltaBlockID returns the ID of a block of a delta update. It's made from the block's position and content, so that
+// the next delta update can tell whether the block has changed from the block list alone, without downloading it
+func deltaBlockID(prefix string, blockIndex int32, reader io.ReadSeeker) (string, error) {
+	h := md5.New()
+	fmt.Fprintf(h, "%d|", blockIndex)
+	_, err := io.Copy(h, reader)
+	if _, seekErr := reader.Seek(0, io.SeekStart); err == nil {
+		err = seekErr
+	}
+	if err != nil {
+		return "", err
+	}
+	var sum [md5.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return encodeBlockID(prefix, blockIndex, hashedBlockIDPart(sum)), nil
+}
+
+// blockIsUnchanged returns true if the block at the same position in the existing destination blob has the same ID,
+// and so the same content
+func (u *blockBlobUploader) blockIsUnchanged(blockIndex int32, encodedBlockID string) bool {
+	return int(blockIndex) < len(u.existingBlocks) && u.existingBlocks[blockIndex].Name == encodedBlockID
+}
+
+// blockWasStaged returns true if the chunk lies within the checkpointed part of the source,
+// and its block was found among the staged blocks of the destination
+func (u *blockBlobUploader) blockWasStaged(encodedBlockID string, id common.ChunkID) bool {
+	if u.stagedBlockIDs == nil || id.OffsetInFile()+id.Length() > u.jptm.CheckpointedBytes() {
+		return false
+	}
+	return u.stagedBlockIDs[encodedBlockID]
+}
+
 // generates PUT Blob (for a blob that fits in a single put request)
 func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex int32, reader common.SingleChunkReader) chunkFunc {
 
@@ -102,7 +264,7 @@ func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex i
 		}
 
 		if jptm.Info().SourceSize == 0 {
-			_, err = u.destBlockBlobURL.Upload(jptm.Context(), bytes.NewReader(nil), u.headersToApply, u.metadataToApply, azblob.BlobAccessConditions{}, u.destBlobTier, blobTags)
+			_, err = u.destBlockBlobURL.Upload(ContextForAccessTier(jptm.Context(), u.destBlobTier), bytes.NewReader(nil), u.headersToApply, u.metadataToApply, azblob.BlobAccessConditions{}, u.destBlobTier, blobTags)
 		} else {
 			// File with content
 
@@ -112,11 +274,18 @@ func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex i
 				jptm.FailActiveUpload("Getting hash", errNoHash)
 				return
 			}
-			u.headersToApply.ContentMD5 = md5Hash
+			if u.encryptor == nil { // otherwise it's the hash of the plaintext, which isn't what the blob holds
+				u.headersToApply.ContentMD5 = md5Hash
+			}
 
 			// Upload the file
-			body := newPacedRequestBody(jptm.Context(), reader, u.pacer)
-			_, err = u.destBlockBlobURL.Upload(jptm.Context(), body, u.headersToApply, u.metadataToApply, azblob.BlobAccessConditions{}, u.destBlobTier, blobTags)
+			chunkBody, err := u.chunkBody(reader)
+			if err != nil {
+				jptm.FailActiveUpload("Encrypting blob", err)
+				return
+			}
+			body := newPacedRequestBody(jptm.Context(), chunkBody, u.pacer)
+			_, err = u.destBlockBlobURL.Upload(ContextForAccessTier(jptm.Context(), u.destBlobTier), body, u.headersToApply, u.metadataToApply, azblob.BlobAccessConditions{}, u.destBlobTier, blobTags)
 		}
 
 		// if the put blob is a failure, update the transfer status to failed
@@ -142,16 +311,36 @@ func (u *blockBlobUploader) Epilogue() {
 
 		md5Hash, ok := <-u.md5Channel
 		if ok {
-			u.headersToApply.ContentMD5 = md5Hash
+			if u.encryptor == nil { // otherwise it's the hash of the plaintext, which isn't what the blob holds
+				u.headersToApply.ContentMD5 = md5Hash
+			}
 		} else {
 			jptm.FailActiveSend("Getting hash", errNoHash)
 			return
 		}
 	}
 
+	if u.existingBlocks != nil && jptm.IsLive() {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Delta update kept %d of %d blocks unchanged", atomic.LoadInt32(&u.atomicUnchangedCount), u.numChunks))
+	}
+	if u.stagedBlockIDs != nil && jptm.IsLive() {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Resumed upload reused %d of %d blocks staged before the interruption", atomic.LoadInt32(&u.atomicResumedCount), u.numChunks))
+	}
+
 	u.blockBlobSenderBase.Epilogue()
 }
 
+func (u *blockBlobUploader) Cleanup() {
+	jptm := u.jptm
+	if u.existingBlocks != nil && jptm.IsDeadInflight() && !jptm.WasCanceled() {
+		// The committed blocks still hold the destination as it was before this transfer started.
+		// For a delta update, that's better left in place than deleted.
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogDebug, "Leaving the existing destination blob in place after the delta update failed")
+		return
+	}
+	u.blockBlobSenderBase.Cleanup()
+}
+
 func (u *blockBlobUploader) GetDestinationLength() (int64, error) {
 	prop, err := u.destBlockBlobURL.GetProperties(u.jptm.Context(), azblob.BlobAccessConditions{})
 
@@ -63,6 +63,10 @@ type pageBlobSenderBase struct {
 	// there was a potential for us to not zero out 512b segments that we'd prefetched all zeroes for.
 	// This only posed danger when there was already data in one of these segments.
 	destPageRangeOptimizer *pageRangeOptimizer
+
+	// for reading the immutability of the source, and setting it on the destination, when preserving it
+	p               pipeline.Pipeline
+	srcInfoProvider ISourceInfoProvider
 }
 
 const (
@@ -133,6 +137,8 @@ func newPageBlobSenderBase(jptm IJobPartTransferMgr, destination string, p pipel
 		destBlobTier:           destBlobTier,
 		filePacer:              newNullAutoPacer(), // defer creation of real one to Prologue
 		destPageRangeOptimizer: destRangeOptimizer,
+		p:                      p,
+		srcInfoProvider:        srcInfoProvider,
 	}
 
 	if s.isInManagedDiskImportExportAccount() && jptm.ShouldPutMd5() {
@@ -244,7 +250,7 @@ func (s *pageBlobSenderBase) Prologue(ps common.PrologueState) (destinationModif
 
 	if _, err := s.destPageBlobURL.Create(s.jptm.Context(),
 		s.srcSize,
-		0,
+		s.jptm.PageBlobSequenceNumber(),
 		s.headersToApply,
 		s.metadataToApply,
 		azblob.BlobAccessConditions{},
@@ -265,6 +271,13 @@ func (s *pageBlobSenderBase) Prologue(ps common.PrologueState) (destinationModif
 
 func (s *pageBlobSenderBase) Epilogue() {
 	_ = s.filePacer.Close() // release resources
+
+	if s.jptm.IsLive() {
+		verifyBlobEncryption(s.jptm, s.destPageBlobURL.BlobURL)
+	}
+	if s.jptm.IsLive() {
+		preserveBlobImmutability(s.jptm, s.srcInfoProvider, s.destPageBlobURL.BlobURL, s.p)
+	}
 }
 
 func (s *pageBlobSenderBase) Cleanup() {
@@ -22,6 +22,7 @@ package ste
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
@@ -186,12 +187,16 @@ func newBlobUploader(jptm IJobPartTransferMgr, destination string, p pipeline.Pi
 	intendedType := override.ToAzBlobType()
 
 	if override == common.EBlobType.Detect() {
-		intendedType = inferBlobType(jptm.Info().Source, azblob.BlobBlockBlob)
+		intendedType = InferBlobType(jptm.Info().Source, azblob.BlobBlockBlob)
 		// jptm.LogTransferInfo(fmt.Sprintf("Autodetected %s blob type as %s.", jptm.Info().Source , intendedType))
 		// TODO: Log these? @JohnRusk and @zezha-msft this creates quite a bit of spam in the logs but is important info.
 		// TODO: Perhaps we should log it only if it isn't a block blob?
 	}
 
+	if _, encrypt, _ := jptm.ClientSideEncryptionKey(); encrypt && intendedType != azblob.BlobBlockBlob && intendedType != azblob.BlobNone {
+		return nil, fmt.Errorf("client-side encryption is only supported for block blobs, not %s", intendedType)
+	}
+
 	switch intendedType {
 	case azblob.BlobBlockBlob:
 		return newBlockBlobUploader(jptm, destination, p, pacer, sip)
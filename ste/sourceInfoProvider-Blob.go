@@ -21,6 +21,8 @@
 package ste
 
 import (
+	"context"
+	"net/http"
 	"time"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
@@ -62,3 +64,61 @@ func (p *blobSourceInfoProvider) GetFreshFileLastModifiedTime() (time.Time, erro
 
 	return properties.LastModified(), nil
 }
+
+func (p *blobSourceInfoProvider) Expiry() (time.Time, error) {
+	presignedURL, err := p.PreSignedSourceURL()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// the expiry is only returned by service versions that support it
+	ctx := context.WithValue(p.jptm.Context(), ServiceAPIVersionOverride, azblob.ServiceVersion)
+	blobURL := azblob.NewBlobURL(*presignedURL, p.jptm.SourceProviderPipeline())
+	properties, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	expiry := properties.Response().Header.Get("x-ms-expiry-time")
+	if expiry == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(http.TimeFormat, expiry)
+}
+
+func (p *blobSourceInfoProvider) LastAccessTime() (time.Time, error) {
+	presignedURL, err := p.PreSignedSourceURL()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// the last access time is only returned by service versions that support it, and only if the account tracks it
+	ctx := context.WithValue(p.jptm.Context(), ServiceAPIVersionOverride, ServiceVersionForAccessTiers())
+	blobURL := azblob.NewBlobURL(*presignedURL, p.jptm.SourceProviderPipeline())
+	properties, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	lastAccessed := properties.Response().Header.Get("x-ms-last-access-time")
+	if lastAccessed == "" {
+		return properties.LastModified(), nil
+	}
+	return time.Parse(http.TimeFormat, lastAccessed)
+}
+
+func (p *blobSourceInfoProvider) Immutability() (blobImmutability, error) {
+	presignedURL, err := p.PreSignedSourceURL()
+	if err != nil {
+		return blobImmutability{}, err
+	}
+
+	// as for the expiry, the immutability is only returned by service versions that support it
+	ctx := context.WithValue(p.jptm.Context(), ServiceAPIVersionOverride, BlobImmutabilityServiceVersion)
+	blobURL := azblob.NewBlobURL(*presignedURL, p.jptm.SourceProviderPipeline())
+	properties, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{})
+	if err != nil {
+		return blobImmutability{}, err
+	}
+	return immutabilityFromHeaders(properties.Response().Header)
+}
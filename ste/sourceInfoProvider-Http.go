@@ -0,0 +1,127 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// Source info provider for plain HTTP(S) sources, e.g. web-hosted content.
+// The service fetches the content itself, so all we do is tell it where the content really is.
+type httpSourceInfoProvider struct {
+	jptm         IJobPartTransferMgr
+	transferInfo TransferInfo
+	client       *http.Client
+
+	// the source as found at the start of this transfer, after following any redirects, since the service won't follow them
+	probed *common.HTTPSourceInfo
+}
+
+func newHttpSourceInfoProvider(jptm IJobPartTransferMgr) (ISourceInfoProvider, error) {
+	return &httpSourceInfoProvider{
+		jptm:         jptm,
+		transferInfo: jptm.Info(),
+		client:       NewAzcopyHTTPClient(1),
+	}, nil
+}
+
+// probe finds out where the source is, and what it is. The result is kept, so that all chunks are read from the same place
+func (p *httpSourceInfoProvider) probe() (*common.HTTPSourceInfo, error) {
+	if p.probed != nil {
+		return p.probed, nil
+	}
+	u, err := url.Parse(p.transferInfo.Source)
+	if err != nil {
+		return nil, err
+	}
+	info, err := common.ProbeHTTPSource(p.jptm.Context(), p.client, *u)
+	if err != nil {
+		return nil, err
+	}
+	p.probed = &info
+	return p.probed, nil
+}
+
+func (p *httpSourceInfoProvider) PreSignedSourceURL() (*url.URL, error) {
+	info, err := p.probe()
+	if err != nil {
+		return nil, err
+	}
+	u := info.URL
+	return &u, nil
+}
+
+func (p *httpSourceInfoProvider) Properties() (*SrcProperties, error) {
+	srcProperties := SrcProperties{
+		SrcHTTPHeaders: p.transferInfo.SrcHTTPHeaders,
+		SrcMetadata:    p.transferInfo.SrcMetadata,
+	}
+
+	// Get properties in backend.
+	if p.transferInfo.S2SGetPropertiesInBackend {
+		info, err := p.probe()
+		if err != nil {
+			return nil, err
+		}
+		srcProperties.SrcHTTPHeaders = common.ResourceHTTPHeaders{
+			ContentType:        info.ContentType(),
+			ContentEncoding:    info.ContentEncoding(),
+			ContentDisposition: info.ContentDisposition(),
+			ContentLanguage:    info.ContentLanguage(),
+			CacheControl:       info.CacheControl(),
+			ContentMD5:         info.ContentMD5(),
+		}
+	}
+
+	return &srcProperties, nil
+}
+
+func (p *httpSourceInfoProvider) SourceSize() int64 {
+	return p.transferInfo.SourceSize
+}
+
+func (p *httpSourceInfoProvider) RawSource() string {
+	return p.transferInfo.Source
+}
+
+func (p *httpSourceInfoProvider) IsLocal() bool {
+	return false
+}
+
+func (p *httpSourceInfoProvider) GetFreshFileLastModifiedTime() (time.Time, error) {
+	u, err := url.Parse(p.transferInfo.Source)
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := common.ProbeHTTPSource(p.jptm.Context(), p.client, *u)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.LastModified, nil
+}
+
+func (p *httpSourceInfoProvider) EntityType() common.EntityType {
+	return common.EEntityType.File() // web servers have no folders we can see
+}
@@ -22,6 +22,7 @@ package ste
 
 import (
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/Azure/azure-storage-azcopy/common"
@@ -43,6 +44,32 @@ func (f localFileSourceInfoProvider) Properties() (*SrcProperties, error) {
 
 	headers, metadata, blobTags := f.jptm.ResourceDstData(nil) // we don't have a known MIME type yet, so pass nil for the sniffed content of thefile
 
+	if f.transferInfo.PreserveFileAttributes {
+		// the SMB properties are only available on Windows, where the SIP is an ISMBPropertyBearingSourceInfoProvider
+		if sip, ok := interface{}(f).(ISMBPropertyBearingSourceInfoProvider); ok {
+			props, err := sip.GetSMBProperties()
+			if err != nil {
+				return nil, err
+			}
+
+			// copy, so as not to modify the metadata shared by the other transfers in the job part
+			withAttributes := make(common.Metadata, len(metadata)+1)
+			for k, v := range metadata {
+				withAttributes[k] = v
+			}
+			withAttributes[common.FileAttributesMetadataKey] = strconv.FormatUint(uint64(props.FileAttributes()), 10)
+			metadata = withAttributes
+		}
+	}
+
+	if f.transferInfo.PreservePOSIX && f.transferInfo.EntityType == common.EEntityType.File() {
+		props, err := common.GetPOSIXProperties(f.transferInfo.Source)
+		if err != nil {
+			return nil, err
+		}
+		metadata = props.AddToMetadata(metadata) // a copy, like the attributes above
+	}
+
 	return &SrcProperties{
 		SrcHTTPHeaders: common.ResourceHTTPHeaders{
 			ContentType:        headers.ContentType,
@@ -0,0 +1,188 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/azure-storage-file-go/azfile"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// Source info providers for copies between remote locations that are routed through AzCopy, instead of being done by the service.
+// Each wraps the provider that the service-side copy would have used, and reads the source's content a range at a time,
+// from its pre-signed URL, so that the uploaders can send it as though it were a local file.
+// There is a variant for each of the optional interfaces that the uploaders look for, so that blob and SMB properties still carry over.
+
+// relayedSource reads the content of a remote source, for the relayed source info providers.
+// It reads through the source info provider's pipeline, so that the reads are retried and logged like any other request to the source
+type relayedSource struct {
+	ctx      context.Context
+	p        pipeline.Pipeline
+	source   *url.URL
+	location common.Location
+}
+
+func (r relayedSource) open() (common.CloseableReaderAt, error) {
+	return &rangedURLReader{relayedSource: r}, nil
+}
+
+type relayedSourceInfoProvider struct {
+	IRemoteSourceInfoProvider
+	relay relayedSource
+}
+
+func (p *relayedSourceInfoProvider) IsLocal() bool { return true }
+
+func (p *relayedSourceInfoProvider) OpenSourceFile() (common.CloseableReaderAt, error) {
+	return p.relay.open()
+}
+
+type relayedBlobSourceInfoProvider struct {
+	IBlobSourceInfoProvider
+	relay relayedSource
+}
+
+func (p *relayedBlobSourceInfoProvider) IsLocal() bool { return true }
+
+func (p *relayedBlobSourceInfoProvider) OpenSourceFile() (common.CloseableReaderAt, error) {
+	return p.relay.open()
+}
+
+type relayedSMBSourceInfoProvider struct {
+	ISMBPropertyBearingSourceInfoProvider
+	relay relayedSource
+}
+
+func (p *relayedSMBSourceInfoProvider) IsLocal() bool { return true }
+
+func (p *relayedSMBSourceInfoProvider) OpenSourceFile() (common.CloseableReaderAt, error) {
+	return p.relay.open()
+}
+
+// newRelayedSourceInfoProvider makes a factory of providers that route the content of the sources that remoteFactory's providers describe through AzCopy
+func newRelayedSourceInfoProvider(remoteFactory sourceInfoProviderFactory) sourceInfoProviderFactory {
+	return func(jptm IJobPartTransferMgr) (ISourceInfoProvider, error) {
+		sip, err := remoteFactory(jptm)
+		if err != nil {
+			return nil, err
+		}
+		remote, ok := sip.(IRemoteSourceInfoProvider)
+		if !ok {
+			return nil, fmt.Errorf("the source can't be read through AzCopy, since it has no URL")
+		}
+		source, err := remote.PreSignedSourceURL()
+		if err != nil {
+			return nil, err
+		}
+
+		fromTo := jptm.FromTo()
+		relay := relayedSource{ctx: jptm.Context(), p: jptm.SourceProviderPipeline(), source: source, location: fromTo.From()}
+		switch typed := sip.(type) {
+		case IBlobSourceInfoProvider:
+			return &relayedBlobSourceInfoProvider{IBlobSourceInfoProvider: typed, relay: relay}, nil
+		case ISMBPropertyBearingSourceInfoProvider:
+			return &relayedSMBSourceInfoProvider{ISMBPropertyBearingSourceInfoProvider: typed, relay: relay}, nil
+		default:
+			return &relayedSourceInfoProvider{IRemoteSourceInfoProvider: remote, relay: relay}, nil
+		}
+	}
+}
+
+// rangedURLReader reads the content at a URL with a ranged GET for each read, so that chunks can be read independently, and in parallel
+type rangedURLReader struct {
+	relayedSource
+}
+
+func (r *rangedURLReader) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	status, body, err := r.download(off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	if status == http.StatusOK && off != 0 {
+		return 0, fmt.Errorf("cannot read the source a range at a time, since it ignored the range that was asked for")
+	}
+
+	n, err := io.ReadFull(body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF // the source ended within the range, as ReaderAt reports it
+	}
+	return n, err
+}
+
+// download starts reading the given range of the source, returning the status of the response, and its body.
+// A range that starts at or past the end of the source gives io.EOF
+func (r *rangedURLReader) download(off int64, count int64) (int, io.ReadCloser, error) {
+	switch r.location {
+	case common.ELocation.Blob():
+		resp, err := azblob.NewBlobURL(*r.source, r.p).Download(r.ctx, off, count, azblob.BlobAccessConditions{}, false)
+		if stgErr, ok := err.(azblob.StorageError); ok && stgErr.Response().StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return 0, nil, io.EOF
+		} else if err != nil {
+			return 0, nil, err
+		}
+		return resp.StatusCode(), resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: MaxRetryPerDownloadBody}), nil
+	case common.ELocation.File():
+		resp, err := azfile.NewFileURL(*r.source, r.p).Download(r.ctx, off, count, false)
+		if stgErr, ok := err.(azfile.StorageError); ok && stgErr.Response().StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return 0, nil, io.EOF
+		} else if err != nil {
+			return 0, nil, err
+		}
+		return resp.StatusCode(), resp.Body(azfile.RetryReaderOptions{MaxRetryRequests: MaxRetryPerDownloadBody}), nil
+	default:
+		// other sources, such as S3, don't know the x-ms-range header that the Azure Storage SDKs send, so they get the standard one
+		req, err := pipeline.NewRequest(http.MethodGet, *r.source, nil)
+		if err != nil {
+			return 0, nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+count-1))
+		resp, err := r.p.Do(r.ctx, nil, req)
+		if err != nil {
+			return 0, nil, err
+		}
+		raw := resp.Response()
+		switch {
+		case raw.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+			raw.Body.Close()
+			return 0, nil, io.EOF
+		case raw.StatusCode != http.StatusOK && raw.StatusCode != http.StatusPartialContent:
+			raw.Body.Close()
+			return 0, nil, fmt.Errorf("cannot read the source: %s", raw.Status)
+		}
+		return raw.StatusCode, raw.Body, nil
+	}
+}
+
+func (r *rangedURLReader) Close() error {
+	return nil
+}
@@ -76,6 +76,18 @@ type IBlobSourceInfoProvider interface {
 
 	// BlobType returns source's blob type.
 	BlobType() azblob.BlobType
+
+	// Expiry returns when the source blob expires, or the zero time if it never does.
+	// Listing doesn't return the expiry, so this asks the service for it.
+	Expiry() (time.Time, error)
+
+	// Immutability returns the source's immutability policy and legal hold.
+	// Listing doesn't return them, so this asks the service for them.
+	Immutability() (blobImmutability, error)
+
+	// LastAccessTime returns when the source was last read or written, or when it was last modified,
+	// if the account doesn't track access times. Listing doesn't return it, so this asks the service for it.
+	LastAccessTime() (time.Time, error)
 }
 
 type TypedSMBPropertyHolder interface {
@@ -0,0 +1,101 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+// tracer, if set, receives a span for each job, and one for each of the job's transfers
+var tracer *common.OTLPTracer
+
+// traceParent, if valid, is the span that the spans of jobs are children of
+var traceParent common.TraceContext
+
+// how long the end of a job waits for its spans to be sent, since the process may exit as soon as the job is done
+const traceFlushTimeout = 5 * time.Second
+
+// SetTracer makes the jobs that start after this call send their spans to t, as children of parent if that is valid.
+// When AzCopy is used as a library, the caller can pass the context of a span of its own, so that AzCopy's spans join its trace.
+func SetTracer(t *common.OTLPTracer, parent common.TraceContext) {
+	tracer = t
+	traceParent = parent
+}
+
+// startSpan starts the span of the job. It's called when the first part of the job is added
+func (jm *jobMgr) startSpan(plan *JobPartPlanHeader) {
+	jm.span = tracer.StartSpan("azcopy job", traceParent)
+	jm.span.SetAttribute("azcopy.job_id", jm.jobID.String())
+	jm.span.SetAttribute("azcopy.from_to", plan.FromTo.String())
+}
+
+// endSpan ends the span of the job, and sends it along with those of the job's transfers
+func (jm *jobMgr) endSpan(cancelled bool, progress jobPartProgressInfo) {
+	if jm.span == nil {
+		return
+	}
+	jm.span.SetAttribute("azcopy.transfers_completed", progress.transfersCompleted)
+	jm.span.SetAttribute("azcopy.transfers_skipped", progress.transfersSkipped)
+	jm.span.SetAttribute("azcopy.transfers_failed", progress.transfersFailed)
+	if cancelled {
+		jm.span.SetError("the job was cancelled")
+	} else if progress.transfersFailed > 0 {
+		jm.span.SetError(fmt.Sprintf("%d transfers failed", progress.transfersFailed))
+	}
+	jm.span.End()
+	tracer.Flush(traceFlushTimeout)
+}
+
+// startSpan starts the span of the transfer, as a child of that of its job, if the job is traced
+func (jptm *jobPartTransferMgr) startSpan() {
+	jpm, ok := jptm.jobPartMgr.(*jobPartMgr)
+	if !ok {
+		return
+	}
+	jm, ok := jpm.jobMgr.(*jobMgr)
+	if !ok || jm.span == nil {
+		return
+	}
+	info := jptm.Info()
+	jptm.span = tracer.StartSpan("azcopy transfer", jm.span.Context())
+	jptm.span.SetAttribute("azcopy.source", common.URLStringExtension(info.Source).RedactSecretQueryParamForLogging())
+	jptm.span.SetAttribute("azcopy.destination", common.URLStringExtension(info.Destination).RedactSecretQueryParamForLogging())
+	jptm.span.SetAttribute("azcopy.entity_type", info.EntityType.String())
+	jptm.span.SetAttribute("azcopy.size", info.SourceSize)
+}
+
+// endSpan ends the span of the transfer, with its outcome and the number of times its requests were retried
+func (jptm *jobPartTransferMgr) endSpan() {
+	if jptm.span == nil {
+		return
+	}
+	status := jptm.TransferStatusIgnoringCancellation()
+	jptm.span.SetAttribute("azcopy.status", status.String())
+	jptm.span.SetAttribute("azcopy.retries", atomic.LoadInt32(&jptm.atomicRetryCount))
+	if status.IsFailure() {
+		jptm.span.SetAttribute("azcopy.error_code", jptm.ErrorCode())
+		jptm.span.SetError(fmt.Sprintf("the transfer failed with status %s", status))
+	}
+	jptm.span.End()
+}
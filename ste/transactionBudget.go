@@ -0,0 +1,111 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+)
+
+// errTransactionsExhausted is the reason given for the requests that aren't sent, and the transfers that aren't started,
+// once the job has issued all of its transactions
+var errTransactionsExhausted = errors.New("the job has issued all the transactions allowed by max-transactions, so no more requests are sent")
+
+// transactionBudget caps the requests that the transfers send to the service, each try of each request counting as one transaction,
+// as the service bills them. Once the cap is reached, requests fail without being sent, so the transfers in progress fail,
+// and no more are started. Failed, rather than cancelled, so that resuming the job picks them up again.
+type transactionBudget struct {
+	atomicIssued    int64
+	max             int64
+	atomicExhausted int32
+}
+
+// newTransactionBudget returns nil, which caps nothing, if max isn't set
+func newTransactionBudget(max int64) *transactionBudget {
+	if max <= 0 {
+		return nil
+	}
+	return &transactionBudget{max: max}
+}
+
+// take counts a transaction, if the cap allows one more
+func (b *transactionBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	issued := atomic.AddInt64(&b.atomicIssued, 1)
+	if issued > b.max {
+		atomic.AddInt64(&b.atomicIssued, -1) // it wasn't issued after all
+		return false
+	}
+	if issued == b.max && atomic.CompareAndSwapInt32(&b.atomicExhausted, 0, 1) && JobsAdmin != nil {
+		JobsAdmin.LogToJobLog(fmt.Sprintf("The job has issued all of its %d transactions, so no more requests will be sent", b.max), pipeline.LogWarning)
+	}
+	return true
+}
+
+// exhausted says whether the job has issued all of its transactions
+func (b *transactionBudget) exhausted() bool {
+	return b != nil && atomic.LoadInt32(&b.atomicExhausted) == 1
+}
+
+// issued is how many transactions have been issued so far. Zero if they aren't capped
+func (b *transactionBudget) issued() int64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&b.atomicIssued)
+}
+
+// limit is the most transactions that may be issued. Zero if they aren't capped
+func (b *transactionBudget) limit() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.max
+}
+
+var transactionBudgetContextKey = contextKey{"transactionBudget"}
+
+// withTransactionBudget returns a context whose requests are held to the budget, if there is one
+func withTransactionBudget(ctx context.Context, budget *transactionBudget) context.Context {
+	if budget == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, transactionBudgetContextKey, budget)
+}
+
+// newTransactionBudgetPolicyFactory returns a policy which counts every try of every request against the budget of its context,
+// and fails the try, without sending it, once the budget has been used. Requests whose context has no budget aren't counted.
+func newTransactionBudgetPolicyFactory() pipeline.Factory {
+	return pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			if budget, ok := ctx.Value(transactionBudgetContextKey).(*transactionBudget); ok && !budget.take() {
+				return nil, errTransactionsExhausted
+			}
+			return next.Do(ctx, request)
+		}
+	})
+}
@@ -23,6 +23,7 @@ package ste
 import (
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"github.com/Azure/azure-storage-blob-go/azblob"
@@ -108,10 +109,10 @@ func BlobTierAllowed(destTier azblob.AccessTierType) bool {
 		// Any other storage type would have to be file storage, and we can't set tier there.
 		panic("Cannot set tier on azure files.")
 	} else {
-		// Standard storage account. If it's Hot, Cool, or Archive, we're A-OK.
+		// Standard storage account. Any block blob tier is left to the service to accept or not, including ones added since AzCopy was, such as Cold.
 		// Page blobs, however, don't have an access tier on Standard accounts.
 		// However, this is also OK, because the pageblob sender code prevents us from using a standard access tier type.
-		return destTier == azblob.AccessTierArchive || destTier == azblob.AccessTierCool || destTier == azblob.AccessTierHot
+		return !premiumPageBlobTierRegex.MatchString(string(destTier))
 	}
 }
 
@@ -231,10 +232,10 @@ func anyToRemote_file(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pi
 	}
 
 	// step 3: check overwrite option
-	// if the force Write flags is set to false or prompt
+	// if the force Write flags is set to false or prompt, or the job is append-only or transactional,
 	// then check the file exists at the remote location
 	// if it does, react accordingly
-	if jptm.GetOverwriteOption() != common.EOverwriteOption.True() {
+	if jptm.GetOverwriteOption() != common.EOverwriteOption.True() || jptm.IsAppendOnly() || jptm.IsTransactional() {
 		exists, dstLmt, existenceErr := s.RemoteFileExists()
 		if existenceErr != nil {
 			jptm.LogSendError(info.Source, info.Destination, "Could not check destination file existence. "+existenceErr.Error(), 0)
@@ -243,14 +244,21 @@ func anyToRemote_file(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pi
 			return
 		}
 		if exists {
-			shouldOverwrite := false
+			if jptm.IsAppendOnly() {
+				// unlike a skip, this is a failure, so that the job reports that something tried to modify the destination
+				jptm.LogSendError(info.Source, info.Destination, errAppendOnly.Error(), 0)
+				jptm.SetStatus(common.ETransferStatus.Failed())
+				jptm.ReportTransferDone()
+				return
+			}
+
+			// a transactional job only checks in order to know what it created, so it may already have been told to overwrite
+			shouldOverwrite := jptm.GetOverwriteOption() == common.EOverwriteOption.True()
 
 			// if necessary, prompt to confirm user's intent
 			if jptm.GetOverwriteOption() == common.EOverwriteOption.Prompt() {
 				// remove the SAS before prompting the user
-				parsed, _ := url.Parse(info.Destination)
-				parsed.RawQuery = ""
-				shouldOverwrite = jptm.GetOverwritePrompter().ShouldOverwrite(parsed.String(), common.EEntityType.File())
+				shouldOverwrite = shouldOverwriteFile(jptm, withoutQuery(info.Destination), dstLmt, s.GetDestinationLength)
 			} else if jptm.GetOverwriteOption() == common.EOverwriteOption.IfSourceNewer() {
 				// only overwrite if source lmt is newer (after) the destination
 				if jptm.LastModifiedTime().After(dstLmt) {
@@ -265,6 +273,8 @@ func anyToRemote_file(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pi
 				jptm.ReportTransferDone()
 				return
 			}
+		} else if jptm.IsTransactional() {
+			jptm.RecordDestinationCreated()
 		}
 	}
 
@@ -275,7 +285,21 @@ func anyToRemote_file(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pi
 	srcFile := (common.CloseableReaderAt)(nil)
 	if srcInfoProvider.IsLocal() {
 		sourceFileFactory = srcInfoProvider.(ILocalSourceInfoProvider).OpenSourceFile // all local providers must implement this interface
+		sourceFileFactory = newPacedSourceFileFactory(jptm.Context(), sourceFileFactory, jptm.DiskReadPacer())
 		srcFile, err = sourceFileFactory()
+		if err != nil && jptm.ShouldSkipLocked() && common.IsLockedFileError(err) {
+			if jptm.TryClaimLockedRetry() {
+				// put it at the back of the queue, so that the process holding the lock has a chance to finish with it.
+				// Scheduled on a separate goroutine, because we're running on a transfer worker and the channel may be full
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Source is locked by another process, so will be retried later. "+err.Error())
+				go jptm.RescheduleTransfer()
+				return
+			}
+			jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Source is locked by another process, so will be skipped (skipped-locked). "+err.Error())
+			jptm.SetStatus(common.ETransferStatus.SkippedFileLocked())
+			jptm.ReportTransferDone()
+			return
+		}
 		if err != nil {
 			suffix := ""
 			if strings.Contains(err.Error(), "Access is denied") && runtime.GOOS == "windows" {
@@ -373,6 +397,12 @@ func scheduleSendChunks(jptm IJobPartTransferMgr, srcPath string, srcFile common
 	} else {
 		md5Hasher = common.NewNullHasher()
 	}
+	var sha256Hasher hash.Hash
+	if srcInfoProvider.IsLocal() && jptm.ShouldComputeSha256() {
+		// computed from the same reads as the MD5, rather than by reading the file again
+		sha256Hasher = sha256.New()
+		md5Hasher = common.NewTeeHasher(md5Hasher, sha256Hasher)
+	}
 	safeToUseHash := true
 
 	if srcInfoProvider.IsLocal() {
@@ -380,6 +410,12 @@ func scheduleSendChunks(jptm IJobPartTransferMgr, srcPath string, srcFile common
 		defer close(md5Channel)
 	}
 
+	// with a read-ahead, the chunks of a file with more than one are read through a window, several at a time
+	var window *readAheadWindow
+	if srcInfoProvider.IsLocal() && numChunks > 1 {
+		window = jptm.ReadAhead().newWindow(jptm, srcPath, srcSize, int64(chunkSize), sourceFileFactory, srcFile)
+	}
+
 	chunkIDCount := int32(0)
 	for startIndex := int64(0); startIndex < srcSize || isDummyChunkInEmptyFile(startIndex, srcSize); startIndex += int64(chunkSize) {
 
@@ -390,27 +426,34 @@ func scheduleSendChunks(jptm IJobPartTransferMgr, srcPath string, srcFile common
 			adjustedChunkSize = srcSize - startIndex
 		}
 
-		id := common.NewChunkID(srcPath, startIndex, adjustedChunkSize) // TODO: stop using adjustedChunkSize, below, and use the size that's in the ID
+		id := common.NewChunkID(srcPath, jptm.TransferID(), startIndex, adjustedChunkSize) // TODO: stop using adjustedChunkSize, below, and use the size that's in the ID
 
 		if srcInfoProvider.IsLocal() {
 			if jptm.WasCanceled() {
 				prefetchErr = jobCancelledLocalPrefetchErr
+				window.abandon()
 			} else {
 				// As long as the prefetch error is nil, we'll attempt a prefetch.
 				// Otherwise, the chunk reader didn't need to be made.
 				// It's a waste of time to prefetch here, too, if we already know we can't upload.
 				// Furthermore, this prevents prefetchErr changing from under us.
 				if prefetchErr == nil {
-					// create reader and prefetch the data into it
-					chunkReader = createPopulatedChunkReader(jptm, sourceFileFactory, id, adjustedChunkSize, srcFile)
+					if window != nil {
+						// the window has already started reading this chunk, under an ID of its own
+						id, chunkReader, prefetchErr = window.next()
+					} else {
+						// create reader and prefetch the data into it
+						chunkReader = createPopulatedChunkReader(jptm, sourceFileFactory, id, adjustedChunkSize, srcFile)
 
-					// Wait until we have enough RAM, and when we do, prefetch the data for this chunk.
-					prefetchErr = chunkReader.BlockingPrefetch(srcFile, false)
+						// Wait until we have enough RAM, and when we do, prefetch the data for this chunk.
+						prefetchErr = chunkReader.BlockingPrefetch(srcFile, false)
+					}
 					if prefetchErr == nil {
 						chunkReader.WriteBufferTo(md5Hasher)
 						ps = chunkReader.GetPrologueState()
 					} else {
 						safeToUseHash = false // because we've missed a chunk
+						window.abandon()      // nor will any of those after it be sent
 					}
 				}
 			}
@@ -458,6 +501,9 @@ func scheduleSendChunks(jptm IJobPartTransferMgr, srcPath string, srcFile common
 
 	if srcInfoProvider.IsLocal() && safeToUseHash {
 		md5Channel <- md5Hasher.Sum(nil)
+		if sha256Hasher != nil {
+			jptm.RecordSha256(sha256Hasher.Sum(nil))
+		}
 	}
 }
 
@@ -536,7 +582,7 @@ func epilogueWithCleanupSendToRemote(jptm IJobPartTransferMgr, s sender, sip ISo
 			if err != nil {
 				wrapped := fmt.Errorf("Could not read destination length. %w", err)
 				jptm.FailActiveSend(common.IffString(isS2SCopier, "S2S ", "Upload ")+"Length check: Get destination length", wrapped)
-			} else if destLength != jptm.Info().SourceSize {
+			} else if destLength != expectedDestinationLength(jptm, s) {
 				jptm.FailActiveSend(common.IffString(isS2SCopier, "S2S ", "Upload ")+"Length check", errors.New("destination length does not match source length"))
 			}
 		}
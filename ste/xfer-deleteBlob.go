@@ -22,9 +22,22 @@ func DeleteBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer) {
 		return
 	}
 
+	// if the user asked for it, and we can authorize the sub-requests, hand the work over to a batch deleter
+	// which groups many deletions into a single request
+	if jptm.ShouldBatchDelete() {
+		if d := getBlobBatchDeleter(jptm, p); d != nil {
+			d.add(jptm)
+			return
+		}
+	}
+
+	scheduleDeleteBlob(jptm, p)
+}
+
+func scheduleDeleteBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline) {
 	// schedule the work as a chunk, so it will run on the main goroutine pool, instead of the
 	// smaller "transfer initiation pool", where this code runs.
-	id := common.NewChunkID(jptm.Info().Source, 0, 0)
+	id := common.NewChunkID(jptm.Info().Source, jptm.TransferID(), 0, 0)
 	cf := createChunkFunc(true, jptm, id, func() { doDeleteBlob(jptm, p) })
 	jptm.ScheduleChunks(cf)
 }
@@ -37,56 +50,68 @@ func doDeleteBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline) {
 
 	srcBlobURL := azblob.NewBlobURL(*u, p)
 
-	// Internal function which checks the transfer status and logs the msg respectively.
-	// Sets the transfer status and Report Transfer as Done.
-	// Internal function is created to avoid redundancy of the above steps from several places in the api.
-	transferDone := func(status common.TransferStatus, err error) {
-		if status == common.ETransferStatus.Failed() {
-			jptm.LogError(info.Source, "DELETE ERROR ", err)
-		} else if status == common.ETransferStatus.SkippedBlobHasSnapshots() {
-			explainedSkippedRemoveOnce.Do(func() {
-				common.GetLifecycleMgr().Info("Blobs with snapshots are skipped. Please specify the --delete-snapshots flag for alternative behaviors.")
-			})
-
-			// log at error level so that it's clear why the transfer was skipped even when the log level is set to error
-			jptm.Log(pipeline.LogError, fmt.Sprintf("DELETE SKIPPED(blob has snapshots): %s", strings.Split(info.Destination, "?")[0]))
-		} else {
-			jptm.Log(pipeline.LogInfo, fmt.Sprintf("DELETE SUCCESSFUL: %s", strings.Split(info.Destination, "?")[0]))
-		}
-
-		jptm.SetStatus(status)
-		jptm.ReportTransferDone()
-	}
-
 	// note: if deleteSnapshotsOption is 'only', which means deleting all the snapshots but keep the root blob
 	// we still count this delete operation as successful since we accomplished the desired outcome
 	_, err := srcBlobURL.Delete(jptm.Context(), jptm.DeleteSnapshotsOption().ToDeleteSnapshotsOptionType(), azblob.BlobAccessConditions{})
 	if err != nil {
 		if strErr, ok := err.(azblob.StorageError); ok {
-			// if the delete failed with err 404, i.e resource not found, then mark the transfer as success.
-			if strErr.Response().StatusCode == http.StatusNotFound {
-				transferDone(common.ETransferStatus.Success(), nil)
-				return
-			}
-
-			// if the delete failed because the blob has snapshots, then skip it
-			if strErr.Response().StatusCode == http.StatusConflict && strErr.ServiceCode() == azblob.ServiceCodeSnapshotsPresent {
-				transferDone(common.ETransferStatus.SkippedBlobHasSnapshots(), nil)
-				return
-			}
-
-			// If the status code was 403, it means there was an authentication error and we exit.
-			// User can resume the job if completely ordered with a new sas.
-			if strErr.Response().StatusCode == http.StatusForbidden {
-				errMsg := fmt.Sprintf("Authentication Failed. The SAS is not correct or expired or does not have the correct permission %s", err.Error())
-				jptm.Log(pipeline.LogError, errMsg)
-				common.GetLifecycleMgr().Error(errMsg)
-			}
+			reportBlobDeleteDone(jptm, strErr.Response().StatusCode, strErr.ServiceCode(), err)
+			return
+		}
+
+		// in all other cases, make the transfer as failed
+		blobDeleteDone(jptm, common.ETransferStatus.Failed(), err)
+	} else {
+		blobDeleteDone(jptm, common.ETransferStatus.Success(), nil)
+	}
+}
+
+// reportBlobDeleteDone works out the transfer status from the response to a delete request, and reports the transfer as done.
+// It's shared by individual deletes and by the sub-responses of batch deletes.
+func reportBlobDeleteDone(jptm IJobPartTransferMgr, statusCode int, serviceCode azblob.ServiceCodeType, err error) {
+	switch {
+	// if the delete failed with err 404, i.e resource not found, then mark the transfer as success.
+	case statusCode == http.StatusNotFound:
+		blobDeleteDone(jptm, common.ETransferStatus.Success(), nil)
+
+	// if the delete failed because the blob has snapshots, then skip it
+	case statusCode == http.StatusConflict && serviceCode == azblob.ServiceCodeSnapshotsPresent:
+		blobDeleteDone(jptm, common.ETransferStatus.SkippedBlobHasSnapshots(), nil)
+
+	case statusCode >= 200 && statusCode < 300:
+		blobDeleteDone(jptm, common.ETransferStatus.Success(), nil)
+
+	default:
+		// If the status code was 403, it means there was an authentication error and we exit.
+		// User can resume the job if completely ordered with a new sas.
+		if statusCode == http.StatusForbidden {
+			errMsg := fmt.Sprintf("Authentication Failed. The SAS is not correct or expired or does not have the correct permission %s", err.Error())
+			jptm.Log(pipeline.LogError, errMsg)
+			common.GetLifecycleMgr().Error(errMsg)
 		}
 
 		// in all other cases, make the transfer as failed
-		transferDone(common.ETransferStatus.Failed(), err)
+		blobDeleteDone(jptm, common.ETransferStatus.Failed(), err)
+	}
+}
+
+// blobDeleteDone checks the transfer status and logs the msg respectively.
+// Sets the transfer status and Report Transfer as Done.
+func blobDeleteDone(jptm IJobPartTransferMgr, status common.TransferStatus, err error) {
+	info := jptm.Info()
+	if status == common.ETransferStatus.Failed() {
+		jptm.LogError(info.Source, "DELETE ERROR ", err)
+	} else if status == common.ETransferStatus.SkippedBlobHasSnapshots() {
+		explainedSkippedRemoveOnce.Do(func() {
+			common.GetLifecycleMgr().Info("Blobs with snapshots are skipped. Please specify the --delete-snapshots flag for alternative behaviors.")
+		})
+
+		// log at error level so that it's clear why the transfer was skipped even when the log level is set to error
+		jptm.Log(pipeline.LogError, fmt.Sprintf("DELETE SKIPPED(blob has snapshots): %s", strings.Split(info.Destination, "?")[0]))
 	} else {
-		transferDone(common.ETransferStatus.Success(), nil)
+		jptm.Log(pipeline.LogInfo, fmt.Sprintf("DELETE SUCCESSFUL: %s", strings.Split(info.Destination, "?")[0]))
 	}
+
+	jptm.SetStatus(status)
+	jptm.ReportTransferDone()
 }
@@ -0,0 +1,283 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// the service accepts at most this many sub-requests in one batch
+const maxBlobBatchSize = 256
+
+// how long a partially filled batch waits for more deletions before it is sent anyway
+const blobBatchFlushDelay = 200 * time.Millisecond
+
+// container-level batch requests are only supported from this service version onwards
+const blobBatchServiceVersion = "2020-04-08"
+
+var explainedBatchDeleteNeedsSASOnce sync.Once
+
+type blobBatchDeleterKey struct {
+	p         pipeline.Pipeline
+	container string
+}
+
+var blobBatchDeleters = struct {
+	sync.Mutex
+	m map[blobBatchDeleterKey]*blobBatchDeleter
+}{m: make(map[blobBatchDeleterKey]*blobBatchDeleter)}
+
+// blobBatchDeleter collects the deletions of blobs that live in the same container,
+// and sends them to the service as Blob Batch requests.
+// The outcome of each deletion is still recorded against its own transfer, so an interrupted job resumes
+// exactly like a non-batched one: only the transfers that did not complete are attempted again.
+type blobBatchDeleter struct {
+	key          blobBatchDeleterKey
+	p            pipeline.Pipeline
+	containerURL url.URL // includes the SAS
+
+	mu      sync.Mutex
+	pending []IJobPartTransferMgr
+	timer   *time.Timer
+}
+
+// getBlobBatchDeleter returns the deleter for the container of the given transfer,
+// or nil if the deletion cannot be batched.
+func getBlobBatchDeleter(jptm IJobPartTransferMgr, p pipeline.Pipeline) *blobBatchDeleter {
+	u, err := url.Parse(jptm.Info().Source)
+	if err != nil {
+		return nil
+	}
+
+	// the sub-requests of a batch carry no credentials of their own, so we can only batch when the SAS authorizes them
+	parts := azblob.NewBlobURLParts(*u)
+	if parts.SAS.Encode() == "" {
+		explainedBatchDeleteNeedsSASOnce.Do(func() {
+			common.GetLifecycleMgr().Info("Batch deletion requires the source to be authenticated with a SAS token. Blobs will be deleted one at a time.")
+		})
+		return nil
+	}
+	parts.BlobName = ""
+	parts.Snapshot = ""
+	parts.VersionID = ""
+	containerURL := parts.URL()
+
+	key := blobBatchDeleterKey{p: p, container: containerURL.Host + containerURL.Path}
+	blobBatchDeleters.Lock()
+	defer blobBatchDeleters.Unlock()
+	d, ok := blobBatchDeleters.m[key]
+	if !ok {
+		d = &blobBatchDeleter{key: key, p: p, containerURL: containerURL}
+		blobBatchDeleters.m[key] = d
+	}
+	return d
+}
+
+// add queues the deletion, and sends the batch as soon as it is full
+func (d *blobBatchDeleter) add(jptm IJobPartTransferMgr) {
+	d.mu.Lock()
+	d.pending = append(d.pending, jptm)
+	var batch []IJobPartTransferMgr
+	if len(d.pending) >= maxBlobBatchSize {
+		batch = d.takePending()
+	} else if d.timer == nil {
+		d.timer = time.AfterFunc(blobBatchFlushDelay, d.flush)
+	}
+	d.mu.Unlock()
+
+	if batch != nil {
+		d.schedule(batch)
+	}
+}
+
+// flush sends whatever has been queued so far.
+// Deletions have stopped coming in faster than the flush delay, so the deleter is also forgotten, rather than keep the pipeline
+// of a finished job alive. Anything added to it later is still sent, and the next lookup for the container starts a new deleter.
+func (d *blobBatchDeleter) flush() {
+	d.mu.Lock()
+	batch := d.takePending()
+	d.mu.Unlock()
+
+	blobBatchDeleters.Lock()
+	if blobBatchDeleters.m[d.key] == d {
+		delete(blobBatchDeleters.m, d.key)
+	}
+	blobBatchDeleters.Unlock()
+
+	if len(batch) > 0 {
+		d.schedule(batch)
+	}
+}
+
+// takePending must be called with the lock held
+func (d *blobBatchDeleter) takePending() []IJobPartTransferMgr {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	batch := d.pending
+	d.pending = nil
+	return batch
+}
+
+// schedule the batch as a chunk of its first transfer, so that it runs on the main goroutine pool
+func (d *blobBatchDeleter) schedule(batch []IJobPartTransferMgr) {
+	batch[0].ScheduleChunks(func(int) { d.send(batch) })
+}
+
+func (d *blobBatchDeleter) send(batch []IJobPartTransferMgr) {
+	// transfers cancelled while waiting in the batch are simply reported as done, as they would be without batching
+	live := make([]IJobPartTransferMgr, 0, len(batch))
+	for _, jptm := range batch {
+		if jptm.WasCanceled() {
+			jptm.ReportTransferDone()
+		} else {
+			live = append(live, jptm)
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	results, err := d.submit(live)
+	if err != nil {
+		// the batch as a whole was not accepted, so fall back to deleting each blob on its own
+		live[0].Log(pipeline.LogWarning, fmt.Sprintf("Batch deletion of %d blobs failed, deleting them one at a time instead. %s", len(live), err.Error()))
+		for _, jptm := range live {
+			scheduleDeleteBlob(jptm, d.p)
+		}
+		return
+	}
+
+	for i, jptm := range live {
+		r, ok := results[i]
+		if !ok {
+			blobDeleteDone(jptm, common.ETransferStatus.Failed(), errors.New("no response was received for this blob in the batch"))
+			continue
+		}
+		var subErr error
+		if r.statusCode >= 300 {
+			subErr = fmt.Errorf("%d %s", r.statusCode, r.serviceCode)
+		}
+		reportBlobDeleteDone(jptm, r.statusCode, r.serviceCode, subErr)
+	}
+}
+
+type blobBatchSubResponse struct {
+	statusCode  int
+	serviceCode azblob.ServiceCodeType
+}
+
+// submit sends one batch request, and returns the sub-responses keyed by their position in the batch
+func (d *blobBatchDeleter) submit(batch []IJobPartTransferMgr) (map[int]blobBatchSubResponse, error) {
+	boundary := "batch_" + common.NewUUID().String()
+	var body bytes.Buffer
+	for i, jptm := range batch {
+		u, err := url.Parse(jptm.Info().Source)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&body, "--%s\r\n", boundary)
+		body.WriteString("Content-Type: application/http\r\nContent-Transfer-Encoding: binary\r\n")
+		fmt.Fprintf(&body, "Content-ID: %d\r\n\r\n", i)
+		fmt.Fprintf(&body, "DELETE %s HTTP/1.1\r\n", u.RequestURI())
+		if option := jptm.DeleteSnapshotsOption().ToDeleteSnapshotsOptionType(); option != azblob.DeleteSnapshotsOptionNone {
+			fmt.Fprintf(&body, "x-ms-delete-snapshots: %s\r\n", option)
+		}
+		body.WriteString("Content-Length: 0\r\n\r\n")
+	}
+	fmt.Fprintf(&body, "--%s--\r\n", boundary)
+
+	batchURL := d.containerURL
+	batchURL.RawQuery = "restype=container&comp=batch&" + batchURL.RawQuery
+	req, err := pipeline.NewRequest(http.MethodPost, batchURL, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+boundary)
+
+	ctx := context.WithValue(batch[0].Context(), ServiceAPIVersionOverride, blobBatchServiceVersion)
+	resp, err := d.p.Do(ctx, passThroughResponder, req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp := resp.Response()
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("unexpected status %s", httpResp.Status)
+	}
+
+	_, params, err := mime.ParseMediaType(httpResp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[int]blobBatchSubResponse, len(batch))
+	reader := multipart.NewReader(httpResp.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		id, err := strconv.Atoi(part.Header.Get("Content-ID"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Content-ID in batch response: %s", part.Header.Get("Content-ID"))
+		}
+		raw, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		// the blank line that ends the headers of a sub-response is also the start of the boundary after it,
+		// so the part itself may end without one
+		if !bytes.Contains(raw, []byte("\r\n\r\n")) {
+			raw = append(raw, "\r\n"...)
+		}
+		subResp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), nil)
+		if err != nil {
+			return nil, err
+		}
+		_ = subResp.Body.Close()
+		results[id] = blobBatchSubResponse{
+			statusCode:  subResp.StatusCode,
+			serviceCode: azblob.ServiceCodeType(subResp.Header.Get("x-ms-error-code")),
+		}
+	}
+	return results, nil
+}
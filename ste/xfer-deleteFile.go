@@ -58,7 +58,7 @@ func DeleteFile(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer) {
 	} else {
 		// schedule the work as a chunk, so it will run on the main goroutine pool, instead of the
 		// smaller "transfer initiation pool", where this code runs.
-		id := common.NewChunkID(info.Source, 0, 0)
+		id := common.NewChunkID(info.Source, jptm.TransferID(), 0, 0)
 		cf := createChunkFunc(true, jptm, id, func() { doDeleteFile(jptm, p) })
 		jptm.ScheduleChunks(cf)
 	}
@@ -21,10 +21,13 @@
 package ste
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
@@ -64,18 +67,26 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		jptm.ReportTransferDone()
 		return
 	}
-	// if the force Write flags is set to false or prompt
+	// if the force Write flags is set to false or prompt, or the job is append-only,
 	// then check the file exists at the remote location
 	// if it does, react accordingly
-	if jptm.GetOverwriteOption() != common.EOverwriteOption.True() {
+	if jptm.GetOverwriteOption() != common.EOverwriteOption.True() || jptm.IsAppendOnly() {
 		dstProps, err := common.OSStat(info.Destination)
 		if err == nil {
 			// if the error is nil, then file exists locally
+			if jptm.IsAppendOnly() {
+				// unlike a skip, this is a failure, so that the job reports that something tried to modify the destination
+				jptm.LogDownloadError(info.Source, info.Destination, errAppendOnly.Error(), 0)
+				jptm.SetStatus(common.ETransferStatus.Failed())
+				jptm.ReportTransferDone()
+				return
+			}
+
 			shouldOverwrite := false
 
 			// if necessary, prompt to confirm user's intent
 			if jptm.GetOverwriteOption() == common.EOverwriteOption.Prompt() {
-				shouldOverwrite = jptm.GetOverwritePrompter().ShouldOverwrite(info.Destination, common.EEntityType.File())
+				shouldOverwrite = shouldOverwriteFile(jptm, info.Destination, dstProps.ModTime(), func() (int64, error) { return dstProps.Size(), nil })
 			} else if jptm.GetOverwriteOption() == common.EOverwriteOption.IfSourceNewer() {
 				// only overwrite if source lmt is newer (after) the destination
 				if jptm.LastModifiedTime().After(dstProps.ModTime()) {
@@ -107,6 +118,12 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 	// step 4a: mark destination as modified before we take our first action there (which is to create the destination file)
 	jptm.SetDestinationIsModified()
 
+	// the SHA-256 is computed from the data as it's saved, if asked for
+	var sha256Hasher hash.Hash
+	if jptm.ShouldComputeSha256() {
+		sha256Hasher = sha256.New()
+	}
+
 	// step 4b: special handling for empty files
 	if fileSize == 0 {
 		if strings.EqualFold(info.Destination, common.Dev_Null) {
@@ -127,7 +144,7 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		// For blobs, it sets up a page blob pacer if it's a page blob.
 		// For blobFS, it's a noop.
 		dl.Prologue(jptm, p)
-		epilogueWithCleanupDownload(jptm, dl, nil, nil) // need standard epilogue, rather than a quick exit, so we can preserve modification dates
+		epilogueWithCleanupDownload(jptm, dl, nil, nil, sha256Hasher) // need standard epilogue, rather than a quick exit, so we can preserve modification dates
 		return
 	}
 
@@ -146,7 +163,7 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		jptm.LogDownloadError(info.Source, info.Destination, "File Creation Error "+err.Error(), 0)
 		jptm.SetStatus(common.ETransferStatus.Failed())
 		// use standard epilogue for consistency, but force release of file count (without an actual file) if necessary
-		epilogueWithCleanupDownload(jptm, dl, nil, nil)
+		epilogueWithCleanupDownload(jptm, dl, nil, nil, nil)
 	}
 	// block until we can safely use a file handle
 	err := jptm.WaitUntilLockDestination(jptm.Context())
@@ -159,13 +176,16 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 	if strings.EqualFold(info.Destination, common.Dev_Null) {
 		// the user wants to discard the downloaded data
 		dstFile = devNullWriter{}
+		if sha256Hasher != nil {
+			dstFile = hashingWriter{WriteCloser: dstFile, hasher: sha256Hasher}
+		}
 	} else {
 		// Normal scenario, create the destination file as expected
 		// Use pseudo chunk id to allow our usual state tracking mechanism to keep count of how many
 		// file creations are running at any given instant, for perf diagnostics
 		pseudoId := common.NewPseudoChunkIDForWholeFile(info.Source)
 		jptm.LogChunkStatus(pseudoId, common.EWaitReason.CreateLocalFile())
-		dstFile, err = createDestinationFile(jptm, info.Destination, fileSize, writeThrough)
+		dstFile, err = createDestinationFile(jptm, info.Destination, fileSize, writeThrough, sha256Hasher)
 		jptm.LogChunkStatus(pseudoId, common.EWaitReason.ChunkDone()) // normal setting to done doesn't apply to these pseudo ids
 		if err != nil {
 			failFileCreation(err)
@@ -218,7 +238,7 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 
 	// step 5d: tell jptm what to expect, and how to clean up at the end
 	jptm.SetNumberOfChunks(numChunks)
-	jptm.SetActionAfterLastChunk(func() { epilogueWithCleanupDownload(jptm, dl, dstFile, dstWriter) })
+	jptm.SetActionAfterLastChunk(func() { epilogueWithCleanupDownload(jptm, dl, dstFile, dstWriter, sha256Hasher) })
 
 	// step 6: go through the blob range and schedule download chunk jobs
 	// TODO: currently, the epilogue will only run if the number of completed chunks = numChunks.
@@ -238,7 +258,7 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 			adjustedChunkSize = fileSize - startIndex
 		}
 
-		id := common.NewChunkID(info.Destination, startIndex, adjustedChunkSize) // TODO: stop using adjustedChunkSize, below, and use the size that's in the ID
+		id := common.NewChunkID(info.Destination, jptm.TransferID(), startIndex, adjustedChunkSize) // TODO: stop using adjustedChunkSize, below, and use the size that's in the ID
 
 		// Wait until its OK to schedule it
 		// To prevent excessive RAM consumption, we have a limit on the amount of scheduled-but-not-yet-saved data
@@ -263,7 +283,9 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 
 }
 
-func createDestinationFile(jptm IJobPartTransferMgr, destination string, size int64, writeThrough bool) (file io.WriteCloser, err error) {
+// createDestinationFile creates the file that the downloaded data is saved to. If sha256Hasher isn't nil, what ends up in the file,
+// after any decryption and decompression, is hashed into it
+func createDestinationFile(jptm IJobPartTransferMgr, destination string, size int64, writeThrough bool, sha256Hasher hash.Hash) (file io.WriteCloser, err error) {
 	ct := common.ECompressionType.None()
 	if jptm.ShouldDecompress() {
 		size = 0                                  // we don't know what the final size will be, so we can't pre-size it
@@ -276,11 +298,22 @@ func createDestinationFile(jptm IJobPartTransferMgr, destination string, size in
 		// and we still need to set size to zero here, so relying on enumeration more wouldn't simply this code much, if at all.
 	}
 
+	envelope, key, decrypt, err := shouldDecryptClientSide(jptm)
+	if err != nil {
+		return nil, err
+	}
+	if decrypt {
+		size = 0 // the plaintext is shorter, by the nonce and tag of each region
+	}
+
 	var dstFile io.WriteCloser
 	dstFile, err = common.CreateFileOfSizeWithWriteThroughOption(destination, size, writeThrough, jptm.GetFolderCreationTracker(), jptm.GetForceIfReadOnly())
 	if err != nil {
 		return nil, err
 	}
+	if sha256Hasher != nil {
+		dstFile = hashingWriter{WriteCloser: dstFile, hasher: sha256Hasher}
+	}
 	if jptm.ShouldDecompress() {
 		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "will be decompressed from "+ct.String())
 
@@ -290,11 +323,22 @@ func createDestinationFile(jptm IJobPartTransferMgr, destination string, size in
 		// 1. Then we can't check the MD5 hash (since logically, any stored hash should be the hash of the file that exists in Storage, i.e. the compressed one)
 		// 2. Then we can't pre-plan a certain number of fixed-size chunks (which is required by the way our architecture currently works).
 	}
+	if decrypt {
+		// like decompression, decryption is applied to the data as it's written, after the MD5 of what was downloaded has been computed.
+		// The file was compressed, if at all, before it was encrypted on upload, so it's decrypted before it's decompressed
+		decrypting, err := common.NewClientSideDecryptingWriter(dstFile, envelope, key)
+		if err != nil {
+			_ = dstFile.Close()
+			return nil, err
+		}
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "will be decrypted client-side")
+		dstFile = decrypting
+	}
 	return dstFile, nil
 }
 
 // complete epilogue. Handles both success and failure
-func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, activeDstFile io.WriteCloser, cw common.ChunkedFileWriter) {
+func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, activeDstFile io.WriteCloser, cw common.ChunkedFileWriter, sha256Hasher hash.Hash) {
 	info := jptm.Info()
 
 	// allow our usual state tracking mechanism to keep count of how many epilogues are running at any given instant, for perf diagnostics
@@ -342,7 +386,8 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 		dl.Epilogue() // it can release resources here
 
 		// check length if enabled (except for dev null and decompression case, where that's impossible)
-		if jptm.IsLive() && info.DestLengthValidation && info.Destination != common.Dev_Null && !jptm.ShouldDecompress() {
+		_, _, decrypted, _ := shouldDecryptClientSide(jptm)
+		if jptm.IsLive() && info.DestLengthValidation && info.Destination != common.Dev_Null && !jptm.ShouldDecompress() && !decrypted {
 			fi, err := common.OSStat(info.Destination)
 
 			if err != nil {
@@ -353,6 +398,11 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 		}
 	}
 
+	// by now, the hasher has seen everything that was saved
+	if jptm.IsLive() && sha256Hasher != nil {
+		jptm.RecordSha256(sha256Hasher.Sum(nil))
+	}
+
 	// Preserve modified time
 	if jptm.IsLive() {
 		// TODO: the old version of this code did NOT consider it an error to be unable to set the modification date/time
@@ -370,9 +420,70 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 		}
 	}
 
+	// Restore POSIX properties. Unlike the modified time above, failure to do so fails the transfer, since the user has asked for them specifically
+	if jptm.IsLive() && info.PreservePOSIX && !strings.EqualFold(info.Destination, common.Dev_Null) {
+		restorePOSIXProperties(jptm, info)
+	}
+
+	// Set the file mode asked for, overriding the process umask. Like the POSIX properties, failure to do so fails the transfer
+	if jptm.IsLive() && info.FileMode != 0 && !strings.EqualFold(info.Destination, common.Dev_Null) {
+		if err := os.Chmod(info.Destination, os.FileMode(info.FileMode)); err != nil {
+			jptm.FailActiveDownload("Setting file mode", err)
+		}
+	}
+
+	// Restore the Windows file attributes last, since once the file is read-only its modified time can't be changed
+	if jptm.IsLive() && info.PreserveFileAttributes && !strings.EqualFold(info.Destination, common.Dev_Null) {
+		if err := restoreFileAttributes(jptm, dl, info); err != nil {
+			jptm.FailActiveDownload("Setting destination file attributes", err)
+		}
+	}
+
 	commonDownloaderCompletion(jptm, info, common.EEntityType.File())
 }
 
+// restoreFileAttributes applies the attributes that were kept in the source's metadata when it was uploaded
+func restoreFileAttributes(jptm IJobPartTransferMgr, dl downloader, info TransferInfo) error {
+	// The attributes can only be set on Windows, where blobDownloader satisfies fileAttributeAwareDownloader.
+	// (On other OSes, the front end has already told the user that the attributes will be ignored.)
+	fadl, ok := dl.(fileAttributeAwareDownloader)
+	if !ok {
+		return nil
+	}
+
+	value, ok := info.SrcMetadata[common.FileAttributesMetadataKey]
+	if !ok {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogDebug, "No file attributes were restored because none were found in the source's metadata")
+		return nil
+	}
+	attribs, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid value '%s' for metadata %s: %w", value, common.FileAttributesMetadataKey, err)
+	}
+	return fadl.PutFileAttributes(uint32(attribs), info)
+}
+
+// restorePOSIXProperties applies the POSIX properties that were kept in the source's metadata when it was uploaded
+func restorePOSIXProperties(jptm IJobPartTransferMgr, info TransferInfo) {
+	props, found, err := common.POSIXPropertiesFromMetadata(info.SrcMetadata)
+	if err == nil && !found {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogDebug, "No POSIX properties were restored because none were found in the source's metadata")
+		return
+	}
+
+	ownerSet := false
+	if err == nil {
+		ownerSet, err = common.SetPOSIXProperties(info.Destination, props)
+	}
+	if err != nil {
+		jptm.FailActiveDownload("Setting POSIX properties", err)
+		return
+	}
+	if !ownerSet {
+		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "The owner and group were not restored, because only root can change them. The mode and modification time were restored")
+	}
+}
+
 func commonDownloaderCompletion(jptm IJobPartTransferMgr, info TransferInfo, entityType common.EntityType) {
 	// note that we do not really know whether the context was canceled because of an error, or because the user asked for it
 	// if was an intentional cancel, the status is still "in progress", so we are still counting it as pending
@@ -462,3 +573,15 @@ func (devNullWriter) Write(p []byte) (n int, err error) {
 func (devNullWriter) Close() error {
 	return nil
 }
+
+// hashingWriter passes everything written to it on to the file, and into the hash of the file
+type hashingWriter struct {
+	io.WriteCloser
+	hasher hash.Hash
+}
+
+func (w hashingWriter) Write(p []byte) (n int, err error) {
+	n, err = w.WriteCloser.Write(p)
+	_, _ = w.hasher.Write(p[:n]) // writing to a hash never fails
+	return n, err
+}
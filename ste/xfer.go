@@ -21,6 +21,7 @@
 package ste
 
 import (
+	"errors"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -79,7 +80,7 @@ func parameterizeSend(targetFunction newJobXferWithSenderFactory, sf senderFacto
 }
 
 // the xfer factory is generated based on the type of source and destination
-func computeJobXfer(fromTo common.FromTo, blobType common.BlobType) newJobXfer {
+func computeJobXfer(fromTo common.FromTo, blobType common.BlobType, s2sPreference common.S2SPreference) newJobXfer {
 
 	const blobFSNotS2S = "blobFS not supported as S2S source"
 
@@ -99,7 +100,7 @@ func computeJobXfer(fromTo common.FromTo, blobType common.BlobType) newJobXfer {
 	}
 
 	getSenderFactory := func(fromTo common.FromTo) senderFactory {
-		isFromRemote := fromTo.From().IsRemote()
+		isFromRemote := fromTo.From().IsRemote() && s2sPreference != common.ES2SPreference.Never()
 		if isFromRemote {
 			// sending from remote = doing an S2S copy
 			switch fromTo.To() {
@@ -142,6 +143,8 @@ func computeJobXfer(fromTo common.FromTo, blobType common.BlobType) newJobXfer {
 			panic(blobFSNotS2S)
 		case common.ELocation.S3():
 			return newS3SourceInfoProvider
+		case common.ELocation.Http():
+			return newHttpSourceInfoProvider
 		default:
 			panic("unexpected source type")
 		}
@@ -150,25 +153,54 @@ func computeJobXfer(fromTo common.FromTo, blobType common.BlobType) newJobXfer {
 	// main computeJobXfer logic
 	switch {
 	case fromTo == common.EFromTo.BlobTrash():
-		return DeleteBlob
+		return refuseIfAppendOnly(DeleteBlob)
 	case fromTo == common.EFromTo.FileTrash():
-		return DeleteFile
+		return refuseIfAppendOnly(DeleteFile)
 	default:
 		if fromTo.IsDownload() {
 			return parameterizeDownload(remoteToLocal, getDownloader(fromTo.From()))
+		} else if fromTo.IsS2S() && s2sPreference == common.ES2SPreference.Never() {
+			// the source is downloaded through AzCopy and uploaded from there, as though it were local
+			return parameterizeSend(anyToRemote, getSenderFactory(fromTo), newRelayedSourceInfoProvider(getSipFactory(fromTo.From())))
 		} else {
 			return parameterizeSend(anyToRemote, getSenderFactory(fromTo), getSipFactory(fromTo.From()))
 		}
 	}
 }
 
+// errAppendOnly is the reason given when a transfer is failed because it would modify or remove an existing object in an append-only job
+var errAppendOnly = errors.New("the object already exists at the destination, and the job is append-only, so it will not be modified or removed")
+
+// refuseIfAppendOnly wraps a transfer that always modifies an existing object, such as a delete,
+// so that in an append-only job it fails without touching the object
+func refuseIfAppendOnly(xfer newJobXfer) newJobXfer {
+	return func(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer) {
+		if jptm.IsAppendOnly() {
+			jptm.LogError(jptm.Info().Source, "REFUSED ", errAppendOnly)
+			jptm.SetStatus(common.ETransferStatus.Failed())
+			jptm.ReportTransferDone()
+			return
+		}
+		xfer(jptm, p, pacer)
+	}
+}
+
+// folderOverwriteOption is the overwrite option that decides whether folder properties are set.
+// An append-only job only sets the properties of folders it created itself.
+func folderOverwriteOption(jptm IJobPartTransferMgr) common.OverwriteOption {
+	if jptm.IsAppendOnly() {
+		return common.EOverwriteOption.False()
+	}
+	return jptm.GetOverwriteOption()
+}
+
 var inferExtensions = map[string]azblob.BlobType{
 	".vhd":  azblob.BlobPageBlob,
 	".vhdx": azblob.BlobPageBlob,
 }
 
-// infers a blob type from the extension specified.
-func inferBlobType(filename string, defaultBlobType azblob.BlobType) azblob.BlobType {
+// InferBlobType infers a blob type from the extension specified.
+func InferBlobType(filename string, defaultBlobType azblob.BlobType) azblob.BlobType {
 	if b, ok := inferExtensions[strings.ToLower(filepath.Ext(filename))]; ok {
 		return b
 	}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
@@ -159,6 +160,9 @@ func NewBFSXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 			//    When retrying against a secondary, ignore the retry count and wait (.1 second * random(0.8, 1.2))
 			for try := int32(1); try <= o.MaxTries; try++ {
 				logf("\n=====> Try=%d\n", try)
+				if try > 1 {
+					countRetry(ctx)
+				}
 
 				// Determine which endpoint to try. It's primary if there is no secondary or if it is an add # attempt.
 				tryingPrimary := !considerSecondary || (try%2 == 1)
@@ -261,6 +265,10 @@ func NewBFSXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 					action = "NoRetry: successful HTTP request" // no error
 				}
 
+				if action[0] == 'R' && !mayRetry(ctx) {
+					action = "NoRetry: the file has used all of its retries"
+				}
+
 				logf("Action=%s\n", action)
 				if action[0] != 'R' { // Retry only if action starts with 'R'
 					if err != nil {
@@ -305,6 +313,34 @@ func withNoRetryForBlob(ctx context.Context) context.Context {
 	//    all our retry policies into one
 }
 
+var retryCounterContextKey = contextKey{"retryCounter"}
+
+// retryCounter is what a context holds to count the retries of a transfer's requests
+type retryCounter struct {
+	fileRetries *int32
+	budget      *retryBudget // nil if retries aren't capped
+}
+
+// withRetryCounter returns a context in which the retries of requests are counted, by atomically incrementing counter,
+// and are held to the budget
+func withRetryCounter(ctx context.Context, counter *int32, budget *retryBudget) context.Context {
+	return context.WithValue(ctx, retryCounterContextKey, retryCounter{fileRetries: counter, budget: budget})
+}
+
+// countRetry counts a retry in the counter of the context, if it has one
+func countRetry(ctx context.Context) {
+	if c, ok := ctx.Value(retryCounterContextKey).(retryCounter); ok {
+		atomic.AddInt32(c.fileRetries, 1)
+		c.budget.count()
+	}
+}
+
+// mayRetry says whether the budget of the context, if it has one, allows the transfer's requests to be retried again
+func mayRetry(ctx context.Context) bool {
+	c, ok := ctx.Value(retryCounterContextKey).(retryCounter)
+	return !ok || c.budget.fileMayRetry(atomic.LoadInt32(c.fileRetries))
+}
+
 // TODO: Fix the separate retry policies, use Azure blob's retry policy after blob SDK with retry optimization get released.
 // NewBlobXferRetryPolicyFactory creates a RetryPolicyFactory object configured using the specified options.
 func NewBlobXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
@@ -330,6 +366,9 @@ func NewBlobXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 			}
 			for try := int32(1); try <= maxTries; try++ {
 				logf("\n=====> Try=%d\n", try)
+				if try > 1 {
+					countRetry(ctx)
+				}
 
 				// Determine which endpoint to try. It's primary if there is no secondary or if it is an add # attempt.
 				tryingPrimary := !considerSecondary || (try%2 == 1)
@@ -433,6 +472,10 @@ func NewBlobXferRetryPolicyFactory(o XferRetryOptions) pipeline.Factory {
 					action = "NoRetry: successful HTTP request" // no error
 				}
 
+				if action[0] == 'R' && !mayRetry(ctx) {
+					action = "NoRetry: the file has used all of its retries"
+				}
+
 				logf("Action=%s\n", action)
 				if action[0] != 'R' { // Retry only if action starts with 'R'
 					if err != nil {
@@ -0,0 +1,650 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type planMigrationSuite struct{}
+
+var _ = chk.Suite(&planMigrationSuite{})
+
+func (s *planMigrationSuite) TestJobPartPlanFileVersion(c *chk.C) {
+	v, err := JobPartPlanFileVersion("e52247de-0323-b14d-4cc8-76e0be2e2d44--00001.steV23")
+	c.Assert(err, chk.IsNil)
+	c.Assert(v, chk.Equals, common.Version(23))
+
+	_, err = JobPartPlanFileVersion("e52247de-0323-b14d-4cc8-76e0be2e2d44.log")
+	c.Assert(err, chk.NotNil)
+}
+
+// buildOldPlan lays out a plan of a version before 24, whose transfers were 72 bytes, with the given command string,
+// and a transfer for each of the given strings
+func buildOldPlan(version common.Version, headerSize int, command string, transferStrings []string) []byte {
+	const oldTransferSize = 72
+	plan := make([]byte, headerSize)
+	*(*common.Version)(unsafe.Pointer(&plan[0])) = version
+	*(*uint32)(unsafe.Pointer(&plan[4060])) = uint32(len(command))
+	*(*uint32)(unsafe.Pointer(&plan[4064])) = uint32(len(transferStrings))
+	plan = append(plan, command...)
+
+	stringOffset := int64(len(plan) + oldTransferSize*len(transferStrings))
+	for _, str := range transferStrings {
+		transfer := make([]byte, oldTransferSize)
+		*(*int64)(unsafe.Pointer(&transfer[0])) = stringOffset
+		*(*int16)(unsafe.Pointer(&transfer[8])) = int16(len(str))
+		transfer[oldTransferSize-1] = 0x7f // so that we can check the whole transfer is carried over
+		plan = append(plan, transfer...)
+		stringOffset += int64(len(str))
+	}
+	for _, str := range transferStrings {
+		plan = append(plan, str...)
+	}
+	return plan
+}
+
+// planMigrationCase describes what one of the planMigrations must do, besides updating the version and moving the strings along
+type planMigrationCase struct {
+	oldHeaderSize int // the size of the header in the version migrated from
+
+	// prepare sets fields of the plan that the migration must keep, and padding that must not end up in the fields it adds.
+	// check checks both. transfersStart is where the transfers start in the plan given to each
+	prepare func(old []byte, transfersStart int)
+	check   func(c *chk.C, migrated []byte, transfersStart int)
+}
+
+var planMigrationCases = map[common.Version]planMigrationCase{
+	16: {
+		oldHeaderSize: 10400,
+		prepare: func(old []byte, _ int) {
+			old[4048] = byte(common.EJobPriority.Low()) // Priority, which must move along
+			old[4049], old[4050] = 0x7f, 0x7f           // padding in version 16, which must not end up as RetryLocked or Priority
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			c.Assert(migrated[4048], chk.Equals, byte(0)) // SkipLocked
+			c.Assert(migrated[4049], chk.Equals, byte(0)) // RetryLocked
+			c.Assert(migrated[4050], chk.Equals, byte(common.EJobPriority.Low()))
+		},
+	},
+	17: {
+		oldHeaderSize: 10400,
+		prepare: func(old []byte, _ int) {
+			old[10396] = byte(common.EDeleteSnapshotsOption.Include()) // DeleteSnapshotsOption, which must be kept
+			old[10397] = 0x7f                                          // padding in version 17, which must not end up as BatchDelete
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			c.Assert(migrated[10396], chk.Equals, byte(common.EDeleteSnapshotsOption.Include()))
+			c.Assert(migrated[10397], chk.Equals, byte(0))
+		},
+	},
+	18: {
+		oldHeaderSize: 10400,
+		prepare: func(old []byte, _ int) {
+			old[4050] = byte(common.EJobPriority.Low()) // Priority, which must move along
+			old[4051] = 0x7f                            // padding in version 18, which must not end up as Priority
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			c.Assert(migrated[4050], chk.Equals, byte(common.EProgressBasis.Bytes()))
+			c.Assert(migrated[4051], chk.Equals, byte(common.EJobPriority.Low()))
+		},
+	},
+	19: {
+		oldHeaderSize: 10400,
+		prepare: func(old []byte, _ int) {
+			copy(old[10388:], []byte{1, 1, 1, 2}) // the flags and S2SInvalidMetadataHandleOption, which must move along
+			*(*common.JobStatus)(unsafe.Pointer(&old[10392])) = common.EJobStatus.Paused()
+			old[10396] = byte(common.EDeleteSnapshotsOption.Include())
+			old[10397] = 1 // BatchDelete
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			c.Assert(migrated[10388], chk.Equals, byte(0)) // PreserveFileAttributes
+			c.Assert(migrated[10389:10393], chk.DeepEquals, []byte{1, 1, 1, 2})
+			c.Assert(*(*common.JobStatus)(unsafe.Pointer(&migrated[10396])), chk.Equals, common.EJobStatus.Paused())
+			c.Assert(migrated[10400], chk.Equals, byte(common.EDeleteSnapshotsOption.Include()))
+			c.Assert(migrated[10401], chk.Equals, byte(1))
+		},
+	},
+	20: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			old[10388] = 1                        // PreserveFileAttributes, which must be kept
+			copy(old[10389:], []byte{1, 1, 1, 2}) // the flags and S2SInvalidMetadataHandleOption, which must move along
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			c.Assert(migrated[10388], chk.Equals, byte(1))
+			c.Assert(migrated[10389], chk.Equals, byte(common.ETrailingDotOption.Disable()))
+			c.Assert(migrated[10390:10394], chk.DeepEquals, []byte{1, 1, 1, 2})
+		},
+	},
+	21: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			old[4068] = byte(common.ELogLevel.Warning()) // LogLevel, which must be kept
+			old[4069] = 0x7f                             // padding in version 21, which must not end up as LogFormat
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			c.Assert(migrated[4068], chk.Equals, byte(common.ELogLevel.Warning()))
+			c.Assert(migrated[4069], chk.Equals, byte(common.ELogFormat.Text()))
+		},
+	},
+	22: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			old[5366] = 1    // PutMd5, which must be kept
+			old[5367] = 0x7f // padding in version 22, which must not end up as DeltaUpdate
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			c.Assert(migrated[5366], chk.Equals, byte(1))
+			c.Assert(migrated[5367], chk.Equals, byte(0))
+		},
+	},
+	23: {
+		oldHeaderSize: 10408,
+		check: func(c *chk.C, migrated []byte, transfersStart int) {
+			for t := 0; t < 2; t++ {
+				transfer := migrated[transfersStart+t*80:]
+				c.Assert(transfer[71], chk.Equals, byte(0x7f))
+
+				// the added checkpointed byte count starts at zero
+				c.Assert(*(*int64)(unsafe.Pointer(&transfer[72])), chk.Equals, int64(0))
+			}
+		},
+	},
+	24: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			old[10394] = 0x7f // padding in version 24, which must not end up as AppendOnly
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).AppendOnly, chk.Equals, false)
+		},
+	},
+	25: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			old[10394] = 1    // AppendOnly, which must be kept
+			old[10395] = 0x7f // padding in version 25, which must not end up as SetReadOnly
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.AppendOnly, chk.Equals, true)
+			c.Assert(plan.SetReadOnly, chk.Equals, false)
+		},
+	},
+	26: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			*(*common.JobStatus)(unsafe.Pointer(&old[10396])) = common.EJobStatus.Paused()
+			old[10400] = byte(common.EDeleteSnapshotsOption.Include()) // DeleteSnapshotsOption
+			old[10401] = 1                                             // BatchDelete
+			old[10402] = 0x7f                                          // padding in version 26, which must be dropped
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.PreservePOSIX, chk.Equals, false)
+			c.Assert(plan.JobStatus(), chk.Equals, common.EJobStatus.Paused())
+			c.Assert(plan.DeleteSnapshotsOption, chk.Equals, common.EDeleteSnapshotsOption.Include())
+			c.Assert(plan.BatchDelete, chk.Equals, true)
+		},
+	},
+	27: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			old[10372], old[10373] = 0x7f, 0x7f // padding in version 27, which must not end up as MaxBlocks
+			(*JobPartPlanHeader)(unsafe.Pointer(&old[0])).DstBlobData.BlockSize = 8 * 1024 * 1024
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.DstBlobData.MaxBlocks, chk.Equals, uint16(0))
+			c.Assert(plan.DstBlobData.BlockSize, chk.Equals, int64(8*1024*1024))
+		},
+	},
+	28: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			old[10396] = 1    // PreservePOSIX, which must be kept
+			old[10397] = 0x7f // padding in version 28, which must not end up as PreserveDirectoryTimestamps
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.PreservePOSIX, chk.Equals, true)
+			c.Assert(plan.PreserveDirectoryTimestamps, chk.Equals, false)
+		},
+	},
+	29: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, transfersStart int) {
+			old[10397] = 1    // PreserveDirectoryTimestamps, which must be kept
+			old[10398] = 0x7f // padding in version 29, which must not end up as Transactional
+			for t := 0; t < 2; t++ {
+				old[transfersStart+t*80+60] = 3    // SrcBlobTagsLength, which must be kept
+				old[transfersStart+t*80+62] = 0x7f // padding in version 29, which must not end up as DstCreated
+			}
+		},
+		check: func(c *chk.C, migrated []byte, transfersStart int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.PreserveDirectoryTimestamps, chk.Equals, true)
+			c.Assert(plan.Transactional, chk.Equals, false)
+			for t := 0; t < 2; t++ {
+				transfer := (*JobPartPlanTransfer)(unsafe.Pointer(&migrated[transfersStart+t*80]))
+				c.Assert(transfer.SrcBlobTagsLength, chk.Equals, int16(3))
+				c.Assert(transfer.DstCreated, chk.Equals, false)
+			}
+		},
+	},
+	30: {
+		oldHeaderSize: 10408,
+		prepare: func(old []byte, _ int) {
+			old[10405] = 1    // BatchDelete, which must be kept
+			old[10406] = 0x7f // padding in version 30, which must not end up as ExpiryOption
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.BatchDelete, chk.Equals, true)
+			c.Assert(plan.ExpiryOption, chk.Equals, common.EBlobExpiryOption.None())
+			c.Assert(plan.ExpiryTime, chk.Equals, int64(0))
+		},
+	},
+	31: {
+		oldHeaderSize: 10416,
+		prepare: func(old []byte, _ int) {
+			old[10406] = byte(common.EBlobExpiryOption.NeverExpire()) // ExpiryOption, which must be kept
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.ExpiryOption, chk.Equals, common.EBlobExpiryOption.NeverExpire())
+			c.Assert(plan.VerifyEncryption, chk.Equals, false)
+			c.Assert(plan.ExpectedEncryptionScopeLength, chk.Equals, uint8(0))
+		},
+	},
+	32: {
+		oldHeaderSize: 10488,
+		prepare: func(old []byte, _ int) {
+			old[10416] = 1 // VerifyEncryption, which must be kept
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.VerifyEncryption, chk.Equals, true)
+			c.Assert(plan.CommandStartTime, chk.Equals, int64(0))
+		},
+	},
+	33: {
+		oldHeaderSize: 10496,
+		prepare: func(old []byte, _ int) {
+			*(*int64)(unsafe.Pointer(&old[10488])) = 12345 // CommandStartTime, which must be kept
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.CommandStartTime, chk.Equals, int64(12345))
+			c.Assert(plan.PageBlobSequenceNumber, chk.Equals, int64(0))
+		},
+	},
+	34: {
+		oldHeaderSize: 10504,
+		prepare: func(old []byte, _ int) {
+			*(*int64)(unsafe.Pointer(&old[10496])) = 42 // PageBlobSequenceNumber, which must be kept
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.PageBlobSequenceNumber, chk.Equals, int64(42))
+			c.Assert(plan.BlockIDPrefixLength, chk.Equals, uint8(0))
+		},
+	},
+	35: {
+		oldHeaderSize: 10528,
+		prepare: func(old []byte, transfersStart int) {
+			for t := 0; t < 2; t++ {
+				old[transfersStart+t*80+12] = 1    // EntityType, which must be kept
+				old[transfersStart+t*80+13] = 0x7f // padding in version 35, which must not end up as Priority
+			}
+		},
+		check: func(c *chk.C, migrated []byte, transfersStart int) {
+			for t := 0; t < 2; t++ {
+				transfer := (*JobPartPlanTransfer)(unsafe.Pointer(&migrated[transfersStart+t*80]))
+				c.Assert(transfer.EntityType, chk.Equals, common.EEntityType.Folder())
+				c.Assert(transfer.Priority, chk.Equals, common.EJobPriority.Normal())
+			}
+		},
+	},
+	36: {
+		oldHeaderSize: 10528,
+		prepare: func(old []byte, _ int) {
+			old[10398] = 1    // Transactional, which must be kept
+			old[10399] = 0x7f // padding in version 36, which must not end up as PreserveImmutability
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.Transactional, chk.Equals, true)
+			c.Assert(plan.PreserveImmutability, chk.Equals, false)
+		},
+	},
+	37: {
+		oldHeaderSize: 10528,
+		prepare: func(old []byte, _ int) {
+			old[10526] = 'x'  // the last byte of BlockIDPrefix, which must be kept
+			old[10527] = 0x7f // padding in version 37, which must not end up as ComputeSha256
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.BlockIDPrefix[BlockIDPrefixMaxBytes-1], chk.Equals, byte('x'))
+			c.Assert(plan.ComputeSha256, chk.Equals, false)
+		},
+	},
+	38: {
+		oldHeaderSize: 10528,
+		prepare: func(old []byte, _ int) {
+			old[10527] = 1 // ComputeSha256, which must be kept
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.ComputeSha256, chk.Equals, true)
+			c.Assert(plan.BlockBlobTierNameLength, chk.Equals, uint8(0))
+		},
+	},
+	39: {
+		oldHeaderSize: 10544,
+		prepare: func(old []byte, _ int) {
+			old[10538] = 'x'  // the last byte of BlockBlobTierName, which must be kept
+			old[10539] = 0x7f // padding in version 39, which must not end up as SkipSMBInfo
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.BlockBlobTierName[BlobTierMaxBytes-1], chk.Equals, byte('x'))
+			c.Assert(plan.SkipSMBInfo, chk.Equals, false)
+		},
+	},
+	40: {
+		oldHeaderSize: 10544,
+		prepare: func(old []byte, _ int) {
+			old[10539] = 1 // SkipSMBInfo, which must be kept
+			for i := 10540; i < 10544; i++ {
+				old[i] = 0x7f // padding in version 40, which must not end up as FileMode or DirMode
+			}
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.SkipSMBInfo, chk.Equals, true)
+			c.Assert(plan.FileMode, chk.Equals, uint16(0))
+			c.Assert(plan.DirMode, chk.Equals, uint16(0))
+		},
+	},
+	41: {
+		oldHeaderSize: 10544,
+		prepare: func(old []byte, _ int) {
+			old[10542] = 0xed // DirMode, which must be kept
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.DirMode, chk.Equals, uint16(0xed))
+			c.Assert(plan.S2SPreference, chk.Equals, common.ES2SPreference.Prefer())
+		},
+	},
+	42: {
+		oldHeaderSize: 10552,
+		prepare: func(old []byte, _ int) {
+			old[10544] = 1 // S2SPreference, which must be kept
+			for i := 10545; i < 10552; i++ {
+				old[i] = 0x7f // padding in version 42, which must not end up as RehydrateAndCopy
+			}
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.S2SPreference, chk.Equals, common.ES2SPreference.Never())
+			c.Assert(plan.RehydrateAndCopy, chk.Equals, false)
+		},
+	},
+	43: {
+		oldHeaderSize: 10552,
+		prepare: func(old []byte, _ int) {
+			old[10545] = 1 // RehydrateAndCopy, which must be kept
+			for i := 10546; i < 10552; i++ {
+				old[i] = 0x7f // padding in version 43, which must not end up as TierByAccessTimeLength
+			}
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.RehydrateAndCopy, chk.Equals, true)
+			c.Assert(plan.TierByAccessTimeLength, chk.Equals, uint8(0))
+		},
+	},
+	44: {
+		oldHeaderSize: 10616,
+		prepare: func(old []byte, _ int) {
+			old[10545] = 1 // RehydrateAndCopy, which must be kept
+			for i := 10611; i < 10616; i++ {
+				old[i] = 0x7f // padding in version 44, which must not end up as ClientSideEncryption
+			}
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.RehydrateAndCopy, chk.Equals, true)
+			c.Assert(plan.ClientSideEncryption, chk.Equals, false)
+		},
+	},
+	45: {
+		oldHeaderSize: 10616,
+		prepare: func(old []byte, _ int) {
+			old[10611] = 1 // ClientSideEncryption, which must be kept
+			for i := 10612; i < 10616; i++ {
+				old[i] = 0x7f // padding in version 45, which must not end up as TransactionalFailureThreshold
+			}
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.ClientSideEncryption, chk.Equals, true)
+			c.Assert(plan.TransactionalFailureThreshold, chk.Equals, uint32(0))
+		},
+	},
+	46: {
+		oldHeaderSize: 10616,
+		prepare: func(old []byte, _ int) {
+			old[10612] = 3 // TransactionalFailureThreshold, which must be kept
+		},
+		check: func(c *chk.C, migrated []byte, _ int) {
+			plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+			c.Assert(plan.TransactionalFailureThreshold, chk.Equals, uint32(3))
+			c.Assert(plan.PrefixConcurrencyLength, chk.Equals, uint16(0))
+		},
+	},
+}
+
+// planTransferSize is the size of JobPartPlanTransfer in the given data schema version
+func planTransferSize(version common.Version) int {
+	if version < 24 {
+		return 72
+	}
+	return 80
+}
+
+func (s *planMigrationSuite) TestEachMigration(c *chk.C) {
+	c.Assert(len(planMigrationCases), chk.Equals, len(planMigrations))
+	const command = "copy"
+	strs := []string{"/src/a.txt", "/src/dir/b.txt"}
+
+	plan := buildOldPlan(16, planMigrationCases[16].oldHeaderSize, command, strs)
+	for version := common.Version(16); version < DataSchemaVersion; version++ {
+		comment := chk.Commentf("migrating from version %d", version)
+		migrate, ok := planMigrations[version]
+		c.Assert(ok, chk.Equals, true, comment)
+		tc, ok := planMigrationCases[version]
+		c.Assert(ok, chk.Equals, true, comment)
+
+		newHeaderSize := int(unsafe.Sizeof(JobPartPlanHeader{}))
+		if next, ok := planMigrationCases[version+1]; ok {
+			newHeaderSize = next.oldHeaderSize
+		}
+		oldTransferSize, newTransferSize := planTransferSize(version), planTransferSize(version+1)
+
+		old := append([]byte(nil), plan...)
+		if tc.prepare != nil {
+			tc.prepare(old, tc.oldHeaderSize+len(command))
+		}
+		migrated, err := migrate(old)
+		c.Assert(err, chk.IsNil, comment)
+		c.Assert(len(migrated), chk.Equals, len(old)+newHeaderSize-tc.oldHeaderSize+len(strs)*(newTransferSize-oldTransferSize), comment)
+		c.Assert(*(*common.Version)(unsafe.Pointer(&migrated[0])), chk.Equals, version+1, comment)
+		c.Assert(string(migrated[newHeaderSize:newHeaderSize+len(command)]), chk.Equals, command, comment)
+		for i, str := range strs {
+			srcOffset := *(*int64)(unsafe.Pointer(&migrated[newHeaderSize+len(command)+i*newTransferSize]))
+			c.Assert(string(migrated[srcOffset:srcOffset+int64(len(str))]), chk.Equals, str, comment)
+		}
+		if tc.check != nil {
+			tc.check(c, migrated, newHeaderSize+len(command))
+		}
+
+		_, err = migrate(old[:tc.oldHeaderSize-1])
+		c.Assert(err, chk.NotNil, comment)
+
+		// the next step starts from a plan without the fields that this one set, since later versions may have moved them
+		plan, err = migrate(plan)
+		c.Assert(err, chk.IsNil, comment)
+	}
+}
+
+func (s *planMigrationSuite) TestMigrateReleasedV16Plan(c *chk.C) {
+	dir, err := ioutil.TempDir("", "planmigration")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	// a plan written by the last release before plans could be migrated, for an upload of two files and a folder
+	const fileName = "e52247de-0323-b14d-4cc8-76e0be2e2d44--00000.steV16"
+	old, err := ioutil.ReadFile(filepath.Join("testdata", fileName))
+	c.Assert(err, chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, fileName), old, 0644), chk.IsNil)
+
+	c.Assert(MigrateJobPlan(dir, []string{fileName}), chk.IsNil)
+
+	migrated, err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("e52247de-0323-b14d-4cc8-76e0be2e2d44--00000.steV%d", DataSchemaVersion)))
+	c.Assert(err, chk.IsNil)
+	plan := (*JobPartPlanHeader)(unsafe.Pointer(&migrated[0]))
+	c.Assert(plan.Version, chk.Equals, DataSchemaVersion)
+	c.Assert(plan.JobID.String(), chk.Equals, "e52247de-0323-b14d-4cc8-76e0be2e2d44")
+	c.Assert(plan.FromTo, chk.Equals, common.EFromTo.LocalBlob())
+	c.Assert(plan.ForceWrite, chk.Equals, common.EOverwriteOption.IfSourceNewer())
+	c.Assert(plan.Priority, chk.Equals, common.EJobPriority.Low())
+	c.Assert(plan.IsFinalPart, chk.Equals, true)
+	c.Assert(plan.LogLevel, chk.Equals, common.ELogLevel.Warning())
+	c.Assert(plan.CommandString(), chk.Equals, "copy /data/src https://account.blob.core.windows.net/container/dst --recursive")
+	c.Assert(plan.DstBlobData.BlockSize, chk.Equals, int64(8*1024*1024))
+	c.Assert(plan.DstBlobData.PutMd5, chk.Equals, true)
+	c.Assert(string(plan.DstBlobData.ContentType[:plan.DstBlobData.ContentTypeLength]), chk.Equals, "text/plain")
+	c.Assert(string(plan.DstBlobData.Metadata[:plan.DstBlobData.MetadataLength]), chk.Equals, "k=v")
+	c.Assert(plan.PreserveSMBInfo, chk.Equals, true)
+	c.Assert(plan.S2SInvalidMetadataHandleOption, chk.Equals, common.EInvalidMetadataHandleOption.RenameIfInvalid())
+	c.Assert(plan.DestLengthValidation, chk.Equals, true)
+	c.Assert(plan.JobStatus(), chk.Equals, common.EJobStatus.InProgress())
+
+	// the fields added since are what jobs created before they existed did
+	c.Assert(plan.SkipLocked, chk.Equals, false)
+	c.Assert(plan.ProgressBasis, chk.Equals, common.EProgressBasis.Bytes())
+	c.Assert(plan.PreserveFileAttributes, chk.Equals, false)
+	c.Assert(plan.TrailingDot, chk.Equals, common.ETrailingDotOption.Disable())
+	c.Assert(plan.LogFormat, chk.Equals, common.ELogFormat.Text())
+	c.Assert(plan.DstBlobData.DeltaUpdate, chk.Equals, false)
+	c.Assert(plan.BatchDelete, chk.Equals, false)
+
+	c.Assert(plan.NumTransfers, chk.Equals, uint32(3))
+	expected := []struct {
+		path   string
+		size   int64
+		folder bool
+	}{{"/a.txt", 10, false}, {"/dir/b.txt", 20, false}, {"/dir", 0, true}}
+	for i, e := range expected {
+		src, dst, isFolder := plan.TransferSrcDstStrings(uint32(i))
+		c.Assert(src, chk.Equals, "/data/src"+e.path)
+		c.Assert(dst, chk.Equals, "https://account.blob.core.windows.net/container/dst"+e.path)
+		c.Assert(isFolder, chk.Equals, e.folder)
+		c.Assert(plan.Transfer(uint32(i)).SourceSize, chk.Equals, e.size)
+		c.Assert(plan.Transfer(uint32(i)).DstCreated, chk.Equals, false)
+	}
+}
+
+func (s *planMigrationSuite) TestMigrateJobPlanAppliesEachStep(c *chk.C) {
+	dir, err := ioutil.TempDir("", "planmigration")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	jobID := common.NewJobID().String()
+	old := buildOldPlan(23, 10408, "copy", []string{"/a"})
+	old[10394], old[10395] = 1, 1 // padding in version 23, which must not end up as AppendOnly or SetReadOnly
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, jobID+"--00000.steV23"), old, 0644), chk.IsNil)
+
+	c.Assert(MigrateJobPlan(dir, []string{jobID + "--00000.steV23"}), chk.IsNil)
+
+	migrated, err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("%s--00000.steV%d", jobID, DataSchemaVersion)))
+	c.Assert(err, chk.IsNil)
+	c.Assert(*(*common.Version)(unsafe.Pointer(&migrated[0])), chk.Equals, DataSchemaVersion)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).AppendOnly, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).SetReadOnly, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).PreservePOSIX, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).DstBlobData.MaxBlocks, chk.Equals, uint16(0))
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).PreserveDirectoryTimestamps, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).Transactional, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).ExpiryOption, chk.Equals, common.EBlobExpiryOption.None())
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).VerifyEncryption, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).CommandStartTime, chk.Equals, int64(0))
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).PageBlobSequenceNumber, chk.Equals, int64(0))
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).BlockIDPrefixLength, chk.Equals, uint8(0))
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).PreserveImmutability, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).ComputeSha256, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).BlockBlobTierNameLength, chk.Equals, uint8(0))
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).SkipSMBInfo, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).FileMode, chk.Equals, uint16(0))
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).DirMode, chk.Equals, uint16(0))
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).S2SPreference, chk.Equals, common.ES2SPreference.Prefer())
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).RehydrateAndCopy, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).TierByAccessTimeLength, chk.Equals, uint8(0))
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).ClientSideEncryption, chk.Equals, false)
+	c.Assert((*JobPartPlanHeader)(unsafe.Pointer(&migrated[0])).TransactionalFailureThreshold, chk.Equals, uint32(0))
+
+	_, err = os.Stat(filepath.Join(dir, jobID+"--00000.steV23"))
+	c.Assert(os.IsNotExist(err), chk.Equals, true)
+}
+
+func (s *planMigrationSuite) TestMigrateJobPlanLeavesUnsupportedVersionsAlone(c *chk.C) {
+	dir, err := ioutil.TempDir("", "planmigration")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+
+	jobID := common.NewJobID().String()
+	supported := jobID + "--00000.steV23"
+	unsupported := jobID + "--00001.steV15"
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, supported), buildOldPlan(23, 10408, "copy", []string{"/a"}), 0644), chk.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, unsupported), []byte("old"), 0644), chk.IsNil)
+
+	err = MigrateJobPlan(dir, []string{supported, unsupported})
+	c.Assert(err, chk.NotNil)
+
+	// since one part could not be migrated, none of them are
+	_, err = os.Stat(filepath.Join(dir, supported))
+	c.Assert(err, chk.IsNil)
+	_, err = os.Stat(filepath.Join(dir, unsupported))
+	c.Assert(err, chk.IsNil)
+}
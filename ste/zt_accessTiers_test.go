@@ -0,0 +1,134 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type accessTiersSuite struct{}
+
+var _ = chk.Suite(&accessTiersSuite{})
+
+func (s *accessTiersSuite) TestContextForAccessTier(c *chk.C) {
+	defer func(old string) { DefaultServiceApiVersion = old }(DefaultServiceApiVersion)
+	DefaultServiceApiVersion = "2019-12-12"
+	ctx := context.WithValue(context.Background(), ServiceAPIVersionOverride, DefaultServiceApiVersion)
+
+	// tiers that the default service version knows of are sent with it
+	c.Assert(ContextForAccessTier(ctx, azblob.AccessTierCool).Value(ServiceAPIVersionOverride), chk.Equals, "2019-12-12")
+	c.Assert(ContextForAccessTier(ctx, azblob.AccessTierType("archive")).Value(ServiceAPIVersionOverride), chk.Equals, "2019-12-12")
+	c.Assert(ContextForAccessTier(ctx, azblob.AccessTierNone).Value(ServiceAPIVersionOverride), chk.Equals, "2019-12-12")
+
+	// newer ones aren't
+	c.Assert(ContextForAccessTier(ctx, azblob.AccessTierType("Cold")).Value(ServiceAPIVersionOverride), chk.Equals, accessTierServiceVersion)
+
+	// unless the user chose the version
+	DefaultServiceApiVersion = "2019-02-02"
+	c.Assert(ContextForAccessTier(ctx, azblob.AccessTierType("Cold")).Value(ServiceAPIVersionOverride), chk.Equals, "2019-02-02")
+	DefaultServiceApiVersion = "2023-01-03"
+	c.Assert(ServiceVersionForAccessTiers(), chk.Equals, "2023-01-03")
+}
+
+func (s *accessTiersSuite) TestBlobTierAllowedPassesUnknownTiersOnStandardAccounts(c *chk.C) {
+	defer func(possibleFail bool, sku string) { tierSetPossibleFail, destAccountSKU = possibleFail, sku }(tierSetPossibleFail, destAccountSKU)
+	tierSetPossibleFail = false
+	destAccountSKU = "Standard_LRS"
+
+	c.Assert(BlobTierAllowed(azblob.AccessTierHot), chk.Equals, true)
+	c.Assert(BlobTierAllowed(azblob.AccessTierType("Cold")), chk.Equals, true)
+	c.Assert(BlobTierAllowed(azblob.AccessTierP10), chk.Equals, false)
+}
+
+func (s *accessTiersSuite) TestParseAccessTimeTiers(c *chk.C) {
+	rules, err := ParseAccessTimeTiers("30d=cool, 90d=Archive,365d=Cold")
+	c.Assert(err, chk.IsNil)
+	c.Assert(rules, chk.DeepEquals, AccessTimeTiers{
+		{MinAge: 365 * 24 * time.Hour, Tier: azblob.AccessTierType("Cold")},
+		{MinAge: 90 * 24 * time.Hour, Tier: azblob.AccessTierArchive},
+		{MinAge: 30 * 24 * time.Hour, Tier: azblob.AccessTierCool},
+	})
+	c.Assert(rules.String(), chk.Equals, "365d=Cold,90d=Archive,30d=Cool")
+
+	for _, bad := range []string{"", "90=Archive", "90d", "-1d=Cool", "1.5d=Cool", "30d=Cool,30d=Archive", "30d=Not A Tier", "30d=AVeryLongTierName"} {
+		_, err = ParseAccessTimeTiers(bad)
+		c.Assert(err, chk.NotNil, chk.Commentf(bad))
+	}
+}
+
+func (s *accessTiersSuite) TestAccessTimeTiersTierFor(c *chk.C) {
+	rules, err := ParseAccessTimeTiers("90d=Archive,30d=Cool")
+	c.Assert(err, chk.IsNil)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	c.Assert(rules.TierFor(now.AddDate(0, 0, -200), now), chk.Equals, azblob.AccessTierArchive)
+	c.Assert(rules.TierFor(now.AddDate(0, 0, -90), now), chk.Equals, azblob.AccessTierArchive)
+	c.Assert(rules.TierFor(now.AddDate(0, 0, -45), now), chk.Equals, azblob.AccessTierCool)
+	c.Assert(rules.TierFor(now.AddDate(0, 0, -1), now), chk.Equals, azblob.AccessTierNone)
+}
+
+// lastAccessedBlob is a blob source that was last accessed at the given time
+type lastAccessedBlob struct {
+	IBlobSourceInfoProvider
+	lastAccessed time.Time
+	err          error
+}
+
+func (b lastAccessedBlob) LastAccessTime() (time.Time, error) {
+	return b.lastAccessed, b.err
+}
+
+// quietJptm is a transfer that logs nothing
+type quietJptm struct {
+	IJobPartTransferMgr
+}
+
+func (quietJptm) ShouldLog(pipeline.LogLevel) bool {
+	return false
+}
+
+func (s *accessTiersSuite) TestTierByAccessTime(c *chk.C) {
+	rules, err := ParseAccessTimeTiers("90d=Archive,30d=Cool")
+	c.Assert(err, chk.IsNil)
+
+	tier, err := tierByAccessTime(quietJptm{}, lastAccessedBlob{lastAccessed: time.Now().AddDate(0, 0, -100)}, rules, azblob.AccessTierHot)
+	c.Assert(err, chk.IsNil)
+	c.Assert(tier, chk.Equals, azblob.AccessTierArchive)
+
+	// recently accessed blobs get the tier they otherwise would
+	tier, err = tierByAccessTime(quietJptm{}, lastAccessedBlob{lastAccessed: time.Now()}, rules, azblob.AccessTierHot)
+	c.Assert(err, chk.IsNil)
+	c.Assert(tier, chk.Equals, azblob.AccessTierHot)
+
+	// as do sources that aren't blobs
+	tier, err = tierByAccessTime(quietJptm{}, &localFileSourceInfoProvider{}, rules, azblob.AccessTierNone)
+	c.Assert(err, chk.IsNil)
+	c.Assert(tier, chk.Equals, azblob.AccessTierNone)
+
+	_, err = tierByAccessTime(quietJptm{}, lastAccessedBlob{err: errors.New("forbidden")}, rules, azblob.AccessTierHot)
+	c.Assert(err, chk.NotNil)
+}
@@ -0,0 +1,40 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type blobEncryptionSuite struct{}
+
+var _ = chk.Suite(&blobEncryptionSuite{})
+
+func (s *blobEncryptionSuite) TestCheckBlobEncryption(c *chk.C) {
+	c.Assert(checkBlobEncryption("true", "", ""), chk.IsNil)
+	c.Assert(checkBlobEncryption("true", "scope1", ""), chk.IsNil) // no particular scope was asked for
+	c.Assert(checkBlobEncryption("true", "scope1", "scope1"), chk.IsNil)
+
+	c.Assert(checkBlobEncryption("false", "", ""), chk.NotNil)
+	c.Assert(checkBlobEncryption("", "", ""), chk.NotNil)
+	c.Assert(checkBlobEncryption("true", "", "scope1"), chk.ErrorMatches, ".*not encrypted with encryption scope scope1")
+	c.Assert(checkBlobEncryption("true", "scope2", "scope1"), chk.ErrorMatches, ".*encryption scope scope2, instead of scope1")
+}
@@ -0,0 +1,61 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"net/http"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type blobImmutabilitySuite struct{}
+
+var _ = chk.Suite(&blobImmutabilitySuite{})
+
+func (s *blobImmutabilitySuite) TestImmutabilityFromHeaders(c *chk.C) {
+	h := http.Header{}
+	h.Set("x-ms-immutability-policy-until-date", "Wed, 01 Jan 2031 00:00:00 GMT")
+	h.Set("x-ms-immutability-policy-mode", "locked")
+	h.Set("x-ms-legal-hold", "true")
+	i, err := immutabilityFromHeaders(h)
+	c.Assert(err, chk.IsNil)
+	c.Assert(i.policyExpiry.Equal(time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)), chk.Equals, true)
+	c.Assert(i.policyMode, chk.Equals, "Locked")
+	c.Assert(i.legalHold, chk.Equals, true)
+
+	// a legal hold alone
+	i, err = immutabilityFromHeaders(http.Header{"X-Ms-Legal-Hold": []string{"true"}})
+	c.Assert(err, chk.IsNil)
+	c.Assert(i.policyExpiry.IsZero(), chk.Equals, true)
+	c.Assert(i.legalHold, chk.Equals, true)
+
+	// nothing at all
+	i, err = immutabilityFromHeaders(http.Header{})
+	c.Assert(err, chk.IsNil)
+	c.Assert(i, chk.DeepEquals, blobImmutability{})
+
+	h.Set("x-ms-immutability-policy-mode", "sealed")
+	_, err = immutabilityFromHeaders(h)
+	c.Assert(err, chk.NotNil)
+	h.Set("x-ms-immutability-policy-until-date", "tomorrow")
+	_, err = immutabilityFromHeaders(h)
+	c.Assert(err, chk.NotNil)
+}
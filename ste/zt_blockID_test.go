@@ -0,0 +1,120 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"bytes"
+	"encoding/base64"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type blockIDSuite struct{}
+
+var _ = chk.Suite(&blockIDSuite{})
+
+func decodeBlockID(c *chk.C, encoded string) string {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	c.Assert(err, chk.IsNil)
+	return string(decoded)
+}
+
+func (s *blockIDSuite) TestEncodeBlockIDWithoutPrefix(c *chk.C) {
+	unique := common.NewUUID().String()
+
+	// without a prefix, the ID is just the unique part, as it always was
+	c.Assert(decodeBlockID(c, encodeBlockID("", 7, unique)), chk.Equals, unique)
+}
+
+func (s *blockIDSuite) TestEncodeBlockIDWithPrefix(c *chk.C) {
+	unique := common.NewUUID().String()
+	c.Assert(decodeBlockID(c, encodeBlockID("tool-", 7, unique)), chk.Equals, "tool-00007-"+unique)
+
+	// the IDs of all the blocks of a blob have the same length, and sort in the order of the blocks
+	var ids []string
+	for _, i := range []int32{common.MaxNumberOfBlocksPerBlob - 1, 10, 0, 9} {
+		ids = append(ids, decodeBlockID(c, encodeBlockID("tool-", i, common.NewUUID().String())))
+	}
+	for _, id := range ids {
+		c.Assert(len(id), chk.Equals, len(ids[0]))
+	}
+	sort.Strings(ids)
+	c.Assert(ids[0][:11], chk.Equals, "tool-00000-")
+	c.Assert(ids[1][:11], chk.Equals, "tool-00009-")
+	c.Assert(ids[2][:11], chk.Equals, "tool-00010-")
+	c.Assert(ids[3][:11], chk.Equals, "tool-49999-")
+
+	// the longest prefix allowed still gives IDs within the 64 bytes that the service allows
+	longest := strings.Repeat("p", BlockIDPrefixMaxBytes)
+	c.Assert(len(decodeBlockID(c, encodeBlockID(longest, common.MaxNumberOfBlocksPerBlob-1, unique))), chk.Equals, 64)
+}
+
+func (s *blockIDSuite) TestDeltaBlockID(c *chk.C) {
+	content := []byte("the content of a block")
+	reader := bytes.NewReader(content)
+
+	// the ID is made from the position and the content of the block, so it's the same every time they are
+	id, err := deltaBlockID("tool-", 3, reader)
+	c.Assert(err, chk.IsNil)
+	again, err := deltaBlockID("tool-", 3, bytes.NewReader(content))
+	c.Assert(err, chk.IsNil)
+	c.Assert(again, chk.Equals, id)
+	c.Assert(decodeBlockID(c, id)[:11], chk.Equals, "tool-00003-")
+
+	// the reader is left at the start, so that the block can still be uploaded
+	c.Assert(reader.Len(), chk.Equals, len(content))
+
+	// a change to either gives a different ID
+	moved, err := deltaBlockID("tool-", 4, bytes.NewReader(content))
+	c.Assert(err, chk.IsNil)
+	c.Assert(moved, chk.Not(chk.Equals), id)
+	changed, err := deltaBlockID("tool-", 3, bytes.NewReader([]byte("the content of a bloc!")))
+	c.Assert(err, chk.IsNil)
+	c.Assert(changed, chk.Not(chk.Equals), id)
+
+	// the IDs are as long as all the others, since the service rejects a blob whose block IDs differ in length
+	c.Assert(len(id), chk.Equals, len(encodeBlockID("tool-", 3, common.NewUUID().String())))
+	withoutPrefix, err := deltaBlockID("", 3, bytes.NewReader(content))
+	c.Assert(err, chk.IsNil)
+	c.Assert(len(withoutPrefix), chk.Equals, len(encodeBlockID("", 3, common.NewUUID().String())))
+}
+
+func (s *blockIDSuite) TestDeltaUpdateKeepsOnlyBlocksWithTheSameID(c *chk.C) {
+	unchanged, err := deltaBlockID("", 0, bytes.NewReader([]byte("unchanged")))
+	c.Assert(err, chk.IsNil)
+	changed, err := deltaBlockID("", 1, bytes.NewReader([]byte("changed")))
+	c.Assert(err, chk.IsNil)
+	appended, err := deltaBlockID("", 2, bytes.NewReader([]byte("appended")))
+	c.Assert(err, chk.IsNil)
+
+	u := &blockBlobUploader{existingBlocks: []azblob.Block{{Name: unchanged}, {Name: encodeBlockID("", 1, common.NewUUID().String())}}}
+	c.Assert(u.blockIsUnchanged(0, unchanged), chk.Equals, true)
+	c.Assert(u.blockIsUnchanged(1, changed), chk.Equals, false)
+	c.Assert(u.blockIsUnchanged(2, appended), chk.Equals, false)
+
+	// a block that has only moved is uploaded again, since its ID depends on where it is
+	moved, err := deltaBlockID("", 1, bytes.NewReader([]byte("unchanged")))
+	c.Assert(err, chk.IsNil)
+	c.Assert(u.blockIsUnchanged(1, moved), chk.Equals, false)
+}
@@ -0,0 +1,49 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type blockSizeSuite struct{}
+
+var _ = chk.Suite(&blockSizeSuite{})
+
+func (s *blockSizeSuite) TestBlockSizeForMaxBlocks(c *chk.C) {
+	const mib = 1024 * 1024
+
+	// the size is rounded up to a whole MiB
+	c.Assert(blockSizeForMaxBlocks(100*mib, 10), chk.Equals, int64(10*mib))
+	c.Assert(blockSizeForMaxBlocks(100*mib+1, 10), chk.Equals, int64(11*mib))
+
+	// small and empty files still get a 1 MiB block
+	c.Assert(blockSizeForMaxBlocks(0, 10), chk.Equals, int64(mib))
+	c.Assert(blockSizeForMaxBlocks(1000, 10), chk.Equals, int64(mib))
+
+	// whatever the size, the file fits in the requested number of blocks
+	for _, size := range []int64{1, mib - 1, 7*mib + 3, 1024 * 1024 * mib} {
+		blockSize := blockSizeForMaxBlocks(size, common.MaxNumberOfBlocksPerBlob)
+		c.Assert((size+blockSize-1)/blockSize <= common.MaxNumberOfBlocksPerBlob, chk.Equals, true)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"encoding/base64"
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type checkpointSuite struct{}
+
+var _ = chk.Suite(&checkpointSuite{})
+
+func (s *checkpointSuite) TestTransferCheckpointCountsOnlyLeadingChunks(c *chk.C) {
+	cp := newTransferCheckpoint(&JobPartPlanTransfer{})
+	c.Assert(cp.resumeFrom, chk.Equals, int64(0))
+
+	// chunks that complete ahead of an earlier one are not counted until the earlier one completes
+	c.Assert(cp.chunkSent(10, 10), chk.Equals, int64(0))
+	c.Assert(cp.chunkSent(30, 5), chk.Equals, int64(0))
+	c.Assert(cp.chunkSent(0, 10), chk.Equals, int64(20))
+	c.Assert(cp.chunkSent(20, 10), chk.Equals, int64(35))
+	c.Assert(cp.ahead, chk.HasLen, 0)
+
+	// a chunk that is reported again is ignored
+	c.Assert(cp.chunkSent(0, 10), chk.Equals, int64(35))
+}
+
+func (s *checkpointSuite) TestTransferCheckpointResumesFromPlan(c *chk.C) {
+	jppt := &JobPartPlanTransfer{}
+	jppt.SetCheckpointedBytes(1024)
+	cp := newTransferCheckpoint(jppt)
+	c.Assert(cp.resumeFrom, chk.Equals, int64(1024))
+}
+
+func (s *checkpointSuite) TestCheckpointBlockID(c *chk.C) {
+	info := TransferInfo{Source: "/src/file", Destination: "https://account.blob.core.windows.net/container/file"}
+	lmt := time.Unix(1600000000, 0)
+	chunk := common.NewChunkID("/src/file", "", 0, 8*1024*1024)
+
+	id := checkpointBlockID(info, lmt, chunk, "", 0)
+
+	// the same chunk of the same source always gets the same ID...
+	c.Assert(checkpointBlockID(info, lmt, chunk, "", 0), chk.Equals, id)
+
+	// ...with the same length as the usual, random, IDs
+	c.Assert(len(id), chk.Equals, len(base64.StdEncoding.EncodeToString([]byte(common.NewUUID().String()))))
+
+	// but a different chunk, or a changed source, gets a different one
+	c.Assert(checkpointBlockID(info, lmt, common.NewChunkID("/src/file", "", 8*1024*1024, 8*1024*1024), "", 1), chk.Not(chk.Equals), id)
+	c.Assert(checkpointBlockID(info, lmt.Add(time.Second), chunk, "", 0), chk.Not(chk.Equals), id)
+
+	// with a block ID prefix, it is the same length as the usual IDs with that prefix
+	c.Assert(len(checkpointBlockID(info, lmt, chunk, "tool-", 0)), chk.Equals, len(encodeBlockID("tool-", 0, common.NewUUID().String())))
+}
@@ -0,0 +1,75 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type chunkFairnessSuite struct{}
+
+var _ = chk.Suite(&chunkFairnessSuite{})
+
+func (s *chunkFairnessSuite) TestFairChunkQueueRotatesAmongTransfers(c *chk.C) {
+	q := newFairChunkQueue(100)
+	var ran []string
+	chunk := func(name string) chunkFunc {
+		return func(int) { ran = append(ran, name) }
+	}
+
+	// a large transfer schedules all its chunks before two small ones get to schedule theirs
+	for _, name := range []string{"big1", "big2", "big3", "big4"} {
+		q.enqueue("big", chunk(name))
+	}
+	q.enqueue("small-a", chunk("a1"))
+	q.enqueue("small-b", chunk("b1"))
+	q.enqueue("small-b", chunk("b2"))
+
+	for {
+		cf, ok := q.tryDequeue()
+		if !ok {
+			break
+		}
+		cf(0)
+	}
+	c.Assert(ran, chk.DeepEquals, []string{"big1", "a1", "b1", "big2", "b2", "big3", "big4"})
+	c.Assert(q.transfers, chk.HasLen, 0)
+	c.Assert(q.slots, chk.HasLen, 0)
+
+	// a transfer that comes back after draining joins the end of the rotation again
+	ran = nil
+	q.enqueue("small-a", chunk("a2"))
+	cf, ok := q.tryDequeue()
+	c.Assert(ok, chk.Equals, true)
+	cf(0)
+	c.Assert(ran, chk.DeepEquals, []string{"a2"})
+}
+
+func (s *chunkFairnessSuite) TestParseChunkFairness(c *chk.C) {
+	var f common.ChunkFairness
+	c.Assert(f.Parse("round-robin"), chk.IsNil)
+	c.Assert(f, chk.Equals, common.EChunkFairness.RoundRobin())
+	c.Assert(f.Parse("none"), chk.IsNil)
+	c.Assert(f, chk.Equals, common.EChunkFairness.None())
+	c.Assert(f.Parse("random"), chk.NotNil)
+}
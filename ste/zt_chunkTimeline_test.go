@@ -0,0 +1,88 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type chunkTimelineSuite struct{}
+
+var _ = chk.Suite(&chunkTimelineSuite{})
+
+// fakeChunkTimelineSamples returns each of the given counts in turn, for the upload direction,
+// after first reporting that the direction is not yet known
+func fakeChunkTimelineSamples(counts ...[]int64) chunkTimelineSample {
+	calls := 0
+	return func() (common.TransferDirection, []string, []int64) {
+		calls++
+		if calls == 1 {
+			return common.ETransferDirection.UnKnown(), nil, nil
+		}
+		return common.ETransferDirection.Upload(), []string{"Disk", "Body"}, counts[calls-2]
+	}
+}
+
+func (s *chunkTimelineSuite) TestChunkTimelineWritesCSV(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "timeline.csv")
+	t, err := newChunkTimeline(path, fakeChunkTimelineSamples([]int64{3, 1}, []int64{0, 4}))
+	c.Assert(err, chk.IsNil)
+
+	t.record(1) // direction not known yet, so nothing is written
+	t.record(2)
+	t.record(3)
+	c.Assert(t.close(), chk.IsNil)
+
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(data), chk.Equals, "ElapsedSeconds,Direction,Disk,Body\n2,Upload,3,1\n3,Upload,0,4\n")
+}
+
+func (s *chunkTimelineSuite) TestChunkTimelineWritesJSON(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "timeline.JSON")
+	t, err := newChunkTimeline(path, fakeChunkTimelineSamples([]int64{3, 1}, []int64{0, 4}))
+	c.Assert(err, chk.IsNil)
+
+	t.record(1)
+	t.record(2)
+	t.record(3)
+	c.Assert(t.close(), chk.IsNil)
+	c.Assert(t.close(), chk.IsNil) // closing again is harmless
+
+	f, err := os.Open(path)
+	c.Assert(err, chk.IsNil)
+	defer f.Close()
+	var out chunkTimelineJSON
+	c.Assert(json.NewDecoder(f).Decode(&out), chk.IsNil)
+	c.Assert(out, chk.DeepEquals, chunkTimelineJSON{
+		Direction:      "Upload",
+		WaitReasons:    []string{"Disk", "Body"},
+		ElapsedSeconds: []int64{2, 3},
+		Counts:         [][]int64{{3, 0}, {1, 4}},
+	})
+}
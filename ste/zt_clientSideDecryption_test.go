@@ -0,0 +1,114 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type clientSideDecryptionSuite struct{}
+
+var _ = chk.Suite(&clientSideDecryptionSuite{})
+
+// decryptingJptm provides only what creating the destination file of a download asks of the transfer
+type decryptingJptm struct {
+	IJobPartTransferMgr
+	info       TransferInfo
+	key        common.ClientSideEncryptionKey
+	decompress bool
+}
+
+func (j *decryptingJptm) Info() TransferInfo       { return j.info }
+func (j *decryptingJptm) GetForceIfReadOnly() bool { return false }
+func (j *decryptingJptm) ShouldDecompress() bool   { return j.decompress }
+func (j *decryptingJptm) GetSourceCompressionType() (common.CompressionType, error) {
+	return common.ECompressionType.GZip(), nil
+}
+func (j *decryptingJptm) ClientSideEncryptionKey() (common.ClientSideEncryptionKey, bool, error) {
+	return j.key, true, nil
+}
+func (j *decryptingJptm) GetFolderCreationTracker() common.FolderCreationTracker {
+	return common.NewFolderCreationTracker(common.EFolderPropertiesOption.NoFolders())
+}
+func (j *decryptingJptm) LogAtLevelForCurrentTransfer(level pipeline.LogLevel, msg string) {}
+
+// saveEncryptedCompressedFile saves plaintext through createDestinationFile, as it's downloaded when it was compressed before
+// it was encrypted and uploaded, as a gzipped file uploaded with client-side-encryption-key is. Returns what ends up in the file
+func saveEncryptedCompressedFile(c *chk.C, plaintext []byte, sha256Hasher hash.Hash) []byte {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write(plaintext)
+	c.Assert(err, chk.IsNil)
+	c.Assert(zw.Close(), chk.IsNil)
+
+	key := common.ClientSideEncryptionKey{KeyID: "mykey", Key: bytes.Repeat([]byte{7}, 32)}
+	e, err := common.NewClientSideEncryptor(key)
+	c.Assert(err, chk.IsNil)
+	ciphertext, err := e.Encrypt(compressed.Bytes())
+	c.Assert(err, chk.IsNil)
+
+	dir, err := ioutil.TempDir("", "clientsidedecryption")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	destination := filepath.Join(dir, "file")
+
+	jptm := &decryptingJptm{
+		info:       TransferInfo{SrcProperties: SrcProperties{SrcMetadata: common.Metadata{common.ClientSideEncryptionMetadataKey: e.Envelope()}}},
+		key:        key,
+		decompress: true,
+	}
+	dstFile, err := createDestinationFile(jptm, destination, int64(len(ciphertext)), false, sha256Hasher)
+	c.Assert(err, chk.IsNil)
+	_, err = dstFile.Write(ciphertext)
+	c.Assert(err, chk.IsNil)
+	c.Assert(dstFile.Close(), chk.IsNil)
+
+	written, err := ioutil.ReadFile(destination)
+	c.Assert(err, chk.IsNil)
+	return written
+}
+
+func (s *clientSideDecryptionSuite) TestCompressedBlobIsDecryptedBeforeItIsDecompressed(c *chk.C) {
+	plaintext := bytes.Repeat([]byte("the content of the file "), 1000)
+	written := saveEncryptedCompressedFile(c, plaintext, nil)
+	c.Assert(bytes.Equal(written, plaintext), chk.Equals, true)
+}
+
+func (s *clientSideDecryptionSuite) TestSha256IsOfTheFileAsSaved(c *chk.C) {
+	plaintext := bytes.Repeat([]byte("the content of the file "), 1000)
+	sha256Hasher := sha256.New()
+	saveEncryptedCompressedFile(c, plaintext, sha256Hasher)
+
+	// not of the data that was downloaded, which was compressed and encrypted
+	expected := sha256.Sum256(plaintext)
+	c.Assert(sha256Hasher.Sum(nil), chk.DeepEquals, expected[:])
+}
@@ -170,6 +170,21 @@ func (s *concurrencyTunerSuite) TestConcurrencyTuner__HighBandwidthWorkaround_St
 	s.runTest(c, steps, s.noMax(), true, true)
 }
 
+func (s *concurrencyTunerSuite) TestConcurrencyTuner_BacksOffWhenThrottledIfNotBenchmarking(c *chk.C) {
+	steps := []tunerStep{
+		{4, concurrencyReasonInitial, 400, false},
+		{16, concurrencyReasonSeeking, 1000, false}, // throughput went up...
+		{4, concurrencyReasonThrottled, 400, false}, // ... but the service said it was busy, so back off anyway
+		{8, concurrencyReasonSeeking, 800, false},   // then probe more gently
+		{4, concurrencyReasonThrottled, 400, false}, // still throttled
+		{5, concurrencyReasonSeeking, 400, false},   // probe by the smallest worthwhile step
+		{4, concurrencyReasonAtOptimum, 400, false}, // and settle
+		{4, concurrencyReasonFinished, 400, false},
+	}
+
+	s.runTest(c, steps, s.noMax(), false, true)
+}
+
 func (s *concurrencyTunerSuite) runTest(c *chk.C, steps []tunerStep, maxConcurrency int, isBenchmarking bool, simulateRetries bool) {
 	t := NewAutoConcurrencyTuner(4, maxConcurrency, isBenchmarking)
 	observedMbps := -1 // there's no observation at first
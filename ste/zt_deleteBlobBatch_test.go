@@ -0,0 +1,182 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type deleteBlobBatchSuite struct{}
+
+var _ = chk.Suite(&deleteBlobBatchSuite{})
+
+// batchDeleteJptm is the deletion of one blob, which records the status it ends with
+type batchDeleteJptm struct {
+	IJobPartTransferMgr
+	source string
+	status common.TransferStatus
+	done   bool
+}
+
+func (j *batchDeleteJptm) Info() TransferInfo {
+	return TransferInfo{Source: j.source, Destination: j.source}
+}
+func (j *batchDeleteJptm) TransferID() string       { return j.source }
+func (j *batchDeleteJptm) Context() context.Context { return context.Background() }
+func (j *batchDeleteJptm) DeleteSnapshotsOption() common.DeleteSnapshotsOption {
+	return common.EDeleteSnapshotsOption.None()
+}
+func (j *batchDeleteJptm) WasCanceled() bool                                { return false }
+func (j *batchDeleteJptm) ScheduleChunks(cf chunkFunc)                      { cf(0) }
+func (j *batchDeleteJptm) ReportChunkDone(common.ChunkID) (bool, uint32)    { return true, 1 }
+func (j *batchDeleteJptm) OccupyAConnection()                               {}
+func (j *batchDeleteJptm) ReleaseAConnection()                              {}
+func (j *batchDeleteJptm) LogChunkStatus(common.ChunkID, common.WaitReason) {}
+func (j *batchDeleteJptm) SetDestinationIsModified()                        {}
+func (j *batchDeleteJptm) Log(pipeline.LogLevel, string)                    {}
+func (j *batchDeleteJptm) LogError(string, string, error)                   {}
+func (j *batchDeleteJptm) SetStatus(status common.TransferStatus)           { j.status = status }
+func (j *batchDeleteJptm) ReportTransferDone() uint32                       { j.done = true; return 0 }
+
+// batchDeleteServer answers batch requests with the given status for each blob, leaving out the blobs that have none,
+// and answers single deletes with 202. It records the requests that were made.
+func batchDeleteServer(c *chk.C, batchStatus int, blobStatus map[string]string, requests *[]string) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		c.Check(r.URL.Query().Get("comp"), chk.Equals, "batch")
+		c.Check(r.URL.Query().Get("restype"), chk.Equals, "container")
+		if batchStatus != http.StatusAccepted {
+			w.WriteHeader(batchStatus)
+			return
+		}
+
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		c.Assert(err, chk.IsNil)
+		var response strings.Builder
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			body, _ := ioutil.ReadAll(part)
+			blob := strings.Fields(string(body))[1]
+			blob = blob[strings.LastIndex(blob, "/")+1 : strings.Index(blob, "?")]
+			if status, ok := blobStatus[blob]; ok {
+				fmt.Fprintf(&response, "--batchresponse\r\nContent-Type: application/http\r\nContent-ID: %s\r\n\r\nHTTP/1.1 %s\r\n\r\n",
+					part.Header.Get("Content-ID"), status)
+			}
+		}
+		response.WriteString("--batchresponse--\r\n")
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresponse")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(response.String()))
+	}))
+}
+
+// deleteInOneBatch sends the deletion of the given blobs as one batch, and returns the transfers
+func deleteInOneBatch(serverURL string, blobs ...string) []*batchDeleteJptm {
+	p := azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{})
+	containerURL, _ := url.Parse(serverURL + "/container?sig=x")
+	d := &blobBatchDeleter{p: p, containerURL: *containerURL}
+	batch := make([]IJobPartTransferMgr, 0, len(blobs))
+	transfers := make([]*batchDeleteJptm, 0, len(blobs))
+	for _, blob := range blobs {
+		jptm := &batchDeleteJptm{source: serverURL + "/container/" + blob + "?sig=x"}
+		transfers = append(transfers, jptm)
+		batch = append(batch, jptm)
+	}
+	d.send(batch)
+	return transfers
+}
+
+func (s *deleteBlobBatchSuite) TestSubResponsesAreReportedAgainstTheirOwnTransfers(c *chk.C) {
+	var requests []string
+	server := batchDeleteServer(c, http.StatusAccepted, map[string]string{
+		"deleted":   "202 Accepted",
+		"gone":      "404 Not Found",
+		"forbidden": "409 Conflict",
+	}, &requests)
+	defer server.Close()
+
+	transfers := deleteInOneBatch(server.URL, "deleted", "gone", "forbidden", "unanswered")
+	c.Assert(requests, chk.DeepEquals, []string{"POST /container"})
+	c.Assert(transfers[0].status, chk.Equals, common.ETransferStatus.Success())
+	c.Assert(transfers[1].status, chk.Equals, common.ETransferStatus.Success())
+	c.Assert(transfers[2].status, chk.Equals, common.ETransferStatus.Failed())
+	c.Assert(transfers[3].status, chk.Equals, common.ETransferStatus.Failed())
+	for _, jptm := range transfers {
+		c.Assert(jptm.done, chk.Equals, true)
+	}
+}
+
+func (s *deleteBlobBatchSuite) TestRejectedBatchFallsBackToSingleDeletes(c *chk.C) {
+	var requests []string
+	server := batchDeleteServer(c, http.StatusBadRequest, nil, &requests)
+	defer server.Close()
+
+	transfers := deleteInOneBatch(server.URL, "a", "b")
+	c.Assert(requests, chk.DeepEquals, []string{"POST /container", "DELETE /container/a", "DELETE /container/b"})
+	for _, jptm := range transfers {
+		c.Assert(jptm.status, chk.Equals, common.ETransferStatus.Success())
+		c.Assert(jptm.done, chk.Equals, true)
+	}
+}
+
+func (s *deleteBlobBatchSuite) TestIdleDeleterIsForgotten(c *chk.C) {
+	p := azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{})
+	jptm := &batchDeleteJptm{source: "https://account.blob.core.windows.net/container/blob?sig=x"}
+
+	d := getBlobBatchDeleter(jptm, p)
+	c.Assert(d, chk.NotNil)
+	c.Assert(getBlobBatchDeleter(jptm, p), chk.Equals, d)
+
+	d.flush()
+	blobBatchDeleters.Lock()
+	_, ok := blobBatchDeleters.m[d.key]
+	blobBatchDeleters.Unlock()
+	c.Assert(ok, chk.Equals, false)
+	next := getBlobBatchDeleter(jptm, p)
+	c.Assert(next, chk.Not(chk.Equals), d)
+	next.flush()
+}
@@ -0,0 +1,52 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"errors"
+	"net"
+	"net/url"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type dialerSuite struct{}
+
+var _ = chk.Suite(&dialerSuite{})
+
+func (s *dialerSuite) TestDNSErrorsAreMadeTemporary(c *chk.C) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "account.blob.core.windows.net", IsNotFound: true}
+	err := markDNSErrorTemporary(&net.OpError{Op: "dial", Net: "tcp", Err: dnsErr})
+
+	// the error is seen as the retry policies see it: wrapped by the HTTP client, and then by the pipeline
+	wrapped := pipeline.NewError(&url.Error{Op: "Get", URL: "https://account.blob.core.windows.net/", Err: err}, "HTTP request failed")
+	netErr, ok := wrapped.(net.Error)
+	c.Assert(ok, chk.Equals, true)
+	c.Assert(netErr.Temporary(), chk.Equals, true)
+	c.Assert(dnsErr.IsTemporary, chk.Equals, false) // the resolver's own error is left alone
+
+	// other dial errors are left as they were
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	c.Assert(markDNSErrorTemporary(refused), chk.Equals, error(refused))
+	c.Assert(refused.Temporary(), chk.Equals, false)
+	c.Assert(markDNSErrorTemporary(nil), chk.IsNil)
+}
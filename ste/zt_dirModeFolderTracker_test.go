@@ -0,0 +1,75 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type dirModeFolderTrackerSuite struct{}
+
+var _ = chk.Suite(&dirModeFolderTrackerSuite{})
+
+// failOnErrorLogger fails the test if anything is logged at error level
+type failOnErrorLogger struct {
+	c *chk.C
+}
+
+func (l failOnErrorLogger) ShouldLog(level pipeline.LogLevel) bool { return true }
+func (l failOnErrorLogger) Panic(err error)                        { panic(err) }
+func (l failOnErrorLogger) Log(level pipeline.LogLevel, msg string) {
+	if level <= pipeline.LogError {
+		l.c.Error(msg)
+	}
+}
+
+func (s *dirModeFolderTrackerSuite) TestCreatedDirectoriesGetMode(c *chk.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("Windows has no permission bits to set")
+	}
+	root, err := ioutil.TempDir("", "dirmode")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(root)
+
+	existing := filepath.Join(root, "existing")
+	c.Assert(os.Mkdir(existing, 0700), chk.IsNil)
+	c.Assert(os.Chmod(existing, 0700), chk.IsNil) // so that the process umask doesn't matter
+	tracker := newDirModeFolderTracker(common.NewFolderCreationTracker(common.EFolderPropertiesOption.AllFolders()), 0750, failOnErrorLogger{c})
+
+	// both parents of the file are created, so both get the mode, but the directory that already existed keeps its own
+	c.Assert(common.CreateParentDirectoryIfNotExist(filepath.Join(existing, "a", "b", "file"), tracker), chk.IsNil)
+	for _, dir := range []string{filepath.Join(existing, "a"), filepath.Join(existing, "a", "b")} {
+		fi, err := os.Stat(dir)
+		c.Assert(err, chk.IsNil)
+		c.Assert(fi.Mode().Perm(), chk.Equals, os.FileMode(0750))
+		c.Assert(tracker.ShouldSetProperties(dir, common.EOverwriteOption.False(), nil), chk.Equals, true)
+	}
+	fi, err := os.Stat(existing)
+	c.Assert(err, chk.IsNil)
+	c.Assert(fi.Mode().Perm(), chk.Equals, os.FileMode(0700))
+}
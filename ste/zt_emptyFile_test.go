@@ -0,0 +1,114 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type emptyFileSuite struct{}
+
+var _ = chk.Suite(&emptyFileSuite{})
+
+// emptyDownloadJptm provides what a download of an empty file asks of the transfer, on top of its epilogue
+type emptyDownloadJptm struct {
+	completingJptm
+}
+
+func (j *emptyDownloadJptm) GetOverwriteOption() common.OverwriteOption {
+	return common.EOverwriteOption.True()
+}
+func (j *emptyDownloadJptm) IsAppendOnly() bool { return false }
+func (j *emptyDownloadJptm) MD5ValidationOption() common.HashValidationOption {
+	return common.EHashValidationOption.NoCheck()
+}
+func (j *emptyDownloadJptm) SetDestinationIsModified()                          {}
+func (j *emptyDownloadJptm) ShouldComputeSha256() bool                          { return false }
+func (j *emptyDownloadJptm) WaitUntilLockDestination(ctx context.Context) error { return nil }
+func (j *emptyDownloadJptm) Context() context.Context                           { return context.Background() }
+func (j *emptyDownloadJptm) GetFolderCreationTracker() common.FolderCreationTracker {
+	return common.NewFolderCreationTracker(common.EFolderPropertiesOption.NoFolders())
+}
+
+// emptySourceDownloader is a downloader for a source that has no content, so must never be asked for any
+type emptySourceDownloader struct {
+	c         *chk.C
+	prologued bool
+	epilogued bool
+}
+
+func (d *emptySourceDownloader) Prologue(jptm IJobPartTransferMgr, srcPipeline pipeline.Pipeline) {
+	d.prologued = true
+}
+func (d *emptySourceDownloader) GenerateDownloadFunc(jptm IJobPartTransferMgr, srcPipeline pipeline.Pipeline, writer common.ChunkedFileWriter, id common.ChunkID, length int64, pacer pacer) chunkFunc {
+	d.c.Fatal("an empty file has nothing to download")
+	return nil
+}
+func (d *emptySourceDownloader) Epilogue() { d.epilogued = true }
+
+func (s *emptyFileSuite) TestEmptyFileIsDownloadedAsAnEmptyFile(c *chk.C) {
+	dir, err := ioutil.TempDir("", "emptyfile")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "sub", "empty.txt")
+
+	lastModified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	jptm := &emptyDownloadJptm{completingJptm: completingJptm{lastModified: lastModified,
+		info: TransferInfo{Source: "https://acct.blob.core.windows.net/c/sub/empty.txt", Destination: dst, SourceSize: 0, BlockSize: 4}}}
+	dl := &emptySourceDownloader{c: c}
+
+	remoteToLocal_file(jptm, nil, nil, func() downloader { return dl })
+	c.Assert(jptm.failure, chk.IsNil)
+	c.Assert(jptm.status, chk.Equals, common.ETransferStatus.Success())
+	c.Assert(dl.prologued, chk.Equals, true)
+	c.Assert(dl.epilogued, chk.Equals, true)
+
+	fi, err := os.Stat(dst)
+	c.Assert(err, chk.IsNil)
+	c.Assert(fi.Size(), chk.Equals, int64(0))
+	c.Assert(fi.ModTime().Equal(lastModified), chk.Equals, true)
+}
+
+func (s *emptyFileSuite) TestEmptyFileReplacesExistingContent(c *chk.C) {
+	dir, err := ioutil.TempDir("", "emptyfile")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "empty.txt")
+	c.Assert(ioutil.WriteFile(dst, []byte("old content"), 0644), chk.IsNil)
+
+	jptm := &emptyDownloadJptm{completingJptm: completingJptm{lastModified: time.Now(),
+		info: TransferInfo{Source: "https://acct.blob.core.windows.net/c/empty.txt", Destination: dst, SourceSize: 0, BlockSize: 4}}}
+	remoteToLocal_file(jptm, nil, nil, func() downloader { return &emptySourceDownloader{c: c} })
+	c.Assert(jptm.status, chk.Equals, common.ETransferStatus.Success())
+
+	fi, err := os.Stat(dst)
+	c.Assert(err, chk.IsNil)
+	c.Assert(fi.Size(), chk.Equals, int64(0))
+}
@@ -0,0 +1,84 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+	chk "gopkg.in/check.v1"
+)
+
+type enumerationLookaheadSuite struct{}
+
+var _ = chk.Suite(&enumerationLookaheadSuite{})
+
+func newLookaheadTestJobMgr(ctx context.Context) *jobMgr {
+	logger := common.NewJobLogger(common.NewJobID(), common.ELogLevel.None(), common.ELogFormat.Text(), common.NewAppLogger(0, ""), "", common.LogRotationPolicy{}, nil)
+	return &jobMgr{ctx: ctx, logger: logger}
+}
+
+func (s *enumerationLookaheadSuite) TestPartWaitsUntilTransfersAreDone(c *chk.C) {
+	jm := newLookaheadTestJobMgr(context.Background())
+
+	jm.waitForEnumerationLookahead(10, 15) // nothing is pending, so the first part goes straight through
+	c.Assert(atomic.LoadInt64(&jm.atomicPendingTransfers), chk.Equals, int64(10))
+
+	ordered := make(chan struct{})
+	go func() {
+		jm.waitForEnumerationLookahead(10, 15)
+		close(ordered)
+	}()
+
+	select {
+	case <-ordered:
+		c.Fatal("the part was ordered while there was no room for it")
+	case <-time.After(2 * enumerationLookaheadPollInterval):
+	}
+
+	for i := 0; i < 5; i++ {
+		jm.reportTransferDone()
+	}
+	select {
+	case <-ordered:
+	case <-time.After(10 * enumerationLookaheadPollInterval):
+		c.Fatal("the part was not ordered once there was room for it")
+	}
+	c.Assert(atomic.LoadInt64(&jm.atomicPendingTransfers), chk.Equals, int64(15))
+}
+
+func (s *enumerationLookaheadSuite) TestNoLookaheadNeverWaits(c *chk.C) {
+	jm := newLookaheadTestJobMgr(context.Background())
+	jm.waitForEnumerationLookahead(10000, 0)
+	jm.waitForEnumerationLookahead(10000, 0)
+	c.Assert(atomic.LoadInt64(&jm.atomicPendingTransfers), chk.Equals, int64(20000))
+}
+
+func (s *enumerationLookaheadSuite) TestCancellingTheJobStopsTheWait(c *chk.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	jm := newLookaheadTestJobMgr(ctx)
+	jm.waitForEnumerationLookahead(10, 10)
+
+	cancel()
+	jm.waitForEnumerationLookahead(10, 10) // returns, rather than waiting for transfers that will never finish
+}
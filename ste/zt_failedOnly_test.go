@@ -0,0 +1,60 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type failedOnlySuite struct{}
+
+var _ = chk.Suite(&failedOnlySuite{})
+
+func (s *failedOnlySuite) TestOnlyFailuresAreRetried(c *chk.C) {
+	for _, ts := range []common.TransferStatus{common.ETransferStatus.Failed(), common.ETransferStatus.BlobTierFailure(), common.ETransferStatus.TierAvailabilityCheckFailure()} {
+		c.Assert(ts.IsFailure(), chk.Equals, true, chk.Commentf("%v", ts))
+		c.Assert(leftByFailedOnlyResume(ts), chk.Equals, false)
+	}
+
+	// transfers that neither failed nor finished are left for a later resume
+	for _, ts := range []common.TransferStatus{common.ETransferStatus.NotStarted(), common.ETransferStatus.Started(), common.ETransferStatus.Cancelled()} {
+		c.Assert(ts.IsFailure(), chk.Equals, false, chk.Commentf("%v", ts))
+		c.Assert(leftByFailedOnlyResume(ts), chk.Equals, true, chk.Commentf("%v", ts))
+	}
+
+	// while skipped transfers are done
+	for _, ts := range []common.TransferStatus{common.ETransferStatus.SkippedEntityAlreadyExists(), common.ETransferStatus.SkippedFileLocked()} {
+		c.Assert(ts.IsFailure(), chk.Equals, false, chk.Commentf("%v", ts))
+		c.Assert(leftByFailedOnlyResume(ts), chk.Equals, false, chk.Commentf("%v", ts))
+	}
+}
+
+func (s *failedOnlySuite) TestJobWithTransfersLeftIsNotComplete(c *chk.C) {
+	c.Assert(finalJobStatus(jobPartProgressInfo{transfersCompleted: 3}), chk.Equals, common.EJobStatus.Completed())
+	c.Assert(finalJobStatus(jobPartProgressInfo{transfersCompleted: 3, transfersFailed: 1}), chk.Equals, common.EJobStatus.CompletedWithErrors())
+
+	// the retried failures all succeeded, but the transfers left mean the job must still be resumed
+	status := finalJobStatus(jobPartProgressInfo{transfersCompleted: 3, transfersLeft: 2})
+	c.Assert(status, chk.Equals, common.EJobStatus.Cancelled())
+	c.Assert(status.IsJobDone(), chk.Equals, true)
+}
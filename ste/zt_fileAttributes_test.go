@@ -0,0 +1,126 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type fileAttributesSuite struct{}
+
+var _ = chk.Suite(&fileAttributesSuite{})
+
+// completingJptm provides only what the epilogue of a download of an empty file asks of the transfer
+type completingJptm struct {
+	IJobPartTransferMgr
+	info         TransferInfo
+	lastModified time.Time
+	status       common.TransferStatus
+	failure      error
+}
+
+func (j *completingJptm) Info() TransferInfo                                         { return j.info }
+func (j *completingJptm) LogChunkStatus(id common.ChunkID, reason common.WaitReason) {}
+func (j *completingJptm) WasCanceled() bool                                          { return false }
+func (j *completingJptm) ClientSideEncryptionKey() (common.ClientSideEncryptionKey, bool, error) {
+	return common.ClientSideEncryptionKey{}, false, nil
+}
+func (j *completingJptm) IsLive() bool                                                     { return j.failure == nil }
+func (j *completingJptm) IsDeadInflight() bool                                             { return j.failure != nil }
+func (j *completingJptm) IsDeadBeforeStart() bool                                          { return false }
+func (j *completingJptm) PreserveLastModifiedTime() (time.Time, bool)                      { return j.lastModified, true }
+func (j *completingJptm) FailActiveDownload(where string, err error)                       { j.failure = err }
+func (j *completingJptm) SetStatus(status common.TransferStatus)                           { j.status = status }
+func (j *completingJptm) ShouldLog(level pipeline.LogLevel) bool                           { return false }
+func (j *completingJptm) Log(level pipeline.LogLevel, msg string)                          {}
+func (j *completingJptm) LogAtLevelForCurrentTransfer(level pipeline.LogLevel, msg string) {}
+func (j *completingJptm) HoldsDestinationLock() bool                                       { return false }
+func (j *completingJptm) EnsureDestinationUnlocked()                                       {}
+func (j *completingJptm) ReportTransferDone() uint32                                       { return 1 }
+
+// attributeRecordingDownloader sets file attributes as the Windows downloaders do, recording the attributes,
+// and the modified time that the file had when they were set
+type attributeRecordingDownloader struct {
+	downloader
+	attribs      uint32
+	lastModified time.Time
+}
+
+func (d *attributeRecordingDownloader) Epilogue() {}
+func (d *attributeRecordingDownloader) PutFileAttributes(attribs uint32, txInfo TransferInfo) error {
+	fi, err := os.Stat(txInfo.Destination)
+	if err != nil {
+		return err
+	}
+	d.attribs, d.lastModified = attribs, fi.ModTime()
+	return nil
+}
+
+func (s *fileAttributesSuite) TestAttributesAreSetAfterTheModifiedTime(c *chk.C) {
+	dir, err := ioutil.TempDir("", "fileattributes")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "readonly.txt")
+	c.Assert(ioutil.WriteFile(dst, nil, 0644), chk.IsNil)
+
+	// read-only, which on Windows stops the modified time being changed afterwards
+	const readOnly = "1"
+	lastModified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	jptm := &completingJptm{lastModified: lastModified, info: TransferInfo{
+		Destination:            dst,
+		PreserveFileAttributes: true,
+		SrcProperties:          SrcProperties{SrcMetadata: common.Metadata{common.FileAttributesMetadataKey: readOnly}},
+	}}
+	dl := &attributeRecordingDownloader{}
+
+	epilogueWithCleanupDownload(jptm, dl, nil, nil, nil)
+	c.Assert(jptm.failure, chk.IsNil)
+	c.Assert(jptm.status, chk.Equals, common.ETransferStatus.Success())
+	c.Assert(dl.attribs, chk.Equals, uint32(1))
+	c.Assert(dl.lastModified.Equal(lastModified), chk.Equals, true, chk.Commentf("the attributes were set when the file was last modified at %v", dl.lastModified))
+}
+
+func (s *fileAttributesSuite) TestInvalidAttributesFailTheDownload(c *chk.C) {
+	dir, err := ioutil.TempDir("", "fileattributes")
+	c.Assert(err, chk.IsNil)
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "file.txt")
+	c.Assert(ioutil.WriteFile(dst, nil, 0644), chk.IsNil)
+
+	jptm := &completingJptm{lastModified: time.Now(), info: TransferInfo{
+		Destination:            dst,
+		PreserveFileAttributes: true,
+		SrcProperties:          SrcProperties{SrcMetadata: common.Metadata{common.FileAttributesMetadataKey: "hidden"}},
+	}}
+	dl := &attributeRecordingDownloader{}
+
+	epilogueWithCleanupDownload(jptm, dl, nil, nil, nil)
+	c.Assert(jptm.failure, chk.NotNil)
+	c.Assert(dl.attribs, chk.Equals, uint32(0))
+}
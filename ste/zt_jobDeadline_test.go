@@ -0,0 +1,51 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type jobDeadlineSuite struct{}
+
+var _ = chk.Suite(&jobDeadlineSuite{})
+
+func (s *jobDeadlineSuite) TestNoDeadline(c *chk.C) {
+	jm := newLookaheadTestJobMgr(context.Background())
+	c.Assert(jm.pastDeadline(), chk.Equals, false)
+	c.Assert(jm.TransfersNotStartedByDeadline(), chk.Equals, uint32(0))
+}
+
+func (s *jobDeadlineSuite) TestTransfersAreCountedOncePastDeadline(c *chk.C) {
+	jm := newLookaheadTestJobMgr(context.Background())
+
+	jm.setDeadline(time.Now().Add(time.Hour))
+	c.Assert(jm.pastDeadline(), chk.Equals, false)
+	c.Assert(jm.TransfersNotStartedByDeadline(), chk.Equals, uint32(0))
+
+	jm.setDeadline(time.Now().Add(-time.Second))
+	c.Assert(jm.pastDeadline(), chk.Equals, true)
+	c.Assert(jm.pastDeadline(), chk.Equals, true)
+	c.Assert(jm.TransfersNotStartedByDeadline(), chk.Equals, uint32(2))
+}
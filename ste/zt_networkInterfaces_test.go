@@ -0,0 +1,100 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"net"
+
+	chk "gopkg.in/check.v1"
+)
+
+type networkInterfacesSuite struct{}
+
+var _ = chk.Suite(&networkInterfacesSuite{})
+
+func loopbackInterface(c *chk.C) net.Interface {
+	interfaces, err := net.Interfaces()
+	c.Assert(err, chk.IsNil)
+	for _, i := range interfaces {
+		if i.Flags&net.FlagLoopback != 0 && i.Flags&net.FlagUp != 0 {
+			return i
+		}
+	}
+	c.Skip("no loopback interface")
+	return net.Interface{}
+}
+
+func (s *networkInterfacesSuite) TestResolveBindInterface(c *chk.C) {
+	lo := loopbackInterface(c)
+
+	ip, err := resolveBindInterface(lo.Name)
+	c.Assert(err, chk.IsNil)
+	c.Assert(ip.IsLoopback(), chk.Equals, true)
+
+	ip, err = resolveBindInterface("127.0.0.1")
+	c.Assert(err, chk.IsNil)
+	c.Assert(ip.String(), chk.Equals, "127.0.0.1")
+
+	_, err = resolveBindInterface("no-such-interface0")
+	c.Assert(err, chk.ErrorMatches, "there is no network interface with this name")
+
+	_, err = resolveBindInterface("192.0.2.1") // reserved for documentation, so never assigned
+	c.Assert(err, chk.ErrorMatches, "no network interface on this machine has this address")
+
+	err = SetBindInterface("no-such-interface0")
+	c.Assert(err, chk.ErrorMatches, `cannot use the network interface "no-such-interface0": .*`)
+	c.Assert(bindAddress, chk.IsNil)
+}
+
+func (s *networkInterfacesSuite) TestTrafficIsCountedByLocalAddress(c *chk.C) {
+	lo := loopbackInterface(c)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, chk.IsNil)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			buf := make([]byte, 5)
+			_, _ = conn.Read(buf)
+			_, _ = conn.Write([]byte("hi"))
+			_ = conn.Close()
+		}
+	}()
+
+	raw, err := (&net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}}).Dial("tcp", listener.Addr().String())
+	c.Assert(err, chk.IsNil)
+	traffic := newInterfaceTraffic()
+	conn := traffic.track(raw)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello"))
+	c.Assert(err, chk.IsNil)
+	buf := make([]byte, 2)
+	_, err = conn.Read(buf)
+	c.Assert(err, chk.IsNil)
+
+	bytes := traffic.bytes()
+	c.Assert(bytes, chk.HasLen, 1)
+	c.Assert(bytes[0].Interface, chk.Equals, lo.Name)
+	c.Assert(bytes[0].Address, chk.Equals, "127.0.0.1")
+	c.Assert(bytes[0].Bytes, chk.Equals, uint64(7))
+}
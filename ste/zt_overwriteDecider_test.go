@@ -0,0 +1,68 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type overwriteDeciderSuite struct{}
+
+var _ = chk.Suite(&overwriteDeciderSuite{})
+
+func (s *overwriteDeciderSuite) TestDeciderAnswersOrDefersToPrompt(c *chk.C) {
+	// overwrite only if the source is newer and larger, and ask the user when the sizes are unknown
+	decider := func(src, dst OverwriteCandidate) OverwriteDecision {
+		if dst.Size < 0 {
+			return EOverwriteDecision.Prompt()
+		}
+		if src.LastModifiedTime.After(dst.LastModifiedTime) && src.Size > dst.Size {
+			return EOverwriteDecision.Overwrite()
+		}
+		return EOverwriteDecision.Skip()
+	}
+
+	older := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	shouldOverwrite, decided := askOverwriteDecider(decider, OverwriteCandidate{"a", 10, newer}, OverwriteCandidate{"b", 5, older})
+	c.Assert(decided, chk.Equals, true)
+	c.Assert(shouldOverwrite, chk.Equals, true)
+
+	shouldOverwrite, decided = askOverwriteDecider(decider, OverwriteCandidate{"a", 10, older}, OverwriteCandidate{"b", 5, newer})
+	c.Assert(decided, chk.Equals, true)
+	c.Assert(shouldOverwrite, chk.Equals, false)
+
+	_, decided = askOverwriteDecider(decider, OverwriteCandidate{"a", 10, newer}, OverwriteCandidate{"b", -1, older})
+	c.Assert(decided, chk.Equals, false)
+}
+
+func (s *overwriteDeciderSuite) TestOverwriteDecisionString(c *chk.C) {
+	c.Assert(EOverwriteDecision.Prompt().String(), chk.Equals, "Prompt")
+	c.Assert(EOverwriteDecision.Overwrite().String(), chk.Equals, "Overwrite")
+	c.Assert(EOverwriteDecision.Skip().String(), chk.Equals, "Skip")
+}
+
+func (s *overwriteDeciderSuite) TestWithoutQueryRemovesSAS(c *chk.C) {
+	c.Assert(withoutQuery("https://acct.blob.core.windows.net/c/a%20b.txt?sv=x&sig=secret"), chk.Equals, "https://acct.blob.core.windows.net/c/a%20b.txt")
+}
@@ -0,0 +1,73 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type pacedReaderAtSuite struct{}
+
+var _ = chk.Suite(&pacedReaderAtSuite{})
+
+type closeableBytesReader struct {
+	*bytes.Reader
+}
+
+func (closeableBytesReader) Close() error {
+	return nil
+}
+
+func (s *pacedReaderAtSuite) TestBlockBiggerThanBucketIsRead(c *chk.C) {
+	// 8 Mbps holds at most 2.5 MB in the bucket, which is less than the block, so reading the block in one allocation would never finish
+	p := newTokenBucketPacer(1000*1000, pacedDiskReadSize)
+	defer p.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data := bytes.Repeat([]byte{'a', 'b', 'c'}, 1024*1024)
+	pra := &pacedReaderAt{ctx: ctx, file: closeableBytesReader{bytes.NewReader(data)}, p: p}
+
+	block := make([]byte, len(data))
+	n, err := pra.ReadAt(block, 0)
+	c.Assert(err, chk.IsNil)
+	c.Assert(n, chk.Equals, len(data))
+	c.Assert(bytes.Equal(block, data), chk.Equals, true)
+	c.Assert(p.GetTotalTraffic(), chk.Equals, int64(len(data)))
+}
+
+func (s *pacedReaderAtSuite) TestShortReadReturnsUnusedTokens(c *chk.C) {
+	p := newTokenBucketPacer(1000*1000, pacedDiskReadSize)
+	defer p.Close()
+
+	data := []byte("the end of the file")
+	pra := &pacedReaderAt{ctx: context.Background(), file: closeableBytesReader{bytes.NewReader(data)}, p: p}
+
+	block := make([]byte, 100)
+	n, err := pra.ReadAt(block, 4)
+	c.Assert(err, chk.NotNil)
+	c.Assert(n, chk.Equals, len(data)-4)
+	c.Assert(p.GetTotalTraffic(), chk.Equals, int64(len(data)-4))
+}
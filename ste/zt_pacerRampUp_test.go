@@ -0,0 +1,66 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	chk "gopkg.in/check.v1"
+)
+
+type pacerRampUpSuite struct{}
+
+var _ = chk.Suite(&pacerRampUpSuite{})
+
+func (s *pacerRampUpSuite) TestRateRisesLinearlyToTarget(c *chk.C) {
+	p := newRampedTokenBucketPacer(1000, 0, 10*time.Second)
+	defer p.Close()
+	now := time.Now()
+
+	// until the first request, the ramp up hasn't started
+	c.Assert(p.effectiveBytesPerSecond(now.Add(time.Hour)), chk.Equals, int64(100))
+
+	atomic.StoreInt64(&p.atomicRampStartNanos, now.UnixNano())
+	c.Assert(p.effectiveBytesPerSecond(now), chk.Equals, int64(100))
+	c.Assert(p.effectiveBytesPerSecond(now.Add(5*time.Second)), chk.Equals, int64(550))
+	c.Assert(p.effectiveBytesPerSecond(now.Add(10*time.Second)), chk.Equals, int64(1000))
+	c.Assert(p.effectiveBytesPerSecond(now.Add(time.Hour)), chk.Equals, int64(1000))
+
+	// the target that's reported, e.g. in the job summary, is still the cap
+	c.Assert(p.targetBytesPerSecond(), chk.Equals, int64(1000))
+}
+
+func (s *pacerRampUpSuite) TestFirstRequestStartsRampUp(c *chk.C) {
+	p := newRampedTokenBucketPacer(1000, 0, time.Minute)
+	defer p.Close()
+	c.Assert(atomic.LoadInt64(&p.atomicRampStartNanos), chk.Equals, int64(0))
+
+	c.Assert(p.RequestTrafficAllocation(context.Background(), 1), chk.IsNil)
+	c.Assert(atomic.LoadInt64(&p.atomicRampStartNanos), chk.Not(chk.Equals), int64(0))
+}
+
+func (s *pacerRampUpSuite) TestNoRampUpUsesTarget(c *chk.C) {
+	p := newTokenBucketPacer(1000, 0)
+	defer p.Close()
+	c.Assert(p.effectiveBytesPerSecond(time.Now()), chk.Equals, int64(1000))
+}
@@ -0,0 +1,198 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type prefixConcurrencySuite struct{}
+
+var _ = chk.Suite(&prefixConcurrencySuite{})
+
+func (s *prefixConcurrencySuite) TestParsePrefixConcurrency(c *chk.C) {
+	limits, err := ParsePrefixConcurrency("logs/=10, /data/=100,=5")
+	c.Assert(err, chk.IsNil)
+	c.Assert(limits, chk.DeepEquals, map[string]int{"logs/": 10, "data/": 100, "": 5})
+
+	for _, invalid := range []string{"logs/", "logs/=0", "logs/=ten", "logs/=1,logs/=2"} {
+		_, err = ParsePrefixConcurrency(invalid)
+		c.Assert(err, chk.NotNil, chk.Commentf(invalid))
+	}
+}
+
+func (s *prefixConcurrencySuite) TestPrefixLimiterHandsOnSlots(c *chk.C) {
+	l := newPrefixLimiter(map[string]int{"logs/": 2, "logs/app/": 1})
+	var started []string
+	startLater := func(name string) func() {
+		return func() { started = append(started, name) }
+	}
+
+	c.Assert(l.tryStart("logs/a", startLater("logs/a")), chk.Equals, true)
+	c.Assert(l.tryStart("logs/b", startLater("logs/b")), chk.Equals, true)
+	c.Assert(l.tryStart("logs/c", startLater("logs/c")), chk.Equals, false)
+	c.Assert(l.tryStart("logs/d", startLater("logs/d")), chk.Equals, false)
+
+	// the longest prefix that matches applies, and names under no prefix aren't limited
+	c.Assert(l.tryStart("logs/app/x", startLater("logs/app/x")), chk.Equals, true)
+	c.Assert(l.tryStart("data/x", startLater("data/x")), chk.Equals, true)
+	c.Assert(l.tryStart("data/y", startLater("data/y")), chk.Equals, true)
+
+	c.Assert(l.transfersInFlight(), chk.DeepEquals, []common.PrefixTransfersInFlight{
+		{Prefix: "logs/", Limit: 2, InFlight: 2, Waiting: 2},
+		{Prefix: "logs/app/", Limit: 1, InFlight: 1},
+	})
+
+	// each slot that's freed is handed to the transfer that has waited longest
+	l.done("logs/a")()
+	l.done("logs/c")()
+	c.Assert(started, chk.DeepEquals, []string{"logs/c", "logs/d"})
+	c.Assert(l.done("logs/b"), chk.IsNil)
+	c.Assert(l.done("logs/d"), chk.IsNil)
+	c.Assert(l.done("data/x"), chk.IsNil)
+
+	c.Assert(l.transfersInFlight(), chk.DeepEquals, []common.PrefixTransfersInFlight{
+		{Prefix: "logs/", Limit: 2},
+		{Prefix: "logs/app/", Limit: 1, InFlight: 1},
+	})
+}
+
+func (s *prefixConcurrencySuite) TestHandedSlotIsNotTakenByALaterTransfer(c *chk.C) {
+	l := newPrefixLimiter(map[string]int{"logs/": 1})
+	var started []string
+	startLater := func(name string) func() {
+		return func() { started = append(started, name) }
+	}
+
+	c.Assert(l.tryStart("logs/a", startLater("logs/a")), chk.Equals, true)
+	c.Assert(l.tryStart("logs/b", startLater("logs/b")), chk.Equals, false)
+	c.Assert(l.tryStart("logs/c", startLater("logs/c")), chk.Equals, false)
+
+	// the slot of a is b's as soon as a is done, even before b is rescheduled, so a transfer that comes along
+	// in between waits behind c, rather than taking it
+	startB := l.done("logs/a")
+	c.Assert(l.tryStart("logs/d", startLater("logs/d")), chk.Equals, false)
+	startB()
+	l.done("logs/b")()
+	l.done("logs/c")()
+	c.Assert(started, chk.DeepEquals, []string{"logs/b", "logs/c", "logs/d"})
+	c.Assert(l.done("logs/d"), chk.IsNil)
+	c.Assert(l.transfersInFlight(), chk.DeepEquals, []common.PrefixTransfersInFlight{{Prefix: "logs/", Limit: 1}})
+}
+
+func (s *prefixConcurrencySuite) TestLimitHoldsUnderConcurrentTransfers(c *chk.C) {
+	const limit, transfers = 3, 200
+	l := newPrefixLimiter(map[string]int{"logs/": limit})
+	var inFlight, most, ran int32
+
+	var wg sync.WaitGroup
+	var run func(name string)
+	run = func(name string) {
+		defer wg.Done()
+		n := atomic.AddInt32(&inFlight, 1)
+		for m := atomic.LoadInt32(&most); n > m && !atomic.CompareAndSwapInt32(&most, m, n); m = atomic.LoadInt32(&most) {
+		}
+		atomic.AddInt32(&ran, 1)
+		atomic.AddInt32(&inFlight, -1)
+		if start := l.done(name); start != nil {
+			start()
+		}
+	}
+	wg.Add(transfers)
+	for i := 0; i < transfers; i++ {
+		name := fmt.Sprintf("logs/%d", i)
+		go func() {
+			if l.tryStart(name, func() { go run(name) }) {
+				run(name)
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.Assert(atomic.LoadInt32(&ran), chk.Equals, int32(transfers))
+	c.Assert(atomic.LoadInt32(&most) <= limit, chk.Equals, true, chk.Commentf("%d transfers were in flight at once", most))
+	c.Assert(l.transfersInFlight(), chk.DeepEquals, []common.PrefixTransfersInFlight{{Prefix: "logs/", Limit: limit}})
+}
+
+func (s *prefixConcurrencySuite) TestSlotIsReleasedWhenTransferFailsOrIsCancelled(c *chk.C) {
+	jm := newLookaheadTestJobMgr(context.Background())
+	jm.setPrefixConcurrency(map[string]int{"logs/": 1})
+	planMMF := mapTransactionalPlan(c, "", []rollBackTransfer{{dst: "a"}, {dst: "b"}, {dst: "c"}})
+	planMMF.Plan().FromTo = common.EFromTo.LocalBlob()
+	jpm := &jobPartMgr{jobMgr: jm, planMMF: planMMF}
+
+	for i, status := range []common.TransferStatus{common.ETransferStatus.Failed(), common.ETransferStatus.Cancelled()} {
+		comment := chk.Commentf(status.String())
+		ctx, cancel := context.WithCancel(context.Background())
+		jptm := &jobPartTransferMgr{jobPartMgr: jpm, jobPartPlanTransfer: planMMF.Plan().Transfer(uint32(i)), transferIndex: uint32(i),
+			ctx: ctx, cancel: cancel, transferInfo: &TransferInfo{Destination: "https://account.blob.core.windows.net/container/logs/" + status.String()}}
+		c.Assert(jptm.TakePrefixSlot(), chk.Equals, true, comment)
+
+		handed := false
+		c.Assert(jm.prefixConcurrency().tryStart("logs/waiting", func() { handed = true }), chk.Equals, false, comment)
+
+		if status == common.ETransferStatus.Cancelled() {
+			jptm.Cancel()
+		}
+		jptm.jobPartPlanTransfer.SetTransferStatus(status, true)
+		jptm.ReportTransferDone()
+		c.Assert(handed, chk.Equals, true, comment)
+
+		c.Assert(jm.prefixConcurrency().done("logs/waiting"), chk.IsNil, comment)
+		c.Assert(jm.TransfersInFlightByPrefix(), chk.DeepEquals, []common.PrefixTransfersInFlight{{Prefix: "logs/", Limit: 1}}, comment)
+	}
+}
+
+func (s *prefixConcurrencySuite) TestLimitsAreKeptForResume(c *chk.C) {
+	limits := map[string]int{"logs/": 10, "data/": 100, "": 5}
+	formatted := FormatPrefixConcurrency(limits)
+	c.Assert(formatted, chk.Equals, "=5,data/=100,logs/=10")
+	parsed, err := ParsePrefixConcurrency(formatted)
+	c.Assert(err, chk.IsNil)
+	c.Assert(parsed, chk.DeepEquals, limits)
+
+	plan := &JobPartPlanHeader{PrefixConcurrencyLength: uint16(len(formatted))}
+	copy(plan.PrefixConcurrency[:], formatted)
+
+	// a job that's resumed in a new process gets its limits from its plan
+	jm := newLookaheadTestJobMgr(context.Background())
+	jm.restorePrefixConcurrency(plan)
+	c.Assert(jm.TransfersInFlightByPrefix(), chk.DeepEquals, []common.PrefixTransfersInFlight{
+		{Prefix: "", Limit: 5}, {Prefix: "data/", Limit: 100}, {Prefix: "logs/", Limit: 10},
+	})
+
+	// while one that's still in memory keeps the limiter it has, with its transfers in flight
+	c.Assert(jm.prefixConcurrency().tryStart("logs/a", nil), chk.Equals, true)
+	jm.restorePrefixConcurrency(plan)
+	c.Assert(jm.TransfersInFlightByPrefix()[2].InFlight, chk.Equals, 1)
+
+	// and one that had no limits gets none
+	jm = newLookaheadTestJobMgr(context.Background())
+	jm.restorePrefixConcurrency(&JobPartPlanHeader{})
+	c.Assert(jm.TransfersInFlightByPrefix(), chk.IsNil)
+}
@@ -0,0 +1,51 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type readAheadSuite struct{}
+
+var _ = chk.Suite(&readAheadSuite{})
+
+func (s *readAheadSuite) TestReadAheadWindowSize(c *chk.C) {
+	// without a read-ahead, there's no window, and abandoning it does nothing
+	var none *readAhead
+	c.Assert(newReadAhead(0), chk.IsNil)
+	w := none.newWindow(nil, "file", 100, 10, nil, nil)
+	c.Assert(w, chk.IsNil)
+	w.abandon()
+
+	ra := newReadAhead(64 * 1024 * 1024)
+	c.Assert(ra.newWindow(nil, "file", 1<<30, 8*1024*1024, nil, nil).maxChunks, chk.Equals, 8)
+	// chunks bigger than the read-ahead are read one at a time, as they would be without it
+	c.Assert(ra.newWindow(nil, "file", 1<<30, 100*1024*1024, nil, nil).maxChunks, chk.Equals, 1)
+
+	ra.atomicChunks, ra.atomicReadyChunks = 8, 6
+	c.Assert(ra.perfString(), chk.Equals, "RA: 6/8")
+}
+
+func (s *readAheadSuite) TestReadAheadMustLeaveRoomInRAM(c *chk.C) {
+	c.Assert(validateReadAhead(0, 1<<30), chk.IsNil)
+	c.Assert(validateReadAhead(256*1024*1024, 1<<30), chk.IsNil)
+	c.Assert(validateReadAhead(256*1024*1024+1, 1<<30), chk.ErrorMatches, "read-ahead-mb must be at most a quarter of .* 1024 MiB.*")
+}
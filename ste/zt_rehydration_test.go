@@ -0,0 +1,133 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type rehydrationSuite struct{}
+
+var _ = chk.Suite(&rehydrationSuite{})
+
+// rehydrationJptm provides only what checking and rehydrating a download's source asks of the transfer
+type rehydrationJptm struct {
+	IJobPartTransferMgr
+}
+
+func (j *rehydrationJptm) Info() TransferInfo {
+	return TransferInfo{Source: "https://account.blob.core.windows.net/container/blob?sig=x"}
+}
+func (j *rehydrationJptm) FromTo() common.FromTo                                  { return common.EFromTo.BlobLocal() }
+func (j *rehydrationJptm) Context() context.Context                               { return context.Background() }
+func (j *rehydrationJptm) LogAtLevelForCurrentTransfer(pipeline.LogLevel, string) {}
+
+// rehydrationPipeline answers for a source with the given tier and archive status, and records the requests that were made
+func rehydrationPipeline(tier, archiveStatus string, setTierErr error, requests *[]string) pipeline.Pipeline {
+	return pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			*requests = append(*requests, request.Method+" "+request.URL.Query().Get("comp")+" "+request.Header.Get("x-ms-access-tier"))
+			if request.Method == http.MethodPut && setTierErr != nil {
+				return nil, setTierErr
+			}
+			header := http.Header{}
+			header.Set("x-ms-access-tier", tier)
+			if archiveStatus != "" {
+				header.Set("x-ms-archive-status", archiveStatus)
+			}
+			return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(strings.NewReader(""))}), nil
+		}
+	})})
+}
+
+// rehydrate checks a source with the given tier and archive status, and returns whether it's online, and the requests that were made
+func rehydrate(tier, archiveStatus string, setTierErr error) (online bool, requests []string, err error) {
+	online, err = rehydrateIfArchived(&rehydrationJptm{}, rehydrationPipeline(tier, archiveStatus, setTierErr, &requests))
+	return online, requests, err
+}
+
+func (s *rehydrationSuite) TestOnlineSourceIsTransferred(c *chk.C) {
+	online, requests, err := rehydrate("Cool", "", nil)
+	c.Assert(err, chk.IsNil)
+	c.Assert(online, chk.Equals, true)
+	c.Assert(requests, chk.DeepEquals, []string{"HEAD  "})
+}
+
+func (s *rehydrationSuite) TestArchivedSourceIsRehydrated(c *chk.C) {
+	online, requests, err := rehydrate("Archive", "", nil)
+	c.Assert(err, chk.IsNil)
+	c.Assert(online, chk.Equals, false)
+	c.Assert(requests, chk.DeepEquals, []string{"HEAD  ", "PUT tier Hot"})
+
+	_, _, err = rehydrate("Archive", "", errors.New("forbidden"))
+	c.Assert(err, chk.ErrorMatches, ".*cannot rehydrate.*forbidden.*")
+}
+
+func (s *rehydrationSuite) TestRehydratingSourceIsLeftToFinish(c *chk.C) {
+	online, requests, err := rehydrate("Archive", "rehydrate-pending-to-hot", nil)
+	c.Assert(err, chk.IsNil)
+	c.Assert(online, chk.Equals, false)
+	c.Assert(requests, chk.DeepEquals, []string{"HEAD  "})
+}
+
+// waitingJptm records what a transfer that waits for rehydration does
+type waitingJptm struct {
+	rehydrationJptm
+	events chan string
+}
+
+func (j *waitingJptm) SetRehydrating(rehydrating bool) {}
+func (j *waitingJptm) ReleasePrefixSlot()              { j.events <- "release" }
+func (j *waitingJptm) RescheduleTransfer()             { j.events <- "reschedule" }
+
+func (s *rehydrationSuite) TestWaitingTransferGivesUpItsPrefixSlot(c *chk.C) {
+	defer func(d time.Duration) { rehydrationPollInterval = d }(rehydrationPollInterval)
+	rehydrationPollInterval = time.Millisecond
+
+	jptm := &waitingJptm{events: make(chan string, 2)}
+	xfer := rehydrateBeforeTransfer(func(IJobPartTransferMgr, pipeline.Pipeline, pacer) {
+		c.Fatal("an archived source mustn't be transferred")
+	})
+	var requests []string
+	xfer(jptm, rehydrationPipeline("Archive", "rehydrate-pending-to-hot", nil, &requests), nil)
+
+	// the slot is freed before the transfer goes back in the queue, where it takes one again when it's next started
+	c.Assert(<-jptm.events, chk.Equals, "release")
+	c.Assert(<-jptm.events, chk.Equals, "reschedule")
+}
+
+func (s *rehydrationSuite) TestTransfersRehydratingCount(c *chk.C) {
+	jm := newLookaheadTestJobMgr(context.Background())
+	jm.addTransfersRehydrating(1)
+	jm.addTransfersRehydrating(1)
+	jm.addTransfersRehydrating(-1)
+	c.Assert(jm.TransfersRehydrating(), chk.Equals, uint32(1))
+}
@@ -0,0 +1,129 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type relayedSourceSuite struct{}
+
+var _ = chk.Suite(&relayedSourceSuite{})
+
+// relayTestPipeline is the pipeline a source info provider would read through, retrying quickly
+func relayTestPipeline(server *httptest.Server) pipeline.Pipeline {
+	return NewBlobPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{},
+		XferRetryOptions{MaxTries: 3, TryTimeout: time.Minute, RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond},
+		nil, server.Client(), nil)
+}
+
+func (s *relayedSourceSuite) TestRangedURLReader(c *chk.C) {
+	content := []byte("0123456789abcdef")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "source", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	source, err := url.Parse(server.URL)
+	c.Assert(err, chk.IsNil)
+
+	reader, err := relayedSource{ctx: context.Background(), p: relayTestPipeline(server), source: source, location: common.ELocation.S3()}.open()
+	c.Assert(err, chk.IsNil)
+	defer reader.Close()
+
+	buf := make([]byte, 4)
+	n, err := reader.ReadAt(buf, 6)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(buf[:n]), chk.Equals, "6789")
+
+	// a read that runs past the end gets what there is
+	n, err = reader.ReadAt(buf, 14)
+	c.Assert(err, chk.Equals, io.EOF)
+	c.Assert(string(buf[:n]), chk.Equals, "ef")
+
+	_, err = reader.ReadAt(buf, 16)
+	c.Assert(err, chk.Equals, io.EOF)
+}
+
+func (s *relayedSourceSuite) TestRangedURLReaderRefusesIgnoredRanges(c *chk.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+	source, err := url.Parse(server.URL)
+	c.Assert(err, chk.IsNil)
+
+	reader := &rangedURLReader{relayedSource{ctx: context.Background(), p: relayTestPipeline(server), source: source, location: common.ELocation.S3()}}
+	buf := make([]byte, 4)
+	n, err := reader.ReadAt(buf, 0)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(buf[:n]), chk.Equals, "0123")
+
+	_, err = reader.ReadAt(buf, 4)
+	c.Assert(err, chk.ErrorMatches, ".*ignored the range.*")
+}
+
+func (s *relayedSourceSuite) TestRangedURLReaderReadsBlobsThroughThePipeline(c *chk.C) {
+	content := []byte("0123456789abcdef")
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request fails, and is retried by the pipeline
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// the Azure Storage SDKs ask for the range with x-ms-range
+		r.Header.Set("Range", r.Header.Get("x-ms-range"))
+		http.ServeContent(w, r, "source", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	source, err := url.Parse(server.URL + "/account/container/blob")
+	c.Assert(err, chk.IsNil)
+
+	reader, err := relayedSource{ctx: context.Background(), p: relayTestPipeline(server), source: source, location: common.ELocation.Blob()}.open()
+	c.Assert(err, chk.IsNil)
+	defer reader.Close()
+
+	buf := make([]byte, 4)
+	n, err := reader.ReadAt(buf, 6)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(buf[:n]), chk.Equals, "6789")
+	c.Assert(atomic.LoadInt32(&requests), chk.Equals, int32(2))
+
+	n, err = reader.ReadAt(buf, 14)
+	c.Assert(err, chk.Equals, io.EOF)
+	c.Assert(string(buf[:n]), chk.Equals, "ef")
+
+	_, err = reader.ReadAt(buf, 16)
+	c.Assert(err, chk.Equals, io.EOF)
+}
@@ -0,0 +1,78 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"io/ioutil"
+	"os"
+	"unsafe"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type resourceDstDataSuite struct{}
+
+var _ = chk.Suite(&resourceDstDataSuite{})
+
+// mapUploadPlan writes a plan for an upload of files with the given content languages, as the front end records them for
+// files that metadata rules match, and maps it
+func mapUploadPlan(c *chk.C, contentLanguages []string) *JobPartPlanMMF {
+	const src, dst = "/file.txt", "/file.txt"
+	header := JobPartPlanHeader{Version: DataSchemaVersion, FromTo: common.EFromTo.LocalBlob(), NumTransfers: uint32(len(contentLanguages))}
+	plan := append([]byte{}, (*[unsafe.Sizeof(JobPartPlanHeader{})]byte)(unsafe.Pointer(&header))[:]...)
+
+	stringOffset := int64(len(plan)) + int64(len(contentLanguages))*int64(unsafe.Sizeof(JobPartPlanTransfer{}))
+	for _, language := range contentLanguages {
+		transfer := JobPartPlanTransfer{SrcOffset: stringOffset, SrcLength: int16(len(src)), DstLength: int16(len(dst)),
+			SrcContentLanguageLength: int16(len(language))}
+		plan = append(plan, (*[unsafe.Sizeof(JobPartPlanTransfer{})]byte)(unsafe.Pointer(&transfer))[:]...)
+		stringOffset += int64(len(src) + len(dst) + len(language))
+	}
+	for _, language := range contentLanguages {
+		plan = append(plan, src+dst+language...)
+	}
+
+	file, err := ioutil.TempFile("", "upload")
+	c.Assert(err, chk.IsNil)
+	defer file.Close()
+	_, err = file.Write(plan)
+	c.Assert(err, chk.IsNil)
+	mmf, err := common.NewMMF(file, false, 0, int64(len(plan)))
+	c.Assert(err, chk.IsNil)
+	c.Assert(os.Remove(file.Name()), chk.IsNil)
+	return (*JobPartPlanMMF)(mmf)
+}
+
+func (s *resourceDstDataSuite) TestContentLanguageOfTheTransferWins(c *chk.C) {
+	planMMF := mapUploadPlan(c, []string{"fr-FR", ""})
+	defer planMMF.Unmap()
+
+	// the job's content language is the one given as a flag, if any
+	jpm := &jobPartMgr{planMMF: planMMF, httpHeaders: common.ResourceHTTPHeaders{ContentLanguage: "en-US"}}
+
+	headers, _, _ := (&jobPartTransferMgr{jobPartMgr: jpm, transferIndex: 0}).ResourceDstData(nil)
+	c.Assert(headers.ContentLanguage, chk.Equals, "fr-FR")
+
+	headers, _, _ = (&jobPartTransferMgr{jobPartMgr: jpm, transferIndex: 1}).ResourceDstData(nil)
+	c.Assert(headers.ContentLanguage, chk.Equals, "en-US")
+}
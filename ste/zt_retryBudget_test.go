@@ -0,0 +1,78 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	"context"
+
+	chk "gopkg.in/check.v1"
+)
+
+type retryBudgetSuite struct{}
+
+var _ = chk.Suite(&retryBudgetSuite{})
+
+func (s *retryBudgetSuite) TestNoCaps(c *chk.C) {
+	b := newRetryBudget(0, 0)
+	c.Assert(b, chk.IsNil)
+
+	var fileRetries int32
+	ctx := withRetryCounter(context.Background(), &fileRetries, b)
+	for i := 0; i < 100; i++ {
+		countRetry(ctx)
+	}
+	c.Assert(fileRetries, chk.Equals, int32(100))
+	c.Assert(mayRetry(ctx), chk.Equals, true)
+	c.Assert(b.exhausted(), chk.Equals, false)
+
+	// a context that isn't counted is never held back
+	c.Assert(mayRetry(context.Background()), chk.Equals, true)
+}
+
+func (s *retryBudgetSuite) TestPerFileCap(c *chk.C) {
+	b := newRetryBudget(3, 0)
+
+	var first, second int32
+	firstCtx := withRetryCounter(context.Background(), &first, b)
+	secondCtx := withRetryCounter(context.Background(), &second, b)
+	for i := 0; i < 3; i++ {
+		c.Assert(mayRetry(firstCtx), chk.Equals, true)
+		countRetry(firstCtx)
+	}
+	c.Assert(mayRetry(firstCtx), chk.Equals, false)
+
+	// the other file has its own allowance, and the job has no cap
+	c.Assert(mayRetry(secondCtx), chk.Equals, true)
+	c.Assert(b.exhausted(), chk.Equals, false)
+}
+
+func (s *retryBudgetSuite) TestJobCap(c *chk.C) {
+	b := newRetryBudget(0, 5)
+
+	files := make([]int32, 5)
+	for i := range files {
+		c.Assert(b.exhausted(), chk.Equals, false)
+		countRetry(withRetryCounter(context.Background(), &files[i], b))
+	}
+	c.Assert(b.exhausted(), chk.Equals, true)
+
+	// the transfers in progress may still retry, unless their own cap stops them
+	c.Assert(mayRetry(withRetryCounter(context.Background(), &files[0], b)), chk.Equals, true)
+}
@@ -0,0 +1,87 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-file-go/azfile"
+	chk "gopkg.in/check.v1"
+)
+
+type setReadOnlySuite struct{}
+
+var _ = chk.Suite(&setReadOnlySuite{})
+
+// deadSmbInfoJptm is a transfer that has already failed, or been cancelled, by the time the file is finished
+type deadSmbInfoJptm struct {
+	smbInfoJptm
+}
+
+func (j *deadSmbInfoJptm) IsLive() bool { return false }
+
+// fileEpilogueAttributes returns the attributes that are set on a file at the end of sending it to Azure Files, one for each request
+func fileEpilogueAttributes(c *chk.C, jptm IJobPartTransferMgr, attributes *azfile.FileAttributeFlags) []string {
+	var sent []string
+	p := pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			sent = append(sent, request.Header.Get("x-ms-file-attributes"))
+			return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}), nil
+		}
+	})})
+
+	fileURL, err := url.Parse("https://account.file.core.windows.net/share/dir/file")
+	c.Assert(err, chk.IsNil)
+	sender := &azureFileSenderBase{
+		jptm:         jptm,
+		fileOrDirURL: azfile.NewFileURL(*fileURL, p),
+		pipeline:     p,
+		ctx:          context.Background(),
+	}
+	sender.headersToApply.FileAttributes = attributes
+	sender.Epilogue()
+	return sent
+}
+
+func (s *setReadOnlySuite) TestFileIsMadeReadOnlyOnceWritten(c *chk.C) {
+	// a file whose attributes aren't preserved has the ones the service gives a new file, as well as read-only
+	jptm := &smbInfoJptm{info: TransferInfo{SetReadOnly: true}}
+	c.Assert(fileEpilogueAttributes(c, jptm, nil), chk.DeepEquals, []string{"ReadOnly|Archive"})
+
+	// and a file whose attributes are preserved keeps them
+	hidden := azfile.FileAttributeHidden
+	c.Assert(fileEpilogueAttributes(c, jptm, &hidden), chk.DeepEquals, []string{"ReadOnly|Hidden"})
+}
+
+func (s *setReadOnlySuite) TestFileIsOnlyMadeReadOnlyWhenAskedTo(c *chk.C) {
+	c.Assert(fileEpilogueAttributes(c, &smbInfoJptm{}, nil), chk.HasLen, 0)
+}
+
+func (s *setReadOnlySuite) TestFailedTransferIsNotMadeReadOnly(c *chk.C) {
+	// otherwise the incomplete file could not be deleted by the cleanup
+	jptm := &deadSmbInfoJptm{smbInfoJptm{info: TransferInfo{SetReadOnly: true}}}
+	c.Assert(fileEpilogueAttributes(c, jptm, nil), chk.HasLen, 0)
+}
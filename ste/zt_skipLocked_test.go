@@ -0,0 +1,61 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type skipLockedSuite struct{}
+
+var _ = chk.Suite(&skipLockedSuite{})
+
+// lockedOptionsJpm is a job part that only knows whether locked sources are skipped and retried
+type lockedOptionsJpm struct {
+	IJobPartMgr
+	skipLocked  bool
+	retryLocked bool
+}
+
+func (j *lockedOptionsJpm) GetSkipLocked() bool  { return j.skipLocked }
+func (j *lockedOptionsJpm) GetRetryLocked() bool { return j.retryLocked }
+
+func (s *skipLockedSuite) TestLockedSourceIsOnlyRetriedOnce(c *chk.C) {
+	jptm := &jobPartTransferMgr{jobPartMgr: &lockedOptionsJpm{skipLocked: true, retryLocked: true}}
+	c.Assert(jptm.ShouldSkipLocked(), chk.Equals, true)
+	c.Assert(jptm.TryClaimLockedRetry(), chk.Equals, true)
+
+	// the second time the source is found to be locked, it is skipped
+	c.Assert(jptm.TryClaimLockedRetry(), chk.Equals, false)
+
+	// and each transfer gets its own retry
+	other := &jobPartTransferMgr{jobPartMgr: jptm.jobPartMgr}
+	c.Assert(other.TryClaimLockedRetry(), chk.Equals, true)
+}
+
+func (s *skipLockedSuite) TestLockedSourceIsNotRetriedUnlessAskedTo(c *chk.C) {
+	jptm := &jobPartTransferMgr{jobPartMgr: &lockedOptionsJpm{skipLocked: true}}
+	c.Assert(jptm.ShouldSkipLocked(), chk.Equals, true)
+	c.Assert(jptm.TryClaimLockedRetry(), chk.Equals, false)
+
+	jptm = &jobPartTransferMgr{jobPartMgr: &lockedOptionsJpm{}}
+	c.Assert(jptm.ShouldSkipLocked(), chk.Equals, false)
+}
@@ -0,0 +1,111 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-file-go/azfile"
+	chk "gopkg.in/check.v1"
+)
+
+type skipSMBInfoSuite struct{}
+
+var _ = chk.Suite(&skipSMBInfoSuite{})
+
+// smbInfoJptm provides only what setting the properties of a folder, or finishing a file, asks of the transfer
+type smbInfoJptm struct {
+	IJobPartTransferMgr
+	info TransferInfo
+}
+
+func (j *smbInfoJptm) Info() TransferInfo       { return j.info }
+func (j *smbInfoJptm) GetForceIfReadOnly() bool { return false }
+func (j *smbInfoJptm) IsLive() bool             { return true }
+
+// smbInfoPipeline records the comp parameter of each request that's made
+func smbInfoPipeline(comps *[]string) pipeline.Pipeline {
+	return pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			*comps = append(*comps, request.URL.Query().Get("comp"))
+			return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}), nil
+		}
+	})})
+}
+
+// setFolderPropertiesRequests returns the comp parameter of each request made to set the properties of a folder on Azure Files
+func setFolderPropertiesRequests(c *chk.C, info TransferInfo) []string {
+	var comps []string
+	p := smbInfoPipeline(&comps)
+
+	dirURL, err := url.Parse("https://account.file.core.windows.net/share/dir")
+	c.Assert(err, chk.IsNil)
+	sender := &azureFileSenderBase{
+		jptm:         &smbInfoJptm{info: info},
+		fileOrDirURL: azfile.NewDirectoryURL(*dirURL, p),
+		pipeline:     p,
+		ctx:          context.Background(),
+	}
+	c.Assert(sender.SetFolderProperties(), chk.IsNil)
+	return comps
+}
+
+func (s *skipSMBInfoSuite) TestSkipSMBInfoLeavesOutFolderPropertiesCall(c *chk.C) {
+	c.Assert(setFolderPropertiesRequests(c, TransferInfo{}), chk.DeepEquals, []string{"metadata", "properties"})
+
+	// only the metadata, which isn't SMB info, is set
+	c.Assert(setFolderPropertiesRequests(c, TransferInfo{SkipSMBInfo: true}), chk.DeepEquals, []string{"metadata"})
+}
+
+// fileEpilogueRequests returns the comp parameter of each request made at the end of sending a file to Azure Files,
+// when the file is to have the given attributes
+func fileEpilogueRequests(c *chk.C, info TransferInfo, attributes azfile.FileAttributeFlags) []string {
+	var comps []string
+	p := smbInfoPipeline(&comps)
+
+	fileURL, err := url.Parse("https://account.file.core.windows.net/share/dir/file")
+	c.Assert(err, chk.IsNil)
+	sender := &azureFileSenderBase{
+		jptm:         &smbInfoJptm{info: info},
+		fileOrDirURL: azfile.NewFileURL(*fileURL, p),
+		pipeline:     p,
+		ctx:          context.Background(),
+	}
+	sender.headersToApply.FileAttributes = &attributes
+	sender.Epilogue()
+	return comps
+}
+
+func (s *skipSMBInfoSuite) TestSkipSMBInfoLeavesOutFileAttributesCall(c *chk.C) {
+	// a read-only file is only made read-only once its content has been written
+	readOnly := azfile.FileAttributeReadonly | azfile.FileAttributeArchive
+	c.Assert(fileEpilogueRequests(c, TransferInfo{PreserveSMBInfo: true}, readOnly), chk.DeepEquals, []string{"properties"})
+	c.Assert(fileEpilogueRequests(c, TransferInfo{SetReadOnly: true}, azfile.FileAttributeArchive), chk.DeepEquals, []string{"properties"})
+
+	// but not if no SMB info is to be set
+	c.Assert(fileEpilogueRequests(c, TransferInfo{PreserveSMBInfo: true, SkipSMBInfo: true}, readOnly), chk.HasLen, 0)
+	c.Assert(fileEpilogueRequests(c, TransferInfo{SetReadOnly: true, SkipSMBInfo: true}, azfile.FileAttributeArchive), chk.HasLen, 0)
+}
@@ -0,0 +1,98 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type trailingDotSuite struct{}
+
+var _ = chk.Suite(&trailingDotSuite{})
+
+// sendThroughTrailingDotPolicy sends a request for the URL through the policy, and returns the headers it went out with
+func sendThroughTrailingDotPolicy(c *chk.C, option common.TrailingDotOption, rawURL string, copySource string) http.Header {
+	u, err := url.Parse(rawURL)
+	c.Assert(err, chk.IsNil)
+	request, err := pipeline.NewRequest(http.MethodPut, *u, nil)
+	c.Assert(err, chk.IsNil)
+	request.Header.Set("x-ms-version", DefaultServiceApiVersion)
+	if copySource != "" {
+		request.Header.Set("x-ms-copy-source", copySource)
+	}
+
+	var sent http.Header
+	p := pipeline.NewPipeline([]pipeline.Factory{NewTrailingDotPolicyFactory(option)}, pipeline.Options{HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			sent = request.Header
+			return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusCreated, Header: http.Header{}}), nil
+		}
+	})})
+	_, err = p.Do(context.Background(), nil, request)
+	c.Assert(err, chk.IsNil)
+	return sent
+}
+
+func (s *trailingDotSuite) TestOnlyRequestsForTrailingDotNamesGetTheHeaders(c *chk.C) {
+	sent := sendThroughTrailingDotPolicy(c, common.ETrailingDotOption.Enable(), "https://acct.file.core.windows.net/share/dir./file.txt", "")
+	c.Assert(sent.Get("x-ms-version"), chk.Equals, trailingDotServiceVersion)
+	c.Assert(sent.Get("x-ms-allow-trailing-dot"), chk.Equals, "true")
+	c.Assert(sent.Get("x-ms-source-allow-trailing-dot"), chk.Equals, "")
+
+	// the rest keep the version they were going to be sent with
+	for _, rawURL := range []string{
+		"https://acct.file.core.windows.net/share/dir/file.txt",
+		"https://acct.file.core.windows.net/share?restype=share",
+		"https://acct.file.core.windows.net/?comp=list",
+	} {
+		sent = sendThroughTrailingDotPolicy(c, common.ETrailingDotOption.Enable(), rawURL, "")
+		c.Assert(sent.Get("x-ms-version"), chk.Equals, DefaultServiceApiVersion, chk.Commentf(rawURL))
+		c.Assert(sent.Get("x-ms-allow-trailing-dot"), chk.Equals, "", chk.Commentf(rawURL))
+	}
+}
+
+func (s *trailingDotSuite) TestCopySourceWithTrailingDotGetsTheSourceHeader(c *chk.C) {
+	sent := sendThroughTrailingDotPolicy(c, common.ETrailingDotOption.Enable(), "https://acct.file.core.windows.net/share/file.txt",
+		"https://other.file.core.windows.net/share/file.?sig=x")
+	c.Assert(sent.Get("x-ms-version"), chk.Equals, trailingDotServiceVersion)
+	c.Assert(sent.Get("x-ms-source-allow-trailing-dot"), chk.Equals, "true")
+	c.Assert(sent.Get("x-ms-allow-trailing-dot"), chk.Equals, "")
+
+	sent = sendThroughTrailingDotPolicy(c, common.ETrailingDotOption.Enable(), "https://acct.file.core.windows.net/share/file.txt",
+		"https://other.file.core.windows.net/share/file.txt?sig=x")
+	c.Assert(sent.Get("x-ms-version"), chk.Equals, DefaultServiceApiVersion)
+	c.Assert(sent.Get("x-ms-source-allow-trailing-dot"), chk.Equals, "")
+}
+
+func (s *trailingDotSuite) TestDisabledLeavesRequestsUntouched(c *chk.C) {
+	sent := sendThroughTrailingDotPolicy(c, common.ETrailingDotOption.Disable(), "https://acct.file.core.windows.net/share/dir./file.",
+		"https://other.file.core.windows.net/share/file.?sig=x")
+	c.Assert(sent.Get("x-ms-version"), chk.Equals, DefaultServiceApiVersion)
+	c.Assert(sent.Get("x-ms-allow-trailing-dot"), chk.Equals, "")
+	c.Assert(sent.Get("x-ms-source-allow-trailing-dot"), chk.Equals, "")
+}
@@ -0,0 +1,92 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type transactionBudgetSuite struct{}
+
+var _ = chk.Suite(&transactionBudgetSuite{})
+
+func (s *transactionBudgetSuite) TestNoCap(c *chk.C) {
+	b := newTransactionBudget(0)
+	c.Assert(b, chk.IsNil)
+	for i := 0; i < 100; i++ {
+		c.Assert(b.take(), chk.Equals, true)
+	}
+	c.Assert(b.exhausted(), chk.Equals, false)
+	c.Assert(b.issued(), chk.Equals, int64(0))
+	c.Assert(b.limit(), chk.Equals, int64(0))
+}
+
+func (s *transactionBudgetSuite) TestCap(c *chk.C) {
+	b := newTransactionBudget(3)
+	for i := 0; i < 3; i++ {
+		c.Assert(b.exhausted(), chk.Equals, false)
+		c.Assert(b.take(), chk.Equals, true)
+	}
+	c.Assert(b.exhausted(), chk.Equals, true)
+
+	// refused transactions aren't counted as issued
+	c.Assert(b.take(), chk.Equals, false)
+	c.Assert(b.take(), chk.Equals, false)
+	c.Assert(b.issued(), chk.Equals, int64(3))
+	c.Assert(b.limit(), chk.Equals, int64(3))
+}
+
+func (s *transactionBudgetSuite) TestPolicyStopsSendingOnceExhausted(c *chk.C) {
+	sent := 0
+	p := pipeline.NewPipeline([]pipeline.Factory{newTransactionBudgetPolicyFactory(), pipeline.MethodFactoryMarker()}, pipeline.Options{HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			sent++
+			return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}), nil
+		}
+	})})
+	u, err := url.Parse("https://account.blob.core.windows.net/container/blob")
+	c.Assert(err, chk.IsNil)
+	do := func(ctx context.Context) error {
+		request, err := pipeline.NewRequest(http.MethodHead, *u, nil)
+		c.Assert(err, chk.IsNil)
+		_, err = p.Do(ctx, nil, request)
+		return err
+	}
+
+	b := newTransactionBudget(2)
+	ctx := withTransactionBudget(context.Background(), b)
+	c.Assert(do(ctx), chk.IsNil)
+	c.Assert(do(ctx), chk.IsNil)
+	c.Assert(do(ctx), chk.Equals, errTransactionsExhausted)
+	c.Assert(sent, chk.Equals, 2)
+
+	// requests whose context has no budget, such as those made while scanning, aren't held back
+	c.Assert(do(context.Background()), chk.IsNil)
+	c.Assert(sent, chk.Equals, 3)
+	c.Assert(b.issued(), chk.Equals, int64(2))
+}
@@ -0,0 +1,182 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type transactionalSuite struct{}
+
+var _ = chk.Suite(&transactionalSuite{})
+
+// rollBackTransfer is a transfer of a transactional upload, as it is once the job is done
+type rollBackTransfer struct {
+	dst     string
+	created bool
+	status  common.TransferStatus
+}
+
+// mapTransactionalPlan writes a plan for a transactional upload to the given destination root, with the given transfers, and maps it
+func mapTransactionalPlan(c *chk.C, dstRoot string, transfers []rollBackTransfer) *JobPartPlanMMF {
+	header := JobPartPlanHeader{Version: DataSchemaVersion, NumTransfers: uint32(len(transfers)), Transactional: true,
+		DestinationRootLength: uint16(len(dstRoot))}
+	copy(header.DestinationRoot[:], dstRoot)
+	plan := append([]byte{}, (*[unsafe.Sizeof(JobPartPlanHeader{})]byte)(unsafe.Pointer(&header))[:]...)
+
+	stringOffset := int64(len(plan)) + int64(len(transfers))*int64(unsafe.Sizeof(JobPartPlanTransfer{}))
+	for _, t := range transfers {
+		transfer := JobPartPlanTransfer{SrcOffset: stringOffset, DstLength: int16(len(t.dst)), DstCreated: t.created}
+		plan = append(plan, (*[unsafe.Sizeof(JobPartPlanTransfer{})]byte)(unsafe.Pointer(&transfer))[:]...)
+		stringOffset += int64(len(t.dst))
+	}
+	for _, t := range transfers {
+		plan = append(plan, t.dst...)
+	}
+
+	file, err := ioutil.TempFile("", "transactional")
+	c.Assert(err, chk.IsNil)
+	defer file.Close()
+	_, err = file.Write(plan)
+	c.Assert(err, chk.IsNil)
+	mmf, err := common.NewMMF(file, true, 0, int64(len(plan)))
+	c.Assert(err, chk.IsNil)
+	c.Assert(os.Remove(file.Name()), chk.IsNil)
+
+	planMMF := (*JobPartPlanMMF)(mmf)
+	for i, t := range transfers {
+		planMMF.Plan().Transfer(uint32(i)).SetTransferStatus(t.status, true)
+	}
+	return planMMF
+}
+
+func (s *transactionalSuite) TestRollBackDeletesOnlyTheBlobsTheJobCreated(c *chk.C) {
+	// records the blobs deleted, and refuses to delete the one named "locked"
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, chk.Equals, http.MethodDelete)
+		if r.URL.Path == "/container/locked" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		deleted = append(deleted, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	transfers := []rollBackTransfer{
+		{dst: "/created", created: true, status: common.ETransferStatus.Success()},
+		{dst: "/overwritten", created: false, status: common.ETransferStatus.Success()},
+		{dst: "/failed", created: true, status: common.ETransferStatus.Failed()},
+		{dst: "/locked", created: true, status: common.ETransferStatus.Success()},
+	}
+	planMMF := mapTransactionalPlan(c, server.URL+"/container", transfers)
+	defer planMMF.Unmap()
+
+	jm := newLookaheadTestJobMgr(context.Background())
+	jm.jobPartMgrs = newJobPartToJobPartMgr()
+	jm.jobPartMgrs.Set(0, &jobPartMgr{jobMgr: jm, planMMF: planMMF, pipeline: azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{})})
+
+	// the blob of the failed transfer is deleted too, since the transfer may have failed after creating it,
+	// but only the transfer that had succeeded is newly counted as failed
+	c.Assert(jm.rollBack(), chk.Equals, 1)
+	sort.Strings(deleted)
+	c.Assert(deleted, chk.DeepEquals, []string{"/container/created", "/container/failed"})
+
+	// only the successful transfer whose blob was deleted is counted as failed from then on
+	plan := planMMF.Plan()
+	c.Assert(plan.Transfer(0).TransferStatus(), chk.Equals, common.ETransferStatus.Failed())
+	c.Assert(plan.Transfer(1).TransferStatus(), chk.Equals, common.ETransferStatus.Success())
+	c.Assert(plan.Transfer(2).TransferStatus(), chk.Equals, common.ETransferStatus.Failed())
+	c.Assert(plan.Transfer(3).TransferStatus(), chk.Equals, common.ETransferStatus.Success())
+}
+
+func (s *transactionalSuite) TestRollBackDeletesInParallel(c *chk.C) {
+	// each deletion waits until all of them have been asked for, which only happens if they are made at once
+	const numBlobs = 4
+	var mu sync.Mutex
+	waiting := 0
+	allWaiting := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		waiting++
+		if waiting == numBlobs {
+			close(allWaiting)
+		}
+		mu.Unlock()
+		select {
+		case <-allWaiting:
+			w.WriteHeader(http.StatusAccepted)
+		case <-time.After(10 * time.Second):
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	transfers := make([]rollBackTransfer, numBlobs)
+	for i := range transfers {
+		transfers[i] = rollBackTransfer{dst: fmt.Sprintf("/blob%d", i), created: true, status: common.ETransferStatus.Success()}
+	}
+	planMMF := mapTransactionalPlan(c, server.URL+"/container", transfers)
+	defer planMMF.Unmap()
+
+	jm := newLookaheadTestJobMgr(context.Background())
+	jm.jobPartMgrs = newJobPartToJobPartMgr()
+	jm.jobPartMgrs.Set(0, &jobPartMgr{jobMgr: jm, planMMF: planMMF, pipeline: azblob.NewPipeline(azblob.NewAnonymousCredential(),
+		azblob.PipelineOptions{Retry: azblob.RetryOptions{MaxTries: 1}})})
+
+	c.Assert(jm.rollBack(), chk.Equals, numBlobs)
+}
+
+func (s *transactionalSuite) TestRollBackOnlyPastTheFailureThreshold(c *chk.C) {
+	plan := &JobPartPlanHeader{Transactional: true, TransactionalFailureThreshold: 2, atomicJobStatus: common.EJobStatus.InProgress()}
+	c.Assert(needsRollBack(plan, jobPartProgressInfo{transfersCompleted: 5, transfersFailed: 2}), chk.Equals, false)
+	c.Assert(needsRollBack(plan, jobPartProgressInfo{transfersCompleted: 5, transfersFailed: 3}), chk.Equals, true)
+
+	// by default, any failure is too many
+	plan.TransactionalFailureThreshold = 0
+	c.Assert(needsRollBack(plan, jobPartProgressInfo{transfersCompleted: 5, transfersFailed: 1}), chk.Equals, true)
+	c.Assert(needsRollBack(plan, jobPartProgressInfo{transfersCompleted: 5}), chk.Equals, false)
+
+	// a cancelled job, or one that isn't transactional, is left as it is
+	plan.SetJobStatus(common.EJobStatus.Cancelling())
+	c.Assert(needsRollBack(plan, jobPartProgressInfo{transfersFailed: 1}), chk.Equals, false)
+	plan.SetJobStatus(common.EJobStatus.InProgress())
+	plan.Transactional = false
+	c.Assert(needsRollBack(plan, jobPartProgressInfo{transfersFailed: 1}), chk.Equals, false)
+}
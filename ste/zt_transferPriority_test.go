@@ -0,0 +1,62 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package ste
+
+import (
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/common"
+)
+
+type transferPrioritySuite struct{}
+
+var _ = chk.Suite(&transferPrioritySuite{})
+
+func (s *transferPrioritySuite) TestHighPriorityTransferOverridesJobPartPriority(c *chk.C) {
+	jpm := &jobPartMgr{priority: common.EJobPriority.Low()}
+
+	high := &jobPartTransferMgr{jobPartPlanTransfer: &JobPartPlanTransfer{Priority: common.EJobPriority.High()}}
+	c.Assert(jpm.transferPriority(high), chk.Equals, common.EJobPriority.High())
+
+	other := &jobPartTransferMgr{jobPartPlanTransfer: &JobPartPlanTransfer{}}
+	c.Assert(jpm.transferPriority(other), chk.Equals, common.EJobPriority.Low())
+}
+
+func (s *transferPrioritySuite) TestHighPriorityChunksRunFirst(c *chk.C) {
+	ja := &jobsAdmin{
+		chunkFairness:    common.EChunkFairness.RoundRobin(),
+		highFairChunks:   newFairChunkQueue(10),
+		normalFairChunks: newFairChunkQueue(10),
+		lowFairChunks:    newFairChunkQueue(10),
+	}
+	var ran []string
+	chunk := func(name string) chunkFunc {
+		return func(int) { ran = append(ran, name) }
+	}
+
+	// the high priority chunk is scheduled last, but runs first
+	ja.ScheduleChunk(common.EJobPriority.Low(), nil, chunk("low"))
+	ja.ScheduleChunk(common.EJobPriority.Normal(), nil, chunk("normal"))
+	ja.ScheduleChunk(common.EJobPriority.High(), nil, chunk("high"))
+	for i := 0; i < 3; i++ {
+		ja.processFairChunk(0)
+	}
+	c.Assert(ran, chk.DeepEquals, []string{"high", "normal", "low"})
+}